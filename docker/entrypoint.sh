@@ -1,14 +1,221 @@
 #!/bin/bash
 set -e
 
-# Install any custom CA certificates that have been mounted
-if [ -d "/usr/local/share/ca-certificates" ] && [ "$(ls -A /usr/local/share/ca-certificates 2>/dev/null)" ]; then
-    # Run update-ca-certificates to install mounted CA certs into the system trust store
-    # This makes them available to all applications (curl, wget, git, etc.) and subshells
-    if ! update-ca-certificates --fresh 2>&1; then
-        echo "Warning: update-ca-certificates failed, some CA certificates may not be available" >&2
+# Give the session uid a passwd entry when the image has none for it, so ssh,
+# git, and whoami work. Images built by 'enclaude build' already have one for
+# the host user; this covers other uids without writing to /etc.
+if ! getent passwd "$(id -u)" >/dev/null 2>&1; then
+    for lib in /usr/lib/*/libnss_wrapper.so /usr/lib/libnss_wrapper.so; do
+        [ -f "$lib" ] || continue
+        nss="$HOME/.nss"
+        if mkdir -p "$nss" 2>/dev/null; then
+            cp /etc/passwd "$nss/passwd"
+            cp /etc/group "$nss/group"
+            echo "enclaude:x:$(id -u):$(id -g):enclaude:$HOME:/bin/bash" >> "$nss/passwd"
+            getent group "$(id -g)" >/dev/null 2>&1 || echo "enclaude:x:$(id -g):" >> "$nss/group"
+            export LD_PRELOAD="$lib" NSS_WRAPPER_PASSWD="$nss/passwd" NSS_WRAPPER_GROUP="$nss/group"
+        fi
+        break
+    done
+fi
+
+# Replace host locales the image does not have with C.UTF-8, keeping UTF-8
+# text handling without a setlocale warning from every tool
+if available=$(locale -a 2>/dev/null); then
+    available=$(printf '%s\n' "$available" | tr '[:upper:]' '[:lower:]')
+    for var in $(compgen -e | grep -E '^(LANG|LC_[A-Z_]+)$'); do
+        want=$(printf '%s' "${!var}" | tr '[:upper:]' '[:lower:]' | sed 's/utf-8/utf8/')
+        if [ -n "$want" ] && ! printf '%s\n' "$available" | grep -qxF "$want"; then
+            export "$var=C.UTF-8"
+        fi
+    done
+fi
+
+# Trust mounted CA certificates. The system store is rebuilt when it is
+# writable (root with a writable root filesystem); in every case the system
+# roots and the mounted certificates are combined into $ENCLAUDE_CA_BUNDLE on a
+# tmpfs, and tools that take a bundle path (OpenSSL, Python requests and pip,
+# curl, git, Node) are pointed at it.
+if [ -n "$ENCLAUDE_CA_BUNDLE" ] && [ "$(ls -A /usr/local/share/ca-certificates 2>/dev/null)" ]; then
+    if [ -w /etc/ssl/certs ] && ! update-ca-certificates >/dev/null 2>&1; then
+        echo "Warning: update-ca-certificates failed; relying on $ENCLAUDE_CA_BUNDLE" >&2
+    fi
+    if { cat /etc/ssl/certs/ca-certificates.crt 2>/dev/null
+         for f in /usr/local/share/ca-certificates/*; do cat "$f" && echo; done; } > "$ENCLAUDE_CA_BUNDLE"; then
+        export SSL_CERT_FILE="$ENCLAUDE_CA_BUNDLE"
+        export REQUESTS_CA_BUNDLE="$ENCLAUDE_CA_BUNDLE"
+        export CURL_CA_BUNDLE="$ENCLAUDE_CA_BUNDLE"
+        export GIT_SSL_CAINFO="$ENCLAUDE_CA_BUNDLE"
+        export NODE_EXTRA_CA_CERTS="$ENCLAUDE_CA_BUNDLE"
+    else
+        echo "Warning: failed to write $ENCLAUDE_CA_BUNDLE; mounted CA certificates may not be trusted" >&2
+    fi
+fi
+
+# Revoke time-boxed credentials after ENCLAUDE_CREDENTIAL_TTL seconds
+# Staged credential files are zeroed in place and the BASH_ENV script is
+# rewritten to unset every variable it previously exported, so tools started
+# after expiry no longer see the tokens even though the session continues.
+if [ -n "$ENCLAUDE_CREDENTIAL_TTL" ] && [ -d "/run/enclaude/credentials" ]; then
+    (
+        sleep "$ENCLAUDE_CREDENTIAL_TTL"
+        creds=/run/enclaude/credentials
+        for f in "$creds"/*; do
+            [ -f "$f" ] && [ "$f" != "$creds/env.sh" ] && : > "$f"
+        done
+        sed -n 's/^export \([A-Za-z_][A-Za-z0-9_]*\)=.*/unset \1/p' "$creds/env.sh" > "$creds/env.sh.new"
+        mv "$creds/env.sh.new" "$creds/env.sh"
+    ) >/dev/null 2>&1 &
+fi
+
+# Use the login kept on the enclaude-managed volume (claude.session_dir:
+# volume, written by 'enclaude login') as ~/.claude
+if [ -n "$ENCLAUDE_CLAUDE_HOME" ] && [ -w "$ENCLAUDE_CLAUDE_HOME" ] && [ ! -e "$HOME/.claude" ]; then
+    ln -s "$ENCLAUDE_CLAUDE_HOME" "$HOME/.claude" || true
+fi
+
+# Keep Claude Code's caches on the shared cache volume. ~/.cache is moved
+# there by XDG_CACHE_HOME; feature-flag state lives in ~/.claude/statsig, so
+# link it when ~/.claude is not mounted from the host.
+if [ -n "$ENCLAUDE_CLAUDE_CACHE" ] && [ -w "$ENCLAUDE_CLAUDE_CACHE" ]; then
+    mkdir -p "$ENCLAUDE_CLAUDE_CACHE/xdg" "$ENCLAUDE_CLAUDE_CACHE/statsig"
+    if [ ! -e "$HOME/.claude" ]; then
+        mkdir -p "$HOME/.claude" && ln -s "$ENCLAUDE_CLAUDE_CACHE/statsig" "$HOME/.claude/statsig" || true
+    fi
+fi
+
+# Load the Nix development environment baked in by 'enclaude build --nix'.
+# The image's own PATH entries are kept after the environment's.
+if [ -f /etc/enclaude/nix-env.sh ]; then
+    image_path="$PATH"
+    . /etc/enclaude/nix-env.sh || echo "Warning: failed to load the Nix environment" >&2
+    export PATH="$PATH:$image_path"
+fi
+
+# Put wrappers for allowlisted host commands first on the PATH
+if [ -n "$ENCLAUDE_HOST_BIN" ] && [ -d "$ENCLAUDE_HOST_BIN" ]; then
+    export PATH="$ENCLAUDE_HOST_BIN:$PATH"
+fi
+
+# Put the access request helper on the PATH
+if [ -n "$ENCLAUDE_ACCESS_BIN" ] && [ -d "$ENCLAUDE_ACCESS_BIN" ]; then
+    export PATH="$PATH:$ENCLAUDE_ACCESS_BIN"
+fi
+
+# Relay Claude's API traffic to the host proxy that holds the API key, and
+# wait for the relay to listen so Claude's first request does not fail
+if [ -n "$ENCLAUDE_API_RELAY" ] && [ -f "$ENCLAUDE_API_RELAY" ]; then
+    node "$ENCLAUDE_API_RELAY" &
+    for _ in $(seq 50); do
+        (exec 3<>"/dev/tcp/127.0.0.1/$ENCLAUDE_API_PORT") 2>/dev/null && break
+        sleep 0.1
+    done
+fi
+
+# Listen on the forwarded host ports at host.enclaude.internal, and wait for
+# the relay so the first connection does not fail
+if [ -n "$ENCLAUDE_HOSTPORT_RELAY" ] && [ -f "$ENCLAUDE_HOSTPORT_RELAY" ]; then
+    node "$ENCLAUDE_HOSTPORT_RELAY" &
+    first_port=${ENCLAUDE_HOSTPORTS%% *}
+    for _ in $(seq 50); do
+        (exec 3<>"/dev/tcp/$ENCLAUDE_HOSTPORT_ADDR/$first_port") 2>/dev/null && break
+        sleep 0.1
+    done
+fi
+
+# Refuse connections to blocked domains and their subdomains through a
+# loopback HTTPS proxy, chained to any proxy the session already uses, and
+# wait for it to listen before making it the session's proxy
+if [ -n "$ENCLAUDE_BLOCK_PROXY" ] && [ -f "$ENCLAUDE_BLOCK_PROXY" ]; then
+    ENCLAUDE_UPSTREAM_PROXY="${HTTPS_PROXY:-$https_proxy}" node "$ENCLAUDE_BLOCK_PROXY" &
+    for _ in $(seq 50); do
+        (exec 3<>"/dev/tcp/127.0.0.1/$ENCLAUDE_BLOCK_PORT") 2>/dev/null && break
+        sleep 0.1
+    done
+    block_proxy="http://127.0.0.1:$ENCLAUDE_BLOCK_PORT"
+    no_proxy_hosts="${NO_PROXY:-$no_proxy}"
+    no_proxy_hosts="${no_proxy_hosts:+$no_proxy_hosts,}localhost,127.0.0.1,host.enclaude.internal"
+    export HTTPS_PROXY="$block_proxy" https_proxy="$block_proxy" NO_PROXY="$no_proxy_hosts" no_proxy="$no_proxy_hosts"
+fi
+
+# Mirror the host workspace into the workspace volume for --fast-fs.
+# enclaude copies the session's changes back once it ends and then removes
+# the marker. The marker lists every path mirrored, so only files the session
+# removed are deleted from the host; it is removed first, so a volume whose
+# mirror failed is never copied back. A marker still there with changes
+# after it means an earlier session's changes never reached the host, and
+# mirroring would delete them.
+if [ -n "$ENCLAUDE_FASTFS_HOST" ] && [ -d "$ENCLAUDE_FASTFS_HOST" ]; then
+    if ! command -v rsync >/dev/null 2>&1; then
+        echo "Error: --fast-fs needs rsync, which this image does not have" >&2
+        exit 1
+    fi
+    marker="$ENCLAUDE_FASTFS_WORKSPACE/$ENCLAUDE_FASTFS_MARKER"
+    if [ -e "$marker" ] && (
+        cd "$ENCLAUDE_FASTFS_WORKSPACE" || exit 1
+        [ -n "$(find . -mindepth 1 ! -type d ! -path "./$ENCLAUDE_FASTFS_MARKER" -newer "$ENCLAUDE_FASTFS_MARKER" -print -quit)" ] && exit 0
+        while IFS= read -r -d '' f; do
+            [ -e "$f" ] || [ -L "$f" ] || exit 0
+        done < "$ENCLAUDE_FASTFS_MARKER"
+        exit 1
+    ); then
+        echo "Error: the workspace volume holds changes from an earlier session that were not copied back to the host" >&2
+        exit 1
+    fi
+    rm -f "$marker"
+    if ! rsync -a --delete --exclude="/$ENCLAUDE_FASTFS_MARKER" "$ENCLAUDE_FASTFS_HOST/" "$ENCLAUDE_FASTFS_WORKSPACE/"; then
+        echo "Error: failed to mirror the workspace into its volume" >&2
+        exit 1
+    fi
+    if ! (cd "$ENCLAUDE_FASTFS_WORKSPACE" && find . -mindepth 1 ! -path "./$ENCLAUDE_FASTFS_MARKER" ! -path "./$ENCLAUDE_FASTFS_MARKER.tmp" -print0) > "$marker.tmp" \
+        || ! mv "$marker.tmp" "$marker"; then
+        echo "Error: failed to record the mirrored workspace" >&2
+        exit 1
     fi
 fi
 
+# Send stderr to a separate file when the host requested split output
+if [ -n "$ENCLAUDE_STDERR_FILE" ]; then
+    exec 2>>"$ENCLAUDE_STDERR_FILE"
+fi
+
+# Run Claude Code from a mounted npm pack (claude.binary) instead of the
+# image's; if it cannot be installed, fall back to the image's
+CLAUDE_BIN=/usr/local/bin/claude
+if [ -n "$ENCLAUDE_CLAUDE_PACK" ] && [ -f "$ENCLAUDE_CLAUDE_PACK" ]; then
+    if npm install --global --prefix "$HOME/.enclaude-claude" --no-audit --no-fund --loglevel=error "$ENCLAUDE_CLAUDE_PACK" >&2 \
+        && [ -x "$HOME/.enclaude-claude/bin/claude" ]; then
+        CLAUDE_BIN="$HOME/.enclaude-claude/bin/claude"
+    else
+        echo "Warning: failed to install $ENCLAUDE_CLAUDE_PACK; using the image's Claude Code" >&2
+    fi
+fi
+
+# With container.interaction exec, enclaude starts claude with docker exec
+# instead of attaching to this process. Save the environment set up above in
+# a script that starts it, written whole before it is looked for.
+if [ "$ENCLAUDE_INTERACTION" = exec ]; then
+    {
+        export -p
+        echo 'echo $$ > "$HOME/.enclaude-session.pid"'
+        [ -n "$ENCLAUDE_STDERR_FILE" ] && echo 'exec 2>>"$ENCLAUDE_STDERR_FILE"'
+        printf 'exec %q "$@"\n' "$CLAUDE_BIN"
+    } > "$HOME/.enclaude-session.tmp"
+    mv "$HOME/.enclaude-session.tmp" "$HOME/.enclaude-session"
+fi
+
+# Tell enclaude setup is done, so it starts passing the terminal through
+: > "$HOME/.enclaude-ready" 2>/dev/null || true
+
+# Keep the container running for the exec'd session; enclaude removes it
+# when claude exits
+if [ "$ENCLAUDE_INTERACTION" = exec ]; then
+    trap 'exit 0' TERM
+    while :; do
+        sleep 3600 &
+        wait $! || true
+    done
+fi
+
 # Execute the main command (claude)
-exec /usr/local/bin/claude "$@"
+exec "$CLAUDE_BIN" "$@"
@@ -0,0 +1,369 @@
+// Package access lets the container ask for host paths that were not
+// mounted. Each request is shown to the user, and approved paths are copied
+// into a read-only directory the container already has mounted, since Docker
+// cannot add bind mounts to a running container.
+package access
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/jakenelson/enclaude/internal/bridge"
+	"github.com/jakenelson/enclaude/internal/container"
+	"github.com/jakenelson/enclaude/internal/security"
+	"github.com/jakenelson/enclaude/internal/terminal"
+	"github.com/jakenelson/enclaude/internal/workspace"
+)
+
+// ContainerDir is where the shim and socket appear in the container
+const ContainerDir = "/run/enclaude/access"
+
+// GrantsDir is where approved host paths appear in the container, under
+// their host path, e.g. /mnt/host/home/me/src/other-repo
+const GrantsDir = "/mnt/host"
+
+// shim is the in-container client that sends a request to the bridge
+//
+//go:embed enclaude-access.js
+var shim []byte
+
+// request is sent by the shim with the path as given and the directory it
+// was run from, both container paths
+type request struct {
+	Path   string `json:"path"`
+	Cwd    string `json:"cwd"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// response returns where the approved path can be read in the container,
+// or why it was not granted
+type response struct {
+	Path  string `json:"path,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// AuditFunc is called after every request with the host path, if it could
+// be resolved, whether it was granted, and any error, including denials
+type AuditFunc func(hostPath string, granted bool, err error)
+
+// Bridge listens on a Unix socket for access requests from the container
+// and asks the user about each through the session
+type Bridge struct {
+	workDir   string // Container workspace path
+	hostDir   string // Host directory mounted there
+	audit     AuditFunc
+	dir       *bridge.Dir // Shim and socket
+	grants    string      // Approved copies, mounted read-only at GrantsDir
+	approvals chan container.Approval
+
+	mu      sync.Mutex
+	granted map[string]bool // Host paths already copied
+}
+
+// Start creates the bridge and grants directories and starts serving
+// requests. Relative paths in requests are resolved against workDir, the
+// container path of the workspace mounted from hostDir. The grants directory
+// is separate from the bridge directory, which the container can write to,
+// so the session cannot plant symlinks where approved copies are written.
+func Start(workDir, hostDir string, audit AuditFunc) (*Bridge, error) {
+	grants, err := os.MkdirTemp("", "enclaude-access-grants-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create access grants directory: %w", err)
+	}
+	if err := os.Chmod(grants, 0755); err != nil {
+		os.RemoveAll(grants)
+		return nil, fmt.Errorf("failed to create access grants directory: %w", err)
+	}
+	dir, err := bridge.New("enclaude-access-", "access bridge", ContainerDir, false)
+	if err != nil {
+		os.RemoveAll(grants)
+		return nil, err
+	}
+
+	b := &Bridge{
+		workDir:   workDir,
+		hostDir:   hostDir,
+		audit:     audit,
+		dir:       dir,
+		grants:    grants,
+		approvals: make(chan container.Approval),
+		granted:   make(map[string]bool),
+	}
+	if err := dir.WriteExecutable("bin/enclaude-access", shim); err != nil {
+		b.Close()
+		return nil, err
+	}
+	if err := dir.Serve("access.sock", b.handle); err != nil {
+		b.Close()
+		return nil, err
+	}
+	return b, nil
+}
+
+// Mounts returns the bridge directory and the read-only grants directory
+func (b *Bridge) Mounts() []container.Mount {
+	return []container.Mount{
+		b.dir.Mount(),
+		{Source: b.grants, Target: GrantsDir, ReadOnly: true, Kind: container.MountDir},
+	}
+}
+
+// Env returns the environment that locates the socket and the shim
+// directory, which the image entrypoint puts on the PATH
+func (b *Bridge) Env() map[string]string {
+	return map[string]string{
+		"ENCLAUDE_ACCESS_SOCKET": ContainerDir + "/access.sock",
+		"ENCLAUDE_ACCESS_BIN":    ContainerDir + "/bin",
+	}
+}
+
+// Approvals returns the requests awaiting the user's decision, for
+// RunOptions.Approvals
+func (b *Bridge) Approvals() <-chan container.Approval {
+	return b.approvals
+}
+
+// Close stops the bridge, denying requests still waiting for a decision,
+// and removes its directory and the copies
+func (b *Bridge) Close() {
+	b.dir.Close()
+	close(b.approvals)
+	os.RemoveAll(b.grants)
+}
+
+func (b *Bridge) handle(conn net.Conn, done <-chan struct{}) {
+	var req request
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		json.NewEncoder(conn).Encode(response{Error: "invalid request"})
+		return
+	}
+
+	hostPath, target, err := b.grant(req, done)
+	if b.audit != nil {
+		b.audit(hostPath, err == nil, err)
+	}
+	resp := response{Path: target}
+	if err != nil {
+		resp.Error = err.Error()
+	}
+	json.NewEncoder(conn).Encode(resp)
+}
+
+// grant resolves the requested path on the host, asks the user, and copies
+// it into the grants directory, returning the host path and where the copy
+// is in the container. Paths already granted are not asked about again.
+func (b *Bridge) grant(req request, done <-chan struct{}) (string, string, error) {
+	hostPath, err := b.hostPath(req)
+	if err != nil {
+		return "", "", err
+	}
+	if b.inWorkspace(hostPath) {
+		return hostPath, "", fmt.Errorf("%s is in the workspace, which is already mounted", hostPath)
+	}
+	if err := checkPath(hostPath); err != nil {
+		return hostPath, "", err
+	}
+	target := path.Join(GrantsDir, filepath.ToSlash(hostPath))
+
+	if b.covered(hostPath) {
+		return hostPath, target, nil
+	}
+
+	// The reason is the session's text, shown on the user's terminal
+	prompt := fmt.Sprintf("session requests read-only access to %s", hostPath)
+	if reason := terminal.Printable(req.Reason); reason != "" {
+		prompt += fmt.Sprintf(" (%s)", reason)
+	}
+	decision := make(chan bool, 1)
+	approval := container.Approval{Prompt: prompt, Decide: func(allow bool) {
+		select {
+		case decision <- allow:
+		default:
+		}
+	}}
+	select {
+	case b.approvals <- approval:
+	case <-done:
+		return hostPath, "", fmt.Errorf("session ended")
+	}
+	select {
+	case allow := <-decision:
+		if !allow {
+			return hostPath, "", fmt.Errorf("access to %s denied", hostPath)
+		}
+	case <-done:
+		return hostPath, "", fmt.Errorf("session ended")
+	}
+
+	if err := copyGrant(hostPath, b.grants); err != nil {
+		return hostPath, "", fmt.Errorf("failed to copy %s: %w", hostPath, err)
+	}
+	b.mu.Lock()
+	b.granted[hostPath] = true
+	b.mu.Unlock()
+	return hostPath, target, nil
+}
+
+// covered reports whether hostPath was already copied, by itself or as part
+// of a granted directory
+func (b *Bridge) covered(hostPath string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for granted := range b.granted {
+		if security.IsPathInDirectory(hostPath, granted) {
+			return true
+		}
+	}
+	return false
+}
+
+// hostPath maps a requested path to the host. Relative paths are resolved
+// through the workspace mount, so "../other-repo" is the workspace's sibling
+// on the host; "~/" is the host home, and absolute paths outside the
+// workspace are taken as host paths. Paths inside the workspace are refused
+// since the container can already read them.
+func (b *Bridge) hostPath(req request) (string, error) {
+	p := req.Path
+	if p == "" {
+		return "", fmt.Errorf("no path given")
+	}
+	if p == "~" || strings.HasPrefix(p, "~/") {
+		return security.ExpandPath(p)
+	}
+
+	if !path.IsAbs(p) {
+		if !path.IsAbs(req.Cwd) {
+			req.Cwd = b.workDir
+		}
+		p = path.Join(req.Cwd, p)
+	}
+	p = path.Clean(p)
+
+	if security.IsPathInDirectory(p, b.workDir) {
+		return "", fmt.Errorf("%s is in the workspace, which is already mounted", p)
+	}
+	if !path.IsAbs(req.Path) && security.IsPathInDirectory(req.Cwd, b.workDir) {
+		rel, err := filepath.Rel(b.workDir, p)
+		if err != nil {
+			return "", err
+		}
+		p = filepath.Join(b.hostDir, rel)
+	}
+	return security.ExpandPath(p)
+}
+
+// inWorkspace reports whether hostPath is the workspace's host directory or
+// inside it, as given or once symlinks are resolved. The session controls
+// the workspace, so a copy from it could be any content it chose.
+func (b *Bridge) inWorkspace(hostPath string) bool {
+	hostDirs := []string{b.hostDir}
+	if resolved, err := filepath.EvalSymlinks(b.hostDir); err == nil {
+		hostDirs = append(hostDirs, resolved)
+	}
+	paths := []string{hostPath}
+	if resolved, err := filepath.EvalSymlinks(hostPath); err == nil {
+		paths = append(paths, resolved)
+	}
+	for _, dir := range hostDirs {
+		for _, p := range paths {
+			if security.IsPathInDirectory(p, dir) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkPath refuses paths that may never be shared, without asking, and
+// those with control characters, which the prompt could not show as they are
+func checkPath(hostPath string) error {
+	if terminal.Printable(hostPath) != hostPath {
+		return fmt.Errorf("access to %q refused: the path contains control characters", hostPath)
+	}
+	if err := security.ValidateMountPathStrict(hostPath); err != nil {
+		return fmt.Errorf("access to %s refused: %w", hostPath, err)
+	}
+	if sensitive, ok := security.ContainedSensitivePath(hostPath); ok {
+		return fmt.Errorf("access to %s refused: it contains %s", hostPath, sensitive)
+	}
+	if _, err := os.Stat(hostPath); err != nil {
+		return fmt.Errorf("cannot access %s: %w", hostPath, err)
+	}
+	return nil
+}
+
+// copyGrant copies a host file or directory to its host path under root.
+// Directories are copied like a copy-mode workspace, skipping
+// .dockerignore/.gitignore matches. Nothing on the way to the copy may be a
+// symlink, and an earlier copy there is replaced rather than written
+// through, since a granted directory may hold symlinks of its own.
+func copyGrant(src, root string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	dst := filepath.Join(root, src)
+	if err := mkdirNoFollow(root, filepath.Dir(dst)); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(dst); err != nil {
+		return err
+	}
+	if info.IsDir() {
+		if err := os.Mkdir(dst, info.Mode().Perm()); err != nil {
+			return err
+		}
+		return workspace.Copy(src, dst, workspace.CopyOptions{})
+	}
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL|syscall.O_NOFOLLOW, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// mkdirNoFollow creates dir, which is inside root, and its missing parents,
+// refusing any part of it below root that is a symlink or not a directory
+func mkdirNoFollow(root, dir string) error {
+	rel, err := filepath.Rel(root, dir)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("%s is outside %s", dir, root)
+	}
+	current := root
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		if part == "." {
+			continue
+		}
+		current = filepath.Join(current, part)
+		info, err := os.Lstat(current)
+		switch {
+		case os.IsNotExist(err):
+			if err := os.Mkdir(current, 0755); err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		case info.Mode()&os.ModeSymlink != 0:
+			return fmt.Errorf("%s is a symlink", current)
+		case !info.IsDir():
+			return fmt.Errorf("%s is not a directory", current)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package access
+
+import (
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHostPath(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+	b := &Bridge{workDir: "/workspace", hostDir: "/home/me/src/app"}
+
+	tests := []struct {
+		path, cwd string
+		want      string
+		wantErr   bool
+	}{
+		{"../other-repo", "/workspace", "/home/me/src/other-repo", false},
+		{"../../lib", "/workspace/cmd", "/home/me/src/lib", false},
+		{"/opt/sdk", "/workspace", "/opt/sdk", false},
+		{"~/notes.md", "/workspace", filepath.Join(home, "notes.md"), false},
+		{"cmd", "/workspace", "", true},
+		{"/workspace/cmd", "/tmp", "", true},
+		{"", "/workspace", "", true},
+	}
+	for _, tt := range tests {
+		got, err := b.hostPath(request{Path: tt.path, Cwd: tt.cwd})
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("hostPath(%q from %q) = %q, %v, want %q (error: %v)", tt.path, tt.cwd, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestBridgeGrant(t *testing.T) {
+	hostRoot := t.TempDir()
+	workDir := filepath.Join(hostRoot, "app")
+	other := filepath.Join(hostRoot, "other")
+	for _, dir := range []string{workDir, filepath.Join(other, "pkg")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(other, "pkg", "lib.go"), []byte("package pkg\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(hostRoot, "secret.txt"), []byte("no\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var audited []bool
+	b, err := Start("/workspace", workDir, func(hostPath string, granted bool, err error) {
+		audited = append(audited, granted)
+	})
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer b.Close()
+
+	// Stand in for the session menu: allow other, deny everything else
+	var prompts []string
+	go func() {
+		for a := range b.Approvals() {
+			prompts = append(prompts, a.Prompt)
+			a.Decide(strings.Contains(a.Prompt, other))
+		}
+	}()
+
+	send := func(req request) response {
+		t.Helper()
+		conn, err := net.Dial("unix", filepath.Join(b.dir.Path(), "access.sock"))
+		if err != nil {
+			t.Fatalf("failed to connect to bridge: %v", err)
+		}
+		defer conn.Close()
+		if err := json.NewEncoder(conn).Encode(req); err != nil {
+			t.Fatalf("failed to send request: %v", err)
+		}
+		var resp response
+		if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+			t.Fatalf("failed to read response: %v", err)
+		}
+		return resp
+	}
+
+	resp := send(request{Path: "../other", Cwd: "/workspace", Reason: "shared types\x1b]0;title\x07"})
+	if resp.Error != "" || resp.Path != GrantsDir+filepath.ToSlash(other) {
+		t.Fatalf("granted response = %+v", resp)
+	}
+	data, err := os.ReadFile(filepath.Join(b.grants, other, "pkg", "lib.go"))
+	if err != nil || string(data) != "package pkg\n" {
+		t.Errorf("copy of granted directory = %q, %v", data, err)
+	}
+
+	// Inside a granted directory: no second prompt
+	resp = send(request{Path: "../other/pkg/lib.go", Cwd: "/workspace"})
+	if resp.Error != "" {
+		t.Errorf("covered response = %+v", resp)
+	}
+
+	resp = send(request{Path: "../secret.txt", Cwd: "/workspace"})
+	if !strings.Contains(resp.Error, "denied") {
+		t.Errorf("denied response error = %q", resp.Error)
+	}
+
+	// Control characters: refused without a prompt
+	resp = send(request{Path: "../secret\x1b[2J.txt", Cwd: "/workspace"})
+	if !strings.Contains(resp.Error, "control characters") {
+		t.Errorf("control character response error = %q", resp.Error)
+	}
+
+	if len(prompts) != 2 || !strings.Contains(prompts[0], "(shared types]0;title)") {
+		t.Errorf("prompts = %q, want two, the first with the reason without control characters", prompts)
+	}
+	if len(audited) != 4 || !audited[0] || !audited[1] || audited[2] || audited[3] {
+		t.Errorf("audited = %v, want granted, granted, denied, denied", audited)
+	}
+}
+
+func TestCopyGrantSymlinks(t *testing.T) {
+	hostRoot := t.TempDir()
+	src := filepath.Join(hostRoot, "other")
+	if err := os.MkdirAll(filepath.Join(src, "pkg"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(src, "pkg", "lib.go"), []byte("package pkg\n"), 0644)
+	os.WriteFile(filepath.Join(hostRoot, "notes.md"), []byte("notes\n"), 0644)
+	victim := t.TempDir()
+
+	// A symlink planted on the way to a copy is refused, not followed
+	root := t.TempDir()
+	if err := os.Symlink(victim, filepath.Join(root, strings.Split(hostRoot, string(filepath.Separator))[1])); err != nil {
+		t.Fatal(err)
+	}
+	if err := copyGrant(src, root); err == nil || !strings.Contains(err.Error(), "symlink") {
+		t.Errorf("copyGrant() through a planted symlink error = %v, want a symlink error", err)
+	}
+
+	// A symlink where the copy goes is replaced, not written through
+	root = t.TempDir()
+	for _, p := range []string{src, filepath.Join(hostRoot, "notes.md")} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(root, p)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(victim, filepath.Join(root, p)); err != nil {
+			t.Fatal(err)
+		}
+		if err := copyGrant(p, root); err != nil {
+			t.Errorf("copyGrant(%s) error = %v", p, err)
+		}
+		if info, err := os.Lstat(filepath.Join(root, p)); err != nil || info.Mode()&os.ModeSymlink != 0 {
+			t.Errorf("copy of %s: %v, %v; want the symlink replaced", p, info, err)
+		}
+	}
+	if entries, _ := os.ReadDir(victim); len(entries) != 0 {
+		t.Errorf("symlink target written to: %v", entries)
+	}
+}
+
+func TestBridgeGrantWorkspace(t *testing.T) {
+	hostDir := t.TempDir()
+	os.Mkdir(filepath.Join(hostDir, "cmd"), 0755)
+	link := filepath.Join(t.TempDir(), "link")
+	os.Symlink(hostDir, link)
+	b := &Bridge{workDir: "/workspace", hostDir: hostDir}
+
+	// The workspace's host path, given as a host path or through a symlink
+	for _, p := range []string{hostDir, filepath.Join(hostDir, "cmd"), link} {
+		if _, _, err := b.grant(request{Path: p, Cwd: "/workspace"}, nil); err == nil || !strings.Contains(err.Error(), "in the workspace") {
+			t.Errorf("grant(%s) error = %v, want it refused as in the workspace", p, err)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+#!/usr/bin/env node
+// enclaude-access: asks the user, through the enclaude session, for
+// read-only access to a host path that is not mounted. On approval the path
+// is copied into /mnt/host and its location there is printed.
+const net = require('net');
+const path = require('path');
+
+const [target, ...reason] = process.argv.slice(2);
+const socketPath = process.env.ENCLAUDE_ACCESS_SOCKET || path.join(__dirname, '..', 'access.sock');
+
+if (!target || target === '-h' || target === '--help') {
+  console.error('usage: enclaude-access <path> [reason...]');
+  console.error('Relative paths are resolved from the current directory, so ../other-repo');
+  console.error('is the workspace\'s sibling on the host. Blocks until the user decides.');
+  process.exit(2);
+}
+
+let response = '';
+const conn = net.createConnection(socketPath);
+conn.on('connect', () => {
+  console.error('enclaude-access: waiting for the user to approve...');
+  conn.end(JSON.stringify({ path: target, cwd: process.cwd(), reason: reason.join(' ') }) + '\n');
+});
+conn.on('data', (data) => {
+  response += data;
+});
+conn.on('error', (err) => {
+  console.error(`enclaude-access: access request bridge unavailable (${err.message})`);
+  process.exit(126);
+});
+conn.on('close', () => {
+  let msg;
+  try {
+    msg = JSON.parse(response);
+  } catch (err) {
+    console.error('enclaude-access: invalid response from access request bridge');
+    process.exit(126);
+  }
+  if (msg.error) {
+    console.error(`enclaude-access: ${msg.error}`);
+    process.exit(1);
+  }
+  // A read-only snapshot; later host changes are not reflected
+  console.log(msg.path);
+});
@@ -0,0 +1,153 @@
+// Package activity reads Claude Code's JSONL session transcript into a feed
+// of the tools Claude calls, the files it edits, and the commands it runs,
+// so a session can be followed from the host without its terminal.
+package activity
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/jakenelson/enclaude/internal/terminal"
+)
+
+// Event kinds
+const (
+	KindCommand = "command" // Bash
+	KindEdit    = "edit"    // Edit, MultiEdit, Write, NotebookEdit
+	KindTool    = "tool"    // Any other tool
+	KindFailed  = "failed"  // A tool call returned an error
+)
+
+// editTools change files
+var editTools = map[string]bool{"Edit": true, "MultiEdit": true, "Write": true, "NotebookEdit": true}
+
+// detailKeys are the tool input fields that best describe a call, in order
+// of preference
+var detailKeys = []string{"command", "file_path", "notebook_path", "url", "pattern", "query", "description", "prompt"}
+
+// Event is one entry of the activity feed
+type Event struct {
+	Time   time.Time `json:"time"`
+	Kind   string    `json:"kind"`
+	Tool   string    `json:"tool"`
+	Detail string    `json:"detail,omitempty"` // Command, file path, or pattern; the error for failed calls
+}
+
+// entry is the part of a transcript line the feed uses
+type entry struct {
+	Type      string    `json:"type"`
+	Timestamp time.Time `json:"timestamp"`
+	Message   struct {
+		Content json.RawMessage `json:"content"`
+	} `json:"message"`
+}
+
+// block is an element of a message's content
+type block struct {
+	Type      string                 `json:"type"`
+	ID        string                 `json:"id"`
+	Name      string                 `json:"name"`
+	Input     map[string]interface{} `json:"input"`
+	ToolUseID string                 `json:"tool_use_id"`
+	IsError   bool                   `json:"is_error"`
+	Content   json.RawMessage        `json:"content"`
+}
+
+// Parser turns transcript lines into events. It remembers tool call IDs so
+// a failed result can name its tool.
+type Parser struct {
+	tools map[string]string
+}
+
+// NewParser returns a parser for one transcript
+func NewParser() *Parser {
+	return &Parser{tools: make(map[string]string)}
+}
+
+// Parse returns the events in a transcript line. Lines that are not JSON or
+// hold no tool activity, such as text replies, yield none.
+func (p *Parser) Parse(line []byte) []Event {
+	var e entry
+	if err := json.Unmarshal(line, &e); err != nil {
+		return nil
+	}
+	var blocks []block
+	if err := json.Unmarshal(e.Message.Content, &blocks); err != nil {
+		// Plain text content
+		return nil
+	}
+
+	var events []Event
+	for _, b := range blocks {
+		switch {
+		case e.Type == "assistant" && b.Type == "tool_use":
+			p.tools[b.ID] = b.Name
+			events = append(events, Event{Time: e.Timestamp, Kind: toolKind(b.Name), Tool: b.Name, Detail: detail(b.Input)})
+		case e.Type == "user" && b.Type == "tool_result" && b.IsError:
+			events = append(events, Event{Time: e.Timestamp, Kind: KindFailed, Tool: p.tools[b.ToolUseID], Detail: resultText(b.Content)})
+		}
+	}
+	return events
+}
+
+func toolKind(name string) string {
+	switch {
+	case name == "Bash":
+		return KindCommand
+	case editTools[name]:
+		return KindEdit
+	}
+	return KindTool
+}
+
+// detail describes a tool call by its most telling input, on one line
+func detail(input map[string]interface{}) string {
+	for _, key := range detailKeys {
+		if s, ok := input[key].(string); ok && s != "" {
+			return oneLine(s)
+		}
+	}
+	return ""
+}
+
+// resultText returns the first line of a tool result, which is a string or
+// a list of text blocks
+func resultText(content json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(content, &s); err == nil {
+		return oneLine(s)
+	}
+	var blocks []struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(content, &blocks); err == nil {
+		for _, b := range blocks {
+			if b.Text != "" {
+				return oneLine(b.Text)
+			}
+		}
+	}
+	return ""
+}
+
+// oneLine keeps the first line of s, marking the rest as elided
+func oneLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i] + " …"
+	}
+	return s
+}
+
+// Print writes an event as a line of the feed. The session wrote the tool
+// name and detail, so control characters in them are dropped.
+func Print(w io.Writer, e Event) {
+	tool := terminal.Printable(e.Tool)
+	if e.Kind == KindFailed {
+		tool = "✗ " + tool
+	}
+	fmt.Fprintf(w, "%s  %-14s %s\n", e.Time.Local().Format("15:04:05"), tool, terminal.Printable(e.Detail))
+}
@@ -0,0 +1,60 @@
+package activity
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	p := NewParser()
+	lines := []string{
+		`{"type":"assistant","timestamp":"2025-06-01T10:00:00Z","message":{"content":[{"type":"text","text":"Running the tests"},{"type":"tool_use","id":"t1","name":"Bash","input":{"command":"go test ./...\ngo vet ./...","description":"Run tests"}}]}}`,
+		`{"type":"user","timestamp":"2025-06-01T10:00:05Z","message":{"content":[{"type":"tool_result","tool_use_id":"t1","is_error":true,"content":"FAIL pkg\nmore"}]}}`,
+		`{"type":"assistant","timestamp":"2025-06-01T10:00:09Z","message":{"content":[{"type":"tool_use","id":"t2","name":"Edit","input":{"file_path":"/workspace/main.go"}},{"type":"tool_use","id":"t3","name":"Grep","input":{"pattern":"TODO"}}]}}`,
+		`{"type":"user","timestamp":"2025-06-01T10:00:10Z","message":{"content":"please continue"}}`,
+		`{"type":"user","timestamp":"2025-06-01T10:00:11Z","message":{"content":[{"type":"tool_result","tool_use_id":"t2","content":[{"type":"text","text":"ok"}]}]}}`,
+		``,
+		`not json`,
+	}
+
+	var got []Event
+	for _, line := range lines {
+		got = append(got, p.Parse([]byte(line))...)
+	}
+
+	want := []Event{
+		{Kind: KindCommand, Tool: "Bash", Detail: "go test ./... …"},
+		{Kind: KindFailed, Tool: "Bash", Detail: "FAIL pkg …"},
+		{Kind: KindEdit, Tool: "Edit", Detail: "/workspace/main.go"},
+		{Kind: KindTool, Tool: "Grep", Detail: "TODO"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Parse() gave %d events, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		g := got[i]
+		g.Time = time.Time{}
+		if g != want[i] {
+			t.Errorf("event %d = %+v, want %+v", i, g, want[i])
+		}
+	}
+	if !got[0].Time.Equal(time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("event time = %v, want the transcript timestamp", got[0].Time)
+	}
+}
+
+func TestPrint(t *testing.T) {
+	var buf bytes.Buffer
+	Print(&buf, Event{Time: time.Now(), Kind: KindFailed, Tool: "Bash", Detail: "exit 1"})
+	if !strings.Contains(buf.String(), "✗ Bash") || !strings.HasSuffix(buf.String(), "exit 1\n") {
+		t.Errorf("Print() = %q", buf.String())
+	}
+
+	buf.Reset()
+	Print(&buf, Event{Time: time.Now(), Kind: KindCommand, Tool: "Bash\x1b[2J", Detail: "\x1b]0;title\x07ls"})
+	if strings.ContainsAny(buf.String(), "\x1b\x07") {
+		t.Errorf("Print() = %q, want control characters dropped", buf.String())
+	}
+}
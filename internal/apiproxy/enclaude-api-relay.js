@@ -0,0 +1,25 @@
+#!/usr/bin/env node
+// enclaude-api-relay: accepts Claude's API connections on a loopback port and
+// relays each one over a Unix socket to the enclaude proxy on the host, which
+// adds the API key the session never sees.
+const net = require('net');
+
+const socketPath = process.env.ENCLAUDE_API_SOCKET;
+const port = Number(process.env.ENCLAUDE_API_PORT);
+
+const server = net.createServer((client) => {
+  const upstream = net.createConnection(socketPath);
+  const close = () => {
+    client.destroy();
+    upstream.destroy();
+  };
+  client.on('error', close);
+  upstream.on('error', close);
+  client.pipe(upstream);
+  upstream.pipe(client);
+});
+server.on('error', (err) => {
+  console.error(`enclaude-api-relay: ${err.message}`);
+  process.exit(1);
+});
+server.listen(port, '127.0.0.1');
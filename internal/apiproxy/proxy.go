@@ -0,0 +1,137 @@
+// Package apiproxy keeps the Anthropic API key on the host. The session gets
+// a placeholder key and a base URL on a loopback port, where a relay in the
+// container passes each connection over a Unix socket to a proxy on the
+// host. The proxy replaces the placeholder with the real key on the way to
+// the API, so the key never exists inside the sandbox.
+package apiproxy
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/jakenelson/enclaude/internal/container"
+)
+
+// ContainerDir is where the proxy directory is mounted in the container
+const ContainerDir = "/run/enclaude/api"
+
+// RelayPort is the loopback port the relay listens on in the container
+const RelayPort = 41917
+
+// PlaceholderKey is the ANTHROPIC_API_KEY the session sees. It is worthless
+// outside the session: the proxy drops it for the real key.
+const PlaceholderKey = "sk-ant-REDACTED"
+
+// DefaultUpstream is the API the proxy forwards to
+const DefaultUpstream = "https://api.anthropic.com"
+
+// relay is the in-container process forwarding the loopback port to the
+// proxy socket
+//
+//go:embed enclaude-api-relay.js
+var relay []byte
+
+// Proxy serves the Anthropic API on a Unix socket, authenticating every
+// request with the host's key
+type Proxy struct {
+	dir      string
+	listener net.Listener
+	server   *http.Server
+	requests atomic.Int64
+}
+
+// upstreamTransport sends proxied requests; nil uses the default transport
+var upstreamTransport http.RoundTripper
+
+// Start creates the proxy directory holding the relay and the socket, and
+// starts forwarding requests to upstream with apiKey. The key only travels
+// over HTTPS.
+func Start(apiKey, upstream string) (*Proxy, error) {
+	if apiKey == "" {
+		return nil, fmt.Errorf("no API key to hold")
+	}
+	target, err := url.Parse(upstream)
+	if err != nil || target.Host == "" {
+		return nil, fmt.Errorf("invalid API URL %q", upstream)
+	}
+	if target.Scheme != "https" {
+		return nil, fmt.Errorf("API URL %q is not HTTPS; the key is only sent over HTTPS", upstream)
+	}
+
+	dir, err := os.MkdirTemp("", "enclaude-api-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create API proxy directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "enclaude-api-relay.js"), relay, 0755); err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("failed to write API relay: %w", err)
+	}
+	listener, err := net.Listen("unix", filepath.Join(dir, "api.sock"))
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("failed to listen on API proxy socket: %w", err)
+	}
+
+	p := &Proxy{dir: dir, listener: listener}
+	p.server = &http.Server{Handler: p.handler(apiKey, target)}
+	go p.server.Serve(listener)
+	return p, nil
+}
+
+// handler forwards every request to target with the session's credentials
+// replaced by apiKey. Responses are flushed as they arrive, so streamed
+// messages are not held back.
+func (p *Proxy) handler(apiKey string, target *url.URL) http.Handler {
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(target)
+			r.Out.Header.Del("Authorization")
+			r.Out.Header.Set("X-Api-Key", apiKey)
+		},
+		Transport:     upstreamTransport,
+		FlushInterval: -1,
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p.requests.Add(1)
+		proxy.ServeHTTP(w, r)
+	})
+}
+
+// Mount returns the mount exposing the relay and socket in the container
+func (p *Proxy) Mount() container.Mount {
+	return container.Mount{Source: p.dir, Target: ContainerDir, Kind: container.MountDir}
+}
+
+// Env returns the environment pointing Claude at the relay with the
+// placeholder key, and telling the image entrypoint to start the relay
+func (p *Proxy) Env() map[string]string {
+	return map[string]string{
+		"ANTHROPIC_API_KEY":   PlaceholderKey,
+		"ANTHROPIC_BASE_URL":  "http://127.0.0.1:" + strconv.Itoa(RelayPort),
+		"ENCLAUDE_API_RELAY":  ContainerDir + "/enclaude-api-relay.js",
+		"ENCLAUDE_API_SOCKET": ContainerDir + "/api.sock",
+		"ENCLAUDE_API_PORT":   strconv.Itoa(RelayPort),
+	}
+}
+
+// Requests returns how many requests the proxy has forwarded
+func (p *Proxy) Requests() int64 {
+	return p.requests.Load()
+}
+
+// Close stops the proxy and removes its directory
+func (p *Proxy) Close() {
+	if err := p.server.Close(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintf(os.Stderr, "Warning: failed to stop API proxy: %v\n", err)
+	}
+	os.RemoveAll(p.dir)
+}
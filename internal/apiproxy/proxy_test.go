@@ -0,0 +1,72 @@
+package apiproxy
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProxy(t *testing.T) {
+	var gotKey, gotAuth, gotPath string
+	upstream := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotKey, gotAuth, gotPath = r.Header.Get("X-Api-Key"), r.Header.Get("Authorization"), r.URL.Path
+		io.WriteString(w, "ok")
+	}))
+	defer upstream.Close()
+	upstreamTransport = upstream.Client().Transport
+	defer func() { upstreamTransport = nil }()
+
+	p, err := Start("sk-ant-real", upstream.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	socket := filepath.Join(p.dir, "api.sock")
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+		},
+	}}
+
+	req, _ := http.NewRequest("POST", p.Env()["ANTHROPIC_BASE_URL"]+"/v1/messages", strings.NewReader("{}"))
+	req.Header.Set("X-Api-Key", PlaceholderKey)
+	req.Header.Set("Authorization", "Bearer "+PlaceholderKey)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if string(body) != "ok" || gotPath != "/v1/messages" {
+		t.Errorf("response %q for path %q, want ok for /v1/messages", body, gotPath)
+	}
+	if gotKey != "sk-ant-real" || gotAuth != "" {
+		t.Errorf("upstream saw X-Api-Key %q and Authorization %q, want only the real key", gotKey, gotAuth)
+	}
+	if p.Requests() != 1 {
+		t.Errorf("Requests() = %d, want 1", p.Requests())
+	}
+
+	p.Close()
+	if _, err := os.Stat(p.dir); !os.IsNotExist(err) {
+		t.Error("proxy directory was not removed")
+	}
+}
+
+func TestStartInvalid(t *testing.T) {
+	if _, err := Start("", DefaultUpstream); err == nil {
+		t.Error("Start() without a key succeeded")
+	}
+	if _, err := Start("sk-ant-real", "api.anthropic.com"); err == nil {
+		t.Error("Start() with a URL without a scheme succeeded")
+	}
+	if _, err := Start("sk-ant-real", "http://gateway.example.com"); err == nil {
+		t.Error("Start() with a plain HTTP URL succeeded")
+	}
+}
@@ -0,0 +1,103 @@
+// Package bridge is the host side the in-container shims share: a private
+// directory holding a shim and a Unix socket, mounted into the container,
+// with each connection on the socket handled on the host until the session
+// ends.
+package bridge
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/jakenelson/enclaude/internal/container"
+)
+
+// HandlerFunc handles one connection. done is closed when the bridge is
+// closing, so a handler waiting on the user can give up.
+type HandlerFunc func(conn net.Conn, done <-chan struct{})
+
+// Dir is a bridge directory and the socket served in it. Bridges embed it
+// for their Mount and Close.
+type Dir struct {
+	what     string // Names the bridge in errors, e.g. "editor bridge"
+	path     string
+	target   string
+	readOnly bool
+	listener net.Listener
+	done     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// New creates a private temporary directory for a bridge, named with
+// prefix, e.g. "enclaude-editor-", to be mounted at target in the container
+func New(prefix, what, target string, readOnly bool) (*Dir, error) {
+	path, err := os.MkdirTemp("", prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s directory: %w", what, err)
+	}
+	return &Dir{what: what, path: path, target: target, readOnly: readOnly, done: make(chan struct{})}, nil
+}
+
+// Path returns the directory's host path
+func (d *Dir) Path() string {
+	return d.path
+}
+
+// WriteExecutable writes an executable at name, a slash-separated path in
+// the directory whose parent directories are created as needed
+func (d *Dir) WriteExecutable(name string, data []byte) error {
+	path := filepath.Join(d.path, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to write %s %s: %w", d.what, name, err)
+	}
+	if err := os.WriteFile(path, data, 0755); err != nil {
+		return fmt.Errorf("failed to write %s %s: %w", d.what, name, err)
+	}
+	return nil
+}
+
+// Serve listens on the socket called name in the directory and handles
+// each connection with handle, closing it afterwards
+func (d *Dir) Serve(name string, handle HandlerFunc) error {
+	listener, err := net.Listen("unix", filepath.Join(d.path, name))
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s socket: %w", d.what, err)
+	}
+	d.listener = listener
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			d.wg.Add(1)
+			go func() {
+				defer d.wg.Done()
+				defer conn.Close()
+				handle(conn, d.done)
+			}()
+		}
+	}()
+	return nil
+}
+
+// Mount returns the mount exposing the directory in the container
+func (d *Dir) Mount() container.Mount {
+	return container.Mount{Source: d.path, Target: d.target, ReadOnly: d.readOnly, Kind: container.MountDir}
+}
+
+// Close stops accepting connections, waits for those being handled, and
+// removes the directory
+func (d *Dir) Close() {
+	if d.listener != nil {
+		d.listener.Close()
+	}
+	close(d.done)
+	d.wg.Wait()
+	os.RemoveAll(d.path)
+}
@@ -0,0 +1,55 @@
+package bridge
+
+import (
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDir(t *testing.T) {
+	d, err := New("enclaude-bridge-test-", "test bridge", "/run/enclaude/test", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.WriteExecutable("bin/shim", []byte("#!/bin/sh\n")); err != nil {
+		t.Fatalf("WriteExecutable() error = %v", err)
+	}
+	if info, err := os.Stat(filepath.Join(d.Path(), "bin", "shim")); err != nil || info.Mode().Perm() != 0755 {
+		t.Errorf("shim = %v, %v; want an executable", info, err)
+	}
+	if m := d.Mount(); m.Source != d.Path() || m.Target != "/run/enclaude/test" || !m.ReadOnly {
+		t.Errorf("Mount() = %+v", m)
+	}
+
+	// A handler waiting on the user is told when the bridge closes
+	waiting := make(chan struct{})
+	if err := d.Serve("test.sock", func(conn net.Conn, done <-chan struct{}) {
+		close(waiting)
+		<-done
+		conn.Write([]byte("closing"))
+	}); err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+	conn, err := net.Dial("unix", filepath.Join(d.Path(), "test.sock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	select {
+	case <-waiting:
+	case <-time.After(5 * time.Second):
+		t.Fatal("connection was not handled")
+	}
+
+	d.Close()
+	reply, _ := io.ReadAll(conn)
+	if string(reply) != "closing" {
+		t.Errorf("reply = %q, want the handler to finish before Close returns", reply)
+	}
+	if _, err := os.Stat(d.Path()); !os.IsNotExist(err) {
+		t.Errorf("Close() left %s behind", d.Path())
+	}
+}
@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/jakenelson/enclaude/internal/activity"
+	"github.com/jakenelson/enclaude/internal/container"
+	"github.com/spf13/cobra"
+)
+
+var activityJSON bool
+
+func init() {
+	rootCmd.AddCommand(activityCmd)
+	activityCmd.Flags().BoolVar(&activityJSON, "json", false, "Print each event as a JSON object")
+}
+
+var activityCmd = &cobra.Command{
+	Use:   "activity [session]",
+	Short: "Follow the tools Claude calls in a running session",
+	Long: `Follow a running session's activity from the host: every tool Claude calls,
+the files it edits, and the commands it runs, with failed calls marked. The
+feed is read from Claude Code's session transcript in the container, so it
+works without the session's terminal and whether or not ~/.claude is mounted
+from the host. Press Ctrl+C to stop following without affecting the session.
+
+The feed starts from the beginning of the current conversation and switches
+to a new one when Claude starts it, for example after /clear.
+
+The session may be given as a container ID or name, chosen as for
+'enclaude watch'.
+
+Examples:
+  enclaude activity
+  enclaude activity 3f2a9c1b7d4e --json | jq 'select(.kind == "command")'`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runActivity,
+}
+
+func runActivity(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigCh
+		cancel()
+	}()
+
+	runner, err := container.NewRunner()
+	if err != nil {
+		return fmt.Errorf("failed to create container runner: %w", err)
+	}
+	defer runner.Close()
+
+	session, err := pickSession(ctx, runner, args, "activity")
+	if err != nil {
+		return err
+	}
+
+	if !activityJSON {
+		fmt.Fprintf(os.Stderr, "Following activity in session %s (Ctrl+C to stop)\n", shortID(session))
+	}
+	pr, pw := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		printActivity(pr, os.Stdout, activityJSON)
+	}()
+	err = runner.FollowTranscript(ctx, session, pw)
+	pw.Close()
+	<-done
+	return err
+}
+
+// printActivity prints the events in a transcript stream as they arrive
+func printActivity(r io.Reader, w io.Writer, asJSON bool) {
+	parser := activity.NewParser()
+	enc := json.NewEncoder(w)
+	// Transcript lines hold whole messages, including file contents
+	br := bufio.NewReaderSize(r, 1<<20)
+	for {
+		line, err := br.ReadBytes('\n')
+		for _, e := range parser.Parse(line) {
+			if asJSON {
+				enc.Encode(e)
+			} else {
+				activity.Print(w, e)
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
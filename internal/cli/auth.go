@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jakenelson/enclaude/internal/config"
+	"github.com/jakenelson/enclaude/internal/credentials"
+	"github.com/jakenelson/enclaude/internal/workspace"
+	"github.com/moby/term"
+)
+
+// resolveClaudeAuth returns the claude.auth mode for a session in workDir.
+// When auto would pass Claude both an API key and a session, the choice comes
+// from claude.prefer, then the choice remembered for the workspace, then a
+// one-time prompt whose answer is remembered. Without a terminal both are
+// passed as before, with a warning.
+func resolveClaudeAuth(workDir string) string {
+	if !credentials.AuthConflict(cfg) {
+		return cfg.Claude.Auth
+	}
+	if cfg.Claude.Prefer == config.AuthSession || cfg.Claude.Prefer == config.AuthAPIKey {
+		return cfg.Claude.Prefer
+	}
+
+	registry, err := workspace.LoadRegistry(registryPath())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Warning:", err)
+	} else if pin, _, ok := registry.Lookup(workDir); ok && pin.Auth != "" {
+		return pin.Auth
+	}
+
+	if !term.IsTerminal(os.Stdin.Fd()) {
+		fmt.Fprintln(os.Stderr, "Warning: both ANTHROPIC_API_KEY and a Claude session were found; Claude will use the API key. Set claude.prefer to choose.")
+		return cfg.Claude.Auth
+	}
+
+	resume := pausePull()
+	auth := promptClaudeAuth(bufio.NewReader(os.Stdin))
+	resume()
+	if registry != nil {
+		pin := registry.Workspaces[filepath.Clean(workDir)]
+		pin.Auth = auth
+		registry.Pin(workDir, pin)
+		if err := registry.Save(); err != nil {
+			fmt.Fprintln(os.Stderr, "Warning:", err)
+		} else {
+			fmt.Fprintf(os.Stderr, "Remembered for %s; change it with 'enclaude workspace pin --auth' or claude.prefer.\n", workDir)
+		}
+	}
+	return auth
+}
+
+// promptClaudeAuth asks which of the API key and the session to use
+func promptClaudeAuth(reader *bufio.Reader) string {
+	fmt.Fprintln(os.Stderr, "Both ANTHROPIC_API_KEY and a Claude session (~/.claude) were found.")
+	fmt.Fprintln(os.Stderr, "With both, Claude uses the API key and usage is billed to the API.")
+	fmt.Fprintln(os.Stderr, "  1) session  - Use the Claude session")
+	fmt.Fprintln(os.Stderr, "  2) api-key  - Use the API key")
+
+	for {
+		fmt.Fprintf(os.Stderr, "\nChoice [1-2] (default: session): ")
+		input, err := reader.ReadString('\n')
+		if err != nil {
+			return config.AuthSession
+		}
+		switch strings.TrimSpace(input) {
+		case "", "1":
+			return config.AuthSession
+		case "2":
+			return config.AuthAPIKey
+		default:
+			fmt.Fprintln(os.Stderr, "Invalid choice. Please enter 1 or 2.")
+		}
+	}
+}
@@ -7,28 +7,63 @@ import (
 	"path/filepath"
 
 	"github.com/jakenelson/enclaude/internal/container"
+	"github.com/jakenelson/enclaude/internal/nix"
+	"github.com/jakenelson/enclaude/internal/security"
+	"github.com/jakenelson/enclaude/internal/workspace"
+	"github.com/moby/term"
 	"github.com/spf13/cobra"
 )
 
 func init() {
 	rootCmd.AddCommand(buildCmd)
 
-	buildCmd.Flags().StringP("file", "f", "", "path to Dockerfile (default: built-in)")
+	buildCmd.Flags().StringP("file", "f", "", "path to Dockerfile or Containerfile (default: built-in)")
 	buildCmd.Flags().StringP("tag", "t", "enclaude:latest", "image tag")
 	buildCmd.Flags().String("context", "", "build context directory")
 	buildCmd.Flags().Bool("no-cache", false, "do not use cache when building")
 	buildCmd.Flags().String("platform", "", "target platform (e.g., linux/amd64,linux/arm64)")
+	buildCmd.Flags().StringArray("cache-from", nil, "import layer cache from a registry ref or local directory (uses buildx)")
+	buildCmd.Flags().StringArray("cache-to", nil, "export layer cache to a registry ref or local directory (uses buildx)")
+	buildCmd.Flags().Bool("attest", false, "attach SLSA provenance and SBOM attestations (uses buildx)")
+	buildCmd.Flags().Int("uid", -1, "uid of the image's enclaude user (default: your uid, or 1000 when pushing)")
+	buildCmd.Flags().Int("gid", -1, "gid of the image's enclaude user (default: your gid, or 1000 when pushing)")
+	buildCmd.Flags().Bool("push", false, "push the image to the registry named by --tag instead of loading it locally (uses buildx)")
+	buildCmd.Flags().String("registry", "", "registry repository to push to, e.g. ghcr.io/acme/enclaude; the tag of --tag is kept (implies --push)")
+	buildCmd.Flags().Bool("nix", false, "extend image.name with the Nix flake or devenv environment of the current directory and pin the result to it")
+	buildCmd.Flags().String("registry-user", "", "log in to the registry as this user, reading the password or token from $ENCLAUDE_REGISTRY_PASSWORD")
+	buildCmd.Flags().StringArray("secret", nil, "BuildKit secret, as id=name,src=path or id=name,env=VAR, kept out of image layers (repeatable, uses buildx)")
 }
 
 var buildCmd = &cobra.Command{
 	Use:   "build",
 	Short: "Build the enclaude Docker image",
 	Long: `Build the enclaude Docker image from the built-in Dockerfile or a custom one.
+A Containerfile is found like a Dockerfile, and .containerignore is honored.
+When the Docker API is served by Podman, builds with --secret run in the
+podman CLI, against the same service.
 
 Examples:
   enclaude build                        # Build with default settings
   enclaude build -t my-enclaude:v1      # Custom tag
-  enclaude build -f ./Dockerfile.custom # Use custom Dockerfile`,
+  enclaude build -f ./Dockerfile.custom # Use custom Dockerfile
+  enclaude build --uid 1000 --gid 1000  # Bake a specific uid into the enclaude user
+
+  # Realize this project's flake.nix or devenv environment in the image
+  enclaude build --nix
+
+  # Share layer caches through a registry or a local directory
+  enclaude build --cache-from ghcr.io/acme/enclaude:cache --cache-to ghcr.io/acme/enclaude:cache
+  enclaude build --cache-from ./.buildcache --cache-to ./.buildcache
+
+  # Publish to a team registry, printing the pushed digest
+  enclaude build --registry ghcr.io/acme/enclaude -t enclaude:v3
+
+  # Install private packages without baking the token into a layer
+  enclaude build -f Dockerfile.custom --secret id=npmrc,src=~/.npmrc
+
+  # Publish an image with provenance and SBOM attestations
+  enclaude build --attest --push -t ghcr.io/acme/enclaude:latest
+  enclaude inspect-image ghcr.io/acme/enclaude:latest`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx := context.Background()
 
@@ -37,47 +72,46 @@ Examples:
 		contextDir, _ := cmd.Flags().GetString("context")
 		noCache, _ := cmd.Flags().GetBool("no-cache")
 		platform, _ := cmd.Flags().GetString("platform")
+		cacheFrom, _ := cmd.Flags().GetStringArray("cache-from")
+		cacheTo, _ := cmd.Flags().GetStringArray("cache-to")
+		attest, _ := cmd.Flags().GetBool("attest")
+		push, _ := cmd.Flags().GetBool("push")
+		registry, _ := cmd.Flags().GetString("registry")
+		registryUser, _ := cmd.Flags().GetString("registry-user")
+		uid, _ := cmd.Flags().GetInt("uid")
+		gid, _ := cmd.Flags().GetInt("gid")
+		useNix, _ := cmd.Flags().GetBool("nix")
+		secretSpecs, _ := cmd.Flags().GetStringArray("secret")
 
-		// Use config values if flags not provided
-		if dockerfile == "" && cfg.Image.Dockerfile != "" {
-			dockerfile = cfg.Image.Dockerfile
-		}
-		if contextDir == "" && cfg.Image.BuildContext != "" {
-			contextDir = cfg.Image.BuildContext
+		secrets, err := buildSecrets(secretSpecs)
+		if err != nil {
+			return err
 		}
 
-		// If no dockerfile specified, look for built-in one
-		if dockerfile == "" {
-			// Check common locations
-			locations := []string{
-				"docker/Dockerfile",
-				"Dockerfile",
+		// A Nix image is generated from the workspace rather than a Dockerfile
+		var nixDir string
+		if useNix {
+			if dockerfile != "" {
+				return fmt.Errorf("--nix generates its own Dockerfile and cannot be combined with --file")
 			}
-
-			// Also check relative to executable
-			if execPath, err := os.Executable(); err == nil {
-				execDir := filepath.Dir(execPath)
-				locations = append([]string{
-					filepath.Join(execDir, "docker", "Dockerfile"),
-					filepath.Join(execDir, "..", "docker", "Dockerfile"),
-				}, locations...)
+			nixDir, err = os.Getwd()
+			if err != nil {
+				return fmt.Errorf("failed to get working directory: %w", err)
 			}
-
-			for _, loc := range locations {
-				if _, err := os.Stat(loc); err == nil {
-					dockerfile = loc
-					break
-				}
+			if !cmd.Flags().Changed("tag") {
+				tag = container.ProjectVolumeName("nix", nixDir) + ":latest"
 			}
-
-			if dockerfile == "" {
-				return fmt.Errorf("no Dockerfile found; use -f to specify one or run from the enclaude source directory")
+			var cleanup func()
+			dockerfile, contextDir, cleanup, err = prepareNixBuild(nixDir, cfg.Image.Name)
+			if err != nil {
+				return err
 			}
+			defer cleanup()
 		}
 
-		// Default context to Dockerfile directory
-		if contextDir == "" {
-			contextDir = filepath.Dir(dockerfile)
+		dockerfile, contextDir, err = resolveDockerfile(dockerfile, contextDir)
+		if err != nil {
+			return err
 		}
 
 		runner, err := container.NewRunner()
@@ -86,12 +120,46 @@ Examples:
 		}
 		defer runner.Close()
 
+		if registry != "" {
+			tag = container.RegistryTag(registry, tag)
+			push = true
+		}
+		if push && runner.IsPodman(ctx) {
+			return fmt.Errorf("--push and --registry are not supported with Podman; build, then push with 'podman push %s'", tag)
+		}
+		if push {
+			if err := ensureRegistryLogin(ctx, container.RegistryHost(tag), registryUser); err != nil {
+				return err
+			}
+		}
+
+		// Bake the host user into local images so files written to the
+		// workspace keep their owner; published images use the default
+		if uid < 0 {
+			uid = imageUserID(os.Getuid(), push)
+		}
+		if gid < 0 {
+			gid = imageUserID(os.Getgid(), push)
+		}
+
 		opts := container.BuildOptions{
 			Dockerfile: dockerfile,
 			ContextDir: contextDir,
 			Tag:        tag,
 			NoCache:    noCache,
 			Platform:   platform,
+			CacheFrom:  cacheFrom,
+			CacheTo:    cacheTo,
+			Attest:     attest,
+			Push:       push,
+			BuildArgs:  container.UserBuildArgs(uid, gid),
+			Secrets:    secrets,
+		}
+		if useNix {
+			// The enclaude user comes from the base image
+			opts.BuildArgs = nil
+		} else if opts.Labels, err = container.BuildLabels(dockerfile, contextDir); err != nil {
+			return err
 		}
 
 		fmt.Printf("Building image %s from %s...\n", tag, dockerfile)
@@ -99,7 +167,173 @@ Examples:
 			return fmt.Errorf("build failed: %w", err)
 		}
 
+		if push {
+			fmt.Printf("Successfully built and pushed %s\n", tag)
+			digest, err := runner.RemoteDigest(ctx, tag)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: could not read the pushed digest: %v\n", err)
+				return nil
+			}
+			fmt.Printf("Digest: %s\n", digest)
+			fmt.Printf("Pin with: %s@%s\n", container.Repository(tag), digest)
+			return nil
+		}
 		fmt.Printf("Successfully built %s\n", tag)
+		if useNix {
+			return pinWorkspaceImage(nixDir, tag)
+		}
 		return nil
 	},
 }
+
+// resolveDockerfile applies image.dockerfile and image.build_context to the
+// Dockerfile and context given on the command line, falling back to the
+// built-in Dockerfile and its directory, or a Containerfile in their place
+func resolveDockerfile(dockerfile, contextDir string) (string, string, error) {
+	// Use config values if flags not provided
+	if dockerfile == "" && cfg.Image.Dockerfile != "" {
+		dockerfile = cfg.Image.Dockerfile
+	}
+	if contextDir == "" && cfg.Image.BuildContext != "" {
+		contextDir = cfg.Image.BuildContext
+	}
+
+	// If no dockerfile specified, look for built-in one
+	if dockerfile == "" {
+		// Check common locations
+		dirs := []string{"docker", "."}
+
+		// Also check relative to executable
+		if execPath, err := os.Executable(); err == nil {
+			execDir := filepath.Dir(execPath)
+			dirs = append([]string{
+				filepath.Join(execDir, "docker"),
+				filepath.Join(execDir, "..", "docker"),
+			}, dirs...)
+		}
+
+	search:
+		for _, dir := range dirs {
+			for _, name := range []string{"Dockerfile", "Containerfile"} {
+				if loc := filepath.Join(dir, name); security.FileExists(loc) {
+					dockerfile = loc
+					break search
+				}
+			}
+		}
+
+		if dockerfile == "" {
+			return "", "", fmt.Errorf("no Dockerfile or Containerfile found; use -f to specify one or run from the enclaude source directory")
+		}
+	}
+
+	// Default context to Dockerfile directory
+	if contextDir == "" {
+		contextDir = filepath.Dir(dockerfile)
+	}
+	return dockerfile, contextDir, nil
+}
+
+// buildSecrets parses --secret values, resolving file sources on the host.
+// Missing sources fail here rather than partway through the build.
+func buildSecrets(specs []string) ([]container.BuildSecret, error) {
+	var secrets []container.BuildSecret
+	for _, spec := range specs {
+		secret, err := container.ParseBuildSecret(spec)
+		if err != nil {
+			return nil, err
+		}
+		if secret.Source != "" {
+			if secret.Source, err = security.ExpandPath(secret.Source); err != nil {
+				return nil, fmt.Errorf("invalid secret %s source: %w", secret.ID, err)
+			}
+			if !security.FileExists(secret.Source) {
+				return nil, fmt.Errorf("secret %s: %s is not a file", secret.ID, secret.Source)
+			}
+		} else if _, ok := os.LookupEnv(secret.Env); !ok {
+			return nil, fmt.Errorf("secret %s: $%s is not set", secret.ID, secret.Env)
+		}
+		secrets = append(secrets, secret)
+	}
+	return secrets, nil
+}
+
+// prepareNixBuild writes a build context for the Nix environment declared in
+// dir on top of base: a snapshot of the workspace, which flakes may refer to
+// anywhere, and the generated Dockerfile. The cleanup removes the context.
+func prepareNixBuild(dir, base string) (dockerfile, contextDir string, cleanup func(), err error) {
+	kind, ok := nix.Detect(dir)
+	if !ok {
+		return "", "", nil, fmt.Errorf("no flake.nix, devenv.nix or devenv.yaml in %s", dir)
+	}
+	content, err := nix.Dockerfile(base, kind)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	contextDir, err = os.MkdirTemp("", "enclaude-nix-")
+	if err != nil {
+		return "", "", nil, fmt.Errorf("failed to create build context: %w", err)
+	}
+	cleanup = func() { os.RemoveAll(contextDir) }
+	if err := workspace.Copy(dir, filepath.Join(contextDir, nix.SourceDir), workspace.CopyOptions{}); err != nil {
+		cleanup()
+		return "", "", nil, fmt.Errorf("failed to copy workspace: %w", err)
+	}
+	dockerfile = filepath.Join(contextDir, "Dockerfile")
+	if err := os.WriteFile(dockerfile, []byte(content), 0644); err != nil {
+		cleanup()
+		return "", "", nil, fmt.Errorf("failed to write Dockerfile: %w", err)
+	}
+	fmt.Printf("Realizing the Nix %s environment of %s on %s\n", kind, dir, base)
+	return dockerfile, contextDir, cleanup, nil
+}
+
+// pinWorkspaceImage pins image to the workspace at dir, keeping the rest of
+// an existing pin
+func pinWorkspaceImage(dir, image string) error {
+	registry, err := workspace.LoadRegistry(registryPath())
+	if err != nil {
+		return err
+	}
+	pin := registry.Workspaces[filepath.Clean(dir)]
+	pin.Image = image
+	registry.Pin(dir, pin)
+	if err := registry.Save(); err != nil {
+		return err
+	}
+	fmt.Printf("Pinned %s: %s\n", dir, describePin(pin))
+	return nil
+}
+
+// imageUserID returns the id to bake into the image for the host id. Root
+// would collide with the image's own root user, and images meant for others
+// should not carry this host's ids.
+func imageUserID(hostID int, push bool) int {
+	if push || hostID <= 0 {
+		return container.DefaultImageUID
+	}
+	return hostID
+}
+
+// ensureRegistryLogin makes sure the docker CLI can push to host. Existing
+// logins are used as they are; otherwise enclaude logs in as user with the
+// token in $ENCLAUDE_REGISTRY_PASSWORD, or lets docker login prompt when
+// running in a terminal.
+func ensureRegistryLogin(ctx context.Context, host, user string) error {
+	if user == "" && container.LoggedIn(host) {
+		return nil
+	}
+	if user != "" {
+		password := os.Getenv("ENCLAUDE_REGISTRY_PASSWORD")
+		if password == "" {
+			return fmt.Errorf("--registry-user needs the password or token in $ENCLAUDE_REGISTRY_PASSWORD")
+		}
+		return container.Login(ctx, host, user, password)
+	}
+	if !term.IsTerminal(os.Stdin.Fd()) {
+		return fmt.Errorf("not logged in to %s; run 'docker login %s' or pass --registry-user with $ENCLAUDE_REGISTRY_PASSWORD", host, host)
+	}
+	fmt.Printf("Logging in to %s...\n", host)
+	return container.Login(ctx, host, "", "")
+}
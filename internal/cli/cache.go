@@ -0,0 +1,299 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/docker/go-units"
+	"github.com/jakenelson/enclaude/internal/container"
+	"github.com/jakenelson/enclaude/internal/state"
+	"github.com/jakenelson/enclaude/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheListCmd)
+	cacheCmd.AddCommand(cacheSizeCmd)
+	cacheCmd.AddCommand(cacheClearCmd)
+
+	cacheClearCmd.Flags().Bool("project", false, "clear the volumes of the workspace (see --workdir)")
+	cacheClearCmd.Flags().StringP("workdir", "w", "", "workspace for --project (default: current directory)")
+	cacheClearCmd.Flags().Bool("all", false, "clear every volume named enclaude-*")
+	cacheClearCmd.Flags().Bool("dry-run", false, "list the volumes without removing them")
+}
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "List, measure, and clear enclaude's cache volumes",
+	Long: `Manage the Docker volumes sessions keep state in: the shared Claude Code
+cache and login volumes, the named caches of mounts.volumes (such as the npm
+or Go module caches of project templates), and the per-project volumes for
+shell history and --fast-fs. Per-project volumes are matched to their
+workspace through the run history and workspace pins; ones that match
+neither are shown as unknown.
+
+Examples:
+  enclaude cache ls
+  enclaude cache size
+  enclaude cache clear enclaude-npm
+  enclaude cache clear --project -w ~/src/api`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var cacheListCmd = &cobra.Command{
+	Use:     "ls",
+	Aliases: []string{"list"},
+	Short:   "List cache volumes and the projects they belong to",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		volumes, err := cacheVolumes(cmd.Context(), false)
+		if err != nil {
+			return err
+		}
+		if len(volumes) == 0 {
+			fmt.Println("No cache volumes.")
+			return nil
+		}
+		for _, v := range volumes {
+			created := ""
+			if !v.Created.IsZero() {
+				created = v.Created.Local().Format("2006-01-02 15:04")
+			}
+			fmt.Printf("%-36s %-14s %-16s %s\n", v.Name, v.kind, created, v.project)
+		}
+		return nil
+	},
+}
+
+var cacheSizeCmd = &cobra.Command{
+	Use:   "size",
+	Short: "Show the size of each cache volume, by project",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		volumes, err := cacheVolumes(cmd.Context(), true)
+		if err != nil {
+			return err
+		}
+		if len(volumes) == 0 {
+			fmt.Println("No cache volumes.")
+			return nil
+		}
+
+		byProject := make(map[string][]cacheVolume)
+		for _, v := range volumes {
+			byProject[v.project] = append(byProject[v.project], v)
+		}
+		projects := make([]string, 0, len(byProject))
+		for p := range byProject {
+			projects = append(projects, p)
+		}
+		sort.Strings(projects)
+
+		var total int64
+		for _, p := range projects {
+			var sum int64
+			for _, v := range byProject[p] {
+				sum += max(v.Size, 0)
+			}
+			total += sum
+			fmt.Printf("%s  %s\n", p, units.BytesSize(float64(sum)))
+			for _, v := range byProject[p] {
+				size := "unknown"
+				if v.Size >= 0 {
+					size = units.BytesSize(float64(v.Size))
+				}
+				inUse := ""
+				if v.InUse > 0 {
+					inUse = "  in use"
+				}
+				fmt.Printf("  %10s  %-36s %s%s\n", size, v.Name, v.kind, inUse)
+			}
+		}
+		fmt.Printf("Total  %s\n", units.BytesSize(float64(total)))
+		return nil
+	},
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear [VOLUME...]",
+	Short: "Remove cache volumes",
+	Long: `Remove the named cache volumes, the volumes of a workspace with --project,
+or every volume named enclaude-* with --all. Other mounts.volumes volumes
+are only removed by name. --project and --all skip --fast-fs volumes, which
+may hold changes not yet copied back to the workspace; name them to remove
+them. Sessions recreate the volumes empty when they next need them.
+Clearing enclaude-claude-home logs sessions with claude.session_dir volume
+out. Volumes in use by a running session cannot be removed.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		project, _ := cmd.Flags().GetBool("project")
+		all, _ := cmd.Flags().GetBool("all")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		if len(args) == 0 && !project && !all {
+			return fmt.Errorf("name the volumes to clear, or use --project or --all")
+		}
+		if all && (project || len(args) > 0) {
+			return fmt.Errorf("--all cannot be combined with --project or volume names")
+		}
+
+		volumes, err := cacheVolumes(cmd.Context(), false)
+		if err != nil {
+			return err
+		}
+		workDir := ""
+		if project {
+			if workDir, err = resolveWorkDir(cmd); err != nil {
+				return err
+			}
+		}
+		known := make(map[string]bool, len(volumes))
+		var clear []string
+		for _, v := range volumes {
+			known[v.Name] = true
+			kind, owner := classifyVolume(v.Name, []string{workDir})
+			if !(all && strings.HasPrefix(v.Name, container.VolumePrefix)) && !(project && owner == workDir) {
+				continue
+			}
+			if kind == "fastfs" {
+				fmt.Fprintf(os.Stderr, "Skipping %s: it may hold --fast-fs changes not yet copied back to the workspace; name it to remove it\n", v.Name)
+				continue
+			}
+			clear = append(clear, v.Name)
+		}
+		for _, name := range args {
+			if !known[name] {
+				return fmt.Errorf("%s is not an enclaude cache volume; see 'enclaude cache ls'", name)
+			}
+			clear = append(clear, name)
+		}
+		if len(clear) == 0 {
+			fmt.Println("No cache volumes to clear.")
+			return nil
+		}
+
+		if dryRun {
+			for _, name := range clear {
+				fmt.Printf("Would remove %s\n", name)
+			}
+			return nil
+		}
+		runner, err := container.NewRunner()
+		if err != nil {
+			return fmt.Errorf("failed to create container runner: %w", err)
+		}
+		defer runner.Close()
+		var failed int
+		for _, name := range clear {
+			if err := runner.RemoveVolume(cmd.Context(), name); err != nil {
+				fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+				failed++
+				continue
+			}
+			fmt.Printf("✅ Removed %s\n", name)
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d volumes could not be removed", failed, len(clear))
+		}
+		return nil
+	},
+}
+
+// Projects shown for volumes that belong to no single workspace
+const (
+	cacheProjectShared  = "(shared)"
+	cacheProjectUnknown = "(unknown)"
+)
+
+// cacheVolume is a cache volume with what it holds and whose it is
+type cacheVolume struct {
+	container.Volume
+	kind    string
+	project string // Workspace, cacheProjectShared, or cacheProjectUnknown
+}
+
+// cacheVolumes lists enclaude's volumes and those of mounts.volumes, sorted
+// by name. With measure, their sizes are filled in.
+func cacheVolumes(ctx context.Context, measure bool) ([]cacheVolume, error) {
+	runner, err := container.NewRunner()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create container runner: %w", err)
+	}
+	defer runner.Close()
+
+	var names []string
+	for _, v := range cfg.Mounts.Volumes {
+		names = append(names, v.Name)
+	}
+	volumes, err := runner.Volumes(ctx, names, measure)
+	if err != nil {
+		return nil, err
+	}
+
+	workspaces := knownWorkspaces()
+	caches := make([]cacheVolume, 0, len(volumes))
+	for _, v := range volumes {
+		kind, project := classifyVolume(v.Name, workspaces)
+		caches = append(caches, cacheVolume{Volume: v, kind: kind, project: project})
+	}
+	return caches, nil
+}
+
+// classifyVolume returns what a volume holds and the workspace it belongs
+// to, trying each of workspaces for per-project volumes
+func classifyVolume(name string, workspaces []string) (kind, project string) {
+	switch name {
+	case container.ClaudeCacheVolume:
+		return "claude cache", cacheProjectShared
+	case container.ClaudeHomeVolume:
+		return "claude login", cacheProjectShared
+	}
+	if purpose, ok := container.ProjectVolumePurpose(name); ok {
+		for _, ws := range workspaces {
+			if container.ProjectVolumeName(purpose, ws) == name {
+				return purpose, ws
+			}
+		}
+		return purpose, cacheProjectUnknown
+	}
+	if rest, ok := strings.CutPrefix(name, container.VolumePrefix); ok {
+		return rest, cacheProjectShared
+	}
+	return "mounts.volumes", cacheProjectShared
+}
+
+// knownWorkspaces returns the workspaces of recorded runs, pinned or
+// trusted workspaces, and the current directory
+func knownWorkspaces() []string {
+	seen := make(map[string]bool)
+	var dirs []string
+	add := func(dir string) {
+		if dir != "" && !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	if dir, err := state.Dir(); err == nil {
+		if runs, err := state.Runs(dir); err == nil {
+			for _, run := range runs {
+				add(run.Workspace)
+			}
+		}
+	}
+	if registry, err := workspace.LoadRegistry(registryPath()); err == nil {
+		for _, dir := range registry.Dirs() {
+			add(dir)
+		}
+		for _, dir := range registry.TrustedDirs() {
+			add(dir)
+		}
+	}
+	if wd, err := os.Getwd(); err == nil {
+		add(wd)
+	}
+	return dirs
+}
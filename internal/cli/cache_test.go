@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/jakenelson/enclaude/internal/container"
+)
+
+func TestClassifyVolume(t *testing.T) {
+	workspaces := []string{"/home/user/api", "/home/user/web"}
+
+	tests := []struct {
+		name    string
+		kind    string
+		project string
+	}{
+		{container.ClaudeCacheVolume, "claude cache", cacheProjectShared},
+		{container.ClaudeHomeVolume, "claude login", cacheProjectShared},
+		{container.ProjectVolumeName("history", "/home/user/web"), "history", "/home/user/web"},
+		{container.ProjectVolumeName("fastfs", "/home/user/gone"), "fastfs", cacheProjectUnknown},
+		{"enclaude-go-mod", "go-mod", cacheProjectShared},
+		{"pip-cache", "mounts.volumes", cacheProjectShared},
+	}
+	for _, tt := range tests {
+		kind, project := classifyVolume(tt.name, workspaces)
+		if kind != tt.kind || project != tt.project {
+			t.Errorf("classifyVolume(%q) = %q, %q; want %q, %q", tt.name, kind, project, tt.kind, tt.project)
+		}
+	}
+}
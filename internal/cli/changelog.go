@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jakenelson/enclaude/internal/state"
+	"github.com/jakenelson/enclaude/internal/update"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(changelogCmd)
+}
+
+var changelogCmd = &cobra.Command{
+	Use:   "changelog",
+	Short: "Show release notes for versions newer than this one",
+	Long: `Fetch release notes from GitHub for every release between the installed
+version and the latest release. Development builds show the latest release.`,
+	RunE: runChangelog,
+}
+
+func runChangelog(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	releases, err := update.Releases(ctx)
+	if err != nil {
+		return err
+	}
+
+	newer := update.Between(releases, Version)
+	if len(newer) == 0 {
+		fmt.Printf("enclaude %s is the latest release.\n", Version)
+		return nil
+	}
+
+	fmt.Printf("Installed: %s  Latest: %s\n", Version, newer[0].Version)
+	for _, r := range newer {
+		title := r.Version
+		if r.Name != "" && r.Name != r.Version {
+			title += " - " + r.Name
+		}
+		fmt.Printf("\n## %s (%s)\n\n", title, r.Published.Format("2006-01-02"))
+		if notes := strings.TrimSpace(r.Notes); notes != "" {
+			fmt.Println(notes)
+		} else {
+			fmt.Println("No release notes.")
+		}
+		fmt.Printf("\n%s\n", r.URL)
+	}
+	return nil
+}
+
+// notifyUpdate prints a one-line notice when the last release check found a
+// newer version, and refreshes the check in the background when it is more
+// than a day old so startup never waits on the network
+func notifyUpdate() {
+	if !cfg.Updates.Check || !update.IsRelease(Version) {
+		return
+	}
+	cacheDir, err := state.CacheDir()
+	if err != nil {
+		return
+	}
+	statePath := filepath.Join(cacheDir, state.UpdateCheckFile)
+
+	latest, stale := update.CachedLatest(statePath)
+	if latest != "" && update.Compare(latest, Version) > 0 {
+		fmt.Fprintf(os.Stderr, "Note: enclaude %s is available (installed: %s); run 'enclaude changelog' for details\n", latest, Version)
+	}
+
+	if stale {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			_ = update.Refresh(ctx, statePath)
+		}()
+	}
+}
@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/jakenelson/enclaude/internal/container"
+	"github.com/jakenelson/enclaude/internal/security"
+	"github.com/jakenelson/enclaude/internal/workspace"
+)
+
+// startChangeWatch reports workspace file changes to dest while the session
+// runs: "-" for the terminal's stderr, otherwise a file to append to (follow
+// it with tail -f in another pane). The returned function stops watching
+// once the session has ended. Failures only warn.
+func startChangeWatch(opts container.RunOptions, dest string) func() {
+	var root string
+	for _, m := range opts.Mounts {
+		if m.Target == opts.WorkDir {
+			root = m.Source
+		}
+	}
+	if root == "" {
+		return func() {}
+	}
+
+	// The terminal is in raw mode during interactive sessions
+	var out io.Writer = os.Stderr
+	eol := "\r\n"
+	var file *os.File
+	if dest != "-" {
+		path, err := security.ExpandPath(dest)
+		if err == nil {
+			file, err = os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: not watching workspace changes: %v\n", err)
+			return func() {}
+		}
+		out, eol = file, "\n"
+	}
+
+	watcher, err := workspace.NewWatcher(root)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: not watching workspace changes: %v\n", err)
+		if file != nil {
+			file.Close()
+		}
+		return func() {}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		err := watcher.Run(ctx, func(c workspace.Change) {
+			fmt.Fprintf(out, "[enclaude] %s %-8s %s%s", time.Now().Format("15:04:05"), c.Kind, c.Path, eol)
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: stopped watching workspace changes: %v%s", err, eol)
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+		if file != nil {
+			file.Close()
+		}
+	}
+}
@@ -0,0 +1,305 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/jakenelson/enclaude/internal/container"
+	"github.com/jakenelson/enclaude/internal/state"
+	"github.com/jakenelson/enclaude/internal/workspace"
+	"github.com/spf13/cobra"
+)
+
+// Exit statuses of enclaude ci
+const (
+	ciExitFailure = 1 // Claude reported an error, or annotations at --fail-on
+	ciExitError   = 2 // The sandbox failed or timed out before Claude finished
+)
+
+// ciContextVars are the CI variables describing the job, passed to the
+// session so gh, glab, and git can tell which repository and change it is for
+var ciContextVars = []string{
+	"CI",
+	"GITHUB_ACTIONS", "GITHUB_REPOSITORY", "GITHUB_REF", "GITHUB_REF_NAME", "GITHUB_SHA",
+	"GITHUB_BASE_REF", "GITHUB_HEAD_REF", "GITHUB_EVENT_NAME", "GITHUB_RUN_ID",
+	"GITHUB_SERVER_URL", "GITHUB_API_URL",
+	"GITLAB_CI", "CI_PROJECT_PATH", "CI_COMMIT_SHA", "CI_COMMIT_REF_NAME",
+	"CI_MERGE_REQUEST_IID", "CI_SERVER_URL", "CI_PIPELINE_ID",
+}
+
+func init() {
+	rootCmd.AddCommand(ciCmd)
+
+	ciCmd.Flags().StringP("prompt", "p", "", "task for Claude")
+	ciCmd.Flags().String("prompt-file", "", "read the task for Claude from this file")
+	ciCmd.Flags().StringP("workdir", "w", "", "working directory to mount (default: current directory)")
+	ciCmd.Flags().StringArrayP("mount", "m", nil, "additional directories to mount (read-write)")
+	ciCmd.Flags().StringArray("mount-ro", nil, "additional directories to mount (read-only)")
+	ciCmd.Flags().StringArray("ref", nil, "read-only reference directory, as path or name=path, mounted at /refs/<name> (repeatable)")
+	ciCmd.Flags().String("image", "", "Docker image to use (default: enclaude:latest)")
+	ciCmd.Flags().Bool("no-external-credentials", false, "Disable external credential passthrough other than the CI token")
+	ciCmd.Flags().String("permission-mode", "acceptEdits", "Claude permission mode: acceptEdits, bypassPermissions, plan, default")
+	ciCmd.Flags().Bool("no-default-args", false, "Do not put claude.default_args before the Claude arguments")
+	ciCmd.Flags().StringArray("docker-opt", nil, "docker run flag enclaude has no setting for, e.g. --docker-opt shm-size=2g (repeatable)")
+	ciCmd.Flags().Bool("strict", false, "fail instead of warning when the image does not match the workspace's enclaude.lock")
+	ciCmd.Flags().Duration("timeout", 0, "stop the session after this long (0 = no limit)")
+	ciCmd.Flags().String("summary-file", "enclaude-summary.json", "write a machine-readable summary here (empty to skip)")
+	ciCmd.Flags().String("codequality", "", "write a GitLab Code Quality report here (default gl-code-quality-report.json under GitLab CI)")
+	ciCmd.Flags().IntSlice("reverse-forward", nil, "host port reachable from the sandbox at host.enclaude.internal (repeatable)")
+	ciCmd.Flags().Bool("dangerous", false, "allow forwarding or mounting sockets that control the host, such as docker.sock")
+	ciCmd.Flags().Bool("i-know-what-im-doing", false, "same as --dangerous")
+	ciCmd.Flags().MarkDeprecated("i-know-what-im-doing", "use --dangerous")
+	ciCmd.Flags().String("fail-on", severityError, "fail when Claude reports issues at this level: error, warning, none")
+}
+
+var ciCmd = &cobra.Command{
+	Use:   "ci --prompt <task> [flags] [-- claude-args...]",
+	Short: "Run Claude non-interactively in a CI job",
+	Long: `Run a Claude Code task headlessly for GitHub Actions or GitLab CI: no TTY,
+Claude's progress rendered as a readable job log, and a strict exit status.
+
+Exit status:
+  0  Claude finished the task
+  1  Claude reported an error, or file issues at the --fail-on level
+  2  the sandbox failed or --timeout expired before Claude finished
+
+GITHUB_TOKEN (or GH_TOKEN) is passed to the session as both GH_TOKEN and
+GITHUB_TOKEN, with the job's repository and ref, so gh works inside it; set
+credentials.github to disabled to withhold it.
+
+File issues Claude lists in its final answer as "path:line[:col]: [severity:]
+message" become workflow annotations under GitHub Actions and a Code Quality
+report under GitLab CI. Ask for that format in the prompt. A JSON summary with
+the status, result, cost, changed files, and annotations is written to
+--summary-file for upload as an artifact, and appended to the job summary
+under GitHub Actions.
+
+Examples:
+  enclaude ci -p "Fix the failing tests"
+  enclaude ci --prompt-file .github/review-prompt.md --fail-on warning
+  enclaude ci -p "Update the changelog" --timeout 20m -- --model claude-sonnet-4-20250514`,
+	RunE: runCI,
+}
+
+// ciSummary is the machine-readable record of a CI run
+type ciSummary struct {
+	Status      string         `json:"status"` // success, failure, error
+	ExitCode    int            `json:"exit_code"`
+	Error       string         `json:"error,omitempty"`
+	RunID       string         `json:"run_id,omitempty"`
+	Duration    float64        `json:"duration_seconds"`
+	Turns       int            `json:"num_turns,omitempty"`
+	CostUSD     float64        `json:"cost_usd,omitempty"`
+	Result      string         `json:"result,omitempty"`
+	Changes     *ciChanges     `json:"files_changed,omitempty"`
+	Annotations []ciAnnotation `json:"annotations"`
+}
+
+// ciChanges lists workspace files changed during the run
+type ciChanges struct {
+	Added    []string `json:"added"`
+	Modified []string `json:"modified"`
+	Removed  []string `json:"removed"`
+}
+
+func runCI(cmd *cobra.Command, args []string) error {
+	prompt, err := ciPrompt(cmd)
+	if err != nil {
+		return err
+	}
+	failOn, _ := cmd.Flags().GetString("fail-on")
+	if failOn != severityError && failOn != severityWarning && failOn != "none" {
+		return fmt.Errorf("invalid --fail-on %q (allowed: error, warning, none)", failOn)
+	}
+	mode, _ := cmd.Flags().GetString("permission-mode")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if timeout, _ := cmd.Flags().GetDuration("timeout"); timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigCh
+		cancel()
+	}()
+
+	claudeArgs := append([]string{"-p", prompt, "--output-format", "stream-json", "--verbose", "--permission-mode", mode}, args...)
+	pull := startImagePull(ctx, sessionImage(cmd))
+	opts, cleanup, err := buildRunOptions(cmd, claudeArgs)
+	if err != nil {
+		pull.stop()
+		return &exitCodeError{ciExitError, err}
+	}
+	defer cleanup()
+	if err := pull.wait(ctx, opts.Image); err != nil {
+		return &exitCodeError{ciExitError, err}
+	}
+	if err := checkImageLock(ctx, cmd, opts); err != nil {
+		return &exitCodeError{ciExitError, err}
+	}
+	wireCIEnvironment(&opts)
+
+	stream := &ciStream{out: os.Stdout}
+	opts.Stdout = stream
+	opts.NoTTY = true
+
+	session, err := startSession(ctx, cmd, &opts, false)
+	if err != nil {
+		return &exitCodeError{ciExitError, err}
+	}
+	defer session.stop()
+	started := time.Now()
+	var before workspace.Snapshot
+	source := workspaceSource(opts)
+	if source != "" {
+		before, _ = workspace.TakeSnapshot(source)
+	}
+	runErr := session.runner.Run(ctx, cancel, opts)
+	stream.Close()
+	session.finish(runErr)
+
+	summary := ciSummary{RunID: opts.RunID, Duration: time.Since(started).Seconds(), Annotations: []ciAnnotation{}}
+	if before != nil {
+		if after, err := workspace.TakeSnapshot(source); err == nil {
+			c := before.Diff(after)
+			summary.Changes = &ciChanges{Added: c.Added, Modified: c.Modified, Removed: c.Removed}
+		}
+	}
+
+	result := stream.Result()
+	switch {
+	case result == nil:
+		summary.Status, summary.ExitCode = "error", ciExitError
+		switch {
+		case errors.Is(runErr, context.DeadlineExceeded):
+			summary.Error = "timed out before Claude finished"
+		case runErr != nil:
+			summary.Error = runErr.Error()
+		default:
+			summary.Error = "Claude exited without a result"
+		}
+	default:
+		summary.Turns, summary.CostUSD, summary.Result = result.NumTurns, result.CostUSD, result.Result
+		summary.Annotations = append(summary.Annotations, parseAnnotations(result.Result, opts.WorkDir, source)...)
+		summary.Status = "success"
+		if result.IsError || result.Subtype != "success" {
+			summary.Status, summary.ExitCode = "failure", ciExitFailure
+			summary.Error = "Claude reported an error: " + result.Subtype
+		} else if failsOn(summary.Annotations, failOn) {
+			summary.Status, summary.ExitCode = "failure", ciExitFailure
+			summary.Error = fmt.Sprintf("Claude reported issues at level %s or above", failOn)
+		}
+	}
+
+	publishCIResults(cmd, summary)
+
+	if summary.ExitCode != 0 {
+		return &exitCodeError{summary.ExitCode, errors.New(summary.Error)}
+	}
+	return nil
+}
+
+// ciPrompt returns the task from --prompt or --prompt-file
+func ciPrompt(cmd *cobra.Command) (string, error) {
+	prompt, _ := cmd.Flags().GetString("prompt")
+	if file, _ := cmd.Flags().GetString("prompt-file"); file != "" {
+		if prompt != "" {
+			return "", fmt.Errorf("use either --prompt or --prompt-file, not both")
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", fmt.Errorf("failed to read prompt file: %w", err)
+		}
+		prompt = string(data)
+	}
+	if strings.TrimSpace(prompt) == "" {
+		return "", fmt.Errorf("no task given; use --prompt or --prompt-file")
+	}
+	return prompt, nil
+}
+
+// wireCIEnvironment passes the job's context to the session, and its GitHub
+// token under both names tools look for when GitHub passthrough is enabled
+func wireCIEnvironment(opts *container.RunOptions) {
+	if opts.Environment == nil {
+		opts.Environment = make(map[string]string)
+	}
+	for _, k := range ciContextVars {
+		if v, ok := os.LookupEnv(k); ok {
+			opts.Environment[k] = v
+		}
+	}
+	if token := opts.Environment["GH_TOKEN"]; token != "" {
+		opts.Environment["GITHUB_TOKEN"] = token
+	}
+}
+
+// publishCIResults writes the summary artifact and reports annotations and
+// the outcome in the form the CI system understands. Failures only warn, so
+// the exit status reflects the task.
+func publishCIResults(cmd *cobra.Command, summary ciSummary) {
+	if path, _ := cmd.Flags().GetString("summary-file"); path != "" {
+		if err := state.WriteJSON(path, summary); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to write summary: %v\n", err)
+		}
+	}
+
+	if os.Getenv("GITHUB_ACTIONS") == "true" {
+		writeGitHubAnnotations(os.Stdout, summary.Annotations)
+		if path := os.Getenv("GITHUB_STEP_SUMMARY"); path != "" {
+			if err := appendStepSummary(path, summary); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to write job summary: %v\n", err)
+			}
+		}
+	}
+
+	path, _ := cmd.Flags().GetString("codequality")
+	if path == "" && os.Getenv("GITLAB_CI") == "true" {
+		path = "gl-code-quality-report.json"
+	}
+	if path != "" {
+		report, err := codeQualityReport(summary.Annotations)
+		if err == nil {
+			err = os.WriteFile(path, report, 0644)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to write Code Quality report: %v\n", err)
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "\nenclaude ci: %s", summary.Status)
+	if summary.Error != "" {
+		fmt.Fprintf(os.Stderr, " (%s)", summary.Error)
+	}
+	fmt.Fprintf(os.Stderr, ", %d annotation(s), %.0fs\n", len(summary.Annotations), summary.Duration)
+}
+
+// appendStepSummary adds the outcome and Claude's answer to the GitHub
+// Actions job summary
+func appendStepSummary(path string, summary ciSummary) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(f, "### enclaude: %s\n\n", summary.Status)
+	if summary.Error != "" {
+		fmt.Fprintf(f, "%s\n\n", summary.Error)
+	}
+	if summary.Changes != nil {
+		fmt.Fprintf(f, "Files changed: %d added, %d modified, %d removed\n\n", len(summary.Changes.Added), len(summary.Changes.Modified), len(summary.Changes.Removed))
+	}
+	if summary.Result != "" {
+		fmt.Fprintf(f, "%s\n", summary.Result)
+	}
+	return f.Close()
+}
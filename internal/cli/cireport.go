@@ -0,0 +1,259 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Annotation severities, as in GitHub workflow commands
+const (
+	severityError   = "error"
+	severityWarning = "warning"
+	severityNotice  = "notice"
+)
+
+// issueLine matches a file issue reported by Claude as
+// "path:line[:col]: [severity:] message", optionally as a list item and
+// with the location in backticks
+var issueLine = regexp.MustCompile("^\\s*(?:[-*]\\s+)?`?([^\\s:`]+):(\\d+)(?::(\\d+))?`?:?\\s+(?:(?i:(error|warning|notice|note))s?:?\\s+)?(.+)$")
+
+// ciAnnotation is a file issue Claude reported in its final result
+type ciAnnotation struct {
+	Severity string `json:"severity"`
+	File     string `json:"file"` // Relative to the workspace
+	Line     int    `json:"line"`
+	Column   int    `json:"column,omitempty"`
+	Message  string `json:"message"`
+}
+
+// ciResult is the final event of Claude's stream-json output
+type ciResult struct {
+	Subtype    string  `json:"subtype"`
+	IsError    bool    `json:"is_error"`
+	Result     string  `json:"result"`
+	NumTurns   int     `json:"num_turns"`
+	CostUSD    float64 `json:"total_cost_usd"`
+	DurationMS int64   `json:"duration_ms"`
+}
+
+// ciEvent is one line of Claude's stream-json output; only the fields shown
+// in the job log are decoded
+type ciEvent struct {
+	Type    string `json:"type"`
+	Message struct {
+		Content []struct {
+			Type  string `json:"type"`
+			Text  string `json:"text"`
+			Name  string `json:"name"`
+			Input struct {
+				Command  string `json:"command"`
+				FilePath string `json:"file_path"`
+				Pattern  string `json:"pattern"`
+			} `json:"input"`
+		} `json:"content"`
+	} `json:"message"`
+	ciResult
+}
+
+// ciStream renders Claude's stream-json output as a readable job log and
+// keeps the final result. Lines that are not JSON are passed through.
+type ciStream struct {
+	mu     sync.Mutex
+	out    io.Writer
+	buf    []byte
+	result *ciResult
+}
+
+func (s *ciStream) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buf = append(s.buf, p...)
+	for {
+		i := bytes.IndexByte(s.buf, '\n')
+		if i < 0 {
+			break
+		}
+		s.line(s.buf[:i])
+		s.buf = s.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Close handles a final unterminated line
+func (s *ciStream) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.buf) > 0 {
+		s.line(s.buf)
+		s.buf = nil
+	}
+	return nil
+}
+
+func (s *ciStream) line(line []byte) {
+	var ev ciEvent
+	if err := json.Unmarshal(line, &ev); err != nil || ev.Type == "" {
+		fmt.Fprintf(s.out, "%s\n", line)
+		return
+	}
+	switch ev.Type {
+	case "assistant":
+		for _, c := range ev.Message.Content {
+			switch c.Type {
+			case "text":
+				fmt.Fprintln(s.out, strings.TrimSpace(c.Text))
+			case "tool_use":
+				detail := c.Input.Command
+				if detail == "" {
+					detail = c.Input.FilePath
+				}
+				if detail == "" {
+					detail = c.Input.Pattern
+				}
+				detail, _, _ = strings.Cut(detail, "\n")
+				fmt.Fprintf(s.out, "> %s %s\n", c.Name, detail)
+			}
+		}
+	case "result":
+		result := ev.ciResult
+		s.result = &result
+	}
+}
+
+// Result returns the final result, or nil if Claude did not finish
+func (s *ciStream) Result() *ciResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.result
+}
+
+// parseAnnotations extracts file issues from Claude's result text. Container
+// paths under workDir are made relative to the workspace, and issues in files
+// that do not exist in hostDir are dropped as likely false matches.
+func parseAnnotations(text, workDir, hostDir string) []ciAnnotation {
+	var annotations []ciAnnotation
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for scanner.Scan() {
+		m := issueLine.FindStringSubmatch(scanner.Text())
+		if m == nil {
+			continue
+		}
+		file := m[1]
+		if path.IsAbs(file) {
+			rel := strings.TrimPrefix(file, strings.TrimSuffix(workDir, "/")+"/")
+			if rel == file {
+				continue
+			}
+			file = rel
+		}
+		file = path.Clean(strings.TrimPrefix(file, "./"))
+		if strings.HasPrefix(file, "../") {
+			continue
+		}
+		if info, err := os.Stat(filepath.Join(hostDir, filepath.FromSlash(file))); err != nil || info.IsDir() {
+			continue
+		}
+
+		line, _ := strconv.Atoi(m[2])
+		col, _ := strconv.Atoi(m[3])
+		severity := strings.ToLower(m[4])
+		switch severity {
+		case severityError, severityNotice:
+		case "note":
+			severity = severityNotice
+		default:
+			severity = severityWarning
+		}
+		a := ciAnnotation{Severity: severity, File: file, Line: line, Column: col, Message: strings.TrimSpace(m[5])}
+
+		key := fmt.Sprintf("%s:%d:%s", a.File, a.Line, a.Message)
+		if !seen[key] {
+			seen[key] = true
+			annotations = append(annotations, a)
+		}
+	}
+	return annotations
+}
+
+// failsOn reports whether any annotation is at least as severe as level:
+// error, warning, or none
+func failsOn(annotations []ciAnnotation, level string) bool {
+	for _, a := range annotations {
+		switch level {
+		case severityError:
+			if a.Severity == severityError {
+				return true
+			}
+		case severityWarning:
+			if a.Severity != severityNotice {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// writeGitHubAnnotations prints annotations as GitHub Actions workflow
+// commands, which the runner turns into annotations on the changed files
+func writeGitHubAnnotations(w io.Writer, annotations []ciAnnotation) {
+	for _, a := range annotations {
+		props := "file=" + escapeGitHubProperty(a.File) + ",line=" + strconv.Itoa(a.Line)
+		if a.Column > 0 {
+			props += ",col=" + strconv.Itoa(a.Column)
+		}
+		fmt.Fprintf(w, "::%s %s::%s\n", a.Severity, props, escapeGitHubData(a.Message))
+	}
+}
+
+func escapeGitHubData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+func escapeGitHubProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
+
+// codeQualityReport renders annotations as a GitLab Code Quality report
+func codeQualityReport(annotations []ciAnnotation) ([]byte, error) {
+	type lines struct {
+		Begin int `json:"begin"`
+	}
+	type location struct {
+		Path  string `json:"path"`
+		Lines lines  `json:"lines"`
+	}
+	type issue struct {
+		Description string   `json:"description"`
+		CheckName   string   `json:"check_name"`
+		Fingerprint string   `json:"fingerprint"`
+		Severity    string   `json:"severity"`
+		Location    location `json:"location"`
+	}
+	severities := map[string]string{severityError: "major", severityWarning: "minor", severityNotice: "info"}
+
+	issues := make([]issue, 0, len(annotations))
+	for _, a := range annotations {
+		sum := sha1.Sum([]byte(fmt.Sprintf("%s:%d:%s", a.File, a.Line, a.Message)))
+		issues = append(issues, issue{
+			Description: a.Message,
+			CheckName:   "enclaude",
+			Fingerprint: hex.EncodeToString(sum[:]),
+			Severity:    severities[a.Severity],
+			Location:    location{Path: a.File, Lines: lines{Begin: a.Line}},
+		})
+	}
+	return json.MarshalIndent(issues, "", "  ")
+}
@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseAnnotations(t *testing.T) {
+	hostDir := t.TempDir()
+	for _, f := range []string{"main.go", filepath.Join("pkg", "util.go")} {
+		if err := os.MkdirAll(filepath.Join(hostDir, filepath.Dir(f)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(hostDir, f), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	text := strings.Join([]string{
+		"I reviewed the change and found:",
+		"- main.go:12: error: nil map write",
+		"- `pkg/util.go:3:7` unused parameter ctx",
+		"/workspace/pkg/util.go:40: Note: could use strings.Cut",
+		"main.go:12: error: nil map write",
+		"missing.go:1: error: not a real file",
+		"/etc/passwd:1: error: outside the workspace",
+		"Step 2: run the tests",
+	}, "\n")
+
+	got := parseAnnotations(text, "/workspace", hostDir)
+	want := []ciAnnotation{
+		{Severity: severityError, File: "main.go", Line: 12, Message: "nil map write"},
+		{Severity: severityWarning, File: "pkg/util.go", Line: 3, Column: 7, Message: "unused parameter ctx"},
+		{Severity: severityNotice, File: "pkg/util.go", Line: 40, Message: "could use strings.Cut"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseAnnotations() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	if !failsOn(want, severityError) || !failsOn(want[1:], severityWarning) || failsOn(want[1:], severityError) || failsOn(want, "none") {
+		t.Error("failsOn() does not respect the level")
+	}
+}
+
+func TestCIStream(t *testing.T) {
+	var out bytes.Buffer
+	s := &ciStream{out: &out}
+	events := strings.Join([]string{
+		`{"type":"system","subtype":"init"}`,
+		`{"type":"assistant","message":{"content":[{"type":"text","text":"Running the tests.\n"},{"type":"tool_use","name":"Bash","input":{"command":"go test ./...\necho done"}}]}}`,
+		`not json`,
+		`{"type":"result","subtype":"success","is_error":false,"result":"All tests pass.","num_turns":3,"total_cost_usd":0.12}`,
+	}, "\n")
+	// Split mid-line, as container output arrives
+	s.Write([]byte(events[:40]))
+	s.Write([]byte(events[40:]))
+	s.Close()
+
+	want := "Running the tests.\n> Bash go test ./...\nnot json\n"
+	if out.String() != want {
+		t.Errorf("log = %q, want %q", out.String(), want)
+	}
+	r := s.Result()
+	if r == nil || r.Subtype != "success" || r.Result != "All tests pass." || r.NumTurns != 3 || r.CostUSD != 0.12 {
+		t.Errorf("Result() = %+v", r)
+	}
+}
+
+func TestWriteGitHubAnnotations(t *testing.T) {
+	var out bytes.Buffer
+	writeGitHubAnnotations(&out, []ciAnnotation{
+		{Severity: severityError, File: "a,b.go", Line: 2, Column: 5, Message: "100% wrong\nreally"},
+		{Severity: severityNotice, File: "c.go", Line: 1, Message: "fyi"},
+	})
+	want := "::error file=a%2Cb.go,line=2,col=5::100%25 wrong%0Areally\n::notice file=c.go,line=1::fyi\n"
+	if out.String() != want {
+		t.Errorf("annotations = %q, want %q", out.String(), want)
+	}
+}
+
+func TestCodeQualityReport(t *testing.T) {
+	data, err := codeQualityReport([]ciAnnotation{{Severity: severityError, File: "main.go", Line: 12, Message: "nil map write"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var issues []struct {
+		Severity    string `json:"severity"`
+		Fingerprint string `json:"fingerprint"`
+		Location    struct {
+			Path  string `json:"path"`
+			Lines struct {
+				Begin int `json:"begin"`
+			} `json:"lines"`
+		} `json:"location"`
+	}
+	if err := json.Unmarshal(data, &issues); err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 1 || issues[0].Severity != "major" || issues[0].Location.Path != "main.go" || issues[0].Location.Lines.Begin != 12 || issues[0].Fingerprint == "" {
+		t.Errorf("report = %s", data)
+	}
+}
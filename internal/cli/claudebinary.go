@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/jakenelson/enclaude/internal/container"
+	"github.com/jakenelson/enclaude/internal/security"
+)
+
+// Where claude.binary is mounted: a binary replaces the image's claude, and
+// an npm pack is installed from by the entrypoint
+const (
+	claudeBinaryTarget = "/usr/local/bin/claude"
+	claudePackTarget   = "/run/enclaude/claude-pack.tgz"
+)
+
+// collectClaudeBinary mounts claude.binary over the image's Claude Code, so
+// a locally built or patched release can be tried without rebuilding the
+// image. An npm pack (.tgz) is installed into the session's home at startup;
+// anything else must be a Linux executable.
+func collectClaudeBinary() ([]container.Mount, map[string]string, error) {
+	if cfg.Claude.Binary == "" {
+		return nil, nil, nil
+	}
+	source, err := security.ExpandPath(cfg.Claude.Binary)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid claude.binary: %w", err)
+	}
+	if !security.FileExists(source) {
+		return nil, nil, fmt.Errorf("claude.binary %s is not a file", source)
+	}
+
+	if isNpmPack(source) {
+		mount := container.Mount{Source: source, Target: claudePackTarget, ReadOnly: true, Kind: container.MountFile}
+		return []container.Mount{mount}, map[string]string{"ENCLAUDE_CLAUDE_PACK": claudePackTarget}, nil
+	}
+	if err := checkLinuxExecutable(source); err != nil {
+		return nil, nil, fmt.Errorf("claude.binary %s: %w", source, err)
+	}
+	mount := container.Mount{Source: source, Target: claudeBinaryTarget, ReadOnly: true, Kind: container.MountFile}
+	return []container.Mount{mount}, nil, nil
+}
+
+// isNpmPack reports whether path is a tarball made by `npm pack`
+func isNpmPack(path string) bool {
+	return strings.HasSuffix(path, ".tgz") || strings.HasSuffix(path, ".tar.gz")
+}
+
+// checkLinuxExecutable rejects files the container cannot run, such as a
+// macOS build of Claude Code: only ELF binaries and scripts are accepted
+func checkLinuxExecutable(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if info, err := f.Stat(); err == nil && info.Mode()&0111 == 0 {
+		return fmt.Errorf("not executable (run chmod +x on it)")
+	}
+	magic := make([]byte, 4)
+	if _, err := io.ReadFull(f, magic); err != nil {
+		return fmt.Errorf("not a Linux executable")
+	}
+	if !bytes.Equal(magic, []byte("\x7fELF")) && !bytes.HasPrefix(magic, []byte("#!")) {
+		return fmt.Errorf("not a Linux executable (build Claude Code for Linux, or use an npm pack)")
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/jakenelson/enclaude/internal/container"
+	"github.com/jakenelson/enclaude/internal/security"
+)
+
+// claudeDefinitionDir is a configured directory of Claude Code definitions
+// and where Claude looks for it under ~/.claude
+type claudeDefinitionDir struct {
+	key  string
+	path string
+	name string
+}
+
+// collectClaudeDefinitions mounts the directories set in claude.agents_dir,
+// claude.commands_dir, and claude.output_styles_dir read-only into the
+// container's ~/.claude, so a team's definitions can be shared without
+// exposing the rest of the host's Claude configuration
+func collectClaudeDefinitions() ([]container.Mount, error) {
+	dirs := []claudeDefinitionDir{
+		{"claude.agents_dir", cfg.Claude.AgentsDir, "agents"},
+		{"claude.commands_dir", cfg.Claude.CommandsDir, "commands"},
+		{"claude.output_styles_dir", cfg.Claude.OutputStylesDir, "output-styles"},
+	}
+
+	home, _ := os.UserHomeDir()
+	var mounts []container.Mount
+	for _, d := range dirs {
+		if d.path == "" {
+			continue
+		}
+		source, err := security.ExpandPath(d.path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", d.key, err)
+		}
+		if err := prepareClaudeMountPoint(home, d.name); err != nil {
+			return nil, fmt.Errorf("cannot mount %s: %w", d.key, err)
+		}
+		mounts = append(mounts, container.Mount{
+			Source:   source,
+			Target:   container.Home + "/.claude/" + d.name,
+			ReadOnly: true,
+			Kind:     container.MountDir,
+		})
+	}
+	return mounts, nil
+}
+
+// prepareClaudeMountPoint creates ~/.claude/<name> on the host when the host
+// has a ~/.claude, which may be mounted read-only into the session: Docker
+// cannot create a mount point inside a read-only mount.
+func prepareClaudeMountPoint(home, name string) error {
+	if home == "" || !security.DirExists(filepath.Join(home, ".claude")) {
+		return nil
+	}
+	return os.MkdirAll(filepath.Join(home, ".claude", name), 0755)
+}
@@ -1,13 +1,39 @@
 package cli
 
 import (
+	"context"
 	"os"
 
+	"github.com/jakenelson/enclaude/internal/config"
+	"github.com/jakenelson/enclaude/internal/container"
 	"github.com/spf13/cobra"
 )
 
 func init() {
 	rootCmd.AddCommand(completionCmd)
+	configSetCmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) == 1 && args[0] == "container.network" {
+			return completeNetworks(cmd, args, toComplete)
+		}
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeNetworks completes network names: the built-in modes plus the
+// Docker networks that exist, when Docker is reachable
+func completeNetworks(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	names := []string{config.NetworkBridge, config.NetworkHost, config.NetworkNone}
+	if runner, err := container.NewRunner(); err == nil {
+		defer runner.Close()
+		if networks, err := runner.Networks(context.Background()); err == nil {
+			for _, n := range networks {
+				if !config.IsBuiltinNetwork(n) {
+					names = append(names, n)
+				}
+			}
+		}
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
 }
 
 var completionCmd = &cobra.Command{
@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -19,6 +20,12 @@ func init() {
 	configCmd.AddCommand(configSetCmd)
 	configCmd.AddCommand(configPathCmd)
 	configCmd.AddCommand(configInitCmd)
+	configCmd.AddCommand(configMigrateCmd)
+	configCmd.AddCommand(configSchemaCmd)
+
+	configGetCmd.Flags().Bool("default", false, "print the built-in default instead of the effective value")
+	configGetCmd.Flags().Bool("explain", false, "show where the value comes from and what each config layer sets")
+	configGetCmd.MarkFlagsMutuallyExclusive("default", "explain")
 }
 
 var configCmd = &cobra.Command{
@@ -32,10 +39,12 @@ Commands:
   set     Set a configuration value
   path    Show configuration file path
   init    Create default configuration file
+  migrate Upgrade configuration file to the current format
 
 Examples:
   enclaude config list
   enclaude config get claude.auth
+  enclaude config get claude.auth --explain
   enclaude config set claude.auth api-key
   enclaude config set credentials.github disabled`,
 	Run: func(cmd *cobra.Command, args []string) {
@@ -56,16 +65,47 @@ var configListCmd = &cobra.Command{
 var configGetCmd = &cobra.Command{
 	Use:   "get <key>",
 	Short: "Get a configuration value",
-	Args:  cobra.ExactArgs(1),
+	Long: `Get the effective value of a configuration setting.
+
+With --default, the built-in default is printed instead. With --explain, the
+value is shown with where it comes from: the default, the user config file,
+the project's .enclaude.yaml, or an ENCLAUDE_ environment variable, later ones
+taking precedence, and the session flag that overrides it, if any.
+
+Examples:
+  enclaude config get claude.auth
+  enclaude config get image.name --default
+  enclaude config get image.name --explain`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		key := args[0]
+		key := strings.ToLower(args[0])
+		if showDefault, _ := cmd.Flags().GetBool("default"); showDefault {
+			value, ok := config.Default(key)
+			if !ok {
+				return fmt.Errorf("no default for %s", key)
+			}
+			if m, ok := value.(map[string]interface{}); ok {
+				printSettingsFlat(key, m)
+			} else {
+				fmt.Println(value)
+			}
+			return nil
+		}
 		if !viper.IsSet(key) {
 			return fmt.Errorf("key not found: %s", key)
 		}
 		value := viper.Get(key)
+		_, isSection := value.(map[string]interface{})
+		if explain, _ := cmd.Flags().GetBool("explain"); explain {
+			if isSection {
+				return fmt.Errorf("%s is a section; explain one of its settings", key)
+			}
+			printSettingExplanation(os.Stdout, key)
+			return nil
+		}
 		// Handle nested maps by printing them in a readable format
-		if m, ok := value.(map[string]interface{}); ok {
-			printSettingsFlat(key, m)
+		if isSection {
+			printSettingsFlat(key, value.(map[string]interface{}))
 		} else {
 			fmt.Println(value)
 		}
@@ -76,15 +116,57 @@ var configGetCmd = &cobra.Command{
 var configSetCmd = &cobra.Command{
 	Use:   "set <key> <value>",
 	Short: "Set a configuration value",
-	Args:  cobra.ExactArgs(2),
-	RunE: func(cmd *cobra.Command, args []string) error {
-		key, value := args[0], args[1]
+	Long: `Set a configuration value in the user config file.
+
+Values are parsed as the type of the setting: numbers and booleans are
+checked, durations such as credentials.ttl must parse (e.g. 30m), and lists
+of strings take comma-separated values. Append to or remove from a list with
+a trailing + or - on the key, and set map entries by naming them in the key.
 
-		// Validate known keys
-		if err := validateConfigKey(key, value); err != nil {
+Examples:
+  enclaude config set container.pids_limit 512
+  enclaude config set credentials.ttl=45m
+  enclaude config set container.ports 8080:8080,5173:5173
+  enclaude config set mounts.defaults+ ~/shared        # append ~/shared:ro for read-only
+  enclaude config set mounts.defaults- ~/shared
+  enclaude config set mounts.volumes+ go-mod:/var/cache/enclaude/go-mod
+  enclaude config set mounts.workspaces+ api=../api     # add a workspace root
+  enclaude config set environment.passthrough+ AWS_PROFILE
+  enclaude config set environment.custom.FOO=bar`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key, op, value, err := parseSetArgs(args)
+		if err != nil {
 			return err
 		}
 
+		var parsedValue interface{}
+		switch op {
+		case setReplace:
+			// Validate known keys
+			if err := validateConfigKey(key, value); err != nil {
+				return err
+			}
+			parsedValue, err = parseConfigValue(key, value, viper.Get(key))
+			if err != nil {
+				return err
+			}
+		default:
+			list, changed, err := updateList(key, viper.Get(key), op, value)
+			if err != nil {
+				return err
+			}
+			if !changed {
+				if op == setAppend {
+					fmt.Printf("%s already contains %s\n", key, value)
+				} else {
+					fmt.Printf("%s does not contain %s\n", key, value)
+				}
+				return nil
+			}
+			parsedValue = list
+		}
+
 		// Get config file path
 		configPath := getConfigPath()
 
@@ -94,23 +176,25 @@ var configSetCmd = &cobra.Command{
 			return fmt.Errorf("failed to create config directory: %w", err)
 		}
 
-		// Parse value (handle booleans)
-		var parsedValue interface{} = value
-		if value == "true" {
-			parsedValue = true
-		} else if value == "false" {
-			parsedValue = false
-		}
-
 		// Update the value
 		viper.Set(key, parsedValue)
+		if prefix := "environment.custom."; len(key) > len(prefix) && strings.EqualFold(key[:len(prefix)], prefix) {
+			config.RecordEnvName(key[len(prefix):])
+		}
 
 		// Write config to file
-		if err := viper.WriteConfigAs(configPath); err != nil {
+		if err := config.WriteConfigFile(configPath, viper.AllSettings()); err != nil {
 			return fmt.Errorf("failed to write config: %w", err)
 		}
 
-		fmt.Printf("Set %s = %s\n", key, value)
+		switch op {
+		case setAppend:
+			fmt.Printf("Added %s to %s\n", value, key)
+		case setRemove:
+			fmt.Printf("Removed %s from %s\n", value, key)
+		default:
+			fmt.Printf("Set %s = %s\n", key, value)
+		}
 		return nil
 	},
 }
@@ -127,6 +211,27 @@ var configPathCmd = &cobra.Command{
 	},
 }
 
+var configSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print a JSON Schema for the configuration file",
+	Long: `Print a JSON Schema for config.yaml and .enclaude.yaml, so editors can
+complete setting names and flag unknown settings and invalid values.
+
+With the YAML language server (used by VS Code's YAML extension, Neovim, and
+others), save the schema and point the config file at it on its first line:
+
+  # yaml-language-server: $schema=config.schema.json
+
+Examples:
+  enclaude config schema > ~/.config/enclaude/config.schema.json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(config.Schema())
+	},
+}
+
 var configInitCmd = &cobra.Command{
 	Use:   "init",
 	Short: "Create default configuration file",
@@ -145,9 +250,13 @@ var configInitCmd = &cobra.Command{
 		defaultConfig := `# Enclaude configuration
 # See https://github.com/jakenelson/enclaude for documentation
 
+config_version: 2
+
 # Image settings
 image:
   name: enclaude:latest
+  pull_policy: missing  # always | missing | never
+  auto_rebuild: false   # Rebuild without asking when the Dockerfile or build context changed
   # dockerfile: ""       # Path to custom Dockerfile (optional)
   # build_context: ""    # Custom build context (optional)
 
@@ -156,18 +265,55 @@ mounts:
   defaults: []
     # - path: ~/projects/shared-utils
     #   readonly: true
+  volumes: []
+    # - name: enclaude-go-mod
+    #   path: /var/cache/enclaude/go-mod
+  workspace_target: /workspace  # Container path for the workspace, or "host" to use the host path
+  workspaces: []     # Sibling repositories mounted read-write at <workspace_target>/<name>
+    # - path: ../api   # Relative to the working directory
+    # - name: frontend
+    #   path: ~/src/web
 
 # Claude Code authentication
 claude:
   auth: auto              # auto | session | api-key
-  session_dir: readwrite  # none | readonly | readwrite
-  default_args: []
+  session_dir: readwrite  # none | readonly | readwrite | volume
+  session_lock: warn      # warn | wait | readonly | off: when another session already uses ~/.claude read-write
+  prefer: ask             # ask | session | api-key (when auto finds both)
+  default_args: []        # Before every session's Claude arguments; given flags override them
     # Example: ["--model", "claude-sonnet-4-20250514"]
+  arg_presets: {}         # Named invocations for 'enclaude preset <name>'
+  # arg_presets:
+  #   review: ["-p", "Review the uncommitted changes for bugs"]
+  preflight: false        # Check API reachability and CA chain before starting
+  disable_telemetry: false  # Disable telemetry/error reporting and block their endpoints
+  cache_volume: false     # Keep Claude Code's caches in a Docker volume shared by all sessions
+  secretless: false       # Keep ANTHROPIC_API_KEY on the host; the session gets a placeholder
+  secretless_upstream: "" # HTTPS API the secretless proxy forwards to (default: host ANTHROPIC_BASE_URL)
+  agents_dir: ""          # Subagent definitions, mounted read-only at ~/.claude/agents
+  commands_dir: ""        # Custom slash commands, mounted read-only at ~/.claude/commands
+  output_styles_dir: ""   # Output styles, mounted read-only at ~/.claude/output-styles
+  binary: ""              # Host Claude Code to run instead of the image's (Linux binary or npm pack .tgz)
+  provider: anthropic     # anthropic | bedrock | vertex
+  # bedrock:              # Used when provider is "bedrock"
+  #   region: us-east-1   # Default: $AWS_REGION
+  #   profile: ""         # AWS profile to export credentials from (default: $AWS_PROFILE)
+  # vertex:               # Used when provider is "vertex"
+  #   project_id: ""      # Default: $ANTHROPIC_VERTEX_PROJECT_ID
+  #   region: us-east5    # Default: $CLOUD_ML_REGION
 
 # External service credentials
 credentials:
-  github: auto       # auto | enabled | disabled
+  github: auto       # auto | enabled | disabled | app
+  # github_app:      # Used when github is "app"
+  #   app_id: "123456"
+  #   private_key: ~/.config/enclaude/github-app.pem
+  #   repositories: [octo/widgets]  # Repos a token may be minted for
+  #   permissions:                  # Default: contents: write
+  #     contents: write
   gcloud: auto       # auto | enabled | disabled
+  bitbucket: auto    # auto | enabled | disabled
+  azdo: auto         # auto | enabled | disabled (Azure DevOps)
   ssh:
     enabled: false   # Explicit opt-in for SSH
     keys: []         # Specific keys to mount (read-only)
@@ -175,6 +321,14 @@ credentials:
       # - ~/.ssh/id_ed25519.pub
     known_hosts: true       # Include ~/.ssh/known_hosts
     agent_forwarding: true  # Forward SSH_AUTH_SOCK
+  gpg:
+    enabled: false          # Explicit opt-in for GPG signing
+    agent_forwarding: true  # Forward the restricted gpg-agent extra socket
+  extra_files: []    # Other tool credentials, mounted read-only
+    # - source: ~/.terraformrc
+    # - source: ~/.cargo/credentials.toml
+    #   target: ~/.cargo/credentials.toml  # "~/" is the container HOME (default: same place)
+  # ttl: 30m         # Revoke external credentials after this duration (optional)
 
 # Environment variables to pass through
 environment:
@@ -182,20 +336,121 @@ environment:
     - TERM
     - COLORTERM
     - EDITOR
+    # - LC_*          # Patterns pass every match except host session variables and secrets
   custom: {}
     # DEBUG: "false"
 
 # Container settings
 container:
-  user: auto          # auto | uid:gid
-  memory_limit: 4g
-  network: bridge     # bridge | none | host
+  engine: docker      # docker | containerd (runs sessions through nerdctl)
+  namespace: default  # containerd namespace; k8s.io for k3s and Rancher Desktop
+  user: auto          # auto | random | uid:gid
+  preset: ""          # small | medium | large | unlimited (curated memory/cpus/pids/tmpfs)
+  # Individual limits override the preset; without a preset, memory defaults to 4g
+  memory_limit: ""    # e.g. 4g
+  memory_reservation: ""  # Soft limit the kernel reclaims down to before the host swaps, e.g. 2g
+  memory_swappiness: -1   # 0-100, how readily session memory is swapped (-1 = host default; cgroup v1 only)
+  cpus: ""            # e.g. 2 or 1.5
+  idle_after: ""      # Lower the CPU limit after this long without terminal input or output, e.g. 15m (Docker only)
+  idle_cpus: "0.1"    # CPU limit while idle; restored on the next keypress or output
+  pids_limit: 0       # Max processes (0 = preset or no limit)
+  tmpfs_size: ""      # Size of /tmp, /run, /var/tmp, e.g. 1g
+  network: bridge     # bridge | none | host | name of an existing Docker network
+  ports: []          # Published ports, e.g. "8080:8080"
+  stop_grace: 10s     # Time claude gets to save its session on Ctrl+C before the container is stopped (0 = stop at once)
+  ready_check: ""     # Command that succeeds once the session is ready, e.g. "claude --version" (default: the image's ready file)
+  ready_wait: 30s     # How long to wait for ready_check before attaching anyway (0 = attach at once)
+  timezone: auto      # auto (host) | none (UTC) | a zone, e.g. Europe/Berlin
+  locale: auto        # auto (host LANG and LC_*) | none | a locale, e.g. en_US.UTF-8
+  interaction: attach # attach | exec (start claude with docker exec; for proxies that break attach)
+  cgroup:             # Linux only: deprioritize sessions relative to interactive work
+    parent: ""        # e.g. enclaude.slice (systemd driver) or /enclaude (cgroupfs)
+    cpu_weight: 0     # cgroup v2 cpu.weight 1-10000 (default 100); 0 = Docker default
+    io_weight: 0      # cgroup v2 io.weight 1-10000 (default 100); 0 = Docker default
+  extra_docker_args: []   # Other docker run flags, e.g. ["--shm-size=2g", "--ulimit=nofile=65536"]
 
 # Security settings
 security:
   drop_capabilities: true
   no_new_privileges: true
   read_only_root: true
+  workspace_trust: off    # off | prompt | restricted: ask before the first session in a workspace
+  # (restricted runs untrusted workspaces without host credentials)
+  ephemeral: false        # Mount the workspace and everything else read-only; scratch space only in memory
+
+# Shell environment for tools Claude runs
+shell:
+  # bashrc: ~/.config/enclaude/bashrc   # Curated .bashrc (optional)
+  # zshrc: ~/.config/enclaude/zshrc     # Curated .zshrc (optional)
+  persist_history: false  # Keep shell history in a per-project volume
+
+# How the working directory is provided to the container
+workspace:
+  mode: bind              # bind | copy
+  include_ignored: false  # copy mode: also copy .dockerignore/.gitignore matches
+  artifacts: ""           # Host directory mounted read-write at /artifacts, e.g. "artifacts" (relative to the workspace)
+  growth_warning: 2g      # Warn when a session adds more than this to the workspace ("0" disables)
+
+# Host terminal integration
+terminal:
+  hyperlinks: auto        # auto | always | never: make /workspace paths in output clickable
+  link_format: ""         # URL template with {path} {line} {col} {host}; empty for file:// links
+  # link_format: "vscode://file{path}:{line}:{col}"
+
+# Post-processing of piped (non-TTY) output and --tee files, applied in order
+output:
+  filters: []
+    # Example: ["strip-ansi", "host-paths", "cmd:grep --line-buffered -v DEBUG"]
+    # strip-ansi: drop colors and other escape sequences
+    # host-paths: rewrite /workspace paths to the host workspace
+    # highlight-paths: underline file paths
+    # cmd:<command>: pipe output through a host shell command
+
+# Open $EDITOR requests from the container (e.g. git commit) in your host editor
+editor:
+  bridge: false           # Route $EDITOR through the host editor bridge
+  command: ""             # Host editor that waits until closed, e.g. "code --wait" (default: $VISUAL/$EDITOR)
+
+# Let the container run allowlisted host commands through a socket
+host_commands:
+  enabled: false          # Expose the host command bridge (every call is audited)
+  allow: []               # Allowed command lines, "*" for one argument, e.g. ["open *", "pbcopy", "gh auth token"]
+
+# Persistent network other containers can reach sessions on, and host ports
+# sessions can reach
+network:
+  create: ""              # Network to create if absent and join, e.g. enclaude (overrides container.network)
+  alias: claude.local     # The session's DNS name on that network
+  reverse_forward: []     # Host ports reachable at host.enclaude.internal, e.g. [5432] (every connection is audited)
+
+# Check every command Claude runs against a policy before it runs
+shell_policy:
+  enabled: false          # Replace Claude's shell with a checking wrapper (every command is audited)
+  allow: []               # Command names allowed, e.g. ["git", "go", "ls"]; empty allows any not denied
+  deny: []                # Regular expressions of command lines to block, e.g. ["git push .*--force"]
+  block_dangerous: true   # Block curl | sh, rm -rf /, mkfs, dd to disks, and fork bombs
+
+# Let the container ask for host paths that are not mounted
+access_requests:
+  enabled: false          # Approved paths are copied read-only to /mnt/host (every request is audited)
+
+# Release notifications
+updates:
+  check: true             # Print a notice when a newer release is available (checked daily)
+
+# Repositories whose git metadata lives outside the workspace
+git:
+  mount_gitdir: false       # Mount the metadata of linked worktrees and submodule checkouts
+  submodule_sources: false  # Mount local repositories referenced by .gitmodules (read-only)
+
+# Host Unix sockets to forward into the container
+sockets: []
+  # - name: gpg-agent
+  #   source: ${XDG_RUNTIME_DIR}/gnupg/S.gpg-agent.extra
+  #   target: ~/.gnupg/S.gpg-agent     # Default: /run/enclaude/sockets/<name>.sock
+  #   mode: bind                       # bind | proxy (relay and audit each connection)
+  #   env: ""                          # Variable set to the target path (optional)
+  # Container engine sockets such as docker.sock also need --dangerous
 `
 
 		if err := os.WriteFile(configPath, []byte(defaultConfig), 0644); err != nil {
@@ -207,6 +462,41 @@ security:
 	},
 }
 
+var configMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Upgrade configuration file to the current format",
+	Long: `Upgrade an older configuration file to the current schema version.
+
+Deprecated settings are moved to their replacements (for example,
+mounts.claude_dir becomes claude.session_dir) and config_version is updated.
+The original file is kept alongside as a .bak backup. Comments are not
+preserved in the rewritten file.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		configPath := viper.ConfigFileUsed()
+		if configPath == "" {
+			configPath = getConfigPath()
+		}
+		if _, err := os.Stat(configPath); err != nil {
+			return fmt.Errorf("no config file found at %s", configPath)
+		}
+
+		changes, err := config.MigrateFile(configPath)
+		if err != nil {
+			return err
+		}
+		if len(changes) == 0 {
+			fmt.Printf("%s is already at config version %d\n", configPath, config.CurrentConfigVersion)
+			return nil
+		}
+
+		fmt.Printf("Migrated %s (backup at %s.bak):\n", configPath, configPath)
+		for _, change := range changes {
+			fmt.Printf("  - %s\n", change)
+		}
+		return nil
+	},
+}
+
 // printSettingsFlat prints settings in dot notation
 func printSettingsFlat(prefix string, settings map[string]interface{}) {
 	// Collect keys and sort them for consistent output
@@ -231,23 +521,29 @@ func printSettingsFlat(prefix string, settings map[string]interface{}) {
 	}
 }
 
-// getConfigPath returns the default config file path
+// getConfigPath returns the user config file path: the existing file in
+// any format, or config.yaml
 func getConfigPath() string {
 	home, _ := os.UserHomeDir()
-	return filepath.Join(home, ".config", "enclaude", "config.yaml")
+	base := filepath.Join(home, ".config", "enclaude", "config")
+	if file := config.FindConfigFile(base); file != "" {
+		return file
+	}
+	return base + ".yaml"
 }
 
 // validateConfigKey validates key/value pairs for known configuration keys
 func validateConfigKey(key, value string) error {
-	validations := map[string][]string{
-		"claude.auth":        {config.AuthAuto, config.AuthSession, config.AuthAPIKey},
-		"claude.session_dir": {config.SessionNone, config.SessionReadOnly, config.SessionReadWrite},
-		"credentials.github": {config.CredentialAuto, config.CredentialEnabled, config.CredentialDisabled},
-		"credentials.gcloud": {config.CredentialAuto, config.CredentialEnabled, config.CredentialDisabled},
-		"container.network":  {config.NetworkBridge, config.NetworkNone, config.NetworkHost},
+	// Any existing Docker network may be joined, so only the name is checked
+	if key == "container.network" && !config.ValidNetworkName(value) {
+		return fmt.Errorf("invalid value for %s: %s (allowed: %s, %s, %s, or a Docker network name)", key, value, config.NetworkBridge, config.NetworkNone, config.NetworkHost)
+	}
+
+	if key == "mounts.workspace_target" {
+		return config.ValidWorkspaceTarget(value)
 	}
 
-	if allowed, exists := validations[key]; exists {
+	if allowed, exists := config.AllowedValues[key]; exists {
 		for _, v := range allowed {
 			if value == v {
 				return nil
@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/jakenelson/enclaude/internal/config"
+	"github.com/jakenelson/enclaude/internal/security"
+	"github.com/spf13/viper"
+)
+
+// settingLayer is one place a setting can come from
+type settingLayer struct {
+	name  string // default, user, project, or env
+	where string // The file or environment variable, if any
+	value interface{}
+	set   bool
+	held  string // Why a value the project sets does not apply, if it does not
+}
+
+// settingLayers returns where key can be set, lowest precedence first, and
+// what each sets it to. Session flags are not a layer: they only apply to
+// the session they are passed to.
+func settingLayers(key string) []settingLayer {
+	def, ok := config.Default(key)
+	layers := []settingLayer{{name: "default", value: def, set: ok}}
+
+	if cfgFile == "-" {
+		// Settings from stdin are merged over the defaults, as a file's are
+		value, set := config.Setting(stdinConfig, key)
+		layers = append(layers, settingLayer{name: "user", where: "stdin", value: value, set: set})
+	} else {
+		user := viper.ConfigFileUsed()
+		if user == "" {
+			user = getConfigPath()
+		}
+		layers = append(layers, fileLayer("user", user, key))
+	}
+	project := projectFile
+	if project == "" {
+		project = config.ProjectConfigFile
+	}
+	projectLayer := fileLayer("project", project, key)
+	if keyPath := strings.Split(strings.ToLower(key), "."); projectLayer.set && userOnlySetting(keyPath, projectLayer.value) {
+		projectLayer.held = "ignored, since only the user config can set it"
+	} else if projectLayer.set && !projectApproved && !projectSettingAllowed(keyPath) {
+		projectLayer.held = "applied once the workspace is trusted and the file approved"
+	}
+	layers = append(layers, projectLayer)
+
+	// AutomaticEnv upper-cases the key behind the prefix and keeps its dots
+	env := "ENCLAUDE_" + strings.ToUpper(key)
+	value, set := os.LookupEnv(env)
+	layers = append(layers, settingLayer{name: "env", where: env, value: value, set: set})
+	return layers
+}
+
+// fileLayer reads key from a config file, if there is one
+func fileLayer(name, path, key string) settingLayer {
+	layer := settingLayer{name: name, where: path}
+	if !security.FileExists(path) {
+		return layer
+	}
+	value, set, err := config.FileSetting(path, key)
+	if err != nil {
+		return layer
+	}
+	layer.value, layer.set = value, set
+	return layer
+}
+
+// printSettingExplanation shows the effective value of key, the layer it
+// comes from, what every layer sets it to, and the session flag that
+// overrides it
+func printSettingExplanation(w io.Writer, key string) {
+	layers := settingLayers(key)
+	source := "unset"
+	for _, l := range layers {
+		if l.set && l.held == "" {
+			source = l.name
+			if l.where != "" {
+				source += " (" + l.where + ")"
+			}
+		}
+	}
+
+	fmt.Fprintf(w, "%s: %s\n", key, formatSetting(viper.Get(key)))
+	fmt.Fprintf(w, "  source:  %s\n", source)
+	for _, l := range layers {
+		value := "not set"
+		if l.set {
+			value = formatSetting(l.value)
+		}
+		if l.where != "" {
+			value += " (" + l.where + ")"
+		}
+		if l.held != "" {
+			value += ", " + l.held
+		}
+		fmt.Fprintf(w, "  %-8s %s\n", l.name+":", value)
+	}
+	if flag, ok := configFlags[key]; ok {
+		fmt.Fprintf(w, "  flag:    --%s overrides this for the session it is passed to\n", flag)
+	}
+}
+
+// formatSetting renders a setting value, quoting strings so empty ones show
+func formatSetting(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	return fmt.Sprint(value)
+}
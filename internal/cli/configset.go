@@ -0,0 +1,257 @@
+package cli
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jakenelson/enclaude/internal/config"
+	"github.com/jakenelson/enclaude/internal/security"
+)
+
+// setOp is how `config set` applies a value
+type setOp int
+
+const (
+	setReplace setOp = iota
+	setAppend        // key+ value: add to a list
+	setRemove        // key- value: remove from a list
+)
+
+// listItemFields describes config lists whose items are objects rather than
+// strings: the field an item is identified by, and how `config set key+`
+// parses an item from a single argument
+var listItemFields = map[string]struct {
+	id    string
+	parse func(string) (map[string]interface{}, error)
+}{
+	"mounts.defaults": {"path", func(s string) (map[string]interface{}, error) {
+		// ~/shared or ~/shared:ro
+		path, readonly := strings.TrimSuffix(s, ":ro"), strings.HasSuffix(s, ":ro")
+		return map[string]interface{}{"path": path, "readonly": readonly}, nil
+	}},
+	"mounts.volumes": {"name", func(s string) (map[string]interface{}, error) {
+		name, path, ok := strings.Cut(s, ":")
+		if !ok || name == "" || path == "" {
+			return nil, fmt.Errorf("volume must be name:path, got %q", s)
+		}
+		return map[string]interface{}{"name": name, "path": path}, nil
+	}},
+	"mounts.workspaces": {"path", func(s string) (map[string]interface{}, error) {
+		// ../api or api=../api
+		e := config.ParseWorkspaceEntry(s)
+		item := map[string]interface{}{"path": e.Path}
+		if e.Name != "" {
+			item["name"] = e.Name
+		}
+		return item, nil
+	}},
+	"credentials.extra_files": {"source", func(s string) (map[string]interface{}, error) {
+		source, target, _ := strings.Cut(s, ":")
+		item := map[string]interface{}{"source": source}
+		if target != "" {
+			item["target"] = target
+		}
+		return item, nil
+	}},
+	"sockets": {"source", func(s string) (map[string]interface{}, error) {
+		// $SSH_AUTH_SOCK or ~/.gnupg/S.gpg-agent.extra:/home/enclaude/.gnupg/S.gpg-agent
+		source, target, _ := strings.Cut(s, ":")
+		item := map[string]interface{}{"source": source}
+		if target != "" {
+			item["target"] = target
+		}
+		return item, nil
+	}},
+}
+
+// durationKeys hold Go durations stored as strings
+var durationKeys = map[string]bool{
+	"credentials.ttl":      true,
+	"container.stop_grace": true,
+}
+
+// parseSetArgs splits `config set` arguments into the key, operation, and
+// value. Both "key value" and "key=value" are accepted, and a trailing + or -
+// on the key appends to or removes from a list.
+func parseSetArgs(args []string) (string, setOp, string, error) {
+	var key, value string
+	switch len(args) {
+	case 1:
+		var ok bool
+		key, value, ok = strings.Cut(args[0], "=")
+		if !ok {
+			return "", 0, "", fmt.Errorf("missing value; use 'config set %s <value>' or '%s=<value>'", args[0], args[0])
+		}
+	case 2:
+		key, value = args[0], args[1]
+	default:
+		return "", 0, "", fmt.Errorf("expected <key> <value> or <key>=<value>")
+	}
+
+	op := setReplace
+	switch {
+	case strings.HasSuffix(key, "+"):
+		op, key = setAppend, strings.TrimSuffix(key, "+")
+	case strings.HasSuffix(key, "-"):
+		op, key = setRemove, strings.TrimSuffix(key, "-")
+	}
+	if key == "" {
+		return "", 0, "", fmt.Errorf("missing key")
+	}
+	return key, op, value, nil
+}
+
+// parseConfigValue converts value to the type of the key's current value,
+// so numbers and booleans are written as YAML numbers and booleans. Lists
+// are given comma-separated. Keys without a current value keep the
+// historical behavior: true and false are booleans, anything else a string.
+func parseConfigValue(key, value string, current interface{}) (interface{}, error) {
+	if durationKeys[key] {
+		if value != "" {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid duration for %s: %s (e.g. 30m, 2h)", key, value)
+			}
+			if key == "credentials.ttl" && d < time.Second {
+				return nil, fmt.Errorf("%s must be at least 1s, got %s", key, value)
+			}
+		}
+		return value, nil
+	}
+
+	switch current.(type) {
+	case bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s: %s (expected true or false)", key, value)
+		}
+		return b, nil
+	case int, int64:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s: %s (expected a whole number)", key, value)
+		}
+		return n, nil
+	case float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s: %s (expected a number)", key, value)
+		}
+		return f, nil
+	case string:
+		return value, nil
+	}
+
+	if current != nil && reflect.TypeOf(current).Kind() == reflect.Slice {
+		if _, ok := listItemFields[key]; ok {
+			return nil, fmt.Errorf("%s is a list of entries; add them one at a time with 'config set %s+ <value>'", key, key)
+		}
+		items := []interface{}{}
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return items, nil
+	}
+	if _, ok := current.(map[string]interface{}); ok {
+		return nil, fmt.Errorf("%s is a map; set entries with 'config set %s.<name> <value>'", key, key)
+	}
+
+	switch value {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	return value, nil
+}
+
+// updateList returns list with value appended or removed. Items of object
+// lists are matched by their identifying field. changed is false when
+// appending an item already present or removing one that is not.
+func updateList(key string, current interface{}, op setOp, value string) (list []interface{}, changed bool, err error) {
+	if current != nil && reflect.TypeOf(current).Kind() != reflect.Slice {
+		return nil, false, fmt.Errorf("%s is not a list", key)
+	}
+	list = listItems(current)
+
+	fields, isObjects := listItemFields[key]
+	var item interface{} = value
+	id := value
+	if isObjects {
+		parsed, err := fields.parse(value)
+		if err != nil {
+			return nil, false, err
+		}
+		item, id = parsed, fmt.Sprint(parsed[fields.id])
+	}
+
+	index := -1
+	for i, existing := range list {
+		existingID := fmt.Sprint(existing)
+		if m, ok := existing.(map[string]interface{}); ok && isObjects {
+			existingID = fmt.Sprint(m[fields.id])
+		}
+		if existingID == id || (isObjects && sameHostPath(existingID, id)) {
+			index = i
+			break
+		}
+	}
+
+	switch op {
+	case setAppend:
+		if index >= 0 {
+			return list, false, nil
+		}
+		return append(list, item), true, nil
+	case setRemove:
+		if index < 0 {
+			return list, false, nil
+		}
+		return append(list[:index], list[index+1:]...), true, nil
+	}
+	return nil, false, fmt.Errorf("unsupported list operation")
+}
+
+// listItems converts a config list, which may be a typed default such as
+// []MountEntry or a []interface{} read from YAML, into plain values with
+// objects as maps keyed by their mapstructure names
+func listItems(v interface{}) []interface{} {
+	items := []interface{}{}
+	if v == nil {
+		return items
+	}
+	rv := reflect.ValueOf(v)
+	for i := 0; i < rv.Len(); i++ {
+		elem := rv.Index(i)
+		if elem.Kind() == reflect.Interface {
+			elem = elem.Elem()
+		}
+		if elem.Kind() != reflect.Struct {
+			items = append(items, elem.Interface())
+			continue
+		}
+		m := map[string]interface{}{}
+		for f := 0; f < elem.NumField(); f++ {
+			name := elem.Type().Field(f).Tag.Get("mapstructure")
+			if name == "" {
+				name = strings.ToLower(elem.Type().Field(f).Name)
+			}
+			m[name] = elem.Field(f).Interface()
+		}
+		items = append(items, m)
+	}
+	return items
+}
+
+// sameHostPath reports whether two paths name the same host location, so
+// ~/shared matches the /home/user/shared the shell expands it to
+func sameHostPath(a, b string) bool {
+	expandedA, errA := security.ExpandPath(a)
+	expandedB, errB := security.ExpandPath(b)
+	return errA == nil && errB == nil && expandedA == expandedB
+}
@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jakenelson/enclaude/internal/config"
+)
+
+func TestParseSetArgs(t *testing.T) {
+	tests := []struct {
+		args    []string
+		key     string
+		op      setOp
+		value   string
+		wantErr bool
+	}{
+		{args: []string{"container.cpus", "2"}, key: "container.cpus", op: setReplace, value: "2"},
+		{args: []string{"environment.custom.FOO=bar=baz"}, key: "environment.custom.FOO", op: setReplace, value: "bar=baz"},
+		{args: []string{"mounts.defaults+", "~/shared"}, key: "mounts.defaults", op: setAppend, value: "~/shared"},
+		{args: []string{"environment.passthrough-=TERM"}, key: "environment.passthrough", op: setRemove, value: "TERM"},
+		{args: []string{"container.cpus"}, wantErr: true},
+		{args: []string{"+", "x"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		key, op, value, err := parseSetArgs(tt.args)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseSetArgs(%q) error = %v, wantErr %v", tt.args, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && (key != tt.key || op != tt.op || value != tt.value) {
+			t.Errorf("parseSetArgs(%q) = %q, %v, %q, want %q, %v, %q", tt.args, key, op, value, tt.key, tt.op, tt.value)
+		}
+	}
+}
+
+func TestParseConfigValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		value   string
+		current interface{}
+		want    interface{}
+		wantErr bool
+	}{
+		{"int", "container.pids_limit", "512", 0, 512, false},
+		{"bad int", "container.pids_limit", "lots", 0, nil, true},
+		{"bool", "claude.preflight", "true", false, true, false},
+		{"bad bool", "claude.preflight", "yes please", false, nil, true},
+		{"duration", "credentials.ttl", "45m", "", "45m", false},
+		{"bad duration", "credentials.ttl", "45", "", nil, true},
+		{"sub-second ttl", "credentials.ttl", "500ms", "", nil, true},
+		{"sub-second stop grace", "container.stop_grace", "500ms", "", "500ms", false},
+		{"string list", "container.ports", "8080:8080, 5173:5173", []string{}, []interface{}{"8080:8080", "5173:5173"}, false},
+		{"object list", "mounts.defaults", "~/x", []config.MountEntry{}, nil, true},
+		{"map", "environment.custom", "x", map[string]interface{}{}, nil, true},
+		{"unknown bool", "new.key", "false", nil, false, false},
+		{"unknown string", "environment.custom.FOO", "bar", nil, "bar", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseConfigValue(tt.key, tt.value, tt.current)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseConfigValue() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseConfigValue() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateList(t *testing.T) {
+	mounts := []interface{}{map[string]interface{}{"path": "/srv/shared", "readonly": false}}
+
+	tests := []struct {
+		name        string
+		key         string
+		current     interface{}
+		op          setOp
+		value       string
+		want        []interface{}
+		wantChanged bool
+	}{
+		{"append string", "environment.passthrough", []string{"TERM"}, setAppend, "AWS_PROFILE", []interface{}{"TERM", "AWS_PROFILE"}, true},
+		{"append duplicate", "environment.passthrough", []string{"TERM"}, setAppend, "TERM", []interface{}{"TERM"}, false},
+		{"remove string", "environment.passthrough", []interface{}{"TERM", "EDITOR"}, setRemove, "TERM", []interface{}{"EDITOR"}, true},
+		{"remove missing", "environment.passthrough", []interface{}{"TERM"}, setRemove, "EDITOR", []interface{}{"TERM"}, false},
+		{"append to typed default", "mounts.defaults", []config.MountEntry{}, setAppend, "/srv/docs:ro",
+			[]interface{}{map[string]interface{}{"path": "/srv/docs", "readonly": true}}, true},
+		{"remove object", "mounts.defaults", mounts, setRemove, "/srv/shared", []interface{}{}, true},
+		{"append volume", "mounts.volumes", nil, setAppend, "gomod:/var/cache/go",
+			[]interface{}{map[string]interface{}{"name": "gomod", "path": "/var/cache/go"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changed, err := updateList(tt.key, tt.current, tt.op, tt.value)
+			if err != nil {
+				t.Fatalf("updateList() error = %v", err)
+			}
+			if changed != tt.wantChanged || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("updateList() = %v, %v, want %v, %v", got, changed, tt.want, tt.wantChanged)
+			}
+		})
+	}
+
+	if _, _, err := updateList("mounts.volumes", nil, setAppend, "no-path"); err == nil {
+		t.Error("updateList() accepted a volume without a path")
+	}
+	if _, _, err := updateList("container.cpus", "2", setAppend, "x"); err == nil {
+		t.Error("updateList() appended to a scalar")
+	}
+}
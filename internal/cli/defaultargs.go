@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// claudeFlagAliases maps Claude Code's short flags to their long forms, so
+// that -p in one list and --print in the other count as the same flag
+var claudeFlagAliases = map[string]string{
+	"-p": "--print",
+	"-c": "--continue",
+	"-r": "--resume",
+	"-d": "--debug",
+}
+
+// sessionClaudeArgs returns the arguments to run Claude with: the session's
+// own, after claude.default_args unless --no-default-args is given
+func sessionClaudeArgs(cmd *cobra.Command, args []string) []string {
+	if skip, _ := cmd.Flags().GetBool("no-default-args"); skip {
+		return args
+	}
+	return mergeDefaultArgs(cfg.Claude.DefaultArgs, args)
+}
+
+// mergeDefaultArgs returns defaults followed by args. A default flag is
+// dropped along with its values when args give the same flag, so the
+// command line wins over the config instead of passing the flag twice.
+// The values of a flag are the arguments up to the next flag.
+func mergeDefaultArgs(defaults, args []string) []string {
+	given := make(map[string]bool)
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if name := claudeFlagName(arg); name != "" {
+			given[name] = true
+		}
+	}
+
+	merged := make([]string, 0, len(defaults)+len(args))
+	drop := false
+	for i, arg := range defaults {
+		if arg == "--" {
+			// Everything after -- is positional
+			merged = append(merged, defaults[i:]...)
+			break
+		}
+		if name := claudeFlagName(arg); name != "" {
+			drop = given[name]
+		}
+		if !drop {
+			merged = append(merged, arg)
+		}
+	}
+	return append(merged, args...)
+}
+
+// claudeFlagName returns the flag an argument names, in its long form, or
+// "" if it is not a flag
+func claudeFlagName(arg string) string {
+	if len(arg) < 2 || arg[0] != '-' || arg == "--" {
+		return ""
+	}
+	name, _, _ := strings.Cut(arg, "=")
+	if long, ok := claudeFlagAliases[name]; ok {
+		return long
+	}
+	return name
+}
@@ -0,0 +1,33 @@
+package cli
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeDefaultArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		defaults []string
+		args     []string
+		want     []string
+	}{
+		{"no defaults", nil, []string{"--resume"}, []string{"--resume"}},
+		{"no args", []string{"--model", "opus"}, nil, []string{"--model", "opus"}},
+		{"prepended", []string{"--model", "opus"}, []string{"-p", "hi"}, []string{"--model", "opus", "-p", "hi"}},
+		{"flag overridden", []string{"--model", "opus", "--verbose"}, []string{"--model", "sonnet"}, []string{"--verbose", "--model", "sonnet"}},
+		{"equals form", []string{"--model=opus"}, []string{"--model", "sonnet"}, []string{"--model", "sonnet"}},
+		{"equals form in args", []string{"--model", "opus"}, []string{"--model=sonnet"}, []string{"--model=sonnet"}},
+		{"all values dropped", []string{"--allowedTools", "Read", "Edit", "--verbose"}, []string{"--allowedTools", "Bash"}, []string{"--verbose", "--allowedTools", "Bash"}},
+		{"short alias", []string{"--print", "--verbose"}, []string{"-p", "hi"}, []string{"--verbose", "-p", "hi"}},
+		{"positional args ignored", []string{"--model", "opus"}, []string{"--", "--model"}, []string{"--model", "opus", "--", "--model"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mergeDefaultArgs(tt.defaults, tt.args); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mergeDefaultArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
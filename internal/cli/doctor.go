@@ -0,0 +1,212 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/jakenelson/enclaude/internal/config"
+	"github.com/jakenelson/enclaude/internal/container"
+	"github.com/jakenelson/enclaude/internal/nix"
+	"github.com/jakenelson/enclaude/internal/workspace"
+	"github.com/jakenelson/enclaude/internal/wsl"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+	doctorCmd.Flags().StringP("workdir", "w", "", "working directory to check (default: current directory)")
+	doctorCmd.Flags().String("image", "", "Docker image to check (default: enclaude:latest)")
+}
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check for problems that would break a session",
+	Long: `Check Docker, the image, and the workspace for problems that would make
+a session or the commands Claude runs fail, and suggest fixes.
+
+Examples:
+  enclaude doctor
+  enclaude doctor -w ~/projects/myapp`,
+	Args: cobra.NoArgs,
+	RunE: runDoctor,
+}
+
+// doctorResult is the outcome of a single check
+type doctorResult struct {
+	ok     bool
+	status string
+	hint   string
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	workDir, err := resolveWorkDir(cmd)
+	if err != nil {
+		return err
+	}
+	imageName, _ := cmd.Flags().GetString("image")
+	if imageName == "" {
+		imageName = cfg.Image.Name
+	}
+
+	var results []doctorResult
+	var dockerRunner *container.Runner
+	runner, err := container.NewRunner()
+	if err != nil {
+		results = append(results, doctorResult{status: "Docker is not reachable: " + err.Error(), hint: "Start Docker and try again."})
+	} else {
+		defer runner.Close()
+		results = append(results, doctorResult{ok: true, status: "Docker is reachable"})
+		dockerRunner = runner
+		if network := cfg.Container.Network; !config.IsBuiltinNetwork(network) {
+			if err := runner.CheckNetwork(ctx, network); err != nil {
+				results = append(results, doctorResult{status: err.Error(), hint: "Start the stack that owns it or set container.network."})
+			} else {
+				results = append(results, doctorResult{ok: true, status: "Network " + network + " exists"})
+			}
+		}
+		if exists, err := runner.ImageExists(ctx, imageName); err != nil || !exists {
+			results = append(results, doctorResult{status: "Image " + imageName + " not found", hint: "Run 'enclaude build' or set image.name."})
+			runner = nil
+		} else {
+			results = append(results, doctorResult{ok: true, status: "Image " + imageName + " is available"})
+		}
+	}
+
+	if kind, ok := nix.Detect(workDir); ok {
+		results = append(results, nixCheck(kind, func() (map[string]string, error) {
+			if runner == nil {
+				return nil, fmt.Errorf("image unavailable")
+			}
+			return runner.ImageLabels(ctx, imageName)
+		}))
+	}
+
+	results = append(results, wslChecks(wsl.Detect(), workDir, func() (bool, error) {
+		if dockerRunner == nil {
+			return false, fmt.Errorf("docker unavailable")
+		}
+		return dockerRunner.DockerDesktop(ctx)
+	})...)
+
+	if runtime.GOOS == "darwin" {
+		home, _ := os.UserHomeDir()
+		sharing, known := container.DesktopFileSharing(home)
+		results = append(results, fileSharingCheck(sharing, known, func() (bool, error) {
+			if dockerRunner == nil {
+				return false, fmt.Errorf("docker unavailable")
+			}
+			return dockerRunner.DockerDesktop(ctx)
+		})...)
+	}
+
+	target, err := config.ResolveWorkspaceTarget(cfg.Mounts.WorkspaceTarget, workDir)
+	if err != nil {
+		results = append(results, doctorResult{status: err.Error(), hint: "Set mounts.workspace_target to an absolute path or \"host\"."})
+		target = config.WorkspaceTargetDefault
+	}
+	info, err := workspace.InspectGit(workDir, target)
+	if err != nil {
+		results = append(results, doctorResult{status: "Could not inspect git repository: " + err.Error()})
+	} else if info.IsRepo {
+		hasLFS := func() (bool, error) {
+			if runner == nil {
+				return false, fmt.Errorf("image unavailable")
+			}
+			return runner.HasCommand(ctx, imageName, "git-lfs")
+		}
+		results = append(results, gitChecks(info, hasLFS)...)
+	}
+
+	problems := 0
+	for _, r := range results {
+		if r.ok {
+			fmt.Printf("✅ %s\n", r.status)
+			continue
+		}
+		problems++
+		fmt.Printf("❌ %s\n", r.status)
+		if r.hint != "" {
+			fmt.Printf("   %s\n", r.hint)
+		}
+	}
+	if problems > 0 {
+		return fmt.Errorf("doctor found %d problem(s)", problems)
+	}
+	return nil
+}
+
+// nixCheck reports whether the image realizes the Nix environment of kind
+// declared in the workspace
+func nixCheck(kind string, labels func() (map[string]string, error)) doctorResult {
+	l, err := labels()
+	if err != nil {
+		return doctorResult{status: "Workspace declares a Nix " + kind + " environment, but the image could not be checked: " + err.Error()}
+	}
+	if l[nix.Label] != kind {
+		return doctorResult{
+			status: "Workspace declares a Nix " + kind + " environment the image does not include",
+			hint:   "Run 'enclaude build --nix' to build an image with it and pin it to this workspace.",
+		}
+	}
+	return doctorResult{ok: true, status: "Image includes the workspace's Nix " + kind + " environment"}
+}
+
+// gitChecks flags git operations that would fail in the container: LFS files
+// without git-lfs in the image, repository metadata outside the workspace,
+// and submodules whose local source repositories are not mounted
+func gitChecks(info workspace.GitInfo, hasLFS func() (bool, error)) []doctorResult {
+	var results []doctorResult
+
+	if info.UsesLFS {
+		switch ok, err := hasLFS(); {
+		case err != nil:
+			results = append(results, doctorResult{status: "Repository uses Git LFS, but the image could not be checked: " + err.Error()})
+		case !ok:
+			results = append(results, doctorResult{
+				status: "Repository uses Git LFS, but git-lfs is not installed in the image",
+				hint:   "Rebuild with 'enclaude build' or add git-lfs to your custom Dockerfile.",
+			})
+		default:
+			results = append(results, doctorResult{ok: true, status: "Git LFS is available in the image"})
+		}
+	}
+
+	if info.GitDir != nil {
+		switch {
+		case info.GitDir.Worktree != "":
+			results = append(results, doctorResult{
+				status: "Git expects this repository's work tree at " + info.GitDir.Worktree + " in the container",
+				hint:   "This is a submodule checkout; run enclaude from the superproject instead.",
+			})
+		case !cfg.Git.MountGitDir:
+			results = append(results, doctorResult{
+				status: "Git metadata at " + info.GitDir.Source + " is outside the workspace and not mounted",
+				hint:   "Set git.mount_gitdir: true so git commands work in this worktree.",
+			})
+		default:
+			results = append(results, doctorResult{ok: true, status: "Git metadata outside the workspace is mounted"})
+		}
+	}
+
+	for _, sm := range info.Submodules {
+		if sm.Local == nil {
+			continue
+		}
+		if cfg.Git.SubmoduleSources {
+			results = append(results, doctorResult{ok: true, status: "Submodule " + sm.Path + " source " + sm.Local.Source + " is mounted"})
+			continue
+		}
+		if !sm.Initialized {
+			results = append(results, doctorResult{
+				status: "Submodule " + sm.Path + " cannot be updated: its source " + sm.Local.Source + " is not mounted",
+				hint:   "Set git.submodule_sources: true, or run 'git submodule update --init --recursive' on the host first.",
+			})
+		}
+	}
+
+	return results
+}
@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jakenelson/enclaude/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// checkEphemeral refuses settings that would have an ephemeral session
+// write to the host's disk, or that cannot work without doing so, and says
+// what the session can write
+func checkEphemeral(cmd *cobra.Command) error {
+	if cfg.Workspace.Mode == config.WorkspaceCopy {
+		return fmt.Errorf("security.ephemeral cannot be combined with workspace.mode %q, which keeps a writable copy of the workspace on disk", config.WorkspaceCopy)
+	}
+	if fast, _ := cmd.Flags().GetBool("fast-fs"); fast {
+		return fmt.Errorf("security.ephemeral cannot be combined with --fast-fs, which copies changes back to the workspace")
+	}
+	if cfg.Claude.SessionDir == config.SessionVolume && cfg.Claude.Auth != config.AuthAPIKey {
+		return fmt.Errorf("security.ephemeral cannot be combined with claude.session_dir volume, since the login volume would be replaced by an empty tmpfs; use readonly or none")
+	}
+	if cfg.Workspace.Artifacts != "" {
+		return fmt.Errorf("security.ephemeral cannot be combined with workspace.artifacts, which keeps what the session writes on disk")
+	}
+	if cfg.Credentials.TTL != "" {
+		return fmt.Errorf("security.ephemeral cannot be combined with credentials.ttl, which revokes credentials by rewriting them in a writable mount")
+	}
+	fmt.Fprintln(os.Stderr, "Ephemeral session: the workspace and every mount are read-only; scratch space is in memory and discarded when the session ends")
+	return nil
+}
@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/jakenelson/enclaude/internal/config"
+	"github.com/jakenelson/enclaude/internal/container"
+	"github.com/spf13/cobra"
+)
+
+// slowFileSharing reports whether sharing is one of Docker Desktop's file
+// sharing implementations that make bind mounts slow
+func slowFileSharing(sharing string) bool {
+	return sharing == container.FileSharingGRPCFUSE || sharing == container.FileSharingOSXFS
+}
+
+// startFastFS moves the workspace of a --fast-fs session into a per-project
+// volume. The returned function copies the volume back to the host once the
+// session has ended. Without --fast-fs it warns when Docker Desktop shares
+// the workspace through a slow implementation.
+func startFastFS(ctx context.Context, cmd *cobra.Command, runner sessionRunner, opts *container.RunOptions) (func(), error) {
+	fast, _ := cmd.Flags().GetBool("fast-fs")
+	dockerRunner, isDocker := runner.(*container.Runner)
+	if !fast {
+		if isDocker && cfg.Workspace.Mode != config.WorkspaceCopy {
+			warnSlowFileSharing(ctx, dockerRunner)
+		}
+		return func() {}, nil
+	}
+	if !isDocker {
+		return nil, fmt.Errorf("--fast-fs is only supported with the docker engine")
+	}
+	if cfg.Workspace.Mode == config.WorkspaceCopy {
+		return nil, fmt.Errorf("--fast-fs cannot be combined with workspace.mode %q", config.WorkspaceCopy)
+	}
+
+	volume := container.ProjectVolumeName("fastfs", opts.HostWorkDir)
+	hostDir, err := container.UseFastFS(opts, volume)
+	if err != nil {
+		return nil, err
+	}
+	if err := dockerRunner.PrepareFastFS(ctx, *opts, volume); err != nil {
+		return nil, fmt.Errorf("failed to prepare the workspace volume %s: %w", volume, err)
+	}
+
+	// Changes an earlier session could not copy back, because the sync
+	// failed or enclaude was killed, go back before the volume is mirrored
+	// over
+	switch err := dockerRunner.SyncFastFS(ctx, *opts, volume, hostDir); {
+	case errors.Is(err, container.ErrFastFSNotFilled):
+	case err != nil:
+		return nil, fmt.Errorf("an earlier --fast-fs session's changes were not copied back: %w", err)
+	default:
+		fmt.Fprintf(os.Stderr, "Copied back the changes an earlier session left in volume %s\n", volume)
+	}
+	fmt.Fprintf(os.Stderr, "Workspace mirrored into volume %s; changes are copied back to %s when the session ends, not while it runs\n", volume, hostDir)
+
+	return func() {
+		// The session context is canceled by now
+		if err := dockerRunner.SyncFastFS(context.Background(), *opts, volume, hostDir); err != nil && !errors.Is(err, container.ErrFastFSNotFilled) {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}, nil
+}
+
+// warnSlowFileSharing suggests VirtioFS or --fast-fs when Docker Desktop on
+// macOS shares the workspace through gRPC FUSE or osxfs
+func warnSlowFileSharing(ctx context.Context, runner *container.Runner) {
+	if runtime.GOOS != "darwin" {
+		return
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return
+	}
+	sharing, ok := container.DesktopFileSharing(home)
+	if !ok || !slowFileSharing(sharing) {
+		return
+	}
+	if desktop, err := runner.DockerDesktop(ctx); err != nil || !desktop {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Warning: Docker Desktop shares the workspace through %s, which makes file-heavy work slow. Switch to VirtioFS (Settings > General) or use --fast-fs.\n", sharing)
+}
+
+// fileSharingCheck reports on the file sharing implementation Docker Desktop
+// is configured with, for doctor. It returns nothing when Docker Desktop's
+// settings were not found or Docker is not Docker Desktop.
+func fileSharingCheck(sharing string, known bool, desktop func() (bool, error)) []doctorResult {
+	if !known {
+		return nil
+	}
+	if isDesktop, err := desktop(); err != nil || !isDesktop {
+		return nil
+	}
+	if slowFileSharing(sharing) {
+		return []doctorResult{{
+			status: "Docker Desktop shares files through " + sharing + ", which makes workspace I/O slow",
+			hint:   "Switch to VirtioFS in Docker Desktop (Settings > General), or run sessions with --fast-fs.",
+		}}
+	}
+	return []doctorResult{{ok: true, status: "Docker Desktop shares files through " + sharing}}
+}
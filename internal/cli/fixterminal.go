@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/jakenelson/enclaude/internal/terminal"
+	"github.com/moby/term"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(fixTerminalCmd)
+}
+
+var fixTerminalCmd = &cobra.Command{
+	Use:   "fix-terminal",
+	Short: "Restore a terminal left in raw mode by a session",
+	Long: `Restore the terminal after a session ended so abruptly that enclaude could
+not, for example when enclaude itself was killed with SIGKILL. Typed text is
+not echoed, Enter may not work, or the cursor stays hidden.
+
+The terminal's line settings are reset with 'stty sane', and the cursor,
+mouse reporting, bracketed paste, and keyboard modes Claude may have turned
+on are switched off. Input is not echoed while the terminal is broken, so
+type the command blind; if Enter does nothing, press Ctrl+J instead.
+
+Example:
+  enclaude fix-terminal`,
+	Args: cobra.NoArgs,
+	RunE: runFixTerminal,
+}
+
+func runFixTerminal(cmd *cobra.Command, args []string) error {
+	if !term.IsTerminal(os.Stdin.Fd()) {
+		return fmt.Errorf("standard input is not a terminal")
+	}
+	fmt.Fprint(os.Stdout, terminal.ResetModes)
+
+	stty := exec.Command("stty", "sane")
+	stty.Stdin, stty.Stdout, stty.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := stty.Run(); err != nil {
+		return fmt.Errorf("failed to reset terminal settings with stty: %w", err)
+	}
+	fmt.Println("Terminal restored.")
+	return nil
+}
@@ -0,0 +1,177 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/jakenelson/enclaude/internal/container"
+	"github.com/jakenelson/enclaude/internal/state"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(gcCmd)
+	gcCmd.Flags().Bool("dry-run", false, "list stale sessions without removing them")
+}
+
+var gcCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Remove containers left behind by crashed sessions",
+	Long: `Remove session containers whose enclaude process is gone, and prune old
+run history.
+
+A session is stale when it was started on this host and its enclaude process
+no longer exists, or has not recorded a heartbeat for 5 minutes. This happens
+when enclaude is killed with kill -9 or the terminal crashes before it can
+clean up. Stale sessions are also reaped each time a new session starts.
+
+Sessions started from other hosts sharing the same Docker daemon are never
+removed, and neither are sessions detached with Ctrl+\ d.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		runner, err := container.NewRunner()
+		if err != nil {
+			return fmt.Errorf("failed to create container runner: %w", err)
+		}
+		defer runner.Close()
+
+		stale, err := staleSessions(cmd.Context(), runner)
+		if err != nil {
+			return err
+		}
+		if len(stale) == 0 {
+			fmt.Println("No stale sessions.")
+		}
+		for _, s := range stale {
+			if dryRun {
+				fmt.Printf("Would remove %s (%s): %s\n", s.session.Name, s.session.Workspace, s.reason)
+				continue
+			}
+			if err := reapSession(runner, s); err != nil {
+				fmt.Fprintf(os.Stderr, "❌ %s: %v\n", s.session.Name, err)
+				continue
+			}
+			fmt.Printf("✅ Removed %s (%s): %s\n", s.session.Name, s.session.Workspace, s.reason)
+		}
+
+		if !dryRun {
+			if dir, err := state.Dir(); err == nil {
+				if err := state.GC(dir, state.DefaultRunRetention); err != nil {
+					return fmt.Errorf("failed to clean up run history: %w", err)
+				}
+			}
+		}
+		return nil
+	},
+}
+
+// staleSession is a session container whose owning process is gone
+type staleSession struct {
+	session container.Session
+	reason  string
+}
+
+// staleSessions returns the session containers on this host whose enclaude
+// process has died or stopped sending heartbeats
+func staleSessions(ctx context.Context, runner *container.Runner) ([]staleSession, error) {
+	sessions, err := runner.AllSessions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine hostname: %w", err)
+	}
+	dir, _ := state.Dir()
+	lastHeartbeat := func(runID string) (time.Time, bool) {
+		if dir == "" || runID == "" {
+			return time.Time{}, false
+		}
+		return state.LastHeartbeat(dir, runID)
+	}
+
+	var stale []staleSession
+	for _, s := range sessions {
+		// Sessions detached from the session menu outlive their process
+		if dir != "" && s.RunID != "" {
+			if run, err := state.LoadRun(dir, s.RunID); err == nil && run.Detached {
+				continue
+			}
+		}
+		if reason, ok := sessionStale(s, host, processAlive, lastHeartbeat, time.Now()); ok {
+			stale = append(stale, staleSession{session: s, reason: reason})
+		}
+	}
+	return stale, nil
+}
+
+// sessionStale reports whether a session's owning process is gone, and why.
+// Sessions from other hosts, or from versions of enclaude that did not record
+// their owner, are never stale: there is no way to tell from here.
+func sessionStale(s container.Session, host string, alive func(int) bool, lastHeartbeat func(string) (time.Time, bool), now time.Time) (string, bool) {
+	if s.PID == 0 || s.Host != host {
+		return "", false
+	}
+	if !alive(s.PID) {
+		return fmt.Sprintf("process %d exited", s.PID), true
+	}
+	// The PID may have been reused by an unrelated process
+	if last, ok := lastHeartbeat(s.RunID); ok && now.Sub(last) > state.HeartbeatTimeout {
+		return fmt.Sprintf("no heartbeat since %s", last.Local().Format("15:04:05")), true
+	}
+	return "", false
+}
+
+// processAlive reports whether a process with the given PID exists
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// reapSession removes a stale session's container and marks its run record
+// as abandoned
+func reapSession(runner *container.Runner, s staleSession) error {
+	if err := runner.Remove(s.session.ID); err != nil {
+		return fmt.Errorf("failed to remove container: %w", err)
+	}
+
+	dir, err := state.Dir()
+	if err != nil || s.session.RunID == "" {
+		return nil
+	}
+	state.RemoveHeartbeat(dir, s.session.RunID)
+	run, err := state.LoadRun(dir, s.session.RunID)
+	if err != nil || !run.Ended.IsZero() {
+		return nil
+	}
+	run.Ended = time.Now()
+	run.Error = "session abandoned; container reaped: " + s.reason
+	if err := state.SaveRun(dir, run); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record run: %v\n", err)
+	}
+	audit(dir, state.AuditEvent{Event: "session_reaped", RunID: run.ID, Details: map[string]interface{}{"container": s.session.ID, "reason": s.reason}})
+	return nil
+}
+
+// reapStaleSessions removes containers left behind by crashed sessions before
+// a new one starts. Failures only warn.
+func reapStaleSessions(ctx context.Context, runner *container.Runner) {
+	stale, err := staleSessions(ctx, runner)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to check for stale sessions: %v\n", err)
+		return
+	}
+	for _, s := range stale {
+		if err := reapSession(runner, s); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to remove stale session %s: %v\n", s.session.Name, err)
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "Removed stale session %s (%s)\n", s.session.Name, s.reason)
+	}
+}
@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jakenelson/enclaude/internal/container"
+)
+
+func TestSessionStale(t *testing.T) {
+	now := time.Now()
+	beats := map[string]time.Time{
+		"fresh": now.Add(-time.Minute),
+		"old":   now.Add(-time.Hour),
+	}
+	lastHeartbeat := func(runID string) (time.Time, bool) {
+		last, ok := beats[runID]
+		return last, ok
+	}
+	alive := func(pid int) bool { return pid == 100 }
+
+	tests := []struct {
+		name    string
+		session container.Session
+		want    bool
+	}{
+		{"live process", container.Session{PID: 100, Host: "here", RunID: "fresh"}, false},
+		{"dead process", container.Session{PID: 200, Host: "here", RunID: "fresh"}, true},
+		{"dead process without run record", container.Session{PID: 200, Host: "here"}, true},
+		{"reused PID with stale heartbeat", container.Session{PID: 100, Host: "here", RunID: "old"}, true},
+		{"live process without heartbeat", container.Session{PID: 100, Host: "here", RunID: "missing"}, false},
+		{"other host", container.Session{PID: 200, Host: "elsewhere", RunID: "old"}, false},
+		{"unknown owner", container.Session{Host: "here"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, got := sessionStale(tt.session, "here", alive, lastHeartbeat, now)
+			if got != tt.want {
+				t.Errorf("sessionStale() = %v (%q), want %v", got, reason, tt.want)
+			}
+		})
+	}
+}
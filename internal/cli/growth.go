@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/docker/go-units"
+	"github.com/jakenelson/enclaude/internal/container"
+	"github.com/jakenelson/enclaude/internal/workspace"
+)
+
+// growthPaths is how many of the largest new paths a growth warning lists
+const growthPaths = 5
+
+// startGrowthCheck measures the workspace before a session so that, once it
+// ends, the returned function can warn when the session added more than
+// workspace.growth_warning to it. Call it immediately before the container
+// runs.
+func startGrowthCheck(opts container.RunOptions) func() {
+	if cfg.Workspace.GrowthWarning == "" || cfg.Workspace.GrowthWarning == "0" {
+		return func() {}
+	}
+	threshold, err := units.RAMInBytes(cfg.Workspace.GrowthWarning)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: invalid workspace.growth_warning %q: %v\n", cfg.Workspace.GrowthWarning, err)
+		return func() {}
+	}
+	root := workspaceSource(opts)
+	if root == "" {
+		return func() {}
+	}
+	before, err := workspace.MeasureUsage(root)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: workspace growth will not be checked: %v\n", err)
+		return func() {}
+	}
+
+	return func() {
+		after, err := workspace.MeasureUsage(root)
+		if err != nil {
+			return
+		}
+		if g := before.Growth(after, growthPaths); g.Bytes > threshold {
+			printGrowth(os.Stderr, g, cfg.Workspace.GrowthWarning)
+		}
+	}
+}
+
+// printGrowth warns about a session that grew the workspace past threshold
+func printGrowth(w io.Writer, g workspace.Growth, threshold string) {
+	fmt.Fprintf(w, "\nWarning: the session added %s to the workspace (%d files), more than workspace.growth_warning (%s). Largest new paths:\n",
+		units.BytesSize(float64(g.Bytes)), g.Files, threshold)
+	for _, p := range g.Largest {
+		path := p.Path
+		if p.Dir {
+			path += string(os.PathSeparator)
+			fmt.Fprintf(w, "  %10s  %s (%d files)\n", units.BytesSize(float64(p.Bytes)), path, p.Files)
+			continue
+		}
+		fmt.Fprintf(w, "  %10s  %s\n", units.BytesSize(float64(p.Bytes)), path)
+	}
+}
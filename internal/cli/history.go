@@ -0,0 +1,188 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jakenelson/enclaude/internal/config"
+	"github.com/jakenelson/enclaude/internal/container"
+	"github.com/jakenelson/enclaude/internal/credentials"
+	"github.com/jakenelson/enclaude/internal/state"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+	historyCmd.Flags().IntP("limit", "n", 20, "number of runs to show (0 for all)")
+}
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show recent sessions",
+	Long: `Show recently started sessions, most recent first.
+
+Run records and the audit log live in the enclaude state directory,
+$XDG_STATE_HOME/enclaude (default ~/.local/state/enclaude). Records older
+than 30 days are removed automatically.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, err := state.Dir()
+		if err != nil {
+			return err
+		}
+		runs, err := state.Runs(dir)
+		if err != nil {
+			return fmt.Errorf("failed to read run history: %w", err)
+		}
+		if len(runs) == 0 {
+			fmt.Println("No sessions recorded.")
+			return nil
+		}
+
+		limit, _ := cmd.Flags().GetInt("limit")
+		if limit > 0 && len(runs) > limit {
+			runs = runs[:limit]
+		}
+		for _, run := range runs {
+			fmt.Printf("%s  %-8s %s  %s\n", run.Started.Local().Format("2006-01-02 15:04"), runStatus(run), run.Workspace, run.Image)
+		}
+		return nil
+	},
+}
+
+// runStatus summarizes how a recorded run ended
+func runStatus(run state.Run) string {
+	switch {
+	case run.Detached:
+		return "detached"
+	case run.Ended.IsZero():
+		return "running"
+	case strings.HasPrefix(run.Error, "session abandoned"):
+		return "reaped"
+	case run.Error != "":
+		return "failed"
+	default:
+		return run.Ended.Sub(run.Started).Round(time.Second).String()
+	}
+}
+
+// recordRun assigns opts a run ID, records the run in the state directory,
+// and audits which credentials it exposes. The returned function records how
+// the run ended. Failures only warn, so state problems never block a session.
+func recordRun(opts *container.RunOptions) func(error) {
+	dir, err := state.Dir()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: run history disabled: %v\n", err)
+		return func(error) {}
+	}
+	if err := state.GC(dir, state.DefaultRunRetention); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to clean up run history: %v\n", err)
+	}
+
+	run := state.Run{
+		ID:        state.NewRunID(),
+		Workspace: opts.HostWorkDir,
+		Image:     opts.Image,
+		Args:      opts.ClaudeArgs,
+		Started:   time.Now(),
+		HostUID:   opts.HostUID,
+	}
+	if cfg.Container.User == config.UserRandom {
+		run.User = opts.User
+	}
+	opts.RunID = run.ID
+	if err := state.SaveRun(dir, run); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record run: %v\n", err)
+	}
+	audit(dir, state.AuditEvent{Event: "session_start", RunID: run.ID, Details: sessionAuditDetails(*opts)})
+
+	// Let 'enclaude gc' tell a live session from one whose process hung
+	stop, stopped := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(state.HeartbeatInterval)
+		defer ticker.Stop()
+		failing := false // Warn once per run of failures, not every tick
+		for {
+			if err := state.Heartbeat(dir, run.ID); err != nil {
+				if !failing {
+					fmt.Fprintf(os.Stderr, "Warning: failed to record heartbeat: %v\n", err)
+				}
+				failing = true
+			} else {
+				failing = false
+			}
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return func(runErr error) {
+		close(stop)
+		<-stopped
+		state.RemoveHeartbeat(dir, run.ID)
+		var detached *container.DetachedError
+		switch {
+		case errors.As(runErr, &detached):
+			run.Detached = true
+		case runErr != nil:
+			run.Error = runErr.Error()
+		}
+		run.Ended = time.Now()
+		if err := state.SaveRun(dir, run); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to record run: %v\n", err)
+		}
+		audit(dir, state.AuditEvent{Event: "session_end", RunID: run.ID, Details: map[string]interface{}{"error": run.Error}})
+	}
+}
+
+// sessionAuditDetails lists what a session can reach on the host: mounts,
+// read-only references, and the names (never values) of secret environment
+// variables
+func sessionAuditDetails(opts container.RunOptions) map[string]interface{} {
+	var mounts, refs, secrets []string
+	for _, m := range opts.Mounts {
+		if strings.HasPrefix(m.Target, config.RefsTarget+"/") {
+			refs = append(refs, fmt.Sprintf("%s:%s", m.Source, m.Target))
+			continue
+		}
+		mode := "rw"
+		if m.ReadOnly {
+			mode = "ro"
+		}
+		mounts = append(mounts, fmt.Sprintf("%s:%s:%s", m.Source, m.Target, mode))
+	}
+	for key := range opts.Environment {
+		if credentials.IsSecretEnv(key) {
+			secrets = append(secrets, key)
+		}
+	}
+	sort.Strings(secrets)
+
+	details := map[string]interface{}{
+		"workspace":     opts.HostWorkDir,
+		"image":         opts.Image,
+		"network":       opts.Network,
+		"mounts":        mounts,
+		"refs":          refs,
+		"secret_env":    secrets,
+		"blocked_hosts": opts.BlockedHosts,
+	}
+	if opts.HostUID != 0 {
+		details["host_uid"] = opts.HostUID
+	}
+	return details
+}
+
+func audit(dir string, event state.AuditEvent) {
+	if err := state.AppendAudit(dir, event); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to write audit log: %v\n", err)
+	}
+}
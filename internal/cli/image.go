@@ -0,0 +1,228 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/jakenelson/enclaude/internal/container"
+	"github.com/klauspost/compress/zstd"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(imageCmd)
+	rootCmd.AddCommand(inspectImageCmd)
+	imageCmd.AddCommand(imageExportCmd)
+	imageCmd.AddCommand(imageImportCmd)
+
+	imageExportCmd.Flags().StringP("output", "o", "enclaude-image.tar.zst", "archive to write, or - for stdout")
+	imageExportCmd.Flags().String("level", "default", "compression level: fastest, default, better, best")
+}
+
+var imageCmd = &cobra.Command{
+	Use:   "image",
+	Short: "Move images between hosts without a registry, and lock a workspace's image",
+	Long: `Export images to a zstd-compressed archive and import them on another
+host, for air-gapped or low-bandwidth machines without registry access.
+Lock a workspace to the exact content of an image with 'image lock'.
+
+Examples:
+  enclaude image export -o enclaude.tar.zst
+  enclaude image import enclaude.tar.zst
+
+  # Stream straight to another host
+  enclaude image export -o - | ssh build-host enclaude image import -
+
+  # Pin the workspace to the configured image
+  enclaude image lock`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var imageExportCmd = &cobra.Command{
+	Use:   "export [image...]",
+	Short: "Export images to a compressed archive",
+	Long: `Export images (default: the configured image) to a zstd-compressed
+docker save archive. Higher compression levels produce smaller archives at
+the cost of export time.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		images := args
+		if len(images) == 0 {
+			images = []string{cfg.Image.Name}
+		}
+		output, _ := cmd.Flags().GetString("output")
+		levelName, _ := cmd.Flags().GetString("level")
+		level, err := compressionLevel(levelName)
+		if err != nil {
+			return err
+		}
+
+		runner, err := container.NewRunner()
+		if err != nil {
+			return fmt.Errorf("failed to create container runner: %w", err)
+		}
+		defer runner.Close()
+
+		for _, image := range images {
+			exists, err := runner.ImageExists(ctx, image)
+			if err != nil {
+				return fmt.Errorf("failed to check image: %w", err)
+			}
+			if !exists {
+				return fmt.Errorf("image %s not found locally", image)
+			}
+		}
+
+		var w io.Writer = os.Stdout
+		if output != "-" {
+			f, err := os.Create(output)
+			if err != nil {
+				return fmt.Errorf("failed to create archive: %w", err)
+			}
+			defer f.Close()
+			w = f
+		}
+
+		if err := runner.ExportImage(ctx, images, w, level); err != nil {
+			if output != "-" {
+				os.Remove(output)
+			}
+			return err
+		}
+
+		if output != "-" {
+			size := "unknown size"
+			if info, err := os.Stat(output); err == nil {
+				size = units.HumanSize(float64(info.Size()))
+			}
+			fmt.Fprintf(os.Stderr, "✅ Exported %d image(s) to %s (%s)\n", len(images), output, size)
+		}
+		return nil
+	},
+}
+
+var imageImportCmd = &cobra.Command{
+	Use:   "import <archive>",
+	Short: "Import images from an archive",
+	Long: `Import images from an archive written by 'enclaude image export', or from
+- for stdin. Plain and gzip-compressed docker save archives also work.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+
+		var r io.Reader = os.Stdin
+		if args[0] != "-" {
+			f, err := os.Open(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to open archive: %w", err)
+			}
+			defer f.Close()
+			r = f
+		}
+
+		runner, err := container.NewRunner()
+		if err != nil {
+			return fmt.Errorf("failed to create container runner: %w", err)
+		}
+		defer runner.Close()
+
+		return runner.ImportImage(ctx, r, os.Stdout)
+	},
+}
+
+// compressionLevel maps a level name to a zstd encoder level
+func compressionLevel(name string) (zstd.EncoderLevel, error) {
+	switch name {
+	case "fastest":
+		return zstd.SpeedFastest, nil
+	case "", "default":
+		return zstd.SpeedDefault, nil
+	case "better":
+		return zstd.SpeedBetterCompression, nil
+	case "best":
+		return zstd.SpeedBestCompression, nil
+	default:
+		return 0, fmt.Errorf("invalid compression level %q (allowed: fastest, default, better, best)", name)
+	}
+}
+
+var inspectImageCmd = &cobra.Command{
+	Use:   "inspect-image [image]",
+	Short: "Show the provenance and SBOM attestations of an image",
+	Long: `Show the SLSA provenance and SBOM attestations attached to an image by
+'enclaude build --attest' (default: the configured image): the builder, when
+it was built, the base images it was built from, and how many packages the
+SBOM lists.
+
+Attestations live in the registry next to the image, so the image must have
+been pushed (enclaude build --attest --push) and this needs the docker CLI
+with buildx.
+
+Examples:
+  enclaude inspect-image ghcr.io/acme/enclaude:latest`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		image := cfg.Image.Name
+		if len(args) > 0 {
+			image = args[0]
+		}
+
+		runner, err := container.NewRunner()
+		if err != nil {
+			return fmt.Errorf("failed to create container runner: %w", err)
+		}
+		defer runner.Close()
+
+		attestations, err := runner.InspectAttestations(context.Background(), image)
+		if err != nil {
+			return err
+		}
+		if len(attestations) == 0 {
+			return fmt.Errorf("no attestations found for %s; build it with 'enclaude build --attest --push'", image)
+		}
+
+		fmt.Printf("Image %s\n", image)
+		for _, a := range attestations {
+			indent := "  "
+			if a.Platform != "" {
+				fmt.Printf("\n%s:\n", a.Platform)
+				indent = "    "
+			}
+			printAttestations(a, indent)
+		}
+		return nil
+	},
+}
+
+// printAttestations prints the attestations of one platform
+func printAttestations(a container.Attestations, indent string) {
+	if p := a.Provenance; p != nil {
+		fmt.Printf("%s✅ Provenance (SLSA)\n", indent)
+		fmt.Printf("%s  Builder:    %s\n", indent, p.BuilderID)
+		fmt.Printf("%s  Build type: %s\n", indent, p.BuildType)
+		if !p.Finished.IsZero() {
+			fmt.Printf("%s  Built:      %s (took %s)\n", indent, p.Finished.Local().Format("2006-01-02 15:04:05"), p.Finished.Sub(p.Started).Round(time.Second))
+		}
+		if len(p.Materials) > 0 {
+			fmt.Printf("%s  Materials:\n", indent)
+			for _, m := range p.Materials {
+				fmt.Printf("%s    %s %s\n", indent, m.URI, m.Digest)
+			}
+		}
+	} else {
+		fmt.Printf("%s❌ No provenance\n", indent)
+	}
+
+	if a.SBOM != nil {
+		fmt.Printf("%s✅ SBOM (SPDX): %d packages\n", indent, a.SBOM.Packages)
+	} else {
+		fmt.Printf("%s❌ No SBOM\n", indent)
+	}
+}
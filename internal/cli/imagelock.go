@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/jakenelson/enclaude/internal/config"
+	"github.com/jakenelson/enclaude/internal/container"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	imageCmd.AddCommand(imageLockCmd)
+	imageLockCmd.Flags().StringP("workdir", "w", "", "workspace to write the lock into (default: current directory)")
+	imageLockCmd.Flags().Bool("check", false, "compare the image with the lock instead of writing it")
+}
+
+var imageLockCmd = &cobra.Command{
+	Use:   "lock [image]",
+	Short: "Pin the workspace to the exact content of an image",
+	Long: `Record the content digest of a local image (default: the configured
+image) in enclaude.lock in the workspace. Commit the file so every team
+member runs the same sandbox: sessions in the workspace compare their image
+with the lock before starting, warning when it differs, or failing with
+--strict.
+
+The lock records the image's digest and the digests of its filesystem
+layers, so a rebuild that changes anything is caught, even under the same
+tag. Rerun this command after an intended image change, and commit the new
+lock.
+
+Examples:
+  enclaude image lock
+  enclaude image lock ghcr.io/acme/enclaude:1.4
+  enclaude image lock --check`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		if cfg.Container.Engine == config.EngineContainerd {
+			return fmt.Errorf("enclaude image lock is not supported with the containerd engine")
+		}
+		image := cfg.Image.Name
+		if len(args) > 0 {
+			image = args[0]
+		}
+		workDir, err := resolveWorkDir(cmd)
+		if err != nil {
+			return err
+		}
+		path := filepath.Join(workDir, container.LockFile)
+
+		runner, err := container.NewRunner()
+		if err != nil {
+			return fmt.Errorf("failed to create container runner: %w", err)
+		}
+		defer runner.Close()
+		got, err := runner.LockImage(ctx, image)
+		if err != nil {
+			return err
+		}
+
+		if check, _ := cmd.Flags().GetBool("check"); check {
+			lock, err := container.ReadImageLock(path)
+			if err != nil {
+				return err
+			}
+			if diff := lock.Diff(got); diff != "" {
+				return fmt.Errorf("%s: %s", path, diff)
+			}
+			fmt.Printf("✅ %s matches %s\n", image, path)
+			return nil
+		}
+
+		if err := container.WriteImageLock(path, got); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		fmt.Printf("✅ Locked %s to %s in %s\n", image, got.ID, path)
+		if got.RepoDigest == "" {
+			fmt.Println("   The image was built locally, so teammates need the same build; push it to a registry to share it.")
+		}
+		return nil
+	},
+}
+
+// checkImageLock compares the session's image with the workspace's
+// enclaude.lock before the session starts. A mismatch fails the session
+// with --strict and warns otherwise. Workspaces without a lock are not
+// checked.
+func checkImageLock(ctx context.Context, cmd *cobra.Command, opts container.RunOptions) error {
+	if opts.HostWorkDir == "" {
+		return nil
+	}
+	path := filepath.Join(opts.HostWorkDir, container.LockFile)
+	if _, err := os.Stat(path); err != nil {
+		return nil
+	}
+	strict, _ := cmd.Flags().GetBool("strict")
+
+	lock, err := container.ReadImageLock(path)
+	if err != nil {
+		return imageLockMismatch(strict, err)
+	}
+	if cfg.Container.Engine == config.EngineContainerd {
+		return imageLockMismatch(strict, fmt.Errorf("%s is not checked with the containerd engine", path))
+	}
+	runner, err := container.NewRunner()
+	if err != nil {
+		return imageLockMismatch(strict, err)
+	}
+	defer runner.Close()
+	got, err := runner.LockImage(ctx, opts.Image)
+	if err != nil {
+		return imageLockMismatch(strict, err)
+	}
+	diff := lock.Diff(got)
+	if diff == "" {
+		return nil
+	}
+
+	mismatch := imageLockMismatch(strict, fmt.Errorf("%s: %s", path, diff))
+	if lock.RepoDigest != "" {
+		fmt.Fprintf(os.Stderr, "The locked image is %s; run with --image %s to use it.\n", lock.RepoDigest, lock.RepoDigest)
+	}
+	fmt.Fprintln(os.Stderr, "If the image change is intended, update the lock with 'enclaude image lock' and commit it.")
+	return mismatch
+}
+
+// imageLockMismatch fails with err under --strict, and only warns otherwise
+func imageLockMismatch(strict bool, err error) error {
+	if strict {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	return nil
+}
@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/jakenelson/enclaude/internal/config"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(initCmd)
+
+	initCmd.Flags().String("template", "", "project template: "+strings.Join(templateNames(), ", "))
+	initCmd.Flags().Bool("force", false, "overwrite an existing project config file")
+	initCmd.MarkFlagRequired("template")
+}
+
+var initCmd = &cobra.Command{
+	Use:   "init --template <name>",
+	Short: "Scaffold a project sandbox definition",
+	Long: `Write a tailored ` + config.ProjectConfigFile + ` into the current directory.
+
+The project config is merged over your user config whenever enclaude runs in
+this directory, so a team can commit one file to share the sandbox definition:
+image variant, cache volumes, and published ports.
+
+Templates:
+  go-service     Go toolchain image with module and build caches
+  node-app       Node.js with an npm cache and dev server port
+  data-science   Python with a pip cache and Jupyter port
+
+Examples:
+  enclaude init --template go-service
+  enclaude init --template node-app --force`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name, _ := cmd.Flags().GetString("template")
+		force, _ := cmd.Flags().GetBool("force")
+
+		content, ok := projectTemplates[name]
+		if !ok {
+			return fmt.Errorf("unknown template %q (available: %s)", name, strings.Join(templateNames(), ", "))
+		}
+
+		if existing := config.FindProjectConfig(); existing != "" && !force {
+			return fmt.Errorf("%s already exists; use --force to overwrite", existing)
+		}
+
+		if err := os.WriteFile(config.ProjectConfigFile, []byte(content), 0644); err != nil {
+			return fmt.Errorf("failed to write project config: %w", err)
+		}
+
+		fmt.Printf("Created %s from the %s template\n", config.ProjectConfigFile, name)
+		return nil
+	},
+}
+
+// templateNames returns the sorted list of available project templates
+func templateNames() []string {
+	names := make([]string, 0, len(projectTemplates))
+	for name := range projectTemplates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// projectTemplates maps template names to project config file content.
+// Cache volumes target directories under /var/cache/enclaude, which the
+// enclaude images create world-writable so new volumes are usable by the
+// non-root container user.
+var projectTemplates = map[string]string{
+	"go-service": `# Enclaude project configuration (go-service template)
+# Merged over your user config when enclaude runs in this directory
+
+image:
+  # Build with: enclaude build -f docker/examples/Dockerfile.golang -t enclaude-golang:latest
+  name: enclaude-golang:latest
+
+mounts:
+  volumes:
+    - name: enclaude-go-mod
+      path: /var/cache/enclaude/go-mod
+    - name: enclaude-go-build
+      path: /var/cache/enclaude/go-build
+
+environment:
+  custom:
+    GOMODCACHE: /var/cache/enclaude/go-mod
+    GOCACHE: /var/cache/enclaude/go-build
+
+container:
+  ports:
+    - "8080:8080"
+`,
+	"node-app": `# Enclaude project configuration (node-app template)
+# Merged over your user config when enclaude runs in this directory
+
+image:
+  name: enclaude:latest
+
+mounts:
+  volumes:
+    - name: enclaude-npm
+      path: /var/cache/enclaude/npm
+
+environment:
+  custom:
+    npm_config_cache: /var/cache/enclaude/npm
+
+container:
+  memory_limit: 4g
+  ports:
+    - "3000:3000"
+`,
+	"data-science": `# Enclaude project configuration (data-science template)
+# Merged over your user config when enclaude runs in this directory
+
+image:
+  name: enclaude:latest
+
+mounts:
+  volumes:
+    - name: enclaude-pip
+      path: /var/cache/enclaude/pip
+
+environment:
+  custom:
+    PIP_CACHE_DIR: /var/cache/enclaude/pip
+
+container:
+  memory_limit: 8g
+  ports:
+    - "8888:8888"   # Jupyter
+`,
+}
@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jakenelson/enclaude/internal/config"
+	"github.com/spf13/viper"
+)
+
+func TestProjectTemplates(t *testing.T) {
+	for name, content := range projectTemplates {
+		t.Run(name, func(t *testing.T) {
+			v := viper.New()
+			v.SetConfigType("yaml")
+			if err := v.ReadConfig(strings.NewReader(content)); err != nil {
+				t.Fatalf("template %s is not valid YAML: %v", name, err)
+			}
+
+			var cfg config.Config
+			if err := v.Unmarshal(&cfg); err != nil {
+				t.Fatalf("template %s does not match config schema: %v", name, err)
+			}
+
+			if cfg.Image.Name == "" {
+				t.Errorf("template %s should set image.name", name)
+			}
+			for _, vol := range cfg.Mounts.Volumes {
+				if vol.Name == "" || !strings.HasPrefix(vol.Path, "/var/cache/enclaude/") {
+					t.Errorf("template %s has invalid volume %+v", name, vol)
+				}
+			}
+		})
+	}
+}
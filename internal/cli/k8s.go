@@ -0,0 +1,229 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/jakenelson/enclaude/internal/container"
+	"github.com/jakenelson/enclaude/internal/kube"
+	"github.com/jakenelson/enclaude/internal/security"
+	"github.com/jakenelson/enclaude/internal/state"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(k8sCmd)
+	k8sCmd.AddCommand(k8sExecCmd)
+
+	k8sExecCmd.Flags().String("pod", "", "Pod to run Claude in (required)")
+	k8sExecCmd.Flags().StringP("namespace", "n", "", "Namespace of the pod (default: the context's)")
+	k8sExecCmd.Flags().StringP("container", "c", "", "Container in the pod (default: the pod's default container)")
+	k8sExecCmd.Flags().String("context", "", "kubeconfig context to use (default: current)")
+	k8sExecCmd.Flags().Bool("debug", false, "Run in an ephemeral debug container that shares the pod's processes")
+	k8sExecCmd.Flags().String("debug-image", "", "Image for --debug (default: image.name; must be pullable by the cluster)")
+	k8sExecCmd.Flags().String("claude-binary", "", "Linux claude binary to copy in when the container has none")
+	k8sExecCmd.Flags().Bool("no-default-args", false, "Do not put claude.default_args before the Claude arguments")
+	k8sExecCmd.Flags().String("pod-workdir", "", "Directory in the pod to start Claude in")
+	k8sExecCmd.Flags().StringP("workdir", "w", "", "Host workspace whose project config and credentials apply (default: current directory)")
+	k8sExecCmd.Flags().Bool("no-external-credentials", false, "Disable external credential passthrough")
+	k8sExecCmd.MarkFlagRequired("pod")
+}
+
+var k8sCmd = &cobra.Command{
+	Use:   "k8s",
+	Short: "Run Claude in Kubernetes pods",
+}
+
+var k8sExecCmd = &cobra.Command{
+	Use:   "exec --pod <name> [-- claude-args...]",
+	Short: "Run Claude inside an existing dev pod",
+	Long: `Run Claude inside an existing pod with kubectl, for development environments
+that already live in a cluster. No resources are created: Claude runs in the
+pod's container, or with --debug in an ephemeral debug container that shares
+the pod's processes and sees its filesystem under /proc/1/root.
+
+If claude is not installed in the container, --claude-binary copies a Linux
+build in for the session. A --debug container uses the debug image's claude,
+so it should be an enclaude image pushed to a registry the cluster can pull.
+
+enclaude's environment and credential plumbing applies as for local
+sessions of the host workspace in --workdir: Claude authentication, external
+credentials, passthrough and custom environment variables, locale, and the
+telemetry opt-out. --pod-workdir sets where Claude starts in the pod.
+Variables are sent over kubectl exec's stdin rather than on command lines,
+and credential files are copied in and removed when the session ends: those
+a variable points at into a private session directory under /tmp, others
+into the pod user's HOME, refusing to replace a file the pod already has.
+Directories and sockets, such as ~/.claude or an SSH agent, cannot be copied
+and are skipped with a warning; use an API key or a token in the pod instead.
+
+The pod keeps its own network and security context, so enclaude's network
+policy, firewall and container hardening do not apply. The pod cannot reach
+the host's API proxy, so claude.secretless with an API key is refused.
+Sessions are recorded in the audit log.
+
+Examples:
+  enclaude k8s exec --pod dev-0
+  enclaude k8s exec --pod dev-0 -n team -c app --pod-workdir /src
+  enclaude k8s exec --pod dev-0 --claude-binary ~/Downloads/claude-linux-x64
+  enclaude k8s exec --pod dev-0 --debug --debug-image registry.example.com/enclaude:latest
+  enclaude k8s exec --pod dev-0 -- --resume`,
+	RunE: runK8sExec,
+}
+
+func runK8sExec(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	var target kube.Target
+	target.Pod, _ = cmd.Flags().GetString("pod")
+	target.Namespace, _ = cmd.Flags().GetString("namespace")
+	target.Container, _ = cmd.Flags().GetString("container")
+	target.Context, _ = cmd.Flags().GetString("context")
+
+	session, cleanup, err := k8sSession(cmd, args)
+	defer cleanup()
+	if err != nil {
+		return err
+	}
+
+	k, err := kube.NewKubectl(ctx, target)
+	if err != nil {
+		return err
+	}
+
+	if dir, err := state.Dir(); err == nil {
+		envNames := make([]string, 0, len(session.Env))
+		for name := range session.Env {
+			envNames = append(envNames, name)
+		}
+		sort.Strings(envNames)
+		files := make([]string, 0, len(session.Files))
+		for _, f := range session.Files {
+			files = append(files, f.Target)
+		}
+		audit(dir, state.AuditEvent{Event: "k8s_exec", Details: map[string]interface{}{
+			"pod":       target.Pod,
+			"namespace": target.Namespace,
+			"container": target.Container,
+			"context":   target.Context,
+			"debug":     session.Debug,
+			"env":       envNames,
+			"files":     files,
+		}})
+	}
+
+	if err := k.Run(ctx, session); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return &exitCodeError{code: exitErr.ExitCode(), err: fmt.Errorf("claude exited with status %d", exitErr.ExitCode())}
+		}
+		return err
+	}
+	return nil
+}
+
+// k8sSession builds the pod session from flags and config, collecting
+// credentials as for a local session. Credential files are converted to
+// copies; anything that cannot be copied is skipped with a warning. The
+// returned cleanup function removes any credential staging on the host.
+func k8sSession(cmd *cobra.Command, args []string) (kube.Session, func(), error) {
+	s := kube.Session{ClaudeArgs: sessionClaudeArgs(cmd, args)}
+	s.Debug, _ = cmd.Flags().GetBool("debug")
+	s.Image, _ = cmd.Flags().GetString("debug-image")
+	if s.Image == "" {
+		s.Image = cfg.Image.Name
+	}
+	s.ClaudeBinary, _ = cmd.Flags().GetString("claude-binary")
+	s.WorkDir, _ = cmd.Flags().GetString("pod-workdir")
+
+	// The host workspace, resolved as for local sessions
+	workDir, err := resolveWorkDir(cmd)
+	if err != nil {
+		return s, func() {}, err
+	}
+
+	// Time-boxed credentials are staged in a directory, which cannot be
+	// copied, so the pod gets them directly
+	if cfg.Credentials.TTL != "" {
+		fmt.Fprintln(os.Stderr, "Warning: credentials.ttl does not apply in pods; credentials are removed when the session ends")
+		ttl := cfg.Credentials.TTL
+		cfg.Credentials.TTL = ""
+		defer func() { cfg.Credentials.TTL = ttl }()
+	}
+
+	mounts, credEnv, cleanup, err := collectCredentials(cmd, workDir, false)
+	if err != nil {
+		return s, cleanup, err
+	}
+
+	env := localeEnv()
+	passthrough, err := security.PassthroughEnv(cfg.Environment.Passthrough, os.Environ())
+	if err != nil {
+		return s, cleanup, err
+	}
+	for k, v := range passthrough {
+		env[k] = v
+	}
+	for k, v := range cfg.Environment.Custom {
+		env[k] = v
+	}
+	for k, v := range credEnv {
+		env[k] = v
+	}
+	if cfg.Claude.DisableTelemetry {
+		for k, v := range container.TelemetryEnv {
+			env[k] = v
+		}
+	}
+
+	skipped := make(map[string]bool)
+	files := make(map[string]int) // Index in s.Files by container path
+	for _, m := range mounts {
+		info, err := os.Stat(m.Source)
+		if m.Volume || err != nil || !info.Mode().IsRegular() {
+			fmt.Fprintf(os.Stderr, "Warning: %s cannot be copied into a pod; skipping\n", m.Target)
+			skipped[m.Target] = true
+			continue
+		}
+		files[m.Target] = len(s.Files)
+		s.Files = append(s.Files, kube.File{Source: m.Source, Target: podPath(m.Target)})
+	}
+
+	// Files a variable points at are staged in the session directory rather
+	// than the pod user's HOME, other paths follow their files to the HOME,
+	// and variables pointing at skipped mounts are dropped
+	for k, v := range env {
+		if skipped[v] {
+			delete(env, k)
+			continue
+		}
+		if i, ok := files[v]; ok {
+			s.Files[i].Env = append(s.Files[i].Env, k)
+			delete(env, k)
+			continue
+		}
+		env[k] = podPath(v)
+	}
+
+	// The pod cannot reach a proxy on this host, so the key would have to
+	// go in
+	if err := checkSecretless(env); err != nil {
+		return s, cleanup, fmt.Errorf("%w; secretless mode is not supported in pods, so use session auth or leave claude.secretless unset", err)
+	}
+	s.Env = env
+	return s, cleanup, nil
+}
+
+// podPath maps a path under the container home to one under the pod user's
+// HOME, written "~/..."
+func podPath(p string) string {
+	if rest, ok := strings.CutPrefix(p, container.Home+"/"); ok {
+		return "~/" + rest
+	}
+	return p
+}
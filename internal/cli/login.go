@@ -0,0 +1,284 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/jakenelson/enclaude/internal/config"
+	"github.com/jakenelson/enclaude/internal/container"
+	"github.com/jakenelson/enclaude/internal/credentials"
+	"github.com/jakenelson/enclaude/internal/security"
+	"github.com/jakenelson/enclaude/internal/state"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(loginCmd)
+	loginCmd.Flags().String("image", "", "Docker image to use (default: enclaude:latest)")
+}
+
+var loginCmd = &cobra.Command{
+	Use:   "login",
+	Short: "Log in to Claude inside a container, keeping the login in a Docker volume",
+	Long: `Run Claude's browser login inside a container and keep the resulting
+credentials in the enclaude-claude-home Docker volume, so sessions never need
+your host ~/.claude. Sessions use the login when claude.session_dir is volume.
+
+Claude starts in the container as usual; choose to log in with your Claude
+account, or run /login if the volume already holds a login. The login page
+opens in your host browser, and its localhost callback is forwarded into the
+container, so the login completes without copying codes around. If no
+browser can be opened, the URL is printed instead. Exit Claude with /exit
+once you are logged in.
+
+Remove the login with 'docker volume rm enclaude-claude-home'.`,
+	Args: cobra.NoArgs,
+	RunE: runLogin,
+}
+
+// loginHelperPath is where the directory holding the login browser helper
+// is mounted in the container
+const loginHelperPath = "/run/enclaude/login"
+
+// loginBrowser is the browser claude opens the login page with in the
+// container. It hands the URL to the host, which opens it and forwards the
+// callback port.
+const loginBrowser = `#!/bin/sh
+printf '%s\n' "$1" >> ` + loginHelperPath + `/urls
+`
+
+// loginPollInterval is how often the host checks for login URLs
+const loginPollInterval = 250 * time.Millisecond
+
+func runLogin(cmd *cobra.Command, args []string) error {
+	if cfg.Container.Engine == config.EngineContainerd {
+		return fmt.Errorf("enclaude login is not supported with the containerd engine")
+	}
+	if cfg.Container.Network == config.NetworkNone {
+		return fmt.Errorf("enclaude login needs network access, but container.network is none")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigCh
+		cancel()
+	}()
+
+	image := sessionImage(cmd)
+	if err := startImagePull(ctx, image).wait(ctx, image); err != nil {
+		return err
+	}
+
+	helperDir, err := os.MkdirTemp("", "enclaude-login-")
+	if err != nil {
+		return fmt.Errorf("failed to create login helper directory: %w", err)
+	}
+	defer os.RemoveAll(helperDir)
+	if err := os.WriteFile(filepath.Join(helperDir, "xdg-open"), []byte(loginBrowser), 0755); err != nil {
+		return fmt.Errorf("failed to write login helper: %w", err)
+	}
+
+	// The login volume, mounted as sessions with claude.session_dir volume
+	// mount it
+	loginCfg := *cfg
+	loginCfg.Claude.Auth = config.AuthSession
+	loginCfg.Claude.SessionDir = config.SessionVolume
+	mounts, env := credentials.CollectClaudeAuth(&loginCfg)
+	mounts = append(mounts, container.Mount{Source: helperDir, Target: loginHelperPath, Kind: container.MountDir})
+
+	for key, val := range localeEnv() {
+		env[key] = val
+	}
+	// Proxy settings and the like; secrets have no place in a login session
+	passthrough, err := security.PassthroughEnv(cfg.Environment.Passthrough, os.Environ())
+	if err != nil {
+		return err
+	}
+	for key, val := range passthrough {
+		if !credentials.IsSecretEnv(key) {
+			env[key] = val
+		}
+	}
+	env["BROWSER"] = loginHelperPath + "/xdg-open"
+	env["ENCLAUDE_HOST_BIN"] = loginHelperPath
+
+	opts := container.RunOptions{
+		Image:       image,
+		Mounts:      mounts,
+		Environment: env,
+		WorkDir:     config.WorkspaceTargetDefault,
+		User:        cfg.Container.User,
+		Network:     cfg.Container.Network,
+		Interaction: cfg.Container.Interaction,
+		RunID:       state.NewRunID(),
+		Security: container.SecurityOptions{
+			DropCapabilities: cfg.Security.DropCapabilities,
+			NoNewPrivileges:  cfg.Security.NoNewPrivileges,
+			ReadOnlyRoot:     cfg.Security.ReadOnlyRoot,
+			CACerts:          caCertPaths(),
+		},
+	}
+	if err := container.CheckMounts(opts.Mounts); err != nil {
+		return err
+	}
+
+	runner, err := container.NewRunner()
+	if err != nil {
+		return fmt.Errorf("failed to create container runner: %w", err)
+	}
+	defer runner.Close()
+	if err := pickRandomUser(ctx, runner, &opts); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(os.Stderr, "Starting Claude to log in. Choose to log in with your Claude account, or run /login; exit with /exit when done.")
+
+	watchCtx, stopWatch := context.WithCancel(ctx)
+	watched := make(chan struct{})
+	go func() {
+		watchLoginURLs(watchCtx, runner, opts.RunID, filepath.Join(helperDir, "urls"))
+		close(watched)
+	}()
+	err = runner.Run(ctx, cancel, opts)
+	stopWatch()
+	<-watched
+
+	var detached *container.DetachedError
+	if errors.As(err, &detached) {
+		fmt.Fprint(os.Stderr, detachedHint("the login session", detached.ContainerID))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "The login is kept in the %s volume.\n", container.ClaudeHomeVolume)
+	if cfg.Claude.SessionDir != config.SessionVolume {
+		fmt.Fprintln(os.Stderr, "Use it for sessions with 'enclaude config set claude.session_dir volume'.")
+	}
+	return nil
+}
+
+// watchLoginURLs opens the login URLs claude hands to the browser helper on
+// the host, forwarding each localhost callback port into the login container,
+// until ctx is done
+func watchLoginURLs(ctx context.Context, runner *container.Runner, runID, path string) {
+	var listeners []net.Listener
+	defer func() {
+		for _, l := range listeners {
+			l.Close()
+		}
+	}()
+	forwarded := make(map[int]bool)
+
+	var offset int
+	ticker := time.NewTicker(loginPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		data, err := os.ReadFile(path)
+		if err != nil || len(data) <= offset {
+			continue
+		}
+		end := bytes.LastIndexByte(data, '\n')
+		if end < offset {
+			continue
+		}
+		lines := strings.Split(string(data[offset:end]), "\n")
+		offset = end + 1
+
+		for _, authURL := range lines {
+			// Only web pages are opened; the URL comes from the container
+			if u, err := url.Parse(authURL); err != nil || u.Scheme != "https" {
+				continue
+			}
+			if port := callbackPort(authURL); port != 0 && !forwarded[port] {
+				if l, err := forwardLoginPort(ctx, runner, runID, port); err != nil {
+					fmt.Fprintf(os.Stderr, "\r\nWarning: %v; paste the code from the browser into Claude instead\r\n", err)
+				} else {
+					listeners = append(listeners, l)
+					forwarded[port] = true
+				}
+			}
+			if err := openBrowser(authURL); err != nil {
+				fmt.Fprintf(os.Stderr, "\r\nOpen this URL to log in: %s\r\n", authURL)
+			} else {
+				fmt.Fprintf(os.Stderr, "\r\nOpened the login page in your browser\r\n")
+			}
+		}
+	}
+}
+
+// forwardLoginPort forwards port on the host to the login container
+func forwardLoginPort(ctx context.Context, runner *container.Runner, runID string, port int) (net.Listener, error) {
+	sessions, err := runner.ListSessions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range sessions {
+		if s.RunID == runID {
+			return runner.ForwardPort(ctx, s.ID, port)
+		}
+	}
+	return nil, fmt.Errorf("login container not found")
+}
+
+// callbackPort returns the localhost port an OAuth authorization URL
+// redirects to, or 0 if it redirects anywhere else
+func callbackPort(authURL string) int {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		return 0
+	}
+	redirect, err := url.Parse(u.Query().Get("redirect_uri"))
+	if err != nil || redirect.Scheme != "http" {
+		return 0
+	}
+	if host := redirect.Hostname(); host != "localhost" && host != "127.0.0.1" {
+		return 0
+	}
+	port, err := strconv.Atoi(redirect.Port())
+	if err != nil || port <= 0 || port > 65535 {
+		return 0
+	}
+	return port
+}
+
+// openBrowser opens a URL in the host's default browser
+func openBrowser(u string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", u)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", u)
+	default:
+		cmd = exec.Command("xdg-open", u)
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go cmd.Wait()
+	return nil
+}
@@ -0,0 +1,26 @@
+package cli
+
+import "testing"
+
+func TestCallbackPort(t *testing.T) {
+	tests := []struct {
+		name    string
+		authURL string
+		want    int
+	}{
+		{"localhost callback", "https://claude.ai/oauth/authorize?client_id=x&redirect_uri=http%3A%2F%2Flocalhost%3A54545%2Fcallback&state=y", 54545},
+		{"loopback address", "https://claude.ai/oauth/authorize?redirect_uri=http%3A%2F%2F127.0.0.1%3A8080%2Fcallback", 8080},
+		{"hosted callback", "https://claude.ai/oauth/authorize?redirect_uri=https%3A%2F%2Fconsole.anthropic.com%2Foauth%2Fcode%2Fcallback", 0},
+		{"other host", "https://claude.ai/oauth/authorize?redirect_uri=http%3A%2F%2Fexample.com%3A8080%2Fcallback", 0},
+		{"no port", "https://claude.ai/oauth/authorize?redirect_uri=http%3A%2F%2Flocalhost%2Fcallback", 0},
+		{"no redirect", "https://claude.ai/oauth/authorize", 0},
+		{"not a URL", "%zz", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := callbackPort(tt.authURL); got != tt.want {
+				t.Errorf("callbackPort() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/jakenelson/enclaude/internal/container"
+	"github.com/jakenelson/enclaude/internal/state"
+	"github.com/jakenelson/enclaude/internal/workspace"
+)
+
+// trackedMount is the host side of a credential mount as the session found
+// it
+type trackedMount struct {
+	mount    container.Mount
+	hash     string             // Read-only mounts and read-write files
+	snapshot workspace.Snapshot // Read-write directories
+}
+
+// trackMounts records the host side of credential and CA certificate mounts
+// when a session starts, auditing a content hash of each read-only one. The
+// returned function, called once the session has ended, audits whether the
+// read-only ones still match and what changed in the read-write ones, such
+// as a readwrite Claude session directory, and reports those changes.
+func trackMounts(opts *container.RunOptions) func() {
+	dir, err := state.Dir()
+	if err != nil {
+		return func() {}
+	}
+
+	mounts := make([]container.Mount, 0, len(opts.Security.CACerts))
+	for _, m := range opts.Mounts {
+		if m.Credential && !m.Volume {
+			mounts = append(mounts, m)
+		}
+	}
+	for _, cert := range opts.Security.CACerts {
+		mounts = append(mounts, container.Mount{Source: cert, Target: cert, ReadOnly: true})
+	}
+
+	var tracked []trackedMount
+	hashes := make(map[string]string)
+	for _, m := range mounts {
+		info, err := os.Stat(m.Source)
+		if err != nil || !(info.IsDir() || info.Mode().IsRegular()) {
+			// Sockets such as the SSH agent have no contents to track
+			continue
+		}
+		t := trackedMount{mount: m}
+		if m.ReadOnly || !info.IsDir() {
+			if t.hash, err = workspace.HashTree(m.Source); err != nil {
+				// Large directories, such as a long-used ~/.claude, are
+				// left out quietly
+				if !errors.Is(err, workspace.ErrTreeTooLarge) {
+					fmt.Fprintf(os.Stderr, "Warning: not tracking %s: %v\n", m.Source, err)
+				}
+				continue
+			}
+			if m.ReadOnly {
+				hashes[m.Source] = t.hash
+			}
+		} else if t.snapshot, err = workspace.TakeSnapshot(m.Source); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: not tracking %s: %v\n", m.Source, err)
+			continue
+		}
+		tracked = append(tracked, t)
+	}
+	if len(tracked) == 0 {
+		return func() {}
+	}
+	if len(hashes) > 0 {
+		audit(dir, state.AuditEvent{Event: "mount_hashes", RunID: opts.RunID, Details: map[string]interface{}{"mounts": hashes}})
+	}
+
+	return func() {
+		var verified int
+		var changed []string
+		for _, t := range tracked {
+			m := t.mount
+			switch {
+			case m.ReadOnly:
+				if hash, err := workspace.HashTree(m.Source); err == nil && hash == t.hash {
+					verified++
+				} else {
+					changed = append(changed, m.Source)
+				}
+			case t.snapshot != nil:
+				after, err := workspace.TakeSnapshot(m.Source)
+				if err != nil {
+					continue
+				}
+				c := t.snapshot.Diff(after)
+				if c.Count() == 0 {
+					continue
+				}
+				reportMountChanges(dir, opts.RunID, m.Source, c)
+			default:
+				if hash, err := workspace.HashTree(m.Source); err != nil || hash != t.hash {
+					reportMountChanges(dir, opts.RunID, m.Source, workspace.Changes{Modified: []string{"."}})
+				}
+			}
+		}
+		if len(hashes) > 0 {
+			sort.Strings(changed)
+			audit(dir, state.AuditEvent{Event: "mount_verify", RunID: opts.RunID, Details: map[string]interface{}{
+				"verified": verified,
+				"changed":  changed,
+			}})
+		}
+	}
+}
+
+// reportMountChanges prints and audits what changed on the host in a
+// read-write credential mount during a session
+func reportMountChanges(dir, runID, source string, c workspace.Changes) {
+	fmt.Fprintf(os.Stderr, "Changed in %s during the session: %d added, %d modified, %d removed\n", source, len(c.Added), len(c.Modified), len(c.Removed))
+	audit(dir, state.AuditEvent{Event: "mount_changes", RunID: runID, Details: map[string]interface{}{
+		"source":   source,
+		"added":    c.Added,
+		"modified": c.Modified,
+		"removed":  c.Removed,
+	}})
+}
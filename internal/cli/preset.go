@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(presetCmd)
+
+	presetCmd.Flags().StringP("workdir", "w", "", "working directory to mount (default: current directory)")
+	presetCmd.Flags().String("image", "", "Docker image to use (default: enclaude:latest)")
+	presetCmd.Flags().Bool("no-default-args", false, "Do not put claude.default_args before the Claude arguments")
+	presetCmd.Flags().Bool("no-external-credentials", false, "Disable external credential passthrough (GitHub, GCloud, Bitbucket, Azure DevOps, SSH)")
+}
+
+var presetCmd = &cobra.Command{
+	Use:   "preset [name] [-- extra-claude-args...]",
+	Short: "Run Claude with a named argument preset",
+	Long: `Run Claude Code with a named argument list from claude.arg_presets. Presets
+can be defined in your user config or shared through a project's
+.enclaude.yaml. Arguments after -- are appended to the preset's, and
+claude.default_args go before both unless --no-default-args is given.
+
+Without a name, the available presets are listed. Preset names are
+case-insensitive.
+
+Example config:
+  claude:
+    arg_presets:
+      review: ["-p", "Review the uncommitted changes for bugs"]
+      fix: ["-p", "Fix the failing tests"]
+
+Examples:
+  enclaude preset
+  enclaude preset review
+  enclaude preset review -- --model opus`,
+	RunE: runPreset,
+}
+
+func runPreset(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		listPresets()
+		return nil
+	}
+
+	claudeArgs, err := presetArgs(cfg.Claude.ArgPresets, args[0], args[1:])
+	if err != nil {
+		return err
+	}
+	return runContainer(cmd, claudeArgs)
+}
+
+// presetArgs returns the preset's arguments followed by any extra arguments
+func presetArgs(presets map[string][]string, name string, extra []string) ([]string, error) {
+	preset, ok := presets[strings.ToLower(name)]
+	if !ok {
+		if len(presets) == 0 {
+			return nil, fmt.Errorf("unknown preset %q; no presets are defined in claude.arg_presets", name)
+		}
+		return nil, fmt.Errorf("unknown preset %q (available: %s)", name, strings.Join(presetNames(presets), ", "))
+	}
+
+	args := make([]string, 0, len(preset)+len(extra))
+	args = append(args, preset...)
+	return append(args, extra...), nil
+}
+
+// listPresets prints each preset and its arguments
+func listPresets() {
+	if len(cfg.Claude.ArgPresets) == 0 {
+		fmt.Println("No presets defined. Add them under claude.arg_presets in your config or .enclaude.yaml.")
+		return
+	}
+	fmt.Println("Available presets:")
+	for _, name := range presetNames(cfg.Claude.ArgPresets) {
+		fmt.Printf("  %-16s %s\n", name, strings.Join(cfg.Claude.ArgPresets[name], " "))
+	}
+}
+
+func presetNames(presets map[string][]string) []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
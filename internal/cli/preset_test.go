@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPresetArgs(t *testing.T) {
+	presets := map[string][]string{
+		"review": {"-p", "review the diff"},
+		"fix":    {"-p", "fix the tests"},
+	}
+
+	tests := []struct {
+		name    string
+		preset  string
+		extra   []string
+		want    []string
+		wantErr bool
+	}{
+		{"preset only", "review", nil, []string{"-p", "review the diff"}, false},
+		{"extra args appended", "fix", []string{"--model", "opus"}, []string{"-p", "fix the tests", "--model", "opus"}, false},
+		{"case insensitive", "Review", nil, []string{"-p", "review the diff"}, false},
+		{"unknown", "deploy", nil, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := presetArgs(presets, tt.preset, tt.extra)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("presetArgs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("presetArgs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// The preset's own slice must not be modified by appending extra args
+	if _, err := presetArgs(presets, "review", []string{"x"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(presets["review"]) != 2 {
+		t.Errorf("presetArgs() modified the preset: %v", presets["review"])
+	}
+}
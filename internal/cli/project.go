@@ -0,0 +1,259 @@
+package cli
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/jakenelson/enclaude/internal/config"
+	"github.com/jakenelson/enclaude/internal/container"
+	"github.com/jakenelson/enclaude/internal/workspace"
+	"github.com/moby/term"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// projectKeys are the settings a project config may set in any workspace:
+// resources, and Claude's default arguments and argument presets
+// (claude.arg_presets, checked in projectSettingAllowed). Everything else a
+// project sets applies only once the workspace is trusted and the user
+// approved the file, including the image, which gets the user's credentials,
+// and environment variables, which can reroute or inject into Claude.
+var projectKeys = []string{
+	"container.preset",
+	"container.memory_limit",
+	"container.memory_reservation",
+	"container.memory_swappiness",
+	"container.cpus",
+	"container.pids_limit",
+	"container.tmpfs_size",
+	"container.idle_after",
+	"container.idle_cpus",
+	"claude.default_args",
+}
+
+// userOnlyKeys are the settings only the user config can set: a project
+// config setting them is ignored even with approval, since they let the
+// session reach into the host. Each covers the settings below it. Output
+// filters are user-only when they run a host command, and
+// security.ephemeral when it turns ephemeral sessions off (see
+// userOnlySetting).
+var userOnlyKeys = []string{
+	"claude.secretless_upstream",
+	"credentials.github_app",
+	"host_commands",
+	"network.reverse_forward",
+	"security.workspace_trust",
+	"sockets",
+}
+
+// The workspace the project config is read from, the project config in
+// effect, the settings in it that need approval, and those it may not set at
+// all
+var (
+	projectDir        string
+	projectFile       string
+	projectRestricted map[string]interface{}
+	projectApproved   bool // Whether projectRestricted has been merged
+	projectIgnored    []string
+)
+
+// mergeProjectConfig merges the project config from dir, the command's
+// workspace, if present, and reports whether it did. Settings outside
+// projectKeys are held back until applyProjectConfig approves them.
+func mergeProjectConfig(dir string) bool {
+	projectDir = dir
+	file := config.FindProjectConfigIn(dir)
+	if file == "" {
+		return false
+	}
+	project := viper.New()
+	project.SetConfigFile(file)
+	if err := project.ReadInConfig(); err != nil {
+		fmt.Fprintln(os.Stderr, "Warning: error reading project config file:", err)
+		return false
+	}
+	if abs, err := filepath.Abs(file); err == nil {
+		file = abs
+	}
+
+	config.RecordEnvNames(file)
+	allowed, restricted, ignored := splitProjectSettings(project.AllSettings())
+	projectFile, projectRestricted, projectIgnored = file, restricted, ignored
+	if err := viper.MergeConfigMap(allowed); err != nil {
+		fmt.Fprintln(os.Stderr, "Warning: error merging project config file:", err)
+		return false
+	}
+	if projectApproved {
+		if err := viper.MergeConfigMap(restricted); err != nil {
+			fmt.Fprintln(os.Stderr, "Warning: error merging project config file:", err)
+		}
+	}
+	return true
+}
+
+// splitProjectSettings separates the settings a project may set from those
+// that need approval, leaving out and listing those it may not set
+func splitProjectSettings(settings map[string]interface{}) (allowed, restricted map[string]interface{}, ignored []string) {
+	allowed, restricted = make(map[string]interface{}), make(map[string]interface{})
+	var walk func(m map[string]interface{}, path []string)
+	walk = func(m map[string]interface{}, path []string) {
+		for key, value := range m {
+			keyPath := append(path[:len(path):len(path)], key)
+			if sub, ok := value.(map[string]interface{}); ok && len(sub) > 0 {
+				walk(sub, keyPath)
+				continue
+			}
+			switch {
+			case userOnlySetting(keyPath, value):
+				ignored = append(ignored, strings.Join(keyPath, "."))
+			case projectSettingAllowed(keyPath):
+				putSetting(allowed, keyPath, value)
+			default:
+				putSetting(restricted, keyPath, value)
+			}
+		}
+	}
+	walk(settings, nil)
+	sort.Strings(ignored)
+	return allowed, restricted, ignored
+}
+
+// userOnlySetting reports whether the setting at keyPath can only be set in
+// the user config with the given value
+func userOnlySetting(keyPath []string, value interface{}) bool {
+	key := strings.Join(keyPath, ".")
+	if key == "security.ephemeral" {
+		on, _ := value.(bool)
+		return !on
+	}
+	if key == "output.filters" {
+		filters, _ := value.([]interface{})
+		for _, f := range filters {
+			if spec, ok := f.(string); ok && container.IsCommandFilter(spec) {
+				return true
+			}
+		}
+	}
+	for _, userOnly := range userOnlyKeys {
+		if key == userOnly || strings.HasPrefix(key, userOnly+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// projectSettingAllowed reports whether a project may set the setting at
+// keyPath without approval
+func projectSettingAllowed(keyPath []string) bool {
+	if len(keyPath) == 3 && keyPath[0] == "claude" && keyPath[1] == "arg_presets" {
+		return true
+	}
+	return slices.Contains(projectKeys, strings.Join(keyPath, "."))
+}
+
+// putSetting sets the nested setting at keyPath in settings
+func putSetting(settings map[string]interface{}, keyPath []string, value interface{}) {
+	for _, key := range keyPath[:len(keyPath)-1] {
+		sub, ok := settings[key].(map[string]interface{})
+		if !ok {
+			sub = make(map[string]interface{})
+			settings[key] = sub
+		}
+		settings = sub
+	}
+	settings[keyPath[len(keyPath)-1]] = value
+}
+
+// settingKeys returns the dotted keys of the leaf settings in settings, in
+// sorted order
+func settingKeys(settings map[string]interface{}) []string {
+	var keys []string
+	for key, value := range settings {
+		if sub, ok := value.(map[string]interface{}); ok && len(sub) > 0 {
+			for _, subKey := range settingKeys(sub) {
+				keys = append(keys, key+"."+subKey)
+			}
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// applyProjectConfig merges the project settings held back by
+// mergeProjectConfig when the project's directory is trusted and the user
+// approved this version of the file, asking on a terminal if they have not.
+// Any change to the file needs approving again. It reports whether the
+// settings were merged; otherwise they are ignored with a warning, as are
+// those only the user config can set.
+func applyProjectConfig(cmd *cobra.Command) bool {
+	if len(projectIgnored) > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: ignoring %s from %s: only your user config can set them\n", strings.Join(projectIgnored, ", "), projectFile)
+	}
+	if projectFile == "" || len(projectRestricted) == 0 || projectApproved {
+		return false
+	}
+	keys := settingKeys(projectRestricted)
+	ignore := func(reason string) bool {
+		fmt.Fprintf(os.Stderr, "Warning: ignoring %s from %s: %s\n", strings.Join(keys, ", "), projectFile, reason)
+		return false
+	}
+
+	data, err := os.ReadFile(projectFile)
+	if err != nil {
+		return ignore(fmt.Sprintf("could not check their approval: %v", err))
+	}
+	sum := sha256.Sum256(data)
+	digest := hex.EncodeToString(sum[:])
+
+	registry, err := workspace.LoadRegistry(registryPath())
+	if err != nil {
+		return ignore(fmt.Sprintf("could not check their approval: %v", err))
+	}
+	if _, ok := registry.TrustedBy(filepath.Dir(projectFile)); !ok {
+		return ignore("the workspace is not trusted; trust it with 'enclaude workspace trust' to be asked about them")
+	}
+	if !registry.IsApproved(projectFile, digest) {
+		if cmd.Name() == "ci" || !term.IsTerminal(os.Stdin.Fd()) {
+			return ignore("not approved; run enclaude in the workspace on a terminal to approve them")
+		}
+		if !promptProjectConfig(bufio.NewReader(os.Stdin), keys) {
+			return ignore("not approved")
+		}
+		registry.Approve(projectFile, digest)
+		if err := registry.Save(); err != nil {
+			fmt.Fprintln(os.Stderr, "Warning:", err)
+		}
+	}
+
+	if err := viper.MergeConfigMap(projectRestricted); err != nil {
+		fmt.Fprintln(os.Stderr, "Warning: error merging project config file:", err)
+		return false
+	}
+	projectApproved = true
+	return true
+}
+
+// promptProjectConfig asks whether to apply the project settings keys
+func promptProjectConfig(reader *bufio.Reader, keys []string) bool {
+	fmt.Fprintf(os.Stderr, "%s sets settings a project can only set with your approval:\n", projectFile)
+	for _, key := range keys {
+		value, _ := config.Setting(projectRestricted, key)
+		fmt.Fprintf(os.Stderr, "  %s = %v\n", key, value)
+	}
+	fmt.Fprint(os.Stderr, "Apply them, now and until the file changes? [y/N]: ")
+	input, err := reader.ReadString('\n')
+	if err != nil {
+		return false
+	}
+	input = strings.ToLower(strings.TrimSpace(input))
+	return input == "y" || input == "yes"
+}
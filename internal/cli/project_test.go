@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestSplitProjectSettings(t *testing.T) {
+	allowed, restricted, ignored := splitProjectSettings(map[string]interface{}{
+		"image": map[string]interface{}{"name": "enclaude:go", "dockerfile": "Dockerfile"},
+		"container": map[string]interface{}{
+			"memory_limit":      "4g",
+			"network":           "host",
+			"extra_docker_args": []interface{}{"--privileged"},
+		},
+		"environment": map[string]interface{}{"custom": map[string]interface{}{
+			"go_flags":           "-mod=mod",
+			"github_token":       "x",
+			"anthropic_base_url": "http://example.com",
+		}},
+		"claude":        map[string]interface{}{"default_args": []interface{}{"--verbose"}},
+		"mounts":        map[string]interface{}{"volumes": []interface{}{"/:/host"}},
+		"host_commands": map[string]interface{}{"enabled": true, "allow": []interface{}{"sh -c *"}},
+		"network":       map[string]interface{}{"reverse_forward": []interface{}{22}},
+		"security":      map[string]interface{}{"ephemeral": false},
+		"output":        map[string]interface{}{"filters": []interface{}{"strip-ansi", "cmd:sh"}},
+	})
+
+	if got, want := settingKeys(allowed), []string{
+		"claude.default_args", "container.memory_limit",
+	}; !reflect.DeepEqual(got, want) {
+		t.Errorf("allowed = %v, want %v", got, want)
+	}
+	if got, want := settingKeys(restricted), []string{
+		"container.extra_docker_args", "container.network", "environment.custom.anthropic_base_url",
+		"environment.custom.github_token", "environment.custom.go_flags", "image.dockerfile", "image.name", "mounts.volumes",
+	}; !reflect.DeepEqual(got, want) {
+		t.Errorf("restricted = %v, want %v", got, want)
+	}
+	if want := []string{"host_commands.allow", "host_commands.enabled", "network.reverse_forward", "output.filters", "security.ephemeral"}; !reflect.DeepEqual(ignored, want) {
+		t.Errorf("ignored = %v, want %v", ignored, want)
+	}
+
+	_, restricted, ignored = splitProjectSettings(map[string]interface{}{
+		"output":   map[string]interface{}{"filters": []interface{}{"strip-ansi"}},
+		"security": map[string]interface{}{"ephemeral": true},
+	})
+	if len(ignored) != 0 || len(settingKeys(restricted)) != 2 {
+		t.Errorf("built-in filters and ephemeral on: restricted %v, ignored %v; want them to need approval", restricted, ignored)
+	}
+}
+
+func TestMergeProjectConfigWorkDir(t *testing.T) {
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	dir, file, restricted, ignored := projectDir, projectFile, projectRestricted, projectIgnored
+	t.Cleanup(func() { projectDir, projectFile, projectRestricted, projectIgnored = dir, file, restricted, ignored })
+
+	// The workspace's project config applies, not the current directory's
+	workDir := t.TempDir()
+	os.WriteFile(filepath.Join(workDir, ".enclaude.yaml"), []byte("container:\n  memory_limit: 6g\nimage:\n  name: evil:latest\nenvironment:\n  custom:\n    NODE_OPTIONS: --require /workspace/x.js\n"), 0644)
+	t.Chdir(t.TempDir())
+	if !mergeProjectConfig(workDir) {
+		t.Fatal("mergeProjectConfig() found no project config in the workspace")
+	}
+	if got := viper.GetString("container.memory_limit"); got != "6g" {
+		t.Errorf("container.memory_limit = %q, want 6g", got)
+	}
+	if viper.IsSet("image.name") || viper.IsSet("environment.custom") {
+		t.Errorf("image and environment applied without approval: %v", viper.AllSettings())
+	}
+	if projectFile != filepath.Join(workDir, ".enclaude.yaml") {
+		t.Errorf("projectFile = %q", projectFile)
+	}
+}
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/jakenelson/enclaude/internal/config"
+	"github.com/jakenelson/enclaude/internal/container"
+	"github.com/moby/term"
+)
+
+// checkImageInputs compares the Dockerfile and build context image was built
+// from with their current contents, and rebuilds the image when they
+// changed: without asking with image.auto_rebuild, otherwise after asking in
+// a terminal. Images built without the inputs label, such as pulled ones,
+// are not checked. The inputs are those in image.dockerfile and
+// image.build_context, or else the ones the image was built from.
+func checkImageInputs(ctx context.Context, image string) error {
+	if cfg.Container.Engine == config.EngineContainerd {
+		return nil
+	}
+	runner, err := container.NewRunner()
+	if err != nil {
+		// Reported when the session runner connects
+		return nil
+	}
+	defer runner.Close()
+
+	labels, err := runner.ImageLabels(ctx, image)
+	if err != nil || labels[container.BuildInputsLabel] == "" {
+		return nil
+	}
+	dockerfile, contextDir := labels[container.BuildDockerfileLabel], labels[container.BuildContextLabel]
+	if cfg.Image.Dockerfile != "" {
+		if dockerfile, contextDir, err = resolveDockerfile("", ""); err != nil {
+			return nil
+		}
+	}
+	current, err := container.BuildLabels(dockerfile, contextDir)
+	if err != nil {
+		// The inputs moved or were removed; there is nothing to rebuild from
+		return nil
+	}
+	if current[container.BuildInputsLabel] == labels[container.BuildInputsLabel] {
+		return nil
+	}
+
+	fmt.Fprintf(os.Stderr, "The Dockerfile or build context of %s (%s) changed since the image was built.\n", image, dockerfile)
+	if strings.Contains(image, "@") {
+		fmt.Fprintln(os.Stderr, "The image is pinned by digest; run 'enclaude build' and update image.name to use the changes.")
+		return nil
+	}
+	if !cfg.Image.AutoRebuild {
+		if !term.IsTerminal(os.Stdin.Fd()) {
+			fmt.Fprintln(os.Stderr, "Run 'enclaude build' to rebuild it, or set image.auto_rebuild: true.")
+			return nil
+		}
+		if !confirm(bufio.NewReader(os.Stdin), "Rebuild it now?") {
+			return nil
+		}
+	}
+
+	// Keep the enclaude user the image was built with
+	uid, uidErr := strconv.Atoi(labels[container.UIDLabel])
+	gid, gidErr := strconv.Atoi(labels[container.GIDLabel])
+	if uidErr != nil || gidErr != nil {
+		uid, gid = imageUserID(os.Getuid(), false), imageUserID(os.Getgid(), false)
+	}
+
+	fmt.Fprintf(os.Stderr, "Rebuilding image %s from %s...\n", image, dockerfile)
+	err = runner.Build(ctx, container.BuildOptions{
+		Dockerfile: dockerfile,
+		ContextDir: contextDir,
+		Tag:        image,
+		BuildArgs:  container.UserBuildArgs(uid, gid),
+		Labels:     current,
+	})
+	if err != nil {
+		return fmt.Errorf("rebuild failed: %w", err)
+	}
+	return nil
+}
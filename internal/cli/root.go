@@ -1,17 +1,26 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/jakenelson/enclaude/internal/config"
+	"github.com/jakenelson/enclaude/internal/terminal"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
 var (
 	cfgFile string
+	profile string
 	cfg     *config.Config
+
+	// stdinConfig holds the settings read for --config -
+	stdinConfig map[string]interface{}
 )
 
 var rootCmd = &cobra.Command{
@@ -26,70 +35,206 @@ Examples:
   enclaude -w ~/projects/myapp          # Override working directory
   enclaude -m ~/shared-lib              # Mount additional directory
   enclaude --mount-ro ~/docs            # Mount read-only
+  enclaude --ref ../shared-lib          # Mount a reference read-only at /refs/shared-lib
+  enclaude --workspace ../api           # Add a sibling repository as a workspace root
   enclaude --claude-auth=api-key        # Use API key auth only
+  enclaude --claude-provider bedrock    # Use Claude through Amazon Bedrock
   enclaude --no-external-credentials    # Disable GitHub/GCloud/SSH passthrough
+  enclaude --preflight                  # Check API connectivity first
+  enclaude --secretless                 # Keep the API key out of the sandbox
+  enclaude --split-output err.log       # Capture stderr separately
+  enclaude --tee session.log            # Keep a plain-text record of the session
+  enclaude --workspace-mode copy        # Work on a disposable copy
+  enclaude --artifacts artifacts        # Keep reports in ./artifacts
+  enclaude --network devstack_default   # Join a running Compose stack's network
+  enclaude --engine containerd          # Run on containerd through nerdctl
+  enclaude --from-spec run.json         # Run a shared sandbox definition
+  enclaude --template acme/approved:v1  # Run a sandbox template from a registry
+  enclaude --profile work               # Apply a config profile
+  enclaude --summary                    # Report what the session did on exit
+  enclaude --watch-changes              # Show file changes as they happen
+  enclaude --fast-fs                    # Workspace in a volume, synced back to the host
   enclaude -- --help                    # Pass args to Claude Code`,
-	RunE:         runContainer,
-	SilenceUsage: true,
+	PersistentPreRunE: applyWorkspaceSettings,
+	RunE:              runContainer,
+	SilenceUsage:      true,
 }
 
 func Execute() error {
-	return rootCmd.Execute()
+	// A panic must not leave the terminal in raw mode
+	defer terminal.Recover()
+	flushUsage()
+	started := time.Now()
+	cmd, err := rootCmd.ExecuteC()
+	recordUsage(cmd, time.Since(started), err)
+	return err
+}
+
+// exitCodeError is returned by commands that exit with a specific status
+type exitCodeError struct {
+	code int
+	err  error
+}
+
+func (e *exitCodeError) Error() string { return e.err.Error() }
+func (e *exitCodeError) Unwrap() error { return e.err }
+
+// ExitCode returns the exit status for an error returned by Execute
+func ExitCode(err error) int {
+	var e *exitCodeError
+	if errors.As(err, &e) {
+		return e.code
+	}
+	return 1
 }
 
 func init() {
 	cobra.OnInitialize(initConfig)
 
 	// Global flags
-	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.config/enclaude/config.yaml)")
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file in YAML, TOML, or JSON, or - to read it from stdin (default is $HOME/.config/enclaude/config.yaml)")
+	rootCmd.PersistentFlags().StringVar(&profile, "profile", "", "config profile from ~/.config/enclaude/profiles to apply to sessions (overrides workspace pin)")
 
 	// Run flags
 	rootCmd.Flags().StringP("workdir", "w", "", "working directory to mount (default: current directory)")
 	rootCmd.Flags().StringArrayP("mount", "m", nil, "additional directories to mount (read-write)")
 	rootCmd.Flags().StringArray("mount-ro", nil, "additional directories to mount (read-only)")
+	rootCmd.Flags().StringArray("ref", nil, "read-only reference directory, as path or name=path, mounted at /refs/<name> (repeatable)")
+	rootCmd.Flags().StringArray("workspace", nil, "additional workspace root, as path or name=path, mounted read-write at /workspace/<name> (repeatable)")
+	rootCmd.Flags().String("workspace-cwd", "", "name of the workspace root to start in (default: the one containing the working directory)")
 	rootCmd.Flags().String("image", "", "Docker image to use (default: enclaude:latest)")
+	rootCmd.Flags().String("pull", "", "When to pull the image: always, missing, never (overrides config)")
+	rootCmd.Flags().String("network", "", "Docker network: bridge, host, none, or an existing network to join (overrides config)")
+	rootCmd.RegisterFlagCompletionFunc("network", completeNetworks)
+	rootCmd.Flags().IntSlice("reverse-forward", nil, "host port reachable from the sandbox at host.enclaude.internal, e.g. 5432 (repeatable)")
+	rootCmd.Flags().String("engine", "", "Container engine: docker, containerd (overrides config)")
+	rootCmd.Flags().String("split-output", "", "write container stderr to this file instead of the terminal")
+	rootCmd.Flags().String("tee", "", "also write everything shown on the terminal to this file, as plain text")
+	rootCmd.Flags().Bool("tee-raw", false, "keep escape sequences in the --tee file")
+	rootCmd.Flags().String("workspace-mode", "", "Workspace mode: bind, copy (overrides config)")
+	rootCmd.Flags().Bool("include-ignored", false, "In copy mode, also copy files excluded by .dockerignore/.gitignore")
+	rootCmd.Flags().String("artifacts", "", "host directory mounted read-write at /artifacts, relative to the workspace (overrides config)")
+	rootCmd.Flags().Bool("fast-fs", false, "mirror the workspace into a Docker volume for faster file I/O, copying changes back when the session ends (Docker Desktop)")
+	rootCmd.Flags().Bool("ephemeral", false, "mount the workspace and every other mount read-only, keeping scratch space in memory only (overrides config)")
+	rootCmd.Flags().Bool("summary", false, "print duration, peak memory, network traffic, and changed files when the session ends")
+	rootCmd.Flags().String("watch-changes", "", "print each workspace file created, modified, or removed during the session (to stderr, or to the given file)")
+	rootCmd.Flags().Lookup("watch-changes").NoOptDefVal = "-"
+	rootCmd.Flags().Bool("dangerous", false, "allow forwarding or mounting sockets that control the host, such as docker.sock")
+	rootCmd.Flags().Bool("i-know-what-im-doing", false, "same as --dangerous")
+	rootCmd.Flags().MarkDeprecated("i-know-what-im-doing", "use --dangerous")
+	rootCmd.Flags().String("from-spec", "", "run the sandbox definition in this file (see 'enclaude export-spec')")
+	rootCmd.Flags().String("template", "", "run the sandbox template published at this OCI reference (see 'enclaude template')")
+	rootCmd.Flags().String("template-preset", "", "run the --template with one of its argument presets")
+	rootCmd.Flags().Bool("no-default-args", false, "Do not put claude.default_args before the Claude arguments")
+	rootCmd.Flags().StringArray("docker-opt", nil, "docker run flag enclaude has no setting for, e.g. --docker-opt shm-size=2g (repeatable)")
+	rootCmd.Flags().Bool("strict", false, "fail instead of warning when the image does not match the workspace's enclaude.lock")
 
 	// Claude authentication flags (override config)
 	rootCmd.Flags().String("claude-auth", "", "Claude auth method: auto, session, api-key (overrides config)")
-	rootCmd.Flags().String("claude-session-dir", "", "Session dir mode: none, readonly, readwrite (overrides config)")
+	rootCmd.Flags().String("claude-provider", "", "Model provider: anthropic, bedrock, vertex (overrides config)")
+	rootCmd.Flags().String("claude-session-dir", "", "Session dir mode: none, readonly, readwrite, volume (overrides config)")
+	rootCmd.Flags().Bool("preflight", false, "Verify Anthropic API connectivity before starting (overrides config)")
+	rootCmd.Flags().Bool("secretless", false, "Keep ANTHROPIC_API_KEY on the host behind an auth-injecting proxy (overrides config)")
 
 	// External credentials flag
-	rootCmd.Flags().Bool("no-external-credentials", false, "Disable external credential passthrough (GitHub, GCloud, SSH)")
+	rootCmd.Flags().Bool("no-external-credentials", false, "Disable external credential passthrough (GitHub, GCloud, Bitbucket, Azure DevOps, SSH, GPG)")
+	rootCmd.Flags().Bool("no-creds", false, "Run with no host credentials at all, including Claude auth, overriding config (for untrusted code)")
 
 	// Bind flags to viper for config integration
-	viper.BindPFlag("image.name", rootCmd.Flags().Lookup("image"))
-	viper.BindPFlag("claude.auth", rootCmd.Flags().Lookup("claude-auth"))
-	viper.BindPFlag("claude.session_dir", rootCmd.Flags().Lookup("claude-session-dir"))
+	for key, flag := range configFlags {
+		viper.BindPFlag(key, rootCmd.Flags().Lookup(flag))
+	}
+}
+
+// configFlags are the session flags that override config settings, by
+// setting key
+var configFlags = map[string]string{
+	"image.name":                "image",
+	"image.pull_policy":         "pull",
+	"claude.auth":               "claude-auth",
+	"claude.session_dir":        "claude-session-dir",
+	"claude.provider":           "claude-provider",
+	"claude.preflight":          "preflight",
+	"claude.secretless":         "secretless",
+	"container.network":         "network",
+	"container.engine":          "engine",
+	"workspace.mode":            "workspace-mode",
+	"workspace.include_ignored": "include-ignored",
+	"workspace.artifacts":       "artifacts",
+	"security.ephemeral":        "ephemeral",
 }
 
 func initConfig() {
-	if cfgFile != "" {
+	switch {
+	case cfgFile == "-":
+		// Read below, once the environment is bound
+	case cfgFile != "":
 		viper.SetConfigFile(cfgFile)
-	} else {
+	default:
 		home, err := os.UserHomeDir()
 		if err != nil {
 			fmt.Fprintln(os.Stderr, "Warning: could not find home directory:", err)
 			return
 		}
 
-		// Search for config in standard locations
-		viper.AddConfigPath(home + "/.config/enclaude")
-		viper.AddConfigPath(".")
-		viper.SetConfigName("config")
-		viper.SetConfigType("yaml")
+		// Search for config in standard locations, in any supported format
+		if file := config.FindConfigFile(filepath.Join(home, ".config", "enclaude", "config")); file != "" {
+			viper.SetConfigFile(file)
+		} else if file := config.FindConfigFile("config"); file != "" {
+			viper.SetConfigFile(file)
+		}
 	}
 
 	// Environment variables
 	viper.SetEnvPrefix("ENCLAUDE")
 	viper.AutomaticEnv()
 
-	// Read config file (ignore if not found)
-	if err := viper.ReadInConfig(); err != nil {
-		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
-			fmt.Fprintln(os.Stderr, "Warning: error reading config file:", err)
-		}
+	if cfgFile == "-" {
+		readStdinConfig()
+	} else if viper.ConfigFileUsed() != "" {
+		readConfigFile()
 	}
 
-	// Load into config struct
+	// Load into config struct; the project config is merged once the
+	// command's workspace is known (see applyWorkspaceSettings)
 	cfg = config.LoadConfig()
 }
+
+// readConfigFile reads the user config file, upgrading older formats in
+// memory
+func readConfigFile() {
+	if err := viper.ReadInConfig(); err != nil {
+		fmt.Fprintln(os.Stderr, "Warning: error reading config file:", err)
+		return
+	}
+	// `config migrate` rewrites the file
+	file := viper.ConfigFileUsed()
+	config.RecordEnvNames(file)
+	if changes, err := config.ApplyMigrations(file); err != nil {
+		fmt.Fprintln(os.Stderr, "Warning: error migrating config file:", err)
+	} else if len(changes) > 0 {
+		fmt.Fprintf(os.Stderr, "Note: %s uses an older config format; run 'enclaude config migrate' to update it\n", file)
+	}
+}
+
+// readStdinConfig reads a whole config document from stdin for --config -,
+// in place of the user config file, for one-off invocations such as CI jobs
+func readStdinConfig() {
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Warning: error reading config from stdin:", err)
+		return
+	}
+	settings, err := config.ParseConfig(data)
+	if err == nil {
+		_, err = config.Migrate(settings)
+	}
+	if err == nil {
+		err = viper.MergeConfigMap(settings)
+		stdinConfig = settings
+		config.RecordEnvNamesData(data, "")
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Warning: error reading config from stdin:", err)
+	}
+}
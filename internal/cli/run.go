@@ -2,14 +2,31 @@ package cli
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"syscall"
+	"time"
 
+	"github.com/jakenelson/enclaude/internal/access"
+	"github.com/jakenelson/enclaude/internal/apiproxy"
+	"github.com/jakenelson/enclaude/internal/config"
 	"github.com/jakenelson/enclaude/internal/container"
 	"github.com/jakenelson/enclaude/internal/credentials"
+	"github.com/jakenelson/enclaude/internal/domainblock"
+	"github.com/jakenelson/enclaude/internal/editor"
+	"github.com/jakenelson/enclaude/internal/hostexec"
+	"github.com/jakenelson/enclaude/internal/hostports"
 	"github.com/jakenelson/enclaude/internal/security"
+	"github.com/jakenelson/enclaude/internal/shellpolicy"
+	"github.com/jakenelson/enclaude/internal/sockets"
+	"github.com/jakenelson/enclaude/internal/state"
+	"github.com/jakenelson/enclaude/internal/workspace"
+	"github.com/jakenelson/enclaude/internal/wsl"
 	"github.com/spf13/cobra"
 )
 
@@ -25,25 +42,381 @@ func runContainer(cmd *cobra.Command, args []string) error {
 		cancel()
 	}()
 
-	// Get working directory
-	workDir, _ := cmd.Flags().GetString("workdir")
-	if workDir == "" {
-		var err error
-		workDir, err = os.Getwd()
+	notifyUpdate()
+
+	var opts container.RunOptions
+	var cleanup func()
+	var err error
+	var pull *imagePull
+	if specPath, _ := cmd.Flags().GetString("from-spec"); specPath != "" {
+		var spec container.Spec
+		if spec, err = container.ReadSpec(specPath); err != nil {
+			return err
+		}
+		pull = startImagePull(ctx, spec.Options.Image)
+		opts, cleanup, err = buildSpecRunOptions(cmd, args, spec, specPath)
+	} else if ref, _ := cmd.Flags().GetString("template"); ref != "" {
+		var spec container.Spec
+		if spec, args, err = resolveTemplate(ctx, cmd, ref, args); err != nil {
+			return err
+		}
+		pull = startImagePull(ctx, spec.Options.Image)
+		opts, cleanup, err = buildSpecRunOptions(cmd, args, spec, ref)
+	} else {
+		// Pull the image while the workspace and credentials are prepared
+		pull = startImagePull(ctx, sessionImage(cmd))
+		opts, cleanup, err = buildRunOptions(cmd, args)
+	}
+	if err != nil {
+		pull.stop()
+		return err
+	}
+	defer cleanup()
+	if err := pull.wait(ctx, opts.Image); err != nil {
+		return err
+	}
+	if err := checkImageInputs(ctx, opts.Image); err != nil {
+		return err
+	}
+	if err := checkImageLock(ctx, cmd, opts); err != nil {
+		return err
+	}
+
+	// Keep a record of everything the terminal shows
+	if tee, _ := cmd.Flags().GetString("tee"); tee != "" {
+		if opts.TeeFile, err = security.ExpandPath(tee); err != nil {
+			return fmt.Errorf("invalid tee path: %w", err)
+		}
+		opts.TeeRaw, _ = cmd.Flags().GetBool("tee-raw")
+	}
+
+	// Give claude time to save its session when interrupted
+	if cfg.Container.StopGrace != "" {
+		opts.StopGrace, err = time.ParseDuration(cfg.Container.StopGrace)
 		if err != nil {
-			return fmt.Errorf("failed to get current directory: %w", err)
+			return fmt.Errorf("invalid container.stop_grace %q: %w", cfg.Container.StopGrace, err)
 		}
 	}
 
-	// Expand and validate working directory
-	workDir, err := security.ExpandPath(workDir)
+	// Hold input and output until the session is set up
+	opts.ReadyCheck = cfg.Container.ReadyCheck
+	if cfg.Container.ReadyWait != "" {
+		opts.ReadyTimeout, err = time.ParseDuration(cfg.Container.ReadyWait)
+		if err != nil {
+			return fmt.Errorf("invalid container.ready_wait %q: %w", cfg.Container.ReadyWait, err)
+		}
+	}
+
+	// Lower the CPU limit of a session left idle
+	if cfg.Container.IdleAfter != "" {
+		opts.IdleAfter, err = time.ParseDuration(cfg.Container.IdleAfter)
+		if err != nil {
+			return fmt.Errorf("invalid container.idle_after %q: %w", cfg.Container.IdleAfter, err)
+		}
+		opts.IdleCPUs = cfg.Container.IdleCPUs
+		if opts.IdleAfter > 0 && cfg.Container.Engine == config.EngineContainerd {
+			fmt.Fprintln(os.Stderr, "Warning: container.idle_after is not supported with the containerd engine; ignoring")
+		}
+	}
+
+	session, err := startSession(ctx, cmd, &opts, true)
+	if err != nil {
+		return err
+	}
+	defer session.stop()
+
+	var summary *runSummary
+	if show, _ := cmd.Flags().GetBool("summary"); show {
+		summary = startSummary(&opts)
+	}
+	stopWatch := func() {}
+	if dest, _ := cmd.Flags().GetString("watch-changes"); dest != "" {
+		stopWatch = startChangeWatch(opts, dest)
+	}
+	checkGrowth := startGrowthCheck(opts)
+	err = session.runner.Run(ctx, cancel, opts)
+	stopWatch()
+	session.finish(err)
+	if summary != nil {
+		summary.print(os.Stderr, err)
+	}
+	checkGrowth()
+	var detached *container.DetachedError
+	if errors.As(err, &detached) {
+		fmt.Fprint(os.Stderr, detachedHint("the session", detached.ContainerID))
+		return nil
+	}
+	return err
+}
+
+// detachedHint tells the user how to get back to what, a detached session
+// in containerID, with the configured engine's CLI
+func detachedHint(what, containerID string) string {
+	id, cli := shortID(containerID), engineCLI()
+	return fmt.Sprintf("\nDetached; %s keeps running. Reattach with '%s attach %s' or stop it with '%s rm -f %s'.\n", what, cli, id, cli, id)
+}
+
+// engineCLI returns the command line that manages containers on the
+// configured engine, for commands suggested to the user
+func engineCLI() string {
+	if cfg.Container.Engine != config.EngineContainerd {
+		return "docker"
+	}
+	if ns := cfg.Container.Namespace; ns != "" && ns != container.DefaultNamespace {
+		return "nerdctl --namespace " + ns
+	}
+	return "nerdctl"
+}
+
+// sessionRunner runs a session on the configured container engine
+type sessionRunner interface {
+	Run(ctx context.Context, cancel context.CancelFunc, opts container.RunOptions) error
+	Close() error
+	userNamespacer
+}
+
+// newSessionRunner connects to the engine in container.engine. With Docker,
+// sessions left behind by killed enclaude processes are cleaned up, the
+// network.create network is created if missing, and the preflight check
+// runs if enabled.
+func newSessionRunner(ctx context.Context, opts container.RunOptions) (sessionRunner, error) {
+	switch cfg.Container.Engine {
+	case config.EngineContainerd:
+		if cfg.Network.Create != "" {
+			return nil, fmt.Errorf("network.create is not supported with the containerd engine; create the network with 'nerdctl network create' and set container.network")
+		}
+		runner, err := container.NewNerdctlRunner(cfg.Container.Namespace)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create container runner: %w", err)
+		}
+		if cfg.Claude.Preflight {
+			fmt.Fprintln(os.Stderr, "Warning: the preflight check is not supported with the containerd engine; skipping")
+		}
+		return runner, nil
+	case config.EngineDocker, "":
+	default:
+		return nil, fmt.Errorf("invalid container.engine %q (allowed: %s, %s)", cfg.Container.Engine, config.EngineDocker, config.EngineContainerd)
+	}
+
+	runner, err := container.NewRunner()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create container runner: %w", err)
+	}
+
+	// Clean up after sessions whose enclaude process was killed
+	reapStaleSessions(ctx, runner)
+
+	// The persistent network for network.create, which the preflight uses too
+	if cfg.Network.Create != "" {
+		created, err := runner.EnsureNetwork(ctx, opts.Network)
+		if err != nil {
+			runner.Close()
+			return nil, err
+		}
+		if created && len(opts.Aliases) > 0 {
+			fmt.Fprintf(os.Stderr, "Created network %s; other containers on it reach this session as %s\n", opts.Network, opts.Aliases[0])
+		} else if created {
+			fmt.Fprintf(os.Stderr, "Created network %s\n", opts.Network)
+		}
+	}
+
+	// Fail fast on proxy/CA problems instead of opaque TLS errors from Claude
+	if cfg.Claude.Preflight {
+		if err := runner.Preflight(ctx, opts, credentials.ProviderEndpoint(cfg)); err != nil {
+			runner.Close()
+			return nil, fmt.Errorf("preflight check failed: %w", err)
+		}
+	}
+	return runner, nil
+}
+
+// localeEnv returns TZ and the locale variables for container.timezone and
+// container.locale
+func localeEnv() map[string]string {
+	env := make(map[string]string)
+	switch tz := cfg.Container.Timezone; tz {
+	case config.LocaleNone:
+	case config.LocaleAuto, "":
+		if zone := container.HostTimezone(); zone != "" {
+			env["TZ"] = zone
+		}
+	default:
+		env["TZ"] = tz
+	}
+	switch locale := cfg.Container.Locale; locale {
+	case config.LocaleNone:
+	case config.LocaleAuto, "":
+		for k, v := range container.LocaleEnv(os.Environ()) {
+			env[k] = v
+		}
+	default:
+		env["LANG"] = locale
+	}
+	return env
+}
+
+// sessionImage returns the image sessions run in: --image, or image.name
+func sessionImage(cmd *cobra.Command) string {
+	if image, _ := cmd.Flags().GetString("image"); image != "" {
+		return image
+	}
+	return cfg.Image.Name
+}
+
+// imagePull makes the session image available according to
+// image.pull_policy. It is started before the run options are built, so a
+// slow pull overlaps preparing the workspace and credentials.
+type imagePull struct {
+	runner *container.Runner
+	image  string
+	pull   *container.ImagePull
+	cancel context.CancelFunc
+}
+
+// backgroundPull is the pull running while the run options are built. Its
+// progress bar is paused while a prompt uses the terminal (see pausePull).
+var backgroundPull *container.ImagePull
+
+// startImagePull starts pulling image in the background. With an empty
+// image, such as one read from a spec later, nothing starts until wait. The
+// containerd engine pulls through nerdctl run instead.
+func startImagePull(ctx context.Context, image string) *imagePull {
+	if cfg.Container.Engine == config.EngineContainerd {
+		return nil
+	}
+	runner, err := container.NewRunner()
 	if err != nil {
-		return fmt.Errorf("invalid working directory: %w", err)
+		// Reported when the session runner connects
+		return nil
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	p := &imagePull{runner: runner, image: image, cancel: cancel}
+	if image != "" {
+		p.pull = runner.StartImagePull(ctx, image, cfg.Image.PullPolicy, os.Stderr)
+		backgroundPull = p.pull
+	}
+	return p
+}
+
+// wait waits for the pull to finish, then makes image available if it is
+// not the one being pulled
+func (p *imagePull) wait(ctx context.Context, image string) error {
+	if p == nil {
+		return nil
+	}
+	defer p.runner.Close()
+	defer p.cancel()
+	if p.pull != nil {
+		err := p.pull.Wait()
+		backgroundPull = nil
+		if image == p.image {
+			return err
+		}
+	}
+	return p.runner.EnsureImage(ctx, image, cfg.Image.PullPolicy, os.Stderr)
+}
+
+// stop cancels the pull when the session will not start, waiting for it so
+// its progress does not follow the error
+func (p *imagePull) stop() {
+	if p == nil {
+		return
+	}
+	defer p.runner.Close()
+	p.cancel()
+	if p.pull != nil {
+		p.pull.Wait()
+		backgroundPull = nil
+	}
+}
+
+// pausePull holds back the progress of a background pull while a prompt
+// uses the terminal, and returns the function that resumes it
+func pausePull() func() {
+	pull := backgroundPull
+	if pull == nil {
+		return func() {}
+	}
+	pull.Pause()
+	return pull.Resume
+}
+
+// buildRunOptions assembles container run options from flags and config.
+// The returned cleanup function releases any staged host resources and must
+// be called once the container has exited. On error, staged resources are
+// released before returning.
+func buildRunOptions(cmd *cobra.Command, args []string) (opts container.RunOptions, cleanup func(), err error) {
+	var cleanups []func()
+	cleanup = func() {
+		for i := len(cleanups) - 1; i >= 0; i-- {
+			cleanups[i]()
+		}
+	}
+	defer func() {
+		if err != nil {
+			cleanup()
+		}
+	}()
+
+	workDir, err := resolveWorkDir(cmd)
+	if err != nil {
+		return container.RunOptions{}, cleanup, err
+	}
+
+	// Ephemeral sessions write nothing to the host's disk
+	if cfg.Security.Ephemeral {
+		if err := checkEphemeral(cmd); err != nil {
+			return container.RunOptions{}, cleanup, err
+		}
+	}
+
+	// Where the workspace appears in the container
+	workspaceTarget, err := config.ResolveWorkspaceTarget(cfg.Mounts.WorkspaceTarget, workDir)
+	if err != nil {
+		return container.RunOptions{}, cleanup, fmt.Errorf("invalid mounts.workspace_target: %w", err)
+	}
+
+	// Several roots are mounted side by side, and the session starts in one
+	// of them or in the directory holding them
+	roots, err := workspaceRoots(cmd, workDir, workspaceTarget)
+	if err != nil {
+		return container.RunOptions{}, cleanup, err
+	}
+	if len(roots) == 0 {
+		roots = []config.WorkspaceRoot{{Source: workDir, Target: workspaceTarget}}
+	}
+	cwdName, _ := cmd.Flags().GetString("workspace-cwd")
+	cwd, err := selectWorkspaceRoot(roots, cwdName, workDir)
+	if err != nil {
+		return container.RunOptions{}, cleanup, err
+	}
+	hostWorkDir := workDir
+	if cwd != nil {
+		workspaceTarget, hostWorkDir = cwd.Target, cwd.Source
+	}
+
+	stderrFile, err := splitOutputFile(cmd)
+	if err != nil {
+		return container.RunOptions{}, cleanup, err
+	}
+	filters, err := outputFilters()
+	if err != nil {
+		return container.RunOptions{}, cleanup, err
 	}
 
 	// Build mount configuration
-	mounts := []container.Mount{
-		{Source: workDir, Target: "/workspace", ReadOnly: false},
+	var mounts []container.Mount
+	workspaceSource := workDir
+	for _, root := range roots {
+		source, workspaceCleanup, err := prepareWorkspace(root.Source)
+		if err != nil {
+			return container.RunOptions{}, cleanup, err
+		}
+		cleanups = append(cleanups, workspaceCleanup)
+		mounts = append(mounts, container.Mount{Source: source, Target: root.Target, ReadOnly: false, Kind: container.MountDir})
+		if root.Target == workspaceTarget {
+			workspaceSource = source
+		}
 	}
 
 	// Add additional mounts from flags
@@ -51,10 +424,10 @@ func runContainer(cmd *cobra.Command, args []string) error {
 	for _, m := range extraMounts {
 		expanded, err := security.ExpandPath(m)
 		if err != nil {
-			return fmt.Errorf("invalid mount path %q: %w", m, err)
+			return container.RunOptions{}, cleanup, fmt.Errorf("invalid mount path %q: %w", m, err)
 		}
 		if err := security.ValidateMountPath(expanded); err != nil {
-			return fmt.Errorf("mount path denied %q: %w", m, err)
+			return container.RunOptions{}, cleanup, fmt.Errorf("mount path denied %q: %w", m, err)
 		}
 		mounts = append(mounts, container.Mount{Source: expanded, Target: expanded, ReadOnly: false})
 	}
@@ -64,14 +437,22 @@ func runContainer(cmd *cobra.Command, args []string) error {
 	for _, m := range roMounts {
 		expanded, err := security.ExpandPath(m)
 		if err != nil {
-			return fmt.Errorf("invalid mount path %q: %w", m, err)
+			return container.RunOptions{}, cleanup, fmt.Errorf("invalid mount path %q: %w", m, err)
 		}
 		if err := security.ValidateMountPath(expanded); err != nil {
-			return fmt.Errorf("mount path denied %q: %w", m, err)
+			return container.RunOptions{}, cleanup, fmt.Errorf("mount path denied %q: %w", m, err)
 		}
 		mounts = append(mounts, container.Mount{Source: expanded, Target: expanded, ReadOnly: true})
 	}
 
+	// Read-only references under /refs, outside the workspace and so
+	// outside change tracking
+	refs, err := refMounts(cmd)
+	if err != nil {
+		return container.RunOptions{}, cleanup, err
+	}
+	mounts = append(mounts, refs...)
+
 	// Add default mounts from config
 	for _, dm := range cfg.Mounts.Defaults {
 		expanded, err := security.ExpandPath(dm.Path)
@@ -86,14 +467,67 @@ func runContainer(cmd *cobra.Command, args []string) error {
 		mounts = append(mounts, container.Mount{Source: expanded, Target: expanded, ReadOnly: dm.ReadOnly})
 	}
 
+	// Add named volumes from config (e.g., build caches)
+	for _, v := range cfg.Mounts.Volumes {
+		if v.Name == "" || v.Path == "" {
+			fmt.Fprintf(os.Stderr, "Warning: skipping volume with missing name or path: %+v\n", v)
+			continue
+		}
+		mounts = append(mounts, container.Mount{Source: v.Name, Target: v.Path, Volume: true})
+	}
+
+	// Git metadata and submodule sources that live outside the workspace
+	gitStart := len(mounts)
+	for _, root := range roots {
+		mounts = append(mounts, collectGitMounts(root.Source, root.Target)...)
+	}
+	gitEnd := len(mounts)
+
+	// Point out directories the build configuration needs that are not
+	// mounted
+	for _, root := range roots {
+		suggestRefs(root.Source, mounts)
+	}
+
+	// Artifacts directory, writable even when workspace changes are discarded
+	artifacts, err := artifactsMount(workDir)
+	if err != nil {
+		return container.RunOptions{}, cleanup, err
+	}
+
+	// In --no-creds mode nothing credential-bearing reaches the container,
+	// whatever the config says; the result is audited before returning
+	noCreds, _ := cmd.Flags().GetBool("no-creds")
+	if !noCreds {
+		if noCreds, err = checkWorkspaceTrust(cmd, workDir, mounts); err != nil {
+			return container.RunOptions{}, cleanup, err
+		}
+	}
+	// Git metadata is written back to the host's repository, whose hooks
+	// and config run outside the sandbox, so only trusted sessions may
+	// write it
+	if noCreds {
+		for i := gitStart; i < gitEnd; i++ {
+			mounts[i].ReadOnly = true
+		}
+	}
+
 	// Build environment variables
 	env := make(map[string]string)
 
-	// Passthrough environment variables from config
-	for _, key := range cfg.Environment.Passthrough {
-		if val, ok := os.LookupEnv(key); ok {
-			env[key] = val
-		}
+	// Host time zone and locale, so timestamps and text match the host;
+	// passthrough and custom variables override them
+	for key, val := range localeEnv() {
+		env[key] = val
+	}
+
+	// Passthrough environment variables from config, by name or pattern
+	passthrough, err := security.PassthroughEnv(cfg.Environment.Passthrough, os.Environ())
+	if err != nil {
+		return container.RunOptions{}, cleanup, err
+	}
+	for key, val := range passthrough {
+		env[key] = val
 	}
 
 	// Custom environment variables from config
@@ -101,33 +535,175 @@ func runContainer(cmd *cobra.Command, args []string) error {
 		env[key] = val
 	}
 
-	// Handle Claude authentication (always needed for Claude to work)
-	claudeMounts, claudeEnv := credentials.CollectClaudeAuth(cfg)
-	mounts = append(mounts, claudeMounts...)
-	for k, v := range claudeEnv {
-		env[k] = v
+	if noCreds {
+		for key := range env {
+			if credentials.IsSecretEnv(key) {
+				fmt.Fprintf(os.Stderr, "Warning: omitting secret environment variable %s\n", key)
+				delete(env, key)
+			}
+		}
 	}
 
-	// Handle external credentials (unless disabled by flag)
-	noExtCreds, _ := cmd.Flags().GetBool("no-external-credentials")
-	if !noExtCreds {
-		extMounts, extEnv, err := credentials.CollectExternalCredentials(cfg)
+	// Telemetry opt-out applies after custom variables so it cannot be undone there
+	var blockedHosts []string
+	if cfg.Claude.DisableTelemetry {
+		for k, v := range container.TelemetryEnv {
+			env[k] = v
+		}
+		blockedHosts = container.TelemetryHosts
+		blockMounts, blockEnv, blockCleanup, err := startTelemetryBlock()
 		if err != nil {
-			return fmt.Errorf("failed to collect credentials: %w", err)
+			return container.RunOptions{}, cleanup, err
 		}
-		mounts = append(mounts, extMounts...)
-		for k, v := range extEnv {
+		cleanups = append(cleanups, blockCleanup)
+		mounts = append(mounts, blockMounts...)
+		for k, v := range blockEnv {
 			env[k] = v
 		}
 	}
 
-	// Get image name
-	imageName, _ := cmd.Flags().GetString("image")
-	if imageName == "" {
-		imageName = cfg.Image.Name
+	// Claude Code's own caches; XDG_CACHE_HOME moves ~/.cache onto the
+	// volume and the entrypoint links feature-flag state when ~/.claude is
+	// not mounted from the host. Untrusted --no-creds sessions get no shared
+	// volume they could poison for later sessions.
+	if cfg.Claude.CacheVolume && !noCreds {
+		mounts = append(mounts, container.Mount{Source: container.ClaudeCacheVolume, Target: container.ClaudeCachePath, Volume: true})
+		env["XDG_CACHE_HOME"] = container.ClaudeCachePath + "/xdg"
+		env["ENCLAUDE_CLAUDE_CACHE"] = container.ClaudeCachePath
+	}
+
+	// Custom subagents, slash commands, and output styles
+	definitionMounts, err := collectClaudeDefinitions()
+	if err != nil {
+		return container.RunOptions{}, cleanup, err
+	}
+	mounts = append(mounts, definitionMounts...)
+
+	// Locally built Claude Code, replacing the image's
+	binaryMounts, binaryEnv, err := collectClaudeBinary()
+	if err != nil {
+		return container.RunOptions{}, cleanup, err
+	}
+	mounts = append(mounts, binaryMounts...)
+	for k, v := range binaryEnv {
+		env[k] = v
+	}
+
+	if artifacts != nil {
+		mounts = append(mounts, *artifacts)
+		env["ENCLAUDE_ARTIFACTS"] = container.ArtifactsPath
+	}
+
+	// Shell rc injection and history persistence
+	shellMounts, shellEnv := collectShellEnvironment(workDir)
+	mounts = append(mounts, shellMounts...)
+	for k, v := range shellEnv {
+		env[k] = v
+	}
+
+	// Host editor bridge; overrides any passed-through EDITOR
+	editorMounts, editorEnv, editorCleanup, err := startEditorBridge()
+	if err != nil {
+		return container.RunOptions{}, cleanup, err
+	}
+	cleanups = append(cleanups, editorCleanup)
+	mounts = append(mounts, editorMounts...)
+	for k, v := range editorEnv {
+		env[k] = v
+	}
+
+	// Claude authentication and external credentials
+	credMounts, credEnv, credCleanup, err := collectCredentials(cmd, workDir, noCreds)
+	if err != nil {
+		return container.RunOptions{}, cleanup, err
+	}
+	cleanups = append(cleanups, credCleanup)
+	for i := range credMounts {
+		credMounts[i].Credential = true
+	}
+	mounts = append(mounts, credMounts...)
+	for k, v := range credEnv {
+		env[k] = v
+	}
+
+	for k, v := range wslWorkspaceEnv(wsl.Detect(), workspaceSource, workspaceTarget) {
+		env[k] = v
+	}
+
+	imageName := sessionImage(cmd)
+
+	// Expand the resource preset, with individual limits taking precedence
+	resources, err := cfg.Container.Resources()
+	if err != nil {
+		return container.RunOptions{}, cleanup, err
+	}
+
+	network, aliases, err := sessionNetwork()
+	if err != nil {
+		return container.RunOptions{}, cleanup, err
+	}
+
+	dockerArgs, err := sessionDockerArgs(cmd)
+	if err != nil {
+		return container.RunOptions{}, cleanup, err
+	}
+
+	// Build run options
+	opts = container.RunOptions{
+		Image:       imageName,
+		PullPolicy:  cfg.Image.PullPolicy,
+		Mounts:      mounts,
+		Environment: env,
+		ClaudeArgs:  sessionClaudeArgs(cmd, args),
+		WorkDir:     workspaceTarget,
+		HostWorkDir: hostWorkDir,
+		User:        cfg.Container.User,
+		MemoryLimit: resources.Memory,
+		Reservation: cfg.Container.MemoryReservation,
+		Swappiness:  memorySwappiness(),
+		CPUs:        resources.CPUs,
+		PidsLimit:   resources.PidsLimit,
+		TmpfsSize:   resources.TmpfsSize,
+		Network:     network,
+		Aliases:     aliases,
+		Interaction: cfg.Container.Interaction,
+		Security: container.SecurityOptions{
+			DropCapabilities: cfg.Security.DropCapabilities,
+			NoNewPrivileges:  cfg.Security.NoNewPrivileges,
+			ReadOnlyRoot:     cfg.Security.ReadOnlyRoot || cfg.Security.Ephemeral,
+			CACerts:          caCertPaths(),
+			Ephemeral:        cfg.Security.Ephemeral,
+		},
+		StderrFile:   stderrFile,
+		Hyperlinks:   hyperlinksEnabled(),
+		LinkFormat:   cfg.Terminal.LinkFormat,
+		Filters:      filters,
+		Ports:        cfg.Container.Ports,
+		BlockedHosts: blockedHosts,
+		DockerArgs:   dockerArgs,
+		Cgroup: container.CgroupOptions{
+			Parent:    cfg.Container.Cgroup.Parent,
+			CPUWeight: cfg.Container.Cgroup.CPUWeight,
+			IOWeight:  cfg.Container.Cgroup.IOWeight,
+		},
+	}
+
+	opts.NoCredentials = noCreds
+	if noCreds {
+		if err := credentials.AuditNoCredentials(opts); err != nil {
+			return container.RunOptions{}, cleanup, err
+		}
 	}
+	if err := guardEngineSockets(cmd, opts); err != nil {
+		return container.RunOptions{}, cleanup, err
+	}
+
+	return opts, cleanup, nil
+}
 
-	// Expand and validate CA certificate paths
+// caCertPaths expands security.ca_certs, skipping with a warning paths that
+// are invalid, denied, or missing
+func caCertPaths() []string {
 	var caCerts []string
 	for _, certPath := range cfg.Security.CACerts {
 		expanded, err := security.ExpandPath(certPath)
@@ -145,31 +721,856 @@ func runContainer(cmd *cobra.Command, args []string) error {
 		}
 		caCerts = append(caCerts, expanded)
 	}
+	return caCerts
+}
 
-	// Build run options
-	opts := container.RunOptions{
-		Image:       imageName,
-		Mounts:      mounts,
-		Environment: env,
-		ClaudeArgs:  args,
-		WorkDir:     "/workspace",
-		User:        cfg.Container.User,
-		MemoryLimit: cfg.Container.MemoryLimit,
-		Network:     cfg.Container.Network,
-		Security: container.SecurityOptions{
-			DropCapabilities: cfg.Security.DropCapabilities,
-			NoNewPrivileges:  cfg.Security.NoNewPrivileges,
-			ReadOnlyRoot:     cfg.Security.ReadOnlyRoot,
-			CACerts:          caCerts,
-		},
+// collectGitMounts returns mounts for repository metadata and local
+// submodule sources outside the workspace, as enabled in config. The
+// metadata is only writable when the workspace is: in copy mode and
+// ephemeral sessions it is mounted read-only so the original repository is
+// untouched.
+func collectGitMounts(workDir, target string) []container.Mount {
+	if !cfg.Git.MountGitDir && !cfg.Git.SubmoduleSources {
+		return nil
+	}
+	info, err := workspace.InspectGit(workDir, target)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to inspect git repository: %v\n", err)
+		return nil
 	}
 
-	// Create and run container
-	runner, err := container.NewRunner()
+	var mounts []container.Mount
+	seen := make(map[string]bool)
+	add := func(m *workspace.GitMount, readOnly bool) {
+		if seen[m.Target] || security.IsPathInDirectory(m.Source, workDir) {
+			return
+		}
+		if err := validateMountStrict(m.Source); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: skipping denied git mount %q: %v\n", m.Source, err)
+			return
+		}
+		seen[m.Target] = true
+		mounts = append(mounts, container.Mount{Source: m.Source, Target: m.Target, ReadOnly: readOnly})
+	}
+
+	if cfg.Git.MountGitDir && info.GitDir != nil {
+		if info.GitDir.Worktree != "" {
+			fmt.Fprintf(os.Stderr, "Warning: git expects this repository's work tree at %s in the container; open the superproject instead\n", info.GitDir.Worktree)
+		}
+		add(info.GitDir, cfg.Workspace.Mode == config.WorkspaceCopy || cfg.Security.Ephemeral)
+	}
+	if cfg.Git.SubmoduleSources {
+		for _, sm := range info.Submodules {
+			if sm.Local != nil {
+				add(sm.Local, true)
+			}
+		}
+	}
+	return mounts
+}
+
+// validateMountStrict refuses path if it is, or lies under, a denied or
+// credential-controlled path, or contains one, as a parent such as ~ does
+func validateMountStrict(path string) error {
+	if err := security.ValidateMountPathStrict(path); err != nil {
+		return err
+	}
+	if sensitive, ok := security.ContainedSensitivePath(path); ok {
+		return fmt.Errorf("it contains %s", sensitive)
+	}
+	return nil
+}
+
+// artifactsMount returns the mount for the configured artifacts directory,
+// creating it on the host if needed, or nil when none is configured. Relative
+// paths are resolved against the workspace, so each project gets its own.
+func artifactsMount(workDir string) (*container.Mount, error) {
+	dir := cfg.Workspace.Artifacts
+	if dir == "" {
+		return nil, nil
+	}
+	dir, err := resolveArtifactsDir(dir, workDir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid workspace.artifacts %q: %w", cfg.Workspace.Artifacts, err)
+	}
+	if err := validateMountStrict(dir); err != nil {
+		return nil, fmt.Errorf("artifacts directory denied %q: %w", dir, err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create artifacts directory: %w", err)
+	}
+	return &container.Mount{Source: dir, Target: container.ArtifactsPath, Kind: container.MountDir}, nil
+}
+
+// resolveArtifactsDir expands dir, resolving relative paths against workDir
+// rather than the current directory
+func resolveArtifactsDir(dir, workDir string) (string, error) {
+	if !filepath.IsAbs(dir) && dir != "~" && !strings.HasPrefix(dir, "~/") {
+		dir = filepath.Join(workDir, dir)
+	}
+	return security.ExpandPath(dir)
+}
+
+// splitOutputFile resolves the --split-output file that receives container
+// stderr, or returns "" when stderr stays on the terminal
+func splitOutputFile(cmd *cobra.Command) (string, error) {
+	path, _ := cmd.Flags().GetString("split-output")
+	if path == "" {
+		return "", nil
+	}
+	path, err := security.ExpandPath(path)
 	if err != nil {
-		return fmt.Errorf("failed to create container runner: %w", err)
+		return "", fmt.Errorf("invalid split output path: %w", err)
+	}
+	return path, nil
+}
+
+// outputFilters parses output.filters
+func outputFilters() ([]container.OutputFilter, error) {
+	var filters []container.OutputFilter
+	for _, spec := range cfg.Output.Filters {
+		f, err := container.ParseOutputFilter(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid output.filters: %w", err)
+		}
+		filters = append(filters, f)
+	}
+	return filters, nil
+}
+
+// hyperlinksEnabled reports whether workspace paths in session output should
+// become hyperlinks, detecting terminal support in auto mode
+func hyperlinksEnabled() bool {
+	switch cfg.Terminal.Hyperlinks {
+	case config.HyperlinksAlways:
+		return true
+	case config.HyperlinksNever:
+		return false
+	default:
+		return container.SupportsHyperlinks()
+	}
+}
+
+// resolveWorkDir returns the expanded working directory from the --workdir
+// flag, defaulting to the current directory
+func resolveWorkDir(cmd *cobra.Command) (string, error) {
+	workDir, _ := cmd.Flags().GetString("workdir")
+	if workDir == "" {
+		var err error
+		workDir, err = os.Getwd()
+		if err != nil {
+			return "", fmt.Errorf("failed to get current directory: %w", err)
+		}
+	}
+
+	// Expand and validate working directory
+	workDir, err := security.ExpandPath(workDir)
+	if err != nil {
+		return "", fmt.Errorf("invalid working directory: %w", err)
+	}
+	return workDir, nil
+}
+
+// refMounts returns read-only mounts for the --ref directories, each at
+// /refs/<name>
+func refMounts(cmd *cobra.Command) ([]container.Mount, error) {
+	flagRefs, _ := cmd.Flags().GetStringArray("ref")
+	var entries []config.WorkspaceEntry
+	for _, r := range flagRefs {
+		e := config.ParseWorkspaceEntry(r)
+		expanded, err := security.ExpandPath(e.Path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid reference %q: %w", r, err)
+		}
+		if err := security.ValidateMountPath(expanded); err != nil {
+			return nil, fmt.Errorf("reference denied %q: %w", r, err)
+		}
+		if !security.PathExists(expanded, true) {
+			return nil, fmt.Errorf("reference %q is not a directory", r)
+		}
+		e.Path = expanded
+		entries = append(entries, e)
+	}
+	refs, err := config.RefRoots(entries)
+	if err != nil {
+		return nil, err
+	}
+	mounts := make([]container.Mount, 0, len(refs))
+	for _, r := range refs {
+		mounts = append(mounts, container.Mount{Source: r.Source, Target: r.Target, ReadOnly: true, Kind: container.MountDir})
+	}
+	return mounts, nil
+}
+
+// suggestRefs prints a tip for each directory outside the workspace root
+// that go.mod or tsconfig.json points at and no mount covers
+func suggestRefs(root string, mounts []container.Mount) {
+	for _, ref := range workspace.SuggestRefs(root) {
+		covered := false
+		for _, m := range mounts {
+			if !m.Volume && security.IsPathInDirectory(ref.Path, m.Source) {
+				covered = true
+				break
+			}
+		}
+		if covered {
+			continue
+		}
+		arg := ref.Path
+		if wd, err := os.Getwd(); err == nil {
+			if rel, err := filepath.Rel(wd, ref.Path); err == nil {
+				arg = rel
+			}
+		}
+		fmt.Fprintf(os.Stderr, "Tip: %s refers to %s outside the workspace; mount it read-only with --ref %s\n", ref.Source, ref.Path, arg)
+	}
+}
+
+// workspaceRoots returns the roots of a multi-root workspace given with
+// --workspace and mounts.workspaces, or nil when there are none. workDir is
+// added as the first root unless it is inside one of them or holds them.
+// Relative config paths are resolved against workDir.
+func workspaceRoots(cmd *cobra.Command, workDir, target string) ([]config.WorkspaceRoot, error) {
+	var entries []config.WorkspaceEntry
+	flagRoots, _ := cmd.Flags().GetStringArray("workspace")
+	for _, r := range flagRoots {
+		entries = append(entries, config.ParseWorkspaceEntry(r))
+	}
+	for _, e := range cfg.Mounts.Workspaces {
+		if e.Path != "" && !filepath.IsAbs(e.Path) && !strings.HasPrefix(e.Path, "~") {
+			e.Path = filepath.Join(workDir, e.Path)
+		}
+		entries = append(entries, e)
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	related := false
+	for i, e := range entries {
+		expanded, err := security.ExpandPath(e.Path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid workspace root %q: %w", e.Path, err)
+		}
+		if err := validateMountStrict(expanded); err != nil {
+			return nil, fmt.Errorf("workspace root denied %q: %w", e.Path, err)
+		}
+		if !security.PathExists(expanded, true) {
+			return nil, fmt.Errorf("workspace root %q is not a directory", e.Path)
+		}
+		entries[i].Path = expanded
+		if security.IsPathInDirectory(workDir, expanded) || security.IsPathInDirectory(expanded, workDir) {
+			related = true
+		}
+	}
+	if !related {
+		entries = append([]config.WorkspaceEntry{{Path: workDir}}, entries...)
+	}
+
+	if cfg.Mounts.WorkspaceTarget == config.WorkspaceTargetHost {
+		target = config.WorkspaceTargetHost
+	}
+	return config.WorkspaceRoots(entries, target)
+}
+
+// selectWorkspaceRoot returns the root the session starts in: the one named
+// name, else the one holding workDir. It returns nil when workDir holds the
+// roots, so the session starts in the directory they are mounted under.
+func selectWorkspaceRoot(roots []config.WorkspaceRoot, name, workDir string) (*config.WorkspaceRoot, error) {
+	if name != "" {
+		var names []string
+		for i, r := range roots {
+			if r.Name == name {
+				return &roots[i], nil
+			}
+			names = append(names, r.Name)
+		}
+		return nil, fmt.Errorf("no workspace root named %q (roots: %s)", name, strings.Join(names, ", "))
+	}
+	for i, r := range roots {
+		if security.IsPathInDirectory(workDir, r.Source) {
+			return &roots[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// prepareWorkspace returns the host directory to mount as the workspace for the
+// configured workspace mode, and a cleanup function that removes any copy
+func prepareWorkspace(workDir string) (string, func(), error) {
+	switch cfg.Workspace.Mode {
+	case "", config.WorkspaceBind:
+		return workDir, func() {}, nil
+	case config.WorkspaceCopy:
+		// Snapshot the workspace so the container cannot modify the original
+		copyDir, err := os.MkdirTemp("", "enclaude-workspace-")
+		if err != nil {
+			return "", func() {}, fmt.Errorf("failed to create workspace copy: %w", err)
+		}
+		cleanup := func() { os.RemoveAll(copyDir) }
+		if err := workspace.Copy(workDir, copyDir, workspace.CopyOptions{IncludeIgnored: cfg.Workspace.IncludeIgnored}); err != nil {
+			cleanup()
+			return "", func() {}, fmt.Errorf("failed to copy workspace: %w", err)
+		}
+		return copyDir, cleanup, nil
+	default:
+		return "", func() {}, fmt.Errorf("invalid workspace mode %q (allowed: %s, %s)", cfg.Workspace.Mode, config.WorkspaceBind, config.WorkspaceCopy)
+	}
+}
+
+// collectCredentials gathers Claude authentication and external credentials
+// according to config and flags. Nothing is collected when noCreds is set.
+// The returned cleanup function removes any time-boxed credential staging.
+func collectCredentials(cmd *cobra.Command, workDir string, noCreds bool) ([]container.Mount, map[string]string, func(), error) {
+	var mounts []container.Mount
+	env := make(map[string]string)
+	cleanup := func() {}
+	if noCreds {
+		return mounts, env, cleanup, nil
+	}
+
+	// Handle Claude authentication (always needed for Claude to work); auto
+	// is narrowed to one method when both an API key and a session exist
+	authCfg := *cfg
+	authCfg.Claude.Auth = resolveClaudeAuth(workDir)
+	claudeMounts, claudeEnv := credentials.CollectClaudeAuth(&authCfg)
+	mounts = append(mounts, claudeMounts...)
+	for k, v := range claudeEnv {
+		env[k] = v
+	}
+
+	// Route Claude through Bedrock or Vertex AI when configured
+	providerMounts, providerEnv, err := credentials.CollectProviderAuth(cfg)
+	if err != nil {
+		return nil, nil, cleanup, err
+	}
+	mounts = append(mounts, providerMounts...)
+	for k, v := range providerEnv {
+		env[k] = v
+	}
+
+	// Handle external credentials (unless disabled by flag)
+	noExtCreds, _ := cmd.Flags().GetBool("no-external-credentials")
+	if noExtCreds {
+		return mounts, env, cleanup, nil
+	}
+
+	// Under WSL 2 the Windows SSH agent is relayed in when no Linux agent runs
+	relayCleanup := startWSLAgentRelay(wsl.Detect())
+	extMounts, extEnv, err := credentials.CollectExternalCredentials(cfg, workDir)
+	if err != nil {
+		relayCleanup()
+		return nil, nil, cleanup, fmt.Errorf("failed to collect credentials: %w", err)
+	}
+	cleanup = relayCleanup
+
+	// Time-box external credentials if a TTL is configured
+	if cfg.Credentials.TTL != "" {
+		ttl, err := time.ParseDuration(cfg.Credentials.TTL)
+		if err != nil {
+			relayCleanup()
+			return nil, nil, cleanup, fmt.Errorf("invalid credentials.ttl %q: %w", cfg.Credentials.TTL, err)
+		}
+		if ttl < time.Second {
+			relayCleanup()
+			return nil, nil, cleanup, fmt.Errorf("invalid credentials.ttl %q: must be at least 1s", cfg.Credentials.TTL)
+		}
+		timeBoxed, err := credentials.TimeBox(extMounts, extEnv, ttl)
+		if err != nil {
+			relayCleanup()
+			return nil, nil, cleanup, err
+		}
+		for _, source := range timeBoxed.Unrevoked {
+			fmt.Fprintf(os.Stderr, "Warning: credentials.ttl cannot revoke %s, which is not a regular file; it stays usable for the whole session\n", source)
+		}
+		cleanup = func() {
+			timeBoxed.Cleanup()
+			relayCleanup()
+		}
+		extMounts, extEnv = timeBoxed.Mounts, timeBoxed.Env
+	}
+	mounts = append(mounts, extMounts...)
+	for k, v := range extEnv {
+		env[k] = v
+	}
+
+	return mounts, env, cleanup, nil
+}
+
+// startHostCommands starts the host command bridge when enabled in config
+// and adds its mount and environment to opts. Each request is audited under
+// the run ID that recordRun assigns before the container starts. The
+// returned function stops the bridge. Sessions without host credentials
+// get no bridge.
+func startHostCommands(opts *container.RunOptions) (func(), error) {
+	if !cfg.HostCommands.Enabled {
+		return func() {}, nil
+	}
+	if opts.NoCredentials {
+		fmt.Fprintln(os.Stderr, "Warning: host commands are disabled for sessions without host credentials")
+		return func() {}, nil
+	}
+
+	dir, err := state.Dir()
+	if err != nil {
+		return nil, fmt.Errorf("host commands require the audit log: %w", err)
+	}
+	bridge, err := hostexec.Start(cfg.HostCommands.Allow, func(args []string, exitCode int, err error) {
+		details := map[string]interface{}{"command": args, "exit_code": exitCode}
+		if err != nil {
+			details["error"] = err.Error()
+		}
+		audit(dir, state.AuditEvent{Event: "host_command", RunID: opts.RunID, Details: details})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to start host command bridge: %w", err)
+	}
+
+	opts.Mounts = append(opts.Mounts, bridge.Mount())
+	if opts.Environment == nil {
+		opts.Environment = make(map[string]string)
+	}
+	for k, v := range bridge.Env() {
+		opts.Environment[k] = v
+	}
+	return bridge.Close, nil
+}
+
+// startAPIProxy keeps ANTHROPIC_API_KEY on the host when claude.secretless
+// is set: the session gets a placeholder key and the proxy's relay, and the
+// proxy adds the real key to each request. The number of requests is audited
+// under the run ID when the returned function stops the proxy.
+func startAPIProxy(opts *container.RunOptions) (func(), error) {
+	key := opts.Environment["ANTHROPIC_API_KEY"]
+	if !cfg.Claude.Secretless || key == "" {
+		return func() {}, nil
+	}
+
+	dir, err := state.Dir()
+	if err != nil {
+		return nil, fmt.Errorf("secretless mode requires the audit log: %w", err)
+	}
+	// The key goes where the user config or the host environment says,
+	// never where the session's environment, which a project can set, says
+	upstream := cfg.Claude.SecretlessUpstream
+	if upstream == "" {
+		upstream = os.Getenv("ANTHROPIC_BASE_URL")
+	}
+	if upstream == "" {
+		upstream = apiproxy.DefaultUpstream
+	}
+	if session := opts.Environment["ANTHROPIC_BASE_URL"]; session != "" && session != upstream {
+		fmt.Fprintf(os.Stderr, "Warning: secretless mode sends the API key to %s, not ANTHROPIC_BASE_URL %s; set claude.secretless_upstream to change it\n", upstream, session)
+	}
+	proxy, err := apiproxy.Start(key, upstream)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start API proxy: %w", err)
+	}
+
+	opts.Mounts = append(opts.Mounts, proxy.Mount())
+	for k, v := range proxy.Env() {
+		opts.Environment[k] = v
+	}
+	return func() {
+		proxy.Close()
+		audit(dir, state.AuditEvent{Event: "api_proxy", RunID: opts.RunID, Details: map[string]interface{}{
+			"upstream": upstream,
+			"requests": proxy.Requests(),
+		}})
+	}, nil
+}
+
+// checkSecretless refuses to start a session whose environment still holds
+// the real ANTHROPIC_API_KEY when claude.secretless is set, so an entry point
+// that skips startAPIProxy fails rather than hand the key over
+func checkSecretless(env map[string]string) error {
+	if !cfg.Claude.Secretless {
+		return nil
+	}
+	if key := env["ANTHROPIC_API_KEY"]; key != "" && key != apiproxy.PlaceholderKey {
+		return fmt.Errorf("claude.secretless is set, but this session would get the real ANTHROPIC_API_KEY")
+	}
+	return nil
+}
+
+// startSockets forwards the host sockets configured under sockets, warning
+// about what each one exposes and auditing it under the run ID. Proxied
+// sockets also audit each connection. The returned function stops the
+// proxies. Sessions without host credentials get no sockets.
+func startSockets(opts *container.RunOptions, dangerous bool) (func(), error) {
+	if len(cfg.Sockets) == 0 {
+		return func() {}, nil
+	}
+	if opts.NoCredentials {
+		fmt.Fprintln(os.Stderr, "Warning: sockets are not forwarded to sessions without host credentials")
+		return func() {}, nil
+	}
+	forwards, err := sockets.Resolve(cfg.Sockets, dangerous)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := state.Dir()
+	if err != nil {
+		return nil, fmt.Errorf("socket forwarding requires the audit log: %w", err)
+	}
+	var proxies []*sockets.Proxy
+	stop := func() {
+		for _, p := range proxies {
+			p.Close()
+		}
+	}
+	for _, f := range forwards {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", sockets.Warning(f))
+		mount := f.Mount()
+		if f.Mode == config.SocketProxy {
+			p, err := sockets.StartProxy(f, func(f sockets.Forward, sent, received int64, d time.Duration, err error) {
+				details := map[string]interface{}{"name": f.Name, "bytes_sent": sent, "bytes_received": received, "duration_ms": d.Milliseconds()}
+				if err != nil {
+					details["error"] = err.Error()
+				}
+				audit(dir, state.AuditEvent{Event: "socket_connection", RunID: opts.RunID, Details: details})
+			})
+			if err != nil {
+				stop()
+				return nil, err
+			}
+			proxies = append(proxies, p)
+			mount = p.Mount()
+		}
+
+		opts.Mounts = append(opts.Mounts, mount)
+		if f.Env != "" {
+			if opts.Environment == nil {
+				opts.Environment = make(map[string]string)
+			}
+			opts.Environment[f.Env] = f.Target
+		}
+		audit(dir, state.AuditEvent{Event: "socket_forward", RunID: opts.RunID, Details: map[string]interface{}{
+			"name":      f.Name,
+			"source":    f.Source,
+			"target":    f.Target,
+			"mode":      f.Mode,
+			"dangerous": f.Dangerous,
+		}})
+	}
+	return stop, nil
+}
+
+// hostServices names the parts of the session set up above that run on the
+// host, or are torn down, when enclaude exits, so detaching would leave the
+// session without them
+func hostServices(opts container.RunOptions) []string {
+	var services []string
+	for _, s := range []struct{ env, name string }{
+		{"ENCLAUDE_API_SOCKET", "the API proxy"},
+		{"ENCLAUDE_HOST_SOCKET", "host commands"},
+		{"ENCLAUDE_SHELL_SOCKET", "the shell policy"},
+		{"ENCLAUDE_ACCESS_SOCKET", "access requests"},
+		{"ENCLAUDE_EDITOR_SOCKET", "the editor bridge"},
+		{"ENCLAUDE_HOSTPORT_DIR", "forwarded host ports"},
+		{"ENCLAUDE_FASTFS_HOST", "the --fast-fs copy back"},
+		{"ENCLAUDE_CREDENTIAL_TTL", "time-boxed credentials"},
+	} {
+		if _, ok := opts.Environment[s.env]; ok {
+			services = append(services, s.name)
+		}
+	}
+	if len(cfg.Sockets) > 0 && !opts.NoCredentials {
+		services = append(services, "forwarded sockets")
+	}
+	if cfg.Workspace.Mode == config.WorkspaceCopy {
+		services = append(services, "the workspace copy")
+	}
+	for _, m := range opts.Mounts {
+		if m.Target == container.Home+"/.claude" && !m.Volume && !m.ReadOnly && cfg.Claude.SessionLock != config.SessionLockOff {
+			services = append(services, "the ~/.claude session lock")
+		}
+	}
+	return services
+}
+
+// startHostPorts makes the host ports in network.reverse_forward and the
+// --reverse-forward flags reachable from the session at
+// host.enclaude.internal, auditing them and each connection under the run
+// ID. Sessions without host credentials reach no host ports. The returned
+// function stops forwarding.
+func startHostPorts(cmd *cobra.Command, opts *container.RunOptions) (func(), error) {
+	flagPorts, _ := cmd.Flags().GetIntSlice("reverse-forward")
+	ports := append(append([]int{}, cfg.Network.ReverseForward...), flagPorts...)
+	if len(ports) == 0 {
+		return func() {}, nil
+	}
+	if opts.NoCredentials {
+		fmt.Fprintln(os.Stderr, "Warning: host ports are not forwarded for sessions without host credentials")
+		return func() {}, nil
+	}
+
+	dir, err := state.Dir()
+	if err != nil {
+		return nil, fmt.Errorf("host port forwarding requires the audit log: %w", err)
+	}
+	forwarder, err := hostports.Start(ports, func(port int, sent, received int64, d time.Duration, err error) {
+		details := map[string]interface{}{"port": port, "bytes_sent": sent, "bytes_received": received, "duration_ms": d.Milliseconds()}
+		if err != nil {
+			details["error"] = err.Error()
+		}
+		audit(dir, state.AuditEvent{Event: "host_port_connection", RunID: opts.RunID, Details: details})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]string, len(forwarder.Ports()))
+	for i, port := range forwarder.Ports() {
+		list[i] = strconv.Itoa(port)
+	}
+	if opts.Network == config.NetworkNone {
+		fmt.Fprintf(os.Stderr, "Warning: host ports %s are reachable at %s despite container.network none\n", strings.Join(list, ", "), hostports.Hostname)
+	} else {
+		fmt.Fprintf(os.Stderr, "Note: host ports %s are reachable at %s\n", strings.Join(list, ", "), hostports.Hostname)
+	}
+
+	opts.Mounts = append(opts.Mounts, forwarder.Mount())
+	opts.HostEntries = append(opts.HostEntries, forwarder.HostEntry())
+	if opts.Environment == nil {
+		opts.Environment = make(map[string]string)
+	}
+	for k, v := range forwarder.Env() {
+		opts.Environment[k] = v
+	}
+	audit(dir, state.AuditEvent{Event: "host_port_forward", RunID: opts.RunID, Details: map[string]interface{}{
+		"ports": forwarder.Ports(),
+	}})
+	return forwarder.Close, nil
+}
+
+// dangerousAllowed reports whether --dangerous, or the older
+// --i-know-what-im-doing it replaces, was given
+func dangerousAllowed(cmd *cobra.Command) bool {
+	dangerous, _ := cmd.Flags().GetBool("dangerous")
+	legacy, _ := cmd.Flags().GetBool("i-know-what-im-doing")
+	return dangerous || legacy
+}
+
+// guardEngineSockets refuses mounts that expose a container engine socket,
+// the most common way out of a sandbox, unless --dangerous is given, as it
+// is for sockets forwarded under sockets. Every attempt is audited, whether
+// refused or allowed.
+func guardEngineSockets(cmd *cobra.Command, opts container.RunOptions) error {
+	allow := dangerousAllowed(cmd)
+	for _, m := range opts.Mounts {
+		if m.Volume {
+			continue
+		}
+		socket, found := security.ContainedEngineSocket(m.Source)
+		if !found {
+			continue
+		}
+		if dir, err := state.Dir(); err == nil {
+			audit(dir, state.AuditEvent{Event: "engine_socket_mount", Details: map[string]interface{}{
+				"source":  m.Source,
+				"target":  m.Target,
+				"socket":  socket,
+				"allowed": allow,
+			}})
+		}
+		if !allow {
+			return fmt.Errorf("mount %s exposes the container engine socket %s, which amounts to root on the host; pass --dangerous to mount it anyway", m.Source, socket)
+		}
+		fmt.Fprintf(os.Stderr, "Warning: mount %s exposes the container engine socket %s; the session can take over the host\n", m.Source, socket)
+	}
+	return nil
+}
+
+// sessionNetwork returns the network the session joins and its DNS aliases
+// there: network.create under network.alias, or container.network
+func sessionNetwork() (string, []string, error) {
+	name := cfg.Network.Create
+	if name == "" {
+		return cfg.Container.Network, nil, nil
+	}
+	if config.IsBuiltinNetwork(name) || !config.ValidNetworkName(name) {
+		return "", nil, fmt.Errorf("invalid network.create %q: must name a user-defined network", name)
+	}
+	if n := cfg.Container.Network; n != "" && n != config.NetworkBridge && n != name {
+		return "", nil, fmt.Errorf("network.create %q conflicts with container.network %q; set only one", name, n)
+	}
+	var aliases []string
+	if cfg.Network.Alias != "" {
+		aliases = []string{cfg.Network.Alias}
+	}
+	return name, aliases, nil
+}
+
+// sessionDockerArgs returns container.extra_docker_args followed by the
+// --docker-opt flags, refusing flags that would weaken the sandbox
+func sessionDockerArgs(cmd *cobra.Command) ([]string, error) {
+	opts, _ := cmd.Flags().GetStringArray("docker-opt")
+	args := append(append([]string{}, cfg.Container.ExtraDockerArgs...), opts...)
+	if _, err := container.ParseDockerArgs(args); err != nil {
+		return nil, err
+	}
+	return args, nil
+}
+
+// memorySwappiness returns container.memory_swappiness, or nil for the host
+// default when it is negative
+func memorySwappiness() *int64 {
+	if cfg.Container.MemorySwappiness < 0 {
+		return nil
+	}
+	swappiness := int64(cfg.Container.MemorySwappiness)
+	return &swappiness
+}
+
+// startShellPolicy starts the shell policy bridge when enabled in config
+// and points Claude's shell at its wrapper. Every command is audited under
+// the run ID, with whether it was blocked. The returned function stops the
+// bridge.
+func startShellPolicy(opts *container.RunOptions) (func(), error) {
+	if !cfg.ShellPolicy.Enabled {
+		return func() {}, nil
+	}
+
+	policy, err := shellpolicy.NewPolicy(cfg.ShellPolicy.Allow, cfg.ShellPolicy.Deny, cfg.ShellPolicy.BlockDangerous)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := state.Dir()
+	if err != nil {
+		return nil, fmt.Errorf("the shell policy requires the audit log: %w", err)
+	}
+	bridge, err := shellpolicy.Start(policy, func(command, cwd string, allowed bool, reason string) {
+		details := map[string]interface{}{"command": command, "cwd": cwd, "allowed": allowed}
+		if reason != "" {
+			details["reason"] = reason
+		}
+		audit(dir, state.AuditEvent{Event: "shell_command", RunID: opts.RunID, Details: details})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to start shell policy bridge: %w", err)
+	}
+
+	opts.Mounts = append(opts.Mounts, bridge.Mount())
+	if opts.Environment == nil {
+		opts.Environment = make(map[string]string)
+	}
+	for k, v := range bridge.Env() {
+		opts.Environment[k] = v
+	}
+	return bridge.Close, nil
+}
+
+// startAccessRequests starts the access request bridge when enabled in
+// config, adding its mounts and environment to opts and routing its requests
+// to the session menu. Each request is audited under the run ID. The returned
+// function stops the bridge.
+func startAccessRequests(opts *container.RunOptions) (func(), error) {
+	if !cfg.AccessRequests.Enabled {
+		return func() {}, nil
+	}
+
+	dir, err := state.Dir()
+	if err != nil {
+		return nil, fmt.Errorf("access requests require the audit log: %w", err)
+	}
+	bridge, err := access.Start(opts.WorkDir, opts.HostWorkDir, func(hostPath string, granted bool, err error) {
+		details := map[string]interface{}{"path": hostPath, "granted": granted}
+		if err != nil {
+			details["error"] = err.Error()
+		}
+		audit(dir, state.AuditEvent{Event: "access_request", RunID: opts.RunID, Details: details})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to start access request bridge: %w", err)
+	}
+
+	opts.Mounts = append(opts.Mounts, bridge.Mounts()...)
+	if opts.Environment == nil {
+		opts.Environment = make(map[string]string)
+	}
+	for k, v := range bridge.Env() {
+		opts.Environment[k] = v
+	}
+	opts.Approvals = bridge.Approvals()
+	return bridge.Close, nil
+}
+
+// startEditorBridge starts the host editor bridge when enabled in config and
+// returns its mount, environment, and a cleanup function that stops it
+func startEditorBridge() ([]container.Mount, map[string]string, func(), error) {
+	if !cfg.Editor.Bridge {
+		return nil, nil, func() {}, nil
+	}
+
+	command := editor.HostCommand(cfg.Editor.Command)
+	if editor.IsTerminalEditor(command) {
+		fmt.Fprintf(os.Stderr, "Warning: host editor %q runs in the terminal the session is using; set editor.command to a GUI editor that waits, e.g. \"code --wait\"\n", command)
+	}
+
+	bridge, err := editor.Start(command)
+	if err != nil {
+		return nil, nil, func() {}, fmt.Errorf("failed to start editor bridge: %w", err)
+	}
+	return []container.Mount{bridge.Mount()}, bridge.Env(), bridge.Close, nil
+}
+
+// startTelemetryBlock starts the proxy refusing the telemetry domains and
+// their subdomains, and returns its mount, environment, and a cleanup
+// function that removes it
+func startTelemetryBlock() ([]container.Mount, map[string]string, func(), error) {
+	block, err := domainblock.Start(container.TelemetryHosts)
+	if err != nil {
+		return nil, nil, func() {}, fmt.Errorf("failed to start telemetry blocking: %w", err)
+	}
+	return []container.Mount{block.Mount()}, block.Env(), block.Close, nil
+}
+
+// collectShellEnvironment builds mounts and environment variables for the
+// curated shell rc files and per-project history volume from config
+func collectShellEnvironment(workDir string) ([]container.Mount, map[string]string) {
+	var mounts []container.Mount
+	env := make(map[string]string)
+
+	// rc files are mounted into the container HOME
+	rcFiles := []struct{ path, target string }{
+		{cfg.Shell.Bashrc, container.Home + "/.bashrc"},
+		{cfg.Shell.Zshrc, container.Home + "/.zshrc"},
+	}
+	for _, rc := range rcFiles {
+		path, target := rc.path, rc.target
+		if path == "" {
+			continue
+		}
+		expanded, err := security.ExpandPath(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: skipping invalid shell rc path %q: %v\n", path, err)
+			continue
+		}
+		if err := security.ValidateMountPath(expanded); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: skipping denied shell rc path %q: %v\n", path, err)
+			continue
+		}
+		if !security.FileExists(expanded) {
+			fmt.Fprintf(os.Stderr, "Warning: shell rc file not found %q\n", expanded)
+			continue
+		}
+		mounts = append(mounts, container.Mount{Source: expanded, Target: target, ReadOnly: true, Kind: container.MountFile})
+	}
+
+	if cfg.Shell.PersistHistory {
+		mounts = append(mounts, container.Mount{
+			Source: container.ProjectVolumeName("history", workDir),
+			Target: "/var/lib/enclaude/history",
+			Volume: true,
+		})
+		env["HISTFILE"] = "/var/lib/enclaude/history/.shell_history"
+		env["HISTSIZE"] = "10000"
+		env["SAVEHIST"] = "10000"
+		// Append after every command so history survives abrupt exits
+		env["PROMPT_COMMAND"] = "history -a"
 	}
-	defer runner.Close()
 
-	return runner.Run(ctx, cancel, opts)
+	return mounts, env
 }
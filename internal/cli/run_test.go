@@ -0,0 +1,277 @@
+package cli
+
+import (
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jakenelson/enclaude/internal/config"
+	"github.com/jakenelson/enclaude/internal/container"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func TestResolveArtifactsDir(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+	workDir := t.TempDir()
+
+	tests := []struct {
+		dir  string
+		want string
+	}{
+		{"artifacts", filepath.Join(workDir, "artifacts")},
+		{"out/reports", filepath.Join(workDir, "out", "reports")},
+		{"/var/tmp/reports", "/var/tmp/reports"},
+		{"~/reports", filepath.Join(home, "reports")},
+	}
+	for _, tt := range tests {
+		got, err := resolveArtifactsDir(tt.dir, workDir)
+		if err != nil {
+			t.Errorf("resolveArtifactsDir(%q) error: %v", tt.dir, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("resolveArtifactsDir(%q) = %q, want %q", tt.dir, got, tt.want)
+		}
+	}
+}
+
+func TestSelectWorkspaceRoot(t *testing.T) {
+	roots := []config.WorkspaceRoot{
+		{Name: "api", Source: "/src/api", Target: "/workspace/api"},
+		{Name: "web", Source: "/src/web", Target: "/workspace/web"},
+	}
+
+	tests := []struct {
+		name, workDir string
+		want          string // Root name, or "" for the directory holding the roots
+		wantErr       bool
+	}{
+		{"", "/src/api", "api", false},
+		{"", "/src/web/cmd", "web", false},
+		{"", "/src", "", false},
+		{"web", "/src/api", "web", false},
+		{"docs", "/src/api", "", true},
+	}
+	for _, tt := range tests {
+		got, err := selectWorkspaceRoot(roots, tt.name, tt.workDir)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("selectWorkspaceRoot(%q, %q) error = %v, wantErr %v", tt.name, tt.workDir, err, tt.wantErr)
+			continue
+		}
+		gotName := ""
+		if got != nil {
+			gotName = got.Name
+		}
+		if gotName != tt.want {
+			t.Errorf("selectWorkspaceRoot(%q, %q) = %q, want %q", tt.name, tt.workDir, gotName, tt.want)
+		}
+	}
+}
+
+func TestShareBridgeDirs(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+	bridge, err := os.MkdirTemp("", "enclaude-host-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener, err := net.Listen("unix", filepath.Join(bridge, "host.sock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	staged, err := os.MkdirTemp("", "enclaude-creds-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := container.RunOptions{Mounts: []container.Mount{
+		{Source: bridge, Kind: container.MountDir},
+		{Source: filepath.Join(staged, "token"), Kind: container.MountFile},
+	}}
+
+	shareBridgeDirs(opts)
+	if info, _ := os.Stat(bridge); info.Mode().Perm() != 0700 {
+		t.Errorf("without a random uid, bridge dir mode = %v, want it unchanged", info.Mode().Perm())
+	}
+
+	opts.HostUID = 100000
+	shareBridgeDirs(opts)
+	if info, _ := os.Stat(bridge); info.Mode().Perm() != 0750 {
+		t.Errorf("bridge dir mode = %v, want 0750", info.Mode().Perm())
+	}
+	if info, _ := os.Stat(filepath.Join(bridge, "host.sock")); info.Mode().Perm() != 0660 {
+		t.Errorf("socket mode = %v, want 0660", info.Mode().Perm())
+	}
+	if info, _ := os.Stat(staged); info.Mode().Perm() != 0700 {
+		t.Errorf("dir without sockets has mode %v, want it unchanged", info.Mode().Perm())
+	}
+}
+
+func TestSecretlessSessions(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("ANTHROPIC_API_KEY", "sk-ant-real")
+	t.Setenv("ANTHROPIC_BASE_URL", "")
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	viper.Set("claude.secretless", true)
+	saved := cfg
+	cfg = config.LoadConfig()
+	t.Cleanup(func() { cfg = saved })
+
+	real := map[string]string{"ANTHROPIC_API_KEY": "sk-ant-real"}
+	if err := checkSecretless(real); err == nil {
+		t.Error("checkSecretless() passed the real key")
+	}
+
+	// Local sessions, run, ci and serve, swap the key through the proxy
+	opts := container.RunOptions{Environment: map[string]string{"ANTHROPIC_API_KEY": "sk-ant-real"}}
+	stop, err := startAPIProxy(&opts)
+	if err != nil {
+		t.Fatalf("startAPIProxy() error = %v", err)
+	}
+	defer stop()
+	for k, v := range opts.Environment {
+		if strings.Contains(v, "sk-ant-real") {
+			t.Errorf("session env %s holds the real key", k)
+		}
+	}
+	if err := checkSecretless(opts.Environment); err != nil {
+		t.Errorf("checkSecretless() after the proxy = %v", err)
+	}
+
+	// Pods cannot reach the proxy, so the session is refused
+	session, cleanup, err := k8sSession(k8sExecCmd, nil)
+	defer cleanup()
+	if err == nil || !strings.Contains(err.Error(), "secretless") {
+		t.Errorf("k8sSession() = %v, %v; want a secretless error", session.Env, err)
+	}
+
+	cfg.Claude.Secretless = false
+	if err := checkSecretless(real); err != nil {
+		t.Errorf("checkSecretless() without secretless = %v", err)
+	}
+}
+
+func TestK8sSessionWorkDir(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	workDir := t.TempDir()
+	for _, args := range [][]string{{"init", "-q"}, {"remote", "add", "origin", "git@github.com:octo/widgets.git"}} {
+		if out, err := exec.Command("git", append([]string{"-C", workDir}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	viper.Set("credentials.github", config.CredentialApp)
+	viper.Set("credentials.github_app.app_id", "12345")
+	viper.Set("credentials.github_app.private_key", filepath.Join(workDir, "missing.pem"))
+	saved := cfg
+	cfg = config.LoadConfig()
+	t.Cleanup(func() { cfg = saved })
+
+	cmd := &cobra.Command{}
+	cmd.Flags().AddFlagSet(k8sExecCmd.Flags())
+	t.Cleanup(func() {
+		cmd.Flags().Set("workdir", "")
+		cmd.Flags().Set("pod-workdir", "")
+	})
+	cmd.Flags().Set("workdir", workDir)
+	cmd.Flags().Set("pod-workdir", "/src")
+
+	// Credentials are collected for the host workspace in --workdir, not the
+	// directory enclaude runs in or the directory in the pod
+	session, cleanup, err := k8sSession(cmd, nil)
+	defer cleanup()
+	if err == nil || !strings.Contains(err.Error(), "octo/widgets") {
+		t.Errorf("k8sSession() error = %v, want the GitHub App scoped to the --workdir repository", err)
+	}
+	if session.WorkDir != "/src" {
+		t.Errorf("k8sSession() pod workdir = %q, want /src", session.WorkDir)
+	}
+}
+
+func TestRecordRunReservesRandomUser(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	viper.Set("container.user", config.UserRandom)
+	saved := cfg
+	cfg = config.LoadConfig()
+	t.Cleanup(func() { cfg = saved })
+
+	// Every entry point, serve included, records the run through
+	// startSession, which is what keeps a later session off its uid
+	opts := container.RunOptions{User: "123456:1000", HostUID: 223456}
+	finish := recordRun(&opts)
+	if !randomUsersInUse()[123456] {
+		t.Error("randomUsersInUse() misses the uid of a recorded running session")
+	}
+	finish(nil)
+	if randomUsersInUse()[123456] {
+		t.Error("randomUsersInUse() still holds the uid of an ended session")
+	}
+}
+
+func TestSplitOutputFile(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	cwd, _ := os.Getwd()
+
+	tests := []struct {
+		flag string
+		want string
+	}{
+		{"", ""},
+		{"~/err.log", filepath.Join(home, "err.log")},
+		{"logs/../err.log", filepath.Join(cwd, "err.log")},
+		{"/tmp/err.log", "/tmp/err.log"},
+	}
+	for _, tt := range tests {
+		cmd := &cobra.Command{}
+		cmd.Flags().String("split-output", "", "")
+		cmd.Flags().Set("split-output", tt.flag)
+		got, err := splitOutputFile(cmd)
+		if err != nil {
+			t.Errorf("splitOutputFile(%q) error = %v", tt.flag, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("splitOutputFile(%q) = %q, want %q", tt.flag, got, tt.want)
+		}
+	}
+}
+
+func TestDetachedHint(t *testing.T) {
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	saved := cfg
+	cfg = config.LoadConfig()
+	t.Cleanup(func() { cfg = saved })
+
+	tests := []struct {
+		engine    string
+		namespace string
+		id        string
+		want      string
+	}{
+		{config.EngineDocker, "", "0123456789abcdef0123", "'docker attach 0123456789ab' or stop it with 'docker rm -f 0123456789ab'"},
+		{config.EngineDocker, "", "abc", "'docker attach abc'"},
+		{config.EngineContainerd, "", "0123456789abcdef", "'nerdctl attach 0123456789ab' or stop it with 'nerdctl rm -f 0123456789ab'"},
+		{config.EngineContainerd, container.DefaultNamespace, "abc", "'nerdctl attach abc'"},
+		{config.EngineContainerd, "k8s.io", "abc", "'nerdctl --namespace k8s.io attach abc' or stop it with 'nerdctl --namespace k8s.io rm -f abc'"},
+	}
+	for _, tt := range tests {
+		cfg.Container.Engine, cfg.Container.Namespace = tt.engine, tt.namespace
+		if got := detachedHint("the session", tt.id); !strings.Contains(got, tt.want) {
+			t.Errorf("detachedHint() on %s %q for %q = %q, want %s", tt.engine, tt.namespace, tt.id, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/jakenelson/enclaude/internal/security"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(securityCmd)
+	securityCmd.AddCommand(securityReportCmd)
+
+	securityReportCmd.Flags().StringP("workdir", "w", "", "workspace whose pins to apply (default: current directory)")
+	securityReportCmd.Flags().String("format", "text", "output format: text, json")
+}
+
+var securityCmd = &cobra.Command{
+	Use:   "security",
+	Short: "Review the security posture of the configuration",
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var securityReportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Score the effective configuration against a security benchmark",
+	Long: `Evaluate the effective configuration, including the project config, profile,
+and workspace pins, against a built-in benchmark and print a scored report
+with recommendations.
+
+The benchmark covers network exposure, credential breadth, writable mounts,
+image provenance, and container isolation. Each check is weighted by its
+severity (high 3, medium 2, low 1), and the score is the weighted share of
+checks passed. Only the configuration is examined, so flags such as
+--no-external-credentials given to a particular session are not reflected.
+
+Examples:
+  enclaude security report
+  enclaude --profile work security report --format json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+		if format != "text" && format != "json" {
+			return fmt.Errorf("invalid --format %q (allowed: text, json)", format)
+		}
+
+		report := security.Evaluate(cfg)
+		if format == "json" {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(report)
+		}
+		printSecurityReport(report)
+		return nil
+	},
+}
+
+// printSecurityReport prints the report grouped by category, followed by
+// the recommendations for failed checks, most severe first
+func printSecurityReport(report security.Report) {
+	fmt.Printf("Security score: %d/100\n", report.Score)
+	for _, c := range report.Categories {
+		fmt.Printf("\n%s: %d/100\n", c.Name, c.Score)
+		for _, f := range report.Findings {
+			if f.Category != c.Name {
+				continue
+			}
+			if f.Passed {
+				fmt.Printf("  ✅ %s\n", f.Title)
+				continue
+			}
+			fmt.Printf("  ❌ %s (%s)\n", f.Title, f.Severity)
+			if f.Detail != "" {
+				fmt.Printf("     %s\n", f.Detail)
+			}
+		}
+	}
+
+	failed := report.Failed()
+	if len(failed) == 0 {
+		fmt.Println("\nNo recommendations: every check passed.")
+		return
+	}
+	fmt.Println("\nRecommendations:")
+	for i, f := range failed {
+		fmt.Printf("  %d. [%s] %s\n", i+1, f.Severity, f.Recommendation)
+	}
+}
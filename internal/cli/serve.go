@@ -0,0 +1,195 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/jakenelson/enclaude/internal/config"
+	"github.com/jakenelson/enclaude/internal/container"
+	"github.com/jakenelson/enclaude/internal/web"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+
+	serveCmd.Flags().Bool("web", false, "serve the session TTY over an authenticated WebSocket (experimental)")
+	serveCmd.Flags().String("listen", "127.0.0.1:7681", "address to listen on; other than loopback, requires --tls-cert and --tls-key")
+	serveCmd.Flags().String("tls-cert", "", "TLS certificate file, to serve over wss://")
+	serveCmd.Flags().String("tls-key", "", "TLS private key file for --tls-cert")
+	serveCmd.Flags().StringArray("allow-origin", nil, "browser origin allowed to attach besides the server's own, e.g. https://ui.example.com (repeatable)")
+	serveCmd.Flags().StringP("workdir", "w", "", "working directory to mount (default: current directory)")
+	serveCmd.Flags().String("image", "", "Docker image to use (default: enclaude:latest)")
+}
+
+var serveCmd = &cobra.Command{
+	Use:   "serve --web [flags] [-- claude-args...]",
+	Short: "Serve a session for remote attach (experimental)",
+	Long: `Start a Claude Code session in the background and expose its TTY over an
+authenticated WebSocket, for a companion web UI or remote attach from another
+machine on the LAN.
+
+A random access token is generated at startup and printed separately from the
+URL. Clients send it in an "Authorization: Bearer" header; browsers, which
+cannot set headers on a WebSocket, offer the subprotocols "enclaude" and
+"enclaude.token.<token>" instead. The token is never accepted in the URL.
+Binary frames carry terminal data; text frames carry JSON control messages
+such as {"type":"resize","rows":40,"cols":120}.
+
+The server listens on localhost by default. Listening on any other address
+requires --tls-cert and --tls-key, so the token and the session never cross
+the network unencrypted. Browsers may only attach from a page on the server's
+own origin or one passed to --allow-origin.
+
+The session is set up like 'enclaude run': host commands, the shell policy,
+sockets, host ports, and the run record all apply. Only the docker engine is
+supported.
+
+Examples:
+  enclaude serve --web
+  enclaude serve --web --listen 0.0.0.0:7681 --tls-cert cert.pem --tls-key key.pem
+  enclaude serve --web --allow-origin https://ui.example.com`,
+	RunE: runServe,
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	if web, _ := cmd.Flags().GetBool("web"); !web {
+		return fmt.Errorf("no frontend selected; use --web")
+	}
+	listen, _ := cmd.Flags().GetString("listen")
+	certFile, _ := cmd.Flags().GetString("tls-cert")
+	keyFile, _ := cmd.Flags().GetString("tls-key")
+	origins, _ := cmd.Flags().GetStringArray("allow-origin")
+	if (certFile == "") != (keyFile == "") {
+		return fmt.Errorf("--tls-cert and --tls-key must be used together")
+	}
+	if certFile == "" && !loopbackAddr(listen) {
+		return fmt.Errorf("refusing to serve on %s without TLS; the access token and the session would cross the network unencrypted (use --tls-cert and --tls-key, or listen on 127.0.0.1)", listen)
+	}
+	if cfg.Container.Engine == config.EngineContainerd {
+		return fmt.Errorf("serve is not supported with the containerd engine")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigCh
+		cancel()
+	}()
+
+	opts, cleanup, err := buildRunOptions(cmd, args)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	session, err := startSession(ctx, cmd, &opts, false)
+	if err != nil {
+		return err
+	}
+	defer session.stop()
+	runner := session.runner.(*container.Runner)
+
+	containerID, err := runner.StartDetached(ctx, opts)
+	if err != nil {
+		session.finish(err)
+		return err
+	}
+	defer runner.Remove(containerID)
+
+	server, err := web.NewServer(&containerTerminal{runner: runner, containerID: containerID})
+	if err != nil {
+		session.finish(err)
+		return err
+	}
+	server.AllowedOrigins = origins
+
+	mux := http.NewServeMux()
+	mux.Handle("/attach", server)
+	httpServer := &http.Server{Addr: listen, Handler: mux}
+
+	serveErr := make(chan error, 1)
+	scheme := "ws"
+	if certFile != "" {
+		scheme = "wss"
+	}
+	go func() {
+		if certFile != "" {
+			serveErr <- httpServer.ListenAndServeTLS(certFile, keyFile)
+			return
+		}
+		serveErr <- httpServer.ListenAndServe()
+	}()
+
+	fmt.Printf("Serving session on %s://%s/attach\n", scheme, listen)
+	fmt.Printf("Access token: %s\n", server.Token)
+	fmt.Printf("Send it as 'Authorization: Bearer <token>', or from a browser as the subprotocols %q and \"%s<token>\".\n", web.Subprotocol, web.TokenSubprotocolPrefix)
+	fmt.Println("Press Ctrl+C to stop.")
+
+	exited := make(chan error, 1)
+	go func() {
+		code, err := runner.Wait(ctx, containerID)
+		if err == nil && code != 0 {
+			err = fmt.Errorf("container exited with code %d", code)
+		}
+		exited <- err
+	}()
+
+	select {
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			err = fmt.Errorf("web server failed: %w", err)
+			session.finish(err)
+			return err
+		}
+	case err := <-exited:
+		httpServer.Close()
+		if ctx.Err() == nil {
+			session.finish(err)
+			return err
+		}
+	case <-ctx.Done():
+		httpServer.Close()
+	}
+
+	session.finish(nil)
+	return nil
+}
+
+// loopbackAddr reports whether the listen address only accepts connections
+// from this machine
+func loopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// containerTerminal adapts a session container to web.Terminal
+type containerTerminal struct {
+	runner      *container.Runner
+	containerID string
+}
+
+func (t *containerTerminal) Attach(ctx context.Context) (io.ReadWriteCloser, error) {
+	return t.runner.AttachTTY(ctx, t.containerID)
+}
+
+func (t *containerTerminal) Resize(ctx context.Context, rows, cols uint) error {
+	return t.runner.ResizeTTY(ctx, t.containerID, rows, cols)
+}
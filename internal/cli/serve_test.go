@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestLoopbackAddr(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"127.0.0.1:7681", true},
+		{"127.0.0.2:7681", true},
+		{"localhost:7681", true},
+		{"[::1]:7681", true},
+		{"0.0.0.0:7681", false},
+		{":7681", false},
+		{"[::]:7681", false},
+		{"192.168.1.10:7681", false},
+		{"example.com:7681", false},
+		{"127.0.0.1", false},
+	}
+
+	for _, tt := range tests {
+		if got := loopbackAddr(tt.addr); got != tt.want {
+			t.Errorf("loopbackAddr(%q) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestServeRequiresTLS(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"LAN without TLS", []string{"--listen", "0.0.0.0:7681"}, "without TLS"},
+		{"all interfaces without TLS", []string{"--listen", ":7681"}, "without TLS"},
+		{"cert without key", []string{"--listen", "0.0.0.0:7681", "--tls-cert", "cert.pem"}, "used together"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &cobra.Command{}
+			cmd.Flags().Bool("web", false, "")
+			cmd.Flags().String("listen", "127.0.0.1:7681", "")
+			cmd.Flags().String("tls-cert", "", "")
+			cmd.Flags().String("tls-key", "", "")
+			cmd.Flags().StringArray("allow-origin", nil, "")
+			if err := cmd.Flags().Parse(append([]string{"--web"}, tt.args...)); err != nil {
+				t.Fatal(err)
+			}
+			err := runServe(cmd, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("runServe() error = %v, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
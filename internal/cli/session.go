@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/jakenelson/enclaude/internal/container"
+	"github.com/spf13/cobra"
+)
+
+// hostSession is the host side of a session that startSession set up
+type hostSession struct {
+	runner sessionRunner
+	finish func(error) // Records how the run ended and checks credential mounts
+	stop   func()      // Stops everything started on the host, in reverse
+}
+
+// startSession sets up the host side every entry point that runs a session
+// container shares, in order: the mount check, the ~/.claude lock, the API
+// proxy, the engine's runner and session user, --fast-fs, host commands, the
+// shell policy, access requests when the session has a menu to approve them
+// from, the run record, forwarded sockets and host ports, and credential
+// mount tracking. It refuses a session that would get the real API key in
+// secretless mode, and shares the bridge directories with the session user
+// last, so start the container right after it. Call finish with the
+// session's result, then stop; on error, everything started is already
+// stopped.
+func startSession(ctx context.Context, cmd *cobra.Command, opts *container.RunOptions, menu bool) (*hostSession, error) {
+	var stops []func()
+	stop := func() {
+		for i := len(stops) - 1; i >= 0; i-- {
+			stops[i]()
+		}
+	}
+	start := func(f func() (func(), error)) error {
+		s, err := f()
+		if err != nil {
+			return err
+		}
+		stops = append(stops, s)
+		return nil
+	}
+
+	// Catch missing or unreadable mount sources before Docker turns them
+	// into cryptic failures inside the container
+	if err := container.CheckMounts(opts.Mounts); err != nil {
+		return nil, err
+	}
+
+	// Serialize parallel use of a read-write ~/.claude
+	if err := start(func() (func(), error) { return lockClaudeDir(ctx, opts) }); err != nil {
+		return nil, err
+	}
+
+	// Swap the API key for a placeholder before any container sees it
+	if err := start(func() (func(), error) { return startAPIProxy(opts) }); err != nil {
+		stop()
+		return nil, err
+	}
+
+	runner, err := newSessionRunner(ctx, *opts)
+	if err != nil {
+		stop()
+		return nil, err
+	}
+	stops = append(stops, func() { runner.Close() })
+	if err := pickRandomUser(ctx, runner, opts); err != nil {
+		stop()
+		return nil, err
+	}
+
+	for _, f := range []func() (func(), error){
+		// Mirror the workspace into a volume for --fast-fs, copied back
+		// when the session ends
+		func() (func(), error) { return startFastFS(ctx, cmd, runner, opts) },
+		// Allowlisted host commands, audited against the run
+		func() (func(), error) { return startHostCommands(opts) },
+		// Commands Claude runs, checked against shell_policy and audited
+		func() (func(), error) { return startShellPolicy(opts) },
+	} {
+		if err := start(f); err != nil {
+			stop()
+			return nil, err
+		}
+	}
+
+	// Host paths the session asks for, approved from the session menu
+	if menu {
+		if err := start(func() (func(), error) { return startAccessRequests(opts) }); err != nil {
+			stop()
+			return nil, err
+		}
+	}
+
+	// Record the run and what it can reach in the state directory
+	finishRun := recordRun(opts)
+	for _, f := range []func() (func(), error){
+		// Host sockets from config, audited against the run
+		func() (func(), error) { return startSockets(opts, dangerousAllowed(cmd)) },
+		// Host ports reachable at host.enclaude.internal, audited against
+		// the run
+		func() (func(), error) { return startHostPorts(cmd, opts) },
+	} {
+		if err := start(f); err != nil {
+			finishRun(err)
+			stop()
+			return nil, err
+		}
+	}
+	if err := checkSecretless(opts.Environment); err != nil {
+		finishRun(err)
+		stop()
+		return nil, err
+	}
+
+	// Credential mounts, checked on the host once the session ends
+	finishMounts := trackMounts(opts)
+	opts.HostServices = hostServices(*opts)
+	shareBridgeDirs(*opts)
+	return &hostSession{
+		runner: runner,
+		finish: func(err error) {
+			finishMounts()
+			finishRun(err)
+		},
+		stop: stop,
+	}, nil
+}
@@ -0,0 +1,160 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jakenelson/enclaude/internal/config"
+	"github.com/jakenelson/enclaude/internal/container"
+	"github.com/jakenelson/enclaude/internal/state"
+)
+
+// claudeDirActiveWindow is how recently a session transcript must have been
+// written for Claude on the host to count as running
+const claudeDirActiveWindow = 2 * time.Minute
+
+// claudeDirWaitInterval is how often a waiting session checks the lock
+const claudeDirWaitInterval = 2 * time.Second
+
+// lockClaudeDir guards a read-write ~/.claude mount against parallel use.
+// Another enclaude session holding the lock, or Claude on the host having
+// written a session transcript since the last session ended, is handled as
+// claude.session_lock says: warn, wait for the lock, or mount ~/.claude
+// read-only. The returned function releases the lock.
+func lockClaudeDir(ctx context.Context, opts *container.RunOptions) (func(), error) {
+	var mount *container.Mount
+	for i := range opts.Mounts {
+		m := &opts.Mounts[i]
+		if m.Target == container.Home+"/.claude" && !m.Volume && !m.ReadOnly {
+			mount = m
+			break
+		}
+	}
+	if mount == nil || cfg.Claude.SessionLock == config.SessionLockOff {
+		return func() {}, nil
+	}
+	dir, err := state.Dir()
+	if err != nil {
+		return func() {}, nil
+	}
+
+	host, _ := os.Hostname()
+	lock := state.ClaudeDirLock{PID: os.Getpid(), Host: host, Workspace: opts.HostWorkDir}
+	waiting := false
+	for {
+		lock.Acquired = time.Now()
+		holder, taken, err := state.LockClaudeDir(dir, lock, processAlive)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: not checking for parallel use of %s: %v\n", mount.Source, err)
+			return func() {}, nil
+		}
+		if taken {
+			return holdClaudeDir(dir, lock, holder.Released, mount), nil
+		}
+
+		switch cfg.Claude.SessionLock {
+		case config.SessionLockWait:
+			if !waiting {
+				fmt.Fprintf(os.Stderr, "Waiting for the session using %s read-write (%s) to end...\n", mount.Source, describeHolder(holder))
+				waiting = true
+			}
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(claudeDirWaitInterval):
+			}
+		case config.SessionLockReadOnly:
+			fmt.Fprintf(os.Stderr, "Note: mounting %s read-only; another session uses it read-write (%s)\n", mount.Source, describeHolder(holder))
+			mount.ReadOnly = true
+			return func() {}, nil
+		default:
+			fmt.Fprintf(os.Stderr, "Warning: another session uses %s read-write (%s); parallel sessions can corrupt session data. Set claude.session_lock to wait or readonly to avoid this.\n", mount.Source, describeHolder(holder))
+			return func() {}, nil
+		}
+	}
+}
+
+// holdClaudeDir checks a freshly taken ~/.claude lock for Claude running on
+// the host, then keeps the lock refreshed until the returned function
+// releases it
+func holdClaudeDir(dir string, lock state.ClaudeDirLock, released time.Time, mount *container.Mount) func() {
+	if active := claudeDirActivity(mount.Source, released); !active.IsZero() && time.Since(active) < claudeDirActiveWindow {
+		if cfg.Claude.SessionLock == config.SessionLockReadOnly {
+			fmt.Fprintf(os.Stderr, "Note: mounting %s read-only; Claude on the host wrote a session %s ago\n", mount.Source, time.Since(active).Round(time.Second))
+			mount.ReadOnly = true
+			state.UnlockClaudeDir(dir, lock.Host, lock.PID)
+			return func() {}
+		}
+		fmt.Fprintf(os.Stderr, "Warning: Claude on the host wrote a session in %s %s ago; running both at once can corrupt session data\n", mount.Source, time.Since(active).Round(time.Second))
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(state.HeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				state.RefreshClaudeDirLock(dir)
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+		state.UnlockClaudeDir(dir, lock.Host, lock.PID)
+	}
+}
+
+// claudeDirActivity returns when a session transcript under a Claude
+// directory was last written after since, or the zero time if none was.
+// Only projects/<project>/ is looked at, where Claude writes transcripts as
+// a session runs.
+func claudeDirActivity(claudeDir string, since time.Time) time.Time {
+	var latest time.Time
+	projects, err := os.ReadDir(filepath.Join(claudeDir, "projects"))
+	if err != nil {
+		return latest
+	}
+	for _, p := range projects {
+		if !p.IsDir() {
+			continue
+		}
+		entries, err := os.ReadDir(filepath.Join(claudeDir, "projects", p.Name()))
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			info, err := e.Info()
+			if err != nil || !info.Mode().IsRegular() {
+				continue
+			}
+			if mod := info.ModTime(); mod.After(since) && mod.After(latest) {
+				latest = mod
+			}
+		}
+	}
+	return latest
+}
+
+// describeHolder describes the session holding the ~/.claude lock
+func describeHolder(l state.ClaudeDirLock) string {
+	desc := fmt.Sprintf("pid %d", l.PID)
+	if l.Host != "" {
+		desc += " on " + l.Host
+	}
+	if l.Workspace != "" {
+		desc += " in " + l.Workspace
+	}
+	if !l.Acquired.IsZero() {
+		desc += ", since " + l.Acquired.Local().Format("15:04")
+	}
+	return desc
+}
@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/jakenelson/enclaude/internal/config"
+	"github.com/jakenelson/enclaude/internal/container"
+	"github.com/jakenelson/enclaude/internal/state"
+)
+
+// subUIDPath is where the host's subordinate uid ranges are listed
+const subUIDPath = "/etc/subuid"
+
+// userNamespacer reports the user namespace an engine runs containers in
+type userNamespacer interface {
+	UserNamespace(ctx context.Context) string
+}
+
+// pickRandomUser gives a container.user random session a uid of its own,
+// so the files it writes can be told apart from those of other sessions
+// and of the host user
+func pickRandomUser(ctx context.Context, runner userNamespacer, opts *container.RunOptions) error {
+	if opts.User != config.UserRandom {
+		return nil
+	}
+	if cfg.Claude.SessionDir == config.SessionVolume {
+		return fmt.Errorf("container.user random cannot be used with claude.session_dir volume, since each session's uid could not read the login an earlier one kept")
+	}
+	name := ""
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	subuid, ok, err := container.ReadSubIDRange(subUIDPath, name, os.Getuid())
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", subUIDPath, err)
+	}
+	opts.User, opts.HostUID, err = container.RandomUser(runner.UserNamespace(ctx), subuid, ok, os.Getgid(), randomUsersInUse())
+	if err != nil {
+		return err
+	}
+	if opts.HostUID != 0 {
+		fmt.Fprintf(os.Stderr, "Running as %s; files the session writes belong to host uid %d\n", opts.User, opts.HostUID)
+	} else {
+		fmt.Fprintf(os.Stderr, "Running as %s in the container\n", opts.User)
+	}
+
+	// The session reaches host files through their group and other
+	// permissions only
+	if source := workspaceSource(*opts); source != "" {
+		if info, err := os.Stat(source); err == nil && info.Mode().Perm()&0020 == 0 {
+			fmt.Fprintf(os.Stderr, "Warning: %s is not group-writable, so the session cannot change it; run 'chmod -R g+w' on it to allow changes\n", source)
+		}
+	}
+	for _, m := range opts.Mounts {
+		if m.Target == container.Home+"/.claude" && !m.Volume {
+			fmt.Fprintf(os.Stderr, "Warning: the session can read %s only if it is group-readable; use an API key to authenticate instead\n", m.Source)
+		}
+	}
+	return nil
+}
+
+// randomUsersInUse returns the container uids of container.user random
+// sessions that are running, or whose run was never recorded as ended
+func randomUsersInUse() map[int]bool {
+	inUse := make(map[int]bool)
+	dir, err := state.Dir()
+	if err != nil {
+		return inUse
+	}
+	runs, err := state.Runs(dir)
+	if err != nil {
+		return inUse
+	}
+	for _, run := range runs {
+		if run.User == "" || (!run.Ended.IsZero() && !run.Detached) {
+			continue
+		}
+		uid, _, _ := strings.Cut(run.User, ":")
+		if n, err := strconv.Atoi(uid); err == nil {
+			inUse[n] = true
+		}
+	}
+	return inUse
+}
+
+// shareBridgeDirs lets a container.user random session reach the bridges
+// enclaude serves it: their sockets sit in directories only the host user
+// can open, which the session's uid is not. Each directory enclaude created
+// for a bridge is opened to the user's group, which the session runs in, and
+// its sockets made group-writable so the session can connect. Under user
+// namespace remapping the session's group is not the user's, so nothing
+// changes.
+func shareBridgeDirs(opts container.RunOptions) {
+	if opts.HostUID == 0 {
+		return
+	}
+	tmp := filepath.Clean(os.TempDir())
+	for _, m := range opts.Mounts {
+		dir := m.Source
+		if m.Kind != container.MountDir {
+			dir = filepath.Dir(m.Source)
+		}
+		if filepath.Dir(dir) != tmp || !strings.HasPrefix(filepath.Base(dir), "enclaude-") {
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		shared := false
+		for _, e := range entries {
+			if e.Type()&fs.ModeSocket == 0 {
+				continue
+			}
+			if err := os.Chmod(filepath.Join(dir, e.Name()), 0660); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: the session may not reach %s: %v\n", filepath.Join(dir, e.Name()), err)
+				continue
+			}
+			shared = true
+		}
+		if !shared {
+			continue
+		}
+		if err := os.Chmod(dir, 0750); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: the session may not reach %s: %v\n", dir, err)
+		}
+	}
+}
@@ -2,17 +2,35 @@ package cli
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/jakenelson/enclaude/internal/config"
+	"github.com/jakenelson/enclaude/internal/security"
 	"github.com/spf13/cobra"
 )
 
 func init() {
 	rootCmd.AddCommand(setupCmd)
+	setupCmd.Flags().String("section", "", "rerun one part of the wizard and merge it into the existing config (credentials, network, security)")
+}
+
+// Parts of the wizard that --section reruns on their own
+const (
+	setupSectionCredentials = "credentials"
+	setupSectionNetwork     = "network"
+	setupSectionSecurity    = "security"
+)
+
+// setupHardening is the container hardening chosen in the wizard
+type setupHardening struct {
+	dropCapabilities bool
+	noNewPrivileges  bool
+	readOnlyRoot     bool
 }
 
 var setupCmd = &cobra.Command{
@@ -25,16 +43,29 @@ This command will:
 - Detect available Claude authentication methods (API key, session directory)
 - Guide you through selecting authentication preferences
 - Configure external credential passthrough (GitHub, GCloud, SSH)
+- Detect TLS-intercepting proxies and offer to trust their CA certificate
+- Choose how the container is hardened
 - Create or update your configuration file
 - Verify the Docker image is available
 
-Run this command when first installing enclaude or to reconfigure settings.`,
+Run this command when first installing enclaude or to reconfigure settings.
+
+To change one part of an existing configuration, rerun just that part with
+--section; each prompt defaults to the current setting, and the answers
+are merged into the config file and every other setting is kept:
+  credentials  Claude authentication and GitHub, Google Cloud, and SSH credentials
+  network      Container network mode and the TLS-intercepting proxy check
+  security     Container hardening`,
 	RunE: runSetup,
 }
 
 func runSetup(cmd *cobra.Command, args []string) error {
 	reader := bufio.NewReader(os.Stdin)
 
+	if section, _ := cmd.Flags().GetString("section"); section != "" {
+		return runSetupSection(reader, section)
+	}
+
 	fmt.Println("🔧 Enclaude Setup Wizard")
 	fmt.Println("========================")
 
@@ -47,23 +78,33 @@ func runSetup(cmd *cobra.Command, args []string) error {
 	// Step 2: Select authentication method
 	fmt.Println("\nStep 2: Configure Claude Authentication")
 	fmt.Println("----------------------------------------")
-	selectedAuth := selectAuthMethod(reader, authMethods)
+	selectedAuth := selectAuthMethod(reader, authMethods, config.AuthAuto)
 
 	// Step 3: Configure external credentials
 	fmt.Println("\nStep 3: Configure External Credentials")
 	fmt.Println("---------------------------------------")
 	githubCred := configureCredential(reader, "GitHub", config.CredentialAuto)
 	gcloudCred := configureCredential(reader, "Google Cloud", config.CredentialAuto)
-	sshEnabled := configureSSH(reader)
+	sshEnabled := configureSSH(reader, false)
 
 	// Step 4: Container preferences
 	fmt.Println("\nStep 4: Container Preferences")
 	fmt.Println("-----------------------------")
 	memoryLimit := configureMemory(reader)
-	network := configureNetwork(reader)
+	network := configureNetwork(reader, config.NetworkBridge)
 
-	// Step 5: Create config file
-	fmt.Println("\nStep 5: Creating Configuration")
+	// Step 5: Detect TLS-intercepting proxy
+	fmt.Println("\nStep 5: Network Check")
+	fmt.Println("---------------------")
+	caCerts := configureProxyCA(reader)
+
+	// Step 6: Container hardening
+	fmt.Println("\nStep 6: Security")
+	fmt.Println("----------------")
+	hardening := configureHardening(reader, setupHardening{true, true, true})
+
+	// Step 7: Create config file
+	fmt.Println("\nStep 7: Creating Configuration")
 	fmt.Println("------------------------------")
 	configPath := getConfigPath()
 
@@ -74,6 +115,7 @@ func runSetup(cmd *cobra.Command, args []string) error {
 		fmt.Printf("⚠️  Configuration file already exists at: %s\n", configPath)
 		if !confirm(reader, "Do you want to overwrite it?") {
 			fmt.Println("\n❌ Setup cancelled. No changes were made.")
+			fmt.Println("   To change one part and keep the rest, run: enclaude setup --section credentials|network|security")
 			return nil
 		}
 	}
@@ -85,7 +127,7 @@ func runSetup(cmd *cobra.Command, args []string) error {
 	}
 
 	// Generate config content
-	configContent := generateConfig(selectedAuth, githubCred, gcloudCred, sshEnabled, memoryLimit, network)
+	configContent := generateConfig(selectedAuth, githubCred, gcloudCred, sshEnabled, memoryLimit, network, caCerts, hardening)
 
 	// Write config file
 	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
@@ -98,8 +140,8 @@ func runSetup(cmd *cobra.Command, args []string) error {
 		fmt.Printf("\n✅ Configuration created at: %s\n", configPath)
 	}
 
-	// Step 6: Verify Docker image
-	fmt.Println("\nStep 6: Docker Image")
+	// Step 8: Verify Docker image
+	fmt.Println("\nStep 8: Docker Image")
 	fmt.Println("--------------------")
 	fmt.Println("📦 To use enclaude, you need the Docker image.")
 	fmt.Println("   Run: enclaude build")
@@ -111,6 +153,84 @@ func runSetup(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// runSetupSection reruns one part of the wizard and merges the answers into
+// the existing config file
+func runSetupSection(reader *bufio.Reader, section string) error {
+	configPath := getConfigPath()
+	if _, err := os.Stat(configPath); err != nil {
+		return fmt.Errorf("no configuration file at %s; run 'enclaude setup' first", configPath)
+	}
+
+	// Each prompt defaults to what the file sets now, or to the setting's
+	// default if it sets nothing, so pressing Enter keeps it
+	currentString := func(key, def string) string {
+		if value, ok, err := config.FileSetting(configPath, key); err == nil && ok {
+			if s, ok := value.(string); ok {
+				return s
+			}
+		}
+		return def
+	}
+	currentBool := func(key string, def bool) bool {
+		if value, ok, err := config.FileSetting(configPath, key); err == nil && ok {
+			if b, ok := value.(bool); ok {
+				return b
+			}
+		}
+		return def
+	}
+
+	values := make(map[string]interface{})
+	var appendTo []string
+	switch section {
+	case setupSectionCredentials:
+		fmt.Println("🔧 Enclaude Setup: Credentials")
+		fmt.Println("=============================")
+		authMethods := detectClaudeAuth()
+		displayAuthMethods(authMethods)
+		values["claude.auth"] = selectAuthMethod(reader, authMethods, currentString("claude.auth", config.AuthAuto))
+		values["credentials.github"] = configureCredential(reader, "GitHub", currentString("credentials.github", config.CredentialAuto))
+		values["credentials.gcloud"] = configureCredential(reader, "Google Cloud", currentString("credentials.gcloud", config.CredentialAuto))
+		values["credentials.ssh.enabled"] = configureSSH(reader, currentBool("credentials.ssh.enabled", false))
+	case setupSectionNetwork:
+		fmt.Println("🔧 Enclaude Setup: Network")
+		fmt.Println("==========================")
+		values["container.network"] = configureNetwork(reader, currentString("container.network", config.NetworkBridge))
+		fmt.Println()
+		if caCerts := configureProxyCA(reader); len(caCerts) > 0 {
+			values["security.ca_certs"] = caCerts
+			appendTo = append(appendTo, "security.ca_certs")
+		}
+	case setupSectionSecurity:
+		fmt.Println("🔧 Enclaude Setup: Security")
+		fmt.Println("===========================")
+		hardening := configureHardening(reader, setupHardening{
+			dropCapabilities: currentBool("security.drop_capabilities", true),
+			noNewPrivileges:  currentBool("security.no_new_privileges", true),
+			readOnlyRoot:     currentBool("security.read_only_root", true),
+		})
+		values["security.drop_capabilities"] = hardening.dropCapabilities
+		values["security.no_new_privileges"] = hardening.noNewPrivileges
+		values["security.read_only_root"] = hardening.readOnlyRoot
+	default:
+		return fmt.Errorf("invalid --section %q (allowed: %s, %s, %s)", section, setupSectionCredentials, setupSectionNetwork, setupSectionSecurity)
+	}
+
+	changed, err := config.MergeFile(configPath, values, appendTo...)
+	if err != nil {
+		return err
+	}
+	if len(changed) == 0 {
+		fmt.Printf("\n✅ No changes; %s already has these settings\n", configPath)
+		return nil
+	}
+	fmt.Printf("\n✅ Updated %s (backup at %s.bak):\n", configPath, configPath)
+	for _, key := range changed {
+		fmt.Printf("   • %s\n", key)
+	}
+	return nil
+}
+
 // detectClaudeAuth detects available Claude authentication methods
 func detectClaudeAuth() map[string]bool {
 	methods := make(map[string]bool)
@@ -149,18 +269,16 @@ func displayAuthMethods(methods map[string]bool) {
 	}
 }
 
-// selectAuthMethod prompts user to select authentication method
-func selectAuthMethod(reader *bufio.Reader, methods map[string]bool) string {
+// selectAuthMethod prompts user to select authentication method, answering
+// defaultChoice when the user just presses Enter
+func selectAuthMethod(reader *bufio.Reader, methods map[string]bool, defaultChoice string) string {
 	fmt.Println("\nSelect Claude authentication mode:")
 	fmt.Println("  1) auto     - Use all available methods (recommended)")
 	fmt.Println("  2) api-key  - Use API key only")
 	fmt.Println("  3) session  - Use session directory only")
 
-	// Determine default based on what's available
-	defaultChoice := config.AuthAuto
-
 	for {
-		fmt.Printf("\nChoice [1-3] (default: auto): ")
+		fmt.Printf("\nChoice [1-3] (default: %s): ", defaultChoice)
 		input, err := reader.ReadString('\n')
 		if err != nil {
 			fmt.Printf("\nError reading input: %v\n", err)
@@ -199,7 +317,7 @@ func configureCredential(reader *bufio.Reader, name, defaultValue string) string
 	fmt.Println("  3) disabled - Never use")
 
 	for {
-		fmt.Printf("\nChoice [1-3] (default: auto): ")
+		fmt.Printf("\nChoice [1-3] (default: %s): ", defaultValue)
 		input, err := reader.ReadString('\n')
 		if err != nil {
 			fmt.Printf("\nError reading input: %v\n", err)
@@ -224,12 +342,13 @@ func configureCredential(reader *bufio.Reader, name, defaultValue string) string
 	}
 }
 
-// configureSSH prompts for SSH configuration
-func configureSSH(reader *bufio.Reader) bool {
+// configureSSH prompts for SSH configuration, answering enabled when the
+// user just presses Enter
+func configureSSH(reader *bufio.Reader, enabled bool) bool {
 	fmt.Println("\nConfigure SSH credentials:")
 	fmt.Println("  SSH credentials are disabled by default for security.")
 	fmt.Println("  Enable if you need to use SSH keys or agent forwarding.")
-	return confirm(reader, "Enable SSH credentials?")
+	return confirmDefault(reader, "Enable SSH credentials?", enabled)
 }
 
 // configureMemory prompts for memory limit
@@ -259,24 +378,25 @@ func configureMemory(reader *bufio.Reader) string {
 	}
 }
 
-// configureNetwork prompts for network mode
-func configureNetwork(reader *bufio.Reader) string {
+// configureNetwork prompts for network mode, answering defaultMode when the
+// user just presses Enter
+func configureNetwork(reader *bufio.Reader, defaultMode string) string {
 	fmt.Println("\nContainer network mode:")
 	fmt.Println("  1) bridge - Standard Docker bridge network (recommended)")
 	fmt.Println("  2) host   - Use host network (less isolated)")
 	fmt.Println("  3) none   - No network access")
 
 	for {
-		fmt.Printf("\nChoice [1-3] (default: bridge): ")
+		fmt.Printf("\nChoice [1-3] (default: %s): ", defaultMode)
 		input, err := reader.ReadString('\n')
 		if err != nil {
 			fmt.Printf("\nError reading input: %v\n", err)
-			return config.NetworkBridge
+			return defaultMode
 		}
 		input = strings.TrimSpace(input)
 
 		if input == "" {
-			return config.NetworkBridge
+			return defaultMode
 		}
 
 		switch input {
@@ -292,18 +412,79 @@ func configureNetwork(reader *bufio.Reader) string {
 	}
 }
 
+// configureProxyCA probes for a TLS-intercepting proxy and offers to export
+// its root CA from the host trust store so the container trusts it too.
+// Returns the CA certificate paths to configure.
+func configureProxyCA(reader *bufio.Reader) []string {
+	fmt.Printf("Checking TLS connection to %s...\n", security.ProxyProbeHost)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	root, err := security.DetectProxyCA(ctx, security.ProxyProbeHost)
+	if err != nil {
+		fmt.Printf("⚠️  Could not check for a TLS-intercepting proxy: %v\n", err)
+		fmt.Println("   If Claude fails with TLS errors, add your proxy's CA to security.ca_certs.")
+		return nil
+	}
+	if root == nil {
+		fmt.Println("✅ No TLS-intercepting proxy detected")
+		return nil
+	}
+
+	fmt.Println("⚠️  A TLS-intercepting proxy appears to be re-signing HTTPS traffic.")
+	fmt.Printf("   Root CA: %s\n", root.Subject.String())
+	fmt.Println("   The container will not trust it unless it is added to security.ca_certs.")
+	if !confirm(reader, "Export this CA certificate and add it to your configuration?") {
+		return nil
+	}
+
+	certPath := filepath.Join(filepath.Dir(getConfigPath()), "certs", "proxy-ca.pem")
+	if err := security.WriteCertPEM(certPath, root); err != nil {
+		fmt.Printf("❌ Failed to write CA certificate: %v\n", err)
+		return nil
+	}
+	fmt.Printf("✅ CA certificate saved to: %s\n", certPath)
+	return []string{certPath}
+}
+
+// configureHardening prompts for the container hardening settings, answering
+// those of current when the user just presses Enter
+func configureHardening(reader *bufio.Reader, current setupHardening) setupHardening {
+	fmt.Println("\nContainer hardening:")
+	fmt.Println("  Each of these is recommended; turn one off only if a tool you need fails without it.")
+	return setupHardening{
+		dropCapabilities: confirmDefault(reader, "Drop Linux capabilities?", current.dropCapabilities),
+		noNewPrivileges:  confirmDefault(reader, "Stop processes from gaining privileges (no-new-privileges)?", current.noNewPrivileges),
+		readOnlyRoot:     confirmDefault(reader, "Make the container's root filesystem read-only?", current.readOnlyRoot),
+	}
+}
+
 // confirm prompts for yes/no confirmation
 func confirm(reader *bufio.Reader, prompt string) bool {
+	return confirmDefault(reader, prompt, false)
+}
+
+// confirmDefault prompts for yes/no confirmation, answering def when the
+// user just presses Enter
+func confirmDefault(reader *bufio.Reader, prompt string, def bool) bool {
+	hint := "[y/N]"
+	if def {
+		hint = "[Y/n]"
+	}
 	for {
-		fmt.Printf("%s [y/N]: ", prompt)
+		fmt.Printf("%s %s: ", prompt, hint)
 		input, err := reader.ReadString('\n')
 		if err != nil {
 			fmt.Printf("\nError reading input: %v\n", err)
-			return false
+			return def
 		}
 		input = strings.ToLower(strings.TrimSpace(input))
 
-		if input == "" || input == "n" || input == "no" {
+		if input == "" {
+			return def
+		}
+		if input == "n" || input == "no" {
 			return false
 		}
 		if input == "y" || input == "yes" {
@@ -315,16 +496,26 @@ func confirm(reader *bufio.Reader, prompt string) bool {
 }
 
 // generateConfig creates the configuration file content
-func generateConfig(auth, github, gcloud string, sshEnabled bool, memory, network string) string {
+func generateConfig(auth, github, gcloud string, sshEnabled bool, memory, network string, caCerts []string, hardening setupHardening) string {
 	sshEnabledStr := "false"
 	if sshEnabled {
 		sshEnabledStr = "true"
 	}
 
+	caCertsStr := " []"
+	if len(caCerts) > 0 {
+		caCertsStr = ""
+		for _, cert := range caCerts {
+			caCertsStr += fmt.Sprintf("\n    - %s", cert)
+		}
+	}
+
 	return fmt.Sprintf(`# Enclaude configuration
 # Generated by 'enclaude setup'
 # See https://github.com/jakenelson/enclaude for documentation
 
+config_version: 2
+
 # Image settings
 image:
   name: enclaude:latest
@@ -337,6 +528,7 @@ mounts:
 claude:
   auth: %s              # auto | session | api-key
   session_dir: readonly   # none | readonly | readwrite
+  prefer: ask             # ask | session | api-key (when auto finds both)
   default_args: []
 
 # External service credentials
@@ -365,9 +557,10 @@ container:
 
 # Security settings
 security:
-  drop_capabilities: true
-  no_new_privileges: true
-  read_only_root: true
-  ca_certs: []        # Additional CA certificates to mount (e.g., corporate CA)
-`, auth, github, gcloud, sshEnabledStr, memory, network)
+  drop_capabilities: %t
+  no_new_privileges: %t
+  read_only_root: %t
+  # Additional CA certificates to mount (e.g., corporate CA)
+  ca_certs:%s
+`, auth, github, gcloud, sshEnabledStr, memory, network, hardening.dropCapabilities, hardening.noNewPrivileges, hardening.readOnlyRoot, caCertsStr)
 }
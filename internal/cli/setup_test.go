@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"bufio"
 	"os"
 	"path/filepath"
 	"strings"
@@ -91,7 +92,7 @@ func TestDetectClaudeAuth(t *testing.T) {
 }
 
 func TestGenerateConfig(t *testing.T) {
-	cfg := generateConfig(config.AuthAuto, config.CredentialAuto, config.CredentialDisabled, false, "4g", config.NetworkBridge)
+	cfg := generateConfig(config.AuthAuto, config.CredentialAuto, config.CredentialDisabled, false, "4g", config.NetworkBridge, nil, setupHardening{dropCapabilities: true, noNewPrivileges: true})
 
 	// Check that config contains expected values
 	expectedStrings := []string{
@@ -102,6 +103,9 @@ func TestGenerateConfig(t *testing.T) {
 		"memory_limit: 4g",
 		"network: bridge",
 		"ca_certs: []",
+		"drop_capabilities: true",
+		"no_new_privileges: true",
+		"read_only_root: false",
 	}
 
 	for _, expected := range expectedStrings {
@@ -110,3 +114,61 @@ func TestGenerateConfig(t *testing.T) {
 		}
 	}
 }
+
+func TestGenerateConfig_CACerts(t *testing.T) {
+	cfg := generateConfig(config.AuthAuto, config.CredentialAuto, config.CredentialAuto, false, "4g", config.NetworkBridge, []string{"/home/user/.config/enclaude/certs/proxy-ca.pem"}, setupHardening{})
+
+	if !strings.Contains(cfg, "ca_certs:\n    - /home/user/.config/enclaude/certs/proxy-ca.pem\n") {
+		t.Errorf("generateConfig() did not list CA certificate:\n%s", cfg)
+	}
+}
+
+func TestRunSetupSection_CurrentDefaults(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	path := filepath.Join(home, ".config", "enclaude", "config.yaml")
+	os.MkdirAll(filepath.Dir(path), 0755)
+	original := `config_version: 2
+claude:
+  auth: session
+credentials:
+  github: disabled
+  gcloud: enabled
+  ssh:
+    enabled: true
+security:
+  drop_capabilities: true
+  no_new_privileges: false
+  read_only_root: true
+`
+	if err := os.WriteFile(path, []byte(original), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Pressing Enter at every prompt keeps the file as it is
+	for _, section := range []string{setupSectionCredentials, setupSectionSecurity} {
+		reader := bufio.NewReader(strings.NewReader(strings.Repeat("\n", 10)))
+		if err := runSetupSection(reader, section); err != nil {
+			t.Fatalf("%s: runSetupSection() error = %v", section, err)
+		}
+		if _, err := os.Stat(path + ".bak"); !os.IsNotExist(err) {
+			t.Errorf("%s: defaults changed the config file", section)
+		}
+	}
+
+	reader := bufio.NewReader(strings.NewReader("\n\n3\n\n"))
+	if err := runSetupSection(reader, setupSectionCredentials); err != nil {
+		t.Fatalf("runSetupSection() error = %v", err)
+	}
+	for key, want := range map[string]interface{}{
+		"claude.auth":             config.AuthSession,
+		"credentials.github":      config.CredentialDisabled,
+		"credentials.gcloud":      config.CredentialDisabled,
+		"credentials.ssh.enabled": true,
+		"security.read_only_root": true,
+	} {
+		if got, _, _ := config.FileSetting(path, key); got != want {
+			t.Errorf("%s = %v, want %v", key, got, want)
+		}
+	}
+}
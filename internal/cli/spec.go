@@ -0,0 +1,267 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jakenelson/enclaude/internal/config"
+	"github.com/jakenelson/enclaude/internal/container"
+	"github.com/jakenelson/enclaude/internal/credentials"
+	"github.com/jakenelson/enclaude/internal/security"
+	"github.com/jakenelson/enclaude/internal/wsl"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(exportSpecCmd)
+
+	exportSpecCmd.Flags().StringP("workdir", "w", "", "working directory to mount (default: current directory)")
+	exportSpecCmd.Flags().StringArrayP("mount", "m", nil, "additional directories to mount (read-write)")
+	exportSpecCmd.Flags().StringArray("mount-ro", nil, "additional directories to mount (read-only)")
+	exportSpecCmd.Flags().StringArray("ref", nil, "read-only reference directory, as path or name=path, mounted at /refs/<name> (repeatable)")
+	exportSpecCmd.Flags().String("image", "", "Docker image to use (default: enclaude:latest)")
+
+	// Specs never carry credentials; buildRunOptions skips and audits them
+	exportSpecCmd.Flags().Bool("no-creds", true, "")
+	exportSpecCmd.Flags().MarkHidden("no-creds")
+}
+
+var exportSpecCmd = &cobra.Command{
+	Use:   "export-spec <file> [flags] [-- claude-args...]",
+	Short: "Write the resolved sandbox definition to a file",
+	Long: `Resolve the run options enclaude would use in the current directory from
+config and flags, and write them to a JSON file that can be checked into a
+repository or sent to a colleague and run with 'enclaude --from-spec'.
+
+The spec never contains secrets: Claude authentication, external credentials,
+and environment variables that look like secrets are left out, and export
+fails if a mount points at a credential directory. Paths under your home
+directory are written relative to "~", and the workspace is written as "."
+so the spec runs from any checkout. Whoever runs the spec supplies their own
+credentials from their config.
+
+Examples:
+  enclaude export-spec run.json
+  enclaude export-spec run.json --mount-ro ~/docs -- --model opus`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runExportSpec,
+}
+
+func runExportSpec(cmd *cobra.Command, args []string) error {
+	// The workspace is stored as ".", so there is nothing to gain from copying
+	// it, and the editor bridge is started by whoever runs the spec
+	cfg.Workspace.Mode = config.WorkspaceBind
+	cfg.Editor.Bridge = false
+
+	opts, cleanup, err := buildRunOptions(cmd, args[1:])
+	if err != nil {
+		return fmt.Errorf("cannot export spec: %w", err)
+	}
+	defer cleanup()
+
+	home, _ := os.UserHomeDir()
+	if err := container.WriteSpec(args[0], container.NewSpec(opts, home)); err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Sandbox definition written to: %s\n", args[0])
+	fmt.Printf("   Run it with: enclaude --from-spec %s\n", args[0])
+	return nil
+}
+
+// buildSpecRunOptions assembles run options from a spec written by
+// export-spec or published as a template, adding the local workspace,
+// credentials, and split output. Specs may come from other people, so their
+// mounts are held to the same rules as local ones, and a spec that makes the
+// sandbox weaker than the local config would (see specWeakenings) only runs
+// with --dangerous, and then without credentials. Cleanup semantics match
+// buildRunOptions.
+func buildSpecRunOptions(cmd *cobra.Command, args []string, spec container.Spec, specName string) (opts container.RunOptions, cleanup func(), err error) {
+	var cleanups []func()
+	cleanup = func() {
+		for i := len(cleanups) - 1; i >= 0; i-- {
+			cleanups[i]()
+		}
+	}
+	defer func() {
+		if err != nil {
+			cleanup()
+		}
+	}()
+
+	workDir, err := resolveWorkDir(cmd)
+	if err != nil {
+		return container.RunOptions{}, cleanup, err
+	}
+
+	// Ephemeral sessions write nothing to the host's disk, whether the spec
+	// or the local config asks for them
+	ephemeral := cfg.Security.Ephemeral || spec.Options.Security.Ephemeral
+	if ephemeral {
+		if err := checkEphemeral(cmd); err != nil {
+			return container.RunOptions{}, cleanup, err
+		}
+	}
+
+	workspaceSource, workspaceCleanup, err := prepareWorkspace(workDir)
+	if err != nil {
+		return container.RunOptions{}, cleanup, err
+	}
+	cleanups = append(cleanups, workspaceCleanup)
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return container.RunOptions{}, cleanup, fmt.Errorf("failed to resolve home directory: %w", err)
+	}
+	opts = spec.Resolve(workspaceSource, home)
+	if ephemeral {
+		opts.Security.Ephemeral, opts.Security.ReadOnlyRoot = true, true
+	}
+
+	if err := credentials.AuditNoCredentials(opts); err != nil {
+		return container.RunOptions{}, cleanup, fmt.Errorf("spec %s: %w", specName, err)
+	}
+	for _, m := range opts.Mounts {
+		if m.Volume || m.Source == workspaceSource {
+			continue
+		}
+		if err := security.ValidateMountPath(m.Source); err != nil {
+			return container.RunOptions{}, cleanup, fmt.Errorf("spec %s: mount path denied %q: %w", specName, m.Source, err)
+		}
+	}
+
+	// CA certificates are host files that may not exist on this machine
+	var caCerts []string
+	for _, certPath := range opts.Security.CACerts {
+		if _, err := os.Stat(certPath); os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "Warning: CA cert file not found %q\n", certPath)
+			continue
+		}
+		caCerts = append(caCerts, certPath)
+	}
+	opts.Security.CACerts = caCerts
+
+	// Credentials come from the local config, never from the spec, and are
+	// kept out of a sandbox the spec loosens
+	noCreds, _ := cmd.Flags().GetBool("no-creds")
+	if weakenings := specWeakenings(opts, cfg, workspaceSource); len(weakenings) > 0 {
+		if !dangerousAllowed(cmd) {
+			return container.RunOptions{}, cleanup, fmt.Errorf("spec %s weakens the sandbox: %s; pass --dangerous to run it without credentials", specName, strings.Join(weakenings, ", "))
+		}
+		fmt.Fprintf(os.Stderr, "Warning: spec %s weakens the sandbox (%s); running it without host credentials\n", specName, strings.Join(weakenings, ", "))
+		noCreds = true
+	}
+	if !noCreds {
+		if noCreds, err = checkWorkspaceTrust(cmd, workDir, opts.Mounts); err != nil {
+			return container.RunOptions{}, cleanup, err
+		}
+	}
+	credMounts, credEnv, credCleanup, err := collectCredentials(cmd, workDir, noCreds)
+	if err != nil {
+		return container.RunOptions{}, cleanup, err
+	}
+	cleanups = append(cleanups, credCleanup)
+	opts.NoCredentials = noCreds
+	opts.Mounts = append(opts.Mounts, credMounts...)
+	if opts.Environment == nil {
+		opts.Environment = make(map[string]string)
+	}
+	for k, v := range credEnv {
+		opts.Environment[k] = v
+	}
+
+	for k, v := range wslWorkspaceEnv(wsl.Detect(), workspaceSource, opts.WorkDir) {
+		opts.Environment[k] = v
+	}
+
+	editorMounts, editorEnv, editorCleanup, err := startEditorBridge()
+	if err != nil {
+		return container.RunOptions{}, cleanup, err
+	}
+	cleanups = append(cleanups, editorCleanup)
+	opts.Mounts = append(opts.Mounts, editorMounts...)
+	for k, v := range editorEnv {
+		opts.Environment[k] = v
+	}
+
+	// Local telemetry policy applies to specs as well
+	if cfg.Claude.DisableTelemetry {
+		for k, v := range container.TelemetryEnv {
+			opts.Environment[k] = v
+		}
+		opts.BlockedHosts = append(opts.BlockedHosts, container.TelemetryHosts...)
+		blockMounts, blockEnv, blockCleanup, err := startTelemetryBlock()
+		if err != nil {
+			return container.RunOptions{}, cleanup, err
+		}
+		cleanups = append(cleanups, blockCleanup)
+		opts.Mounts = append(opts.Mounts, blockMounts...)
+		for k, v := range blockEnv {
+			opts.Environment[k] = v
+		}
+	}
+
+	if len(args) > 0 {
+		opts.ClaudeArgs = args
+	}
+	opts.HostWorkDir = workDir
+	opts.Interaction = cfg.Container.Interaction
+	opts.Hyperlinks = hyperlinksEnabled()
+	opts.LinkFormat = cfg.Terminal.LinkFormat
+	if opts.Filters, err = outputFilters(); err != nil {
+		return container.RunOptions{}, cleanup, err
+	}
+
+	if opts.StderrFile, err = splitOutputFile(cmd); err != nil {
+		return container.RunOptions{}, cleanup, err
+	}
+	if err := guardEngineSockets(cmd, opts); err != nil {
+		return container.RunOptions{}, cleanup, fmt.Errorf("spec %s: %w", specName, err)
+	}
+
+	return opts, cleanup, nil
+}
+
+// specWeakenings lists how the sandbox opts, resolved from a spec, is weaker
+// than the one local would give: security options it turns off, a network
+// other than local's or none, running as root, read-write mounts besides the
+// workspace at workspaceSource, or further docker run flags
+func specWeakenings(opts container.RunOptions, local *config.Config, workspaceSource string) []string {
+	var weakenings []string
+	for _, o := range []struct {
+		name        string
+		spec, local bool
+	}{
+		{"drop_capabilities", opts.Security.DropCapabilities, local.Security.DropCapabilities},
+		{"no_new_privileges", opts.Security.NoNewPrivileges, local.Security.NoNewPrivileges},
+		{"read_only_root", opts.Security.ReadOnlyRoot, local.Security.ReadOnlyRoot},
+	} {
+		if o.local && !o.spec {
+			weakenings = append(weakenings, o.name+" off")
+		}
+	}
+	if network := specNetwork(opts.Network); network != "none" && network != specNetwork(local.Container.Network) {
+		weakenings = append(weakenings, fmt.Sprintf("network %q", network))
+	}
+	if user, _, _ := strings.Cut(opts.User, ":"); user == "0" || user == "root" {
+		weakenings = append(weakenings, "user root")
+	}
+	for _, m := range opts.Mounts {
+		if !m.Volume && !m.ReadOnly && m.Source != workspaceSource {
+			weakenings = append(weakenings, "read-write mount "+m.Source)
+		}
+	}
+	if len(opts.DockerArgs) > 0 {
+		weakenings = append(weakenings, "docker args "+strings.Join(opts.DockerArgs, " "))
+	}
+	return weakenings
+}
+
+// specNetwork returns network, or the default bridge network if it is empty
+func specNetwork(network string) string {
+	if network == "" {
+		return "bridge"
+	}
+	return network
+}
@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jakenelson/enclaude/internal/config"
+	"github.com/jakenelson/enclaude/internal/container"
+)
+
+func TestSpecWeakenings(t *testing.T) {
+	local := &config.Config{}
+	local.Security.DropCapabilities = true
+	local.Security.NoNewPrivileges = true
+	local.Container.Network = "bridge"
+
+	same := container.RunOptions{
+		Network:  "bridge",
+		Security: container.SecurityOptions{DropCapabilities: true, NoNewPrivileges: true, ReadOnlyRoot: true},
+		Mounts: []container.Mount{
+			{Source: "/src/app", Target: "/workspace"},
+			{Source: "/home/user/docs", Target: "/refs/docs", ReadOnly: true},
+			{Source: "enclaude-npm", Target: "/cache", Volume: true},
+		},
+	}
+	if got := specWeakenings(same, local, "/src/app"); len(got) != 0 {
+		t.Errorf("specWeakenings() = %v for a spec as strict as the config", got)
+	}
+	if got := specWeakenings(container.RunOptions{Network: "none"}, local, "/src/app"); !reflect.DeepEqual(got, []string{"drop_capabilities off", "no_new_privileges off"}) {
+		t.Errorf("specWeakenings() = %v, want the security options turned off", got)
+	}
+
+	weak := same
+	weak.Network = "host"
+	weak.User = "0:0"
+	weak.Mounts = append(weak.Mounts, container.Mount{Source: "/home/user", Target: "/home/user"})
+	weak.DockerArgs = []string{"--shm-size=2g"}
+	want := []string{`network "host"`, "user root", "read-write mount /home/user", "docker args --shm-size=2g"}
+	if got := specWeakenings(weak, local, "/src/app"); !reflect.DeepEqual(got, want) {
+		t.Errorf("specWeakenings() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,164 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/jakenelson/enclaude/internal/container"
+	"github.com/jakenelson/enclaude/internal/state"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(sshConfigCmd)
+}
+
+var sshConfigCmd = &cobra.Command{
+	Use:   "ssh-config [session]",
+	Short: "Print an SSH config block for opening a session in an editor",
+	Long: `Prepare a running session for SSH and print a Host block for ~/.ssh/config,
+so VS Code Remote-SSH, JetBrains Gateway, or plain ssh can open the sandboxed
+workspace directly.
+
+No port is published: ssh's ProxyCommand starts sshd inside the session
+through docker exec for each connection, running as the session user and
+accepting only a key generated for this session. The key and the session's
+host key are kept in the enclaude state directory. The session image needs
+openssh-server, which images built by 'enclaude build' include.
+
+SSH sessions see the container's filesystem, mounts, and network, but start
+with a login environment rather than Claude's, so host credentials passed to
+Claude as environment variables are not set.
+
+The session may be given as a container ID or name. Without an argument, the
+only running session is used; if there are several, the one started from the
+current directory is used, or running sessions are listed.
+
+Examples:
+  enclaude ssh-config >> ~/.ssh/config
+  ssh enclaude-3f2a9c1b7d4e
+  code --remote ssh-remote+enclaude-3f2a9c1b7d4e /workspace`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runSSHConfig,
+}
+
+func runSSHConfig(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		return fmt.Errorf("ssh-keygen not found; install OpenSSH to connect to sessions over SSH")
+	}
+
+	runner, err := container.NewRunner()
+	if err != nil {
+		return fmt.Errorf("failed to create container runner: %w", err)
+	}
+	defer runner.Close()
+
+	session, err := pickSession(ctx, runner, args, "ssh-config")
+	if err != nil {
+		return err
+	}
+	id, err := sessionID(ctx, runner, session)
+	if err != nil {
+		return err
+	}
+	host := "enclaude-" + shortID(id)
+
+	dir, err := state.Dir()
+	if err != nil {
+		return err
+	}
+	keys, err := sessionSSHKeys(filepath.Join(dir, "ssh", shortID(id)))
+	if err != nil {
+		return err
+	}
+
+	target, err := runner.InstallSSH(ctx, id, keys.hostKey, keys.clientPub)
+	if err != nil {
+		return err
+	}
+	knownHosts := filepath.Join(keys.dir, "known_hosts")
+	if err := os.WriteFile(knownHosts, []byte(host+" "+string(keys.hostPub)), 0600); err != nil {
+		return fmt.Errorf("failed to write known_hosts: %w", err)
+	}
+
+	fmt.Printf(`Host %s
+  HostName %s
+  User %s
+  IdentityFile %s
+  IdentitiesOnly yes
+  UserKnownHostsFile %s
+  HostKeyAlias %s
+  StrictHostKeyChecking yes
+  ProxyCommand %s
+`, host, host, target.User, sshQuote(keys.clientKey), sshQuote(knownHosts), host, strings.Join(target.ProxyCommand, " "))
+	return nil
+}
+
+// sessionID returns the full container ID of a running session given by ID
+// prefix or name
+func sessionID(ctx context.Context, runner *container.Runner, session string) (string, error) {
+	sessions, err := runner.ListSessions(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, s := range sessions {
+		if s.Name == session || strings.HasPrefix(s.ID, session) {
+			return s.ID, nil
+		}
+	}
+	return "", fmt.Errorf("no running session %q", session)
+}
+
+// sshKeys are the client key and host key generated for a session
+type sshKeys struct {
+	dir       string
+	clientKey string // Private key path
+	clientPub []byte
+	hostKey   []byte
+	hostPub   []byte
+}
+
+// sessionSSHKeys returns the keys in dir, generating them with ssh-keygen
+// the first time so reconnecting editors keep trusting the session
+func sessionSSHKeys(dir string) (sshKeys, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return sshKeys{}, fmt.Errorf("failed to create SSH key directory: %w", err)
+	}
+	keys := sshKeys{dir: dir, clientKey: filepath.Join(dir, "id_ed25519")}
+	hostKey := filepath.Join(dir, "ssh_host_ed25519_key")
+	for _, path := range []string{keys.clientKey, hostKey} {
+		if _, err := os.Stat(path); err == nil {
+			continue
+		}
+		out, err := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-C", "enclaude", "-f", path).CombinedOutput()
+		if err != nil {
+			return sshKeys{}, fmt.Errorf("failed to generate SSH key: %s", strings.TrimSpace(string(out)))
+		}
+	}
+
+	var err error
+	if keys.clientPub, err = os.ReadFile(keys.clientKey + ".pub"); err != nil {
+		return sshKeys{}, err
+	}
+	if keys.hostKey, err = os.ReadFile(hostKey); err != nil {
+		return sshKeys{}, err
+	}
+	if keys.hostPub, err = os.ReadFile(hostKey + ".pub"); err != nil {
+		return sshKeys{}, err
+	}
+	return keys, nil
+}
+
+// sshQuote quotes a path for ssh_config if it contains spaces
+func sshQuote(s string) string {
+	if strings.ContainsAny(s, " \t") {
+		return `"` + s + `"`
+	}
+	return s
+}
@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/jakenelson/enclaude/internal/container"
+	"github.com/moby/term"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	statsCmd.Flags().Bool("live", false, "keep updating the dashboard until Ctrl+C")
+	statsCmd.Flags().Int("top", 10, "number of processes to show (fits the terminal in --live mode)")
+	rootCmd.AddCommand(statsCmd)
+}
+
+var statsCmd = &cobra.Command{
+	Use:   "stats [session]",
+	Short: "Show resource usage of a running session",
+	Long: `Show CPU, memory, network, and process usage of a running session, and the
+processes using the most CPU, to diagnose why a session feels slow.
+
+With --live the dashboard is redrawn about once a second until Ctrl+C or the
+session ends; without it a single sample is printed.
+
+The session may be given as a container ID or name. Without an argument, the
+only running session is shown; if there are several, the one started from the
+current directory is shown, or running sessions are listed.
+
+Examples:
+  enclaude stats
+  enclaude stats --live
+  enclaude stats --live 3f2a9c1b7d4e`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runStats,
+}
+
+func runStats(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigCh
+		cancel()
+	}()
+
+	live, _ := cmd.Flags().GetBool("live")
+	top, _ := cmd.Flags().GetInt("top")
+
+	runner, err := container.NewRunner()
+	if err != nil {
+		return fmt.Errorf("failed to create container runner: %w", err)
+	}
+	defer runner.Close()
+
+	session, err := pickSession(ctx, runner, args, "stats")
+	if err != nil {
+		return err
+	}
+
+	// Redraw in place only when stdout is a terminal; otherwise each sample
+	// is appended, so the output can be logged
+	redraw := live && term.IsTerminal(os.Stdout.Fd())
+	return runner.StreamStats(ctx, session, func(s container.SessionStats) bool {
+		n, width := top, 0
+		if redraw {
+			fmt.Print("\x1b[H\x1b[2J")
+			if ws, err := term.GetWinsize(os.Stdout.Fd()); err == nil {
+				n = max(0, min(n, int(ws.Height)-statsHeaderLines))
+				width = int(ws.Width)
+			}
+		}
+		printStats(os.Stdout, shortID(session), s, n, width)
+		return live
+	})
+}
+
+// statsHeaderLines is the number of lines printStats writes before the
+// process rows, plus one for the cursor
+const statsHeaderLines = 9
+
+// printStats renders one sample with up to top processes, cutting process
+// rows at width columns unless width is 0
+func printStats(w io.Writer, session string, s container.SessionStats, top, width int) {
+	fmt.Fprintf(w, "Session %s  %s\n\n", session, time.Now().Format("15:04:05"))
+	fmt.Fprintf(w, "  CPU:     %.1f%% (%d CPUs)\n", s.CPUPercent, s.OnlineCPUs)
+
+	mem := units.BytesSize(float64(s.Memory))
+	if s.MemoryLimit > 0 {
+		mem += fmt.Sprintf(" / %s (%.1f%%)", units.BytesSize(float64(s.MemoryLimit)), float64(s.Memory)/float64(s.MemoryLimit)*100)
+	}
+	fmt.Fprintf(w, "  Memory:  %s\n", mem)
+	fmt.Fprintf(w, "  Network: %s received, %s sent\n", units.BytesSize(float64(s.NetworkRx)), units.BytesSize(float64(s.NetworkTx)))
+
+	pids := fmt.Sprintf("%d", s.PIDs)
+	if s.PIDsLimit > 0 {
+		pids += fmt.Sprintf(" / %d", s.PIDsLimit)
+	}
+	fmt.Fprintf(w, "  PIDs:    %s\n\n", pids)
+
+	fmt.Fprintf(w, "  %-8s %-10s %6s %6s  %s\n", "PID", "USER", "%CPU", "%MEM", "COMMAND")
+	for i, p := range s.Processes {
+		if i >= top {
+			break
+		}
+		row := fmt.Sprintf("  %-8s %-10s %6.1f %6.1f  %s", p.PID, p.User, p.CPUPercent, p.MemPercent, p.Command)
+		if width > 0 && len(row) > width {
+			row = row[:width]
+		}
+		fmt.Fprintln(w, row)
+	}
+}
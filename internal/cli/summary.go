@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/jakenelson/enclaude/internal/container"
+	"github.com/jakenelson/enclaude/internal/workspace"
+)
+
+// runSummary collects what a session did for the --summary report
+type runSummary struct {
+	started   time.Time
+	workspace string             // Host directory mounted as the workspace
+	before    workspace.Snapshot // nil if the workspace could not be scanned
+	metrics   container.RunMetrics
+}
+
+// startSummary snapshots the workspace and arranges for opts to collect
+// resource usage. Call it immediately before the container runs.
+func startSummary(opts *container.RunOptions) *runSummary {
+	s := &runSummary{started: time.Now(), workspace: workspaceSource(*opts)}
+	opts.Metrics = &s.metrics
+
+	if s.workspace != "" {
+		snap, err := workspace.TakeSnapshot(s.workspace)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: changed files will not be counted: %v\n", err)
+		} else {
+			s.before = snap
+		}
+	}
+	return s
+}
+
+// workspaceSource returns the host directory mounted as the workspace, which
+// in copy mode is the copy
+func workspaceSource(opts container.RunOptions) string {
+	for _, m := range opts.Mounts {
+		// With --fast-fs the workspace is a volume and the host directory is
+		// mounted for the entrypoint to mirror
+		if m.Target == container.FastFSHostPath {
+			return m.Source
+		}
+	}
+	for _, m := range opts.Mounts {
+		if m.Target == opts.WorkDir {
+			return m.Source
+		}
+	}
+	return ""
+}
+
+// print writes the summary once the session has ended with runErr
+func (s *runSummary) print(w io.Writer, runErr error) {
+	changed := "unknown"
+	if s.before != nil {
+		if after, err := workspace.TakeSnapshot(s.workspace); err == nil {
+			c := s.before.Diff(after)
+			changed = fmt.Sprintf("%d (%d added, %d modified, %d removed)", c.Count(), len(c.Added), len(c.Modified), len(c.Removed))
+		}
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Session summary:")
+	fmt.Fprintf(w, "  Duration:      %s\n", time.Since(s.started).Round(time.Second))
+	fmt.Fprintf(w, "  Peak memory:   %s\n", units.BytesSize(float64(s.metrics.PeakMemory)))
+	fmt.Fprintf(w, "  Network:       %s received, %s sent\n", units.BytesSize(float64(s.metrics.NetworkRx)), units.BytesSize(float64(s.metrics.NetworkTx)))
+	fmt.Fprintf(w, "  Files changed: %s\n", changed)
+	fmt.Fprintf(w, "  Exit status:   %s\n", exitStatus(s.metrics, runErr))
+}
+
+// exitStatus describes how the session ended
+func exitStatus(m container.RunMetrics, runErr error) string {
+	switch {
+	case m.Exited:
+		return fmt.Sprintf("%d", m.ExitCode)
+	case errors.As(runErr, new(*container.DetachedError)):
+		return "detached"
+	case errors.Is(runErr, context.Canceled):
+		return "interrupted"
+	case runErr != nil:
+		return "error: " + runErr.Error()
+	default:
+		return "unknown"
+	}
+}
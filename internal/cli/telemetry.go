@@ -0,0 +1,153 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jakenelson/enclaude/internal/state"
+	"github.com/jakenelson/enclaude/internal/telemetry"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(telemetryCmd)
+	telemetryCmd.AddCommand(telemetryOnCmd)
+	telemetryCmd.AddCommand(telemetryOffCmd)
+	telemetryCmd.AddCommand(telemetryStatusCmd)
+}
+
+var telemetryCmd = &cobra.Command{
+	Use:   "telemetry",
+	Short: "Opt in to or out of anonymous usage telemetry",
+	Long: `Anonymous usage telemetry helps the maintainers decide what to work on. It
+is off unless you turn it on, and only records, per command run: the command
+name (e.g. "enclaude config set", never its arguments), how long it took, its
+exit status, the class of any error (never the message), and the enclaude
+version, OS, and architecture, with a random install ID.
+
+Paths, prompts, arguments, config values, and error messages are never
+recorded, and events pass through the same secret redaction as run history.
+Events are kept in the state directory and sent in the background on a later
+run. DO_NOT_TRACK=1 or ENCLAUDE_TELEMETRY=off disables telemetry even after
+opting in.
+
+Examples:
+  enclaude telemetry status
+  enclaude telemetry on
+  enclaude telemetry off`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var telemetryOnCmd = &cobra.Command{
+	Use:   "on",
+	Short: "Send anonymous usage telemetry",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, err := state.Dir()
+		if err != nil {
+			return err
+		}
+		if _, err := telemetry.SetConsent(dir, true); err != nil {
+			return err
+		}
+		fmt.Println("Telemetry is on. Thank you! Run 'enclaude telemetry off' to stop at any time.")
+		if telemetry.DisabledByEnv() {
+			fmt.Println("Note: DO_NOT_TRACK or ENCLAUDE_TELEMETRY=off is set, so nothing is recorded in this environment.")
+		}
+		return nil
+	},
+}
+
+var telemetryOffCmd = &cobra.Command{
+	Use:   "off",
+	Short: "Stop sending usage telemetry and discard unsent events",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, err := state.Dir()
+		if err != nil {
+			return err
+		}
+		if _, err := telemetry.SetConsent(dir, false); err != nil {
+			return err
+		}
+		fmt.Println("Telemetry is off.")
+		return nil
+	},
+}
+
+var telemetryStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether usage telemetry is on",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, err := state.Dir()
+		if err != nil {
+			return err
+		}
+		consent, err := telemetry.LoadConsent(dir)
+		if err != nil {
+			return err
+		}
+		switch {
+		case !consent.Enabled:
+			fmt.Println("Telemetry is off.")
+		case telemetry.DisabledByEnv():
+			fmt.Println("Telemetry is on, but disabled in this environment by DO_NOT_TRACK or ENCLAUDE_TELEMETRY.")
+		default:
+			fmt.Println("Telemetry is on.")
+		}
+		if consent.Enabled {
+			fmt.Printf("Install ID:     %s\n", consent.InstallID)
+			fmt.Printf("Unsent events:  %d\n", telemetry.Pending(dir))
+		}
+		return nil
+	},
+}
+
+// telemetryDir returns the state directory and consent when usage telemetry
+// should be recorded, or ok false
+func telemetryDir() (dir string, consent telemetry.Consent, ok bool) {
+	if telemetry.DisabledByEnv() {
+		return "", consent, false
+	}
+	dir, err := state.Dir()
+	if err != nil {
+		return "", consent, false
+	}
+	consent, err = telemetry.LoadConsent(dir)
+	if err != nil || !consent.Enabled {
+		return "", consent, false
+	}
+	return dir, consent, true
+}
+
+// flushUsage sends usage events spooled by earlier runs in the background
+// while this command runs; whatever is not sent by exit is tried again later
+func flushUsage() {
+	dir, _, ok := telemetryDir()
+	if !ok || telemetry.Pending(dir) == 0 {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = telemetry.Flush(ctx, dir)
+	}()
+}
+
+// recordUsage spools a usage event for the command that ran. Failures are
+// ignored; telemetry never affects the command.
+func recordUsage(cmd *cobra.Command, d time.Duration, err error) {
+	dir, consent, ok := telemetryDir()
+	if !ok || cmd == nil {
+		return
+	}
+	exitCode := 0
+	if err != nil {
+		exitCode = ExitCode(err)
+	}
+	_ = telemetry.Record(dir, telemetry.NewEvent(consent, Version, cmd.CommandPath(), d, exitCode, err))
+}
@@ -0,0 +1,220 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jakenelson/enclaude/internal/config"
+	"github.com/jakenelson/enclaude/internal/container"
+	"github.com/jakenelson/enclaude/internal/oci"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(templateCmd)
+	templateCmd.AddCommand(templatePublishCmd)
+	templateCmd.AddCommand(templatePullCmd)
+
+	templatePublishCmd.Flags().String("spec", "", "publish this spec file instead of resolving one from config and flags")
+	templatePublishCmd.Flags().StringP("workdir", "w", "", "working directory to mount (default: current directory)")
+	templatePublishCmd.Flags().StringArrayP("mount", "m", nil, "additional directories to mount (read-write)")
+	templatePublishCmd.Flags().StringArray("mount-ro", nil, "additional directories to mount (read-only)")
+	templatePublishCmd.Flags().StringArray("ref", nil, "read-only reference directory, as path or name=path, mounted at /refs/<name> (repeatable)")
+	templatePublishCmd.Flags().String("image", "", "Docker image to use (default: enclaude:latest)")
+
+	// Templates never carry credentials, as with export-spec
+	templatePublishCmd.Flags().Bool("no-creds", true, "")
+	templatePublishCmd.Flags().MarkHidden("no-creds")
+
+	templatePullCmd.Flags().StringP("output", "o", "", "write the template's spec to this file, for use with --from-spec")
+}
+
+var templateCmd = &cobra.Command{
+	Use:   "template",
+	Short: "Share sandbox definitions through an OCI registry",
+	Long: `Publish and pull sandbox templates: a sandbox definition, as written by
+export-spec, and the argument presets to run it with, stored as an OCI
+artifact. A platform team can publish an approved template and users run it
+with 'enclaude --template <ref>@sha256:<digest>'; templates only run pinned
+by digest, which 'template pull' shows.
+
+Registry logins are read from the docker CLI; run 'docker login' first.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var templatePublishCmd = &cobra.Command{
+	Use:   "publish <oci-ref> [flags] [-- claude-args...]",
+	Short: "Publish a sandbox template to a registry",
+	Long: `Resolve the sandbox definition enclaude would use in the current directory,
+as export-spec does, and push it to a registry together with the presets in
+claude.arg_presets. With --spec, an existing spec file is published instead.
+
+Like specs, templates never contain secrets, and paths under your home
+directory are stored relative to "~".
+
+Examples:
+  enclaude template publish ghcr.io/acme/approved:v1
+  enclaude template publish ghcr.io/acme/approved:v1 --spec run.json`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runTemplatePublish,
+}
+
+var templatePullCmd = &cobra.Command{
+	Use:   "pull <oci-ref>",
+	Short: "Show a sandbox template from a registry",
+	Long: `Pull a sandbox template and show what it runs: the image, mounts, network
+policy, and presets. With --output, its spec is also written to a file that
+can be reviewed, checked in, and run with 'enclaude --from-spec'.
+
+Examples:
+  enclaude template pull ghcr.io/acme/approved:v1
+  enclaude template pull ghcr.io/acme/approved:v1 -o approved.json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTemplatePull,
+}
+
+func runTemplatePublish(cmd *cobra.Command, args []string) error {
+	ref, err := oci.ParseReference(args[0])
+	if err != nil {
+		return err
+	}
+
+	var spec container.Spec
+	if specPath, _ := cmd.Flags().GetString("spec"); specPath != "" {
+		if spec, err = container.ReadSpec(specPath); err != nil {
+			return err
+		}
+	} else {
+		// As in export-spec, the workspace is stored as "." and the editor
+		// bridge is started by whoever runs the template
+		cfg.Workspace.Mode = config.WorkspaceBind
+		cfg.Editor.Bridge = false
+
+		opts, cleanup, err := buildRunOptions(cmd, args[1:])
+		if err != nil {
+			return fmt.Errorf("cannot publish template: %w", err)
+		}
+		defer cleanup()
+		home, _ := os.UserHomeDir()
+		spec = container.NewSpec(opts, home)
+	}
+
+	data, err := container.EncodeTemplate(container.Template{Spec: spec, Presets: cfg.Claude.ArgPresets})
+	if err != nil {
+		return err
+	}
+	digest, err := templateClient().Push(context.Background(), ref, container.TemplateArtifactType, data)
+	if err != nil {
+		return err
+	}
+
+	pinned := ref
+	pinned.Tag, pinned.Digest = "", digest
+	fmt.Printf("✅ Template published: %s@%s\n", ref, digest)
+	fmt.Printf("   Run it with: enclaude --template %s\n", pinned)
+	return nil
+}
+
+func runTemplatePull(cmd *cobra.Command, args []string) error {
+	t, digest, err := pullTemplate(context.Background(), args[0])
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Template: %s\n", args[0])
+	fmt.Printf("Digest:   %s\n", digest)
+	if ref, err := oci.ParseReference(args[0]); err == nil {
+		ref.Tag, ref.Digest = "", digest
+		fmt.Printf("Run:      enclaude --template %s\n", ref)
+	}
+	fmt.Printf("Image:    %s\n", t.Options.Image)
+	network := t.Options.Network
+	if network == "" {
+		network = "default"
+	}
+	fmt.Printf("Network:  %s\n", network)
+	if len(t.Options.BlockedHosts) > 0 {
+		fmt.Printf("Blocked:  %s\n", strings.Join(t.Options.BlockedHosts, ", "))
+	}
+	if len(t.Options.Mounts) > 0 {
+		fmt.Println("Mounts:")
+		for _, m := range t.Options.Mounts {
+			mode := "rw"
+			if m.ReadOnly {
+				mode = "ro"
+			}
+			fmt.Printf("  %s -> %s (%s)\n", m.Source, m.Target, mode)
+		}
+	}
+	if len(t.Presets) > 0 {
+		fmt.Println("Presets:")
+		for _, name := range presetNames(t.Presets) {
+			fmt.Printf("  %-16s %s\n", name, strings.Join(t.Presets[name], " "))
+		}
+	}
+
+	if output, _ := cmd.Flags().GetString("output"); output != "" {
+		if err := container.WriteSpec(output, t.Spec); err != nil {
+			return err
+		}
+		fmt.Printf("\n✅ Spec written to: %s\n", output)
+	}
+	return nil
+}
+
+// resolveTemplate pulls the template for --template and returns its spec
+// and the claude arguments to run it with: those given on the command line,
+// or those of the preset chosen with --template-preset. The reference must
+// be pinned by digest, so whoever can push to the tag cannot change what
+// runs.
+func resolveTemplate(ctx context.Context, cmd *cobra.Command, ref string, args []string) (container.Spec, []string, error) {
+	parsed, err := oci.ParseReference(ref)
+	if err != nil {
+		return container.Spec{}, nil, err
+	}
+	if parsed.Digest == "" {
+		return container.Spec{}, nil, fmt.Errorf("template %s must be pinned by digest (%s@sha256:...); run 'enclaude template pull %s' to see its digest and review it", ref, ref, ref)
+	}
+	t, digest, err := pullTemplate(ctx, ref)
+	if err != nil {
+		return container.Spec{}, nil, err
+	}
+	fmt.Fprintf(os.Stderr, "Using template %s (%s)\n", ref, digest)
+
+	if name, _ := cmd.Flags().GetString("template-preset"); name != "" {
+		if len(t.Presets) == 0 {
+			return container.Spec{}, nil, fmt.Errorf("template %s defines no presets", ref)
+		}
+		if args, err = presetArgs(t.Presets, name, args); err != nil {
+			return container.Spec{}, nil, fmt.Errorf("template %s: %w", ref, err)
+		}
+	}
+	return t.Spec, args, nil
+}
+
+// pullTemplate downloads and decodes the template at ref, returning it with
+// its manifest digest
+func pullTemplate(ctx context.Context, ref string) (container.Template, string, error) {
+	parsed, err := oci.ParseReference(ref)
+	if err != nil {
+		return container.Template{}, "", err
+	}
+	data, digest, err := templateClient().Pull(ctx, parsed, container.TemplateArtifactType)
+	if err != nil {
+		return container.Template{}, "", err
+	}
+	t, err := container.DecodeTemplate(data, ref)
+	if err != nil {
+		return container.Template{}, "", err
+	}
+	return t, digest, nil
+}
+
+// templateClient talks to registries with the docker CLI's logins
+func templateClient() *oci.Client {
+	return &oci.Client{Credentials: container.RegistryCredentials}
+}
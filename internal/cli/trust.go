@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jakenelson/enclaude/internal/config"
+	"github.com/jakenelson/enclaude/internal/container"
+	"github.com/jakenelson/enclaude/internal/workspace"
+	"github.com/moby/term"
+	"github.com/spf13/cobra"
+)
+
+// Answers to the workspace trust prompt
+const (
+	trustAccept     = "trust"
+	trustRestricted = "restricted"
+	trustCancel     = "cancel"
+)
+
+// checkWorkspaceTrust applies security.workspace_trust to a session in
+// workDir, reporting whether it must run without host credentials, as with
+// --no-creds. The first session in a workspace that is not trusted shows
+// what the session will have access to and asks whether to trust it; a
+// trusted directory covers everything below it. Without a terminal to ask,
+// or with restricted, untrusted workspaces run without credentials, and
+// without the host-side services that would reach into the host. The mode
+// is only read from the user config, and the project settings that are not
+// on the projectKeys allowlist apply only once the workspace is trusted (see
+// applyProjectConfig). CI runs are not checked, since their checkouts are
+// new every time.
+func checkWorkspaceTrust(cmd *cobra.Command, workDir string, mounts []container.Mount) (bool, error) {
+	mode := cfg.Security.WorkspaceTrust
+	if mode != config.TrustPrompt && mode != config.TrustRestricted || cmd.Name() == "ci" {
+		return false, nil
+	}
+
+	registry, err := workspace.LoadRegistry(registryPath())
+	if err != nil {
+		return false, err
+	}
+	if _, ok := registry.TrustedBy(workDir); ok {
+		return false, nil
+	}
+
+	if mode == config.TrustRestricted || !term.IsTerminal(os.Stdin.Fd()) {
+		fmt.Fprintf(os.Stderr, "%s is not a trusted workspace; running without host credentials. Trust it with 'enclaude workspace trust'.\n", workDir)
+		return true, nil
+	}
+
+	defer pausePull()()
+	describeWorkspaceAccess(os.Stderr, workDir, mounts)
+	switch promptWorkspaceTrust(bufio.NewReader(os.Stdin)) {
+	case trustAccept:
+		registry.Trust(workDir, time.Now())
+		if err := registry.Save(); err != nil {
+			fmt.Fprintln(os.Stderr, "Warning:", err)
+		} else {
+			fmt.Fprintf(os.Stderr, "Trusted %s; undo it with 'enclaude workspace untrust'.\n", workDir)
+		}
+		return false, nil
+	case trustRestricted:
+		return true, nil
+	}
+	return false, fmt.Errorf("session cancelled: %s is not trusted", workDir)
+}
+
+// describeWorkspaceAccess lists the host directories and credentials a
+// session in workDir would be given
+func describeWorkspaceAccess(w io.Writer, workDir string, mounts []container.Mount) {
+	fmt.Fprintf(w, "enclaude has not run in %s before. The session will have access to:\n", workDir)
+	for _, m := range mounts {
+		access := "read-write"
+		if m.ReadOnly {
+			access = "read-only"
+		}
+		source := m.Source
+		if m.Volume {
+			source = "volume " + source
+		}
+		fmt.Fprintf(w, "  %s → %s (%s)\n", source, m.Target, access)
+	}
+	fmt.Fprintf(w, "  Host credentials enabled in your config: %s\n", strings.Join(configuredCredentials(), ", "))
+}
+
+// configuredCredentials names the host credentials the config would pass to
+// a session. Those set to auto are passed only if found on the host.
+func configuredCredentials() []string {
+	creds := []string{"Claude authentication"}
+	for _, c := range []struct{ name, setting string }{
+		{"GitHub", cfg.Credentials.GitHub},
+		{"Google Cloud", cfg.Credentials.GCloud},
+		{"Bitbucket", cfg.Credentials.Bitbucket},
+		{"Azure DevOps", cfg.Credentials.AzDO},
+	} {
+		if c.setting != "" && c.setting != config.CredentialDisabled {
+			creds = append(creds, c.name)
+		}
+	}
+	if cfg.Credentials.SSH.Enabled {
+		creds = append(creds, "SSH")
+	}
+	if cfg.Credentials.GPG.Enabled {
+		creds = append(creds, "GPG")
+	}
+	for _, f := range cfg.Credentials.ExtraFiles {
+		creds = append(creds, f.Source)
+	}
+	return creds
+}
+
+// promptWorkspaceTrust asks whether to trust the workspace
+func promptWorkspaceTrust(reader *bufio.Reader) string {
+	fmt.Fprintln(os.Stderr, "\nDo you trust the files in this workspace?")
+	fmt.Fprintln(os.Stderr, "  1) trust       - Trust it and run with your credentials, now and from now on")
+	fmt.Fprintln(os.Stderr, "  2) restricted  - Run this session without host credentials")
+	fmt.Fprintln(os.Stderr, "  3) cancel      - Do not run")
+
+	for {
+		fmt.Fprintf(os.Stderr, "\nChoice [1-3] (default: cancel): ")
+		input, err := reader.ReadString('\n')
+		if err != nil {
+			return trustCancel
+		}
+		switch strings.TrimSpace(input) {
+		case "1":
+			return trustAccept
+		case "2":
+			return trustRestricted
+		case "", "3":
+			return trustCancel
+		default:
+			fmt.Fprintln(os.Stderr, "Invalid choice. Please enter 1, 2, or 3.")
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/jakenelson/enclaude/internal/container"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+}
+
+var watchCmd = &cobra.Command{
+	Use:   "watch [session]",
+	Short: "Watch a running session read-only",
+	Long: `Attach a read-only view of a running session's terminal, so a teammate or a
+second terminal can follow what Claude is doing in real time. Input is never
+sent to the session; press Ctrl+C to stop watching without affecting it.
+
+The session may be given as a container ID or name. Without an argument, the
+only running session is watched; if there are several, the one started from
+the current directory is watched, or running sessions are listed.
+
+The view is rendered at the session's terminal size, so output may wrap
+differently if the watching terminal is smaller.
+
+Examples:
+  enclaude watch
+  enclaude watch 3f2a9c1b7d4e`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runWatch,
+}
+
+func runWatch(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigCh
+		cancel()
+	}()
+
+	runner, err := container.NewRunner()
+	if err != nil {
+		return fmt.Errorf("failed to create container runner: %w", err)
+	}
+	defer runner.Close()
+
+	session, err := pickSession(ctx, runner, args, "watch")
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Watching session %s (read-only, Ctrl+C to stop)\n", shortID(session))
+	if err := runner.Watch(ctx, session, os.Stdout); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "\nStopped watching session %s\n", shortID(session))
+	return nil
+}
+
+// pickSession returns the session named in args, or the only running
+// session. When several are running it prefers the one started from the
+// current directory, and otherwise lists them and asks for one by name in
+// 'enclaude <command> <session>'.
+func pickSession(ctx context.Context, runner *container.Runner, args []string, command string) (string, error) {
+	if len(args) > 0 {
+		return args[0], nil
+	}
+	sessions, err := runner.ListSessions(ctx)
+	if err != nil {
+		return "", err
+	}
+	switch len(sessions) {
+	case 0:
+		return "", fmt.Errorf("no running enclaude sessions")
+	case 1:
+		return sessions[0].ID, nil
+	}
+
+	if cwd, err := os.Getwd(); err == nil {
+		var here []container.Session
+		for _, s := range sessions {
+			if s.Workspace == cwd {
+				here = append(here, s)
+			}
+		}
+		if len(here) == 1 {
+			return here[0].ID, nil
+		}
+	}
+
+	fmt.Println("Running sessions:")
+	for _, s := range sessions {
+		fmt.Printf("  %s  %-24s %s  (started %s)\n", shortID(s.ID), s.Name, s.Workspace, s.Created.Format("15:04:05"))
+	}
+	return "", fmt.Errorf("multiple sessions running; specify one with 'enclaude %s <session>'", command)
+}
+
+// shortID truncates a container ID for display
+func shortID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
@@ -0,0 +1,316 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jakenelson/enclaude/internal/config"
+	"github.com/jakenelson/enclaude/internal/security"
+	"github.com/jakenelson/enclaude/internal/workspace"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	rootCmd.AddCommand(workspaceCmd)
+	workspaceCmd.AddCommand(workspacePinCmd)
+	workspaceCmd.AddCommand(workspaceUnpinCmd)
+	workspaceCmd.AddCommand(workspaceListCmd)
+	workspaceCmd.AddCommand(workspaceTrustCmd)
+	workspaceCmd.AddCommand(workspaceUntrustCmd)
+
+	workspacePinCmd.Flags().StringP("workdir", "w", "", "workspace to pin (default: current directory)")
+	workspacePinCmd.Flags().String("image", "", "Docker image to use in this workspace")
+	workspacePinCmd.Flags().String("profile", "", "config profile to apply in this workspace")
+	workspacePinCmd.Flags().String("auth", "", "Claude auth to use when both an API key and a session exist (session, api-key)")
+	workspaceUnpinCmd.Flags().StringP("workdir", "w", "", "workspace to unpin (default: current directory)")
+	workspaceTrustCmd.Flags().StringP("workdir", "w", "", "workspace to trust (default: current directory)")
+	workspaceUntrustCmd.Flags().StringP("workdir", "w", "", "workspace to stop trusting (default: current directory)")
+}
+
+var workspaceCmd = &cobra.Command{
+	Use:   "workspace",
+	Short: "Pin images and profiles to workspaces, and trust workspaces",
+	Long: `Pin an image and/or config profile to a workspace, so returning to the
+project uses them automatically without any flags. The Claude auth method
+chosen when both an API key and a session exist is remembered here too. Pins apply to the pinned
+directory and everything below it, and are stored centrally in
+~/.config/enclaude/workspaces.json rather than in the project.
+
+With security.workspace_trust set, sessions in workspaces that have not been
+trusted run without host credentials, or ask first. Trusting a directory
+trusts everything below it.
+
+A profile is a config file at ~/.config/enclaude/profiles/<name>.yaml that
+is merged over your user config; a project's .enclaude.yaml still takes
+precedence over it. The --image and --profile flags override pins.
+
+Examples:
+  enclaude workspace pin --image enclaude:go1.22 --profile work
+  enclaude workspace list
+  enclaude workspace unpin
+  enclaude workspace trust -w ~/src/api`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var workspacePinCmd = &cobra.Command{
+	Use:   "pin",
+	Short: "Pin an image, profile and/or auth method to a workspace",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		image, _ := cmd.Flags().GetString("image")
+		profileName, _ := cmd.Flags().GetString("profile")
+		auth, _ := cmd.Flags().GetString("auth")
+		if image == "" && profileName == "" && auth == "" {
+			return fmt.Errorf("specify --image, --profile and/or --auth to pin")
+		}
+		if auth != "" && auth != config.AuthSession && auth != config.AuthAPIKey {
+			return fmt.Errorf("invalid --auth %q (allowed: %s, %s)", auth, config.AuthSession, config.AuthAPIKey)
+		}
+		if profileName != "" {
+			if _, err := profilePath(profileName); err != nil {
+				return err
+			}
+		}
+
+		workDir, err := resolveWorkDir(cmd)
+		if err != nil {
+			return err
+		}
+		registry, err := workspace.LoadRegistry(registryPath())
+		if err != nil {
+			return err
+		}
+		// A remembered auth choice survives re-pinning the image or profile
+		pin := workspace.Pin{Image: image, Profile: profileName, Auth: auth}
+		if pin.Auth == "" {
+			pin.Auth = registry.Workspaces[filepath.Clean(workDir)].Auth
+		}
+		registry.Pin(workDir, pin)
+		if err := registry.Save(); err != nil {
+			return err
+		}
+
+		fmt.Printf("Pinned %s: %s\n", workDir, describePin(pin))
+		return nil
+	},
+}
+
+var workspaceUnpinCmd = &cobra.Command{
+	Use:   "unpin",
+	Short: "Remove a workspace's pin",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		workDir, err := resolveWorkDir(cmd)
+		if err != nil {
+			return err
+		}
+		registry, err := workspace.LoadRegistry(registryPath())
+		if err != nil {
+			return err
+		}
+		if !registry.Unpin(workDir) {
+			return fmt.Errorf("%s is not pinned", workDir)
+		}
+		if err := registry.Save(); err != nil {
+			return err
+		}
+		fmt.Printf("Unpinned %s\n", workDir)
+		return nil
+	},
+}
+
+var workspaceListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List pinned and trusted workspaces",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		registry, err := workspace.LoadRegistry(registryPath())
+		if err != nil {
+			return err
+		}
+		dirs := registry.Dirs()
+		for _, dir := range registry.TrustedDirs() {
+			if _, ok := registry.Workspaces[dir]; !ok {
+				dirs = append(dirs, dir)
+			}
+		}
+		if len(dirs) == 0 {
+			fmt.Println("No pinned or trusted workspaces.")
+			return nil
+		}
+		sort.Strings(dirs)
+		for _, dir := range dirs {
+			var parts []string
+			if pin, ok := registry.Workspaces[dir]; ok {
+				parts = append(parts, describePin(pin))
+			}
+			if at, ok := registry.Trusted[dir]; ok {
+				parts = append(parts, "trusted since "+at.Local().Format("2006-01-02"))
+			}
+			fmt.Printf("%s\n  %s\n", dir, strings.Join(parts, ", "))
+		}
+		return nil
+	},
+}
+
+var workspaceTrustCmd = &cobra.Command{
+	Use:   "trust",
+	Short: "Trust a workspace, so its sessions get host credentials",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		workDir, err := resolveWorkDir(cmd)
+		if err != nil {
+			return err
+		}
+		registry, err := workspace.LoadRegistry(registryPath())
+		if err != nil {
+			return err
+		}
+		registry.Trust(workDir, time.Now())
+		if err := registry.Save(); err != nil {
+			return err
+		}
+		fmt.Printf("Trusted %s\n", workDir)
+		return nil
+	},
+}
+
+var workspaceUntrustCmd = &cobra.Command{
+	Use:   "untrust",
+	Short: "Stop trusting a workspace",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		workDir, err := resolveWorkDir(cmd)
+		if err != nil {
+			return err
+		}
+		registry, err := workspace.LoadRegistry(registryPath())
+		if err != nil {
+			return err
+		}
+		if !registry.Untrust(workDir) {
+			if dir, ok := registry.TrustedBy(workDir); ok {
+				return fmt.Errorf("%s is trusted through %s; untrust that directory instead", workDir, dir)
+			}
+			return fmt.Errorf("%s is not trusted", workDir)
+		}
+		if err := registry.Save(); err != nil {
+			return err
+		}
+		fmt.Printf("Untrusted %s\n", workDir)
+		return nil
+	},
+}
+
+// applyWorkspaceSettings applies the project settings that need approval,
+// and the --profile flag or the pin registered for the command's workspace,
+// before a session command runs. Explicit flags take
+// precedence over the pin. Commands without a workspace, such as config, are
+// left alone so a profile is never written back to the user config.
+func applyWorkspaceSettings(cmd *cobra.Command, args []string) error {
+	// The project config comes from the command's workspace, not the
+	// directory enclaude was started in
+	workDir := "."
+	if cmd.Flags().Lookup("workdir") != nil {
+		var err error
+		if workDir, err = resolveWorkDir(cmd); err != nil {
+			return err
+		}
+	}
+	if mergeProjectConfig(workDir) {
+		cfg = config.LoadConfig()
+	}
+
+	if cmd.Flags().Lookup("workdir") == nil || cmd == workspacePinCmd || cmd == workspaceUnpinCmd ||
+		cmd == workspaceTrustCmd || cmd == workspaceUntrustCmd {
+		return nil
+	}
+	projectChanged := applyProjectConfig(cmd)
+
+	profileName := profile
+	var image string
+	registry, err := workspace.LoadRegistry(registryPath())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Warning:", err)
+	} else if pin, dir, ok := registry.Lookup(workDir); ok {
+		if profileName == "" {
+			profileName = pin.Profile
+		}
+		if imageFlag := cmd.Flags().Lookup("image"); imageFlag == nil || !imageFlag.Changed {
+			image = pin.Image
+		}
+		fmt.Fprintf(os.Stderr, "Using workspace pin for %s: %s\n", dir, describePin(pin))
+	}
+
+	if profileName == "" && image == "" && !projectChanged {
+		return nil
+	}
+	if profileName != "" {
+		if err := mergeProfile(profileName); err != nil {
+			return err
+		}
+	}
+	if image != "" {
+		viper.Set("image.name", image)
+	}
+	cfg = config.LoadConfig()
+	return nil
+}
+
+// mergeProfile merges the named profile over the user config, then merges
+// the project config again so it keeps precedence over the profile
+func mergeProfile(name string) error {
+	path, err := profilePath(name)
+	if err != nil {
+		return err
+	}
+	p := viper.New()
+	p.SetConfigFile(path)
+	if err := p.ReadInConfig(); err != nil {
+		return fmt.Errorf("failed to read profile %q: %w", name, err)
+	}
+	if err := viper.MergeConfigMap(p.AllSettings()); err != nil {
+		return fmt.Errorf("failed to merge profile %q: %w", name, err)
+	}
+	config.RecordEnvNames(path)
+	mergeProjectConfig(projectDir)
+	return nil
+}
+
+// profilePath returns the config file of the named profile, which must exist
+func profilePath(name string) (string, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") {
+		return "", fmt.Errorf("invalid profile name %q", name)
+	}
+	path := filepath.Join(filepath.Dir(getConfigPath()), "profiles", name+".yaml")
+	if !security.FileExists(path) {
+		return "", fmt.Errorf("profile %q not found (expected %s)", name, path)
+	}
+	return path, nil
+}
+
+// registryPath returns the path of the central workspace registry
+func registryPath() string {
+	return filepath.Join(filepath.Dir(getConfigPath()), "workspaces.json")
+}
+
+func describePin(pin workspace.Pin) string {
+	var parts []string
+	if pin.Image != "" {
+		parts = append(parts, "image "+pin.Image)
+	}
+	if pin.Profile != "" {
+		parts = append(parts, "profile "+pin.Profile)
+	}
+	if pin.Auth != "" {
+		parts = append(parts, "auth "+pin.Auth)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/jakenelson/enclaude/internal/wsl"
+)
+
+// startWSLAgentRelay relays the Windows OpenSSH agent into the distribution
+// when agent forwarding is enabled under WSL 2 and no Linux agent is running,
+// so the existing SSH_AUTH_SOCK forwarding picks it up. The returned function
+// stops the relay.
+func startWSLAgentRelay(info wsl.Info) func() {
+	ssh := cfg.Credentials.SSH
+	if info.Version != 2 || !ssh.Enabled || !ssh.AgentForwarding || os.Getenv("SSH_AUTH_SOCK") != "" {
+		return func() {}
+	}
+	sock, stop, err := wsl.StartAgentRelay()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Windows SSH agent not forwarded: %v\n", err)
+		return func() {}
+	}
+	os.Setenv("SSH_AUTH_SOCK", sock)
+	return func() {
+		os.Unsetenv("SSH_AUTH_SOCK")
+		stop()
+	}
+}
+
+// wslWorkspaceEnv returns environment for a workspace on a mounted Windows
+// drive. Docker Desktop presents drvfs files as owned by root, so git in the
+// container would refuse the repository as having dubious ownership unless
+// the workspace is marked safe.
+func wslWorkspaceEnv(info wsl.Info, workDir, target string) map[string]string {
+	drive, ok := info.Drive(workDir)
+	if !ok {
+		return nil
+	}
+	fmt.Fprintf(os.Stderr, "Warning: workspace is on Windows drive %s:; file access will be slow and Unix permissions are not preserved\n", drive)
+	return map[string]string{
+		"GIT_CONFIG_COUNT":   "1",
+		"GIT_CONFIG_KEY_0":   "safe.directory",
+		"GIT_CONFIG_VALUE_0": target,
+	}
+}
+
+// wslChecks flags WSL setups that break or slow down sessions: WSL 1, a
+// Docker Desktop without integration for this distribution, a root default
+// user, workspaces on Windows drives, and SSH agent forwarding without a
+// relay to the Windows agent
+func wslChecks(info wsl.Info, workDir string, desktop func() (bool, error)) []doctorResult {
+	if !info.Enabled() {
+		return nil
+	}
+	var results []doctorResult
+
+	if info.Version == 1 {
+		results = append(results, doctorResult{
+			status: "Running under WSL 1, which Docker does not support",
+			hint:   "Convert the distribution with 'wsl --set-version " + info.Distro + " 2' from Windows.",
+		})
+	} else {
+		results = append(results, doctorResult{ok: true, status: "Running under WSL 2 (" + info.Distro + ")"})
+	}
+
+	switch isDesktop, err := desktop(); {
+	case err != nil:
+		results = append(results, doctorResult{
+			status: "Docker is not reachable from WSL",
+			hint:   "Enable WSL integration for " + info.Distro + " in Docker Desktop (Settings > Resources > WSL Integration), or start dockerd in the distribution.",
+		})
+	case isDesktop:
+		results = append(results, doctorResult{ok: true, status: "Using Docker Desktop through WSL integration"})
+	default:
+		results = append(results, doctorResult{ok: true, status: "Using Docker Engine inside the WSL distribution"})
+	}
+
+	if os.Getuid() == 0 {
+		results = append(results, doctorResult{
+			status: "The WSL default user is root, so files the container creates will be owned by root",
+			hint:   "Create a regular user and set it under [user] default in /etc/wsl.conf.",
+		})
+	}
+
+	if drive, ok := info.Drive(workDir); ok {
+		results = append(results, doctorResult{
+			status: "Workspace is on Windows drive " + drive + ": (" + info.WindowsPath(workDir) + ")",
+			hint:   "Files under " + info.MountRoot + " are slow and ignore Unix permissions; move the project into the Linux filesystem, e.g. ~/src.",
+		})
+	} else {
+		results = append(results, doctorResult{ok: true, status: "Workspace is in the Linux filesystem (" + info.WindowsPath(workDir) + " from Windows)"})
+	}
+
+	ssh := cfg.Credentials.SSH
+	if ssh.Enabled && ssh.AgentForwarding && os.Getenv("SSH_AUTH_SOCK") == "" {
+		_, socatErr := exec.LookPath("socat")
+		_, relayErr := exec.LookPath("npiperelay.exe")
+		if socatErr != nil || relayErr != nil {
+			results = append(results, doctorResult{
+				status: "SSH agent forwarding is enabled, but no agent is running and the Windows agent cannot be relayed",
+				hint:   "Install socat in the distribution and npiperelay.exe on the Windows PATH, or start ssh-agent in WSL.",
+			})
+		} else {
+			results = append(results, doctorResult{ok: true, status: "Windows SSH agent will be relayed with npiperelay"})
+		}
+	}
+
+	return results
+}
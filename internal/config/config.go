@@ -1,18 +1,33 @@
 package config
 
 import (
+	"strings"
+
 	"github.com/spf13/viper"
 )
 
 // Config represents the full configuration structure
 type Config struct {
-	Image       ImageConfig       `mapstructure:"image"`
-	Mounts      MountsConfig      `mapstructure:"mounts"`
-	Claude      ClaudeConfig      `mapstructure:"claude"`
-	Credentials CredentialsConfig `mapstructure:"credentials"`
-	Environment EnvironmentConfig `mapstructure:"environment"`
-	Container   ContainerConfig   `mapstructure:"container"`
-	Security    SecurityConfig    `mapstructure:"security"`
+	ConfigVersion  int                  `mapstructure:"config_version"`
+	Image          ImageConfig          `mapstructure:"image"`
+	Mounts         MountsConfig         `mapstructure:"mounts"`
+	Claude         ClaudeConfig         `mapstructure:"claude"`
+	Credentials    CredentialsConfig    `mapstructure:"credentials"`
+	Environment    EnvironmentConfig    `mapstructure:"environment"`
+	Container      ContainerConfig      `mapstructure:"container"`
+	Security       SecurityConfig       `mapstructure:"security"`
+	Shell          ShellConfig          `mapstructure:"shell"`
+	Workspace      WorkspaceConfig      `mapstructure:"workspace"`
+	Updates        UpdatesConfig        `mapstructure:"updates"`
+	Terminal       TerminalConfig       `mapstructure:"terminal"`
+	Output         OutputConfig         `mapstructure:"output"`
+	Editor         EditorConfig         `mapstructure:"editor"`
+	HostCommands   HostCommandsConfig   `mapstructure:"host_commands"`
+	AccessRequests AccessRequestsConfig `mapstructure:"access_requests"`
+	ShellPolicy    ShellPolicyConfig    `mapstructure:"shell_policy"`
+	Network        NetworkConfig        `mapstructure:"network"`
+	Git            GitConfig            `mapstructure:"git"`
+	Sockets        []SocketEntry        `mapstructure:"sockets"`
 }
 
 // ImageConfig configures the Docker image
@@ -20,12 +35,23 @@ type ImageConfig struct {
 	Name         string `mapstructure:"name"`
 	Dockerfile   string `mapstructure:"dockerfile"`
 	BuildContext string `mapstructure:"build_context"`
+	PullPolicy   string `mapstructure:"pull_policy"`  // always, missing, never
+	AutoRebuild  bool   `mapstructure:"auto_rebuild"` // Rebuild without asking when the Dockerfile or build context changed
 }
 
 // MountsConfig configures default mount behavior
 type MountsConfig struct {
-	Defaults  []MountEntry `mapstructure:"defaults"`
-	ClaudeDir string       `mapstructure:"claude_dir"` // Deprecated: use claude.session_dir
+	Defaults  []MountEntry  `mapstructure:"defaults"`
+	Volumes   []VolumeEntry `mapstructure:"volumes"`    // Named volumes, e.g. for build caches
+	ClaudeDir string        `mapstructure:"claude_dir"` // Deprecated: migrated to claude.session_dir
+
+	// WorkspaceTarget is where the workspace is mounted in the container:
+	// an absolute path, or "host" to use the workspace's host path
+	WorkspaceTarget string `mapstructure:"workspace_target"`
+
+	// Workspaces are roots of a multi-root workspace, each mounted
+	// read-write under WorkspaceTarget
+	Workspaces []WorkspaceEntry `mapstructure:"workspaces"`
 }
 
 // MountEntry represents a single mount configuration
@@ -34,18 +60,92 @@ type MountEntry struct {
 	ReadOnly bool   `mapstructure:"readonly"`
 }
 
+// WorkspaceEntry is one root of a multi-root workspace
+type WorkspaceEntry struct {
+	Name string `mapstructure:"name"` // Directory under the workspace target (default: the path's base name)
+	Path string `mapstructure:"path"` // Host path; relative paths are resolved against the working directory
+}
+
+// VolumeEntry represents a named Docker volume mounted into the container
+type VolumeEntry struct {
+	Name string `mapstructure:"name"`
+	Path string `mapstructure:"path"`
+}
+
 // ClaudeConfig configures Claude authentication and behavior
 type ClaudeConfig struct {
-	Auth        string   `mapstructure:"auth"`        // auto, session, api-key
-	SessionDir  string   `mapstructure:"session_dir"` // none, readonly, readwrite
-	DefaultArgs []string `mapstructure:"default_args"`
+	Auth        string              `mapstructure:"auth"`        // auto, session, api-key
+	Provider    string              `mapstructure:"provider"`    // anthropic, bedrock, vertex
+	SessionDir  string              `mapstructure:"session_dir"` // none, readonly, readwrite, volume
+	Prefer      string              `mapstructure:"prefer"`      // ask, session, api-key: used when auto finds both
+	DefaultArgs []string            `mapstructure:"default_args"`
+	ArgPresets  map[string][]string `mapstructure:"arg_presets"` // Named argument lists for `enclaude preset <name>`
+	Preflight   bool                `mapstructure:"preflight"`   // Check API connectivity before starting
+
+	// What a session does when another session already uses the host
+	// ~/.claude read-write: warn, wait, readonly, off
+	SessionLock string `mapstructure:"session_lock"`
+
+	DisableTelemetry bool `mapstructure:"disable_telemetry"` // Disable telemetry/error reporting and block their endpoints
+	CacheVolume      bool `mapstructure:"cache_volume"`      // Keep Claude Code's caches in a shared Docker volume
+	Secretless       bool `mapstructure:"secretless"`        // Keep ANTHROPIC_API_KEY on the host behind an auth-injecting proxy
+
+	// HTTPS URL the secretless proxy forwards to (default: the host's
+	// ANTHROPIC_BASE_URL, else the Anthropic API)
+	SecretlessUpstream string `mapstructure:"secretless_upstream"`
+
+	// Host directories of Claude Code definitions, mounted read-only into
+	// the container's ~/.claude
+	AgentsDir       string `mapstructure:"agents_dir"`        // Subagent definitions (~/.claude/agents)
+	CommandsDir     string `mapstructure:"commands_dir"`      // Custom slash commands (~/.claude/commands)
+	OutputStylesDir string `mapstructure:"output_styles_dir"` // Output styles (~/.claude/output-styles)
+
+	// Host Claude Code to run instead of the image's: a Linux binary, or an
+	// npm pack (.tgz) installed at startup
+	Binary string `mapstructure:"binary"`
+
+	Bedrock BedrockConfig `mapstructure:"bedrock"` // Used when provider is "bedrock"
+	Vertex  VertexConfig  `mapstructure:"vertex"`  // Used when provider is "vertex"
+}
+
+// BedrockConfig configures access to Claude through Amazon Bedrock
+type BedrockConfig struct {
+	Region  string `mapstructure:"region"`  // AWS region (default: $AWS_REGION)
+	Profile string `mapstructure:"profile"` // AWS profile to export credentials from (default: $AWS_PROFILE)
+}
+
+// VertexConfig configures access to Claude through Google Vertex AI
+type VertexConfig struct {
+	ProjectID string `mapstructure:"project_id"` // GCP project (default: $ANTHROPIC_VERTEX_PROJECT_ID)
+	Region    string `mapstructure:"region"`     // Vertex region (default: $CLOUD_ML_REGION or us-east5)
 }
 
 // CredentialsConfig configures external service credential passthrough
 type CredentialsConfig struct {
-	GitHub string    `mapstructure:"github"` // auto, enabled, disabled
-	GCloud string    `mapstructure:"gcloud"` // auto, enabled, disabled
-	SSH    SSHConfig `mapstructure:"ssh"`
+	GitHub     string          `mapstructure:"github"`    // auto, enabled, disabled, app
+	GCloud     string          `mapstructure:"gcloud"`    // auto, enabled, disabled
+	Bitbucket  string          `mapstructure:"bitbucket"` // auto, enabled, disabled
+	AzDO       string          `mapstructure:"azdo"`      // auto, enabled, disabled
+	SSH        SSHConfig       `mapstructure:"ssh"`
+	GPG        GPGConfig       `mapstructure:"gpg"`
+	TTL        string          `mapstructure:"ttl"`         // e.g., "30m"; empty means no expiry
+	GitHubApp  GitHubAppConfig `mapstructure:"github_app"`  // Used when github is "app"
+	ExtraFiles []ExtraFile     `mapstructure:"extra_files"` // Additional tool credential files, mounted read-only
+}
+
+// ExtraFile maps a host credential file or directory into the container
+type ExtraFile struct {
+	Source string `mapstructure:"source"` // Host path, e.g., "~/.terraformrc"
+	Target string `mapstructure:"target"` // Container path; "~/" is the container HOME (default: same place under HOME)
+}
+
+// GitHubAppConfig configures repository-scoped GitHub App installation tokens
+type GitHubAppConfig struct {
+	AppID        string            `mapstructure:"app_id"`
+	PrivateKey   string            `mapstructure:"private_key"`  // Path to the app's PEM private key
+	APIURL       string            `mapstructure:"api_url"`      // GitHub Enterprise API URL (optional)
+	Repositories []string          `mapstructure:"repositories"` // owner/repo a token may be minted for; the origin remote must match one
+	Permissions  map[string]string `mapstructure:"permissions"`  // Token permissions (default: contents: write)
 }
 
 // SSHConfig configures SSH credential passthrough
@@ -56,6 +156,13 @@ type SSHConfig struct {
 	AgentForwarding bool     `mapstructure:"agent_forwarding"`
 }
 
+// GPGConfig configures GPG signing through the host agent. Only public
+// keyring data is mounted; private keys stay with the host agent.
+type GPGConfig struct {
+	Enabled         bool `mapstructure:"enabled"`
+	AgentForwarding bool `mapstructure:"agent_forwarding"` // Forward the restricted S.gpg-agent.extra socket
+}
+
 // EnvironmentConfig configures environment variables
 type EnvironmentConfig struct {
 	Passthrough []string          `mapstructure:"passthrough"`
@@ -64,9 +171,44 @@ type EnvironmentConfig struct {
 
 // ContainerConfig configures container runtime settings
 type ContainerConfig struct {
-	User        string `mapstructure:"user"`         // auto, or uid:gid
-	MemoryLimit string `mapstructure:"memory_limit"` // e.g., "4g"
-	Network     string `mapstructure:"network"`      // bridge, none, host
+	Engine      string       `mapstructure:"engine"`       // docker, containerd
+	Namespace   string       `mapstructure:"namespace"`    // containerd namespace, e.g., "k8s.io" for k3s
+	User        string       `mapstructure:"user"`         // auto, random, or uid:gid
+	Preset      string       `mapstructure:"preset"`       // small, medium, large, unlimited
+	MemoryLimit string       `mapstructure:"memory_limit"` // e.g., "4g" (overrides preset)
+	CPUs        string       `mapstructure:"cpus"`         // e.g., "2" or "1.5" (overrides preset)
+	PidsLimit   int64        `mapstructure:"pids_limit"`   // Max processes (overrides preset)
+	TmpfsSize   string       `mapstructure:"tmpfs_size"`   // Size of /tmp, /run, /var/tmp (overrides preset)
+	Network     string       `mapstructure:"network"`      // bridge, none, host, or a user-defined network
+	Ports       []string     `mapstructure:"ports"`        // e.g., "8080:8080"
+	StopGrace   string       `mapstructure:"stop_grace"`   // How long claude gets to exit on Ctrl+C before the container is stopped, e.g., "10s"
+	ReadyCheck  string       `mapstructure:"ready_check"`  // Shell command that succeeds once the session is ready (default: the image's ready file)
+	ReadyWait   string       `mapstructure:"ready_wait"`   // How long to wait for ready_check before attaching anyway, e.g., "30s" ("0" attaches at once)
+	Timezone    string       `mapstructure:"timezone"`     // auto, none, or a zone, e.g., "Europe/Berlin"
+	Locale      string       `mapstructure:"locale"`       // auto, none, or a locale for LANG, e.g., "en_US.UTF-8"
+	Interaction string       `mapstructure:"interaction"`  // attach, exec: how the terminal is connected to claude (Docker only)
+	Cgroup      CgroupConfig `mapstructure:"cgroup"`
+
+	// Memory pressure: under host memory pressure the kernel reclaims the
+	// container down to its reservation before the host swaps
+	MemoryReservation string `mapstructure:"memory_reservation"` // Soft limit below memory_limit, e.g., "2g"
+	MemorySwappiness  int    `mapstructure:"memory_swappiness"`  // 0-100; -1 for the host default
+
+	// Idle shrink: a session without terminal input or output for IdleAfter
+	// runs under IdleCPUs until either resumes
+	IdleAfter string `mapstructure:"idle_after"` // e.g., "15m" (empty = never)
+	IdleCPUs  string `mapstructure:"idle_cpus"`  // e.g., "0.1"
+
+	// ExtraDockerArgs are docker run flags enclaude has no setting for, e.g.
+	// "--shm-size=2g"; flags that would weaken the sandbox are refused
+	ExtraDockerArgs []string `mapstructure:"extra_docker_args"`
+}
+
+// CgroupConfig places the container under a dedicated cgroup (Linux only)
+type CgroupConfig struct {
+	Parent    string `mapstructure:"parent"`     // e.g., "enclaude.slice" (systemd driver) or "/enclaude" (cgroupfs)
+	CPUWeight int    `mapstructure:"cpu_weight"` // cgroup v2 cpu.weight, 1-10000 (0 = Docker default)
+	IOWeight  int    `mapstructure:"io_weight"`  // cgroup v2 io.weight, 1-10000 (0 = Docker default)
 }
 
 // SecurityConfig configures security settings
@@ -75,11 +217,100 @@ type SecurityConfig struct {
 	NoNewPrivileges  bool     `mapstructure:"no_new_privileges"`
 	ReadOnlyRoot     bool     `mapstructure:"read_only_root"`
 	CACerts          []string `mapstructure:"ca_certs"` // Additional CA certificate paths to mount
+	WorkspaceTrust   string   `mapstructure:"workspace_trust"`
+	Ephemeral        bool     `mapstructure:"ephemeral"` // Read-only workspace and mounts; scratch space only in tmpfs
+}
+
+// ShellConfig configures the shell environment Claude runs tools from
+type ShellConfig struct {
+	Bashrc         string `mapstructure:"bashrc"`          // Host path to a curated .bashrc
+	Zshrc          string `mapstructure:"zshrc"`           // Host path to a curated .zshrc
+	PersistHistory bool   `mapstructure:"persist_history"` // Keep shell history in a per-project volume
+}
+
+// WorkspaceConfig configures how the working directory is provided to the container
+type WorkspaceConfig struct {
+	Mode           string `mapstructure:"mode"`            // bind, copy
+	IncludeIgnored bool   `mapstructure:"include_ignored"` // Copy mode: ignore .dockerignore/.gitignore
+	Artifacts      string `mapstructure:"artifacts"`       // Host directory mounted read-write at /artifacts; relative to the workspace
+	GrowthWarning  string `mapstructure:"growth_warning"`  // Warn when a session grows the workspace by more than this, e.g. "2g" ("0" disables)
+}
+
+// TerminalConfig configures how session output is presented on the host terminal
+type TerminalConfig struct {
+	Hyperlinks string `mapstructure:"hyperlinks"`  // auto, always, never
+	LinkFormat string `mapstructure:"link_format"` // URL template, e.g., "vscode://file{path}:{line}:{col}"
+}
+
+// OutputConfig configures post-processing of session output that is not a
+// terminal, such as piped output and tee files
+type OutputConfig struct {
+	Filters []string `mapstructure:"filters"` // Applied in order: strip-ansi, host-paths, highlight-paths, or "cmd:<command>"
+}
+
+// EditorConfig configures the bridge that opens container $EDITOR requests
+// in the host editor
+type EditorConfig struct {
+	Bridge  bool   `mapstructure:"bridge"`  // Route $EDITOR in the container to the host editor
+	Command string `mapstructure:"command"` // Host editor command, e.g., "code --wait" (default: $VISUAL/$EDITOR)
+}
+
+// HostCommandsConfig configures the bridge that runs allowlisted host
+// commands on behalf of the container
+type HostCommandsConfig struct {
+	Enabled bool     `mapstructure:"enabled"` // Expose the host command bridge to the container
+	Allow   []string `mapstructure:"allow"`   // Allowed command lines, "*" for one argument, e.g. "open *", "gh auth token"
+}
+
+// NetworkConfig configures a persistent network sessions join under a
+// fixed DNS name, so other containers can reach them and they can reach
+// other containers by name
+type NetworkConfig struct {
+	Create string `mapstructure:"create"` // Network to create if absent and join, e.g. "enclaude"
+	Alias  string `mapstructure:"alias"`  // The session's DNS name on that network
+
+	// Host ports the session reaches at host.enclaude.internal
+	ReverseForward []int `mapstructure:"reverse_forward"`
+}
+
+// ShellPolicyConfig configures the wrapper that checks and audits every
+// command Claude runs through its shell
+type ShellPolicyConfig struct {
+	Enabled        bool     `mapstructure:"enabled"`         // Replace Claude's shell with the policy wrapper
+	Allow          []string `mapstructure:"allow"`           // Command names allowed; empty allows any not denied
+	Deny           []string `mapstructure:"deny"`            // Regular expressions of command lines to block
+	BlockDangerous bool     `mapstructure:"block_dangerous"` // Block built-in patterns such as curl | sh and rm -rf /
+}
+
+// AccessRequestsConfig configures requests from the container for host paths
+// that are not mounted, approved by the user from the session
+type AccessRequestsConfig struct {
+	Enabled bool `mapstructure:"enabled"` // Let the container ask for read-only copies of host paths
+}
+
+// SocketEntry forwards a host Unix socket into the container
+type SocketEntry struct {
+	Name   string `mapstructure:"name"`   // Identifies the socket in warnings and the audit log (default: from source)
+	Source string `mapstructure:"source"` // Host socket path; $VARS and ~ are expanded
+	Target string `mapstructure:"target"` // Container path (default: /run/enclaude/sockets/<name>.sock); "~/" is the container HOME
+	Mode   string `mapstructure:"mode"`   // bind, proxy
+	Env    string `mapstructure:"env"`    // Variable set to the target path in the container (optional)
+}
+
+// GitConfig configures mounts for repositories that reach outside the workspace
+type GitConfig struct {
+	MountGitDir      bool `mapstructure:"mount_gitdir"`      // Mount worktree/submodule metadata that lives outside the workspace
+	SubmoduleSources bool `mapstructure:"submodule_sources"` // Mount local repositories referenced by .gitmodules (read-only)
+}
+
+// UpdatesConfig configures the startup check for new releases
+type UpdatesConfig struct {
+	Check bool `mapstructure:"check"` // Check for a newer release at most once a day
 }
 
 // LoadConfig loads configuration from viper with defaults
 func LoadConfig() *Config {
-	setDefaults()
+	setDefaults(viper.GetViper())
 
 	cfg := &Config{}
 	if err := viper.Unmarshal(cfg); err != nil {
@@ -87,74 +318,215 @@ func LoadConfig() *Config {
 		return defaultConfig()
 	}
 
-	// Migrate deprecated mounts.claude_dir to claude.session_dir
-	if cfg.Claude.SessionDir == "" && cfg.Mounts.ClaudeDir != "" {
-		cfg.Claude.SessionDir = cfg.Mounts.ClaudeDir
+	// Viper lowercases map keys, but environment variable names are case
+	// sensitive; take them as the config files wrote them
+	custom := make(map[string]string, len(cfg.Environment.Custom))
+	for k, v := range cfg.Environment.Custom {
+		custom[customEnvName(k)] = v
 	}
+	cfg.Environment.Custom = custom
 
 	return cfg
 }
 
-func setDefaults() {
+// Default returns the built-in default of a setting, with ok false for keys
+// that have none
+func Default(key string) (value interface{}, ok bool) {
+	v := viper.New()
+	setDefaults(v)
+	return v.Get(key), v.IsSet(key)
+}
+
+// FileSetting returns a setting as the config file at path sets it, after
+// upgrading older formats as loading does, with ok false if the file does
+// not set it
+func FileSetting(path, key string) (value interface{}, ok bool, err error) {
+	settings, err := readRawConfig(path)
+	if err != nil {
+		return nil, false, err
+	}
+	if _, err := Migrate(settings); err != nil {
+		return nil, false, err
+	}
+	value, ok = getSetting(settings, strings.ToLower(key))
+	return value, ok, nil
+}
+
+func setDefaults(v *viper.Viper) {
+	v.SetDefault("config_version", CurrentConfigVersion)
+
 	// Image defaults
-	viper.SetDefault("image.name", "enclaude:latest")
-	viper.SetDefault("image.dockerfile", "")
-	viper.SetDefault("image.build_context", "")
+	v.SetDefault("image.name", "enclaude:latest")
+	v.SetDefault("image.dockerfile", "")
+	v.SetDefault("image.build_context", "")
+	v.SetDefault("image.pull_policy", PullMissing)
+	v.SetDefault("image.auto_rebuild", false)
 
 	// Mount defaults
-	viper.SetDefault("mounts.defaults", []MountEntry{})
+	v.SetDefault("mounts.defaults", []MountEntry{})
+	v.SetDefault("mounts.volumes", []VolumeEntry{})
+	v.SetDefault("mounts.workspace_target", WorkspaceTargetDefault)
+	v.SetDefault("mounts.workspaces", []WorkspaceEntry{})
 
 	// Claude authentication defaults
-	viper.SetDefault("claude.auth", "auto")
-	viper.SetDefault("claude.session_dir", "readonly")
-	viper.SetDefault("claude.default_args", []string{})
+	v.SetDefault("claude.auth", "auto")
+	v.SetDefault("claude.provider", "anthropic")
+	v.SetDefault("claude.session_dir", "readonly")
+	v.SetDefault("claude.session_lock", SessionLockWarn)
+	v.SetDefault("claude.prefer", PreferAsk)
+	v.SetDefault("claude.default_args", []string{})
+	v.SetDefault("claude.arg_presets", map[string][]string{})
+	v.SetDefault("claude.preflight", false)
+	v.SetDefault("claude.disable_telemetry", false)
+	v.SetDefault("claude.cache_volume", false)
+	v.SetDefault("claude.secretless", false)
+	v.SetDefault("claude.secretless_upstream", "")
+	v.SetDefault("claude.agents_dir", "")
+	v.SetDefault("claude.commands_dir", "")
+	v.SetDefault("claude.output_styles_dir", "")
+	v.SetDefault("claude.binary", "")
+	v.SetDefault("claude.bedrock.region", "")
+	v.SetDefault("claude.bedrock.profile", "")
+	v.SetDefault("claude.vertex.project_id", "")
+	v.SetDefault("claude.vertex.region", "")
 
 	// External credential defaults
-	viper.SetDefault("credentials.github", "auto")
-	viper.SetDefault("credentials.gcloud", "auto")
-	viper.SetDefault("credentials.ssh.enabled", false)
-	viper.SetDefault("credentials.ssh.keys", []string{})
-	viper.SetDefault("credentials.ssh.known_hosts", true)
-	viper.SetDefault("credentials.ssh.agent_forwarding", true)
+	v.SetDefault("credentials.github", "auto")
+	v.SetDefault("credentials.gcloud", "auto")
+	v.SetDefault("credentials.bitbucket", "auto")
+	v.SetDefault("credentials.azdo", "auto")
+	v.SetDefault("credentials.ssh.enabled", false)
+	v.SetDefault("credentials.ssh.keys", []string{})
+	v.SetDefault("credentials.ssh.known_hosts", true)
+	v.SetDefault("credentials.ssh.agent_forwarding", true)
+	v.SetDefault("credentials.gpg.enabled", false)
+	v.SetDefault("credentials.gpg.agent_forwarding", true)
+	v.SetDefault("credentials.ttl", "")
+	v.SetDefault("credentials.github_app.app_id", "")
+	v.SetDefault("credentials.github_app.private_key", "")
+	v.SetDefault("credentials.github_app.api_url", "")
+	v.SetDefault("credentials.github_app.repositories", []string{})
+	v.SetDefault("credentials.extra_files", []ExtraFile{})
 
 	// Environment defaults
-	viper.SetDefault("environment.passthrough", []string{"TERM", "COLORTERM", "EDITOR"})
-	viper.SetDefault("environment.custom", map[string]string{})
+	v.SetDefault("environment.passthrough", []string{"TERM", "COLORTERM", "EDITOR"})
+	v.SetDefault("environment.custom", map[string]string{})
 
 	// Container defaults
-	viper.SetDefault("container.user", "")
-	viper.SetDefault("container.memory_limit", "4g")
-	viper.SetDefault("container.network", "bridge")
+	v.SetDefault("container.engine", EngineDocker)
+	v.SetDefault("container.namespace", "default")
+	v.SetDefault("container.user", "")
+	v.SetDefault("container.preset", "")
+	v.SetDefault("container.memory_limit", "")
+	v.SetDefault("container.cpus", "")
+	v.SetDefault("container.pids_limit", 0)
+	v.SetDefault("container.tmpfs_size", "")
+	v.SetDefault("container.network", "bridge")
+	v.SetDefault("container.ports", []string{})
+	v.SetDefault("container.stop_grace", "10s")
+	v.SetDefault("container.ready_check", "")
+	v.SetDefault("container.ready_wait", "30s")
+	v.SetDefault("container.timezone", LocaleAuto)
+	v.SetDefault("container.locale", LocaleAuto)
+	v.SetDefault("container.interaction", InteractionAttach)
+	v.SetDefault("container.cgroup.parent", "")
+	v.SetDefault("container.cgroup.cpu_weight", 0)
+	v.SetDefault("container.cgroup.io_weight", 0)
+	v.SetDefault("container.memory_reservation", "")
+	v.SetDefault("container.memory_swappiness", -1)
+	v.SetDefault("container.idle_after", "")
+	v.SetDefault("container.idle_cpus", "0.1")
+	v.SetDefault("container.extra_docker_args", []string{})
 
 	// Security defaults
-	viper.SetDefault("security.drop_capabilities", true)
-	viper.SetDefault("security.no_new_privileges", true)
-	viper.SetDefault("security.read_only_root", true)
-	viper.SetDefault("security.ca_certs", []string{})
+	v.SetDefault("security.drop_capabilities", true)
+	v.SetDefault("security.no_new_privileges", true)
+	v.SetDefault("security.read_only_root", true)
+	v.SetDefault("security.ca_certs", []string{})
+	v.SetDefault("security.workspace_trust", TrustOff)
+	v.SetDefault("security.ephemeral", false)
+
+	// Shell defaults
+	v.SetDefault("shell.bashrc", "")
+	v.SetDefault("shell.zshrc", "")
+	v.SetDefault("shell.persist_history", false)
+
+	// Workspace defaults
+	v.SetDefault("workspace.mode", "bind")
+	v.SetDefault("workspace.include_ignored", false)
+	v.SetDefault("workspace.artifacts", "")
+	v.SetDefault("workspace.growth_warning", "2g")
+
+	// Terminal defaults
+	v.SetDefault("terminal.hyperlinks", "auto")
+	v.SetDefault("terminal.link_format", "")
+
+	// Output post-processing defaults
+	v.SetDefault("output.filters", []string{})
+
+	// Editor bridge defaults
+	v.SetDefault("editor.bridge", false)
+	v.SetDefault("editor.command", "")
+
+	// Host command bridge defaults
+	v.SetDefault("host_commands.enabled", false)
+	v.SetDefault("host_commands.allow", []string{})
+	v.SetDefault("network.create", "")
+	v.SetDefault("network.alias", "claude.local")
+	v.SetDefault("network.reverse_forward", []int{})
+	v.SetDefault("shell_policy.enabled", false)
+	v.SetDefault("shell_policy.allow", []string{})
+	v.SetDefault("shell_policy.deny", []string{})
+	v.SetDefault("shell_policy.block_dangerous", true)
+
+	// Access request defaults
+	v.SetDefault("access_requests.enabled", false)
+
+	// Update check defaults
+	v.SetDefault("updates.check", true)
+
+	// Git defaults
+	v.SetDefault("git.mount_gitdir", false)
+	v.SetDefault("git.submodule_sources", false)
+
+	// Socket forwarding defaults
+	v.SetDefault("sockets", []SocketEntry{})
 }
 
 func defaultConfig() *Config {
 	return &Config{
+		ConfigVersion: CurrentConfigVersion,
 		Image: ImageConfig{
-			Name: "enclaude:latest",
+			Name:       "enclaude:latest",
+			PullPolicy: PullMissing,
 		},
 		Mounts: MountsConfig{
-			Defaults: []MountEntry{},
+			Defaults:        []MountEntry{},
+			Volumes:         []VolumeEntry{},
+			Workspaces:      []WorkspaceEntry{},
+			WorkspaceTarget: WorkspaceTargetDefault,
 		},
 		Claude: ClaudeConfig{
 			Auth:        "auto",
 			SessionDir:  "readonly",
+			SessionLock: SessionLockWarn,
 			DefaultArgs: []string{},
 		},
 		Credentials: CredentialsConfig{
-			GitHub: "auto",
-			GCloud: "auto",
+			GitHub:    "auto",
+			GCloud:    "auto",
+			Bitbucket: "auto",
+			AzDO:      "auto",
 			SSH: SSHConfig{
 				Enabled:         false,
 				Keys:            []string{},
 				KnownHosts:      true,
 				AgentForwarding: true,
 			},
+			GPG: GPGConfig{
+				Enabled:         false,
+				AgentForwarding: true,
+			},
 		},
 		Environment: EnvironmentConfig{
 			Passthrough: []string{"TERM", "COLORTERM", "EDITOR"},
@@ -164,12 +536,23 @@ func defaultConfig() *Config {
 			User:        "auto",
 			MemoryLimit: "4g",
 			Network:     "bridge",
+			Ports:       []string{},
+			Timezone:    LocaleAuto,
+			Locale:      LocaleAuto,
+			Interaction: InteractionAttach,
+
+			MemorySwappiness: -1,
+			ExtraDockerArgs:  []string{},
 		},
 		Security: SecurityConfig{
 			DropCapabilities: true,
 			NoNewPrivileges:  true,
 			ReadOnlyRoot:     true,
 			CACerts:          []string{},
+			WorkspaceTrust:   TrustOff,
+		},
+		Updates: UpdatesConfig{
+			Check: true,
 		},
 	}
 }
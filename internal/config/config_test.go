@@ -1,6 +1,8 @@
 package config
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -51,3 +53,80 @@ func TestSecurityConfigCACerts(t *testing.T) {
 		t.Errorf("expected '/path/to/cert2.pem', got '%s'", cfg.CACerts[1])
 	}
 }
+
+func TestValidNetworkName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"", true},
+		{NetworkBridge, true},
+		{NetworkNone, true},
+		{"devstack_default", true},
+		{"my-net.v2", true},
+		{"-leading-dash", false},
+		{"has space", false},
+		{"container:abc123", false},
+	}
+	for _, tt := range tests {
+		if got := ValidNetworkName(tt.name); got != tt.want {
+			t.Errorf("ValidNetworkName(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestResolveWorkspaceTarget(t *testing.T) {
+	tests := []struct {
+		target  string
+		hostDir string
+		want    string
+		wantErr bool
+	}{
+		{"", "/home/me/app", "/workspace", false},
+		{"/workspace", "/home/me/app", "/workspace", false},
+		{"/src/app/", "/home/me/app", "/src/app", false},
+		{WorkspaceTargetHost, "/home/me/app", "/home/me/app", false},
+		{WorkspaceTargetHost, "/Users/me/src/app", "/Users/me/src/app", false},
+		{"relative/app", "/home/me/app", "", true},
+		{"/", "/home/me/app", "", true},
+		{"/tmp", "/home/me/app", "", true},
+		{"/usr/src/app", "/home/me/app", "", true},
+		{"/run/enclaude/editor", "/home/me/app", "", true},
+		{"/artifacts", "/home/me/app", "", true},
+		{WorkspaceTargetHost, "/etc/app", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ResolveWorkspaceTarget(tt.target, tt.hostDir)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ResolveWorkspaceTarget(%q, %q) = %q, %v, want %q (error: %v)", tt.target, tt.hostDir, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestDefault(t *testing.T) {
+	if v, ok := Default("image.name"); !ok || v != "enclaude:latest" {
+		t.Errorf("Default(image.name) = %v, %v, want enclaude:latest, true", v, ok)
+	}
+	if _, ok := Default("image.nonexistent"); ok {
+		t.Error("Default() of an unknown key should report false")
+	}
+}
+
+func TestFileSetting(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := "image:\n  name: enclaude:dev\nmounts:\n  claude_dir: readwrite\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if v, ok, err := FileSetting(path, "image.name"); err != nil || !ok || v != "enclaude:dev" {
+		t.Errorf("FileSetting(image.name) = %v, %v, %v, want enclaude:dev", v, ok, err)
+	}
+	if _, ok, _ := FileSetting(path, "image.pull_policy"); ok {
+		t.Error("FileSetting() of a setting the file does not set should report false")
+	}
+	// Older formats are upgraded as when loading
+	if v, ok, _ := FileSetting(path, "claude.session_dir"); !ok || v != "readwrite" {
+		t.Errorf("FileSetting(claude.session_dir) = %v, %v, want the migrated mounts.claude_dir", v, ok)
+	}
+}
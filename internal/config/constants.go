@@ -7,11 +7,23 @@ const (
 	AuthAPIKey  = "api-key"
 )
 
+// PreferAsk prompts for claude.prefer when auth=auto finds both an API key
+// and a session
+const PreferAsk = "ask"
+
+// Model providers
+const (
+	ProviderAnthropic = "anthropic"
+	ProviderBedrock   = "bedrock"
+	ProviderVertex    = "vertex"
+)
+
 // Credential settings
 const (
 	CredentialAuto     = "auto"
 	CredentialEnabled  = "enabled"
 	CredentialDisabled = "disabled"
+	CredentialApp      = "app" // GitHub only: repository-scoped GitHub App token
 )
 
 // Session directory settings
@@ -19,6 +31,16 @@ const (
 	SessionNone      = "none"
 	SessionReadOnly  = "readonly"
 	SessionReadWrite = "readwrite"
+	SessionVolume    = "volume" // Login kept in a Docker volume by 'enclaude login'
+)
+
+// What a session does when the host ~/.claude it would mount read-write is
+// already in use by another session
+const (
+	SessionLockWarn     = "warn"
+	SessionLockWait     = "wait"
+	SessionLockReadOnly = "readonly"
+	SessionLockOff      = "off"
 )
 
 // Network modes
@@ -28,7 +50,85 @@ const (
 	NetworkNone   = "none"
 )
 
+// Workspace modes
+const (
+	WorkspaceBind = "bind"
+	WorkspaceCopy = "copy"
+)
+
+// Workspace mount targets
+const (
+	WorkspaceTargetDefault = "/workspace"
+	WorkspaceTargetHost    = "host" // Mount at the workspace's absolute host path
+)
+
+// RefsTarget is the container directory read-only references are mounted
+// under, each at RefsTarget/<name>
+const RefsTarget = "/refs"
+
+// Resource presets
+const (
+	PresetSmall     = "small"
+	PresetMedium    = "medium"
+	PresetLarge     = "large"
+	PresetUnlimited = "unlimited"
+)
+
+// security.workspace_trust settings
+const (
+	TrustOff        = "off"
+	TrustPrompt     = "prompt"     // Ask the first time a workspace is used
+	TrustRestricted = "restricted" // Run untrusted workspaces without host credentials
+)
+
+// Terminal hyperlink settings
+const (
+	HyperlinksAuto   = "auto"
+	HyperlinksAlways = "always"
+	HyperlinksNever  = "never"
+)
+
+// container.interaction settings
+const (
+	InteractionAttach = "attach" // Attach to the container's main process
+	InteractionExec   = "exec"   // Start claude with docker exec in a container kept running
+)
+
+// Container engines
+const (
+	EngineDocker     = "docker"
+	EngineContainerd = "containerd" // Through the nerdctl CLI
+)
+
+// container.timezone and container.locale settings; any other value is a
+// zone such as "Europe/Berlin" or a locale such as "en_US.UTF-8"
+const (
+	LocaleAuto = "auto" // Follow the host
+	LocaleNone = "none" // Keep the image default (UTC, POSIX locale)
+)
+
+// Image pull policies
+const (
+	PullAlways  = "always"  // Pull before every session
+	PullMissing = "missing" // Pull only when the image is not available locally
+	PullNever   = "never"   // Use local images only
+)
+
+// Socket forwarding modes
+const (
+	SocketBind  = "bind"  // Bind-mount the host socket
+	SocketProxy = "proxy" // Relay connections through enclaude, auditing each
+)
+
 // User settings
 const (
-	UserAuto = "auto"
+	UserAuto   = "auto"
+	UserRandom = "random" // A fresh subordinate uid for each session
 )
+
+// ProjectConfigFile is the per-project config file merged over the user config
+// when enclaude runs in a directory containing it. .enclaude.toml and
+// .enclaude.json are read too; see FindProjectConfig.
+const ProjectConfigFile = projectConfigBase + ".yaml"
+
+const projectConfigBase = ".enclaude"
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"go.yaml.in/yaml/v3"
+)
+
+// customEnvNames maps the lowercased names of environment.custom variables
+// to the case the config files wrote them in. Viper lowercases map keys,
+// but environment variable names are case sensitive.
+var customEnvNames = make(map[string]string)
+
+// RecordEnvNames notes the case of the environment.custom names in the
+// config file at path, so LoadConfig keeps it. Files recorded later win, as
+// they do when merged.
+func RecordEnvNames(path string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	RecordEnvNamesData(data, strings.TrimPrefix(filepath.Ext(path), "."))
+}
+
+// RecordEnvName notes the case of an environment.custom name set other than
+// through a config file, such as with config set
+func RecordEnvName(name string) {
+	customEnvNames[strings.ToLower(name)] = name
+}
+
+// RecordEnvNamesData is RecordEnvNames for a config document given without
+// a file, in format ext, or in YAML, which includes JSON, or TOML if ext is
+// ""
+func RecordEnvNamesData(data []byte, ext string) {
+	var settings map[string]interface{}
+	var err error
+	switch ext {
+	case "toml":
+		err = toml.Unmarshal(data, &settings)
+	case "":
+		if err = yaml.Unmarshal(data, &settings); err != nil {
+			settings = nil
+			err = toml.Unmarshal(data, &settings)
+		}
+	default:
+		err = yaml.Unmarshal(data, &settings)
+	}
+	if err != nil {
+		return
+	}
+	for name := range keyFold(keyFold(settings, "environment"), "custom") {
+		customEnvNames[strings.ToLower(name)] = name
+	}
+}
+
+// keyFold returns the map under key in settings, matching key without
+// regard to case as viper does
+func keyFold(settings map[string]interface{}, key string) map[string]interface{} {
+	for k, v := range settings {
+		if sub, ok := v.(map[string]interface{}); ok && strings.EqualFold(k, key) {
+			return sub
+		}
+	}
+	return nil
+}
+
+// customEnvName returns the environment.custom name viper lowercased to
+// name as the config files wrote it, or upper-cased, the convention, if
+// none recorded it
+func customEnvName(name string) string {
+	if original, ok := customEnvNames[name]; ok {
+		return original
+	}
+	return strings.ToUpper(name)
+}
+
+// WriteConfigFile writes settings to the config file at path in the format
+// of its extension, as viper does, but with the environment.custom names in
+// their recorded case rather than lowercased
+func WriteConfigFile(path string, settings map[string]interface{}) error {
+	if env := keyFold(settings, "environment"); env != nil {
+		if custom := keyFold(env, "custom"); len(custom) > 0 {
+			named := make(map[string]interface{}, len(custom))
+			for k, v := range custom {
+				named[customEnvName(strings.ToLower(k))] = v
+			}
+			settings = copyWith(settings, "environment", copyWith(env, "custom", named))
+		}
+	}
+
+	var data []byte
+	var err error
+	switch ext := strings.TrimPrefix(filepath.Ext(path), "."); ext {
+	case "yaml", "yml":
+		data, err = yaml.Marshal(settings)
+	case "toml":
+		data, err = toml.Marshal(settings)
+	case "json":
+		data, err = json.MarshalIndent(settings, "", "  ")
+	default:
+		return fmt.Errorf("unsupported config file format %q", ext)
+	}
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// copyWith returns a shallow copy of settings with key set to value
+func copyWith(settings map[string]interface{}, key string, value interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(settings))
+	for k, v := range settings {
+		if !strings.EqualFold(k, key) {
+			out[k] = v
+		}
+	}
+	out[key] = value
+	return out
+}
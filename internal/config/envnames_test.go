@@ -0,0 +1,74 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestRecordEnvNames(t *testing.T) {
+	saved := customEnvNames
+	customEnvNames = make(map[string]string)
+	t.Cleanup(func() { customEnvNames = saved })
+
+	dir := t.TempDir()
+	user := filepath.Join(dir, "config.yaml")
+	os.WriteFile(user, []byte("environment:\n  custom:\n    http_proxy: http://proxy:3128\n    GOFLAGS: -mod=mod\n    Mixed_Case: a\n"), 0600)
+	project := filepath.Join(dir, ".enclaude.toml")
+	os.WriteFile(project, []byte("[environment.custom]\nmixed_case = \"b\"\n"), 0600)
+
+	RecordEnvNames(user)
+	RecordEnvNames(project)
+	RecordEnvNamesData([]byte(`{"Environment": {"Custom": {"node_env": "test"}}}`), "")
+
+	tests := map[string]string{
+		"http_proxy": "http_proxy",
+		"goflags":    "GOFLAGS",
+		"mixed_case": "mixed_case", // The project file is recorded last
+		"node_env":   "node_env",
+		"unrecorded": "UNRECORDED",
+	}
+	for name, want := range tests {
+		if got := customEnvName(name); got != want {
+			t.Errorf("customEnvName(%q) = %q, want %q", name, got, want)
+		}
+	}
+
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+	viper.Set("environment.custom", map[string]interface{}{"http_proxy": "http://proxy:3128"})
+	if got := LoadConfig().Environment.Custom; got["http_proxy"] != "http://proxy:3128" {
+		t.Errorf("environment.custom = %v, want http_proxy kept lower case", got)
+	}
+}
+
+func TestWriteConfigFile(t *testing.T) {
+	saved := customEnvNames
+	customEnvNames = map[string]string{"goflags": "GOFLAGS", "http_proxy": "http_proxy"}
+	t.Cleanup(func() { customEnvNames = saved })
+
+	settings := map[string]interface{}{
+		"image":       map[string]interface{}{"name": "custom"},
+		"environment": map[string]interface{}{"custom": map[string]interface{}{"goflags": "-mod=mod", "http_proxy": "http://proxy:3128"}},
+	}
+	for _, ext := range []string{"yaml", "toml", "json"} {
+		path := filepath.Join(t.TempDir(), "config."+ext)
+		if err := WriteConfigFile(path, settings); err != nil {
+			t.Fatalf("%s: WriteConfigFile() error = %v", ext, err)
+		}
+		customEnvNames = make(map[string]string)
+		RecordEnvNames(path)
+		if customEnvNames["goflags"] != "GOFLAGS" || customEnvNames["http_proxy"] != "http_proxy" {
+			t.Errorf("%s: names written as %v", ext, customEnvNames)
+		}
+		if v, ok, _ := FileSetting(path, "image.name"); !ok || v != "custom" {
+			t.Errorf("%s: image.name = %v, want custom", ext, v)
+		}
+		customEnvNames = map[string]string{"goflags": "GOFLAGS", "http_proxy": "http_proxy"}
+	}
+	if err := WriteConfigFile(filepath.Join(t.TempDir(), "config.ini"), settings); err == nil {
+		t.Error("expected an error for an unsupported format")
+	}
+}
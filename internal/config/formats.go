@@ -0,0 +1,63 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// ConfigFormats are the config file formats, by extension, in the order
+// they are looked for when a directory has more than one
+var ConfigFormats = []string{"yaml", "yml", "toml", "json"}
+
+// FindConfigFile returns the first of base.yaml, base.yml, base.toml, and
+// base.json that exists, or "" if none does
+func FindConfigFile(base string) string {
+	for _, ext := range ConfigFormats {
+		if info, err := os.Stat(base + "." + ext); err == nil && !info.IsDir() {
+			return base + "." + ext
+		}
+	}
+	return ""
+}
+
+// FindProjectConfig returns the project config file in the current
+// directory in any format, or "" if there is none
+func FindProjectConfig() string {
+	return FindProjectConfigIn(".")
+}
+
+// FindProjectConfigIn returns the project config file in dir in any format,
+// or "" if there is none
+func FindProjectConfigIn(dir string) string {
+	return FindConfigFile(filepath.Join(dir, projectConfigBase))
+}
+
+// Setting returns a setting from settings as ParseConfig returns them, with
+// ok false if they do not set it
+func Setting(settings map[string]interface{}, key string) (value interface{}, ok bool) {
+	return getSetting(settings, strings.ToLower(key))
+}
+
+// ParseConfig reads the settings of a config document given without a file
+// name, such as on stdin. YAML, which includes JSON, is tried first, then
+// TOML.
+func ParseConfig(data []byte) (map[string]interface{}, error) {
+	var yamlErr error
+	for _, format := range []string{"yaml", "toml"} {
+		v := viper.New()
+		v.SetConfigType(format)
+		err := v.ReadConfig(bytes.NewReader(data))
+		if err == nil {
+			return v.AllSettings(), nil
+		}
+		if yamlErr == nil {
+			yamlErr = err
+		}
+	}
+	return nil, fmt.Errorf("config is not valid YAML, JSON, or TOML: %w", yamlErr)
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"yaml", "image:\n  name: custom\ncontainer:\n  pids_limit: 512\n"},
+		{"json", `{"image": {"name": "custom"}, "container": {"pids_limit": 512}}`},
+		{"toml", "[image]\nname = \"custom\"\n\n[container]\npids_limit = 512\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings, err := ParseConfig([]byte(tt.data))
+			if err != nil {
+				t.Fatalf("ParseConfig() error = %v", err)
+			}
+			if v, _ := Setting(settings, "image.name"); v != "custom" {
+				t.Errorf("image.name = %v, want custom", v)
+			}
+			if v, ok := Setting(settings, "container.pids_limit"); !ok || v == nil {
+				t.Errorf("container.pids_limit not set")
+			}
+		})
+	}
+}
+
+func TestParseConfig_Invalid(t *testing.T) {
+	if _, err := ParseConfig([]byte("image = [")); err == nil {
+		t.Error("ParseConfig() of invalid config succeeded")
+	}
+}
+
+func TestFindConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "config")
+
+	if got := FindConfigFile(base); got != "" {
+		t.Errorf("FindConfigFile() = %q with no config, want none", got)
+	}
+
+	os.WriteFile(base+".toml", []byte("[image]\n"), 0644)
+	if got := FindConfigFile(base); got != base+".toml" {
+		t.Errorf("FindConfigFile() = %q, want %q", got, base+".toml")
+	}
+
+	// YAML is preferred when there is more than one
+	os.WriteFile(base+".yaml", []byte("image:\n"), 0644)
+	if got := FindConfigFile(base); got != base+".yaml" {
+		t.Errorf("FindConfigFile() = %q, want %q", got, base+".yaml")
+	}
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+// MergeFile sets values, keyed by dotted setting name, in the config file at
+// path and leaves its other settings as they are. Lists under the keys in
+// appendTo are added to the file's list instead, skipping items already in
+// it. The original is kept as a backup at path + ".bak"; comments are not
+// preserved. It returns the settings whose values changed, sorted.
+func MergeFile(path string, values map[string]interface{}, appendTo ...string) ([]string, error) {
+	original, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	settings, err := readRawConfig(path)
+	if err != nil {
+		return nil, err
+	}
+
+	appending := make(map[string]bool, len(appendTo))
+	for _, key := range appendTo {
+		appending[key] = true
+	}
+	var changed []string
+	for key, value := range values {
+		current, exists := getSetting(settings, key)
+		if appending[key] {
+			value = appendNew(current, value)
+		}
+		if exists && fmt.Sprint(current) == fmt.Sprint(value) {
+			continue
+		}
+		setSetting(settings, key, value)
+		changed = append(changed, key)
+	}
+	sort.Strings(changed)
+	if len(changed) == 0 {
+		return nil, nil
+	}
+
+	if err := writeBackup(path, original); err != nil {
+		return nil, err
+	}
+	RecordEnvNames(path)
+	if err := WriteConfigFile(path, settings); err != nil {
+		return nil, fmt.Errorf("failed to write config: %w", err)
+	}
+	return changed, nil
+}
+
+// appendNew returns the items of list followed by those of add that it does
+// not already hold
+func appendNew(list, add interface{}) []interface{} {
+	var items []interface{}
+	seen := make(map[string]bool)
+	for _, l := range []interface{}{list, add} {
+		switch v := l.(type) {
+		case []interface{}:
+			for _, item := range v {
+				if !seen[fmt.Sprint(item)] {
+					seen[fmt.Sprint(item)] = true
+					items = append(items, item)
+				}
+			}
+		case []string:
+			for _, item := range v {
+				if !seen[item] {
+					seen[item] = true
+					items = append(items, item)
+				}
+			}
+		}
+	}
+	return items
+}
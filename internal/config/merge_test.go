@@ -0,0 +1,71 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMergeFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	original := `config_version: 2
+claude:
+  auth: session
+container:
+  network: bridge
+  memory_limit: 8g
+security:
+  ca_certs:
+    - /certs/corp.pem
+`
+	if err := os.WriteFile(path, []byte(original), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	changed, err := MergeFile(path, map[string]interface{}{
+		"container.network": NetworkNone,
+		"claude.auth":       AuthSession,
+		"security.ca_certs": []string{"/certs/corp.pem", "/certs/proxy.pem"},
+	}, "security.ca_certs")
+	if err != nil {
+		t.Fatalf("MergeFile() error = %v", err)
+	}
+	if want := []string{"container.network", "security.ca_certs"}; !reflect.DeepEqual(changed, want) {
+		t.Errorf("changed = %v, want %v", changed, want)
+	}
+
+	settings, err := readRawConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"claude.auth":            AuthSession,
+		"container.network":      NetworkNone,
+		"container.memory_limit": "8g",
+		"security.ca_certs":      "[/certs/corp.pem /certs/proxy.pem]",
+	}
+	for key, value := range want {
+		if got, _ := getSetting(settings, key); fmt.Sprint(got) != value {
+			t.Errorf("%s = %v, want %s", key, got, value)
+		}
+	}
+
+	backup, err := os.ReadFile(path + ".bak")
+	if err != nil || string(backup) != original {
+		t.Errorf("backup = %q, %v; want the original file", backup, err)
+	}
+	if info, err := os.Stat(path + ".bak"); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("backup mode: %v, %v; want the original's 0600", info, err)
+	}
+
+	// Nothing to change leaves the file alone
+	os.Remove(path + ".bak")
+	if changed, err := MergeFile(path, map[string]interface{}{"container.network": NetworkNone}); err != nil || len(changed) != 0 {
+		t.Errorf("unchanged MergeFile() = %v, %v; want no changes", changed, err)
+	}
+	if _, err := os.Stat(path + ".bak"); !os.IsNotExist(err) {
+		t.Error("backup written without changes")
+	}
+}
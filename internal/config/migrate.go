@@ -0,0 +1,225 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// CurrentConfigVersion is the schema version written by this release.
+// Config files without config_version are treated as version 1.
+const CurrentConfigVersion = 2
+
+// migration upgrades raw config settings from one schema version to the next
+type migration struct {
+	from  int
+	apply func(settings map[string]interface{}) []string
+}
+
+// migrations are applied in order; each upgrades from version `from` to from+1
+var migrations = []migration{
+	{from: 1, apply: migrateV1},
+}
+
+// migrateV1 moves settings that were superseded by the claude section
+func migrateV1(settings map[string]interface{}) []string {
+	var changes []string
+
+	// mounts.claude_dir -> claude.session_dir
+	if v, ok := getSetting(settings, "mounts.claude_dir"); ok {
+		if _, exists := getSetting(settings, "claude.session_dir"); !exists {
+			setSetting(settings, "claude.session_dir", v)
+			changes = append(changes, fmt.Sprintf("moved mounts.claude_dir (%v) to claude.session_dir", v))
+		} else {
+			changes = append(changes, "removed mounts.claude_dir (claude.session_dir is already set)")
+		}
+		deleteSetting(settings, "mounts.claude_dir")
+	}
+
+	// credentials.anthropic -> claude.auth
+	if v, ok := getSetting(settings, "credentials.anthropic"); ok {
+		if _, exists := getSetting(settings, "claude.auth"); !exists {
+			auth := AuthAuto
+			switch fmt.Sprint(v) {
+			case CredentialEnabled:
+				auth = AuthAPIKey
+			case CredentialDisabled:
+				auth = AuthSession
+			}
+			setSetting(settings, "claude.auth", auth)
+			changes = append(changes, fmt.Sprintf("replaced credentials.anthropic (%v) with claude.auth (%s)", v, auth))
+		} else {
+			changes = append(changes, "removed credentials.anthropic (claude.auth is already set)")
+		}
+		deleteSetting(settings, "credentials.anthropic")
+	}
+
+	return changes
+}
+
+// Migrate upgrades raw config settings in place to CurrentConfigVersion and
+// returns a description of each change made
+func Migrate(settings map[string]interface{}) ([]string, error) {
+	version := 1
+	if v, ok := settings["config_version"]; ok {
+		n, err := toInt(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid config_version %v", v)
+		}
+		version = n
+	}
+	if version > CurrentConfigVersion {
+		return nil, fmt.Errorf("config_version %d is newer than supported version %d; upgrade enclaude", version, CurrentConfigVersion)
+	}
+
+	var changes []string
+	for _, m := range migrations {
+		if m.from < version {
+			continue
+		}
+		changes = append(changes, m.apply(settings)...)
+		version = m.from + 1
+	}
+
+	if fmt.Sprint(settings["config_version"]) != fmt.Sprint(CurrentConfigVersion) {
+		settings["config_version"] = CurrentConfigVersion
+		changes = append(changes, fmt.Sprintf("set config_version to %d", CurrentConfigVersion))
+	}
+
+	return changes, nil
+}
+
+// ApplyMigrations migrates the config file at path in memory and merges the
+// result into the global viper instance, without modifying the file.
+// It returns the changes that `enclaude config migrate` would write.
+func ApplyMigrations(path string) ([]string, error) {
+	settings, err := readRawConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := Migrate(settings)
+	if err != nil {
+		return nil, err
+	}
+	if len(changes) > 0 {
+		if err := viper.MergeConfigMap(settings); err != nil {
+			return nil, err
+		}
+	}
+	return changes, nil
+}
+
+// MigrateFile upgrades the config file at path and writes it back, keeping a
+// backup of the original at path + ".bak". It returns the changes made.
+func MigrateFile(path string) ([]string, error) {
+	original, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	settings, err := readRawConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := Migrate(settings)
+	if err != nil || len(changes) == 0 {
+		return changes, err
+	}
+
+	if err := writeBackup(path, original); err != nil {
+		return nil, err
+	}
+
+	RecordEnvNames(path)
+	if err := WriteConfigFile(path, settings); err != nil {
+		return nil, fmt.Errorf("failed to write config: %w", err)
+	}
+
+	return changes, nil
+}
+
+// writeBackup writes original, the contents of the config file at path, to
+// path + ".bak" with the file's own mode, since it may hold secrets
+func writeBackup(path string, original []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+	backup := path + ".bak"
+	if err := os.WriteFile(backup, original, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+	// WriteFile keeps the mode of a backup left by an earlier run
+	if err := os.Chmod(backup, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+	return nil
+}
+
+// readRawConfig reads only the settings present in a config file, without defaults
+func readRawConfig(path string) (map[string]interface{}, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	return v.AllSettings(), nil
+}
+
+func getSetting(settings map[string]interface{}, key string) (interface{}, bool) {
+	parts := strings.Split(key, ".")
+	current := settings
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := current[part].(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		current = next
+	}
+	v, ok := current[parts[len(parts)-1]]
+	return v, ok
+}
+
+func setSetting(settings map[string]interface{}, key string, value interface{}) {
+	parts := strings.Split(key, ".")
+	current := settings
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := current[part].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			current[part] = next
+		}
+		current = next
+	}
+	current[parts[len(parts)-1]] = value
+}
+
+func deleteSetting(settings map[string]interface{}, key string) {
+	parts := strings.Split(key, ".")
+	current := settings
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := current[part].(map[string]interface{})
+		if !ok {
+			return
+		}
+		current = next
+	}
+	delete(current, parts[len(parts)-1])
+}
+
+func toInt(v interface{}) (int, error) {
+	switch n := v.(type) {
+	case int:
+		return n, nil
+	case int64:
+		return int(n), nil
+	case float64:
+		return int(n), nil
+	default:
+		var i int
+		_, err := fmt.Sscan(fmt.Sprint(v), &i)
+		return i, err
+	}
+}
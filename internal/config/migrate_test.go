@@ -0,0 +1,111 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestMigrate_V1(t *testing.T) {
+	settings := map[string]interface{}{
+		"mounts": map[string]interface{}{
+			"claude_dir": "readwrite",
+		},
+		"credentials": map[string]interface{}{
+			"anthropic": "disabled",
+			"github":    "auto",
+		},
+	}
+
+	changes, err := Migrate(settings)
+	if err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	if len(changes) != 3 {
+		t.Errorf("Migrate() changes = %v, want 3 changes", changes)
+	}
+
+	if v, _ := getSetting(settings, "claude.session_dir"); v != "readwrite" {
+		t.Errorf("claude.session_dir = %v, want readwrite", v)
+	}
+	if v, _ := getSetting(settings, "claude.auth"); v != AuthSession {
+		t.Errorf("claude.auth = %v, want %s", v, AuthSession)
+	}
+	if _, ok := getSetting(settings, "mounts.claude_dir"); ok {
+		t.Error("mounts.claude_dir should be removed")
+	}
+	if _, ok := getSetting(settings, "credentials.anthropic"); ok {
+		t.Error("credentials.anthropic should be removed")
+	}
+	if v, _ := getSetting(settings, "credentials.github"); v != "auto" {
+		t.Errorf("credentials.github = %v, unrelated settings should be kept", v)
+	}
+	if settings["config_version"] != CurrentConfigVersion {
+		t.Errorf("config_version = %v, want %d", settings["config_version"], CurrentConfigVersion)
+	}
+}
+
+func TestMigrate_ExistingSettingWins(t *testing.T) {
+	settings := map[string]interface{}{
+		"mounts": map[string]interface{}{"claude_dir": "readwrite"},
+		"claude": map[string]interface{}{"session_dir": "none"},
+	}
+
+	if _, err := Migrate(settings); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	if v, _ := getSetting(settings, "claude.session_dir"); v != "none" {
+		t.Errorf("claude.session_dir = %v, want existing value none", v)
+	}
+}
+
+func TestMigrate_Current(t *testing.T) {
+	settings := map[string]interface{}{"config_version": CurrentConfigVersion}
+	changes, err := Migrate(settings)
+	if err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("Migrate() on current config changes = %v, want none", changes)
+	}
+
+	if _, err := Migrate(map[string]interface{}{"config_version": CurrentConfigVersion + 1}); err == nil {
+		t.Error("Migrate() on newer config_version should return an error")
+	}
+}
+
+func TestMigrateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	original := "mounts:\n  claude_dir: readwrite\n"
+	if err := os.WriteFile(path, []byte(original), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	changes, err := MigrateFile(path)
+	if err != nil {
+		t.Fatalf("MigrateFile() error = %v", err)
+	}
+	if len(changes) == 0 {
+		t.Fatal("MigrateFile() reported no changes")
+	}
+
+	backup, err := os.ReadFile(path + ".bak")
+	if err != nil || string(backup) != original {
+		t.Errorf("MigrateFile() backup = %q, %v; want original content", backup, err)
+	}
+
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		t.Fatalf("failed to read migrated config: %v", err)
+	}
+	if v.GetString("claude.session_dir") != "readwrite" || v.IsSet("mounts.claude_dir") {
+		t.Errorf("migrated config = %v, want claude.session_dir moved", v.AllSettings())
+	}
+	if !strings.Contains(changes[len(changes)-1], "config_version") {
+		t.Errorf("MigrateFile() last change = %q, want config_version update", changes[len(changes)-1])
+	}
+}
@@ -0,0 +1,22 @@
+package config
+
+import "regexp"
+
+// networkNamePattern matches the names Docker accepts for user-defined networks
+var networkNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// IsBuiltinNetwork reports whether name is a network mode Docker always
+// provides. An empty name means Docker's default, the bridge network.
+func IsBuiltinNetwork(name string) bool {
+	switch name {
+	case "", NetworkBridge, NetworkHost, NetworkNone:
+		return true
+	}
+	return false
+}
+
+// ValidNetworkName reports whether name is a built-in network mode or could
+// name a user-defined Docker network
+func ValidNetworkName(name string) bool {
+	return IsBuiltinNetwork(name) || networkNamePattern.MatchString(name)
+}
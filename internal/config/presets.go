@@ -0,0 +1,52 @@
+package config
+
+import "fmt"
+
+// ResourceLimits are the resolved container resource limits. Empty or zero
+// values mean no limit.
+type ResourceLimits struct {
+	Memory    string
+	CPUs      string
+	PidsLimit int64
+	TmpfsSize string
+}
+
+// ResourcePresets are the curated limits behind container.preset
+var ResourcePresets = map[string]ResourceLimits{
+	PresetSmall:     {Memory: "2g", CPUs: "1", PidsLimit: 256, TmpfsSize: "512m"},
+	PresetMedium:    {Memory: "4g", CPUs: "2", PidsLimit: 512, TmpfsSize: "1g"},
+	PresetLarge:     {Memory: "8g", CPUs: "4", PidsLimit: 1024, TmpfsSize: "2g"},
+	PresetUnlimited: {},
+}
+
+// legacyLimits apply when no preset is configured, matching the defaults
+// from before presets existed
+var legacyLimits = ResourceLimits{Memory: "4g"}
+
+// Resources expands the configured preset and applies any individually set
+// limits over it
+func (c ContainerConfig) Resources() (ResourceLimits, error) {
+	limits := legacyLimits
+	if c.Preset != "" {
+		preset, ok := ResourcePresets[c.Preset]
+		if !ok {
+			return ResourceLimits{}, fmt.Errorf("invalid container preset %q (allowed: %s, %s, %s, %s)",
+				c.Preset, PresetSmall, PresetMedium, PresetLarge, PresetUnlimited)
+		}
+		limits = preset
+	}
+
+	if c.MemoryLimit != "" {
+		limits.Memory = c.MemoryLimit
+	}
+	if c.CPUs != "" {
+		limits.CPUs = c.CPUs
+	}
+	if c.PidsLimit != 0 {
+		limits.PidsLimit = c.PidsLimit
+	}
+	if c.TmpfsSize != "" {
+		limits.TmpfsSize = c.TmpfsSize
+	}
+	return limits, nil
+}
@@ -0,0 +1,50 @@
+package config
+
+import "testing"
+
+func TestContainerConfigResources(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     ContainerConfig
+		want    ResourceLimits
+		wantErr bool
+	}{
+		{
+			name: "no preset keeps legacy memory default",
+			cfg:  ContainerConfig{},
+			want: ResourceLimits{Memory: "4g"},
+		},
+		{
+			name: "preset",
+			cfg:  ContainerConfig{Preset: PresetSmall},
+			want: ResourcePresets[PresetSmall],
+		},
+		{
+			name: "individual override",
+			cfg:  ContainerConfig{Preset: PresetLarge, MemoryLimit: "16g", PidsLimit: 4096},
+			want: ResourceLimits{Memory: "16g", CPUs: "4", PidsLimit: 4096, TmpfsSize: "2g"},
+		},
+		{
+			name: "unlimited",
+			cfg:  ContainerConfig{Preset: PresetUnlimited},
+			want: ResourceLimits{},
+		},
+		{
+			name:    "unknown preset",
+			cfg:     ContainerConfig{Preset: "huge"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.cfg.Resources()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Resources() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Resources() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
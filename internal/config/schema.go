@@ -0,0 +1,99 @@
+package config
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// SchemaID identifies the config file schema
+const SchemaID = "https://github.com/jakenelson/enclaude/config.schema.json"
+
+// AllowedValues lists the values of settings that take one of a fixed set
+var AllowedValues = map[string][]string{
+	"claude.auth":              {AuthAuto, AuthSession, AuthAPIKey},
+	"claude.session_dir":       {SessionNone, SessionReadOnly, SessionReadWrite, SessionVolume},
+	"claude.session_lock":      {SessionLockWarn, SessionLockWait, SessionLockReadOnly, SessionLockOff},
+	"claude.prefer":            {PreferAsk, AuthSession, AuthAPIKey},
+	"claude.provider":          {ProviderAnthropic, ProviderBedrock, ProviderVertex},
+	"credentials.github":       {CredentialAuto, CredentialEnabled, CredentialDisabled, CredentialApp},
+	"credentials.gcloud":       {CredentialAuto, CredentialEnabled, CredentialDisabled},
+	"credentials.bitbucket":    {CredentialAuto, CredentialEnabled, CredentialDisabled},
+	"credentials.azdo":         {CredentialAuto, CredentialEnabled, CredentialDisabled},
+	"container.engine":         {EngineDocker, EngineContainerd},
+	"image.pull_policy":        {PullAlways, PullMissing, PullNever},
+	"container.preset":         {PresetSmall, PresetMedium, PresetLarge, PresetUnlimited},
+	"container.interaction":    {InteractionAttach, InteractionExec},
+	"workspace.mode":           {WorkspaceBind, WorkspaceCopy},
+	"terminal.hyperlinks":      {HyperlinksAuto, HyperlinksAlways, HyperlinksNever},
+	"security.workspace_trust": {TrustOff, TrustPrompt, TrustRestricted},
+}
+
+// Schema returns a JSON Schema for the config file, derived from Config:
+// the type of every setting, its default, and the allowed values of those
+// in AllowedValues. Unknown settings are rejected, as they are most likely
+// typos. The same schema applies to the project's .enclaude.yaml.
+func Schema() map[string]interface{} {
+	schema := structSchema(reflect.TypeOf(Config{}), "")
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["$id"] = SchemaID
+	schema["title"] = "enclaude configuration"
+	return schema
+}
+
+// typeSchema returns the schema of a setting of type t at key. The elements
+// of lists and maps get keys no setting has, such as "sockets[]".
+func typeSchema(t reflect.Type, key string) map[string]interface{} {
+	var schema map[string]interface{}
+	switch t.Kind() {
+	case reflect.Struct:
+		return structSchema(t, key)
+	case reflect.Slice:
+		schema = map[string]interface{}{"type": "array", "items": typeSchema(t.Elem(), key+"[]")}
+	case reflect.Map:
+		schema = map[string]interface{}{"type": "object", "additionalProperties": typeSchema(t.Elem(), key+".*")}
+	case reflect.Bool:
+		schema = map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int64:
+		schema = map[string]interface{}{"type": "integer"}
+	default:
+		// Numbers are read into string settings too, e.g. cpus: 1.5
+		schema = map[string]interface{}{"type": []string{"string", "number"}}
+	}
+
+	def, hasDefault := Default(key)
+	if hasDefault && def != nil {
+		schema["default"] = def
+	}
+	if allowed, ok := AllowedValues[key]; ok {
+		// An empty default, such as no preset, is allowed too
+		if s, ok := def.(string); ok && !slices.Contains(allowed, s) {
+			allowed = append([]string{s}, allowed...)
+		}
+		schema["type"] = "string"
+		schema["enum"] = allowed
+	}
+	return schema
+}
+
+// structSchema returns the schema of a section of settings
+func structSchema(t reflect.Type, key string) map[string]interface{} {
+	properties := make(map[string]interface{})
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		fieldKey := name
+		if key != "" {
+			fieldKey = key + "." + name
+		}
+		properties[name] = typeSchema(field.Type, fieldKey)
+	}
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+}
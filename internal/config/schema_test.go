@@ -0,0 +1,46 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestSchemaCoversDefaults(t *testing.T) {
+	schema := Schema()
+	v := viper.New()
+	setDefaults(v)
+
+	for _, key := range v.AllKeys() {
+		node := schema
+		for _, part := range strings.Split(key, ".") {
+			properties, _ := node["properties"].(map[string]interface{})
+			next, ok := properties[part].(map[string]interface{})
+			if !ok {
+				t.Errorf("Schema() has no setting %s", key)
+				break
+			}
+			node = next
+		}
+	}
+}
+
+func TestSchemaEnums(t *testing.T) {
+	properties := Schema()["properties"].(map[string]interface{})
+	container := properties["container"].(map[string]interface{})["properties"].(map[string]interface{})
+
+	preset := container["preset"].(map[string]interface{})
+	enum := preset["enum"].([]string)
+	if len(enum) != 5 || enum[0] != "" || enum[1] != PresetSmall {
+		t.Errorf("container.preset enum = %q, want the empty default and the presets", enum)
+	}
+
+	memory := container["memory_limit"].(map[string]interface{})
+	if _, ok := memory["enum"]; ok {
+		t.Error("container.memory_limit should not be an enum")
+	}
+	if memory["default"] != "" {
+		t.Errorf("container.memory_limit default = %v, want \"\"", memory["default"])
+	}
+}
@@ -0,0 +1,155 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// protectedTargets are container directories the workspace may not replace
+// or be mounted inside of, because the image or enclaude relies on them
+var protectedTargets = []string{
+	"/bin", "/boot", "/dev", "/etc", "/lib", "/lib64", "/proc", "/sbin", "/sys", "/usr",
+	"/artifacts", "/home/enclaude", "/mnt/host", "/refs", "/run/enclaude", "/var/cache/enclaude", "/var/lib/enclaude",
+}
+
+// parentTargets hold other container state, so the workspace may be mounted
+// below them but not over them
+var parentTargets = []string{"/", "/home", "/media", "/mnt", "/opt", "/root", "/run", "/srv", "/tmp", "/var"}
+
+// ResolveWorkspaceTarget returns the container path to mount the workspace
+// at hostDir on. An empty target means the default /workspace, and "host"
+// mirrors hostDir so absolute paths embedded in build caches stay valid.
+func ResolveWorkspaceTarget(target, hostDir string) (string, error) {
+	switch target {
+	case "":
+		return WorkspaceTargetDefault, nil
+	case WorkspaceTargetHost:
+		target = filepath.ToSlash(hostDir)
+	}
+	if err := ValidWorkspaceTarget(target); err != nil {
+		return "", err
+	}
+	return path.Clean(target), nil
+}
+
+// ValidWorkspaceTarget reports why target cannot hold the workspace
+func ValidWorkspaceTarget(target string) error {
+	if target == WorkspaceTargetHost {
+		return nil
+	}
+	if !path.IsAbs(target) {
+		return fmt.Errorf("workspace target %q must be an absolute path or %q", target, WorkspaceTargetHost)
+	}
+	target = path.Clean(target)
+	for _, dir := range parentTargets {
+		if target == dir {
+			return fmt.Errorf("workspace target %q would replace a system directory", target)
+		}
+	}
+	for _, dir := range protectedTargets {
+		if target == dir || strings.HasPrefix(target, dir+"/") {
+			return fmt.Errorf("workspace target %q is inside %s, which the container needs", target, dir)
+		}
+	}
+	return nil
+}
+
+// workspaceRootName is a valid directory name for a workspace root
+var workspaceRootName = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// WorkspaceRoot is one resolved root of a multi-root workspace
+type WorkspaceRoot struct {
+	Name   string
+	Source string // Absolute host path
+	Target string // Container path
+}
+
+// ParseWorkspaceEntry parses a workspace root given as "path" or "name=path"
+func ParseWorkspaceEntry(s string) WorkspaceEntry {
+	if name, p, ok := strings.Cut(s, "="); ok && workspaceRootName.MatchString(name) {
+		return WorkspaceEntry{Name: name, Path: p}
+	}
+	return WorkspaceEntry{Path: s}
+}
+
+// WorkspaceRoots names the roots of a multi-root workspace and places each
+// under the container directory base, or at its host path when base is
+// "host". Entry paths must be absolute. Names default to the path's base
+// name and must be unique, and roots may not be nested in one another.
+func WorkspaceRoots(entries []WorkspaceEntry, base string) ([]WorkspaceRoot, error) {
+	roots := make([]WorkspaceRoot, 0, len(entries))
+	names := make(map[string]string)
+	for _, e := range entries {
+		if !filepath.IsAbs(e.Path) {
+			return nil, fmt.Errorf("workspace root %q must be an absolute path", e.Path)
+		}
+		r := WorkspaceRoot{Name: e.Name, Source: filepath.Clean(e.Path)}
+		if r.Name == "" {
+			r.Name = filepath.Base(r.Source)
+		}
+		if !workspaceRootName.MatchString(r.Name) || r.Name == "." || r.Name == ".." {
+			return nil, fmt.Errorf("invalid workspace root name %q; name it with name=path", r.Name)
+		}
+		if other, ok := names[r.Name]; ok {
+			return nil, fmt.Errorf("workspace roots %s and %s are both named %q; name one with name=path", other, r.Source, r.Name)
+		}
+		names[r.Name] = r.Source
+
+		for _, other := range roots {
+			if nestedPath(r.Source, other.Source) || nestedPath(other.Source, r.Source) {
+				return nil, fmt.Errorf("workspace roots %s and %s overlap", other.Source, r.Source)
+			}
+		}
+
+		if base == WorkspaceTargetHost {
+			r.Target = filepath.ToSlash(r.Source)
+			if err := ValidWorkspaceTarget(r.Target); err != nil {
+				return nil, err
+			}
+		} else {
+			r.Target = path.Join(base, r.Name)
+		}
+		roots = append(roots, r)
+	}
+	return roots, nil
+}
+
+// RefRoots names read-only reference directories and places each under
+// RefsTarget. Entry paths must be absolute, and names default to the path's
+// base name and must be unique. Unlike workspace roots, references may
+// overlap, since none of them can be written.
+func RefRoots(entries []WorkspaceEntry) ([]WorkspaceRoot, error) {
+	refs := make([]WorkspaceRoot, 0, len(entries))
+	names := make(map[string]string)
+	for _, e := range entries {
+		if !filepath.IsAbs(e.Path) {
+			return nil, fmt.Errorf("reference %q must be an absolute path", e.Path)
+		}
+		r := WorkspaceRoot{Name: e.Name, Source: filepath.Clean(e.Path)}
+		if r.Name == "" {
+			r.Name = filepath.Base(r.Source)
+		}
+		if !workspaceRootName.MatchString(r.Name) || r.Name == "." || r.Name == ".." {
+			return nil, fmt.Errorf("invalid reference name %q; name it with name=path", r.Name)
+		}
+		if other, ok := names[r.Name]; ok {
+			if other == r.Source {
+				continue
+			}
+			return nil, fmt.Errorf("references %s and %s are both named %q; name one with name=path", other, r.Source, r.Name)
+		}
+		names[r.Name] = r.Source
+		r.Target = path.Join(RefsTarget, r.Name)
+		refs = append(refs, r)
+	}
+	return refs, nil
+}
+
+// nestedPath reports whether p is dir or inside it
+func nestedPath(p, dir string) bool {
+	rel, err := filepath.Rel(dir, p)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
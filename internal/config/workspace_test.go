@@ -0,0 +1,76 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseWorkspaceEntry(t *testing.T) {
+	tests := []struct {
+		in   string
+		want WorkspaceEntry
+	}{
+		{"../api", WorkspaceEntry{Path: "../api"}},
+		{"api=../api", WorkspaceEntry{Name: "api", Path: "../api"}},
+		{"/src/a=b", WorkspaceEntry{Path: "/src/a=b"}},
+	}
+	for _, tt := range tests {
+		if got := ParseWorkspaceEntry(tt.in); got != tt.want {
+			t.Errorf("ParseWorkspaceEntry(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestWorkspaceRoots(t *testing.T) {
+	roots, err := WorkspaceRoots([]WorkspaceEntry{{Path: "/src/api"}, {Name: "frontend", Path: "/src/web/"}}, "/workspace")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []WorkspaceRoot{
+		{Name: "api", Source: "/src/api", Target: "/workspace/api"},
+		{Name: "frontend", Source: "/src/web", Target: "/workspace/frontend"},
+	}
+	if !reflect.DeepEqual(roots, want) {
+		t.Errorf("WorkspaceRoots() = %+v, want %+v", roots, want)
+	}
+
+	roots, err = WorkspaceRoots([]WorkspaceEntry{{Path: "/src/api"}}, WorkspaceTargetHost)
+	if err != nil || roots[0].Target != "/src/api" {
+		t.Errorf("WorkspaceRoots() with host targets = %+v, %v", roots, err)
+	}
+
+	for name, entries := range map[string][]WorkspaceEntry{
+		"duplicate name": {{Path: "/a/app"}, {Path: "/b/app"}},
+		"nested":         {{Path: "/src"}, {Path: "/src/api"}},
+		"relative":       {{Path: "api"}},
+		"invalid name":   {{Name: "..", Path: "/src/api"}},
+	} {
+		if _, err := WorkspaceRoots(entries, "/workspace"); err == nil {
+			t.Errorf("%s: WorkspaceRoots() succeeded, want error", name)
+		}
+	}
+}
+
+func TestRefRoots(t *testing.T) {
+	refs, err := RefRoots([]WorkspaceEntry{{Path: "/src/lib"}, {Name: "proto", Path: "/src/lib/proto/"}, {Path: "/src/lib"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []WorkspaceRoot{
+		{Name: "lib", Source: "/src/lib", Target: "/refs/lib"},
+		{Name: "proto", Source: "/src/lib/proto", Target: "/refs/proto"},
+	}
+	if !reflect.DeepEqual(refs, want) {
+		t.Errorf("RefRoots() = %+v, want %+v", refs, want)
+	}
+
+	for name, entries := range map[string][]WorkspaceEntry{
+		"duplicate name": {{Path: "/a/lib"}, {Path: "/b/lib"}},
+		"relative":       {{Path: "lib"}},
+		"invalid name":   {{Name: "..", Path: "/src/lib"}},
+	} {
+		if _, err := RefRoots(entries); err == nil {
+			t.Errorf("%s: RefRoots() succeeded, want error", name)
+		}
+	}
+}
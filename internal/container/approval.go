@@ -0,0 +1,73 @@
+package container
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/jakenelson/enclaude/internal/terminal"
+)
+
+// Approval is a request from inside the session that needs the user's
+// decision, such as access to a host path. It is answered from the session
+// menu; Decide is called exactly once.
+type Approval struct {
+	Prompt string
+	Decide func(allow bool)
+}
+
+// approvalQueue holds approvals awaiting a decision, answered oldest first
+type approvalQueue struct {
+	mu      sync.Mutex
+	pending []Approval
+}
+
+// serve queues approvals from ch and announces each on the terminal until
+// ch is closed. Without a terminal to ask on, approvals are denied.
+func (q *approvalQueue) serve(ch <-chan Approval, isTTY bool) {
+	defer terminal.Recover()
+	for a := range ch {
+		if !isTTY {
+			fmt.Fprintf(os.Stderr, "[enclaude] denied, no terminal to ask on: %s\n", a.Prompt)
+			a.Decide(false)
+			continue
+		}
+		q.mu.Lock()
+		q.pending = append(q.pending, a)
+		q.mu.Unlock()
+		fmt.Fprintf(os.Stderr, "\r\n[enclaude] %s\r\n[enclaude] press Ctrl+\\ then y to allow or n to deny\r\n", a.Prompt)
+	}
+}
+
+// next removes and returns the oldest pending approval
+func (q *approvalQueue) next() (Approval, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.pending) == 0 {
+		return Approval{}, false
+	}
+	a := q.pending[0]
+	q.pending = q.pending[1:]
+	return a, true
+}
+
+// peek returns the oldest pending approval without removing it
+func (q *approvalQueue) peek() (Approval, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.pending) == 0 {
+		return Approval{}, false
+	}
+	return q.pending[0], true
+}
+
+// denyAll denies every approval still pending, once the session has ended
+func (q *approvalQueue) denyAll() {
+	for {
+		a, ok := q.next()
+		if !ok {
+			return
+		}
+		a.Decide(false)
+	}
+}
@@ -0,0 +1,209 @@
+package container
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Attestations summarizes the BuildKit provenance and SBOM attached to one
+// platform of an image
+type Attestations struct {
+	Platform   string // Empty for single-platform images
+	Provenance *Provenance
+	SBOM       *SBOM
+}
+
+// Provenance is the part of an SLSA provenance predicate worth showing
+type Provenance struct {
+	BuilderID string
+	BuildType string
+	Started   time.Time
+	Finished  time.Time
+	Materials []Material // Base images and other inputs, pinned by digest
+}
+
+// Material is a build input recorded in provenance
+type Material struct {
+	URI    string
+	Digest string
+}
+
+// SBOM is the part of an SPDX software bill of materials worth showing
+type SBOM struct {
+	Packages int
+}
+
+// InspectAttestations reads the attestations of a registry image through
+// docker buildx imagetools. Attestations are stored alongside the image in
+// the registry, so local-only images cannot be inspected this way.
+func (r *Runner) InspectAttestations(ctx context.Context, ref string) ([]Attestations, error) {
+	if _, err := exec.LookPath("docker"); err != nil {
+		return nil, fmt.Errorf("inspecting attestations requires the docker CLI with buildx: %w", err)
+	}
+	out, err := exec.CommandContext(ctx, "docker", "buildx", "imagetools", "inspect", ref, "--format", "{{json .}}").Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("docker buildx imagetools inspect failed: %s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("docker buildx imagetools inspect failed: %w", err)
+	}
+	return parseImagetools(out)
+}
+
+// imagetoolsAttestations is the per-platform shape of imagetools output
+type imagetoolsAttestations struct {
+	Provenance *struct {
+		SLSA json.RawMessage `json:"SLSA"`
+	} `json:"Provenance"`
+	SBOM *struct {
+		SPDX *struct {
+			Packages []json.RawMessage `json:"packages"`
+		} `json:"SPDX"`
+	} `json:"SBOM"`
+}
+
+// parseImagetools parses `docker buildx imagetools inspect --format
+// '{{json .}}'` output. Single-platform images carry Provenance and SBOM at
+// the top level; multi-platform images key them by platform.
+func parseImagetools(data []byte) ([]Attestations, error) {
+	var top struct {
+		Provenance map[string]json.RawMessage `json:"Provenance"`
+		SBOM       map[string]json.RawMessage `json:"SBOM"`
+	}
+	if err := json.Unmarshal(data, &top); err != nil {
+		return nil, fmt.Errorf("failed to parse imagetools output: %w", err)
+	}
+	_, provenanceSingle := top.Provenance["SLSA"]
+	_, sbomSingle := top.SBOM["SPDX"]
+	if provenanceSingle || sbomSingle {
+		var entry imagetoolsAttestations
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse imagetools output: %w", err)
+		}
+		a, err := entry.summarize("")
+		if err != nil {
+			return nil, err
+		}
+		return []Attestations{a}, nil
+	}
+
+	platforms := map[string]bool{}
+	for p := range top.Provenance {
+		platforms[p] = true
+	}
+	for p := range top.SBOM {
+		platforms[p] = true
+	}
+	var names []string
+	for p := range platforms {
+		names = append(names, p)
+	}
+	sort.Strings(names)
+
+	var result []Attestations
+	for _, p := range names {
+		var entry imagetoolsAttestations
+		if raw, ok := top.Provenance[p]; ok {
+			if err := json.Unmarshal(raw, &entry.Provenance); err != nil {
+				return nil, fmt.Errorf("failed to parse provenance for %s: %w", p, err)
+			}
+		}
+		if raw, ok := top.SBOM[p]; ok {
+			if err := json.Unmarshal(raw, &entry.SBOM); err != nil {
+				return nil, fmt.Errorf("failed to parse SBOM for %s: %w", p, err)
+			}
+		}
+		a, err := entry.summarize(p)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, a)
+	}
+	return result, nil
+}
+
+func (e imagetoolsAttestations) summarize(platform string) (Attestations, error) {
+	a := Attestations{Platform: platform}
+	if e.Provenance != nil && len(e.Provenance.SLSA) > 0 {
+		p, err := parseSLSA(e.Provenance.SLSA)
+		if err != nil {
+			return a, fmt.Errorf("failed to parse provenance: %w", err)
+		}
+		a.Provenance = p
+	}
+	if e.SBOM != nil && e.SBOM.SPDX != nil {
+		a.SBOM = &SBOM{Packages: len(e.SBOM.SPDX.Packages)}
+	}
+	return a, nil
+}
+
+// parseSLSA reads an SLSA provenance predicate in either the v0.2 layout
+// BuildKit produces by default or the v1 layout
+func parseSLSA(data []byte) (*Provenance, error) {
+	var pred struct {
+		// v0.2
+		Builder   struct{ ID string } `json:"builder"`
+		BuildType string              `json:"buildType"`
+		Metadata  struct {
+			Started  time.Time `json:"buildStartedOn"`
+			Finished time.Time `json:"buildFinishedOn"`
+		} `json:"metadata"`
+		Materials []slsaResource `json:"materials"`
+
+		// v1
+		BuildDefinition struct {
+			BuildType            string         `json:"buildType"`
+			ResolvedDependencies []slsaResource `json:"resolvedDependencies"`
+		} `json:"buildDefinition"`
+		RunDetails struct {
+			Builder  struct{ ID string } `json:"builder"`
+			Metadata struct {
+				Started  time.Time `json:"startedOn"`
+				Finished time.Time `json:"finishedOn"`
+			} `json:"metadata"`
+		} `json:"runDetails"`
+	}
+	if err := json.Unmarshal(data, &pred); err != nil {
+		return nil, err
+	}
+
+	p := &Provenance{
+		BuilderID: pred.Builder.ID,
+		BuildType: pred.BuildType,
+		Started:   pred.Metadata.Started,
+		Finished:  pred.Metadata.Finished,
+	}
+	materials := pred.Materials
+	if pred.BuildDefinition.BuildType != "" {
+		p.BuilderID = pred.RunDetails.Builder.ID
+		p.BuildType = pred.BuildDefinition.BuildType
+		p.Started = pred.RunDetails.Metadata.Started
+		p.Finished = pred.RunDetails.Metadata.Finished
+		materials = pred.BuildDefinition.ResolvedDependencies
+	}
+	for _, m := range materials {
+		p.Materials = append(p.Materials, Material{URI: m.URI, Digest: m.digest()})
+	}
+	return p, nil
+}
+
+// slsaResource is a material (v0.2) or resolved dependency (v1)
+type slsaResource struct {
+	URI    string            `json:"uri"`
+	Digest map[string]string `json:"digest"`
+}
+
+func (r slsaResource) digest() string {
+	if d, ok := r.Digest["sha256"]; ok {
+		return "sha256:" + d
+	}
+	for alg, d := range r.Digest {
+		return alg + ":" + d
+	}
+	return ""
+}
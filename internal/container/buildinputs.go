@@ -0,0 +1,94 @@
+package container
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Labels recording what an image was built from, so a session can tell
+// when the Dockerfile or build context has changed since
+const (
+	BuildInputsLabel     = "io.enclaude.build-inputs"
+	BuildDockerfileLabel = "io.enclaude.dockerfile"
+	BuildContextLabel    = "io.enclaude.build-context"
+)
+
+// HashBuildInputs hashes the Dockerfile and the regular files of the build
+// context. Hidden files are skipped, as Build does not send them.
+func HashBuildInputs(dockerfile, contextDir string) (string, error) {
+	h := sha256.New()
+	if err := hashFile(h, "Dockerfile", dockerfile); err != nil {
+		return "", err
+	}
+	err := filepath.Walk(contextDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != contextDir && strings.HasPrefix(info.Name(), ".") && !isIgnoreFile(info.Name()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		relPath, err := filepath.Rel(contextDir, path)
+		if err != nil {
+			return err
+		}
+		return hashFile(h, filepath.ToSlash(relPath), path)
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to hash build context: %w", err)
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// isIgnoreFile reports whether name is a build context ignore file: Docker's
+// .dockerignore or the .containerignore Buildah and Podman also read
+func isIgnoreFile(name string) bool {
+	return name == ".dockerignore" || name == ".containerignore"
+}
+
+// hashFile adds name and the content of path to h
+func hashFile(h io.Writer, name, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fmt.Fprintf(h, "%s\x00", name)
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+	_, err = h.Write([]byte{0})
+	return err
+}
+
+// BuildLabels returns the labels recording dockerfile and contextDir as the
+// inputs of a build
+func BuildLabels(dockerfile, contextDir string) (map[string]string, error) {
+	dockerfile, err := filepath.Abs(dockerfile)
+	if err != nil {
+		return nil, err
+	}
+	contextDir, err = filepath.Abs(contextDir)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := HashBuildInputs(dockerfile, contextDir)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		BuildInputsLabel:     hash,
+		BuildDockerfileLabel: dockerfile,
+		BuildContextLabel:    contextDir,
+	}, nil
+}
@@ -0,0 +1,62 @@
+package container
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHashBuildInputs(t *testing.T) {
+	dir := t.TempDir()
+	dockerfile := filepath.Join(dir, "Dockerfile")
+	write := func(path, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(dockerfile, "FROM ubuntu:24.04\n")
+	write(filepath.Join(dir, "entrypoint.sh"), "#!/bin/bash\n")
+
+	hash := func() string {
+		t.Helper()
+		h, err := HashBuildInputs(dockerfile, dir)
+		if err != nil {
+			t.Fatalf("HashBuildInputs() error = %v", err)
+		}
+		return h
+	}
+	original := hash()
+	if original != hash() {
+		t.Error("HashBuildInputs() should be stable")
+	}
+
+	write(filepath.Join(dir, ".git", "HEAD"), "ref: refs/heads/main\n")
+	if hash() != original {
+		t.Error("HashBuildInputs() should ignore hidden files")
+	}
+
+	write(filepath.Join(dir, "entrypoint.sh"), "#!/bin/bash\nset -e\n")
+	changedContext := hash()
+	if changedContext == original {
+		t.Error("HashBuildInputs() should change with the build context")
+	}
+
+	write(filepath.Join(dir, ".containerignore"), "*.md\n")
+	withIgnore := hash()
+	if withIgnore == changedContext {
+		t.Error("HashBuildInputs() should change with .containerignore")
+	}
+
+	write(dockerfile, "FROM ubuntu:24.04\nRUN true\n")
+	if hash() == withIgnore {
+		t.Error("HashBuildInputs() should change with the Dockerfile")
+	}
+
+	if _, err := HashBuildInputs(filepath.Join(dir, "missing"), dir); err == nil {
+		t.Error("HashBuildInputs() with a missing Dockerfile should fail")
+	}
+}
@@ -0,0 +1,156 @@
+package container
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// buildWithBuildx builds through the docker CLI's buildx plugin, which is
+// required for exporting and importing layer caches, attestations, and
+// pushing. The Engine API build endpoint used by Build supports none of them.
+func (r *Runner) buildWithBuildx(ctx context.Context, opts BuildOptions) error {
+	if _, err := exec.LookPath("docker"); err != nil {
+		return fmt.Errorf("--cache-to, --cache-from, --attest, --push, and --secret require the docker CLI with buildx: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, "docker", buildxArgs(opts)...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		if opts.Attest && !opts.Push {
+			return fmt.Errorf("docker buildx build failed: %w (loading attested images locally needs the containerd image store; use --push to attach attestations in a registry instead)", err)
+		}
+		return fmt.Errorf("docker buildx build failed: %w (exporting local or registry caches needs a docker-container builder: docker buildx create --use)", err)
+	}
+	return nil
+}
+
+// buildxArgs returns the docker CLI arguments for a buildx build
+func buildxArgs(opts BuildOptions) []string {
+	output := "--load"
+	if opts.Push {
+		output = "--push"
+	}
+	args := []string{"buildx", "build", "--file", opts.Dockerfile, "--tag", opts.Tag, output}
+	if opts.Attest {
+		args = append(args, "--provenance=mode=max", "--sbom=true")
+	}
+	if opts.NoCache {
+		args = append(args, "--no-cache")
+	}
+	if opts.Platform != "" {
+		args = append(args, "--platform", opts.Platform)
+	}
+	for _, from := range opts.CacheFrom {
+		args = append(args, "--cache-from", cacheSpec(from, false))
+	}
+	for _, to := range opts.CacheTo {
+		args = append(args, "--cache-to", cacheSpec(to, true))
+	}
+	keys := make([]string, 0, len(opts.BuildArgs))
+	for k := range opts.BuildArgs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		args = append(args, "--build-arg", k+"="+opts.BuildArgs[k])
+	}
+	for _, secret := range opts.Secrets {
+		args = append(args, "--secret", secret.String())
+	}
+	labels := make([]string, 0, len(opts.Labels))
+	for k := range opts.Labels {
+		labels = append(labels, k)
+	}
+	sort.Strings(labels)
+	for _, k := range labels {
+		args = append(args, "--label", k+"="+opts.Labels[k])
+	}
+	return append(args, opts.ContextDir)
+}
+
+// BuildSecret is a file or environment variable handed to the build as a
+// BuildKit secret, so a Dockerfile can use it with RUN --mount=type=secret
+// without it ending up in a layer
+type BuildSecret struct {
+	ID     string
+	Source string // Host file, when Env is not set
+	Env    string // Host environment variable
+}
+
+// ParseBuildSecret parses a secret in docker's id=name,src=path or
+// id=name,env=VAR form. "source" is accepted for src, and a secret with
+// neither reads the environment variable named by its id.
+func ParseBuildSecret(spec string) (BuildSecret, error) {
+	var s BuildSecret
+	for _, field := range strings.Split(spec, ",") {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok || value == "" {
+			return BuildSecret{}, fmt.Errorf("invalid secret %q: expected key=value fields", spec)
+		}
+		switch key {
+		case "id":
+			s.ID = value
+		case "src", "source":
+			s.Source = value
+		case "env":
+			s.Env = value
+		case "type":
+			if value != "file" && value != "env" {
+				return BuildSecret{}, fmt.Errorf("invalid secret %q: unsupported type %q", spec, value)
+			}
+		default:
+			return BuildSecret{}, fmt.Errorf("invalid secret %q: unknown field %q", spec, key)
+		}
+	}
+	if s.ID == "" {
+		return BuildSecret{}, fmt.Errorf("invalid secret %q: id is required", spec)
+	}
+	if s.Source != "" && s.Env != "" {
+		return BuildSecret{}, fmt.Errorf("invalid secret %q: src and env are mutually exclusive", spec)
+	}
+	if s.Source == "" && s.Env == "" {
+		s.Env = s.ID
+	}
+	return s, nil
+}
+
+// String formats s as a buildx --secret value
+func (s BuildSecret) String() string {
+	if s.Env != "" {
+		return "id=" + s.ID + ",env=" + s.Env
+	}
+	return "id=" + s.ID + ",src=" + s.Source
+}
+
+// cacheSpec expands a cache location into a buildx cache specification.
+// Full specifications (containing "type=") are passed through. Paths are
+// local directory caches and anything else is a registry reference. Exports
+// use mode=max so intermediate layers of multi-stage builds are cached too.
+func cacheSpec(location string, export bool) string {
+	if strings.Contains(location, "type=") {
+		return location
+	}
+
+	if isLocalCachePath(location) {
+		if export {
+			return "type=local,dest=" + location + ",mode=max"
+		}
+		return "type=local,src=" + location
+	}
+
+	if export {
+		return "type=registry,ref=" + location + ",mode=max"
+	}
+	return "type=registry,ref=" + location
+}
+
+// isLocalCachePath reports whether location names a directory rather than an image reference
+func isLocalCachePath(location string) bool {
+	return strings.HasPrefix(location, "/") || strings.HasPrefix(location, "./") ||
+		strings.HasPrefix(location, "../") || location == "."
+}
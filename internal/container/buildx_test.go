@@ -0,0 +1,167 @@
+package container
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCacheSpec(t *testing.T) {
+	tests := []struct {
+		location string
+		export   bool
+		want     string
+	}{
+		{"./.cache/enclaude", false, "type=local,src=./.cache/enclaude"},
+		{"/var/cache/enclaude", true, "type=local,dest=/var/cache/enclaude,mode=max"},
+		{"ghcr.io/acme/enclaude:cache", false, "type=registry,ref=ghcr.io/acme/enclaude:cache"},
+		{"ghcr.io/acme/enclaude:cache", true, "type=registry,ref=ghcr.io/acme/enclaude:cache,mode=max"},
+		{"type=gha,scope=enclaude", true, "type=gha,scope=enclaude"},
+	}
+
+	for _, tt := range tests {
+		if got := cacheSpec(tt.location, tt.export); got != tt.want {
+			t.Errorf("cacheSpec(%q, %v) = %q, want %q", tt.location, tt.export, got, tt.want)
+		}
+	}
+}
+
+func TestBuildxArgs(t *testing.T) {
+	got := buildxArgs(BuildOptions{
+		Dockerfile: "docker/Dockerfile",
+		ContextDir: ".",
+		Tag:        "enclaude:latest",
+		CacheFrom:  []string{"ghcr.io/acme/enclaude:cache"},
+		CacheTo:    []string{"./cache"},
+	})
+	want := []string{
+		"buildx", "build", "--file", "docker/Dockerfile", "--tag", "enclaude:latest", "--load",
+		"--cache-from", "type=registry,ref=ghcr.io/acme/enclaude:cache",
+		"--cache-to", "type=local,dest=./cache,mode=max",
+		".",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("buildxArgs() = %v, want %v", got, want)
+	}
+}
+
+func TestBuildxArgsAttest(t *testing.T) {
+	got := buildxArgs(BuildOptions{
+		Dockerfile: "Dockerfile",
+		ContextDir: ".",
+		Tag:        "ghcr.io/acme/enclaude:latest",
+		Attest:     true,
+		Push:       true,
+		BuildArgs:  UserBuildArgs(1000, 1000),
+	})
+	want := []string{
+		"buildx", "build", "--file", "Dockerfile", "--tag", "ghcr.io/acme/enclaude:latest", "--push",
+		"--provenance=mode=max", "--sbom=true",
+		"--build-arg", "ENCLAUDE_GID=1000", "--build-arg", "ENCLAUDE_UID=1000",
+		".",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("buildxArgs() = %v, want %v", got, want)
+	}
+}
+
+func TestParseImagetools(t *testing.T) {
+	single := `{
+		"Provenance": {"SLSA": {
+			"builder": {"id": "https://github.com/acme/enclaude/actions/runs/1"},
+			"buildType": "https://mobyproject.org/buildkit@v1",
+			"metadata": {"buildStartedOn": "2026-01-02T10:00:00Z", "buildFinishedOn": "2026-01-02T10:03:00Z"},
+			"materials": [{"uri": "pkg:docker/node@20-slim", "digest": {"sha256": "abc123"}}]
+		}},
+		"SBOM": {"SPDX": {"packages": [{}, {}, {}]}}
+	}`
+	multi := `{
+		"Provenance": {
+			"linux/amd64": {"SLSA": {
+				"buildDefinition": {"buildType": "https://github.com/moby/buildkit/blob/master/docs/attestations/slsa-definitions.md", "resolvedDependencies": [{"uri": "pkg:docker/node@20-slim", "digest": {"sha256": "def456"}}]},
+				"runDetails": {"builder": {"id": "ci"}, "metadata": {"startedOn": "2026-01-02T10:00:00Z", "finishedOn": "2026-01-02T10:01:00Z"}}
+			}},
+			"linux/arm64": {"SLSA": {"buildType": "https://mobyproject.org/buildkit@v1"}}
+		},
+		"SBOM": {"linux/amd64": {"SPDX": {"packages": [{}]}}}
+	}`
+
+	got, err := parseImagetools([]byte(single))
+	if err != nil {
+		t.Fatalf("parseImagetools(single) error = %v", err)
+	}
+	if len(got) != 1 || got[0].Platform != "" || got[0].Provenance == nil || got[0].SBOM == nil {
+		t.Fatalf("parseImagetools(single) = %+v", got)
+	}
+	p := got[0].Provenance
+	if p.BuilderID != "https://github.com/acme/enclaude/actions/runs/1" || p.Finished.Sub(p.Started).Minutes() != 3 {
+		t.Errorf("provenance = %+v", p)
+	}
+	if want := []Material{{URI: "pkg:docker/node@20-slim", Digest: "sha256:abc123"}}; !reflect.DeepEqual(p.Materials, want) {
+		t.Errorf("materials = %v, want %v", p.Materials, want)
+	}
+	if got[0].SBOM.Packages != 3 {
+		t.Errorf("SBOM packages = %d, want 3", got[0].SBOM.Packages)
+	}
+
+	got, err = parseImagetools([]byte(multi))
+	if err != nil {
+		t.Fatalf("parseImagetools(multi) error = %v", err)
+	}
+	if len(got) != 2 || got[0].Platform != "linux/amd64" || got[1].Platform != "linux/arm64" {
+		t.Fatalf("parseImagetools(multi) = %+v", got)
+	}
+	if got[0].Provenance.BuilderID != "ci" || got[0].Provenance.Materials[0].Digest != "sha256:def456" || got[0].SBOM.Packages != 1 {
+		t.Errorf("linux/amd64 = %+v %+v", got[0].Provenance, got[0].SBOM)
+	}
+	if got[1].SBOM != nil {
+		t.Errorf("linux/arm64 SBOM = %+v, want none", got[1].SBOM)
+	}
+
+	got, err = parseImagetools([]byte(`{"name": "enclaude:latest"}`))
+	if err != nil || len(got) != 0 {
+		t.Errorf("parseImagetools(none) = %v, %v, want no attestations", got, err)
+	}
+}
+
+func TestParseBuildSecret(t *testing.T) {
+	tests := []struct {
+		spec string
+		want BuildSecret
+	}{
+		{"id=npmrc,src=/home/alice/.npmrc", BuildSecret{ID: "npmrc", Source: "/home/alice/.npmrc"}},
+		{"type=file,id=npmrc,source=.npmrc", BuildSecret{ID: "npmrc", Source: ".npmrc"}},
+		{"id=token,env=GITHUB_TOKEN", BuildSecret{ID: "token", Env: "GITHUB_TOKEN"}},
+		{"id=GITHUB_TOKEN", BuildSecret{ID: "GITHUB_TOKEN", Env: "GITHUB_TOKEN"}},
+	}
+	for _, tt := range tests {
+		got, err := ParseBuildSecret(tt.spec)
+		if err != nil {
+			t.Errorf("ParseBuildSecret(%q) error: %v", tt.spec, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseBuildSecret(%q) = %+v, want %+v", tt.spec, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"src=.npmrc", "id=npmrc,src=.npmrc,env=X", "id=npmrc,mode=0600", "npmrc"} {
+		if _, err := ParseBuildSecret(bad); err == nil {
+			t.Errorf("ParseBuildSecret(%q) should fail", bad)
+		}
+	}
+
+	got := buildxArgs(BuildOptions{
+		Dockerfile: "Dockerfile",
+		ContextDir: ".",
+		Tag:        "enclaude:latest",
+		Secrets:    []BuildSecret{{ID: "npmrc", Source: "/home/alice/.npmrc"}, {ID: "token", Env: "GITHUB_TOKEN"}},
+	})
+	want := []string{
+		"buildx", "build", "--file", "Dockerfile", "--tag", "enclaude:latest", "--load",
+		"--secret", "id=npmrc,src=/home/alice/.npmrc", "--secret", "id=token,env=GITHUB_TOKEN",
+		".",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("buildxArgs() = %v, want %v", got, want)
+	}
+}
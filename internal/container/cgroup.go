@@ -0,0 +1,65 @@
+package container
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+
+	containerTypes "github.com/docker/docker/api/types/container"
+)
+
+// cgroup v2 weight range for cpu.weight and io.weight
+const (
+	minCgroupWeight = 1
+	maxCgroupWeight = 10000
+)
+
+// applyCgroup sets the parent cgroup and scheduling weights on resources.
+// Weights are given in cgroup v2 units and converted to the Docker API's
+// CPU shares and blkio weight, which the runtime maps back to cpu.weight and
+// io.weight on cgroup v2 hosts. Settings are ignored outside Linux, where the
+// container runs inside a VM whose cgroups the user does not share.
+func applyCgroup(resources *containerTypes.Resources, opts CgroupOptions) error {
+	if opts.Parent == "" && opts.CPUWeight == 0 && opts.IOWeight == 0 {
+		return nil
+	}
+	if runtime.GOOS != "linux" {
+		fmt.Fprintf(os.Stderr, "Warning: container.cgroup settings are only supported on Linux hosts; ignoring\n")
+		return nil
+	}
+
+	if opts.CPUWeight != 0 {
+		if opts.CPUWeight < minCgroupWeight || opts.CPUWeight > maxCgroupWeight {
+			return fmt.Errorf("invalid cpu_weight %d: must be between %d and %d", opts.CPUWeight, minCgroupWeight, maxCgroupWeight)
+		}
+		resources.CPUShares = cpuWeightToShares(opts.CPUWeight)
+	}
+
+	if opts.IOWeight != 0 {
+		if opts.IOWeight < minCgroupWeight || opts.IOWeight > maxCgroupWeight {
+			return fmt.Errorf("invalid io_weight %d: must be between %d and %d", opts.IOWeight, minCgroupWeight, maxCgroupWeight)
+		}
+		resources.BlkioWeight = ioWeightToBlkio(opts.IOWeight)
+	}
+
+	resources.CgroupParent = opts.Parent
+	return nil
+}
+
+// cpuWeightToShares inverts runc's shares-to-weight conversion
+// (weight = 1 + (shares-2)*9999/262142), rounding up so the round trip
+// yields the requested weight
+func cpuWeightToShares(weight int) int64 {
+	return 2 + ceilDiv(int64(weight-1)*262142, 9999)
+}
+
+// ioWeightToBlkio inverts runc's blkio-to-io.weight conversion
+// (weight = 1 + (blkio-10)*9999/990). Blkio weight has coarser resolution,
+// so the resulting io.weight is approximate.
+func ioWeightToBlkio(weight int) uint16 {
+	return uint16(10 + ceilDiv(int64(weight-1)*990, 9999))
+}
+
+func ceilDiv(a, b int64) int64 {
+	return (a + b - 1) / b
+}
@@ -0,0 +1,71 @@
+package container
+
+import (
+	"runtime"
+	"testing"
+
+	containerTypes "github.com/docker/docker/api/types/container"
+)
+
+func TestCPUWeightToShares(t *testing.T) {
+	tests := []struct {
+		weight int
+		want   int64
+	}{
+		{1, 2},
+		{100, 2598},
+		{10000, 262144},
+	}
+
+	for _, tt := range tests {
+		got := cpuWeightToShares(tt.weight)
+		if got != tt.want {
+			t.Errorf("cpuWeightToShares(%d) = %d, want %d", tt.weight, got, tt.want)
+		}
+		// runc's conversion back to cpu.weight should round-trip
+		if back := 1 + ((got-2)*9999)/262142; back != int64(tt.weight) {
+			t.Errorf("cpuWeightToShares(%d) round-trips to weight %d", tt.weight, back)
+		}
+	}
+}
+
+func TestIOWeightToBlkio(t *testing.T) {
+	tests := []struct {
+		weight int
+		want   uint16
+	}{
+		{1, 10},
+		{100, 20},
+		{10000, 1000},
+	}
+
+	for _, tt := range tests {
+		if got := ioWeightToBlkio(tt.weight); got != tt.want {
+			t.Errorf("ioWeightToBlkio(%d) = %d, want %d", tt.weight, got, tt.want)
+		}
+	}
+}
+
+func TestApplyCgroup(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("cgroup settings only apply on Linux")
+	}
+
+	var res containerTypes.Resources
+	if err := applyCgroup(&res, CgroupOptions{Parent: "enclaude.slice", CPUWeight: 50}); err != nil {
+		t.Fatalf("applyCgroup() error = %v", err)
+	}
+	if res.CgroupParent != "enclaude.slice" {
+		t.Errorf("CgroupParent = %q, want enclaude.slice", res.CgroupParent)
+	}
+	if res.CPUShares == 0 {
+		t.Error("CPUShares not set")
+	}
+	if res.BlkioWeight != 0 {
+		t.Errorf("BlkioWeight = %d, want unset", res.BlkioWeight)
+	}
+
+	if err := applyCgroup(&res, CgroupOptions{IOWeight: 20000}); err == nil {
+		t.Error("applyCgroup() with out-of-range io_weight should fail")
+	}
+}
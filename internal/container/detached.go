@@ -0,0 +1,89 @@
+package container
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/docker/docker/api/types"
+	containerTypes "github.com/docker/docker/api/types/container"
+	"github.com/jakenelson/enclaude/internal/config"
+)
+
+// StartDetached creates and starts a TTY session container without attaching
+// the local terminal, for frontends that attach over another transport.
+// Frontends attach to the container, so claude always runs as its main
+// process.
+func (r *Runner) StartDetached(ctx context.Context, opts RunOptions) (string, error) {
+	opts.Interaction = config.InteractionAttach
+	containerID, err := r.createContainer(ctx, opts, true)
+	if err != nil {
+		return "", err
+	}
+
+	if err := r.client.ContainerStart(ctx, containerID, containerTypes.StartOptions{}); err != nil {
+		r.Remove(containerID)
+		return "", fmt.Errorf("failed to start container: %w", err)
+	}
+
+	return containerID, nil
+}
+
+// AttachTTY attaches to a running session container's TTY. Writes go to the
+// container's stdin and reads return its terminal output.
+func (r *Runner) AttachTTY(ctx context.Context, containerID string) (io.ReadWriteCloser, error) {
+	resp, err := r.client.ContainerAttach(ctx, containerID, containerTypes.AttachOptions{
+		Stream: true,
+		Stdin:  true,
+		Stdout: true,
+		Stderr: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to attach to container: %w", err)
+	}
+	return &hijackedStream{resp: resp}, nil
+}
+
+// ResizeTTY sets the container TTY dimensions
+func (r *Runner) ResizeTTY(ctx context.Context, containerID string, rows, cols uint) error {
+	return r.client.ContainerResize(ctx, containerID, containerTypes.ResizeOptions{
+		Height: rows,
+		Width:  cols,
+	})
+}
+
+// Wait blocks until the container stops and returns its exit code
+func (r *Runner) Wait(ctx context.Context, containerID string) (int64, error) {
+	statusCh, errCh := r.client.ContainerWait(ctx, containerID, containerTypes.WaitConditionNotRunning)
+	select {
+	case err := <-errCh:
+		return 0, fmt.Errorf("error waiting for container: %w", err)
+	case status := <-statusCh:
+		return status.StatusCode, nil
+	}
+}
+
+// Remove force-removes a container
+func (r *Runner) Remove(containerID string) error {
+	return r.client.ContainerRemove(context.Background(), containerID, containerTypes.RemoveOptions{
+		Force: true,
+	})
+}
+
+// hijackedStream adapts a Docker attach connection to io.ReadWriteCloser
+type hijackedStream struct {
+	resp types.HijackedResponse
+}
+
+func (h *hijackedStream) Read(p []byte) (int, error) {
+	return h.resp.Reader.Read(p)
+}
+
+func (h *hijackedStream) Write(p []byte) (int, error) {
+	return h.resp.Conn.Write(p)
+}
+
+func (h *hijackedStream) Close() error {
+	h.resp.Close()
+	return nil
+}
@@ -0,0 +1,290 @@
+package container
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	containerTypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/go-units"
+)
+
+// DockerArg is one docker run flag from container.extra_docker_args or
+// --docker-opt, such as --shm-size=2g
+type DockerArg struct {
+	Name  string // Long flag name without dashes, e.g. "shm-size"
+	Value string // Empty for flags without a value, such as --init
+}
+
+// String renders the argument as a single docker run flag
+func (a DockerArg) String() string {
+	if a.Value == "" {
+		return "--" + a.Name
+	}
+	return "--" + a.Name + "=" + a.Value
+}
+
+// deniedDockerArgs are flags that would undo the sandbox or get around
+// enclaude's mount checks, with the reason they are refused
+var deniedDockerArgs = map[string]string{
+	"privileged":         "it disables all isolation",
+	"cap-add":            "it gives the container back capabilities enclaude drops",
+	"security-opt":       "it can turn off seccomp, AppArmor, and no-new-privileges",
+	"device":             "it exposes host devices",
+	"device-cgroup-rule": "it exposes host devices",
+	"pid":                "it can share the host's process namespace",
+	"ipc":                "it can share the host's IPC namespace",
+	"uts":                "it can share the host's UTS namespace",
+	"userns":             "it can turn off user namespace remapping",
+	"cgroupns":           "it can share the host's cgroup namespace",
+	"volume":             "mounts bypass enclaude's denied paths; use --mount or mounts.defaults",
+	"mount":              "mounts bypass enclaude's denied paths; use --mount or mounts.defaults",
+	"volumes-from":       "mounts bypass enclaude's denied paths; use --mount or mounts.defaults",
+	"log-driver":         "enclaude reads the session's output from the container logs",
+	"log-opt":            "enclaude reads the session's output from the container logs",
+	"runtime":            "it can swap the container runtime for one that isolates less",
+}
+
+// safeSysctls are the sysctls --sysctl may set: those confined to the
+// container's namespaces that Kubernetes also allows unprivileged pods
+var safeSysctls = []string{
+	"kernel.shm_rmid_forced",
+	"net.ipv4.ip_local_port_range",
+	"net.ipv4.ip_local_reserved_ports",
+	"net.ipv4.ip_unprivileged_port_start",
+	"net.ipv4.ping_group_range",
+	"net.ipv4.tcp_fin_timeout",
+	"net.ipv4.tcp_keepalive_intvl",
+	"net.ipv4.tcp_keepalive_probes",
+	"net.ipv4.tcp_keepalive_time",
+	"net.ipv4.tcp_syncookies",
+}
+
+// managedDockerArgs are flags enclaude sets itself, with the setting to use
+// instead, if there is one
+var managedDockerArgs = map[string]string{
+	"network":       "container.network",
+	"net":           "container.network",
+	"network-alias": "network.alias",
+	"publish":       "container.ports",
+	"memory":        "container.memory_limit",
+	"cpus":          "container.cpus",
+	"pids-limit":    "container.pids_limit",
+	"tmpfs":         "container.tmpfs_size",
+	"read-only":     "security.read_only_root",
+	"cap-drop":      "security.drop_capabilities",
+	"cgroup-parent": "container.cgroup.parent",
+	"user":          "container.user",
+	"workdir":       "mounts.workspace_target",
+	"env":           "environment.custom",
+	"env-file":      "environment.custom",
+	"entrypoint":    "a custom image",
+	"name":          "",
+	"rm":            "",
+	"detach":        "",
+	"interactive":   "",
+	"tty":           "",
+}
+
+// dockerArgSetters translate the supported flags into container and host
+// configuration
+var dockerArgSetters = map[string]func(c *containerTypes.Config, hc *containerTypes.HostConfig, v string) error{
+	"add-host": func(c *containerTypes.Config, hc *containerTypes.HostConfig, v string) error {
+		hc.ExtraHosts = append(hc.ExtraHosts, v)
+		return nil
+	},
+	"dns": func(c *containerTypes.Config, hc *containerTypes.HostConfig, v string) error {
+		hc.DNS = append(hc.DNS, v)
+		return nil
+	},
+	"dns-search": func(c *containerTypes.Config, hc *containerTypes.HostConfig, v string) error {
+		hc.DNSSearch = append(hc.DNSSearch, v)
+		return nil
+	},
+	"dns-option": func(c *containerTypes.Config, hc *containerTypes.HostConfig, v string) error {
+		hc.DNSOptions = append(hc.DNSOptions, v)
+		return nil
+	},
+	"group-add": func(c *containerTypes.Config, hc *containerTypes.HostConfig, v string) error {
+		hc.GroupAdd = append(hc.GroupAdd, v)
+		return nil
+	},
+	"hostname": func(c *containerTypes.Config, hc *containerTypes.HostConfig, v string) error {
+		c.Hostname = v
+		return nil
+	},
+	"init": func(c *containerTypes.Config, hc *containerTypes.HostConfig, v string) error {
+		enabled, err := parseDockerBool(v)
+		hc.Init = &enabled
+		return err
+	},
+	"label": func(c *containerTypes.Config, hc *containerTypes.HostConfig, v string) error {
+		key, value, _ := strings.Cut(v, "=")
+		if strings.HasPrefix(key, "com.enclaude.") {
+			return fmt.Errorf("com.enclaude labels are reserved for enclaude")
+		}
+		if c.Labels == nil {
+			c.Labels = make(map[string]string)
+		}
+		c.Labels[key] = value
+		return nil
+	},
+	"oom-score-adj": func(c *containerTypes.Config, hc *containerTypes.HostConfig, v string) error {
+		adj, err := strconv.Atoi(v)
+		hc.OomScoreAdj = adj
+		return err
+	},
+	"shm-size": func(c *containerTypes.Config, hc *containerTypes.HostConfig, v string) error {
+		size, err := units.RAMInBytes(v)
+		hc.ShmSize = size
+		return err
+	},
+	"stop-signal": func(c *containerTypes.Config, hc *containerTypes.HostConfig, v string) error {
+		c.StopSignal = v
+		return nil
+	},
+	"storage-opt": func(c *containerTypes.Config, hc *containerTypes.HostConfig, v string) error {
+		key, value, ok := strings.Cut(v, "=")
+		if !ok {
+			return fmt.Errorf("expected key=value")
+		}
+		if hc.StorageOpt == nil {
+			hc.StorageOpt = make(map[string]string)
+		}
+		hc.StorageOpt[key] = value
+		return nil
+	},
+	"sysctl": func(c *containerTypes.Config, hc *containerTypes.HostConfig, v string) error {
+		key, value, ok := strings.Cut(v, "=")
+		if !ok {
+			return fmt.Errorf("expected key=value")
+		}
+		if !slices.Contains(safeSysctls, key) {
+			return fmt.Errorf("%s is not one of the sysctls allowed (%s)", key, strings.Join(safeSysctls, ", "))
+		}
+		if hc.Sysctls == nil {
+			hc.Sysctls = make(map[string]string)
+		}
+		hc.Sysctls[key] = value
+		return nil
+	},
+	"ulimit": func(c *containerTypes.Config, hc *containerTypes.HostConfig, v string) error {
+		ulimit, err := units.ParseUlimit(v)
+		if err != nil {
+			return err
+		}
+		hc.Ulimits = append(hc.Ulimits, ulimit)
+		return nil
+	},
+	"cpu-shares": func(c *containerTypes.Config, hc *containerTypes.HostConfig, v string) error {
+		shares, err := strconv.ParseInt(v, 10, 64)
+		hc.CPUShares = shares
+		return err
+	},
+	"cpuset-cpus": func(c *containerTypes.Config, hc *containerTypes.HostConfig, v string) error {
+		hc.CpusetCpus = v
+		return nil
+	},
+	"cpuset-mems": func(c *containerTypes.Config, hc *containerTypes.HostConfig, v string) error {
+		hc.CpusetMems = v
+		return nil
+	},
+	"memory-swap": func(c *containerTypes.Config, hc *containerTypes.HostConfig, v string) error {
+		if v == "-1" {
+			hc.MemorySwap = -1
+			return nil
+		}
+		swap, err := units.RAMInBytes(v)
+		hc.MemorySwap = swap
+		return err
+	},
+	"gpus": func(c *containerTypes.Config, hc *containerTypes.HostConfig, v string) error {
+		count := -1
+		if v != "all" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				return fmt.Errorf("expected all or a number of GPUs")
+			}
+			count = n
+		}
+		hc.DeviceRequests = append(hc.DeviceRequests, containerTypes.DeviceRequest{
+			Count:        count,
+			Capabilities: [][]string{{"gpu"}},
+		})
+		return nil
+	},
+}
+
+// ParseDockerArgs parses docker run flags given one per entry, as
+// --name=value or --name, with the leading dashes optional. Flags that would
+// weaken the sandbox, or that enclaude sets itself, are refused, as are
+// those without a setter in dockerArgSetters, so every engine gets the same
+// flags. Values are checked by the setters too.
+func ParseDockerArgs(args []string) ([]DockerArg, error) {
+	var parsed []DockerArg
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--") {
+			return nil, fmt.Errorf("invalid docker option %q: use the long form, e.g. --shm-size=2g", arg)
+		}
+		name, value, _ := strings.Cut(strings.TrimPrefix(arg, "--"), "=")
+		if name == "" {
+			return nil, fmt.Errorf("invalid docker option %q", arg)
+		}
+		if reason, ok := deniedDockerArgs[name]; ok {
+			return nil, fmt.Errorf("docker option --%s is not allowed: %s", name, reason)
+		}
+		if setting, ok := managedDockerArgs[name]; ok && setting != "" {
+			return nil, fmt.Errorf("docker option --%s is set by enclaude; use %s instead", name, setting)
+		} else if ok {
+			return nil, fmt.Errorf("docker option --%s is set by enclaude", name)
+		}
+		arg := DockerArg{Name: name, Value: value}
+		set, ok := dockerArgSetters[name]
+		if !ok {
+			return nil, fmt.Errorf("docker option --%s is not supported (supported: %s)", name, strings.Join(supportedDockerArgs(), ", "))
+		}
+		if value == "" && name != "init" {
+			return nil, fmt.Errorf("docker option --%s needs a value, e.g. --%s=...", name, name)
+		}
+		if err := set(&containerTypes.Config{}, &containerTypes.HostConfig{}, value); err != nil {
+			return nil, fmt.Errorf("invalid docker option %s: %w", arg, err)
+		}
+		parsed = append(parsed, arg)
+	}
+	return parsed, nil
+}
+
+// applyDockerArgs sets the container and host configuration for args
+func applyDockerArgs(c *containerTypes.Config, hc *containerTypes.HostConfig, args []string) error {
+	parsed, err := ParseDockerArgs(args)
+	if err != nil {
+		return err
+	}
+	for _, arg := range parsed {
+		if err := dockerArgSetters[arg.Name](c, hc, arg.Value); err != nil {
+			return fmt.Errorf("invalid docker option %s: %w", arg, err)
+		}
+	}
+	return nil
+}
+
+// supportedDockerArgs returns the flags enclaude accepts
+func supportedDockerArgs() []string {
+	names := make([]string, 0, len(dockerArgSetters))
+	for name := range dockerArgSetters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// parseDockerBool parses the value of a boolean flag, which is true when
+// given without one
+func parseDockerBool(v string) (bool, error) {
+	if v == "" {
+		return true, nil
+	}
+	return strconv.ParseBool(v)
+}
@@ -0,0 +1,112 @@
+package container
+
+import (
+	"strings"
+	"testing"
+
+	containerTypes "github.com/docker/docker/api/types/container"
+)
+
+func TestParseDockerArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    []DockerArg
+		wantErr string
+	}{
+		{"long form", []string{"--shm-size=2g", "--init"}, []DockerArg{{"shm-size", "2g"}, {"init", ""}}, ""},
+		{"without dashes", []string{"ulimit=nofile=1024:2048"}, []DockerArg{{"ulimit", "nofile=1024:2048"}}, ""},
+		{"denied", []string{"--privileged"}, nil, "not allowed"},
+		{"denied mount", []string{"volume=/:/host"}, nil, "not allowed"},
+		{"managed", []string{"--memory=8g"}, nil, "use container.memory_limit"},
+		{"managed without a setting", []string{"--rm"}, nil, "set by enclaude"},
+		{"short form", []string{"-v"}, nil, "long form"},
+		{"empty", []string{"--"}, nil, "invalid"},
+		{"unsupported", []string{"--platform=linux/arm64"}, nil, "not supported"},
+		{"runtime", []string{"--runtime=runc"}, nil, "not allowed"},
+		{"unsafe sysctl", []string{"--sysctl=kernel.core_pattern=|/tmp/x"}, nil, "not one of the sysctls allowed"},
+		{"reserved label", []string{"--label=com.enclaude.session=true"}, nil, "reserved"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDockerArgs(tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseDockerArgs() error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDockerArgs() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ParseDockerArgs() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("ParseDockerArgs()[%d] = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestApplyDockerArgs(t *testing.T) {
+	c := &containerTypes.Config{}
+	hc := &containerTypes.HostConfig{ExtraHosts: []string{"blocked.example:0.0.0.0"}}
+	err := applyDockerArgs(c, hc, []string{
+		"--shm-size=1g",
+		"--ulimit=nofile=1024:2048",
+		"--sysctl=net.ipv4.ip_unprivileged_port_start=0",
+		"--add-host=db.local:10.0.0.5",
+		"--init",
+		"--gpus=all",
+		"--label=team=infra",
+	})
+	if err != nil {
+		t.Fatalf("applyDockerArgs() error = %v", err)
+	}
+	if hc.ShmSize != 1<<30 {
+		t.Errorf("ShmSize = %d, want %d", hc.ShmSize, 1<<30)
+	}
+	if len(hc.Ulimits) != 1 || hc.Ulimits[0].Name != "nofile" || hc.Ulimits[0].Soft != 1024 || hc.Ulimits[0].Hard != 2048 {
+		t.Errorf("Ulimits = %v, want nofile=1024:2048", hc.Ulimits)
+	}
+	if hc.Sysctls["net.ipv4.ip_unprivileged_port_start"] != "0" {
+		t.Errorf("Sysctls = %v", hc.Sysctls)
+	}
+	if len(hc.ExtraHosts) != 2 || hc.ExtraHosts[1] != "db.local:10.0.0.5" {
+		t.Errorf("ExtraHosts = %v, want the blocked host kept and db.local added", hc.ExtraHosts)
+	}
+	if hc.Init == nil || !*hc.Init {
+		t.Errorf("Init = %v, want true", hc.Init)
+	}
+	if len(hc.DeviceRequests) != 1 || hc.DeviceRequests[0].Count != -1 {
+		t.Errorf("DeviceRequests = %v, want all GPUs", hc.DeviceRequests)
+	}
+	if c.Labels["team"] != "infra" {
+		t.Errorf("Labels = %v", c.Labels)
+	}
+}
+
+func TestApplyDockerArgs_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		arg     string
+		wantErr string
+	}{
+		{"unsupported", "--platform=linux/arm64", "not supported"},
+		{"missing value", "--shm-size", "needs a value"},
+		{"bad value", "--shm-size=lots", "invalid docker option --shm-size=lots"},
+		{"reserved label", "--label=com.enclaude.session=true", "reserved"},
+		{"denied", "--cap-add=SYS_ADMIN", "not allowed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := applyDockerArgs(&containerTypes.Config{}, &containerTypes.HostConfig{}, []string{tt.arg})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("applyDockerArgs() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
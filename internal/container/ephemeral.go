@@ -0,0 +1,19 @@
+package container
+
+import "github.com/docker/docker/api/types/mount"
+
+// engineMount returns the engine mount for m. In an ephemeral session bind
+// mounts are read-only and named volumes are replaced by empty tmpfs
+// mounts, so nothing the session writes reaches the host's disk.
+func engineMount(m Mount, ephemeral bool) mount.Mount {
+	if ephemeral {
+		if m.Volume {
+			return mount.Mount{Type: mount.TypeTmpfs, Target: m.Target}
+		}
+		return mount.Mount{Type: mount.TypeBind, Source: m.Source, Target: m.Target, ReadOnly: true}
+	}
+	if m.Volume {
+		return mount.Mount{Type: mount.TypeVolume, Source: m.Source, Target: m.Target, ReadOnly: m.ReadOnly}
+	}
+	return mount.Mount{Type: mount.TypeBind, Source: m.Source, Target: m.Target, ReadOnly: m.ReadOnly}
+}
@@ -0,0 +1,32 @@
+package container
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types/mount"
+)
+
+func TestEngineMount(t *testing.T) {
+	bind := Mount{Source: "/src/app", Target: "/workspace"}
+	volume := Mount{Source: "enclaude-npm", Target: "/home/claude/.npm", Volume: true}
+
+	tests := []struct {
+		name      string
+		m         Mount
+		ephemeral bool
+		want      mount.Mount
+	}{
+		{"bind", bind, false, mount.Mount{Type: mount.TypeBind, Source: "/src/app", Target: "/workspace"}},
+		{"volume", volume, false, mount.Mount{Type: mount.TypeVolume, Source: "enclaude-npm", Target: "/home/claude/.npm"}},
+		{"ephemeral bind", bind, true, mount.Mount{Type: mount.TypeBind, Source: "/src/app", Target: "/workspace", ReadOnly: true}},
+		{"ephemeral volume", volume, true, mount.Mount{Type: mount.TypeTmpfs, Target: "/home/claude/.npm"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := engineMount(tt.m, tt.ephemeral)
+			if got.Type != tt.want.Type || got.Source != tt.want.Source || got.Target != tt.want.Target || got.ReadOnly != tt.want.ReadOnly {
+				t.Errorf("engineMount() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
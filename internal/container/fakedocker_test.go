@@ -0,0 +1,35 @@
+package container
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/client"
+)
+
+// fakeDockerVersion is the API version the fake daemon's client speaks
+const fakeDockerVersion = "1.45"
+
+// fakeDocker returns a Runner whose client talks to handler in place of a
+// Docker daemon. handler sees request paths without the API version prefix.
+func fakeDocker(t *testing.T, handler http.HandlerFunc) *Runner {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.URL.Path = strings.TrimPrefix(r.URL.Path, "/v"+fakeDockerVersion)
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	cli, err := client.NewClientWithOpts(
+		client.WithHost("tcp://"+server.Listener.Addr().String()),
+		client.WithHTTPClient(server.Client()),
+		client.WithVersion(fakeDockerVersion),
+	)
+	if err != nil {
+		t.Fatalf("failed to create Docker client: %v", err)
+	}
+	t.Cleanup(func() { cli.Close() })
+	return &Runner{client: cli}
+}
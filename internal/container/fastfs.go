@@ -0,0 +1,246 @@
+package container
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	containerTypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/strslice"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// Docker Desktop file sharing implementations on macOS. VirtioFS is the
+// fast one; gRPC FUSE and osxfs make every file operation on a bind mount a
+// round trip to the host.
+const (
+	FileSharingVirtioFS = "VirtioFS"
+	FileSharingGRPCFUSE = "gRPC FUSE"
+	FileSharingOSXFS    = "osxfs"
+)
+
+// desktopSettingsFiles are Docker Desktop's settings on macOS, newest first
+var desktopSettingsFiles = []string{
+	"Library/Group Containers/group.com.docker/settings-store.json",
+	"Library/Group Containers/group.com.docker/settings.json",
+}
+
+// DesktopFileSharing reads which file sharing implementation Docker Desktop
+// is configured with from its settings under home. It reports false when
+// there are no settings or they do not say, as newer versions only record
+// settings that differ from the default.
+func DesktopFileSharing(home string) (string, bool) {
+	for _, name := range desktopSettingsFiles {
+		data, err := os.ReadFile(filepath.Join(home, name))
+		if err != nil {
+			continue
+		}
+		var raw map[string]interface{}
+		if json.Unmarshal(data, &raw) != nil {
+			continue
+		}
+		// Key casing differs between settings.json and settings-store.json
+		settings := make(map[string]interface{}, len(raw))
+		for k, v := range raw {
+			settings[strings.ToLower(k)] = v
+		}
+		virtiofs, hasVirtioFS := settings["usevirtualizationframeworkvirtiofs"].(bool)
+		grpcfuse, hasGRPCFUSE := settings["usegrpcfuse"].(bool)
+		switch {
+		case virtiofs:
+			return FileSharingVirtioFS, true
+		case grpcfuse:
+			return FileSharingGRPCFUSE, true
+		case hasVirtioFS && hasGRPCFUSE:
+			return FileSharingOSXFS, true
+		}
+		return "", false
+	}
+	return "", false
+}
+
+// FastFSHostPath is where a --fast-fs session sees the host workspace,
+// which the entrypoint mirrors into the workspace volume
+const FastFSHostPath = "/run/enclaude/fastfs/host"
+
+// fastFSMarker is written to the workspace volume once the entrypoint has
+// mirrored the host workspace into it, listing every path it mirrored. Its
+// modification time is when the mirror was made. Syncing back is refused
+// without it, so a volume that was never filled cannot empty the host
+// workspace, and removes it once the changes are on the host. The
+// entrypoint refuses to mirror over a volume whose marker is still there
+// with changes after it, since they were never copied back.
+const fastFSMarker = ".enclaude-fastfs"
+
+// FastFSEnv are the variables that turn on mirroring in the entrypoint
+func FastFSEnv(workDir string) map[string]string {
+	return map[string]string{
+		"ENCLAUDE_FASTFS_HOST":      FastFSHostPath,
+		"ENCLAUDE_FASTFS_WORKSPACE": workDir,
+		"ENCLAUDE_FASTFS_MARKER":    fastFSMarker,
+	}
+}
+
+// UseFastFS replaces the bind mount of the workspace in opts with volume,
+// and mounts the host workspace at FastFSHostPath for the entrypoint to
+// mirror. It returns the host workspace directory.
+func UseFastFS(opts *RunOptions, volume string) (string, error) {
+	for i, m := range opts.Mounts {
+		if m.Target != opts.WorkDir || m.Volume {
+			continue
+		}
+		opts.Mounts[i] = Mount{Source: volume, Target: opts.WorkDir, Volume: true}
+		opts.Mounts = append(opts.Mounts, Mount{Source: m.Source, Target: FastFSHostPath, Kind: MountDir})
+		if opts.Environment == nil {
+			opts.Environment = make(map[string]string)
+		}
+		for k, v := range FastFSEnv(opts.WorkDir) {
+			opts.Environment[k] = v
+		}
+		return m.Source, nil
+	}
+	return "", fmt.Errorf("--fast-fs needs a bind-mounted workspace")
+}
+
+// fastFSSyncScript copies the session's changes in the workspace volume
+// back to the host once the session has ended: the files it changed or
+// created since the mirror was made, and the deletion of mirrored files it
+// removed. Files the host changed during the session are never overwritten
+// or deleted; they are kept, listed on stderr, and the script exits with
+// fastFSConflictExit, keeping the marker. Paths are read NUL-separated from
+// find and the marker, so any file name is safe.
+const fastFSSyncScript = `cd /src || exit 3
+marker=` + fastFSMarker + `
+[ -e "$marker" ] || { echo "the workspace volume was never filled" >&2; exit 5; }
+command -v rsync >/dev/null || { echo "the image has no rsync, which --fast-fs needs" >&2; exit 3; }
+conflicts=0
+conflict() { echo "${1#./}" >&2; conflicts=1; }
+
+changed=$(mktemp)
+while IFS= read -r -d '' f; do
+    if [ -e "/dst/$f" ] && [ "/dst/$f" -nt "$marker" ]; then conflict "$f"; continue; fi
+    printf '%s\0' "$f" >> "$changed"
+done < <(find . -mindepth 1 ! -type d ! -path "./$marker" -newer "$marker" -print0)
+rsync -a --from0 --files-from="$changed" ./ /dst/ || exit 1
+
+dirs=()
+while IFS= read -r -d '' f; do
+    [ -e "$f" ] || [ -L "$f" ] && continue
+    [ -e "/dst/$f" ] || [ -L "/dst/$f" ] || continue
+    if [ -d "/dst/$f" ] && [ ! -L "/dst/$f" ]; then dirs=("/dst/$f" "${dirs[@]}"); continue; fi
+    if [ "/dst/$f" -nt "$marker" ]; then conflict "$f"; continue; fi
+    rm -f "/dst/$f"
+done < "$marker"
+for d in "${dirs[@]}"; do rmdir "$d" 2>/dev/null; done
+[ "$conflicts" = 0 ] || exit 4
+rm -f "$marker"`
+
+// Exit statuses of fastFSSyncScript when it kept host versions of files
+// changed on both sides, and when there was no mirror to sync
+const (
+	fastFSConflictExit  = 4
+	fastFSNotFilledExit = 5
+)
+
+// ErrFastFSNotFilled is returned by SyncFastFS for a workspace volume with
+// nothing to copy back: new, or already synced
+var ErrFastFSNotFilled = errors.New("the workspace volume holds no mirror to copy back")
+
+// PrepareFastFS hands the workspace volume to the session user, since a new
+// volume mounted where the image has no world-writable directory belongs to
+// root
+func (r *Runner) PrepareFastFS(ctx context.Context, opts RunOptions, volume string) error {
+	user := r.sessionUser(ctx, opts.User, opts.Image)
+	if uid, _, _ := strings.Cut(user, ":"); uid == "" || uid == "0" {
+		return nil
+	}
+	return r.runFastFSHelper(ctx, opts.Image, "0:0", []string{"chown", user, "/dst"},
+		[]mount.Mount{{Type: mount.TypeVolume, Source: volume, Target: "/dst"}})
+}
+
+// SyncFastFS copies the changes a --fast-fs session made in its workspace
+// volume back to hostDir from a throwaway container, once the session has
+// ended, or before the next one starts if that failed. Conflicts with
+// changes made on the host are reported in the error. Until it succeeds, the
+// entrypoint refuses to mirror the host workspace over the volume.
+func (r *Runner) SyncFastFS(ctx context.Context, opts RunOptions, volume, hostDir string) error {
+	err := r.runFastFSHelper(ctx, opts.Image, r.sessionUser(ctx, opts.User, opts.Image), []string{"/bin/bash", "-c", fastFSSyncScript},
+		[]mount.Mount{
+			{Type: mount.TypeVolume, Source: volume, Target: "/src"},
+			{Type: mount.TypeBind, Source: hostDir, Target: "/dst"},
+		})
+	var helperErr *helperError
+	if errors.As(err, &helperErr) && helperErr.code == fastFSNotFilledExit {
+		return ErrFastFSNotFilled
+	}
+	if errors.As(err, &helperErr) && helperErr.code == fastFSConflictExit {
+		files := strings.ReplaceAll(helperErr.stderr, "\n", "\n  ")
+		return fmt.Errorf("kept the host's version of files changed both in the session and on the host since it started; the session's versions are still in the volume %s, and --fast-fs sessions in this workspace will not start until you copy out what you need and remove it with 'enclaude cache clear %s':\n  %s", volume, volume, files)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to sync the workspace volume %s back to %s: %w", volume, hostDir, err)
+	}
+	return nil
+}
+
+// helperError is the failure of a helper container, with its exit status
+// and stderr
+type helperError struct {
+	code   int
+	stderr string
+}
+
+func (e *helperError) Error() string {
+	if e.stderr == "" {
+		return fmt.Sprintf("helper exited with status %d", e.code)
+	}
+	return e.stderr
+}
+
+// runFastFSHelper runs command in a throwaway container without network,
+// bypassing the image's entrypoint, and returns its stderr on failure
+func (r *Runner) runFastFSHelper(ctx context.Context, image, user string, command []string, mounts []mount.Mount) error {
+	containerConfig := &containerTypes.Config{
+		Image:      image,
+		Entrypoint: strslice.StrSlice(command),
+		User:       user,
+	}
+	hostConfig := &containerTypes.HostConfig{Mounts: mounts, NetworkMode: "none"}
+
+	resp, err := r.client.ContainerCreate(ctx, containerConfig, hostConfig, nil, nil, "")
+	if err != nil {
+		return fmt.Errorf("failed to create helper container: %w", err)
+	}
+	defer func() {
+		_ = r.client.ContainerRemove(context.Background(), resp.ID, containerTypes.RemoveOptions{Force: true})
+	}()
+
+	if err := r.client.ContainerStart(ctx, resp.ID, containerTypes.StartOptions{}); err != nil {
+		return fmt.Errorf("failed to start helper container: %w", err)
+	}
+
+	var code int64
+	statusCh, errCh := r.client.ContainerWait(ctx, resp.ID, containerTypes.WaitConditionNotRunning)
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("error waiting for helper container: %w", err)
+	case status := <-statusCh:
+		if status.StatusCode == 0 {
+			return nil
+		}
+		code = status.StatusCode
+	}
+
+	var stderr bytes.Buffer
+	if logs, err := r.client.ContainerLogs(ctx, resp.ID, containerTypes.LogsOptions{ShowStderr: true}); err == nil {
+		_, _ = stdcopy.StdCopy(&stderr, &stderr, logs)
+		logs.Close()
+	}
+	return &helperError{code: int(code), stderr: strings.TrimSpace(stderr.String())}
+}
@@ -0,0 +1,192 @@
+package container
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDesktopFileSharing(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		settings string
+		want     string
+		wantOK   bool
+	}{
+		{"virtiofs", "settings-store.json", `{"UseVirtualizationFrameworkVirtioFS": true, "UseGrpcfuse": false}`, FileSharingVirtioFS, true},
+		{"grpc fuse", "settings.json", `{"useVirtualizationFrameworkVirtioFS": false, "useGrpcfuse": true}`, FileSharingGRPCFUSE, true},
+		{"osxfs", "settings.json", `{"useVirtualizationFrameworkVirtioFS": false, "useGrpcfuse": false}`, FileSharingOSXFS, true},
+		{"default not recorded", "settings-store.json", `{"AutoStart": true}`, "", false},
+		{"invalid json", "settings.json", `{`, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			home := t.TempDir()
+			dir := filepath.Join(home, "Library", "Group Containers", "group.com.docker")
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(dir, tt.file), []byte(tt.settings), 0644); err != nil {
+				t.Fatal(err)
+			}
+			got, ok := DesktopFileSharing(home)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("DesktopFileSharing() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+
+	if _, ok := DesktopFileSharing(t.TempDir()); ok {
+		t.Error("DesktopFileSharing() without settings should report false")
+	}
+}
+
+func TestUseFastFS(t *testing.T) {
+	opts := RunOptions{
+		WorkDir: "/workspace",
+		Mounts: []Mount{
+			{Source: "/home/user/.gitconfig", Target: "/home/claude/.gitconfig", ReadOnly: true},
+			{Source: "/home/user/project", Target: "/workspace"},
+		},
+	}
+
+	hostDir, err := UseFastFS(&opts, "enclaude-fastfs-abc")
+	if err != nil {
+		t.Fatalf("UseFastFS() error = %v", err)
+	}
+	if hostDir != "/home/user/project" {
+		t.Errorf("UseFastFS() = %q, want /home/user/project", hostDir)
+	}
+	if m := opts.Mounts[1]; !m.Volume || m.Source != "enclaude-fastfs-abc" || m.Target != "/workspace" {
+		t.Errorf("workspace mount = %+v, want the volume", m)
+	}
+	if m := opts.Mounts[2]; m.Volume || m.Source != "/home/user/project" || m.Target != FastFSHostPath {
+		t.Errorf("host mount = %+v, want the workspace at %s", m, FastFSHostPath)
+	}
+	if opts.Environment["ENCLAUDE_FASTFS_WORKSPACE"] != "/workspace" {
+		t.Errorf("ENCLAUDE_FASTFS_WORKSPACE = %q, want /workspace", opts.Environment["ENCLAUDE_FASTFS_WORKSPACE"])
+	}
+
+	volumeOnly := RunOptions{WorkDir: "/workspace", Mounts: []Mount{{Source: "vol", Target: "/workspace", Volume: true}}}
+	if _, err := UseFastFS(&volumeOnly, "enclaude-fastfs-abc"); err == nil {
+		t.Error("UseFastFS() without a bind-mounted workspace should fail")
+	}
+}
+
+// fakeRsync stands in for rsync in the fast-fs scripts: it copies the
+// --files-from list, or the whole source directory
+const fakeRsync = `#!/bin/bash
+list=
+for a; do case $a in --files-from=*) list=${a#--files-from=};; esac; done
+src=${@: -2:1}; dst=${@: -1}
+if [ -n "$list" ]; then
+    while IFS= read -r -d '' f; do mkdir -p "$dst/$(dirname "$f")"; cp -a "$src/$f" "$dst/$f"; done < "$list"
+else
+    cp -a "$src/." "$dst/"
+fi
+`
+
+func TestFastFSUnsyncedChanges(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not installed")
+	}
+	entrypoint, err := os.ReadFile("../../docker/entrypoint.sh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := strings.Index(string(entrypoint), "# Mirror the host workspace into the workspace volume")
+	end := strings.Index(string(entrypoint)[start:], "\nfi\n")
+	if start < 0 || end < 0 {
+		t.Fatal("fast-fs mirror not found in the entrypoint")
+	}
+	mirrorScript := string(entrypoint)[start : start+end+4]
+
+	bin, host, volume := t.TempDir(), t.TempDir(), t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "rsync"), []byte(fakeRsync), 0755); err != nil {
+		t.Fatal(err)
+	}
+	env := append(os.Environ(), "PATH="+bin+":"+os.Getenv("PATH"))
+	for k, v := range FastFSEnv(volume) {
+		env = append(env, k+"="+v)
+	}
+	env = append(env, "ENCLAUDE_FASTFS_HOST="+host)
+	run := func(script string) (int, string) {
+		t.Helper()
+		cmd := exec.Command(bash, "-c", script)
+		cmd.Env = env
+		out, err := cmd.CombinedOutput()
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode(), string(out)
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		return 0, string(out)
+	}
+	mirror := func() (int, string) { return run("set -e\n" + mirrorScript) }
+	syncBack := func() (int, string) {
+		return run(strings.NewReplacer("/src", volume, "/dst", host).Replace(fastFSSyncScript))
+	}
+	marker := filepath.Join(volume, fastFSMarker)
+	touch := func(path string, after time.Duration) {
+		t.Helper()
+		info, err := os.Stat(marker)
+		if err != nil {
+			t.Fatal(err)
+		}
+		when := info.ModTime().Add(after)
+		if err := os.Chtimes(path, when, when); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	os.WriteFile(filepath.Join(host, "a.txt"), []byte("host\n"), 0644)
+	if code, out := mirror(); code != 0 {
+		t.Fatalf("mirror: exit %d: %s", code, out)
+	}
+	// A mirror without changes since, such as a restarted container, is
+	// mirrored again
+	if code, out := mirror(); code != 0 {
+		t.Fatalf("mirror without changes: exit %d: %s", code, out)
+	}
+
+	// The session and the host both change a.txt, so syncing back fails
+	os.WriteFile(filepath.Join(volume, "a.txt"), []byte("session\n"), 0644)
+	touch(filepath.Join(volume, "a.txt"), time.Hour)
+	os.WriteFile(filepath.Join(host, "a.txt"), []byte("host edit\n"), 0644)
+	touch(filepath.Join(host, "a.txt"), time.Hour)
+	if code, out := syncBack(); code != fastFSConflictExit || !strings.Contains(out, "a.txt") {
+		t.Fatalf("sync with a conflict: exit %d: %s", code, out)
+	}
+
+	// The next session refuses to mirror over the unsynced change
+	if code, out := mirror(); code == 0 || !strings.Contains(out, "not copied back") {
+		t.Errorf("mirror over unsynced changes: exit %d: %s", code, out)
+	}
+	if data, _ := os.ReadFile(filepath.Join(volume, "a.txt")); string(data) != "session\n" {
+		t.Errorf("session's a.txt = %q, want it kept", data)
+	}
+
+	// Once the conflict is gone the change is copied back, the marker is
+	// removed, and the volume is mirrored again
+	touch(filepath.Join(host, "a.txt"), -time.Hour)
+	if code, out := syncBack(); code != 0 {
+		t.Fatalf("sync: exit %d: %s", code, out)
+	}
+	if data, _ := os.ReadFile(filepath.Join(host, "a.txt")); string(data) != "session\n" {
+		t.Errorf("host a.txt = %q, want the session's version", data)
+	}
+	if code, _ := syncBack(); code != fastFSNotFilledExit {
+		t.Errorf("sync after syncing: exit %d, want %d", code, fastFSNotFilledExit)
+	}
+	if code, out := mirror(); code != 0 {
+		t.Errorf("mirror after syncing: exit %d: %s", code, out)
+	}
+}
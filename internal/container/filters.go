@@ -0,0 +1,298 @@
+package container
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Output filters, applied in order to the non-TTY output stream and the tee
+// file
+const (
+	FilterStripANSI      = "strip-ansi"      // Drop escape sequences and control characters
+	FilterHostPaths      = "host-paths"      // Rewrite workspace paths to the host workspace
+	FilterHighlightPaths = "highlight-paths" // Underline file paths
+	filterCommandPrefix  = "cmd:"            // "cmd:<command>" pipes output through a host shell command
+)
+
+// OutputFilter is one step of the output post-processing pipeline
+type OutputFilter struct {
+	Kind    string
+	Command string // Shell command, for "cmd:" filters
+}
+
+// ParseOutputFilter parses an entry of output.filters: one of the built-in
+// filters or "cmd:<command>"
+func ParseOutputFilter(spec string) (OutputFilter, error) {
+	spec = strings.TrimSpace(spec)
+	switch spec {
+	case FilterStripANSI, FilterHostPaths, FilterHighlightPaths:
+		return OutputFilter{Kind: spec}, nil
+	}
+	if command, ok := strings.CutPrefix(spec, filterCommandPrefix); ok {
+		if command = strings.TrimSpace(command); command == "" {
+			return OutputFilter{}, fmt.Errorf("output filter %q has no command", spec)
+		}
+		return OutputFilter{Kind: filterCommandPrefix, Command: command}, nil
+	}
+	return OutputFilter{}, fmt.Errorf("unknown output filter %q (allowed: %s, %s, %s, cmd:<command>)", spec, FilterStripANSI, FilterHostPaths, FilterHighlightPaths)
+}
+
+// IsCommandFilter reports whether an entry of output.filters runs a host
+// command
+func IsCommandFilter(spec string) bool {
+	return strings.HasPrefix(strings.TrimSpace(spec), filterCommandPrefix)
+}
+
+// newOutputFilters chains filters in front of out, so the first filter sees
+// the output first. Closing the returned writer flushes every filter and
+// waits for filter commands to exit; it does not close out.
+func newOutputFilters(out io.Writer, filters []OutputFilter, workDir, hostDir string) (io.WriteCloser, error) {
+	chain := &filterChain{}
+	w := out
+	for i := len(filters) - 1; i >= 0; i-- {
+		f := filters[i]
+		var next io.WriteCloser
+		switch f.Kind {
+		case FilterStripANSI:
+			s := &ansiStripper{}
+			next = newLineFilter(w, s.strip)
+		case FilterHostPaths:
+			next = newLineFilter(w, func(line []byte) []byte { return hostPaths(line, workDir, hostDir) })
+		case FilterHighlightPaths:
+			next = newLineFilter(w, highlightPaths)
+		case filterCommandPrefix:
+			cmd, err := startFilterCommand(w, f.Command)
+			if err != nil {
+				chain.Close()
+				return nil, err
+			}
+			next = cmd
+		default:
+			chain.Close()
+			return nil, fmt.Errorf("unknown output filter %q", f.Kind)
+		}
+		chain.stages = append(chain.stages, next)
+		w = next
+	}
+	chain.head = w
+	return chain, nil
+}
+
+// closeFilters flushes output filters once the session has ended, reporting
+// a failed filter command
+func closeFilters(filters io.Closer) {
+	if err := filters.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
+
+// filterChain writes to the first filter and closes them first to last, so
+// each flushes into the next before that one closes
+type filterChain struct {
+	head   io.Writer
+	stages []io.WriteCloser // Last filter first
+}
+
+func (c *filterChain) Write(p []byte) (int, error) {
+	return c.head.Write(p)
+}
+
+func (c *filterChain) Close() error {
+	var first error
+	for i := len(c.stages) - 1; i >= 0; i-- {
+		if err := c.stages[i].Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// lineFilter applies a transformation to each complete line, holding back a
+// partial line until its newline or Close. It is safe for concurrent use, as
+// stdout and stderr share the tee file.
+type lineFilter struct {
+	mu      sync.Mutex
+	out     io.Writer
+	apply   func([]byte) []byte
+	pending []byte
+}
+
+func newLineFilter(out io.Writer, apply func([]byte) []byte) *lineFilter {
+	return &lineFilter{out: out, apply: apply}
+}
+
+func (f *lineFilter) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pending = append(f.pending, p...)
+	end := bytes.LastIndexByte(f.pending, '\n')
+	if end < 0 {
+		return len(p), nil
+	}
+	lines := f.pending[:end+1]
+	var buf []byte
+	for len(lines) > 0 {
+		i := bytes.IndexByte(lines, '\n')
+		buf = append(buf, f.apply(lines[:i+1])...)
+		lines = lines[i+1:]
+	}
+	f.pending = append([]byte(nil), f.pending[end+1:]...)
+	if _, err := f.out.Write(buf); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close writes out a trailing partial line
+func (f *lineFilter) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.pending) == 0 {
+		return nil
+	}
+	_, err := f.out.Write(f.apply(f.pending))
+	f.pending = nil
+	return err
+}
+
+// ansiStripper drops escape sequences, carriage returns, and other control
+// characters, keeping newlines and tabs. Sequences may span writes.
+type ansiStripper struct {
+	esc int
+}
+
+func (s *ansiStripper) strip(p []byte) []byte {
+	text := make([]byte, 0, len(p))
+	for _, c := range p {
+		switch {
+		case s.esc != escNone:
+			s.esc = advanceEscape(s.esc, c)
+		case c == 0x1b:
+			s.esc = escStart
+		case c == '\n' || c == '\t' || (c >= 0x20 && c != 0x7f):
+			text = append(text, c)
+		}
+	}
+	return text
+}
+
+// hostPaths rewrites paths under the container workspace in line to the
+// host workspace. Only whole path components match, so /workspace2 is left
+// alone.
+func hostPaths(line []byte, workDir, hostDir string) []byte {
+	workDir = strings.TrimRight(workDir, "/")
+	if workDir == "" || hostDir == "" || workDir == hostDir {
+		return line
+	}
+	prefix := []byte(workDir)
+	var out []byte
+	for {
+		i := bytes.Index(line, prefix)
+		if i < 0 {
+			return append(out, line...)
+		}
+		end := i + len(prefix)
+		startsPath := i == 0 || !isPathByte(line[i-1]) || line[i-1] == ':'
+		endsComponent := end == len(line) || line[end] == '/' || !isPathByte(line[end]) || line[end] == ':'
+		out = append(out, line[:i]...)
+		if startsPath && endsComponent {
+			out = append(out, hostDir...)
+		} else {
+			out = append(out, prefix...)
+		}
+		line = line[end:]
+	}
+}
+
+// filePath matches absolute and relative file paths of at least two
+// components, with an optional :line or :line:col location
+var filePath = regexp.MustCompile(`(?:~|\.{1,2})?/?[A-Za-z0-9._+@-]+(?:/[A-Za-z0-9._+@-]+)+(?::\d+(?::\d+)?)?`)
+
+// highlightPaths underlines file paths in line. Text inside escape sequences
+// is not inspected, so existing styling is kept.
+func highlightPaths(line []byte) []byte {
+	var out []byte
+	for len(line) > 0 {
+		esc := bytes.IndexByte(line, 0x1b)
+		text := line
+		if esc >= 0 {
+			text = line[:esc]
+		}
+		out = append(out, filePath.ReplaceAll(text, []byte("\x1b[4m$0\x1b[24m"))...)
+		if esc < 0 {
+			break
+		}
+		line = line[esc:]
+		n := escapeLength(line)
+		out = append(out, line[:n]...)
+		line = line[n:]
+	}
+	return out
+}
+
+// escapeLength returns the length of the escape sequence at the start of p,
+// or all of p if it is incomplete
+func escapeLength(p []byte) int {
+	state := escStart
+	for i := 1; i < len(p); i++ {
+		if state = advanceEscape(state, p[i]); state == escNone {
+			return i + 1
+		}
+	}
+	return len(p)
+}
+
+// filterCommand pipes output through a host shell command. If the command
+// exits early, the rest of the output bypasses it rather than being lost.
+type filterCommand struct {
+	mu      sync.Mutex
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	out     io.Writer
+	command string
+	failed  bool
+}
+
+func startFilterCommand(out io.Writer, command string) (*filterCommand, error) {
+	cmd := exec.Command("/bin/sh", "-c", command)
+	cmd.Stdout = out
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start output filter %q: %w", command, err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start output filter %q: %w", command, err)
+	}
+	return &filterCommand{cmd: cmd, stdin: stdin, out: out, command: command}, nil
+}
+
+func (f *filterCommand) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.failed {
+		if _, err := f.stdin.Write(p); err == nil {
+			return len(p), nil
+		}
+		f.failed = true
+		fmt.Fprintf(os.Stderr, "\r\nenclaude: output filter %q stopped; passing output through unfiltered\r\n", f.command)
+	}
+	return f.out.Write(p)
+}
+
+// Close ends the command's input and waits for it to write its last output
+func (f *filterCommand) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.stdin.Close()
+	if err := f.cmd.Wait(); err != nil && !f.failed {
+		return fmt.Errorf("output filter %q failed: %w", f.command, err)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package container
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestParseOutputFilter(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    OutputFilter
+		wantErr bool
+	}{
+		{spec: "strip-ansi", want: OutputFilter{Kind: FilterStripANSI}},
+		{spec: " host-paths ", want: OutputFilter{Kind: FilterHostPaths}},
+		{spec: "cmd: grep -v DEBUG", want: OutputFilter{Kind: "cmd:", Command: "grep -v DEBUG"}},
+		{spec: "cmd:", wantErr: true},
+		{spec: "uppercase", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseOutputFilter(tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseOutputFilter(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseOutputFilter(%q) = %+v, want %+v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestOutputFilters(t *testing.T) {
+	filters := []OutputFilter{{Kind: FilterStripANSI}, {Kind: FilterHostPaths}}
+	var out bytes.Buffer
+	w, err := newOutputFilters(&out, filters, "/workspace", "/home/user/project")
+	if err != nil {
+		t.Fatalf("newOutputFilters() error = %v", err)
+	}
+
+	// Lines arrive split across writes, with an escape sequence spanning them
+	w.Write([]byte("\x1b[1mEdited /workspace/main.go:12\x1b"))
+	w.Write([]byte("[0m\nsee /workspace2/x and /workspace\nlast"))
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	want := "Edited /home/user/project/main.go:12\nsee /workspace2/x and /home/user/project\nlast"
+	if out.String() != want {
+		t.Errorf("filtered output = %q, want %q", out.String(), want)
+	}
+}
+
+func TestHighlightPaths(t *testing.T) {
+	got := string(highlightPaths([]byte("\x1b[32mok\x1b[0m src/main.go:3 done\n")))
+	want := "\x1b[32mok\x1b[0m \x1b[4msrc/main.go:3\x1b[24m done\n"
+	if got != want {
+		t.Errorf("highlightPaths() = %q, want %q", got, want)
+	}
+}
+
+func TestOutputFilterCommand(t *testing.T) {
+	var out bytes.Buffer
+	w, err := newOutputFilters(&out, []OutputFilter{{Kind: "cmd:", Command: "tr a-z A-Z"}}, "/workspace", "/host")
+	if err != nil {
+		t.Fatalf("newOutputFilters() error = %v", err)
+	}
+	w.Write([]byte("hello\n"))
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if out.String() != "HELLO\n" {
+		t.Errorf("filtered output = %q, want %q", out.String(), "HELLO\n")
+	}
+}
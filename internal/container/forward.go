@@ -0,0 +1,67 @@
+package container
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+
+	containerTypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// forwardScript connects its stdin and stdout to a TCP port on the
+// container's loopback interface. Node is used because every image that runs
+// claude has it.
+const forwardScript = `const s = require("net").connect(+process.argv[1], "127.0.0.1");
+process.stdin.pipe(s);
+s.pipe(process.stdout);
+s.on("error", () => process.exit(1));
+s.on("close", () => process.exit(0));`
+
+// ForwardPort listens on port on the host's loopback interface and relays
+// each connection to the same port inside the container through docker exec,
+// so the host can reach a server that listens only on the container's
+// localhost, such as an OAuth callback. Close the listener to stop.
+func (r *Runner) ForwardPort(ctx context.Context, containerID string, port int) (net.Listener, error) {
+	l, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to forward port %d: %w", port, err)
+	}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go r.relayConn(ctx, containerID, port, conn)
+		}
+	}()
+	return l, nil
+}
+
+// relayConn copies one forwarded connection to and from the container port
+func (r *Runner) relayConn(ctx context.Context, containerID string, port int, conn net.Conn) {
+	defer conn.Close()
+	created, err := r.client.ContainerExecCreate(ctx, containerID, containerTypes.ExecOptions{
+		Cmd:          []string{"node", "-e", forwardScript, strconv.Itoa(port)},
+		AttachStdin:  true,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return
+	}
+	resp, err := r.client.ContainerExecAttach(ctx, created.ID, containerTypes.ExecAttachOptions{})
+	if err != nil {
+		return
+	}
+	defer resp.Close()
+
+	go func() {
+		io.Copy(resp.Conn, conn)
+		resp.CloseWrite()
+	}()
+	stdcopy.StdCopy(conn, io.Discard, resp.Reader)
+}
@@ -0,0 +1,222 @@
+package container
+
+import (
+	"io"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// linkHoldback is how long a possible path at the end of an output chunk is
+// held back waiting for the rest of it before being written as plain text
+const linkHoldback = 30 * time.Millisecond
+
+// maxLinkCandidate bounds how much text is held back as a possible path
+const maxLinkCandidate = 4096
+
+// lineSuffix matches a trailing ":line" or ":line:col" location
+var lineSuffix = regexp.MustCompile(`:(\d+)(?::(\d+))?$`)
+
+// Escape sequence parser states
+const (
+	escNone = iota
+	escStart
+	escCSI
+	escOSC
+	escOSCEnd
+)
+
+// SupportsHyperlinks reports whether the host terminal is known to render
+// OSC 8 hyperlinks
+func SupportsHyperlinks() bool {
+	switch os.Getenv("TERM_PROGRAM") {
+	case "iTerm.app", "WezTerm", "vscode":
+		return true
+	}
+	return os.Getenv("TERM") == "xterm-kitty" || os.Getenv("KITTY_WINDOW_ID") != "" || os.Getenv("WEZTERM_PANE") != ""
+}
+
+// linkWriter rewrites references to container paths under containerDir in a
+// terminal stream into OSC 8 hyperlinks to the matching host path. The
+// visible text is left unchanged so the TUI layout is unaffected, and escape
+// sequences pass through untouched.
+type linkWriter struct {
+	mu           sync.Mutex
+	out          io.Writer
+	containerDir string
+	hostDir      string
+	format       string
+	hostname     string
+
+	esc       int
+	prev      byte
+	candidate []byte
+	timer     *time.Timer
+}
+
+// newLinkWriter creates a linkWriter. format is a URL template with {path},
+// {line}, {col}, and {host} placeholders; empty means a file:// URL.
+func newLinkWriter(out io.Writer, containerDir, hostDir, format string) *linkWriter {
+	hostname, _ := os.Hostname()
+	return &linkWriter{
+		out:          out,
+		containerDir: strings.TrimRight(containerDir, "/") + "/",
+		hostDir:      strings.TrimRight(hostDir, "/"),
+		format:       format,
+		hostname:     hostname,
+	}
+}
+
+func (w *linkWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+
+	var buf []byte
+	for _, c := range p {
+		if w.esc != escNone {
+			buf = append(buf, c)
+			// Escape sequences pass through so their contents are never rewritten
+			w.esc = advanceEscape(w.esc, c)
+			continue
+		}
+
+		if len(w.candidate) > 0 {
+			if isPathByte(c) && len(w.candidate) < maxLinkCandidate {
+				w.candidate = append(w.candidate, c)
+				if !w.mayMatch() {
+					buf = append(buf, w.candidate...)
+					w.candidate = nil
+				}
+				w.prev = c
+				continue
+			}
+			buf = append(buf, w.finishCandidate()...)
+		}
+
+		switch {
+		case c == 0x1b:
+			w.esc = escStart
+			buf = append(buf, c)
+		case c == '/' && !isPathByte(w.prev):
+			w.candidate = append(w.candidate, c)
+		default:
+			buf = append(buf, c)
+		}
+		w.prev = c
+	}
+
+	if len(w.candidate) > 0 {
+		w.timer = time.AfterFunc(linkHoldback, func() { w.Flush() })
+	}
+
+	if _, err := w.out.Write(buf); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush writes out any text held back as a possible path
+func (w *linkWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.candidate) > 0 {
+		w.out.Write(w.finishCandidate())
+	}
+}
+
+// advanceEscape returns the escape sequence parser state after c
+func advanceEscape(state int, c byte) int {
+	switch state {
+	case escStart:
+		switch c {
+		case '[':
+			return escCSI
+		case ']':
+			return escOSC
+		}
+		return escNone
+	case escCSI:
+		if c >= 0x40 && c <= 0x7e {
+			return escNone
+		}
+	case escOSC:
+		switch c {
+		case 0x07:
+			return escNone
+		case 0x1b:
+			return escOSCEnd
+		}
+	case escOSCEnd:
+		return escNone
+	}
+	return state
+}
+
+// mayMatch reports whether the candidate could still become a path under containerDir
+func (w *linkWriter) mayMatch() bool {
+	n := len(w.candidate)
+	if n <= len(w.containerDir) {
+		return strings.HasPrefix(w.containerDir, string(w.candidate))
+	}
+	return strings.HasPrefix(string(w.candidate), w.containerDir)
+}
+
+// finishCandidate returns the held candidate, wrapped in a hyperlink if it is
+// a path under containerDir, and clears it
+func (w *linkWriter) finishCandidate() []byte {
+	text := string(w.candidate)
+	w.candidate = nil
+
+	if !strings.HasPrefix(text, w.containerDir) || len(text) == len(w.containerDir) {
+		return []byte(text)
+	}
+
+	// Trailing punctuation is part of the sentence, not the path
+	trimmed := strings.TrimRight(text, ".,:")
+	trailing := text[len(trimmed):]
+
+	path, line, col := trimmed, "", ""
+	if m := lineSuffix.FindStringSubmatchIndex(trimmed); m != nil {
+		path = trimmed[:m[0]]
+		line = trimmed[m[2]:m[3]]
+		if m[4] >= 0 {
+			col = trimmed[m[4]:m[5]]
+		}
+	}
+
+	hostPath := w.hostDir + "/" + strings.TrimPrefix(path, w.containerDir)
+	target := w.linkTarget(hostPath, line, col)
+	return []byte("\x1b]8;;" + target + "\x1b\\" + trimmed + "\x1b]8;;\x1b\\" + trailing)
+}
+
+// linkTarget renders the hyperlink URL for a host path and optional location
+func (w *linkWriter) linkTarget(path, line, col string) string {
+	if w.format == "" {
+		return (&url.URL{Scheme: "file", Host: w.hostname, Path: path}).String()
+	}
+
+	target := w.format
+	if col == "" {
+		target = strings.ReplaceAll(target, ":{col}", "")
+	}
+	if line == "" {
+		target = strings.ReplaceAll(target, ":{line}", "")
+	}
+	return strings.NewReplacer("{path}", path, "{line}", line, "{col}", col, "{host}", w.hostname).Replace(target)
+}
+
+// isPathByte reports whether c can appear in a path reference
+func isPathByte(c byte) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		return true
+	}
+	return strings.IndexByte("/._-+@~:", c) >= 0
+}
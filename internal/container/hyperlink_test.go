@@ -0,0 +1,62 @@
+package container
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestLinkWriter(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		chunks []string
+		want   string
+	}{
+		{
+			name:   "path with line",
+			format: "vscode://file{path}:{line}:{col}",
+			chunks: []string{"see /workspace/main.go:42 now"},
+			want:   "see \x1b]8;;vscode://file/home/u/app/main.go:42\x1b\\/workspace/main.go:42\x1b]8;;\x1b\\ now",
+		},
+		{
+			name:   "split across writes with trailing period",
+			format: "editor://{path}",
+			chunks: []string{"edit /work", "space/pkg/a.go."},
+			want:   "edit \x1b]8;;editor:///home/u/app/pkg/a.go\x1b\\/workspace/pkg/a.go\x1b]8;;\x1b\\.",
+		},
+		{
+			name:   "other paths untouched",
+			format: "editor://{path}",
+			chunks: []string{"/usr/bin/go and foo/workspace/x"},
+			want:   "/usr/bin/go and foo/workspace/x",
+		},
+		{
+			name:   "escape sequences untouched",
+			format: "editor://{path}",
+			chunks: []string{"\x1b]8;;file:///workspace/a.go\x1b\\link\x1b]8;;\x1b\\"},
+			want:   "\x1b]8;;file:///workspace/a.go\x1b\\link\x1b]8;;\x1b\\",
+		},
+		{
+			name:   "workspace root alone",
+			format: "editor://{path}",
+			chunks: []string{"cd /workspace/ ok"},
+			want:   "cd /workspace/ ok",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			w := newLinkWriter(&out, "/workspace", "/home/u/app", tt.format)
+			for _, chunk := range tt.chunks {
+				if _, err := w.Write([]byte(chunk)); err != nil {
+					t.Fatalf("Write() error = %v", err)
+				}
+			}
+			w.Flush()
+			if got := out.String(); got != tt.want {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package container
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	containerTypes "github.com/docker/docker/api/types/container"
+)
+
+// cpuPeriod is the CFS period, in microseconds, of the idle CPU quota of
+// sessions without a CPU limit
+const cpuPeriod = 100000
+
+// idleResources returns the CPU limit to apply while a session is idle and
+// the one that restores its own. A session created with a CPU limit keeps
+// using NanoCPUs; one without gets a CFS quota instead, which Docker can
+// lift again, unlike NanoCPUs.
+func idleResources(cpus, idleCPUs string) (shrink, restore containerTypes.Resources, err error) {
+	idle, err := strconv.ParseFloat(idleCPUs, 64)
+	if err != nil || idle <= 0 {
+		return shrink, restore, fmt.Errorf("invalid idle cpus %q: must be a positive number", idleCPUs)
+	}
+	if cpus != "" {
+		limit, err := strconv.ParseFloat(cpus, 64)
+		if err != nil || limit <= 0 {
+			return shrink, restore, fmt.Errorf("invalid cpus %q: must be a positive number", cpus)
+		}
+		return containerTypes.Resources{NanoCPUs: int64(idle * 1e9)}, containerTypes.Resources{NanoCPUs: int64(limit * 1e9)}, nil
+	}
+	shrink = containerTypes.Resources{CPUPeriod: cpuPeriod, CPUQuota: int64(idle * cpuPeriod)}
+	restore = containerTypes.Resources{CPUQuota: -1}
+	return shrink, restore, nil
+}
+
+// idleWatch records when the session last had terminal input or output
+type idleWatch struct {
+	last   atomic.Int64 // Unix nanoseconds
+	shrunk atomic.Bool
+	wake   chan struct{} // Signalled on activity while shrunk
+}
+
+func newIdleWatch() *idleWatch {
+	w := &idleWatch{wake: make(chan struct{}, 1)}
+	w.touch()
+	return w
+}
+
+// touch records activity, waking a shrunk session
+func (w *idleWatch) touch() {
+	w.last.Store(time.Now().UnixNano())
+	if w.shrunk.Load() {
+		select {
+		case w.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// idleFor returns how long the session has been without activity
+func (w *idleWatch) idleFor() time.Duration {
+	return time.Since(time.Unix(0, w.last.Load()))
+}
+
+// writer returns out, recording each write as activity
+func (w *idleWatch) writer(out io.Writer) io.Writer {
+	return idleWriter{out: out, watch: w}
+}
+
+type idleWriter struct {
+	out   io.Writer
+	watch *idleWatch
+}
+
+func (iw idleWriter) Write(p []byte) (int, error) {
+	iw.watch.touch()
+	return iw.out.Write(p)
+}
+
+// shrinkWhenIdle applies shrink through update once the session has been
+// idle for after, and restore on its next activity, until ctx is done. A
+// failed update ends the watch, leaving the session as it was.
+func (w *idleWatch) shrinkWhenIdle(ctx context.Context, after time.Duration, shrink, restore containerTypes.Resources, update func(containerTypes.Resources) error) {
+	timer := time.NewTimer(after)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+		if idle := w.idleFor(); idle < after {
+			timer.Reset(after - idle)
+			continue
+		}
+
+		w.shrunk.Store(true)
+		if err := update(shrink); err != nil {
+			fmt.Fprintf(os.Stderr, "[enclaude] failed to lower the CPU limit of the idle session: %v\r\n", err)
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.wake:
+		}
+		w.shrunk.Store(false)
+		select {
+		case <-w.wake:
+		default:
+		}
+		if err := update(restore); err != nil {
+			fmt.Fprintf(os.Stderr, "[enclaude] failed to restore the CPU limit of the session: %v\r\n", err)
+			return
+		}
+		timer.Reset(after)
+	}
+}
+
+// updateResources changes the CPU limit of a running container
+func (r *Runner) updateResources(containerID string) func(containerTypes.Resources) error {
+	return func(res containerTypes.Resources) error {
+		_, err := r.client.ContainerUpdate(context.Background(), containerID, containerTypes.UpdateConfig{Resources: res})
+		return err
+	}
+}
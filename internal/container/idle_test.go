@@ -0,0 +1,99 @@
+package container
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	containerTypes "github.com/docker/docker/api/types/container"
+)
+
+func TestIdleResources(t *testing.T) {
+	shrink, restore, err := idleResources("2", "0.5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shrink.NanoCPUs != 5e8 || restore.NanoCPUs != 2e9 {
+		t.Errorf("with a CPU limit: shrink %+v, restore %+v", shrink, restore)
+	}
+
+	shrink, restore, err = idleResources("", "0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shrink.CPUPeriod != 100000 || shrink.CPUQuota != 10000 || shrink.NanoCPUs != 0 {
+		t.Errorf("without a CPU limit: shrink %+v, want a 10000/100000 quota", shrink)
+	}
+	if restore.CPUQuota != -1 {
+		t.Errorf("without a CPU limit: restore %+v, want the quota lifted", restore)
+	}
+
+	for _, c := range [][2]string{{"", ""}, {"", "0"}, {"", "fast"}, {"x", "0.1"}} {
+		if _, _, err := idleResources(c[0], c[1]); err == nil {
+			t.Errorf("idleResources(%q, %q) succeeded", c[0], c[1])
+		}
+	}
+}
+
+func TestShrinkWhenIdle(t *testing.T) {
+	w := newIdleWatch()
+	updates := make(chan containerTypes.Resources, 4)
+	shrink := containerTypes.Resources{NanoCPUs: 1}
+	restore := containerTypes.Resources{NanoCPUs: 2}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.shrinkWhenIdle(ctx, 50*time.Millisecond, shrink, restore, func(r containerTypes.Resources) error {
+		updates <- r
+		return nil
+	})
+
+	next := func(want containerTypes.Resources) {
+		t.Helper()
+		select {
+		case got := <-updates:
+			if got.NanoCPUs != want.NanoCPUs {
+				t.Fatalf("update to %d NanoCPUs, want %d", got.NanoCPUs, want.NanoCPUs)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no update to %d NanoCPUs", want.NanoCPUs)
+		}
+	}
+
+	next(shrink)
+	var out bytes.Buffer
+	w.writer(&out).Write([]byte("x"))
+	next(restore)
+	if out.String() != "x" {
+		t.Errorf("output = %q, want it passed through", out.String())
+	}
+	next(shrink)
+}
+
+func TestShrinkWhenIdleActive(t *testing.T) {
+	w := newIdleWatch()
+	updates := make(chan containerTypes.Resources, 1)
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		w.shrinkWhenIdle(ctx, 100*time.Millisecond, containerTypes.Resources{}, containerTypes.Resources{}, func(r containerTypes.Resources) error {
+			updates <- r
+			return nil
+		})
+		close(done)
+	}()
+	for {
+		select {
+		case <-done:
+			if len(updates) != 0 {
+				t.Error("an active session was shrunk")
+			}
+			return
+		case <-time.After(20 * time.Millisecond):
+			w.touch()
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package container
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// LockFile pins the image sessions in a project run, committed next to
+// the project's .enclaude.yaml
+const LockFile = "enclaude.lock"
+
+// ImageLock records the content of an image, so every team member can be
+// held to the same sandbox
+type ImageLock struct {
+	Image      string   `json:"image"`                 // Reference the image was locked from
+	ID         string   `json:"id"`                    // Digest of the image configuration, which covers its layers
+	Layers     []string `json:"layers"`                // Digests of the uncompressed filesystem layers
+	RepoDigest string   `json:"repo_digest,omitempty"` // Registry reference to pull the image by, if it came from one
+}
+
+// ReadImageLock reads a lock file written by WriteImageLock
+func ReadImageLock(path string) (ImageLock, error) {
+	var lock ImageLock
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return lock, err
+	}
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return lock, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	if !strings.HasPrefix(lock.ID, "sha256:") {
+		return lock, fmt.Errorf("invalid %s: no image digest", path)
+	}
+	return lock, nil
+}
+
+// WriteImageLock writes lock to path
+func WriteImageLock(path string, lock ImageLock) error {
+	data, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// LockImage returns the lock for a local image
+func (r *Runner) LockImage(ctx context.Context, image string) (ImageLock, error) {
+	inspect, _, err := r.client.ImageInspectWithRaw(ctx, image)
+	if err != nil {
+		return ImageLock{}, fmt.Errorf("failed to inspect image %s: %w", image, err)
+	}
+	lock := ImageLock{Image: image, ID: inspect.ID, Layers: inspect.RootFS.Layers}
+	if len(inspect.RepoDigests) > 0 {
+		lock.RepoDigest = inspect.RepoDigests[0]
+	}
+	return lock, nil
+}
+
+// Diff describes how the image in got differs from the one locked, or
+// returns "" if they are the same image
+func (l ImageLock) Diff(got ImageLock) string {
+	if got.ID == l.ID {
+		return ""
+	}
+	locked := make(map[string]bool, len(l.Layers))
+	for _, layer := range l.Layers {
+		locked[layer] = true
+	}
+	var changed int
+	for _, layer := range got.Layers {
+		if !locked[layer] {
+			changed++
+		}
+	}
+	if changed == 0 && len(got.Layers) == len(l.Layers) {
+		return fmt.Sprintf("image %s is %s, not the locked %s; its layers match, but its configuration (environment, entrypoint, labels) differs",
+			got.Image, shortDigest(got.ID), shortDigest(l.ID))
+	}
+	return fmt.Sprintf("image %s is %s, not the locked %s; %d of its %d layers are not in the locked image",
+		got.Image, shortDigest(got.ID), shortDigest(l.ID), changed, len(got.Layers))
+}
+
+// shortDigest abbreviates a sha256 digest as docker images does
+func shortDigest(digest string) string {
+	hex := strings.TrimPrefix(digest, "sha256:")
+	if len(hex) > 12 {
+		hex = hex[:12]
+	}
+	return hex
+}
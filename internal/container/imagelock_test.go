@@ -0,0 +1,58 @@
+package container
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestImageLockRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), LockFile)
+	lock := ImageLock{
+		Image:      "ghcr.io/acme/enclaude:1.4",
+		ID:         "sha256:" + strings.Repeat("a", 64),
+		Layers:     []string{"sha256:" + strings.Repeat("1", 64), "sha256:" + strings.Repeat("2", 64)},
+		RepoDigest: "ghcr.io/acme/enclaude@sha256:" + strings.Repeat("b", 64),
+	}
+	if err := WriteImageLock(path, lock); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadImageLock(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, lock) {
+		t.Errorf("ReadImageLock() = %+v, want %+v", got, lock)
+	}
+
+	if err := WriteImageLock(path, ImageLock{Image: "enclaude:latest"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadImageLock(path); err == nil {
+		t.Error("expected an error for a lock without a digest")
+	}
+}
+
+func TestImageLockDiff(t *testing.T) {
+	layer1, layer2, layer3 := "sha256:"+strings.Repeat("1", 64), "sha256:"+strings.Repeat("2", 64), "sha256:"+strings.Repeat("3", 64)
+	lock := ImageLock{ID: "sha256:" + strings.Repeat("a", 64), Layers: []string{layer1, layer2}}
+
+	tests := []struct {
+		name string
+		got  ImageLock
+		want string
+	}{
+		{"same image", ImageLock{Image: "enclaude:latest", ID: lock.ID, Layers: lock.Layers}, ""},
+		{"config changed", ImageLock{Image: "enclaude:latest", ID: "sha256:" + strings.Repeat("c", 64), Layers: lock.Layers}, "its layers match"},
+		{"layer changed", ImageLock{Image: "enclaude:latest", ID: "sha256:" + strings.Repeat("c", 64), Layers: []string{layer1, layer3}}, "1 of its 2 layers"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := lock.Diff(tt.got)
+			if tt.want == "" && diff != "" || !strings.Contains(diff, tt.want) {
+				t.Errorf("Diff() = %q, want %q", diff, tt.want)
+			}
+		})
+	}
+}
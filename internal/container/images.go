@@ -0,0 +1,84 @@
+package container
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/klauspost/compress/zstd"
+)
+
+// zstdMagic opens every zstd frame
+var zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+// ExportImage writes images as a zstd-compressed `docker save` archive, so an
+// image can be carried to a host without registry access. Higher levels
+// trade CPU time for a smaller archive.
+func (r *Runner) ExportImage(ctx context.Context, images []string, w io.Writer, level zstd.EncoderLevel) error {
+	archive, err := r.client.ImageSave(ctx, images)
+	if err != nil {
+		return fmt.Errorf("failed to save image: %w", err)
+	}
+	defer archive.Close()
+
+	return compressArchive(w, archive, level)
+}
+
+// ImportImage loads an archive written by ExportImage. Uncompressed and
+// gzip-compressed `docker save` archives are accepted too, since the Docker
+// daemon decompresses those itself.
+func (r *Runner) ImportImage(ctx context.Context, src io.Reader, out io.Writer) error {
+	archive, closeArchive, err := decompressArchive(src)
+	if err != nil {
+		return err
+	}
+	defer closeArchive()
+
+	resp, err := r.client.ImageLoad(ctx, archive, false)
+	if err != nil {
+		return fmt.Errorf("failed to load image: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if err := jsonmessage.DisplayJSONMessagesStream(resp.Body, out, 0, false, nil); err != nil {
+		return fmt.Errorf("failed to load image: %w", err)
+	}
+	return nil
+}
+
+// compressArchive zstd-compresses src into dst
+func compressArchive(dst io.Writer, src io.Reader, level zstd.EncoderLevel) error {
+	enc, err := zstd.NewWriter(dst, zstd.WithEncoderLevel(level))
+	if err != nil {
+		return fmt.Errorf("failed to create compressor: %w", err)
+	}
+	if _, err := io.Copy(enc, src); err != nil {
+		enc.Close()
+		return fmt.Errorf("failed to write image archive: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("failed to write image archive: %w", err)
+	}
+	return nil
+}
+
+// decompressArchive returns src, transparently decompressed if it is zstd
+func decompressArchive(src io.Reader) (io.Reader, func(), error) {
+	buffered := bufio.NewReader(src)
+	header, err := buffered.Peek(len(zstdMagic))
+	if err != nil && err != io.EOF {
+		return nil, func() {}, fmt.Errorf("failed to read image archive: %w", err)
+	}
+	if !bytes.Equal(header, zstdMagic) {
+		return buffered, func() {}, nil
+	}
+
+	dec, err := zstd.NewReader(buffered)
+	if err != nil {
+		return nil, func() {}, fmt.Errorf("failed to create decompressor: %w", err)
+	}
+	return dec, dec.Close, nil
+}
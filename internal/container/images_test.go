@@ -0,0 +1,71 @@
+package container
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestArchiveRoundTrip(t *testing.T) {
+	// Image archives are mostly compressible layer tarballs
+	original := bytes.Repeat([]byte("layer.tar contents "), 4096)
+
+	for _, level := range []zstd.EncoderLevel{zstd.SpeedFastest, zstd.SpeedBestCompression} {
+		t.Run(level.String(), func(t *testing.T) {
+			var compressed bytes.Buffer
+			if err := compressArchive(&compressed, bytes.NewReader(original), level); err != nil {
+				t.Fatalf("compressArchive() error = %v", err)
+			}
+			if compressed.Len() >= len(original) {
+				t.Errorf("compressed size %d not smaller than original %d", compressed.Len(), len(original))
+			}
+
+			archive, closeArchive, err := decompressArchive(&compressed)
+			if err != nil {
+				t.Fatalf("decompressArchive() error = %v", err)
+			}
+			defer closeArchive()
+			got, err := io.ReadAll(archive)
+			if err != nil {
+				t.Fatalf("reading decompressed archive: %v", err)
+			}
+			if !bytes.Equal(got, original) {
+				t.Error("round-tripped archive differs from original")
+			}
+		})
+	}
+}
+
+func TestDecompressArchive_PassThrough(t *testing.T) {
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	w.Write([]byte("tarball"))
+	w.Close()
+
+	tests := []struct {
+		name  string
+		input []byte
+	}{
+		{"plain tar", []byte("plain tar archive")},
+		{"gzip", gz.Bytes()},
+		{"shorter than magic", []byte{0x28}},
+		{"empty", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			archive, closeArchive, err := decompressArchive(bytes.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("decompressArchive() error = %v", err)
+			}
+			defer closeArchive()
+			got, _ := io.ReadAll(archive)
+			if !bytes.Equal(got, tt.input) {
+				t.Errorf("decompressArchive() = %q, want input unchanged", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package container
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	containerTypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/moby/term"
+)
+
+// In exec interaction mode the entrypoint sets the session up, writes a
+// script that starts claude in the environment it prepared, and keeps the
+// container running. claude is then started with docker exec, which does not
+// depend on the attach connection surviving proxies and engines that handle
+// it poorly.
+const (
+	sessionScript  = Home + "/.enclaude-session"
+	sessionPidFile = Home + "/.enclaude-session.pid"
+)
+
+// sessionStartTimeout is how long the entrypoint gets to write the session
+// script in exec mode
+const sessionStartTimeout = 2 * time.Minute
+
+// startExecSession waits for the session script and starts claude with it,
+// returning the exec's connection and ID
+func (r *Runner) startExecSession(ctx context.Context, containerID string, opts RunOptions, isTTY bool) (types.HijackedResponse, string, error) {
+	deadline := time.Now().Add(sessionStartTimeout)
+	for {
+		if _, err := r.exec(ctx, containerID, []string{"test", "-f", sessionScript}, nil); err == nil {
+			break
+		}
+		if ctx.Err() != nil {
+			return types.HijackedResponse{}, "", ctx.Err()
+		}
+		if inspect, err := r.client.ContainerInspect(ctx, containerID); err == nil && inspect.State != nil && !inspect.State.Running {
+			r.printLogs(ctx, containerID)
+			return types.HijackedResponse{}, "", fmt.Errorf("container exited with code %d before the session started", inspect.State.ExitCode)
+		}
+		if time.Now().After(deadline) {
+			return types.HijackedResponse{}, "", fmt.Errorf("the session did not start within %s; container.interaction exec needs an image with the enclaude entrypoint", sessionStartTimeout)
+		}
+		time.Sleep(readyPollInterval)
+	}
+
+	execOpts := containerTypes.ExecOptions{
+		Cmd:          append([]string{"/bin/bash", sessionScript}, opts.ClaudeArgs...),
+		WorkingDir:   opts.WorkDir,
+		Tty:          isTTY,
+		AttachStdin:  true,
+		AttachStdout: true,
+		AttachStderr: true,
+	}
+	if isTTY {
+		if winsize, err := term.GetWinsize(os.Stdout.Fd()); err == nil {
+			execOpts.ConsoleSize = &[2]uint{uint(winsize.Height), uint(winsize.Width)}
+		}
+	}
+	created, err := r.client.ContainerExecCreate(ctx, containerID, execOpts)
+	if err != nil {
+		return types.HijackedResponse{}, "", fmt.Errorf("failed to start claude: %w", err)
+	}
+	resp, err := r.client.ContainerExecAttach(ctx, created.ID, containerTypes.ExecAttachOptions{Tty: isTTY})
+	if err != nil {
+		return types.HijackedResponse{}, "", fmt.Errorf("failed to attach to claude: %w", err)
+	}
+	return resp, created.ID, nil
+}
+
+// pumpExecOutput copies the multiplexed output of a non-TTY exec to stdout
+// and stderr until it ends
+func pumpExecOutput(resp types.HijackedResponse, stdout, stderr io.Writer, done chan<- error) {
+	_, err := stdcopy.StdCopy(stdout, stderr, resp.Reader)
+	done <- err
+}
+
+// execExitCode returns how an exec'd claude exited. An exec that is still
+// running lost its connection, which cannot be re-established.
+func (r *Runner) execExitCode(execID string) (int, error) {
+	inspect, err := r.client.ContainerExecInspect(context.Background(), execID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read claude's exit status: %w", err)
+	}
+	if inspect.Running {
+		return 0, fmt.Errorf("lost the connection to claude; sessions started with container.interaction exec cannot be re-attached")
+	}
+	return inspect.ExitCode, nil
+}
+
+// stopExecSession asks an exec'd claude to exit with SIGINT, so it can save
+// its session, and waits for it up to grace. The container is removed
+// afterwards either way.
+func (r *Runner) stopExecSession(containerID string, grace time.Duration, force <-chan struct{}, done <-chan error) {
+	if grace <= 0 {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "\r\nenclaude: stopping session (Ctrl+C again to force)...\r\n")
+	ctx, cancel := context.WithTimeout(context.Background(), grace)
+	defer cancel()
+	if _, err := r.exec(ctx, containerID, []string{"/bin/sh", "-c", `kill -INT "$(cat "$1")"`, "sh", sessionPidFile}, nil); err != nil {
+		return
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+	select {
+	case <-done:
+	case <-ctx.Done():
+	case <-force:
+	case <-sigCh:
+	}
+}
+
+// printLogs writes a container's output so far to the terminal, to explain
+// why it stopped
+func (r *Runner) printLogs(ctx context.Context, containerID string) {
+	logs, err := r.client.ContainerLogs(ctx, containerID, containerTypes.LogsOptions{ShowStdout: true, ShowStderr: true})
+	if err != nil {
+		return
+	}
+	defer logs.Close()
+	stdcopy.StdCopy(os.Stderr, os.Stderr, logs)
+}
@@ -0,0 +1,66 @@
+package container
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// HostTimezone returns the host's IANA time zone name, such as
+// "Europe/Berlin", from TZ, the /etc/localtime link, or /etc/timezone. It
+// returns "" if the zone cannot be told, leaving the container on UTC.
+func HostTimezone() string {
+	return hostTimezone(os.Getenv("TZ"), "/etc/localtime", "/etc/timezone")
+}
+
+func hostTimezone(tz, localtime, timezoneFile string) string {
+	if tz != "" {
+		// ":Europe/Berlin" and ":/etc/localtime" are POSIX forms of TZ
+		tz = strings.TrimPrefix(tz, ":")
+		if !filepath.IsAbs(tz) {
+			return tz
+		}
+		localtime = tz
+	}
+	if target, err := filepath.EvalSymlinks(localtime); err == nil {
+		if zone := zoneFromPath(target); zone != "" {
+			return zone
+		}
+	}
+	if data, err := os.ReadFile(timezoneFile); err == nil {
+		return strings.TrimSpace(string(data))
+	}
+	return ""
+}
+
+// zoneFromPath extracts the zone name from a zoneinfo file path, such as
+// /usr/share/zoneinfo/Europe/Berlin or, on macOS,
+// /var/db/timezone/zoneinfo/Europe/Berlin
+func zoneFromPath(p string) string {
+	_, zone, ok := strings.Cut(filepath.ToSlash(p), "/zoneinfo/")
+	if !ok {
+		return ""
+	}
+	// Leap-second and POSIX variants name the same zones
+	for _, prefix := range []string{"posix/", "right/"} {
+		zone = strings.TrimPrefix(zone, prefix)
+	}
+	return zone
+}
+
+// LocaleEnv returns the locale variables in environ, as KEY=value pairs from
+// os.Environ: LANG, LANGUAGE, and LC_*. The image entrypoint replaces
+// locales the image does not have with C.UTF-8.
+func LocaleEnv(environ []string) map[string]string {
+	env := make(map[string]string)
+	for _, kv := range environ {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok || v == "" {
+			continue
+		}
+		if k == "LANG" || k == "LANGUAGE" || strings.HasPrefix(k, "LC_") {
+			env[k] = v
+		}
+	}
+	return env
+}
@@ -0,0 +1,59 @@
+package container
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestHostTimezone(t *testing.T) {
+	dir := t.TempDir()
+	zoneDir := filepath.Join(dir, "usr", "share", "zoneinfo", "Europe")
+	os.MkdirAll(zoneDir, 0755)
+	os.WriteFile(filepath.Join(zoneDir, "Berlin"), []byte("TZif"), 0644)
+	localtime := filepath.Join(dir, "localtime")
+	os.Symlink(filepath.Join(zoneDir, "Berlin"), localtime)
+	timezoneFile := filepath.Join(dir, "timezone")
+	os.WriteFile(timezoneFile, []byte("America/Chicago\n"), 0644)
+	missing := filepath.Join(dir, "missing")
+
+	tests := []struct {
+		name, tz, localtime, timezoneFile, want string
+	}{
+		{"TZ name", "Asia/Tokyo", localtime, timezoneFile, "Asia/Tokyo"},
+		{"POSIX TZ name", ":Asia/Tokyo", localtime, timezoneFile, "Asia/Tokyo"},
+		{"TZ path", ":" + localtime, missing, timezoneFile, "Europe/Berlin"},
+		{"localtime link", "", localtime, timezoneFile, "Europe/Berlin"},
+		{"timezone file", "", missing, timezoneFile, "America/Chicago"},
+		{"unknown", "", missing, missing, ""},
+	}
+	for _, tt := range tests {
+		if got := hostTimezone(tt.tz, tt.localtime, tt.timezoneFile); got != tt.want {
+			t.Errorf("%s: hostTimezone() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestZoneFromPath(t *testing.T) {
+	tests := map[string]string{
+		"/usr/share/zoneinfo/Europe/Berlin":          "Europe/Berlin",
+		"/var/db/timezone/zoneinfo/America/New_York": "America/New_York",
+		"/usr/share/zoneinfo/posix/Australia/Perth":  "Australia/Perth",
+		"/usr/share/zoneinfo/UTC":                    "UTC",
+		"/etc/localtime":                             "",
+	}
+	for p, want := range tests {
+		if got := zoneFromPath(p); got != want {
+			t.Errorf("zoneFromPath(%q) = %q, want %q", p, got, want)
+		}
+	}
+}
+
+func TestLocaleEnv(t *testing.T) {
+	got := LocaleEnv([]string{"LANG=en_GB.UTF-8", "LC_TIME=de_DE.UTF-8", "LC_ALL=", "LANGUAGE=en_GB:en", "TERM=xterm", "LCX=1"})
+	want := map[string]string{"LANG": "en_GB.UTF-8", "LC_TIME": "de_DE.UTF-8", "LANGUAGE": "en_GB:en"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LocaleEnv() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,33 @@
+package container
+
+import (
+	"fmt"
+
+	containerTypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/go-units"
+)
+
+// applyMemoryPressure sets a soft memory limit below the hard one, which the
+// kernel reclaims the container down to when the host runs short of memory,
+// before the host itself swaps, and how readily the container's memory is
+// swapped. resources.Memory must already hold the hard limit.
+func applyMemoryPressure(resources *containerTypes.Resources, reservation string, swappiness *int64) error {
+	if reservation != "" {
+		soft, err := units.RAMInBytes(reservation)
+		if err != nil {
+			return fmt.Errorf("invalid memory reservation %q: %w", reservation, err)
+		}
+		if resources.Memory > 0 && soft > resources.Memory {
+			return fmt.Errorf("memory reservation %s is above the memory limit %s; it must be the lower, soft limit",
+				units.BytesSize(float64(soft)), units.BytesSize(float64(resources.Memory)))
+		}
+		resources.MemoryReservation = soft
+	}
+	if swappiness != nil {
+		if *swappiness < 0 || *swappiness > 100 {
+			return fmt.Errorf("invalid memory swappiness %d: must be between 0 and 100", *swappiness)
+		}
+		resources.MemorySwappiness = swappiness
+	}
+	return nil
+}
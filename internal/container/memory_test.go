@@ -0,0 +1,41 @@
+package container
+
+import (
+	"testing"
+
+	containerTypes "github.com/docker/docker/api/types/container"
+)
+
+func TestApplyMemoryPressure(t *testing.T) {
+	swappiness := int64(10)
+	resources := containerTypes.Resources{Memory: 4 << 30}
+	if err := applyMemoryPressure(&resources, "2g", &swappiness); err != nil {
+		t.Fatalf("applyMemoryPressure() error = %v", err)
+	}
+	if resources.MemoryReservation != 2<<30 {
+		t.Errorf("MemoryReservation = %d, want %d", resources.MemoryReservation, int64(2<<30))
+	}
+	if resources.MemorySwappiness == nil || *resources.MemorySwappiness != 10 {
+		t.Errorf("MemorySwappiness = %v, want 10", resources.MemorySwappiness)
+	}
+
+	unset := containerTypes.Resources{}
+	if err := applyMemoryPressure(&unset, "", nil); err != nil || unset.MemoryReservation != 0 || unset.MemorySwappiness != nil {
+		t.Errorf("applyMemoryPressure() with nothing set = %+v, %v", unset, err)
+	}
+
+	tooHigh := int64(101)
+	for _, tt := range []struct {
+		reservation string
+		swappiness  *int64
+	}{
+		{"8g", nil},
+		{"lots", nil},
+		{"", &tooHigh},
+	} {
+		resources := containerTypes.Resources{Memory: 4 << 30}
+		if err := applyMemoryPressure(&resources, tt.reservation, tt.swappiness); err == nil {
+			t.Errorf("applyMemoryPressure(%q, %v) expected error", tt.reservation, tt.swappiness)
+		}
+	}
+}
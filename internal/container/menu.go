@@ -0,0 +1,133 @@
+package container
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+const (
+	// ctrlC stops the session in raw terminal mode; pressed again while
+	// stopping, it forces the container down
+	ctrlC = 0x03
+
+	// MenuKey (Ctrl+\) opens the session menu. Pressing it twice sends a
+	// literal Ctrl+\ to the container.
+	MenuKey = 0x1c
+)
+
+// DetachedError is returned by Run when the user detaches from the session
+// menu, leaving the container running
+type DetachedError struct {
+	ContainerID string
+}
+
+func (e *DetachedError) Error() string {
+	return fmt.Sprintf("detached from session %.12s", e.ContainerID)
+}
+
+const menuPrompt = "\r\n[enclaude] p: pause  r: resume  d: detach  k: kill  (Ctrl+\\ again to send it, any other key to cancel)\r\n"
+
+// approvalPrompt follows menuPrompt while an approval is pending
+const approvalPrompt = "[enclaude] y: allow  n: deny  %s\r\n"
+
+// inputHandler splits raw terminal input into bytes for the container, the
+// Ctrl+C interrupt, and session menu commands
+type inputHandler struct {
+	menuOpen  bool
+	stopping  bool                // Ctrl+C was pressed; further input is dropped
+	forward   func([]byte)        // Sends input to the container
+	interrupt func()              // Ctrl+C, once per press; nil to forward it like any other byte
+	openMenu  func()              // Shows the menu prompt
+	command   func(key byte) bool // Runs a menu command; true stops reading input
+}
+
+// handle processes a chunk of input, returning false once no more input
+// should be read
+func (h *inputHandler) handle(p []byte) bool {
+	var out []byte
+	flush := func() {
+		if len(out) > 0 {
+			h.forward(out)
+			out = nil
+		}
+	}
+
+	for _, b := range p {
+		switch {
+		case h.menuOpen:
+			h.menuOpen = false
+			if b == MenuKey {
+				out = append(out, b)
+				continue
+			}
+			flush()
+			if h.command(b) {
+				return false
+			}
+		case b == ctrlC && h.interrupt != nil:
+			flush()
+			h.stopping = true
+			h.interrupt()
+		case h.stopping:
+			// The session is shutting down; keep input away from claude
+		case b == MenuKey:
+			flush()
+			h.menuOpen = true
+			h.openMenu()
+		default:
+			out = append(out, b)
+		}
+	}
+	flush()
+	return true
+}
+
+// showMenu prints the session menu, with the oldest pending approval
+func showMenu(approvals *approvalQueue) {
+	fmt.Fprint(os.Stderr, menuPrompt)
+	if a, ok := approvals.peek(); ok {
+		fmt.Fprintf(os.Stderr, approvalPrompt, a.Prompt)
+	}
+}
+
+// menuCommand runs the session menu command for key, reporting whether the
+// user chose to detach
+func (r *Runner) menuCommand(ctx context.Context, containerID string, key byte, approvals *approvalQueue) bool {
+	var err error
+	switch key {
+	case 'y', 'Y', 'n', 'N':
+		a, ok := approvals.next()
+		if !ok {
+			fmt.Fprint(os.Stderr, "[enclaude] nothing to approve\r\n")
+			break
+		}
+		allow := key == 'y' || key == 'Y'
+		a.Decide(allow)
+		if allow {
+			fmt.Fprint(os.Stderr, "[enclaude] allowed\r\n")
+		} else {
+			fmt.Fprint(os.Stderr, "[enclaude] denied\r\n")
+		}
+	case 'p', 'P':
+		if err = r.client.ContainerPause(ctx, containerID); err == nil {
+			fmt.Fprint(os.Stderr, "[enclaude] session paused; press Ctrl+\\ then r to resume\r\n")
+		}
+	case 'r', 'R':
+		if err = r.client.ContainerUnpause(ctx, containerID); err == nil {
+			fmt.Fprint(os.Stderr, "[enclaude] session resumed\r\n")
+		}
+	case 'd', 'D':
+		return true
+	case 'k', 'K':
+		if err = r.client.ContainerKill(ctx, containerID, "KILL"); err == nil {
+			fmt.Fprint(os.Stderr, "[enclaude] session killed\r\n")
+		}
+	default:
+		fmt.Fprint(os.Stderr, "[enclaude] cancelled\r\n")
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[enclaude] %v\r\n", err)
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package container
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInputHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		chunks     []string
+		commandEnd bool // command returns true (detach)
+		forwarded  string
+		commands   string
+		menus      int
+		interrupts int
+		stopped    bool
+	}{
+		{name: "plain input", chunks: []string{"hello", " world"}, forwarded: "hello world"},
+		{name: "ctrl+c interrupts", chunks: []string{"ab\x03cd"}, forwarded: "ab", interrupts: 1},
+		{name: "ctrl+c again while stopping", chunks: []string{"a\x03b", "c\x03"}, forwarded: "a", interrupts: 2},
+		{name: "menu command", chunks: []string{"ab\x1cpcd"}, forwarded: "abcd", commands: "p", menus: 1},
+		{name: "menu key split across reads", chunks: []string{"ab\x1c", "rcd"}, forwarded: "abcd", commands: "r", menus: 1},
+		{name: "menu key twice sends it", chunks: []string{"a\x1c\x1cb"}, forwarded: "a\x1cb", menus: 1},
+		{name: "ctrl+c in menu is a command", chunks: []string{"\x1c\x03x"}, forwarded: "x", commands: "\x03", menus: 1},
+		{name: "detach stops input", chunks: []string{"a\x1cdb"}, commandEnd: true, forwarded: "a", commands: "d", menus: 1, stopped: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var forwarded, commands string
+			var menus, interrupts int
+			h := &inputHandler{
+				forward:   func(p []byte) { forwarded += string(p) },
+				interrupt: func() { interrupts++ },
+				openMenu:  func() { menus++ },
+				command: func(key byte) bool {
+					commands += string(key)
+					return tt.commandEnd
+				},
+			}
+
+			stopped := false
+			for _, chunk := range tt.chunks {
+				if !h.handle([]byte(chunk)) {
+					stopped = true
+					break
+				}
+			}
+
+			got := []interface{}{forwarded, commands, menus, interrupts, stopped}
+			want := []interface{}{tt.forwarded, tt.commands, tt.menus, tt.interrupts, tt.stopped}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("forwarded, commands, menus, interrupts, stopped = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestInputHandlerWithoutInterrupt(t *testing.T) {
+	var forwarded string
+	h := &inputHandler{forward: func(p []byte) { forwarded += string(p) }}
+	if !h.handle([]byte("a\x03b")) {
+		t.Fatal("handle() stopped without an interrupt handler")
+	}
+	if forwarded != "a\x03b" {
+		t.Errorf("forwarded = %q, want Ctrl+C passed through", forwarded)
+	}
+}
@@ -0,0 +1,70 @@
+package container
+
+import (
+	"context"
+	"encoding/json"
+
+	containerTypes "github.com/docker/docker/api/types/container"
+)
+
+// RunMetrics records what a session did, filled in by Run when set in
+// RunOptions
+type RunMetrics struct {
+	PeakMemory uint64 // Highest memory usage seen, excluding reclaimable page cache
+	NetworkRx  uint64 // Bytes received across all container networks
+	NetworkTx  uint64 // Bytes sent across all container networks
+	Exited     bool   // The container ran to completion
+	ExitCode   int    // Container exit code, valid when Exited is set
+}
+
+// collectMetrics streams container stats into m until ctx is cancelled or
+// the container stops. Stats are best-effort: errors leave m incomplete.
+func (r *Runner) collectMetrics(ctx context.Context, containerID string, m *RunMetrics) {
+	resp, err := r.client.ContainerStats(ctx, containerID, true)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var stats containerTypes.StatsResponse
+		if err := dec.Decode(&stats); err != nil {
+			return
+		}
+		m.record(stats)
+	}
+}
+
+// record folds one stats sample into m. Network counters are cumulative, so
+// the latest sample wins; a stopped container reports zeroes, which are
+// ignored.
+func (m *RunMetrics) record(stats containerTypes.StatsResponse) {
+	if usage := memoryUsage(stats.MemoryStats); usage > m.PeakMemory {
+		m.PeakMemory = usage
+	}
+	var rx, tx uint64
+	for _, n := range stats.Networks {
+		rx += n.RxBytes
+		tx += n.TxBytes
+	}
+	if rx > m.NetworkRx {
+		m.NetworkRx = rx
+	}
+	if tx > m.NetworkTx {
+		m.NetworkTx = tx
+	}
+}
+
+// memoryUsage returns memory in use, excluding inactive page cache the
+// kernel can reclaim, matching what `docker stats` reports
+func memoryUsage(mem containerTypes.MemoryStats) uint64 {
+	cache := mem.Stats["inactive_file"] // cgroup v2
+	if v, ok := mem.Stats["total_inactive_file"]; ok {
+		cache = v // cgroup v1
+	}
+	if cache > mem.Usage {
+		return 0
+	}
+	return mem.Usage - cache
+}
@@ -0,0 +1,52 @@
+package container
+
+import (
+	"testing"
+
+	containerTypes "github.com/docker/docker/api/types/container"
+)
+
+func TestRunMetricsRecord(t *testing.T) {
+	sample := func(usage, cache, rx, tx uint64) containerTypes.StatsResponse {
+		var s containerTypes.StatsResponse
+		s.MemoryStats = containerTypes.MemoryStats{Usage: usage, Stats: map[string]uint64{"inactive_file": cache}}
+		s.Networks = map[string]containerTypes.NetworkStats{
+			"eth0": {RxBytes: rx, TxBytes: tx},
+			"eth1": {RxBytes: rx, TxBytes: tx},
+		}
+		return s
+	}
+
+	var m RunMetrics
+	m.record(sample(300, 100, 10, 5))
+	m.record(sample(500, 100, 20, 8))
+	m.record(sample(250, 50, 30, 9))
+	m.record(sample(0, 0, 0, 0)) // container stopped
+
+	if m.PeakMemory != 400 {
+		t.Errorf("PeakMemory = %d, want 400", m.PeakMemory)
+	}
+	if m.NetworkRx != 60 || m.NetworkTx != 18 {
+		t.Errorf("NetworkRx, NetworkTx = %d, %d, want 60, 18", m.NetworkRx, m.NetworkTx)
+	}
+}
+
+func TestMemoryUsage(t *testing.T) {
+	tests := []struct {
+		name string
+		mem  containerTypes.MemoryStats
+		want uint64
+	}{
+		{"cgroup v2", containerTypes.MemoryStats{Usage: 1000, Stats: map[string]uint64{"inactive_file": 200}}, 800},
+		{"cgroup v1", containerTypes.MemoryStats{Usage: 1000, Stats: map[string]uint64{"total_inactive_file": 300, "inactive_file": 1}}, 700},
+		{"no stats", containerTypes.MemoryStats{Usage: 1000}, 1000},
+		{"cache exceeds usage", containerTypes.MemoryStats{Usage: 100, Stats: map[string]uint64{"inactive_file": 200}}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := memoryUsage(tt.mem); got != tt.want {
+				t.Errorf("memoryUsage() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
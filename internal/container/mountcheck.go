@@ -0,0 +1,121 @@
+package container
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"syscall"
+)
+
+// MountKind is what a bind mount source is expected to be
+type MountKind int
+
+const (
+	MountAny  MountKind = iota // File, directory or socket
+	MountFile                  // Regular file
+	MountDir                   // Directory
+)
+
+// Access modes for syscall.Access
+const (
+	accessRead    = 0x4
+	accessWrite   = 0x2
+	accessExecute = 0x1
+)
+
+// MountError describes a bind mount whose source failed pre-flight
+type MountError struct {
+	Mount  Mount
+	Reason string
+}
+
+// MountErrors reports every mount that failed pre-flight at once, so a
+// misconfiguration is fixed in one pass rather than one container start at
+// a time
+type MountErrors []MountError
+
+func (e MountErrors) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d mount(s) failed pre-flight:", len(e))
+	for _, m := range e {
+		fmt.Fprintf(&b, "\n  %s -> %s: %s", m.Mount.Source, m.Mount.Target, m.Reason)
+	}
+	return b.String()
+}
+
+// CheckMounts verifies that each bind mount source exists, is accessible
+// with the current permissions (writable for read-write mounts) and matches
+// its expected kind. Named volumes are created by Docker and are not checked.
+func CheckMounts(mounts []Mount) error {
+	var errs MountErrors
+	for _, m := range mounts {
+		if m.Volume {
+			continue
+		}
+		if reason := checkMount(m); reason != "" {
+			errs = append(errs, MountError{Mount: m, Reason: reason})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// checkMount returns why a bind mount cannot be used, or "" if it can
+func checkMount(m Mount) string {
+	info, err := os.Stat(m.Source)
+	if errors.Is(err, os.ErrNotExist) {
+		return "source does not exist"
+	}
+	if errors.Is(err, os.ErrPermission) {
+		return "source is not accessible: permission denied"
+	}
+	if err != nil {
+		return fmt.Sprintf("source is not accessible: %v", err)
+	}
+
+	switch {
+	case m.Kind == MountFile && info.IsDir():
+		if empty, _ := emptyDir(m.Source); empty {
+			// Docker creates an empty directory in place of a missing file
+			// source, which then shadows the real file on later runs
+			return "source is an empty directory but a file was expected; remove it and restore the file"
+		}
+		return "source is a directory but a file was expected"
+	case m.Kind == MountDir && !info.IsDir():
+		return "source is a file but a directory was expected"
+	}
+
+	mode := uint32(accessRead)
+	if !m.ReadOnly {
+		mode |= accessWrite
+	}
+	if info.IsDir() {
+		mode |= accessExecute
+	}
+	if err := syscall.Access(m.Source, mode); err != nil {
+		if m.ReadOnly {
+			return "source is not readable with current permissions"
+		}
+		return "source is not readable and writable with current permissions"
+	}
+	return ""
+}
+
+// emptyDir reports whether dir has no entries
+func emptyDir(dir string) (bool, error) {
+	f, err := os.Open(dir)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	if _, err := f.Readdirnames(1); err == io.EOF {
+		return true, nil
+	} else if err != nil {
+		return false, err
+	}
+	return false, nil
+}
@@ -0,0 +1,77 @@
+package container
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckMounts(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "hosts.yml")
+	if err := os.WriteFile(file, []byte("github.com:\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	emptyDir := filepath.Join(dir, "empty")
+	if err := os.Mkdir(emptyDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	fullDir := filepath.Join(dir, "full")
+	if err := os.Mkdir(fullDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(fullDir, "a"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		mount Mount
+		want  string
+	}{
+		{"file", Mount{Source: file, Target: "/t", ReadOnly: true, Kind: MountFile}, ""},
+		{"dir", Mount{Source: fullDir, Target: "/t", Kind: MountDir}, ""},
+		{"any dir", Mount{Source: emptyDir, Target: "/t"}, ""},
+		{"volume", Mount{Source: "no-such-volume", Target: "/t", Volume: true}, ""},
+		{"missing", Mount{Source: filepath.Join(dir, "nope"), Target: "/t"}, "does not exist"},
+		{"empty dir for file", Mount{Source: emptyDir, Target: "/t", Kind: MountFile}, "empty directory"},
+		{"dir for file", Mount{Source: fullDir, Target: "/t", Kind: MountFile}, "directory but a file"},
+		{"file for dir", Mount{Source: file, Target: "/t", Kind: MountDir}, "file but a directory"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckMounts([]Mount{tt.mount})
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("CheckMounts() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("CheckMounts() = %v, want error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckMountsAggregates(t *testing.T) {
+	dir := t.TempDir()
+	mounts := []Mount{
+		{Source: filepath.Join(dir, "a"), Target: "/a"},
+		{Source: dir, Target: "/ok"},
+		{Source: filepath.Join(dir, "b"), Target: "/b"},
+	}
+	err := CheckMounts(mounts)
+	var errs MountErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("CheckMounts() = %v, want MountErrors", err)
+	}
+	if len(errs) != 2 {
+		t.Fatalf("got %d mount errors, want 2: %v", len(errs), err)
+	}
+	if !strings.Contains(err.Error(), "/a") || !strings.Contains(err.Error(), "/b") {
+		t.Errorf("error does not list every failing mount: %v", err)
+	}
+}
@@ -0,0 +1,375 @@
+package container
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	containerTypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/go-units"
+	"github.com/jakenelson/enclaude/internal/config"
+	"github.com/jakenelson/enclaude/internal/terminal"
+	"github.com/moby/term"
+)
+
+// DefaultNamespace is the containerd namespace nerdctl uses by default.
+// k3s and Rancher Desktop keep Kubernetes images in "k8s.io".
+const DefaultNamespace = "default"
+
+// NerdctlRunner runs sessions on containerd through the nerdctl CLI, for
+// hosts without a Docker socket. nerdctl's stdio is the session's, so the
+// session menu, re-attach, and live stats are not available.
+type NerdctlRunner struct {
+	bin       string
+	namespace string
+}
+
+// NewNerdctlRunner finds nerdctl on the PATH and checks that it can reach
+// containerd in namespace
+func NewNerdctlRunner(namespace string) (*NerdctlRunner, error) {
+	bin, err := exec.LookPath("nerdctl")
+	if err != nil {
+		return nil, fmt.Errorf("nerdctl not found on PATH; install it to use the containerd engine")
+	}
+	if namespace == "" {
+		namespace = DefaultNamespace
+	}
+	r := &NerdctlRunner{bin: bin, namespace: namespace}
+	if out, err := r.command(context.Background(), "version").CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("failed to connect to containerd: %s", strings.TrimSpace(string(out)))
+	}
+	return r, nil
+}
+
+// Close releases nothing; it lets NerdctlRunner stand in for Runner
+func (r *NerdctlRunner) Close() error {
+	return nil
+}
+
+// command returns a nerdctl command in the runner's namespace
+func (r *NerdctlRunner) command(ctx context.Context, args ...string) *exec.Cmd {
+	return exec.CommandContext(ctx, r.bin, append([]string{"--namespace", r.namespace}, args...)...)
+}
+
+// Run runs a session container with the terminal attached and waits for it
+// to exit. When ctx is cancelled claude gets SIGINT and opts.StopGrace to
+// exit before the container is removed. Approvals are denied, since there is
+// no session menu to answer them from.
+func (r *NerdctlRunner) Run(ctx context.Context, cancel context.CancelFunc, opts RunOptions) error {
+	isTTY := term.IsTerminal(os.Stdin.Fd()) && !opts.NoTTY
+
+	if opts.Approvals != nil {
+		go (&approvalQueue{}).serve(opts.Approvals, false)
+	}
+	if opts.Hyperlinks {
+		fmt.Fprintln(os.Stderr, "Warning: clickable paths are not supported with the containerd engine")
+	}
+	if opts.TeeFile != "" {
+		fmt.Fprintln(os.Stderr, "Warning: --tee is not supported with the containerd engine")
+	}
+	if len(opts.Filters) > 0 {
+		fmt.Fprintln(os.Stderr, "Warning: output.filters is not supported with the containerd engine")
+	}
+
+	name, err := sessionName()
+	if err != nil {
+		return err
+	}
+	args, env, err := nerdctlRunArgs(opts, name, r.sessionUser(ctx, opts.User), isTTY)
+	if err != nil {
+		return err
+	}
+
+	// The session must outlive ctx so it can be stopped gracefully
+	cmd := r.command(context.Background(), args...)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if opts.Stdout != nil {
+		cmd.Stdout = opts.Stdout
+	}
+	// nerdctl puts the terminal into raw mode itself; if it is killed it
+	// cannot undo that, so the state is restored here
+	if isTTY {
+		terminal.Save(os.Stdin.Fd())
+	}
+	if err := cmd.Start(); err != nil {
+		terminal.Restore()
+		return fmt.Errorf("failed to start nerdctl: %w", err)
+	}
+	cleanExit := false
+	defer func() {
+		if cleanExit || !isTTY {
+			terminal.Restore()
+		} else {
+			terminal.Reset(os.Stdout)
+		}
+	}()
+	defer func() {
+		_ = r.command(context.Background(), "rm", "-f", name).Run()
+	}()
+
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+
+	select {
+	case err = <-exited:
+	case <-ctx.Done():
+		r.shutdown(name, opts.StopGrace, exited)
+		return ctx.Err()
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		if opts.Metrics != nil {
+			opts.Metrics.Exited = true
+		}
+		cleanExit = true
+		return nil
+	case errors.As(err, &exitErr):
+		if opts.Metrics != nil {
+			opts.Metrics.Exited = true
+			opts.Metrics.ExitCode = exitErr.ExitCode()
+		}
+		return fmt.Errorf("container exited with code %d", exitErr.ExitCode())
+	default:
+		return fmt.Errorf("nerdctl failed: %w", err)
+	}
+}
+
+// shutdown sends claude SIGINT and removes the container once it has not
+// exited within grace
+func (r *NerdctlRunner) shutdown(name string, grace time.Duration, exited <-chan error) {
+	if grace > 0 {
+		fmt.Fprintf(os.Stderr, "\r\nenclaude: stopping session...\r\n")
+		if err := r.command(context.Background(), "kill", "--signal", "SIGINT", name).Run(); err == nil {
+			select {
+			case <-exited:
+				return
+			case <-time.After(grace):
+			}
+		}
+	}
+	timeout := strconv.Itoa(stopTimeout)
+	_ = r.command(context.Background(), "stop", "--time", timeout, name).Run()
+}
+
+// sessionUser resolves the container user like Runner.sessionUser, running
+// as root under rootless containerd where root maps to the host user
+func (r *NerdctlRunner) sessionUser(ctx context.Context, user string) string {
+	if user != config.UserAuto {
+		return user
+	}
+	if r.UserNamespace(ctx) == UserNamespaceRootless {
+		return "0:0"
+	}
+	return fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid())
+}
+
+// UserNamespace reports the user namespace containerd runs containers in,
+// like Runner.UserNamespace
+func (r *NerdctlRunner) UserNamespace(ctx context.Context) string {
+	out, err := r.command(ctx, "info", "--format", "{{json .SecurityOptions}}").Output()
+	if err != nil {
+		return ""
+	}
+	var opts []string
+	if json.Unmarshal(out, &opts) != nil {
+		return ""
+	}
+	if userNamespace(opts) == UserNamespaceRootless {
+		return UserNamespaceRootless
+	}
+	return ""
+}
+
+// sessionName returns a unique container name, used to signal and remove
+// the session since nerdctl run does not report the container ID up front
+func sessionName() (string, error) {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate session name: %w", err)
+	}
+	return "enclaude-" + hex.EncodeToString(b), nil
+}
+
+// nerdctlRunArgs translates opts into `nerdctl run` arguments, applying the
+// same hardening as createContainer. Session environment variables are
+// named in the arguments and their values returned separately for nerdctl's
+// environment, so secrets never appear in the host process list.
+func nerdctlRunArgs(opts RunOptions, name, user string, isTTY bool) (args, env []string, err error) {
+	args = []string{"run", "--name", name, "-i"}
+	if isTTY {
+		args = append(args, "-t")
+	}
+	if opts.PullPolicy != "" {
+		args = append(args, "--pull", opts.PullPolicy)
+	}
+
+	labels := sessionLabels(opts)
+	for _, k := range sortedKeys(labels) {
+		args = append(args, "--label", k+"="+labels[k])
+	}
+
+	// PATH and HOME would also change nerdctl's own, so they are given inline
+	args = append(args, "-e", "PATH=/usr/local/bin:/usr/bin:/bin", "-e", "HOME="+Home)
+	for _, k := range sortedKeys(opts.Environment) {
+		if k != "PATH" && k != "HOME" {
+			env = append(env, k+"="+opts.Environment[k])
+		}
+	}
+
+	var mounts []mount.Mount
+	for _, m := range opts.Mounts {
+		mounts = append(mounts, engineMount(m, opts.Security.Ephemeral))
+	}
+	caMounts, caEnv := caCertMounts(opts.Security.CACerts)
+	mounts = append(mounts, caMounts...)
+	env = append(env, caEnv...)
+
+	if opts.StderrFile != "" && isTTY {
+		m, e, err := stderrFileMount(opts.StderrFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		mounts = append(mounts, m)
+		env = append(env, e)
+	}
+
+	for _, e := range env {
+		k, _, _ := strings.Cut(e, "=")
+		args = append(args, "-e", k)
+	}
+	for _, m := range mounts {
+		args = append(args, "--mount", mountFlag(m))
+	}
+
+	var tmpfsSize int64
+	if opts.TmpfsSize != "" {
+		size, err := units.RAMInBytes(opts.TmpfsSize)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid tmpfs size %q: %w", opts.TmpfsSize, err)
+		}
+		tmpfsSize = size
+	}
+	args = append(args, "--tmpfs", Home+":"+homeTmpfs(user, tmpfsSize))
+	if opts.Security.ReadOnlyRoot {
+		args = append(args, "--read-only")
+		for _, path := range []string{"/tmp", "/run", "/var/tmp"} {
+			tmpfs := path
+			if tmpfsSize > 0 {
+				tmpfs += ":size=" + strconv.FormatInt(tmpfsSize, 10)
+			}
+			args = append(args, "--tmpfs", tmpfs)
+		}
+	}
+
+	if user != "" {
+		args = append(args, "--user", user)
+	}
+	if opts.WorkDir != "" {
+		args = append(args, "--workdir", opts.WorkDir)
+	}
+	if opts.Network != "" {
+		args = append(args, "--network", opts.Network)
+	}
+	for _, p := range opts.Ports {
+		args = append(args, "--publish", p)
+	}
+	for _, h := range append(blockedHostEntries(opts.BlockedHosts), opts.HostEntries...) {
+		args = append(args, "--add-host", h)
+	}
+
+	if opts.MemoryLimit != "" {
+		if _, err := units.RAMInBytes(opts.MemoryLimit); err != nil {
+			return nil, nil, fmt.Errorf("invalid memory limit %q: %w", opts.MemoryLimit, err)
+		}
+		args = append(args, "--memory", opts.MemoryLimit)
+	}
+	if opts.CPUs != "" {
+		if cpus, err := strconv.ParseFloat(opts.CPUs, 64); err != nil || cpus <= 0 {
+			return nil, nil, fmt.Errorf("invalid cpus %q: must be a positive number", opts.CPUs)
+		}
+		args = append(args, "--cpus", opts.CPUs)
+	}
+	if opts.PidsLimit > 0 {
+		args = append(args, "--pids-limit", strconv.FormatInt(opts.PidsLimit, 10))
+	}
+
+	var resources containerTypes.Resources
+	resources.Memory, _ = units.RAMInBytes(opts.MemoryLimit)
+	if err := applyMemoryPressure(&resources, opts.Reservation, opts.Swappiness); err != nil {
+		return nil, nil, err
+	}
+	if opts.Reservation != "" {
+		args = append(args, "--memory-reservation", opts.Reservation)
+	}
+	if resources.MemorySwappiness != nil {
+		args = append(args, "--memory-swappiness", strconv.FormatInt(*resources.MemorySwappiness, 10))
+	}
+	if err := applyCgroup(&resources, opts.Cgroup); err != nil {
+		return nil, nil, err
+	}
+	if resources.CgroupParent != "" {
+		args = append(args, "--cgroup-parent", resources.CgroupParent)
+	}
+	if resources.CPUShares != 0 {
+		args = append(args, "--cpu-shares", strconv.FormatInt(resources.CPUShares, 10))
+	}
+	if resources.BlkioWeight != 0 {
+		args = append(args, "--blkio-weight", strconv.Itoa(int(resources.BlkioWeight)))
+	}
+
+	if opts.Security.DropCapabilities {
+		args = append(args, "--cap-drop", "ALL")
+	}
+	if opts.Security.NoNewPrivileges {
+		args = append(args, "--security-opt", "no-new-privileges")
+	}
+
+	// Passed through as given, once checked against the denied flags
+	dockerArgs, err := ParseDockerArgs(opts.DockerArgs)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, arg := range dockerArgs {
+		args = append(args, arg.String())
+	}
+
+	args = append(args, opts.Image)
+	return append(args, opts.ClaudeArgs...), env, nil
+}
+
+// mountFlag renders m as a --mount value
+func mountFlag(m mount.Mount) string {
+	s := "type=" + string(m.Type)
+	if m.Source != "" {
+		s += ",src=" + m.Source
+	}
+	s += ",dst=" + m.Target
+	if m.ReadOnly {
+		s += ",readonly"
+	}
+	return s
+}
+
+// sortedKeys returns the keys of m in order, so generated arguments are
+// stable
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
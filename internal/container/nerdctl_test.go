@@ -0,0 +1,90 @@
+package container
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/mount"
+)
+
+func TestNerdctlRunArgs(t *testing.T) {
+	opts := RunOptions{
+		Image:       "enclaude:latest",
+		Mounts:      []Mount{{Source: "/home/me/app", Target: "/workspace"}, {Source: "/home/me/docs", Target: "/mnt/docs", ReadOnly: true}},
+		Environment: map[string]string{"ANTHROPIC_API_KEY": "sk-secret", "TERM": "xterm"},
+		ClaudeArgs:  []string{"--resume"},
+		WorkDir:     "/workspace",
+		MemoryLimit: "4g",
+		Network:     "none",
+		Security:    SecurityOptions{DropCapabilities: true, NoNewPrivileges: true, ReadOnlyRoot: true},
+		PullPolicy:  "never",
+		DockerArgs:  []string{"shm-size=2g"},
+	}
+	args, env, err := nerdctlRunArgs(opts, "enclaude-test", "1000:1000", false)
+	if err != nil {
+		t.Fatalf("nerdctlRunArgs() error = %v", err)
+	}
+	line := strings.Join(args, " ")
+
+	for _, want := range []string{
+		"run --name enclaude-test -i ",
+		"--pull never",
+		"--mount type=bind,src=/home/me/app,dst=/workspace ",
+		"--mount type=bind,src=/home/me/docs,dst=/mnt/docs,readonly ",
+		"--read-only",
+		"--tmpfs /tmp",
+		"--user 1000:1000",
+		"--workdir /workspace",
+		"--network none",
+		"--memory 4g",
+		"--cap-drop ALL",
+		"--security-opt no-new-privileges",
+		"-e ANTHROPIC_API_KEY ",
+		"--shm-size=2g enclaude:latest",
+	} {
+		if !strings.Contains(line, want) {
+			t.Errorf("args missing %q:\n%s", want, line)
+		}
+	}
+	if slices.Contains(args, "-t") {
+		t.Errorf("args request a TTY without one: %s", line)
+	}
+	if strings.Contains(line, "sk-secret") {
+		t.Errorf("args expose an environment value: %s", line)
+	}
+	if !slices.Contains(env, "ANTHROPIC_API_KEY=sk-secret") {
+		t.Errorf("env = %q, want the API key passed through nerdctl's environment", env)
+	}
+	if got := args[len(args)-2:]; got[0] != "enclaude:latest" || got[1] != "--resume" {
+		t.Errorf("args end with %q, want the image then claude's arguments", got)
+	}
+}
+
+func TestNerdctlRunArgsInvalidLimits(t *testing.T) {
+	for _, opts := range []RunOptions{
+		{Image: "enclaude:latest", MemoryLimit: "lots"},
+		{Image: "enclaude:latest", CPUs: "-1"},
+		{Image: "enclaude:latest", TmpfsSize: "big"},
+		{Image: "enclaude:latest", DockerArgs: []string{"--privileged"}},
+	} {
+		if _, _, err := nerdctlRunArgs(opts, "enclaude-test", "", false); err == nil {
+			t.Errorf("nerdctlRunArgs(%+v) error = nil, want an error", opts)
+		}
+	}
+}
+
+func TestMountFlag(t *testing.T) {
+	tests := []struct {
+		m    mount.Mount
+		want string
+	}{
+		{mount.Mount{Type: mount.TypeBind, Source: "/src", Target: "/dst"}, "type=bind,src=/src,dst=/dst"},
+		{mount.Mount{Type: mount.TypeVolume, Source: "cache", Target: "/cache", ReadOnly: true}, "type=volume,src=cache,dst=/cache,readonly"},
+	}
+	for _, tt := range tests {
+		if got := mountFlag(tt.m); got != tt.want {
+			t.Errorf("mountFlag(%+v) = %q, want %q", tt.m, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package container
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/errdefs"
+	"github.com/jakenelson/enclaude/internal/config"
+)
+
+// Networks returns the names of the Docker networks a session can join
+func (r *Runner) Networks(ctx context.Context) ([]string, error) {
+	summaries, err := r.client.NetworkList(ctx, network.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list networks: %w", err)
+	}
+	var names []string
+	for _, n := range summaries {
+		names = append(names, n.Name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// NetworkLabel marks the networks enclaude creates for network.create
+const NetworkLabel = "io.enclaude.network"
+
+// EnsureNetwork creates a user-defined bridge network unless one by that
+// name exists, reporting whether it did. The network outlives the session,
+// so containers started from the host can join it ahead of time.
+func (r *Runner) EnsureNetwork(ctx context.Context, name string) (bool, error) {
+	if _, err := r.client.NetworkInspect(ctx, name, network.InspectOptions{}); err == nil {
+		return false, nil
+	} else if !errdefs.IsNotFound(err) {
+		return false, fmt.Errorf("failed to inspect network %q: %w", name, err)
+	}
+
+	_, err := r.client.NetworkCreate(ctx, name, network.CreateOptions{
+		Driver: "bridge",
+		Labels: map[string]string{NetworkLabel: "true"},
+	})
+	if errdefs.IsConflict(err) {
+		// Another session created it first
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to create network %q: %w", name, err)
+	}
+	return true, nil
+}
+
+// endpointConfig gives the session its DNS aliases on a user-defined network
+func endpointConfig(name string, aliases []string) *network.NetworkingConfig {
+	if len(aliases) == 0 || config.IsBuiltinNetwork(name) {
+		return nil
+	}
+	return &network.NetworkingConfig{
+		EndpointsConfig: map[string]*network.EndpointSettings{name: {Aliases: aliases}},
+	}
+}
+
+// CheckNetwork verifies that a user-defined network exists, so a typo fails
+// with a hint instead of a Docker error after the container is configured
+func (r *Runner) CheckNetwork(ctx context.Context, name string) error {
+	if config.IsBuiltinNetwork(name) {
+		return nil
+	}
+	if _, err := r.client.NetworkInspect(ctx, name, network.InspectOptions{}); err != nil {
+		if errdefs.IsNotFound(err) {
+			return fmt.Errorf("network %q not found; create it with 'docker network create %s' or choose one from 'docker network ls'", name, name)
+		}
+		return fmt.Errorf("failed to inspect network %q: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+package container
+
+import "testing"
+
+func TestEndpointConfig(t *testing.T) {
+	if endpointConfig("bridge", []string{"claude.local"}) != nil {
+		t.Error("endpointConfig() should not set aliases on the default bridge network")
+	}
+	if endpointConfig("enclaude", nil) != nil {
+		t.Error("endpointConfig() without aliases should be nil")
+	}
+
+	cfg := endpointConfig("enclaude", []string{"claude.local"})
+	if cfg == nil || cfg.EndpointsConfig["enclaude"] == nil {
+		t.Fatalf("endpointConfig() = %+v, want an endpoint on enclaude", cfg)
+	}
+	if aliases := cfg.EndpointsConfig["enclaude"].Aliases; len(aliases) != 1 || aliases[0] != "claude.local" {
+		t.Errorf("aliases = %q, want [claude.local]", aliases)
+	}
+}
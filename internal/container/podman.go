@@ -0,0 +1,88 @@
+package container
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+)
+
+// IsPodman reports whether the engine behind the Docker API is Podman's
+// compatibility service, whose build endpoint takes no build secrets, so
+// builds that need them run in the podman CLI instead
+func (r *Runner) IsPodman(ctx context.Context) bool {
+	version, err := r.client.ServerVersion(ctx)
+	if err != nil {
+		return false
+	}
+	return isPodmanVersion(version)
+}
+
+// isPodmanVersion reports whether a version response came from Podman
+func isPodmanVersion(v types.Version) bool {
+	for _, c := range v.Components {
+		if strings.Contains(strings.ToLower(c.Name), "podman") {
+			return true
+		}
+	}
+	return strings.Contains(strings.ToLower(v.Platform.Name), "podman")
+}
+
+// buildWithPodman builds with the podman CLI, for the build secrets
+// Podman's build endpoint does not take. The CLI is pointed at the service
+// enclaude talks to, so the image lands where sessions look for it, which
+// is checked after the build. Images are written in Docker format, as docker
+// build writes them; the OCI format would drop HEALTHCHECK, SHELL, and
+// ONBUILD instructions of custom Dockerfiles.
+func (r *Runner) buildWithPodman(ctx context.Context, opts BuildOptions) error {
+	if _, err := exec.LookPath("podman"); err != nil {
+		return fmt.Errorf("build secrets with Podman require the podman CLI: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, "podman", podmanBuildArgs(r.client.DaemonHost(), opts)...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("podman build failed: %w", err)
+	}
+	if _, _, err := r.client.ImageInspectWithRaw(ctx, opts.Tag); err != nil {
+		return fmt.Errorf("podman built %s, but the engine at %s does not have it: %w", opts.Tag, r.client.DaemonHost(), err)
+	}
+	return nil
+}
+
+// podmanBuildArgs returns the podman arguments for a build on the service
+// at host
+func podmanBuildArgs(host string, opts BuildOptions) []string {
+	args := []string{"--url", host, "build", "--format", "docker", "--layers", "--file", opts.Dockerfile, "--tag", opts.Tag}
+	if opts.NoCache {
+		args = append(args, "--no-cache")
+	}
+	if opts.Platform != "" {
+		args = append(args, "--platform", opts.Platform)
+	}
+	keys := make([]string, 0, len(opts.BuildArgs))
+	for k := range opts.BuildArgs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		args = append(args, "--build-arg", k+"="+opts.BuildArgs[k])
+	}
+	for _, secret := range opts.Secrets {
+		args = append(args, "--secret", secret.String())
+	}
+	labels := make([]string, 0, len(opts.Labels))
+	for k := range opts.Labels {
+		labels = append(labels, k)
+	}
+	sort.Strings(labels)
+	for _, k := range labels {
+		args = append(args, "--label", k+"="+opts.Labels[k])
+	}
+	return append(args, opts.ContextDir)
+}
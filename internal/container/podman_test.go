@@ -0,0 +1,52 @@
+package container
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+)
+
+func TestIsPodmanVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		version types.Version
+		want    bool
+	}{
+		{"podman component", types.Version{Components: []types.ComponentVersion{{Name: "Podman Engine"}}}, true},
+		{"podman platform", types.Version{Platform: struct{ Name string }{"linux/amd64 (Podman)"}}, true},
+		{"docker", types.Version{Platform: struct{ Name string }{"Docker Engine - Community"}, Components: []types.ComponentVersion{{Name: "Engine"}, {Name: "containerd"}}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isPodmanVersion(tt.version); got != tt.want {
+				t.Errorf("isPodmanVersion() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPodmanBuildArgs(t *testing.T) {
+	got := podmanBuildArgs("unix:///run/podman/podman.sock", BuildOptions{
+		Dockerfile: "docker/Containerfile",
+		ContextDir: "docker",
+		Tag:        "enclaude:latest",
+		NoCache:    true,
+		BuildArgs:  map[string]string{"ENCLAUDE_UID": "1000", "ENCLAUDE_GID": "1000"},
+		Secrets:    []BuildSecret{{ID: "npmrc", Source: "/home/me/.npmrc"}},
+		Labels:     map[string]string{BuildInputsLabel: "sha256:abc"},
+	})
+	want := []string{
+		"--url", "unix:///run/podman/podman.sock",
+		"build", "--format", "docker", "--layers", "--file", "docker/Containerfile", "--tag", "enclaude:latest",
+		"--no-cache",
+		"--build-arg", "ENCLAUDE_GID=1000",
+		"--build-arg", "ENCLAUDE_UID=1000",
+		"--secret", "id=npmrc,src=/home/me/.npmrc",
+		"--label", BuildInputsLabel + "=sha256:abc",
+		"docker",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("podmanBuildArgs() =\n%v\nwant\n%v", got, want)
+	}
+}
@@ -0,0 +1,146 @@
+package container
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	containerTypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/strslice"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// PreflightURL is the Anthropic API endpoint checked before starting a session
+const PreflightURL = "https://api.anthropic.com/"
+
+// preflightScript builds the CA bundle the same way the entrypoint does, then
+// probes the API. Any HTTP response counts as reachable; only transport and
+// TLS failures are reported through curl's exit code.
+const preflightScript = `if [ -n "$ENCLAUDE_CA_BUNDLE" ]; then
+    { cat /etc/ssl/certs/ca-certificates.crt 2>/dev/null
+      for f in /usr/local/share/ca-certificates/*; do cat "$f"; echo; done; } > "$ENCLAUDE_CA_BUNDLE"
+    export CURL_CA_BUNDLE="$ENCLAUDE_CA_BUNDLE"
+fi
+exec curl -sS -o /dev/null --max-time 10 "$1"`
+
+// Preflight verifies from a throwaway container that the model API at url is
+// reachable and its certificate chain validates with the session's network,
+// proxy environment, and CA configuration.
+func (r *Runner) Preflight(ctx context.Context, opts RunOptions, url string) error {
+	if err := r.CheckNetwork(ctx, opts.Network); err != nil {
+		return err
+	}
+
+	var env []string
+	for k, v := range opts.Environment {
+		env = append(env, k+"="+v)
+	}
+	mounts, caEnv := caCertMounts(opts.Security.CACerts)
+	env = append(env, caEnv...)
+
+	containerConfig := &containerTypes.Config{
+		Image:      opts.Image,
+		Entrypoint: strslice.StrSlice{"/bin/bash", "-c", preflightScript, "preflight"},
+		Cmd:        strslice.StrSlice{url},
+		Env:        env,
+	}
+	hostConfig := &containerTypes.HostConfig{
+		Mounts:      mounts,
+		NetworkMode: containerTypes.NetworkMode(opts.Network),
+	}
+
+	resp, err := r.client.ContainerCreate(ctx, containerConfig, hostConfig, nil, nil, "")
+	if err != nil {
+		return fmt.Errorf("failed to create preflight container: %w", err)
+	}
+	defer func() {
+		_ = r.client.ContainerRemove(context.Background(), resp.ID, containerTypes.RemoveOptions{Force: true})
+	}()
+
+	if err := r.client.ContainerStart(ctx, resp.ID, containerTypes.StartOptions{}); err != nil {
+		return fmt.Errorf("failed to start preflight container: %w", err)
+	}
+
+	var exitCode int64
+	statusCh, errCh := r.client.ContainerWait(ctx, resp.ID, containerTypes.WaitConditionNotRunning)
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("error waiting for preflight container: %w", err)
+	case status := <-statusCh:
+		exitCode = status.StatusCode
+	}
+	if exitCode == 0 {
+		return nil
+	}
+
+	// Collect curl's error message for the report
+	var stderr bytes.Buffer
+	if logs, err := r.client.ContainerLogs(ctx, resp.ID, containerTypes.LogsOptions{ShowStderr: true}); err == nil {
+		_, _ = stdcopy.StdCopy(&stderr, &stderr, logs)
+		logs.Close()
+	}
+
+	return fmt.Errorf("cannot reach %s: %s\n%s", url, strings.TrimSpace(stderr.String()), PreflightAdvice(exitCode))
+}
+
+// PreflightAdvice returns actionable guidance for a curl exit code
+func PreflightAdvice(exitCode int64) string {
+	switch exitCode {
+	case 5:
+		return "The proxy host could not be resolved. Check HTTPS_PROXY/HTTP_PROXY in environment.passthrough or environment.custom."
+	case 6:
+		return "DNS resolution failed. Check container.network (\"none\" disables networking) and your Docker DNS settings."
+	case 7:
+		return "The connection was refused or unreachable. If you are behind a proxy, pass HTTPS_PROXY through via environment.passthrough."
+	case 28:
+		return "The connection timed out. A firewall or proxy may be blocking outbound HTTPS; configure HTTPS_PROXY if required."
+	case 35, 60, 77:
+		return "TLS verification failed. If a corporate proxy intercepts HTTPS, add its CA certificate to security.ca_certs."
+	case 127:
+		return "curl is not available in the image; preflight checks require curl."
+	default:
+		return fmt.Sprintf("Connectivity check failed (curl exit code %d).", exitCode)
+	}
+}
+
+// HasCommand reports whether name is on the PATH in image, by running a
+// throwaway container with the image's entrypoint bypassed
+func (r *Runner) HasCommand(ctx context.Context, image, name string) (bool, error) {
+	containerConfig := &containerTypes.Config{
+		Image:      image,
+		Entrypoint: strslice.StrSlice{"/bin/sh", "-c", `command -v "$1" >/dev/null`, "has-command"},
+		Cmd:        strslice.StrSlice{name},
+	}
+	hostConfig := &containerTypes.HostConfig{NetworkMode: "none"}
+
+	resp, err := r.client.ContainerCreate(ctx, containerConfig, hostConfig, nil, nil, "")
+	if err != nil {
+		return false, fmt.Errorf("failed to create probe container: %w", err)
+	}
+	defer func() {
+		_ = r.client.ContainerRemove(context.Background(), resp.ID, containerTypes.RemoveOptions{Force: true})
+	}()
+
+	if err := r.client.ContainerStart(ctx, resp.ID, containerTypes.StartOptions{}); err != nil {
+		return false, fmt.Errorf("failed to start probe container: %w", err)
+	}
+
+	statusCh, errCh := r.client.ContainerWait(ctx, resp.ID, containerTypes.WaitConditionNotRunning)
+	select {
+	case err := <-errCh:
+		return false, fmt.Errorf("error waiting for probe container: %w", err)
+	case status := <-statusCh:
+		return status.StatusCode == 0, nil
+	}
+}
+
+// DockerDesktop reports whether the daemon is Docker Desktop rather than a
+// Docker Engine the user runs directly
+func (r *Runner) DockerDesktop(ctx context.Context) (bool, error) {
+	info, err := r.client.Info(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to query Docker: %w", err)
+	}
+	return strings.Contains(info.OperatingSystem, "Docker Desktop"), nil
+}
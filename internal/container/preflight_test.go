@@ -0,0 +1,119 @@
+package container
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	containerTypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+func TestPreflightAdvice(t *testing.T) {
+	tests := []struct {
+		exitCode int64
+		want     string
+	}{
+		{5, "HTTPS_PROXY/HTTP_PROXY"},
+		{6, "DNS resolution failed"},
+		{7, "refused or unreachable"},
+		{28, "timed out"},
+		{35, "security.ca_certs"},
+		{60, "security.ca_certs"},
+		{77, "security.ca_certs"},
+		{127, "curl is not available"},
+		{52, "curl exit code 52"},
+	}
+	for _, tt := range tests {
+		if got := PreflightAdvice(tt.exitCode); !strings.Contains(got, tt.want) {
+			t.Errorf("PreflightAdvice(%d) = %q, want it to mention %q", tt.exitCode, got, tt.want)
+		}
+	}
+}
+
+func TestPreflight(t *testing.T) {
+	tests := []struct {
+		name     string
+		network  string
+		exitCode int64
+		stderr   string
+		wantErr  []string // Substrings of the error; nil for success
+	}{
+		{"reachable", "bridge", 0, "", nil},
+		{"tls failure", "bridge", 60, "curl: (60) SSL certificate problem", []string{"cannot reach " + PreflightURL, "SSL certificate problem", "security.ca_certs"}},
+		{"dns failure", "bridge", 6, "curl: (6) Could not resolve host", []string{"Could not resolve host", "container.network"}},
+		{"missing network", "corp", 0, "", []string{`network "corp" not found`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var created containerTypes.Config
+			removed := false
+			r := fakeDocker(t, func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.URL.Path == "/networks/corp":
+					http.Error(w, `{"message":"network corp not found"}`, http.StatusNotFound)
+				case r.URL.Path == "/containers/create":
+					json.NewDecoder(r.Body).Decode(&created)
+					fmt.Fprint(w, `{"Id":"preflight"}`)
+				case r.URL.Path == "/containers/preflight/start":
+					w.WriteHeader(http.StatusNoContent)
+				case r.URL.Path == "/containers/preflight/wait":
+					fmt.Fprintf(w, `{"StatusCode":%d}`, tt.exitCode)
+				case r.URL.Path == "/containers/preflight/logs":
+					stdcopy.NewStdWriter(w, stdcopy.Stderr).Write([]byte(tt.stderr + "\n"))
+				case r.URL.Path == "/containers/preflight" && r.Method == http.MethodDelete:
+					removed = true
+					w.WriteHeader(http.StatusNoContent)
+				default:
+					http.NotFound(w, r)
+				}
+			})
+
+			opts := RunOptions{
+				Image:       "enclaude:test",
+				Network:     tt.network,
+				Environment: map[string]string{"HTTPS_PROXY": "http://proxy:3128"},
+				Security:    SecurityOptions{CACerts: []string{"/etc/corp-ca.crt"}},
+			}
+			err := r.Preflight(context.Background(), opts, PreflightURL)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("Preflight() error = %v", err)
+				}
+			} else {
+				if err == nil {
+					t.Fatal("Preflight() error = nil, want a failure")
+				}
+				for _, want := range tt.wantErr {
+					if !strings.Contains(err.Error(), want) {
+						t.Errorf("Preflight() error = %q, want it to mention %q", err, want)
+					}
+				}
+			}
+			if tt.network == "corp" {
+				if created.Image != "" {
+					t.Error("Preflight() created a container on a missing network")
+				}
+				return
+			}
+
+			// The probe runs with the session's image, proxy environment,
+			// and CA bundle, and is always removed
+			if created.Image != opts.Image || len(created.Cmd) != 1 || created.Cmd[0] != PreflightURL {
+				t.Errorf("preflight container = image %q cmd %q, want %q probing %s", created.Image, created.Cmd, opts.Image, PreflightURL)
+			}
+			env := strings.Join(created.Env, "\n")
+			for _, want := range []string{"HTTPS_PROXY=http://proxy:3128", "ENCLAUDE_CA_BUNDLE=" + CABundle} {
+				if !strings.Contains(env, want) {
+					t.Errorf("preflight env = %q, want %s", created.Env, want)
+				}
+			}
+			if !removed {
+				t.Error("Preflight() left its container behind")
+			}
+		})
+	}
+}
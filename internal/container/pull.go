@@ -0,0 +1,189 @@
+package container
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types/image"
+	"github.com/jakenelson/enclaude/internal/config"
+	"github.com/moby/term"
+)
+
+// pullBarWidth is the width of the pull progress bar, in cells
+const pullBarWidth = 30
+
+// pullRedraw limits how often the progress line is redrawn
+const pullRedraw = 100 * time.Millisecond
+
+// EnsureImage makes image available locally according to policy: always
+// pulls it, missing pulls it if it is not present, and never leaves a
+// missing image for ContainerCreate to report. Progress is written to
+// progress, as a redrawn bar on a terminal and as start and end lines
+// otherwise.
+func (r *Runner) EnsureImage(ctx context.Context, ref, policy string, progress io.Writer) error {
+	switch policy {
+	case config.PullNever:
+		return nil
+	case config.PullAlways:
+	case config.PullMissing, "":
+		exists, err := r.ImageExists(ctx, ref)
+		if err != nil {
+			return fmt.Errorf("failed to inspect image: %w", err)
+		}
+		if exists {
+			return nil
+		}
+	default:
+		return fmt.Errorf("invalid image.pull_policy %q (allowed: %s, %s, %s)", policy, config.PullAlways, config.PullMissing, config.PullNever)
+	}
+
+	if err := r.pull(ctx, ref, progress); err != nil {
+		if policy == config.PullAlways {
+			return err
+		}
+		return fmt.Errorf("image %q not found locally and could not be pulled (%v); run 'enclaude build' first or set image.name", ref, err)
+	}
+	return nil
+}
+
+// pull pulls ref, rendering the daemon's progress stream
+func (r *Runner) pull(ctx context.Context, ref string, progress io.Writer) error {
+	stream, err := r.client.ImagePull(ctx, ref, image.PullOptions{RegistryAuth: registryAuth(RegistryHost(ref))})
+	if err != nil {
+		return fmt.Errorf("failed to pull %s: %w", ref, err)
+	}
+	defer stream.Close()
+
+	tty := writesToTerminal(progress)
+	if !tty {
+		fmt.Fprintf(progress, "Pulling %s...\n", ref)
+	}
+
+	p := newPullProgress()
+	var drawn time.Time
+	dec := json.NewDecoder(stream)
+	for {
+		var m pullMessage
+		if err := dec.Decode(&m); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if tty {
+				fmt.Fprint(progress, "\r\033[K")
+			}
+			return fmt.Errorf("failed to read pull progress: %w", err)
+		}
+		if m.Error != "" {
+			if tty {
+				fmt.Fprint(progress, "\r\033[K")
+			}
+			return fmt.Errorf("failed to pull %s: %s", ref, m.Error)
+		}
+		p.update(m)
+		if tty && time.Since(drawn) >= pullRedraw {
+			fmt.Fprint(progress, "\r\033[K"+p.line(ref, pullBarWidth))
+			drawn = time.Now()
+		}
+	}
+
+	if tty {
+		fmt.Fprint(progress, "\r\033[K")
+	}
+	fmt.Fprintf(progress, "Pulled %s\n", ref)
+	return nil
+}
+
+// ImagePull is an image pull running in the background. Its progress can
+// be paused while the terminal is needed for something else, such as a
+// prompt.
+type ImagePull struct {
+	done chan struct{}
+	err  error
+	out  *pausableWriter
+}
+
+// StartImagePull runs EnsureImage in the background, so a pull overlaps
+// the rest of preparing a session. Cancel ctx to stop it.
+func (r *Runner) StartImagePull(ctx context.Context, ref, policy string, progress io.Writer) *ImagePull {
+	p := &ImagePull{done: make(chan struct{}), out: &pausableWriter{w: progress, tty: writesToTerminal(progress)}}
+	go func() {
+		defer close(p.done)
+		p.err = r.EnsureImage(ctx, ref, policy, p.out)
+	}()
+	return p
+}
+
+// Wait waits for the pull to finish and returns its error
+func (p *ImagePull) Wait() error {
+	<-p.done
+	return p.err
+}
+
+// Pause holds the pull's progress back, clearing the progress bar, until
+// Resume
+func (p *ImagePull) Pause() {
+	p.out.pause()
+}
+
+// Resume shows the progress held back since Pause
+func (p *ImagePull) Resume() {
+	p.out.resume()
+}
+
+// pausableWriter passes writes through to w unless paused, when it holds
+// them back. Of the progress bar redraws held, only the last is kept.
+type pausableWriter struct {
+	mu     sync.Mutex
+	w      io.Writer
+	tty    bool
+	paused bool
+	held   []byte
+}
+
+func (pw *pausableWriter) Write(p []byte) (int, error) {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+	if !pw.paused {
+		return pw.w.Write(p)
+	}
+	if bytes.HasPrefix(p, []byte("\r")) {
+		pw.held = pw.held[:bytes.LastIndexByte(pw.held, '\n')+1]
+	}
+	pw.held = append(pw.held, p...)
+	return len(p), nil
+}
+
+func (pw *pausableWriter) pause() {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+	if !pw.paused && pw.tty {
+		fmt.Fprint(pw.w, "\r\033[K")
+	}
+	pw.paused = true
+}
+
+func (pw *pausableWriter) resume() {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+	pw.paused = false
+	pw.w.Write(pw.held)
+	pw.held = nil
+}
+
+// writesToTerminal reports whether w writes to a terminal
+func writesToTerminal(w io.Writer) bool {
+	switch w := w.(type) {
+	case *os.File:
+		return term.IsTerminal(w.Fd())
+	case *pausableWriter:
+		return w.tty
+	}
+	return false
+}
@@ -0,0 +1,28 @@
+package container
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestPausableWriter(t *testing.T) {
+	var out bytes.Buffer
+	w := &pausableWriter{w: &out, tty: true}
+
+	fmt.Fprint(w, "\r\033[K10%")
+	w.pause()
+	fmt.Fprint(w, "\r\033[K50%")
+	fmt.Fprint(w, "\r\033[K90%")
+	if got := out.String(); got != "\r\033[K10%\r\033[K" {
+		t.Errorf("output while paused = %q, want the bar cleared and nothing more", got)
+	}
+
+	fmt.Fprint(w, "\r\033[K")
+	fmt.Fprint(w, "Pulled x\n")
+	out.Reset()
+	w.resume()
+	if got := out.String(); got != "\r\033[KPulled x\n" {
+		t.Errorf("output on resume = %q, want only the last redraw and the lines after it", got)
+	}
+}
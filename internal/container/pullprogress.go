@@ -0,0 +1,110 @@
+package container
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/docker/go-units"
+)
+
+// pullMessage is the part of a pull progress message that is displayed. The
+// daemon streams one JSON message per layer event.
+type pullMessage struct {
+	ID             string `json:"id"`
+	Status         string `json:"status"`
+	ProgressDetail struct {
+		Current int64 `json:"current"`
+		Total   int64 `json:"total"`
+	} `json:"progressDetail"`
+	Error string `json:"error"`
+}
+
+// layerProgress is the download state of one image layer
+type layerProgress struct {
+	current, total int64
+	done           bool
+}
+
+// pullProgress aggregates per-layer pull messages into one progress figure
+type pullProgress struct {
+	layers map[string]*layerProgress
+	order  []string
+}
+
+func newPullProgress() *pullProgress {
+	return &pullProgress{layers: make(map[string]*layerProgress)}
+}
+
+// update applies a message. Only messages about layers count; the status
+// lines about the image as a whole carry no progress.
+func (p *pullProgress) update(m pullMessage) {
+	if m.ID == "" || !isLayerStatus(m.Status) {
+		return
+	}
+	l, ok := p.layers[m.ID]
+	if !ok {
+		l = &layerProgress{}
+		p.layers[m.ID] = l
+		p.order = append(p.order, m.ID)
+	}
+	switch m.Status {
+	case "Downloading":
+		l.current, l.total = m.ProgressDetail.Current, m.ProgressDetail.Total
+	case "Download complete", "Verifying Checksum", "Extracting":
+		// Downloaded; extraction is quick next to it, so count it as done
+		l.current = l.total
+	case "Pull complete", "Already exists":
+		l.current = l.total
+		l.done = true
+	}
+}
+
+// isLayerStatus reports whether status describes a layer rather than the
+// image, whose messages also carry an ID (the tag or digest)
+func isLayerStatus(status string) bool {
+	switch status {
+	case "Pulling fs layer", "Waiting", "Downloading", "Verifying Checksum", "Download complete", "Extracting", "Pull complete", "Already exists":
+		return true
+	}
+	return false
+}
+
+// line renders the progress as a single line with a bar of width cells,
+// e.g. "Pulling enclaude:latest [=====>    ] 52% 120MB/230MB, 3/7 layers"
+func (p *pullProgress) line(image string, width int) string {
+	var current, total int64
+	done := 0
+	for _, id := range p.order {
+		l := p.layers[id]
+		current += l.current
+		total += l.total
+		if l.done {
+			done++
+		}
+	}
+
+	fraction := 0.0
+	switch {
+	case total > 0:
+		fraction = float64(current) / float64(total)
+	case len(p.order) > 0:
+		fraction = float64(done) / float64(len(p.order))
+	}
+	if fraction > 1 {
+		fraction = 1
+	}
+
+	filled := int(fraction * float64(width))
+	bar := strings.Repeat("=", filled)
+	if filled < width {
+		bar += ">" + strings.Repeat(" ", width-filled-1)
+	}
+	line := fmt.Sprintf("Pulling %s [%s] %3d%%", image, bar, int(fraction*100))
+	if total > 0 {
+		line += fmt.Sprintf(" %s/%s", units.HumanSize(float64(current)), units.HumanSize(float64(total)))
+	}
+	if len(p.order) > 0 {
+		line += fmt.Sprintf(", %d/%d layers", done, len(p.order))
+	}
+	return line
+}
@@ -0,0 +1,50 @@
+package container
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestPullProgress(t *testing.T) {
+	stream := `{"status":"Pulling from library/enclaude","id":"latest"}
+{"status":"Already exists","id":"a1"}
+{"status":"Pulling fs layer","id":"b2"}
+{"status":"Pulling fs layer","id":"c3"}
+{"status":"Downloading","progressDetail":{"current":50000000,"total":100000000},"id":"b2"}
+{"status":"Downloading","progressDetail":{"current":0,"total":100000000},"id":"c3"}
+`
+	p := newPullProgress()
+	dec := json.NewDecoder(strings.NewReader(stream))
+	for dec.More() {
+		var m pullMessage
+		if err := dec.Decode(&m); err != nil {
+			t.Fatal(err)
+		}
+		p.update(m)
+	}
+
+	got := p.line("enclaude:latest", 10)
+	want := "Pulling enclaude:latest [==>       ]  25% 50MB/200MB, 1/3 layers"
+	if got != want {
+		t.Errorf("line() = %q, want %q", got, want)
+	}
+
+	for _, id := range []string{"b2", "c3"} {
+		p.update(pullMessage{ID: id, Status: "Pull complete"})
+	}
+	got = p.line("enclaude:latest", 10)
+	want = "Pulling enclaude:latest [==========] 100% 200MB/200MB, 3/3 layers"
+	if got != want {
+		t.Errorf("line() after completion = %q, want %q", got, want)
+	}
+}
+
+func TestPullProgressWithoutSizes(t *testing.T) {
+	p := newPullProgress()
+	p.update(pullMessage{ID: "a1", Status: "Already exists"})
+	p.update(pullMessage{ID: "b2", Status: "Waiting"})
+	if got, want := p.line("img", 4), "Pulling img [==> ]  50%, 1/2 layers"; got != want {
+		t.Errorf("line() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,129 @@
+package container
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	containerTypes "github.com/docker/docker/api/types/container"
+	"github.com/moby/term"
+)
+
+// ReadyFileLabel names the file an image's entrypoint creates once the
+// session is set up, just before it starts claude. Images without it are
+// only gated when a health command is configured.
+const ReadyFileLabel = "io.enclaude.ready-file"
+
+// readyPollInterval is how often the health command runs while waiting
+const readyPollInterval = 200 * time.Millisecond
+
+// spinnerFrames are drawn while waiting for the session to become ready
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// healthCommand returns the shell command that reports the session ready:
+// the configured one, or a check for the image's ready file. Empty means the
+// session is not gated.
+func (r *Runner) healthCommand(ctx context.Context, opts RunOptions) string {
+	if opts.ReadyTimeout <= 0 {
+		return ""
+	}
+	if opts.ReadyCheck != "" {
+		return opts.ReadyCheck
+	}
+	labels, err := r.ImageLabels(ctx, opts.Image)
+	if err != nil || labels[ReadyFileLabel] == "" {
+		return ""
+	}
+	return "test -e " + labels[ReadyFileLabel]
+}
+
+// healthConfig makes the health command the container's Docker healthcheck,
+// so `docker ps` shows whether a session came up
+func healthConfig(command string) *containerTypes.HealthConfig {
+	if command == "" {
+		return nil
+	}
+	return &containerTypes.HealthConfig{
+		Test:     []string{"CMD-SHELL", command},
+		Interval: 30 * time.Second,
+		Timeout:  5 * time.Second,
+		Retries:  3,
+	}
+}
+
+// waitReady runs command in the container until it succeeds, the container
+// stops, or timeout passes, drawing a spinner on a terminal meanwhile. Not
+// becoming ready is reported but not an error: the session is attached
+// anyway.
+func (r *Runner) waitReady(ctx context.Context, containerID, command string, timeout time.Duration) error {
+	spin := term.IsTerminal(os.Stderr.Fd())
+	deadline := time.Now().Add(timeout)
+	defer func() {
+		if spin {
+			fmt.Fprint(os.Stderr, "\r\x1b[K")
+		}
+	}()
+
+	for frame := 0; ; frame++ {
+		if _, err := r.exec(ctx, containerID, []string{"/bin/sh", "-c", command}, nil); err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if inspect, err := r.client.ContainerInspect(ctx, containerID); err == nil && inspect.State != nil && !inspect.State.Running {
+			// The session ended or crashed; its output and exit status tell why
+			return nil
+		}
+		if time.Now().After(deadline) {
+			if spin {
+				fmt.Fprint(os.Stderr, "\r\x1b[K")
+				spin = false
+			}
+			fmt.Fprintf(os.Stderr, "Warning: the session did not report ready within %s (health command: %s); attaching anyway\n", timeout, command)
+			return nil
+		}
+		if spin {
+			fmt.Fprintf(os.Stderr, "\r%s Waiting for the session to start...", spinnerFrames[frame%len(spinnerFrames)])
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(readyPollInterval):
+		}
+	}
+}
+
+// readyGate holds output back until the session is ready, so early setup
+// output does not interleave with the spinner, then passes it through
+type readyGate struct {
+	mu     sync.Mutex
+	out    io.Writer
+	held   bytes.Buffer
+	opened bool
+}
+
+func (g *readyGate) Write(p []byte) (int, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.opened {
+		return g.held.Write(p)
+	}
+	return g.out.Write(p)
+}
+
+// open writes out the held output and passes everything after it through
+func (g *readyGate) open() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.opened {
+		return
+	}
+	g.opened = true
+	g.out.Write(g.held.Bytes())
+	g.held.Reset()
+}
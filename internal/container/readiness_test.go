@@ -0,0 +1,33 @@
+package container
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestReadyGate(t *testing.T) {
+	var out bytes.Buffer
+	gate := &readyGate{out: &out}
+
+	gate.Write([]byte("setting up\n"))
+	if out.Len() != 0 {
+		t.Fatalf("output before open = %q, want none", out.String())
+	}
+
+	gate.open()
+	gate.Write([]byte("ready\n"))
+	gate.open()
+	if out.String() != "setting up\nready\n" {
+		t.Errorf("output = %q, want %q", out.String(), "setting up\nready\n")
+	}
+}
+
+func TestHealthConfig(t *testing.T) {
+	if healthConfig("") != nil {
+		t.Error("healthConfig(\"\") should not set a healthcheck")
+	}
+	hc := healthConfig("test -e /tmp/ready")
+	if hc == nil || len(hc.Test) != 2 || hc.Test[0] != "CMD-SHELL" || hc.Test[1] != "test -e /tmp/ready" {
+		t.Errorf("healthConfig() = %+v, want a CMD-SHELL test running the command", hc)
+	}
+}
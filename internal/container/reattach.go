@@ -0,0 +1,151 @@
+package container
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	containerTypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/jakenelson/enclaude/internal/terminal"
+)
+
+const (
+	// maxReattaches bounds how many times a session re-attaches after losing
+	// the Docker connection, so a persistent API fault cannot loop forever
+	maxReattaches = 5
+
+	// reconnectTimeout is how long to wait for the daemon to come back
+	reconnectTimeout = 60 * time.Second
+)
+
+// attachment holds the current attach connection for a session. It is
+// replaced when the session re-attaches after a Docker connection loss.
+type attachment struct {
+	mu   sync.Mutex
+	resp types.HijackedResponse
+}
+
+// Write sends input to the container over the current connection
+func (a *attachment) Write(p []byte) (int, error) {
+	a.mu.Lock()
+	conn := a.resp.Conn
+	a.mu.Unlock()
+	return conn.Write(p)
+}
+
+// CloseWrite signals end of input on the current connection
+func (a *attachment) CloseWrite() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.resp.CloseWrite()
+}
+
+// Replace swaps in a new connection, closing the old one
+func (a *attachment) Replace(resp types.HijackedResponse) {
+	a.mu.Lock()
+	old := a.resp
+	a.resp = resp
+	a.mu.Unlock()
+	old.Close()
+}
+
+// Close closes the current connection
+func (a *attachment) Close() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.resp.Close()
+}
+
+// pumpOutput copies TTY output from an attach connection to out until it ends
+func pumpOutput(resp types.HijackedResponse, out io.Writer, done chan<- error) {
+	defer terminal.Recover()
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := resp.Reader.Read(buf)
+		if n > 0 {
+			out.Write(buf[:n])
+			os.Stdout.Sync()
+		}
+		if err != nil {
+			if lw, ok := out.(*linkWriter); ok {
+				lw.Flush()
+			}
+			done <- err
+			return
+		}
+	}
+}
+
+// followLogs streams demultiplexed container logs to stdout and stderr.
+// since limits output to entries after a timestamp, so a re-attach does not
+// replay output already shown.
+func (r *Runner) followLogs(ctx context.Context, containerID, since string, stdout, stderr io.Writer, done chan<- error) {
+	logs, err := r.client.ContainerLogs(ctx, containerID, containerTypes.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     true,
+		Since:      since,
+	})
+	if err != nil {
+		done <- err
+		return
+	}
+	defer logs.Close()
+	_, err = stdcopy.StdCopy(stdout, stderr, logs)
+	done <- err
+}
+
+// recoverWait handles waiting on the container failing with err after the
+// session has re-attached reattaches times. It returns nil once the daemon is
+// back and the container still running, so the session can re-attach, and
+// gives up after maxReattaches.
+func (r *Runner) recoverWait(ctx context.Context, containerID string, reattaches int, err error) error {
+	if reattaches >= maxReattaches {
+		return fmt.Errorf("error waiting for container: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "\r\nenclaude: lost connection to Docker (%v); reconnecting...\r\n", err)
+	return r.awaitContainer(ctx, containerID)
+}
+
+// awaitContainer waits for the Docker daemon to become reachable again and
+// returns nil if the container is still running. Otherwise it returns an
+// error describing what happened to the container.
+func (r *Runner) awaitContainer(ctx context.Context, containerID string) error {
+	deadline := time.Now().Add(reconnectTimeout)
+	delay := 500 * time.Millisecond
+
+	for {
+		info, err := r.client.ContainerInspect(ctx, containerID)
+		if err == nil {
+			if info.State.Running {
+				return nil
+			}
+			if info.State.OOMKilled {
+				return fmt.Errorf("container was killed (out of memory) while Docker was unavailable")
+			}
+			return fmt.Errorf("container stopped while Docker was unavailable (exit code %d); the daemon may have restarted without live-restore", info.State.ExitCode)
+		}
+		if client.IsErrNotFound(err) {
+			return fmt.Errorf("container no longer exists after Docker reconnect")
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("could not reconnect to Docker within %s: %w", reconnectTimeout, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		if delay < 5*time.Second {
+			delay *= 2
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// inspectDaemon fakes a daemon that is unreachable for the first down
+// inspects and then reports the container with state, a ContainerInspect
+// State object, or as missing when state is ""
+func inspectDaemon(t *testing.T, down int32, state string) (*Runner, *atomic.Int32) {
+	t.Helper()
+	var inspects atomic.Int32
+	r := fakeDocker(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/containers/session/json" {
+			http.NotFound(w, r)
+			return
+		}
+		switch n := inspects.Add(1); {
+		case n <= down:
+			http.Error(w, `{"message":"daemon restarting"}`, http.StatusServiceUnavailable)
+		case state == "":
+			http.Error(w, `{"message":"No such container: session"}`, http.StatusNotFound)
+		default:
+			fmt.Fprintf(w, `{"Id":"session","State":%s}`, state)
+		}
+	})
+	return r, &inspects
+}
+
+func TestAwaitContainer(t *testing.T) {
+	tests := []struct {
+		name    string
+		down    int32
+		state   string
+		wantErr string // "" when the session can re-attach
+	}{
+		{"running", 0, `{"Running":true}`, ""},
+		{"running after the daemon returns", 1, `{"Running":true}`, ""},
+		{"stopped while away", 0, `{"Running":false,"ExitCode":137}`, "stopped while Docker was unavailable (exit code 137)"},
+		{"out of memory", 0, `{"Running":false,"OOMKilled":true,"ExitCode":137}`, "out of memory"},
+		{"not found", 0, "", "no longer exists"},
+		{"not found after the daemon returns", 1, "", "no longer exists"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, inspects := inspectDaemon(t, tt.down, tt.state)
+			err := r.awaitContainer(context.Background(), "session")
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("awaitContainer() error = %v, want the container running", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("awaitContainer() error = %v, want %q", err, tt.wantErr)
+			}
+			if got := inspects.Load(); got != tt.down+1 {
+				t.Errorf("awaitContainer() inspected %d times, want %d", got, tt.down+1)
+			}
+		})
+	}
+}
+
+func TestAwaitContainerCancelled(t *testing.T) {
+	r, _ := inspectDaemon(t, 1<<30, "")
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := r.awaitContainer(ctx, "session"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("awaitContainer() error = %v, want the context's error while the daemon is down", err)
+	}
+}
+
+func TestRecoverWait(t *testing.T) {
+	lost := errors.New("unexpected EOF")
+	r, inspects := inspectDaemon(t, 0, `{"Running":true}`)
+
+	// Each lost connection re-attaches to a running container, up to the
+	// limit
+	for i := 0; i < maxReattaches; i++ {
+		if err := r.recoverWait(context.Background(), "session", i, lost); err != nil {
+			t.Fatalf("recoverWait() after %d re-attaches error = %v", i, err)
+		}
+	}
+	err := r.recoverWait(context.Background(), "session", maxReattaches, lost)
+	if err == nil || !errors.Is(err, lost) {
+		t.Errorf("recoverWait() after %d re-attaches error = %v, want the wait error", maxReattaches, err)
+	}
+	if got := inspects.Load(); got != maxReattaches {
+		t.Errorf("recoverWait() inspected %d times, want %d; the last loss should give up without waiting", got, maxReattaches)
+	}
+
+	// A container that stopped while the daemon was away ends the session
+	r, _ = inspectDaemon(t, 0, `{"Running":false,"ExitCode":1}`)
+	if err := r.recoverWait(context.Background(), "session", 0, lost); err == nil || !strings.Contains(err.Error(), "stopped") {
+		t.Errorf("recoverWait() for a stopped container error = %v, want it reported", err)
+	}
+	r, _ = inspectDaemon(t, 0, "")
+	if err := r.recoverWait(context.Background(), "session", 0, lost); err == nil || !strings.Contains(err.Error(), "no longer exists") {
+		t.Errorf("recoverWait() for a removed container error = %v, want it reported", err)
+	}
+}
@@ -0,0 +1,271 @@
+package container
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// dockerHub is the registry host of references without one
+const dockerHub = "docker.io"
+
+// RegistryHost returns the registry of an image reference. As in Docker, the
+// first path component names a registry only if it contains a dot or a colon
+// or is localhost; anything else is on Docker Hub.
+func RegistryHost(ref string) string {
+	first, _, found := strings.Cut(ref, "/")
+	if !found || (!strings.ContainsAny(first, ".:") && first != "localhost") {
+		return dockerHub
+	}
+	return first
+}
+
+// Repository returns ref without its tag or digest
+func Repository(ref string) string {
+	ref, _, _ = strings.Cut(ref, "@")
+	slash := strings.LastIndex(ref, "/")
+	if i := strings.LastIndex(ref, ":"); i > slash {
+		return ref[:i]
+	}
+	return ref
+}
+
+// RegistryTag places the tag of tag, such as "v1" in "enclaude:v1", on
+// repository, such as ghcr.io/acme/enclaude. A repository that already has a
+// tag or digest is returned unchanged.
+func RegistryTag(repository, tag string) string {
+	repository = strings.TrimSuffix(repository, "/")
+	if Repository(repository) != repository {
+		return repository
+	}
+	if name := Repository(tag); name != tag {
+		return repository + tag[len(name):]
+	}
+	return repository + ":latest"
+}
+
+// dockerConfig is the part of the docker CLI config that records logins
+type dockerConfig struct {
+	Auths       map[string]json.RawMessage `json:"auths"`
+	CredsStore  string                     `json:"credsStore"`
+	CredHelpers map[string]string          `json:"credHelpers"`
+}
+
+// LoggedIn reports whether the docker CLI has credentials for host, stored in
+// its config file, a credential helper, or its credential store
+func LoggedIn(host string) bool {
+	cfg, ok := loadDockerConfig()
+	return ok && cfg.hasLogin(host, storedServers)
+}
+
+// loadDockerConfig reads the docker CLI config from DOCKER_CONFIG or
+// ~/.docker
+func loadDockerConfig() (dockerConfig, bool) {
+	var cfg dockerConfig
+	dir := os.Getenv("DOCKER_CONFIG")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return cfg, false
+		}
+		dir = filepath.Join(home, ".docker")
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	if err != nil {
+		return cfg, false
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, false
+	}
+	return cfg, true
+}
+
+// RegistryCredentials returns the docker CLI's login for host, for talking
+// to the registry directly
+func RegistryCredentials(host string) (user, secret string, ok bool) {
+	cfg, found := loadDockerConfig()
+	if !found {
+		return "", "", false
+	}
+	return cfg.credentials(host, helperCredentials)
+}
+
+// registryAuth returns the docker CLI's credentials for host, encoded as the
+// Engine API expects them, or "" to pull anonymously. The daemon does not
+// read the CLI's logins itself.
+func registryAuth(host string) string {
+	user, secret, ok := RegistryCredentials(host)
+	if !ok {
+		return ""
+	}
+	server := host
+	if host == dockerHub {
+		server = dockerHubServer
+	}
+	data, err := json.Marshal(map[string]string{"username": user, "password": secret, "serveraddress": server})
+	if err != nil {
+		return ""
+	}
+	return base64.URLEncoding.EncodeToString(data)
+}
+
+// dockerHubServer is the server address the docker CLI stores Docker Hub
+// logins under
+const dockerHubServer = "https://index.docker.io/v1/"
+
+// credentials finds the login for host: from its credential helper, an
+// inline auths entry, or the credential store, asking helpers through get
+func (cfg dockerConfig) credentials(host string, get func(helper, server string) (string, string, bool)) (user, secret string, ok bool) {
+	server := host
+	if host == dockerHub {
+		server = dockerHubServer
+	}
+	if helper, found := cfg.CredHelpers[host]; found {
+		return get(helper, server)
+	}
+	for s, raw := range cfg.Auths {
+		if serverHost(s) != host {
+			continue
+		}
+		var entry struct {
+			Auth string `json:"auth"`
+		}
+		if json.Unmarshal(raw, &entry) != nil || entry.Auth == "" {
+			continue
+		}
+		decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+		if err != nil {
+			continue
+		}
+		if user, secret, found := strings.Cut(string(decoded), ":"); found {
+			return user, secret, true
+		}
+	}
+	if cfg.CredsStore != "" {
+		return get(cfg.CredsStore, server)
+	}
+	return "", "", false
+}
+
+// helperCredentials asks docker-credential-<helper> for the login to server
+func helperCredentials(helper, server string) (string, string, bool) {
+	cmd := exec.Command("docker-credential-"+helper, "get")
+	cmd.Stdin = strings.NewReader(server)
+	out, err := cmd.Output()
+	if err != nil {
+		return "", "", false
+	}
+	var creds struct {
+		Username string `json:"Username"`
+		Secret   string `json:"Secret"`
+	}
+	if err := json.Unmarshal(out, &creds); err != nil || creds.Secret == "" {
+		return "", "", false
+	}
+	return creds.Username, creds.Secret, true
+}
+
+// hasLogin checks cfg for credentials for host. Entries under auths may be
+// empty placeholders when a credential store holds the secret, so the store
+// is asked through list.
+func (cfg dockerConfig) hasLogin(host string, list func(store string) []string) bool {
+	if _, ok := cfg.CredHelpers[host]; ok {
+		return true
+	}
+	servers := list(cfg.CredsStore)
+	for server, auth := range cfg.Auths {
+		if len(auth) > 2 { // More than "{}"
+			servers = append(servers, server)
+		}
+	}
+	for _, server := range servers {
+		if serverHost(server) == host {
+			return true
+		}
+	}
+	return false
+}
+
+// serverHost normalizes a docker login server address to a registry host
+func serverHost(server string) string {
+	server = strings.TrimPrefix(strings.TrimPrefix(server, "https://"), "http://")
+	server, _, _ = strings.Cut(server, "/")
+	if server == "index.docker.io" || server == "registry-1.docker.io" {
+		return dockerHub
+	}
+	return server
+}
+
+// storedServers lists the servers a docker credential store holds
+// credentials for
+func storedServers(store string) []string {
+	if store == "" {
+		return nil
+	}
+	out, err := exec.Command("docker-credential-"+store, "list").Output()
+	if err != nil {
+		return nil
+	}
+	var servers map[string]string
+	if err := json.Unmarshal(out, &servers); err != nil {
+		return nil
+	}
+	var list []string
+	for server := range servers {
+		list = append(list, server)
+	}
+	return list
+}
+
+// Login logs the docker CLI in to host. Given a username, the password or
+// token is passed on stdin; otherwise docker login prompts on the terminal.
+func Login(ctx context.Context, host, username, password string) error {
+	args := []string{"login", host}
+	if username != "" {
+		args = append(args, "--username", username, "--password-stdin")
+	}
+	cmd := exec.CommandContext(ctx, "docker", args...)
+	if username != "" {
+		cmd.Stdin = strings.NewReader(password)
+	} else {
+		cmd.Stdin = os.Stdin
+	}
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("docker login %s failed: %w", host, err)
+	}
+	return nil
+}
+
+// RemoteDigest returns the manifest digest a registry serves for ref, the
+// value to pin the image by
+func (r *Runner) RemoteDigest(ctx context.Context, ref string) (string, error) {
+	out, err := exec.CommandContext(ctx, "docker", "buildx", "imagetools", "inspect", ref, "--format", "{{json .Manifest}}").Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return "", fmt.Errorf("docker buildx imagetools inspect failed: %s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("docker buildx imagetools inspect failed: %w", err)
+	}
+	return parseManifestDigest(out)
+}
+
+// parseManifestDigest reads the digest from an imagetools manifest descriptor
+func parseManifestDigest(data []byte) (string, error) {
+	var manifest struct {
+		Digest string `json:"digest"`
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return "", fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	if manifest.Digest == "" {
+		return "", fmt.Errorf("registry returned no digest")
+	}
+	return manifest.Digest, nil
+}
@@ -0,0 +1,117 @@
+package container
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRegistryHost(t *testing.T) {
+	tests := map[string]string{
+		"enclaude:latest":                "docker.io",
+		"acme/enclaude:latest":           "docker.io",
+		"ghcr.io/acme/enclaude:latest":   "ghcr.io",
+		"localhost/enclaude":             "localhost",
+		"registry.local:5000/enclaude:1": "registry.local:5000",
+	}
+	for ref, want := range tests {
+		if got := RegistryHost(ref); got != want {
+			t.Errorf("RegistryHost(%q) = %q, want %q", ref, got, want)
+		}
+	}
+}
+
+func TestRegistryTag(t *testing.T) {
+	tests := []struct {
+		repository, tag, want string
+	}{
+		{"ghcr.io/acme/enclaude", "enclaude:latest", "ghcr.io/acme/enclaude:latest"},
+		{"ghcr.io/acme/enclaude/", "enclaude:v2", "ghcr.io/acme/enclaude:v2"},
+		{"localhost:5000/enclaude", "enclaude", "localhost:5000/enclaude:latest"},
+		{"ghcr.io/acme/enclaude:stable", "enclaude:latest", "ghcr.io/acme/enclaude:stable"},
+	}
+	for _, tt := range tests {
+		if got := RegistryTag(tt.repository, tt.tag); got != tt.want {
+			t.Errorf("RegistryTag(%q, %q) = %q, want %q", tt.repository, tt.tag, got, tt.want)
+		}
+	}
+
+	if got := Repository("localhost:5000/enclaude:v1"); got != "localhost:5000/enclaude" {
+		t.Errorf("Repository() = %q", got)
+	}
+}
+
+func TestHasLogin(t *testing.T) {
+	var cfg dockerConfig
+	data := `{
+		"auths": {"https://index.docker.io/v1/": {"auth": "dXNlcjpwYXNz"}, "ghcr.io": {}},
+		"credHelpers": {"123456789012.dkr.ecr.us-east-1.amazonaws.com": "ecr-login"},
+		"credsStore": "desktop"
+	}`
+	if err := json.Unmarshal([]byte(data), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	store := func(name string) []string {
+		if name != "desktop" {
+			t.Errorf("credential store = %q, want desktop", name)
+		}
+		return []string{"https://ghcr.io"}
+	}
+
+	tests := map[string]bool{
+		"docker.io": true,
+		"ghcr.io":   true,
+		"123456789012.dkr.ecr.us-east-1.amazonaws.com": true,
+		"quay.io": false,
+	}
+	for host, want := range tests {
+		if got := cfg.hasLogin(host, store); got != want {
+			t.Errorf("hasLogin(%q) = %v, want %v", host, got, want)
+		}
+	}
+	noStore := func(string) []string { return nil }
+	if cfg.hasLogin("ghcr.io", noStore) {
+		t.Error("an empty auths entry without a stored credential should not count as a login")
+	}
+}
+
+func TestCredentials(t *testing.T) {
+	var cfg dockerConfig
+	data := `{
+		"auths": {"https://index.docker.io/v1/": {"auth": "dXNlcjpwYXNz"}, "ghcr.io": {}},
+		"credHelpers": {"123456789012.dkr.ecr.us-east-1.amazonaws.com": "ecr-login"},
+		"credsStore": "desktop"
+	}`
+	if err := json.Unmarshal([]byte(data), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	get := func(helper, server string) (string, string, bool) {
+		return helper, server, true
+	}
+
+	tests := map[string][2]string{
+		"docker.io": {"user", "pass"},
+		"ghcr.io":   {"desktop", "ghcr.io"},
+		"123456789012.dkr.ecr.us-east-1.amazonaws.com": {"ecr-login", "123456789012.dkr.ecr.us-east-1.amazonaws.com"},
+	}
+	for host, want := range tests {
+		user, secret, ok := cfg.credentials(host, get)
+		if !ok || user != want[0] || secret != want[1] {
+			t.Errorf("credentials(%q) = %q, %q, %v; want %q, %q", host, user, secret, ok, want[0], want[1])
+		}
+	}
+
+	cfg.CredsStore = ""
+	if _, _, ok := cfg.credentials("quay.io", get); ok {
+		t.Error("credentials found for a registry without a login")
+	}
+}
+
+func TestParseManifestDigest(t *testing.T) {
+	got, err := parseManifestDigest([]byte(`{"mediaType":"application/vnd.oci.image.index.v1+json","digest":"sha256:abc","size":856}`))
+	if err != nil || got != "sha256:abc" {
+		t.Errorf("parseManifestDigest() = %q, %v", got, err)
+	}
+	if _, err := parseManifestDigest([]byte(`{}`)); err == nil {
+		t.Error("parseManifestDigest() expected error for missing digest")
+	}
+}
@@ -0,0 +1,73 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/docker/docker/errdefs"
+)
+
+// retryDelays are the waits between attempts at a Docker API call that
+// failed transiently. Together they give Docker Desktop about 15 seconds to
+// wake from resource saver mode.
+var retryDelays = []time.Duration{
+	500 * time.Millisecond,
+	time.Second,
+	2 * time.Second,
+	4 * time.Second,
+	8 * time.Second,
+}
+
+// withRetry calls fn until it succeeds, fails with an error that retrying
+// will not fix, or the retries run out. what names the call in progress
+// messages, e.g. "create the container".
+func withRetry(ctx context.Context, what string, fn func() error) error {
+	err := fn()
+	for i, delay := range retryDelays {
+		if !isTransient(err) {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Docker did not respond to %s (%v); retrying in %s (%d/%d)...\n", what, err, delay, i+1, len(retryDelays))
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return err
+		}
+		err = fn()
+	}
+	return err
+}
+
+// isTransient reports whether err is a daemon hiccup worth retrying: a
+// dropped connection, or a server error other than the container runtime
+// refusing the configuration. A daemon that is not running at all is not
+// retried, so that error is reported at once.
+func isTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	msg := err.Error()
+	if errdefs.IsUnavailable(err) {
+		return true
+	}
+	if errdefs.IsSystem(err) {
+		for _, permanent := range []string{"OCI runtime", "already allocated", "address already in use", "No such image"} {
+			if strings.Contains(msg, permanent) {
+				return false
+			}
+		}
+		return true
+	}
+	// Transport errors are not always wrapped, only described
+	return strings.HasSuffix(msg, ": EOF") || strings.Contains(msg, "connection reset by peer")
+}
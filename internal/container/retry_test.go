@@ -0,0 +1,74 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/errdefs"
+)
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"EOF", fmt.Errorf("error during connect: %w", io.EOF), true},
+		{"connection reset", fmt.Errorf("read: %w", syscall.ECONNRESET), true},
+		{"described reset", errors.New("read unix @->/var/run/docker.sock: read: connection reset by peer"), true},
+		{"unavailable", errdefs.Unavailable(errors.New("daemon is starting")), true},
+		{"server error", errdefs.System(errors.New("containerd: timeout")), true},
+		{"runtime refusal", errdefs.System(errors.New("OCI runtime create failed: exec: \"claude\": not found")), false},
+		{"port in use", errdefs.System(errors.New("Bind for 0.0.0.0:8080 failed: port is already allocated")), false},
+		{"not found", errdefs.NotFound(errors.New("No such image: enclaude:latest")), false},
+		{"cancelled", context.Canceled, false},
+	}
+	for _, tt := range tests {
+		if got := isTransient(tt.err); got != tt.want {
+			t.Errorf("isTransient(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	saved := retryDelays
+	retryDelays = []time.Duration{time.Millisecond, time.Millisecond}
+	defer func() { retryDelays = saved }()
+
+	calls := 0
+	err := withRetry(context.Background(), "test", func() error {
+		calls++
+		if calls < 2 {
+			return io.EOF
+		}
+		return nil
+	})
+	if err != nil || calls != 2 {
+		t.Errorf("recovering call: err = %v after %d calls, want nil after 2", err, calls)
+	}
+
+	calls = 0
+	err = withRetry(context.Background(), "test", func() error {
+		calls++
+		return io.EOF
+	})
+	if !errors.Is(err, io.EOF) || calls != 3 {
+		t.Errorf("failing call: err = %v after %d calls, want EOF after 3", err, calls)
+	}
+
+	calls = 0
+	permanent := errdefs.InvalidParameter(errors.New("invalid mount config"))
+	err = withRetry(context.Background(), "test", func() error {
+		calls++
+		return permanent
+	})
+	if err != permanent || calls != 1 {
+		t.Errorf("permanent error: err = %v after %d calls, want it after 1", err, calls)
+	}
+}
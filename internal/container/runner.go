@@ -8,21 +8,31 @@ import (
 	"io"
 	"os"
 	"os/signal"
+	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/docker/docker/api/types"
 	containerTypes "github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/api/types/strslice"
 	"github.com/docker/docker/client"
-	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/docker/go-connections/nat"
 	"github.com/docker/go-units"
 	"github.com/jakenelson/enclaude/internal/config"
+	"github.com/jakenelson/enclaude/internal/security"
+	"github.com/jakenelson/enclaude/internal/terminal"
 	"github.com/moby/term"
 )
 
+// stopTimeout is how many seconds the container gets to exit after SIGTERM
+// before Docker kills it
+const stopTimeout = 5
+
 // Runner manages Docker container operations
 type Runner struct {
 	client *client.Client
@@ -35,8 +45,12 @@ func NewRunner() (*Runner, error) {
 		return nil, fmt.Errorf("failed to create Docker client: %w", err)
 	}
 
-	// Verify connection
-	if _, err := cli.Ping(context.Background()); err != nil {
+	// Verify connection, giving a waking Docker Desktop time to respond
+	ctx := context.Background()
+	if err := withRetry(ctx, "ping", func() error {
+		_, err := cli.Ping(ctx)
+		return err
+	}); err != nil {
 		return nil, fmt.Errorf("failed to connect to Docker: %w", err)
 	}
 
@@ -50,6 +64,395 @@ func (r *Runner) Close() error {
 
 // Run creates and runs a container with the given options
 func (r *Runner) Run(ctx context.Context, cancel context.CancelFunc, opts RunOptions) error {
+	// Determine if we should use TTY mode
+	isTTY := term.IsTerminal(os.Stdin.Fd()) && !opts.NoTTY
+	execMode := opts.Interaction == config.InteractionExec
+
+	// Without a TTY, split stderr using the demultiplexed log stream
+	stdout, stderr := io.Writer(os.Stdout), io.Writer(os.Stderr)
+	if opts.Stdout != nil {
+		stdout = opts.Stdout
+	}
+	splitStderr := opts.StderrFile != "" && !isTTY
+	if splitStderr {
+		stderrFile, err := os.OpenFile(opts.StderrFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to open stderr file: %w", err)
+		}
+		defer stderrFile.Close()
+		// The file outlives the session, so keep secrets out of it
+		redacted := security.NewRedactingWriter(stderrFile)
+		defer redacted.Close()
+		stderr = redacted
+	}
+
+	// Post-process the non-TTY stream; a TUI cannot be filtered line by line
+	if !isTTY && len(opts.Filters) > 0 {
+		filtered, err := newOutputFilters(stdout, opts.Filters, opts.WorkDir, opts.HostWorkDir)
+		if err != nil {
+			return err
+		}
+		defer closeFilters(filtered)
+		stdout = filtered
+	}
+
+	// Record what the terminal shows, which excludes split stderr
+	if opts.TeeFile != "" {
+		tee, err := openTee(opts.TeeFile, opts.TeeRaw)
+		if err != nil {
+			return err
+		}
+		defer tee.Close()
+		var teeOut io.Writer = tee
+		if len(opts.Filters) > 0 {
+			filtered, err := newOutputFilters(tee, opts.Filters, opts.WorkDir, opts.HostWorkDir)
+			if err != nil {
+				return err
+			}
+			defer closeFilters(filtered)
+			teeOut = filtered
+		}
+		stdout = io.MultiWriter(stdout, teeOut)
+		if !splitStderr {
+			stderr = io.MultiWriter(os.Stderr, teeOut)
+		}
+	}
+
+	// Lower the CPU limit while the session has no terminal input or output
+	var idle *idleWatch
+	var idleShrink, idleRestore containerTypes.Resources
+	if opts.IdleAfter > 0 {
+		var err error
+		if idleShrink, idleRestore, err = idleResources(opts.CPUs, opts.IdleCPUs); err != nil {
+			return err
+		}
+		idle = newIdleWatch()
+		stdout, stderr = idle.writer(stdout), idle.writer(stderr)
+	}
+
+	// Gate attaching on the session reporting ready, if the image or config
+	// says how to tell
+	opts.ReadyCheck = r.healthCommand(ctx, opts)
+
+	containerID, err := r.createContainer(ctx, opts, isTTY)
+	if err != nil {
+		return err
+	}
+
+	// Ensure cleanup, unless the user detached and left the container running
+	detached := false
+	defer func() {
+		if detached {
+			return
+		}
+		// Container should auto-remove, but force cleanup if needed
+		_ = r.client.ContainerRemove(context.Background(), containerID, containerTypes.RemoveOptions{
+			Force: true,
+		})
+	}()
+
+	// Attach to container (stdin always, stdout/stderr only for TTY). In
+	// exec mode claude is started once the container is up instead.
+	attachOpts := containerTypes.AttachOptions{
+		Stream: true,
+		Stdin:  true,
+		Stdout: isTTY,
+		Stderr: isTTY,
+	}
+
+	var attachResp types.HijackedResponse
+	if !execMode {
+		err = withRetry(ctx, "attach", func() error {
+			attachResp, err = r.client.ContainerAttach(ctx, containerID, attachOpts)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to attach to container: %w", err)
+		}
+	}
+	attached := &attachment{resp: attachResp}
+	defer attached.Close()
+
+	// Terminal output, with container paths made clickable if enabled
+	ttyOut := stdout
+	if isTTY && opts.Hyperlinks && opts.HostWorkDir != "" {
+		ttyOut = newLinkWriter(stdout, opts.WorkDir, opts.HostWorkDir, opts.LinkFormat)
+	}
+	var gate *readyGate
+	if isTTY && opts.ReadyCheck != "" {
+		gate = &readyGate{out: ttyOut}
+		ttyOut = gate
+	}
+
+	// Start output goroutine for TTY mode (reads from attach)
+	outputDone := make(chan error, 1)
+	if isTTY && !execMode {
+		go pumpOutput(attachResp, ttyOut, outputDone)
+	}
+
+	// Start the container
+	if err := withRetry(ctx, "start the container", func() error {
+		return r.client.ContainerStart(ctx, containerID, containerTypes.StartOptions{})
+	}); err != nil {
+		return fmt.Errorf("failed to start container: %w", err)
+	}
+
+	// Sample resource usage until the container exits
+	if opts.Metrics != nil {
+		statsCtx, stopStats := context.WithCancel(ctx)
+		statsDone := make(chan struct{})
+		go func() {
+			defer close(statsDone)
+			r.collectMetrics(statsCtx, containerID, opts.Metrics)
+		}()
+		defer func() {
+			stopStats()
+			<-statsDone
+		}()
+	}
+
+	if idle != nil {
+		idleCtx, stopIdle := context.WithCancel(ctx)
+		defer stopIdle()
+		go idle.shrinkWhenIdle(idleCtx, opts.IdleAfter, idleShrink, idleRestore, r.updateResources(containerID))
+	}
+
+	// Hold output and input until the session is ready. Docker keeps the
+	// logs, so non-TTY output is followed from the start once it is.
+	if opts.ReadyCheck != "" {
+		err := r.waitReady(ctx, containerID, opts.ReadyCheck, opts.ReadyTimeout)
+		if gate != nil {
+			gate.open()
+		}
+		if err != nil {
+			r.shutdown(containerID, opts.StopGrace, nil)
+			return err
+		}
+	}
+
+	// For non-TTY mode, use ContainerLogs (output goes to Docker's log driver)
+	var execID string
+	if execMode {
+		resp, id, err := r.startExecSession(ctx, containerID, opts, isTTY)
+		if err != nil {
+			return err
+		}
+		execID = id
+		attached.Replace(resp)
+		if isTTY {
+			go pumpOutput(resp, ttyOut, outputDone)
+		} else {
+			go pumpExecOutput(resp, stdout, stderr, outputDone)
+		}
+	} else if !isTTY {
+		go r.followLogs(ctx, containerID, "", stdout, stderr, outputDone)
+	}
+
+	// Set up TTY after output goroutine is reading. Unless claude exits
+	// cleanly, it may have left modes on that it never got to turn off.
+	cleanExit := false
+	if isTTY {
+		r.resizeTty(ctx, containerID, execID)
+
+		if err := terminal.MakeRaw(os.Stdin.Fd()); err != nil {
+			return fmt.Errorf("failed to set raw terminal: %w", err)
+		}
+		defer func() {
+			if cleanExit {
+				terminal.Restore()
+			} else {
+				terminal.Reset(os.Stdout)
+			}
+		}()
+
+		// Handle terminal resize signals
+		go r.monitorTtySize(ctx, containerID, execID)
+	}
+
+	// Requests from the container that need a decision, answered from the
+	// session menu; whatever is still pending when the session ends is denied
+	approvals := &approvalQueue{}
+	if opts.Approvals != nil {
+		go approvals.serve(opts.Approvals, isTTY)
+	}
+	defer approvals.denyAll()
+
+	// Copy stdin to container, handling Ctrl+C and the session menu
+	detachCh := make(chan struct{})
+	input := &inputHandler{
+		forward: func(p []byte) {
+			// Input typed while the Docker connection is down is dropped;
+			// writes resume on the new connection after a re-attach
+			attached.Write(p)
+		},
+		openMenu: func() { showMenu(approvals) },
+		command: func(key byte) bool {
+			if execMode && (key == 'd' || key == 'D') {
+				fmt.Fprint(os.Stderr, "[enclaude] detaching is not available with container.interaction exec\r\n")
+				return false
+			}
+			if len(opts.HostServices) > 0 && (key == 'd' || key == 'D') {
+				fmt.Fprintf(os.Stderr, "[enclaude] cannot detach: %s would stop when enclaude exits\r\n", strings.Join(opts.HostServices, ", "))
+				return false
+			}
+			if r.menuCommand(ctx, containerID, key, approvals) {
+				close(detachCh)
+				return true
+			}
+			return false
+		},
+	}
+	// The first Ctrl+C stops the session gracefully; the second forces it
+	force := make(chan struct{})
+	if isTTY && cancel != nil {
+		interrupts := 0
+		input.interrupt = func() {
+			interrupts++
+			switch interrupts {
+			case 1:
+				cancel()
+			case 2:
+				close(force)
+			}
+		}
+	}
+	go func() {
+		defer terminal.Recover()
+		buf := make([]byte, 32*1024)
+		for {
+			n, err := os.Stdin.Read(buf)
+			if err != nil {
+				break
+			}
+			if idle != nil {
+				idle.touch()
+			}
+			if !isTTY {
+				attached.Write(buf[:n])
+				continue
+			}
+			if !input.handle(buf[:n]) {
+				return
+			}
+		}
+		attached.CloseWrite()
+	}()
+
+	// An exec'd claude cannot be re-attached, so the session ends with its
+	// connection
+	if execMode {
+		select {
+		case <-outputDone:
+			code, err := r.execExitCode(execID)
+			if err != nil {
+				return err
+			}
+			if opts.Metrics != nil {
+				opts.Metrics.Exited = true
+				opts.Metrics.ExitCode = code
+			}
+			if code != 0 {
+				return fmt.Errorf("container exited with code %d", code)
+			}
+			cleanExit = true
+			return nil
+		case <-ctx.Done():
+			r.stopExecSession(containerID, opts.StopGrace, force, outputDone)
+			return ctx.Err()
+		}
+	}
+
+	// Wait for container to exit, re-attaching if the Docker connection drops
+	reattaches := 0
+	for {
+		statusCh, errCh := r.client.ContainerWait(ctx, containerID, containerTypes.WaitConditionNotRunning)
+		select {
+		case err := <-errCh:
+			<-outputDone // Always wait for output to complete
+			if err == nil || ctx.Err() != nil {
+				return nil
+			}
+
+			disconnectedAt := time.Now()
+			if err := r.recoverWait(ctx, containerID, reattaches, err); err != nil {
+				return err
+			}
+			reattaches++
+
+			// Still running: re-attach and resume streaming
+			attachResp, err := r.client.ContainerAttach(ctx, containerID, attachOpts)
+			if err != nil {
+				return fmt.Errorf("failed to re-attach to container: %w", err)
+			}
+			attached.Replace(attachResp)
+			if isTTY {
+				go pumpOutput(attachResp, ttyOut, outputDone)
+				r.resizeTty(ctx, containerID, "")
+			} else {
+				go r.followLogs(ctx, containerID, disconnectedAt.Format(time.RFC3339Nano), stdout, stderr, outputDone)
+			}
+			fmt.Fprintf(os.Stderr, "enclaude: re-attached to container\r\n")
+		case status := <-statusCh:
+			<-outputDone // Wait for output to complete
+			if opts.Metrics != nil {
+				opts.Metrics.Exited = true
+				opts.Metrics.ExitCode = int(status.StatusCode)
+			}
+			if status.StatusCode != 0 {
+				return fmt.Errorf("container exited with code %d", status.StatusCode)
+			}
+			cleanExit = true
+			return nil
+		case <-detachCh:
+			detached = true
+			return &DetachedError{ContainerID: containerID}
+		case <-ctx.Done():
+			// Context cancelled (Ctrl+C or signal), stop the container
+			r.shutdown(containerID, opts.StopGrace, force)
+			return ctx.Err()
+		}
+	}
+}
+
+// shutdown stops a session container. claude is sent SIGINT first so it can
+// save session state, and the container is stopped once it has not exited
+// within grace, or right away when force is closed or another SIGINT or
+// SIGTERM arrives.
+func (r *Runner) shutdown(containerID string, grace time.Duration, force <-chan struct{}) {
+	ctx := context.Background()
+	timeout := stopTimeout
+	if grace > 0 {
+		fmt.Fprintf(os.Stderr, "\r\nenclaude: stopping session (Ctrl+C again to force)...\r\n")
+		if err := r.client.ContainerKill(ctx, containerID, "SIGINT"); err == nil {
+			sigCh := make(chan os.Signal, 1)
+			signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+			defer signal.Stop(sigCh)
+
+			waitCtx, cancel := context.WithTimeout(ctx, grace)
+			defer cancel()
+			statusCh, errCh := r.client.ContainerWait(waitCtx, containerID, containerTypes.WaitConditionNotRunning)
+			select {
+			case <-statusCh:
+				return
+			case <-errCh:
+				// Grace period over, or Docker is unreachable
+			case <-force:
+				timeout = 0
+			case <-sigCh:
+				timeout = 0
+			}
+		}
+	}
+	_ = r.client.ContainerStop(ctx, containerID, containerTypes.StopOptions{Timeout: &timeout})
+}
+
+// createContainer creates (but does not start) a session container from opts.
+// When isTTY is set the container is allocated a TTY and attaches stdout/stderr.
+func (r *Runner) createContainer(ctx context.Context, opts RunOptions, isTTY bool) (string, error) {
+	if err := r.CheckNetwork(ctx, opts.Network); err != nil {
+		return "", err
+	}
+
 	// Build environment variables
 	var env []string
 	for k, v := range opts.Environment {
@@ -59,11 +462,16 @@ func (r *Runner) Run(ctx context.Context, cancel context.CancelFunc, opts RunOpt
 	// Ensure PATH includes Claude's install location
 	env = append(env, "PATH=/usr/local/bin:/usr/bin:/bin")
 
-	// Set HOME to a writable location when running as non-root user
-	// This is needed because Claude Code writes to ~/.claude
-	env = append(env, "HOME=/tmp")
+	// HOME is a tmpfs owned by the session user; Claude Code writes to ~/.claude
+	env = append(env, "HOME="+Home)
 
-	
+	// In exec mode the terminal belongs to the exec'd claude, not the
+	// container's main process
+	containerTTY := isTTY
+	if opts.Interaction == config.InteractionExec {
+		env = append(env, "ENCLAUDE_INTERACTION="+config.InteractionExec)
+		containerTTY = false
+	}
 
 	// Build command - just pass the args since the Dockerfile has ENTRYPOINT set to claude
 	cmd := strslice.StrSlice{}
@@ -72,75 +480,81 @@ func (r *Runner) Run(ctx context.Context, cancel context.CancelFunc, opts RunOpt
 	// Build mounts
 	var mounts []mount.Mount
 	for _, m := range opts.Mounts {
-		mounts = append(mounts, mount.Mount{
-			Type:     mount.TypeBind,
-			Source:   m.Source,
-			Target:   m.Target,
-			ReadOnly: m.ReadOnly,
-		})
+		mounts = append(mounts, engineMount(m, opts.Security.Ephemeral))
 	}
 
 	// Add tmpfs mounts for writable areas when using read-only root
+	var tmpfsSize int64
+	if opts.TmpfsSize != "" {
+		size, err := units.RAMInBytes(opts.TmpfsSize)
+		if err != nil {
+			return "", fmt.Errorf("invalid tmpfs size %q: %w", opts.TmpfsSize, err)
+		}
+		tmpfsSize = size
+	}
 	if opts.Security.ReadOnlyRoot {
+		var tmpfsOptions *mount.TmpfsOptions
+		if tmpfsSize > 0 {
+			tmpfsOptions = &mount.TmpfsOptions{SizeBytes: tmpfsSize}
+		}
 		tmpfsMounts := []string{"/tmp", "/run", "/var/tmp"}
 		for _, path := range tmpfsMounts {
 			mounts = append(mounts, mount.Mount{
-				Type:   mount.TypeTmpfs,
-				Target: path,
+				Type:         mount.TypeTmpfs,
+				Target:       path,
+				TmpfsOptions: tmpfsOptions,
 			})
 		}
 	}
 
 	// Mount CA certificates if configured
-	if len(opts.Security.CACerts) > 0 {
-		for _, certPath := range opts.Security.CACerts {
-			certName := filepath.Base(certPath)
-			mounts = append(mounts, mount.Mount{
-				Type:     mount.TypeBind,
-				Source:   certPath,
-				Target:   "/usr/local/share/ca-certificates/" + certName,
-				ReadOnly: true,
-			})
-		}
-		// Add tmpfs mounts for CA certificate installation directories
-		// update-ca-certificates needs to write to these directories at container start
-		caCertDirs := []string{"/etc/ssl/certs", "/etc/ca-certificates"}
-		for _, path := range caCertDirs {
-			mounts = append(mounts, mount.Mount{
-				Type:   mount.TypeTmpfs,
-				Target: path,
-			})
-		}
-		// Set NODE_EXTRA_CA_CERTS for Node.js applications (Claude uses Node.js)
-		// NODE_EXTRA_CA_CERTS only accepts a single file path, so we only set it
-		// when exactly one CA certificate is configured. For multiple certificates,
-		// users should bundle them into a single PEM file.
-		if len(opts.Security.CACerts) == 1 {
-			certName := filepath.Base(opts.Security.CACerts[0])
-			env = append(env, "NODE_EXTRA_CA_CERTS=/usr/local/share/ca-certificates/"+certName)
-		}
-	}
+	caMounts, caEnv := caCertMounts(opts.Security.CACerts)
+	mounts = append(mounts, caMounts...)
+	env = append(env, caEnv...)
 
 	// Determine user
-	user := ""
-	if opts.User == config.UserAuto {
-		user = fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid())
-	} else if opts.User != "" {
-		user = opts.User
-	}
+	user := r.sessionUser(ctx, opts.User, opts.Image)
 
 	// Parse memory limit
 	var memoryLimit int64
 	if opts.MemoryLimit != "" {
 		limit, err := units.RAMInBytes(opts.MemoryLimit)
 		if err != nil {
-			return fmt.Errorf("invalid memory limit %q: %w", opts.MemoryLimit, err)
+			return "", fmt.Errorf("invalid memory limit %q: %w", opts.MemoryLimit, err)
 		}
 		memoryLimit = limit
 	}
 
-	// Determine if we should use TTY mode
-	isTTY := term.IsTerminal(os.Stdin.Fd())
+	// Parse CPU limit
+	var nanoCPUs int64
+	if opts.CPUs != "" {
+		cpus, err := strconv.ParseFloat(opts.CPUs, 64)
+		if err != nil || cpus <= 0 {
+			return "", fmt.Errorf("invalid cpus %q: must be a positive number", opts.CPUs)
+		}
+		nanoCPUs = int64(cpus * 1e9)
+	}
+
+	var pidsLimit *int64
+	if opts.PidsLimit > 0 {
+		pidsLimit = &opts.PidsLimit
+	}
+
+	// Split stderr from the terminal stream if requested
+	if opts.StderrFile != "" && isTTY {
+		m, e, err := stderrFileMount(opts.StderrFile)
+		if err != nil {
+			return "", err
+		}
+		mounts = append(mounts, m)
+		env = append(env, e)
+	}
+
+	// Parse published ports
+	exposedPorts, portBindings, err := nat.ParsePortSpecs(opts.Ports)
+	if err != nil {
+		return "", fmt.Errorf("invalid port specification: %w", err)
+	}
 
 	// Container configuration
 	// For non-TTY mode, don't attach stdout/stderr - use ContainerLogs instead
@@ -150,24 +564,42 @@ func (r *Runner) Run(ctx context.Context, cancel context.CancelFunc, opts RunOpt
 		Env:          env,
 		WorkingDir:   opts.WorkDir,
 		User:         user,
-		Tty:          isTTY,
+		Tty:          containerTTY,
 		OpenStdin:    true,
 		AttachStdin:  true,
-		AttachStdout: isTTY,
-		AttachStderr: isTTY,
+		AttachStdout: containerTTY,
+		AttachStderr: containerTTY,
+		ExposedPorts: exposedPorts,
+		Labels:       sessionLabels(opts),
+		Healthcheck:  healthConfig(opts.ReadyCheck),
 	}
 
 	// Host configuration
 	hostConfig := &containerTypes.HostConfig{
 		Mounts:         mounts,
 		NetworkMode:    containerTypes.NetworkMode(opts.Network),
+		PortBindings:   portBindings,
+		ExtraHosts:     append(blockedHostEntries(opts.BlockedHosts), opts.HostEntries...),
 		ReadonlyRootfs: opts.Security.ReadOnlyRoot,
+		Tmpfs:          map[string]string{Home: homeTmpfs(user, tmpfsSize)},
 		AutoRemove:     false, // Disabled - we clean up manually in defer
 		Resources: containerTypes.Resources{
-			Memory: memoryLimit,
+			Memory:    memoryLimit,
+			NanoCPUs:  nanoCPUs,
+			PidsLimit: pidsLimit,
 		},
 	}
 
+	// Soft memory limit and swappiness
+	if err := applyMemoryPressure(&hostConfig.Resources, opts.Reservation, opts.Swappiness); err != nil {
+		return "", err
+	}
+
+	// Cgroup placement and scheduling weights
+	if err := applyCgroup(&hostConfig.Resources, opts.Cgroup); err != nil {
+		return "", err
+	}
+
 	// Security settings
 	if opts.Security.DropCapabilities {
 		hostConfig.CapDrop = strslice.StrSlice{"ALL"}
@@ -177,158 +609,95 @@ func (r *Runner) Run(ctx context.Context, cancel context.CancelFunc, opts RunOpt
 		hostConfig.SecurityOpt = append(hostConfig.SecurityOpt, "no-new-privileges")
 	}
 
+	// docker run flags enclaude does not model itself
+	if err := applyDockerArgs(containerConfig, hostConfig, opts.DockerArgs); err != nil {
+		return "", err
+	}
+
 	// Create the container
-	resp, err := r.client.ContainerCreate(ctx, containerConfig, hostConfig, nil, nil, "")
+	var resp containerTypes.CreateResponse
+	err = withRetry(ctx, "create the container", func() error {
+		resp, err = r.client.ContainerCreate(ctx, containerConfig, hostConfig, endpointConfig(opts.Network, opts.Aliases), nil, "")
+		return err
+	})
 	if err != nil {
 		// Check if image needs to be pulled
 		if strings.Contains(err.Error(), "No such image") {
-			return fmt.Errorf("image %q not found; run 'enclaude build' first or pull the image", opts.Image)
+			return "", fmt.Errorf("image %q not found; run 'enclaude build' first or pull the image", opts.Image)
 		}
-		return fmt.Errorf("failed to create container: %w", err)
+		return "", fmt.Errorf("failed to create container: %w", err)
 	}
-	containerID := resp.ID
 
-	// Ensure cleanup
-	defer func() {
-		// Container should auto-remove, but force cleanup if needed
-		_ = r.client.ContainerRemove(context.Background(), containerID, containerTypes.RemoveOptions{
-			Force: true,
-		})
-	}()
-
-	// Attach to container (stdin always, stdout/stderr only for TTY)
-	attachOpts := containerTypes.AttachOptions{
-		Stream: true,
-		Stdin:  true,
-		Stdout: isTTY,
-		Stderr: isTTY,
-	}
-
-	attachResp, err := r.client.ContainerAttach(ctx, containerID, attachOpts)
-	if err != nil {
-		return fmt.Errorf("failed to attach to container: %w", err)
-	}
-	defer attachResp.Close()
+	return resp.ID, nil
+}
 
-	// Start output goroutine for TTY mode (reads from attach)
-	outputDone := make(chan error, 1)
-	if isTTY {
-		go func() {
-			buf := make([]byte, 32*1024)
-			for {
-				n, err := attachResp.Reader.Read(buf)
-				if n > 0 {
-					os.Stdout.Write(buf[:n])
-					os.Stdout.Sync()
-				}
-				if err != nil {
-					outputDone <- err
-					return
-				}
-			}
-		}()
-	}
+// CA certificates are mounted into CACertDir. The entrypoint combines them
+// with the image's system roots into CABundle, on a tmpfs so that works as
+// any user and with a read-only root filesystem, and points SSL_CERT_FILE,
+// REQUESTS_CA_BUNDLE and friends at it.
+const (
+	CACertDir = "/usr/local/share/ca-certificates"
+	CABundle  = "/run/enclaude/ca/ca-certificates.crt"
+)
 
-	// Start the container
-	if err := r.client.ContainerStart(ctx, containerID, containerTypes.StartOptions{}); err != nil {
-		return fmt.Errorf("failed to start container: %w", err)
-	}
+// caCertMounts returns the mounts and environment variables needed to trust
+// additional CA certificates in the container
+func caCertMounts(certs []string) ([]mount.Mount, []string) {
+	var mounts []mount.Mount
+	var env []string
 
-	// For non-TTY mode, use ContainerLogs (output goes to Docker's log driver)
-	if !isTTY {
-		go func() {
-			logs, err := r.client.ContainerLogs(ctx, containerID, containerTypes.LogsOptions{
-				ShowStdout: true,
-				ShowStderr: true,
-				Follow:     true,
-			})
-			if err != nil {
-				outputDone <- err
-				return
-			}
-			defer logs.Close()
-			_, err = stdcopy.StdCopy(os.Stdout, os.Stderr, logs)
-			outputDone <- err
-		}()
+	if len(certs) == 0 {
+		return mounts, env
 	}
 
-	// Set up TTY after output goroutine is reading
-	var oldState *term.State
-	if isTTY {
-		r.resizeTty(ctx, containerID)
-
-		oldState, err = term.SetRawTerminal(os.Stdin.Fd())
-		if err != nil {
-			return fmt.Errorf("failed to set raw terminal: %w", err)
-		}
-		defer term.RestoreTerminal(os.Stdin.Fd(), oldState)
-
-		// Handle terminal resize signals
-		go r.monitorTtySize(ctx, containerID)
+	for _, certPath := range certs {
+		certName := filepath.Base(certPath)
+		mounts = append(mounts, mount.Mount{
+			Type:     mount.TypeBind,
+			Source:   certPath,
+			Target:   CACertDir + "/" + certName,
+			ReadOnly: true,
+		})
 	}
+	mounts = append(mounts, mount.Mount{
+		Type:   mount.TypeTmpfs,
+		Target: path.Dir(CABundle),
+	})
+	env = append(env, "ENCLAUDE_CA_BUNDLE="+CABundle)
 
-	// Copy stdin to container with Ctrl+C detection
-	go func() {
-		buf := make([]byte, 32*1024)
-		for {
-			n, err := os.Stdin.Read(buf)
-			if err != nil {
-				break
-			}
-			// Check for Ctrl+C (byte 0x03) in raw mode
-			if isTTY && cancel != nil {
-				for i := 0; i < n; i++ {
-					if buf[i] == 0x03 {
-						cancel()
-						return
-					}
-				}
-			}
-			if _, err := attachResp.Conn.Write(buf[:n]); err != nil {
-				break
-			}
-		}
-		attachResp.CloseWrite()
-	}()
-
-	// Wait for container to exit
-	statusCh, errCh := r.client.ContainerWait(ctx, containerID, containerTypes.WaitConditionNotRunning)
-	select {
-	case err := <-errCh:
-		<-outputDone // Always wait for output to complete
-		if err != nil && ctx.Err() == nil {
-			return fmt.Errorf("error waiting for container: %w", err)
-		}
-	case status := <-statusCh:
-		<-outputDone // Wait for output to complete
-		if status.StatusCode != 0 {
-			return fmt.Errorf("container exited with code %d", status.StatusCode)
-		}
-	case <-ctx.Done():
-		// Context cancelled (Ctrl+C or signal), stop the container
-		stopCtx := context.Background()
-		timeout := 5
-		_ = r.client.ContainerStop(stopCtx, containerID, containerTypes.StopOptions{Timeout: &timeout})
-		return ctx.Err()
+	// Images without the enclaude entrypoint never build the bundle, so Node
+	// (Claude) is pointed at a single certificate directly; the entrypoint
+	// replaces this with the bundle
+	if len(certs) == 1 {
+		certName := filepath.Base(certs[0])
+		env = append(env, "NODE_EXTRA_CA_CERTS="+CACertDir+"/"+certName)
 	}
 
-	return nil
+	return mounts, env
 }
 
-// resizeTty resizes the container TTY to match the current terminal size
-func (r *Runner) resizeTty(ctx context.Context, containerID string) {
+// resizeTty resizes the container TTY, or that of the exec execID if set,
+// to match the current terminal size
+func (r *Runner) resizeTty(ctx context.Context, containerID, execID string) {
 	winsize, err := term.GetWinsize(os.Stdout.Fd())
 	if err != nil {
 		return
 	}
-	r.client.ContainerResize(ctx, containerID, containerTypes.ResizeOptions{
+	size := containerTypes.ResizeOptions{
 		Height: uint(winsize.Height),
 		Width:  uint(winsize.Width),
-	})
+	}
+	if execID != "" {
+		r.client.ContainerExecResize(ctx, execID, size)
+		return
+	}
+	r.client.ContainerResize(ctx, containerID, size)
 }
 
 // monitorTtySize monitors terminal size changes and resizes the container TTY
-func (r *Runner) monitorTtySize(ctx context.Context, containerID string) {
+func (r *Runner) monitorTtySize(ctx context.Context, containerID, execID string) {
+	defer terminal.Recover()
+
 	// Monitor for SIGWINCH signals
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGWINCH)
@@ -337,15 +706,28 @@ func (r *Runner) monitorTtySize(ctx context.Context, containerID string) {
 	for {
 		select {
 		case <-sigCh:
-			r.resizeTty(ctx, containerID)
+			r.resizeTty(ctx, containerID, execID)
 		case <-ctx.Done():
 			return
 		}
 	}
 }
 
-// Build builds a Docker image from a Dockerfile
+// Build builds an image from a Dockerfile or Containerfile through the
+// engine's build endpoint. Features the endpoint lacks use buildx with
+// Docker, and, for build secrets, the podman CLI with Podman.
 func (r *Runner) Build(ctx context.Context, opts BuildOptions) error {
+	if r.IsPodman(ctx) {
+		if opts.Attest || opts.Push || len(opts.CacheFrom) > 0 || len(opts.CacheTo) > 0 {
+			return fmt.Errorf("--cache-to, --cache-from, --attest, and --push are not supported with Podman; build, then push with 'podman push %s'", opts.Tag)
+		}
+		if len(opts.Secrets) > 0 {
+			return r.buildWithPodman(ctx, opts)
+		}
+	} else if len(opts.CacheFrom) > 0 || len(opts.CacheTo) > 0 || opts.Attest || opts.Push || len(opts.Secrets) > 0 {
+		return r.buildWithBuildx(ctx, opts)
+	}
+
 	// Read the Dockerfile
 	dockerfileContent, err := os.ReadFile(opts.Dockerfile)
 	if err != nil {
@@ -386,8 +768,8 @@ func (r *Runner) Build(ctx context.Context, opts BuildOptions) error {
 			return err
 		}
 
-		// Skip hidden files/dirs except .dockerignore
-		if strings.HasPrefix(filepath.Base(path), ".") && filepath.Base(path) != ".dockerignore" {
+		// Skip hidden files/dirs except ignore files
+		if strings.HasPrefix(filepath.Base(path), ".") && !isIgnoreFile(filepath.Base(path)) {
 			if info.IsDir() {
 				return filepath.SkipDir
 			}
@@ -421,6 +803,19 @@ func (r *Runner) Build(ctx context.Context, opts BuildOptions) error {
 		return fmt.Errorf("failed to create build context: %w", err)
 	}
 
+	// Docker reads only .dockerignore; a context with just a .containerignore
+	// gets it under that name
+	if !security.FileExists(filepath.Join(opts.ContextDir, ".dockerignore")) {
+		if content, err := os.ReadFile(filepath.Join(opts.ContextDir, ".containerignore")); err == nil {
+			if err := tw.WriteHeader(&tar.Header{Name: ".dockerignore", Mode: 0644, Size: int64(len(content))}); err != nil {
+				return fmt.Errorf("failed to write .dockerignore header: %w", err)
+			}
+			if _, err := tw.Write(content); err != nil {
+				return fmt.Errorf("failed to write .dockerignore: %w", err)
+			}
+		}
+	}
+
 	if err := tw.Close(); err != nil {
 		return fmt.Errorf("failed to close tar writer: %w", err)
 	}
@@ -431,25 +826,37 @@ func (r *Runner) Build(ctx context.Context, opts BuildOptions) error {
 		Tags:       []string{opts.Tag},
 		NoCache:    opts.NoCache,
 		Remove:     true,
+		Labels:     opts.Labels,
 	}
 
 	if opts.Platform != "" {
 		buildOptions.Platform = opts.Platform
 	}
+	if len(opts.BuildArgs) > 0 {
+		buildOptions.BuildArgs = make(map[string]*string, len(opts.BuildArgs))
+		for k, v := range opts.BuildArgs {
+			v := v
+			buildOptions.BuildArgs[k] = &v
+		}
+	}
 
-	// Build the image
-	resp, err := r.client.ImageBuild(ctx, buf, buildOptions)
+	// Build the image; the context is re-read from the start on each attempt
+	var resp types.ImageBuildResponse
+	err = withRetry(ctx, "build the image", func() error {
+		resp, err = r.client.ImageBuild(ctx, bytes.NewReader(buf.Bytes()), buildOptions)
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("failed to build image: %w", err)
 	}
 	defer resp.Body.Close()
 
-	// Stream build output
-	_, err = io.Copy(os.Stdout, resp.Body)
-	if err != nil {
-		return fmt.Errorf("error reading build output: %w", err)
+	// Show the build's progress as text, as buildx and podman print it,
+	// rather than the API's JSON messages, and report a failed step
+	fd, isTerm := term.GetFdInfo(os.Stdout)
+	if err := jsonmessage.DisplayJSONMessagesStream(resp.Body, os.Stdout, fd, isTerm, nil); err != nil {
+		return err
 	}
-
 	return nil
 }
 
@@ -464,5 +871,3 @@ func (r *Runner) ImageExists(ctx context.Context, image string) (bool, error) {
 	}
 	return true, nil
 }
-
-
@@ -0,0 +1,150 @@
+package container
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	containerTypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// Labels applied to session containers so they can be found later
+const (
+	SessionLabel   = "com.enclaude.session"
+	WorkspaceLabel = "com.enclaude.workspace"
+	RunLabel       = "com.enclaude.run"
+	PIDLabel       = "com.enclaude.pid"  // Process that owns the session
+	HostLabel      = "com.enclaude.host" // Host the owning process runs on
+)
+
+// Session describes an enclaude session container
+type Session struct {
+	ID        string
+	Name      string
+	Image     string
+	Workspace string // Host directory the session was started from
+	RunID     string // Run record in the state directory, if any
+	PID       int    // Owning enclaude process, 0 if unknown
+	Host      string // Host of the owning process
+	Running   bool
+	Created   time.Time
+}
+
+// sessionLabels returns the labels identifying a session container
+func sessionLabels(opts RunOptions) map[string]string {
+	labels := map[string]string{
+		SessionLabel: "true",
+		PIDLabel:     strconv.Itoa(os.Getpid()),
+	}
+	if host, err := os.Hostname(); err == nil {
+		labels[HostLabel] = host
+	}
+	if opts.HostWorkDir != "" {
+		labels[WorkspaceLabel] = opts.HostWorkDir
+	}
+	if opts.RunID != "" {
+		labels[RunLabel] = opts.RunID
+	}
+	return labels
+}
+
+// ListSessions returns the running enclaude session containers
+func (r *Runner) ListSessions(ctx context.Context) ([]Session, error) {
+	return r.listSessions(ctx, false)
+}
+
+// AllSessions returns every enclaude session container, including stopped
+// ones left behind when their enclaude process died
+func (r *Runner) AllSessions(ctx context.Context) ([]Session, error) {
+	return r.listSessions(ctx, true)
+}
+
+func (r *Runner) listSessions(ctx context.Context, all bool) ([]Session, error) {
+	containers, err := r.client.ContainerList(ctx, containerTypes.ListOptions{
+		All:     all,
+		Filters: filters.NewArgs(filters.Arg("label", SessionLabel)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	sessions := make([]Session, 0, len(containers))
+	for _, c := range containers {
+		name := c.ID[:12]
+		if len(c.Names) > 0 {
+			name = strings.TrimPrefix(c.Names[0], "/")
+		}
+		pid, _ := strconv.Atoi(c.Labels[PIDLabel])
+		sessions = append(sessions, Session{
+			ID:        c.ID,
+			Name:      name,
+			Image:     c.Image,
+			Workspace: c.Labels[WorkspaceLabel],
+			RunID:     c.Labels[RunLabel],
+			PID:       pid,
+			Host:      c.Labels[HostLabel],
+			Running:   c.State == "running",
+			Created:   time.Unix(c.Created, 0),
+		})
+	}
+	return sessions, nil
+}
+
+// Watch streams a running session's terminal output to out without attaching
+// stdin, so the viewer cannot send input to Claude. It returns when the
+// session ends or ctx is cancelled.
+func (r *Runner) Watch(ctx context.Context, session string, out io.Writer) error {
+	info, err := r.runningSession(ctx, session)
+	if err != nil {
+		return err
+	}
+
+	resp, err := r.client.ContainerAttach(ctx, info.ID, containerTypes.AttachOptions{
+		Stream: true,
+		Stdout: true,
+		Stderr: true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to attach to session: %w", err)
+	}
+	defer resp.Close()
+
+	go func() {
+		<-ctx.Done()
+		resp.Close()
+	}()
+
+	// TTY sessions produce a raw stream; non-TTY sessions are multiplexed
+	if info.Config.Tty {
+		_, err = io.Copy(out, resp.Reader)
+	} else {
+		_, err = stdcopy.StdCopy(out, out, resp.Reader)
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+// runningSession inspects a session container, failing if it is not a
+// running enclaude session
+func (r *Runner) runningSession(ctx context.Context, session string) (types.ContainerJSON, error) {
+	info, err := r.client.ContainerInspect(ctx, session)
+	if err != nil {
+		return info, fmt.Errorf("session %q not found: %w", session, err)
+	}
+	if _, ok := info.Config.Labels[SessionLabel]; !ok {
+		return info, fmt.Errorf("container %q is not an enclaude session", session)
+	}
+	if !info.State.Running {
+		return info, fmt.Errorf("session %q is not running", session)
+	}
+	return info, nil
+}
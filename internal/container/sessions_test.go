@@ -0,0 +1,31 @@
+package container
+
+import (
+	"os"
+	"strconv"
+	"testing"
+)
+
+func TestSessionLabels(t *testing.T) {
+	labels := sessionLabels(RunOptions{HostWorkDir: "/home/user/project", RunID: "20260101-000000-abc"})
+	if labels[SessionLabel] != "true" {
+		t.Errorf("labels[%s] = %q, want true", SessionLabel, labels[SessionLabel])
+	}
+	if labels[WorkspaceLabel] != "/home/user/project" {
+		t.Errorf("labels[%s] = %q, want /home/user/project", WorkspaceLabel, labels[WorkspaceLabel])
+	}
+	if labels[PIDLabel] != strconv.Itoa(os.Getpid()) {
+		t.Errorf("labels[%s] = %q, want %d", PIDLabel, labels[PIDLabel], os.Getpid())
+	}
+	if labels[RunLabel] != "20260101-000000-abc" {
+		t.Errorf("labels[%s] = %q, want 20260101-000000-abc", RunLabel, labels[RunLabel])
+	}
+
+	labels = sessionLabels(RunOptions{})
+	if _, ok := labels[WorkspaceLabel]; ok {
+		t.Error("workspace label should be omitted when HostWorkDir is empty")
+	}
+	if _, ok := labels[RunLabel]; ok {
+		t.Error("run label should be omitted when RunID is empty")
+	}
+}
@@ -0,0 +1,145 @@
+package container
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SpecVersion is the sandbox definition format written by WriteSpec
+const SpecVersion = 1
+
+// specWorkspace stands in for the host workspace in a spec, so the same
+// definition can be run from any checkout
+const specWorkspace = "."
+
+// Spec is a portable, secret-free sandbox definition. Host paths under the
+// user's home directory are stored with a "~" prefix and the workspace mount
+// source is stored as ".".
+type Spec struct {
+	Version int        `json:"version"`
+	Options RunOptions `json:"options"`
+}
+
+// NewSpec converts resolved run options into a portable spec. Callers are
+// responsible for removing credentials from opts first.
+func NewSpec(opts RunOptions, home string) Spec {
+	opts.HostWorkDir = ""
+	opts.StderrFile = ""
+
+	mounts := make([]Mount, len(opts.Mounts))
+	for i, m := range opts.Mounts {
+		switch {
+		case m.Target == opts.WorkDir:
+			m.Source = specWorkspace
+		case !m.Volume:
+			m.Source = portablePath(m.Source, home)
+		}
+		mounts[i] = m
+	}
+	opts.Mounts = mounts
+
+	caCerts := make([]string, len(opts.Security.CACerts))
+	for i, c := range opts.Security.CACerts {
+		caCerts[i] = portablePath(c, home)
+	}
+	opts.Security.CACerts = caCerts
+
+	return Spec{Version: SpecVersion, Options: opts}
+}
+
+// Resolve returns the spec's run options for this host, mounting
+// workspaceSource as the workspace and expanding "~" to home
+func (s Spec) Resolve(workspaceSource, home string) RunOptions {
+	opts := s.Options
+
+	mounts := make([]Mount, len(opts.Mounts))
+	for i, m := range opts.Mounts {
+		switch {
+		case m.Source == specWorkspace && m.Target == opts.WorkDir:
+			m.Source = workspaceSource
+		case !m.Volume:
+			m.Source = hostPath(m.Source, home)
+		}
+		mounts[i] = m
+	}
+	opts.Mounts = mounts
+
+	caCerts := make([]string, len(opts.Security.CACerts))
+	for i, c := range opts.Security.CACerts {
+		caCerts[i] = hostPath(c, home)
+	}
+	opts.Security.CACerts = caCerts
+
+	return opts
+}
+
+// WriteSpec writes spec to path as indented JSON
+func WriteSpec(path string, spec Spec) error {
+	data, err := json.MarshalIndent(spec, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode spec: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write spec: %w", err)
+	}
+	return nil
+}
+
+// ReadSpec reads a spec written by WriteSpec
+func ReadSpec(path string) (Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Spec{}, fmt.Errorf("failed to read spec: %w", err)
+	}
+
+	var spec Spec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return Spec{}, fmt.Errorf("failed to parse spec %s: %w", path, err)
+	}
+	if err := spec.validate(path); err != nil {
+		return Spec{}, err
+	}
+	return spec, nil
+}
+
+// validate checks that a spec read from name can be run
+func (s Spec) validate(name string) error {
+	if s.Version < 1 || s.Version > SpecVersion {
+		return fmt.Errorf("unsupported spec version %d (supported: %d)", s.Version, SpecVersion)
+	}
+	if s.Options.Image == "" || s.Options.WorkDir == "" {
+		return fmt.Errorf("spec %s is missing image or workdir", name)
+	}
+	if _, err := ParseDockerArgs(s.Options.DockerArgs); err != nil {
+		return fmt.Errorf("spec %s: %w", name, err)
+	}
+	return nil
+}
+
+// portablePath replaces a home directory prefix with "~"
+func portablePath(path, home string) string {
+	if home == "" {
+		return path
+	}
+	if path == home {
+		return "~"
+	}
+	if rel := strings.TrimPrefix(path, home+string(filepath.Separator)); rel != path {
+		return "~/" + filepath.ToSlash(rel)
+	}
+	return path
+}
+
+// hostPath expands a "~" prefix written by portablePath
+func hostPath(path, home string) string {
+	if path == "~" {
+		return home
+	}
+	if strings.HasPrefix(path, "~/") {
+		return filepath.Join(home, filepath.FromSlash(path[2:]))
+	}
+	return path
+}
@@ -0,0 +1,92 @@
+package container
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSpecRoundTrip(t *testing.T) {
+	opts := RunOptions{
+		Image: "enclaude:latest",
+		Mounts: []Mount{
+			{Source: "/home/alice/src/app", Target: "/workspace"},
+			{Source: "/home/alice/shared-lib", Target: "/home/alice/shared-lib", ReadOnly: true},
+			{Source: "/opt/data", Target: "/opt/data"},
+			{Source: "enclaude-gomod", Target: "/var/cache/enclaude/go-mod", Volume: true},
+		},
+		WorkDir:     "/workspace",
+		HostWorkDir: "/home/alice/src/app",
+		StderrFile:  "/home/alice/err.log",
+		Security:    SecurityOptions{CACerts: []string{"/home/alice/certs/ca.pem"}},
+	}
+
+	spec := NewSpec(opts, "/home/alice")
+	wantMounts := []Mount{
+		{Source: ".", Target: "/workspace"},
+		{Source: "~/shared-lib", Target: "/home/alice/shared-lib", ReadOnly: true},
+		{Source: "/opt/data", Target: "/opt/data"},
+		{Source: "enclaude-gomod", Target: "/var/cache/enclaude/go-mod", Volume: true},
+	}
+	if !reflect.DeepEqual(spec.Options.Mounts, wantMounts) {
+		t.Errorf("NewSpec() mounts = %+v, want %+v", spec.Options.Mounts, wantMounts)
+	}
+	if spec.Options.HostWorkDir != "" || spec.Options.StderrFile != "" {
+		t.Error("NewSpec() should drop host-specific fields")
+	}
+	if opts.Mounts[0].Source != "/home/alice/src/app" {
+		t.Error("NewSpec() modified the caller's mounts")
+	}
+
+	path := filepath.Join(t.TempDir(), "run.json")
+	if err := WriteSpec(path, spec); err != nil {
+		t.Fatalf("WriteSpec() error = %v", err)
+	}
+	read, err := ReadSpec(path)
+	if err != nil {
+		t.Fatalf("ReadSpec() error = %v", err)
+	}
+
+	resolved := read.Resolve("/home/bob/app", "/home/bob")
+	if resolved.Mounts[0].Source != "/home/bob/app" {
+		t.Errorf("Resolve() workspace source = %q, want /home/bob/app", resolved.Mounts[0].Source)
+	}
+	if resolved.Mounts[1].Source != "/home/bob/shared-lib" {
+		t.Errorf("Resolve() home mount source = %q, want /home/bob/shared-lib", resolved.Mounts[1].Source)
+	}
+	if resolved.Security.CACerts[0] != "/home/bob/certs/ca.pem" {
+		t.Errorf("Resolve() CA cert = %q, want /home/bob/certs/ca.pem", resolved.Security.CACerts[0])
+	}
+}
+
+func TestReadSpec_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.json")
+	if err := WriteSpec(path, Spec{Version: SpecVersion + 1, Options: RunOptions{Image: "x", WorkDir: "/workspace"}}); err != nil {
+		t.Fatalf("WriteSpec() error = %v", err)
+	}
+	if _, err := ReadSpec(path); err == nil {
+		t.Error("ReadSpec() with newer version should fail")
+	}
+}
+
+func TestTemplateRoundTrip(t *testing.T) {
+	tmpl := Template{
+		Spec:    Spec{Version: SpecVersion, Options: RunOptions{Image: "ghcr.io/acme/enclaude:v1", WorkDir: "/workspace", Network: "none"}},
+		Presets: map[string][]string{"review": {"-p", "Review the changes"}},
+	}
+	data, err := EncodeTemplate(tmpl)
+	if err != nil {
+		t.Fatalf("EncodeTemplate() error = %v", err)
+	}
+	got, err := DecodeTemplate(data, "acme/approved:v1")
+	if err != nil {
+		t.Fatalf("DecodeTemplate() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, tmpl) {
+		t.Errorf("DecodeTemplate() = %+v, want %+v", got, tmpl)
+	}
+
+	if _, err := DecodeTemplate([]byte(`{"version":1,"options":{"image":"x"}}`), "acme/approved:v1"); err == nil {
+		t.Error("DecodeTemplate() without a workdir should fail")
+	}
+}
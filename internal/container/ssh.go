@@ -0,0 +1,168 @@
+package container
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	containerTypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// SSHDir is where sshd's configuration and keys are installed in a session.
+// It is on the home tmpfs, so it is private to the session user and goes
+// away with the container.
+const SSHDir = Home + "/.enclaude/sshd"
+
+// sshLauncher serves one SSH connection on stdio. ssh's ProxyCommand runs it
+// with sh through docker exec, which skips the image entrypoint, so it sets
+// up the same passwd entry for uids the image does not know. Given arguments,
+// it runs them instead of sshd.
+const sshLauncher = `if [ -f "$HOME/.nss/passwd" ]; then
+    for lib in /usr/lib/*/libnss_wrapper.so /usr/lib/libnss_wrapper.so; do
+        [ -f "$lib" ] || continue
+        export LD_PRELOAD="$lib" NSS_WRAPPER_PASSWD="$HOME/.nss/passwd" NSS_WRAPPER_GROUP="$HOME/.nss/group"
+        break
+    done
+fi
+[ $# -gt 0 ] || set -- /usr/sbin/sshd -i -f "` + SSHDir + `/sshd_config"
+exec "$@"
+`
+
+// sshdConfig runs sshd unprivileged as the session user, accepting only the
+// installed key. Local forwarding is allowed since editors tunnel to their
+// remote server through it.
+const sshdConfig = `HostKey ` + SSHDir + `/ssh_host_ed25519_key
+AuthorizedKeysFile ` + SSHDir + `/authorized_keys
+PidFile none
+UsePAM no
+StrictModes no
+PasswordAuthentication no
+KbdInteractiveAuthentication no
+AllowTcpForwarding local
+AllowAgentForwarding no
+X11Forwarding no
+PrintMotd no
+Subsystem sftp internal-sftp
+`
+
+// SSHTarget is a session prepared for SSH: ssh reaches it by running
+// ProxyCommand, as User
+type SSHTarget struct {
+	ContainerID  string
+	User         string
+	ProxyCommand []string
+}
+
+// InstallSSH installs an sshd configuration with the given host key and
+// authorized public key in a running session, so editors can connect over
+// SSH through docker exec without publishing a port
+func (r *Runner) InstallSSH(ctx context.Context, session string, hostKey, authorizedKey []byte) (SSHTarget, error) {
+	info, err := r.client.ContainerInspect(ctx, session)
+	if err != nil {
+		return SSHTarget{}, fmt.Errorf("session %q not found: %w", session, err)
+	}
+	if _, ok := info.Config.Labels[SessionLabel]; !ok {
+		return SSHTarget{}, fmt.Errorf("container %q is not an enclaude session", session)
+	}
+	if !info.State.Running {
+		return SSHTarget{}, fmt.Errorf("session %q is not running", session)
+	}
+
+	if _, err := r.exec(ctx, info.ID, []string{"test", "-x", "/usr/sbin/sshd"}, nil); err != nil {
+		return SSHTarget{}, fmt.Errorf("the session image has no sshd; add openssh-server to it or rebuild with 'enclaude build'")
+	}
+
+	files, err := sshFiles(hostKey, authorizedKey)
+	if err != nil {
+		return SSHTarget{}, err
+	}
+	install := []string{"sh", "-c", `umask 077 && mkdir -p "$0" && tar -xf - -C "$0"`, SSHDir}
+	if _, err := r.exec(ctx, info.ID, install, files); err != nil {
+		return SSHTarget{}, fmt.Errorf("failed to install sshd configuration: %w", err)
+	}
+
+	launcher := SSHDir + "/launch.sh"
+	user, err := r.exec(ctx, info.ID, []string{"sh", launcher, "id", "-un"}, nil)
+	if err != nil {
+		return SSHTarget{}, fmt.Errorf("failed to look up the session user: %w", err)
+	}
+	if strings.TrimSpace(string(user)) == "root" {
+		return SSHTarget{}, fmt.Errorf("sessions running as root cannot be reached over SSH, since sshd needs capabilities the session drops")
+	}
+
+	return SSHTarget{
+		ContainerID:  info.ID,
+		User:         strings.TrimSpace(string(user)),
+		ProxyCommand: []string{"docker", "exec", "-i", info.ID, "sh", launcher},
+	}, nil
+}
+
+// sshFiles returns a tar archive of the sshd configuration, keys, and
+// launcher
+func sshFiles(hostKey, authorizedKey []byte) (*bytes.Buffer, error) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, f := range []struct {
+		name string
+		mode int64
+		data []byte
+	}{
+		{"sshd_config", 0600, []byte(sshdConfig)},
+		{"ssh_host_ed25519_key", 0600, hostKey},
+		{"authorized_keys", 0600, authorizedKey},
+		{"launch.sh", 0600, []byte(sshLauncher)},
+	} {
+		if err := tw.WriteHeader(&tar.Header{Name: f.name, Mode: f.mode, Size: int64(len(f.data))}); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(f.data); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	return &buf, nil
+}
+
+// exec runs cmd in a container as its user, feeding it stdin if set, and
+// returns its stdout. A non-zero exit is an error carrying its stderr.
+func (r *Runner) exec(ctx context.Context, containerID string, cmd []string, stdin *bytes.Buffer) ([]byte, error) {
+	created, err := r.client.ContainerExecCreate(ctx, containerID, containerTypes.ExecOptions{
+		Cmd:          cmd,
+		AttachStdin:  stdin != nil,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.client.ContainerExecAttach(ctx, created.ID, containerTypes.ExecAttachOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Close()
+
+	if stdin != nil {
+		if _, err := resp.Conn.Write(stdin.Bytes()); err != nil {
+			return nil, err
+		}
+		resp.CloseWrite()
+	}
+	var stdout, stderr bytes.Buffer
+	if _, err := stdcopy.StdCopy(&stdout, &stderr, resp.Reader); err != nil {
+		return nil, err
+	}
+
+	inspect, err := r.client.ContainerExecInspect(ctx, created.ID)
+	if err != nil {
+		return nil, err
+	}
+	if inspect.ExitCode != 0 {
+		return nil, fmt.Errorf("%s exited with code %d: %s", cmd[0], inspect.ExitCode, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,44 @@
+package container
+
+import (
+	"archive/tar"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestSSHFiles(t *testing.T) {
+	buf, err := sshFiles([]byte("host key"), []byte("ssh-ed25519 AAAA enclaude\n"))
+	if err != nil {
+		t.Fatalf("sshFiles() error = %v", err)
+	}
+
+	files := make(map[string]string)
+	tr := tar.NewReader(buf)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Mode&0077 != 0 {
+			t.Errorf("%s mode = %o, want private to the session user", hdr.Name, hdr.Mode)
+		}
+		data, _ := io.ReadAll(tr)
+		files[hdr.Name] = string(data)
+	}
+
+	if files["ssh_host_ed25519_key"] != "host key" || files["authorized_keys"] != "ssh-ed25519 AAAA enclaude\n" {
+		t.Errorf("keys = %q, %q", files["ssh_host_ed25519_key"], files["authorized_keys"])
+	}
+	for _, want := range []string{"HostKey " + SSHDir + "/ssh_host_ed25519_key", "PasswordAuthentication no"} {
+		if !strings.Contains(files["sshd_config"], want) {
+			t.Errorf("sshd_config missing %q", want)
+		}
+	}
+	if !strings.Contains(files["launch.sh"], "/usr/sbin/sshd -i -f \""+SSHDir+"/sshd_config\"") {
+		t.Errorf("launch.sh does not start sshd on stdio:\n%s", files["launch.sh"])
+	}
+}
@@ -0,0 +1,146 @@
+package container
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	containerTypes "github.com/docker/docker/api/types/container"
+)
+
+// topArgs are the ps arguments for ContainerTop. The daemon runs ps and keeps
+// the rows belonging to the container, so every process must be listed.
+var topArgs = []string{"-eo", "pid,user,pcpu,pmem,args"}
+
+// SessionStats is one sample of a running session's resource usage
+type SessionStats struct {
+	CPUPercent  float64 // Share of one CPU, as `docker stats` reports it (200% = two busy CPUs)
+	OnlineCPUs  int
+	Memory      uint64 // In use, excluding reclaimable page cache
+	MemoryLimit uint64
+	NetworkRx   uint64 // Bytes received across all container networks
+	NetworkTx   uint64 // Bytes sent across all container networks
+	PIDs        uint64
+	PIDsLimit   uint64    // 0 if unlimited
+	Processes   []Process // Busiest first
+}
+
+// Process is a process running in a session container
+type Process struct {
+	PID        string
+	User       string
+	CPUPercent float64
+	MemPercent float64
+	Command    string
+}
+
+// StreamStats samples a running session's resource usage about once a
+// second, calling fn with each sample until fn returns false, ctx is
+// cancelled, or the session stops
+func (r *Runner) StreamStats(ctx context.Context, session string, fn func(SessionStats) bool) error {
+	info, err := r.client.ContainerInspect(ctx, session)
+	if err != nil {
+		return fmt.Errorf("session %q not found: %w", session, err)
+	}
+	if _, ok := info.Config.Labels[SessionLabel]; !ok {
+		return fmt.Errorf("container %q is not an enclaude session", session)
+	}
+	if !info.State.Running {
+		return fmt.Errorf("session %q is not running", session)
+	}
+
+	resp, err := r.client.ContainerStats(ctx, info.ID, true)
+	if err != nil {
+		return fmt.Errorf("failed to read session stats: %w", err)
+	}
+	defer resp.Body.Close()
+
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var raw containerTypes.StatsResponse
+		if err := dec.Decode(&raw); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("session %q stopped", session)
+		}
+		// The first sample has nothing to measure CPU usage against
+		if raw.PreCPUStats.SystemUsage == 0 {
+			continue
+		}
+
+		stats := sessionStats(raw)
+		// Processes are best-effort; a session that is exiting may not list them
+		if top, err := r.client.ContainerTop(ctx, info.ID, topArgs); err == nil {
+			stats.Processes = parseTop(top.Titles, top.Processes)
+		}
+		if !fn(stats) {
+			return nil
+		}
+	}
+}
+
+// sessionStats converts a Docker stats sample, computing CPU usage the same
+// way `docker stats` does
+func sessionStats(raw containerTypes.StatsResponse) SessionStats {
+	cpus := int(raw.CPUStats.OnlineCPUs)
+	if cpus == 0 {
+		cpus = len(raw.CPUStats.CPUUsage.PercpuUsage)
+	}
+
+	s := SessionStats{
+		OnlineCPUs:  cpus,
+		Memory:      memoryUsage(raw.MemoryStats),
+		MemoryLimit: raw.MemoryStats.Limit,
+		PIDs:        raw.PidsStats.Current,
+		PIDsLimit:   raw.PidsStats.Limit,
+	}
+	cpuDelta := float64(raw.CPUStats.CPUUsage.TotalUsage) - float64(raw.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(raw.CPUStats.SystemUsage) - float64(raw.PreCPUStats.SystemUsage)
+	if cpuDelta > 0 && systemDelta > 0 {
+		s.CPUPercent = cpuDelta / systemDelta * float64(cpus) * 100
+	}
+	for _, n := range raw.Networks {
+		s.NetworkRx += n.RxBytes
+		s.NetworkTx += n.TxBytes
+	}
+	return s
+}
+
+// parseTop turns ContainerTop output into processes, busiest first. Columns
+// are located by title since ps implementations differ in layout.
+func parseTop(titles []string, rows [][]string) []Process {
+	col := make(map[string]int, len(titles))
+	for i, t := range titles {
+		col[t] = i
+	}
+	field := func(row []string, title string) string {
+		if i, ok := col[title]; ok && i < len(row) {
+			return row[i]
+		}
+		return ""
+	}
+
+	procs := make([]Process, 0, len(rows))
+	for _, row := range rows {
+		cpu, _ := strconv.ParseFloat(field(row, "%CPU"), 64)
+		mem, _ := strconv.ParseFloat(field(row, "%MEM"), 64)
+		command := field(row, "COMMAND")
+		if command == "" {
+			command = field(row, "CMD")
+		}
+		procs = append(procs, Process{
+			PID:        field(row, "PID"),
+			User:       field(row, "USER"),
+			CPUPercent: cpu,
+			MemPercent: mem,
+			Command:    command,
+		})
+	}
+	sort.SliceStable(procs, func(i, j int) bool {
+		return procs[i].CPUPercent > procs[j].CPUPercent
+	})
+	return procs
+}
@@ -0,0 +1,60 @@
+package container
+
+import (
+	"testing"
+
+	containerTypes "github.com/docker/docker/api/types/container"
+)
+
+func TestSessionStats(t *testing.T) {
+	var raw containerTypes.StatsResponse
+	raw.PreCPUStats.CPUUsage.TotalUsage = 1000
+	raw.PreCPUStats.SystemUsage = 10000
+	raw.CPUStats.CPUUsage.TotalUsage = 3000
+	raw.CPUStats.SystemUsage = 14000
+	raw.CPUStats.OnlineCPUs = 4
+	raw.MemoryStats = containerTypes.MemoryStats{Usage: 1000, Limit: 4000, Stats: map[string]uint64{"inactive_file": 200}}
+	raw.PidsStats = containerTypes.PidsStats{Current: 12, Limit: 512}
+	raw.Networks = map[string]containerTypes.NetworkStats{
+		"eth0": {RxBytes: 10, TxBytes: 5},
+		"eth1": {RxBytes: 20, TxBytes: 3},
+	}
+
+	s := sessionStats(raw)
+	// 2000 of 4000 system ticks across 4 CPUs is two busy CPUs
+	if s.CPUPercent != 200 {
+		t.Errorf("CPUPercent = %v, want 200", s.CPUPercent)
+	}
+	if s.Memory != 800 || s.MemoryLimit != 4000 {
+		t.Errorf("Memory = %d / %d, want 800 / 4000", s.Memory, s.MemoryLimit)
+	}
+	if s.NetworkRx != 30 || s.NetworkTx != 8 {
+		t.Errorf("NetworkRx, NetworkTx = %d, %d, want 30, 8", s.NetworkRx, s.NetworkTx)
+	}
+	if s.PIDs != 12 || s.PIDsLimit != 512 {
+		t.Errorf("PIDs = %d / %d, want 12 / 512", s.PIDs, s.PIDsLimit)
+	}
+}
+
+func TestParseTop(t *testing.T) {
+	titles := []string{"PID", "USER", "%CPU", "%MEM", "COMMAND"}
+	rows := [][]string{
+		{"101", "1000", "0.5", "1.0", "/bin/bash"},
+		{"102", "1000", "87.3", "12.4", "node /usr/local/bin/claude"},
+		{"103", "1000", "3.0", "0.2", "rg TODO"},
+	}
+
+	procs := parseTop(titles, rows)
+	if len(procs) != 3 {
+		t.Fatalf("got %d processes, want 3", len(procs))
+	}
+	want := []string{"102", "103", "101"}
+	for i, p := range procs {
+		if p.PID != want[i] {
+			t.Errorf("process %d is %s, want %s (busiest first)", i, p.PID, want[i])
+		}
+	}
+	if procs[0].Command != "node /usr/local/bin/claude" || procs[0].MemPercent != 12.4 {
+		t.Errorf("unexpected busiest process %+v", procs[0])
+	}
+}
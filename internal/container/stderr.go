@@ -0,0 +1,25 @@
+package container
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/docker/docker/api/types/mount"
+)
+
+// stderrFileTarget is where a TTY session's split stderr file is mounted
+const stderrFileTarget = "/run/enclaude/stderr.log"
+
+// stderrFileMount prepares path to receive a TTY session's stderr. With a
+// TTY the engine merges stderr into the terminal, so the entrypoint
+// redirects it to the file mounted here, named by ENCLAUDE_STDERR_FILE in the
+// returned environment entry. The file is created first so the bind mount
+// has a source.
+func stderrFileMount(path string) (mount.Mount, string, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return mount.Mount{}, "", fmt.Errorf("failed to open stderr file: %w", err)
+	}
+	f.Close()
+	return mount.Mount{Type: mount.TypeBind, Source: path, Target: stderrFileTarget}, "ENCLAUDE_STDERR_FILE=" + stderrFileTarget, nil
+}
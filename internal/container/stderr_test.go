@@ -0,0 +1,91 @@
+package container
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/mount"
+)
+
+func TestStderrFileMount(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "err.log")
+	m, env, err := stderrFileMount(path)
+	if err != nil {
+		t.Fatalf("stderrFileMount() error = %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("stderrFileMount() did not create the mount source: %v", err)
+	}
+	want := mount.Mount{Type: mount.TypeBind, Source: path, Target: stderrFileTarget}
+	if m != want {
+		t.Errorf("stderrFileMount() mount = %+v, want %+v", m, want)
+	}
+	if env != "ENCLAUDE_STDERR_FILE="+stderrFileTarget {
+		t.Errorf("stderrFileMount() env = %q, want ENCLAUDE_STDERR_FILE at the mount target", env)
+	}
+
+	// Earlier output is kept
+	os.WriteFile(path, []byte("earlier\n"), 0644)
+	if _, _, err := stderrFileMount(path); err != nil {
+		t.Fatalf("stderrFileMount() error = %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "earlier\n" {
+		t.Errorf("stderr file = %q after a second session, want it kept", data)
+	}
+
+	if _, _, err := stderrFileMount(filepath.Join(t.TempDir(), "missing", "err.log")); err == nil {
+		t.Error("stderrFileMount() in a missing directory should return an error")
+	}
+}
+
+func TestEntrypointStderrRedirect(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not installed")
+	}
+	entrypoint, err := os.ReadFile("../../docker/entrypoint.sh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := strings.Index(string(entrypoint), "# Send stderr to a separate file")
+	end := strings.Index(string(entrypoint)[start:], "\nfi\n")
+	if start < 0 || end < 0 {
+		t.Fatal("stderr redirect not found in the entrypoint")
+	}
+	redirect := string(entrypoint)[start : start+end+4]
+
+	run := func(env ...string) (stdout, stderr string) {
+		t.Helper()
+		cmd := exec.Command(bash, "-c", redirect+"echo out; echo err >&2")
+		cmd.Env = append(os.Environ(), env...)
+		var outBuf, errBuf strings.Builder
+		cmd.Stdout, cmd.Stderr = &outBuf, &errBuf
+		if err := cmd.Run(); err != nil {
+			t.Fatalf("redirect: %v: %s", err, errBuf.String())
+		}
+		return outBuf.String(), errBuf.String()
+	}
+
+	path := filepath.Join(t.TempDir(), "stderr.log")
+	os.WriteFile(path, []byte("earlier\n"), 0644)
+	stdout, stderr := run("ENCLAUDE_STDERR_FILE=" + path)
+	if stdout != "out\n" || stderr != "" {
+		t.Errorf("with ENCLAUDE_STDERR_FILE: stdout = %q, stderr = %q; want only stdout on the terminal", stdout, stderr)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "earlier\nerr\n" {
+		t.Errorf("stderr file = %q, want stderr appended", data)
+	}
+
+	stdout, stderr = run("ENCLAUDE_STDERR_FILE=")
+	if stdout != "out\n" || stderr != "err\n" {
+		t.Errorf("without ENCLAUDE_STDERR_FILE: stdout = %q, stderr = %q; want both on the terminal", stdout, stderr)
+	}
+
+	// An exec'd claude gets the same redirect from the session script
+	if !strings.Contains(string(entrypoint), `[ -n "$ENCLAUDE_STDERR_FILE" ] && echo 'exec 2>>"$ENCLAUDE_STDERR_FILE"'`) {
+		t.Error("the exec session script does not redirect stderr")
+	}
+}
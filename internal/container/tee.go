@@ -0,0 +1,63 @@
+package container
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/jakenelson/enclaude/internal/security"
+)
+
+// teeWriter keeps a durable record of terminal output in a log file. Secrets
+// are redacted and, unless raw, escape sequences and control characters are
+// dropped so the log reads as plain text. Failing to write the log never
+// interrupts the session: the first error is reported and logging stops.
+type teeWriter struct {
+	mu     sync.Mutex
+	file   *os.File
+	out    *security.RedactingWriter
+	raw    bool
+	failed bool
+	ansiStripper
+}
+
+// openTee opens path for appending session output
+func openTee(path string, raw bool) (*teeWriter, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open tee file: %w", err)
+	}
+	return &teeWriter{file: f, out: security.NewRedactingWriter(f), raw: raw}, nil
+}
+
+func (t *teeWriter) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.file == nil || t.failed {
+		return len(p), nil
+	}
+
+	text := p
+	if !t.raw {
+		text = t.strip(p)
+	}
+	if _, err := t.out.Write(text); err != nil {
+		t.failed = true
+		fmt.Fprintf(os.Stderr, "\r\nenclaude: stopped writing %s: %v\r\n", t.file.Name(), err)
+	}
+	return len(p), nil
+}
+
+// Close writes out any buffered partial line and closes the file. Output
+// written afterwards, by a stream still draining, is discarded.
+func (t *teeWriter) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.file == nil {
+		return nil
+	}
+	t.out.Close()
+	err := t.file.Close()
+	t.file = nil
+	return err
+}
@@ -0,0 +1,52 @@
+package container
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jakenelson/enclaude/internal/security"
+)
+
+func TestTeeWriter(t *testing.T) {
+	tests := []struct {
+		name   string
+		raw    bool
+		chunks []string
+		want   string
+	}{
+		{"plain", false, []string{"hello\r\n", "world\n"}, "hello\nworld\n"},
+		{"colors", false, []string{"\x1b[1;32mok\x1b[0m\n"}, "ok\n"},
+		{"split sequence", false, []string{"a\x1b[3", "1mb\x1b]8;;file:///x\x07c\x1b]8;;\x1b\\\n"}, "abc\n"},
+		{"control characters", false, []string{"\x07be\bep\tx\n"}, "beep\tx\n"},
+		{"raw", true, []string{"\x1b[1mbold\x1b[0m\r\n"}, "\x1b[1mbold\x1b[0m\r\n"},
+		{"redacted", false, []string{"key sk-ant-REDACTED\n"}, "key " + security.Redacted + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "session.log")
+			tee, err := openTee(path, tt.raw)
+			if err != nil {
+				t.Fatalf("openTee() error = %v", err)
+			}
+			for _, chunk := range tt.chunks {
+				if n, err := tee.Write([]byte(chunk)); err != nil || n != len(chunk) {
+					t.Fatalf("Write(%q) = %d, %v", chunk, n, err)
+				}
+			}
+			if err := tee.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+			// Late output from a draining stream is dropped
+			tee.Write([]byte("late\n"))
+
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("log = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package container
+
+// TelemetryEnv disables Claude Code telemetry, error reporting, and the
+// /bug command, which uploads the session transcript
+var TelemetryEnv = map[string]string{
+	"DISABLE_TELEMETRY":       "1",
+	"DISABLE_ERROR_REPORTING": "1",
+	"DISABLE_BUG_COMMAND":     "1",
+}
+
+// TelemetryHosts are telemetry and error reporting domains blocked when
+// telemetry is disabled, in case a client ignores TelemetryEnv. The hosts
+// file blocks each name itself; the session's HTTPS proxy refuses their
+// subdomains too, such as the per-project ingest hosts of sentry.io.
+var TelemetryHosts = []string{
+	"statsig.anthropic.com",
+	"api.statsig.com",
+	"sentry.io",
+}
+
+// blockedHostEntries maps hosts to an unroutable address in the container's
+// /etc/hosts, so connections to them fail immediately without a DNS lookup
+func blockedHostEntries(hosts []string) []string {
+	var entries []string
+	for _, host := range hosts {
+		entries = append(entries, host+":0.0.0.0")
+	}
+	return entries
+}
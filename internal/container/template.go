@@ -0,0 +1,39 @@
+package container
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// TemplateArtifactType identifies sandbox templates in an OCI registry
+const TemplateArtifactType = "application/vnd.enclaude.template.v1+json"
+
+// Template is a sandbox definition published to a registry so a team can
+// share an approved setup: a spec, carrying the image, mounts, and network
+// policy, plus the argument presets to run it with
+type Template struct {
+	Spec
+	Presets map[string][]string `json:"presets,omitempty"`
+}
+
+// EncodeTemplate serializes t for publishing
+func EncodeTemplate(t Template) ([]byte, error) {
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode template: %w", err)
+	}
+	return data, nil
+}
+
+// DecodeTemplate parses a template pulled from name, holding its spec to the
+// same checks as one read from a file
+func DecodeTemplate(data []byte, name string) (Template, error) {
+	var t Template
+	if err := json.Unmarshal(data, &t); err != nil {
+		return Template{}, fmt.Errorf("failed to parse template %s: %w", name, err)
+	}
+	if err := t.Spec.validate(name); err != nil {
+		return Template{}, err
+	}
+	return t, nil
+}
@@ -0,0 +1,82 @@
+package container
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	containerTypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// followTranscriptScript follows the newest Claude Code transcript written
+// since the session started, from its first line, switching to a newer one
+// when Claude starts another conversation (e.g. after /clear)
+const followTranscriptScript = `dir="$1"; since="$2"; current=""; pid=""
+trap '[ -n "$pid" ] && kill "$pid" 2>/dev/null' EXIT
+trap 'exit 0' TERM
+while :; do
+    newest=$(find "$dir" -name '*.jsonl' -newermt "@$since" -printf '%T@ %p\n' 2>/dev/null | sort -n | tail -n 1 | cut -d' ' -f2-)
+    if [ -n "$newest" ] && [ "$newest" != "$current" ]; then
+        [ -n "$pid" ] && kill "$pid" 2>/dev/null
+        tail -n +1 -F "$newest" 2>/dev/null &
+        pid=$!
+        current="$newest"
+    fi
+    sleep 1
+done`
+
+// stopMarkedScript stops the processes whose command line holds the marker
+// in $1. Docker does not stop exec'd processes when the client goes away.
+const stopMarkedScript = `for p in /proc/[0-9]*; do
+    [ "${p#/proc/}" = "$$" ] && continue
+    tr '\0' ' ' < "$p/cmdline" 2>/dev/null | grep -qF -- "$1" && kill "${p#/proc/}" 2>/dev/null
+done; true`
+
+// FollowTranscript streams the JSONL transcript of the conversation running
+// in a session to out, wherever its ~/.claude lives, until ctx is cancelled
+// or the session ends
+func (r *Runner) FollowTranscript(ctx context.Context, session string, out io.Writer) error {
+	info, err := r.runningSession(ctx, session)
+	if err != nil {
+		return err
+	}
+	since := time.Now()
+	if started, err := time.Parse(time.RFC3339Nano, info.State.StartedAt); err == nil {
+		since = started
+	}
+
+	// The marker is the script's $0, so it can be found and stopped
+	marker := "enclaude-transcript-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	created, err := r.client.ContainerExecCreate(ctx, info.ID, containerTypes.ExecOptions{
+		Cmd:          []string{"/bin/sh", "-c", followTranscriptScript, marker, Home + "/.claude/projects", strconv.FormatInt(since.Unix(), 10)},
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read the session transcript: %w", err)
+	}
+	resp, err := r.client.ContainerExecAttach(ctx, created.ID, containerTypes.ExecAttachOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to read the session transcript: %w", err)
+	}
+	defer resp.Close()
+	defer func() {
+		stopCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		r.exec(stopCtx, info.ID, []string{"/bin/sh", "-c", stopMarkedScript, "sh", marker}, nil)
+	}()
+
+	go func() {
+		<-ctx.Done()
+		resp.Close()
+	}()
+
+	_, err = stdcopy.StdCopy(out, io.Discard, resp.Reader)
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
@@ -1,31 +1,93 @@
 package container
 
-// Mount represents a bind mount configuration
+import (
+	"io"
+	"time"
+)
+
+// Mount represents a bind or named volume mount configuration
 type Mount struct {
-	Source   string // Host path
-	Target   string // Container path
-	ReadOnly bool
+	Source   string `json:"source"` // Host path, or volume name when Volume is set
+	Target   string `json:"target"` // Container path
+	ReadOnly bool   `json:"read_only,omitempty"`
+	Volume   bool   `json:"volume,omitempty"` // Source is a named Docker volume rather than a host path
+
+	Kind       MountKind `json:"-"` // Expected source type, verified by CheckMounts
+	Credential bool      `json:"-"` // Holds host credentials, whose host side is tracked for the audit log
 }
 
 // RunOptions configures container execution
 type RunOptions struct {
-	Image       string
-	Mounts      []Mount
-	Environment map[string]string
-	ClaudeArgs  []string
-	WorkDir     string
-	User        string
-	MemoryLimit string
-	Network     string
-	Security    SecurityOptions
+	Image        string            `json:"image"`
+	Mounts       []Mount           `json:"mounts"`
+	Environment  map[string]string `json:"environment,omitempty"`
+	ClaudeArgs   []string          `json:"claude_args,omitempty"`
+	WorkDir      string            `json:"workdir"`
+	HostWorkDir  string            `json:"-"` // Host directory the session was started from, recorded for `enclaude watch`
+	RunID        string            `json:"-"` // Run record in the enclaude state directory, recorded as a label
+	User         string            `json:"user,omitempty"`
+	HostUID      int               `json:"-"` // Host uid the session's files belong to with container.user random; 0 if unknown
+	MemoryLimit  string            `json:"memory_limit,omitempty"`
+	Reservation  string            `json:"memory_reservation,omitempty"` // Soft memory limit reclaimed to under host memory pressure, e.g., "2g"
+	Swappiness   *int64            `json:"memory_swappiness,omitempty"`  // 0-100; nil for the host default
+	CPUs         string            `json:"cpus,omitempty"`               // e.g., "1.5"
+	PidsLimit    int64             `json:"pids_limit,omitempty"`
+	TmpfsSize    string            `json:"tmpfs_size,omitempty"` // Size of each tmpfs mount, e.g., "1g"
+	Network      string            `json:"network,omitempty"`
+	Aliases      []string          `json:"network_aliases,omitempty"` // DNS names of the session on Network, if user-defined
+	Security     SecurityOptions   `json:"security"`
+	StderrFile   string            `json:"-"`                       // Host file to receive container stderr separately (optional)
+	TeeFile      string            `json:"-"`                       // Host file that also receives everything shown on the terminal (optional)
+	TeeRaw       bool              `json:"-"`                       // Keep escape sequences in TeeFile instead of writing plain text
+	Filters      []OutputFilter    `json:"-"`                       // Post-processing for non-TTY output and TeeFile, applied in order
+	Stdout       io.Writer         `json:"-"`                       // Receives container output instead of the terminal, if set
+	NoTTY        bool              `json:"-"`                       // Run without a TTY even when stdin is a terminal
+	Hyperlinks   bool              `json:"-"`                       // Turn workspace paths in TTY output into host hyperlinks
+	LinkFormat   string            `json:"-"`                       // Hyperlink URL template; empty for file:// URLs
+	Ports        []string          `json:"ports,omitempty"`         // Published ports, e.g., "8080:8080"
+	BlockedHosts []string          `json:"blocked_hosts,omitempty"` // Hostnames made unresolvable inside the container
+	HostEntries  []string          `json:"-"`                       // Further "name:address" hosts file entries, e.g. for forwarded host ports
+	DockerArgs   []string          `json:"docker_args,omitempty"`   // Further docker run flags, e.g. "--shm-size=2g", checked by ParseDockerArgs
+	Cgroup       CgroupOptions     `json:"cgroup,omitempty"`
+	Metrics      *RunMetrics       `json:"-"` // Filled in with resource usage during Run, if set
+	StopGrace    time.Duration     `json:"-"` // How long claude gets to exit after SIGINT before the container is stopped
+	ReadyCheck   string            `json:"-"` // Shell command that succeeds once the session is ready (default: the image's ready file)
+	ReadyTimeout time.Duration     `json:"-"` // How long to wait for the session to be ready before attaching anyway; 0 disables gating
+	IdleAfter    time.Duration     `json:"-"` // Lower the CPU limit to IdleCPUs after this long without terminal input or output; 0 never does (ignored by nerdctl)
+	IdleCPUs     string            `json:"-"` // CPU limit while idle, e.g., "0.1"
+	Approvals    <-chan Approval   `json:"-"` // Requests from the container for the user to allow or deny
+	PullPolicy   string            `json:"-"` // When to pull Image: always, missing, never (used by nerdctl; Runner callers use EnsureImage)
+	Interaction  string            `json:"-"` // attach, or exec to start claude with docker exec (ignored by nerdctl)
+
+	// NoCredentials marks a session run without host credentials, for
+	// --no-creds or an untrusted workspace. Services that reach into the
+	// host, such as host commands, are refused for it too.
+	NoCredentials bool `json:"-"`
+
+	// HostServices names the parts of the session that run on the host and
+	// end when enclaude exits, such as the API proxy. The session cannot be
+	// detached from while it has any.
+	HostServices []string `json:"-"`
+}
+
+// CgroupOptions places the container under a parent cgroup with its own
+// CPU and IO weights, using cgroup v2 weight units (1-10000, 0 = unset)
+type CgroupOptions struct {
+	Parent    string `json:"parent,omitempty"`
+	CPUWeight int    `json:"cpu_weight,omitempty"`
+	IOWeight  int    `json:"io_weight,omitempty"`
 }
 
 // SecurityOptions configures container security settings
 type SecurityOptions struct {
-	DropCapabilities bool
-	NoNewPrivileges  bool
-	ReadOnlyRoot     bool
-	CACerts          []string // Paths to additional CA certificates
+	DropCapabilities bool     `json:"drop_capabilities"`
+	NoNewPrivileges  bool     `json:"no_new_privileges"`
+	ReadOnlyRoot     bool     `json:"read_only_root"`
+	CACerts          []string `json:"ca_certs,omitempty"` // Paths to additional CA certificates
+
+	// Ephemeral mounts every bind mount read-only and replaces named volumes
+	// with empty tmpfs mounts; ReadOnlyRoot is set with it
+	Ephemeral bool `json:"ephemeral,omitempty"`
 }
 
 // BuildOptions configures image building
@@ -35,4 +97,11 @@ type BuildOptions struct {
 	Tag        string
 	NoCache    bool
 	Platform   string
+	CacheFrom  []string          // Registry refs or local directories to import layer cache from
+	CacheTo    []string          // Registry refs or local directories to export layer cache to
+	Attest     bool              // Attach SLSA provenance and SBOM attestations (uses buildx)
+	Push       bool              // Push to the registry named by Tag instead of loading locally (uses buildx)
+	BuildArgs  map[string]string // Build arguments, e.g. the uid and gid of the image's enclaude user
+	Secrets    []BuildSecret     // BuildKit secrets, available to RUN --mount=type=secret but never stored in layers (uses buildx)
+	Labels     map[string]string // Image labels, e.g. the build inputs
 }
@@ -1,6 +1,8 @@
 package container
 
 import (
+	"slices"
+	"strings"
 	"testing"
 )
 
@@ -48,3 +50,33 @@ func TestRunOptionsWithCACerts(t *testing.T) {
 		t.Errorf("expected 2 CA certs, got %d", len(opts.Security.CACerts))
 	}
 }
+
+func TestCACertMounts(t *testing.T) {
+	tests := []struct {
+		name       string
+		certs      []string
+		wantMounts int
+		wantEnv    []string
+	}{
+		{"none", nil, 0, nil},
+		{"one", []string{"/etc/corp/ca.crt"}, 2, []string{"ENCLAUDE_CA_BUNDLE=" + CABundle, "NODE_EXTRA_CA_CERTS=" + CACertDir + "/ca.crt"}},
+		{"several", []string{"/etc/corp/a.crt", "/etc/corp/b.pem"}, 3, []string{"ENCLAUDE_CA_BUNDLE=" + CABundle}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mounts, env := caCertMounts(tt.certs)
+			if len(mounts) != tt.wantMounts {
+				t.Fatalf("got %d mounts, want %d: %+v", len(mounts), tt.wantMounts, mounts)
+			}
+			if !slices.Equal(env, tt.wantEnv) {
+				t.Errorf("env = %v, want %v", env, tt.wantEnv)
+			}
+			for _, m := range mounts {
+				// The system store must stay visible so the bundle keeps the public roots
+				if strings.HasPrefix(m.Target, "/etc/") {
+					t.Errorf("mount %s shadows the system trust store", m.Target)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package container
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/jakenelson/enclaude/internal/config"
+)
+
+// Home is HOME in the container. It is a tmpfs owned by the session user, so
+// it is writable whatever uid the session runs as, even with a read-only root
+// filesystem. Credentials and rc files are mounted inside it.
+const Home = "/home/enclaude"
+
+// Labels recording the uid and gid of the enclaude user baked into an image
+const (
+	UIDLabel = "io.enclaude.uid"
+	GIDLabel = "io.enclaude.gid"
+)
+
+// DefaultImageUID is the uid and gid of the image's enclaude user unless the
+// build overrides them
+const DefaultImageUID = 1000
+
+// UserBuildArgs returns the build arguments that give the image's enclaude
+// user uid and gid
+func UserBuildArgs(uid, gid int) map[string]string {
+	return map[string]string{
+		"ENCLAUDE_UID": strconv.Itoa(uid),
+		"ENCLAUDE_GID": strconv.Itoa(gid),
+	}
+}
+
+// ImageUser returns the uid and gid of the enclaude user baked into image,
+// with ok false for images built without one
+func (r *Runner) ImageUser(ctx context.Context, image string) (uid, gid int, ok bool, err error) {
+	inspect, _, err := r.client.ImageInspectWithRaw(ctx, image)
+	if err != nil {
+		return 0, 0, false, fmt.Errorf("failed to inspect image %s: %w", image, err)
+	}
+	if inspect.Config == nil {
+		return 0, 0, false, nil
+	}
+	uid, uidErr := strconv.Atoi(inspect.Config.Labels[UIDLabel])
+	gid, gidErr := strconv.Atoi(inspect.Config.Labels[GIDLabel])
+	if uidErr != nil || gidErr != nil {
+		return 0, 0, false, nil
+	}
+	return uid, gid, true, nil
+}
+
+// ImageLabels returns the labels of a local image
+func (r *Runner) ImageLabels(ctx context.Context, image string) (map[string]string, error) {
+	inspect, _, err := r.client.ImageInspectWithRaw(ctx, image)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect image %s: %w", image, err)
+	}
+	if inspect.Config == nil {
+		return nil, nil
+	}
+	return inspect.Config.Labels, nil
+}
+
+// sessionUser resolves the container user for a session of image. "auto"
+// runs as the host uid:gid so files in the workspace keep their owner. Under
+// rootless Docker, container root already maps to the host user, so auto
+// runs as root there instead. Under user namespace remapping no container
+// uid maps to the host user, so auto runs as the enclaude user baked into
+// the image, which owns the image's files, rather than as root.
+func (r *Runner) sessionUser(ctx context.Context, user, image string) string {
+	if user != config.UserAuto {
+		return user
+	}
+	switch r.UserNamespace(ctx) {
+	case UserNamespaceRootless:
+		return "0:0"
+	case UserNamespaceRemap:
+		if uid, gid, ok, _ := r.ImageUser(ctx, image); ok {
+			return fmt.Sprintf("%d:%d", uid, gid)
+		}
+	}
+	return fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid())
+}
+
+// User namespaces the engine runs containers in
+const (
+	UserNamespaceRootless = "rootless" // Container root is the host user
+	UserNamespaceRemap    = "userns"   // The daemon remaps uids into its own subordinate range
+)
+
+// UserNamespace reports the user namespace the engine runs containers in,
+// or "" if container uids are host uids
+func (r *Runner) UserNamespace(ctx context.Context) string {
+	info, err := r.client.Info(ctx)
+	if err != nil {
+		return ""
+	}
+	return userNamespace(info.SecurityOptions)
+}
+
+// userNamespace finds the user namespace in an engine's security options
+func userNamespace(securityOptions []string) string {
+	for _, opt := range securityOptions {
+		if strings.Contains(opt, "name=rootless") {
+			return UserNamespaceRootless
+		}
+		if strings.Contains(opt, "name=userns") {
+			return UserNamespaceRemap
+		}
+	}
+	return ""
+}
+
+// Container uids a random session user is picked from under a user
+// namespace: above the users images create for themselves, and within the
+// 65536 subordinate uids engines map by default
+const (
+	randomUIDMin = 10000
+	randomUIDMax = 65535
+)
+
+// SubIDRange is a user's range of subordinate ids from /etc/subuid
+type SubIDRange struct {
+	Start int
+	Count int
+}
+
+// ReadSubIDRange returns the first range in an /etc/subuid-format file for
+// the user with the given name or uid, with ok false if there is none
+func ReadSubIDRange(path, name string, uid int) (r SubIDRange, ok bool, err error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return r, false, nil
+	}
+	if err != nil {
+		return r, false, err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Split(strings.TrimSpace(line), ":")
+		if len(fields) != 3 || (fields[0] != name && fields[0] != strconv.Itoa(uid)) {
+			continue
+		}
+		start, startErr := strconv.Atoi(fields[1])
+		count, countErr := strconv.Atoi(fields[2])
+		if startErr != nil || countErr != nil || start <= 0 || count <= 0 {
+			continue
+		}
+		return SubIDRange{Start: start, Count: count}, true, nil
+	}
+	return r, false, nil
+}
+
+// randomUserAttempts is how many uids RandomUser tries before giving up on
+// finding one no running session uses
+const randomUserAttempts = 100
+
+// RandomUser picks a uid for container.user random that no host account and
+// no running session uses, skipping the container uids in inUse. With
+// container uids as host uids, it is a host uid from the user's subordinate
+// range, run with the user's gid so group-writable workspace files stay
+// writable. Under rootless engines, a container uid maps into the same
+// range, and group 0 to the user's group. It returns the container user and
+// the host uid, which is 0 when the daemon remaps uids into a range of its
+// own.
+func RandomUser(userns string, subuid SubIDRange, hasRange bool, gid int, inUse map[int]bool) (string, int, error) {
+	if userns == "" && !hasRange {
+		return "", 0, fmt.Errorf("container.user random needs a subordinate uid range for your user in /etc/subuid, e.g. 'sudo usermod --add-subuids 100000-165535 $USER'")
+	}
+	for i := 0; i < randomUserAttempts; i++ {
+		if userns != "" {
+			uid, err := randomInt(randomUIDMin, randomUIDMax)
+			if err != nil {
+				return "", 0, err
+			}
+			if inUse[uid] {
+				continue
+			}
+			hostUID := 0
+			if userns == UserNamespaceRootless && hasRange && uid <= subuid.Count {
+				// Rootless engines map container uid 1 to the start of the range
+				hostUID = subuid.Start + uid - 1
+			}
+			return fmt.Sprintf("%d:0", uid), hostUID, nil
+		}
+
+		offset, err := randomInt(0, subuid.Count-1)
+		if err != nil {
+			return "", 0, err
+		}
+		uid := subuid.Start + offset
+		if inUse[uid] {
+			continue
+		}
+		return fmt.Sprintf("%d:%d", uid, gid), uid, nil
+	}
+	return "", 0, fmt.Errorf("failed to pick a uid no running session uses after %d attempts", randomUserAttempts)
+}
+
+// randomInt returns a random integer in [min, max]
+func randomInt(min, max int) (int, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(max-min+1)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to pick a random uid: %w", err)
+	}
+	return min + int(n.Int64()), nil
+}
+
+// homeTmpfs returns the tmpfs options for Home. A numeric user owns its home;
+// a named user's uid is unknown here, so its home is world-writable with the
+// sticky bit instead.
+func homeTmpfs(user string, size int64) string {
+	options := "mode=1777"
+	uid, gid, _ := strings.Cut(user, ":")
+	if _, err := strconv.Atoi(uid); err == nil {
+		options = "mode=0700,uid=" + uid
+		if _, err := strconv.Atoi(gid); err == nil {
+			options += ",gid=" + gid
+		}
+	}
+	if size > 0 {
+		options += ",size=" + strconv.FormatInt(size, 10)
+	}
+	return options
+}
@@ -0,0 +1,124 @@
+package container
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestHomeTmpfs(t *testing.T) {
+	tests := []struct {
+		user string
+		size int64
+		want string
+	}{
+		{"501:20", 0, "mode=0700,uid=501,gid=20"},
+		{"1000", 0, "mode=0700,uid=1000"},
+		{"0:0", 1 << 30, "mode=0700,uid=0,gid=0,size=1073741824"},
+		{"enclaude", 0, "mode=1777"},
+		{"", 0, "mode=1777"},
+	}
+	for _, tt := range tests {
+		if got := homeTmpfs(tt.user, tt.size); got != tt.want {
+			t.Errorf("homeTmpfs(%q, %d) = %q, want %q", tt.user, tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestReadSubIDRange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "subuid")
+	data := "alice:100000:65536\n# comment\n1001:165536:65536\nbob:bad:65536\nbob:231072:65536\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		uid    int
+		want   SubIDRange
+		wantOK bool
+	}{
+		{"alice", 1000, SubIDRange{100000, 65536}, true},
+		{"carol", 1001, SubIDRange{165536, 65536}, true},
+		{"bob", 1002, SubIDRange{231072, 65536}, true},
+		{"dave", 1003, SubIDRange{}, false},
+	}
+	for _, tt := range tests {
+		got, ok, err := ReadSubIDRange(path, tt.name, tt.uid)
+		if err != nil || ok != tt.wantOK || got != tt.want {
+			t.Errorf("ReadSubIDRange(%q, %d) = %+v, %v, %v; want %+v, %v", tt.name, tt.uid, got, ok, err, tt.want, tt.wantOK)
+		}
+	}
+
+	if _, ok, err := ReadSubIDRange(filepath.Join(t.TempDir(), "missing"), "alice", 1000); ok || err != nil {
+		t.Errorf("missing file: ok = %v, err = %v", ok, err)
+	}
+}
+
+func TestRandomUser(t *testing.T) {
+	subuid := SubIDRange{Start: 100000, Count: 65536}
+
+	user, hostUID, err := RandomUser("", subuid, true, 20, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hostUID < subuid.Start || hostUID >= subuid.Start+subuid.Count {
+		t.Errorf("host uid %d outside the subordinate range", hostUID)
+	}
+	if want := fmt.Sprintf("%d:20", hostUID); user != want {
+		t.Errorf("user = %q, want %q", user, want)
+	}
+
+	if _, _, err := RandomUser("", SubIDRange{}, false, 20, nil); err == nil {
+		t.Error("expected an error without a subordinate range")
+	}
+
+	user, hostUID, err = RandomUser(UserNamespaceRootless, subuid, true, 20, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uid, gid, _ := strings.Cut(user, ":")
+	n, _ := strconv.Atoi(uid)
+	if n < randomUIDMin || n > randomUIDMax || gid != "0" {
+		t.Errorf("rootless user = %q", user)
+	}
+	if hostUID != subuid.Start+n-1 {
+		t.Errorf("rootless host uid = %d, want %d", hostUID, subuid.Start+n-1)
+	}
+
+	if _, hostUID, err = RandomUser(UserNamespaceRemap, subuid, true, 20, nil); err != nil || hostUID != 0 {
+		t.Errorf("remapped host uid = %d, err = %v; want 0", hostUID, err)
+	}
+
+	// A range of two uids with one in use leaves only the other
+	small := SubIDRange{Start: 100000, Count: 2}
+	for i := 0; i < 10; i++ {
+		user, _, err := RandomUser("", small, true, 20, map[int]bool{100000: true})
+		if err != nil || user != "100001:20" {
+			t.Fatalf("with 100000 in use: user = %q, err = %v; want 100001:20", user, err)
+		}
+	}
+	if _, _, err := RandomUser("", small, true, 20, map[int]bool{100000: true, 100001: true}); err == nil {
+		t.Error("expected an error with every uid in use")
+	}
+}
+
+func TestUserNamespace(t *testing.T) {
+	tests := []struct {
+		opts []string
+		want string
+	}{
+		{[]string{"name=seccomp,profile=builtin", "name=rootless", "name=cgroupns"}, UserNamespaceRootless},
+		{[]string{"name=seccomp,profile=builtin", "name=userns"}, UserNamespaceRemap},
+		{[]string{"name=seccomp,profile=builtin"}, ""},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		if got := userNamespace(tt.opts); got != tt.want {
+			t.Errorf("userNamespace(%v) = %q, want %q", tt.opts, got, tt.want)
+		}
+	}
+}
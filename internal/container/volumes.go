@@ -0,0 +1,113 @@
+package container
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/volume"
+)
+
+// VolumePrefix starts the names of the volumes enclaude creates, and of the
+// cache volumes in project templates
+const VolumePrefix = "enclaude-"
+
+// Claude Code cache volume, shared by every session so fresh containers
+// start warm. The mount point is created world-writable in the image.
+const (
+	ClaudeCacheVolume = "enclaude-claude-cache"
+	ClaudeCachePath   = "/var/cache/enclaude/claude"
+)
+
+// Claude Code login volume for claude.session_dir volume, written by
+// 'enclaude login' so sessions need no host ~/.claude. The entrypoint links
+// ~/.claude to the mount point, which is created world-writable in the image.
+const (
+	ClaudeHomeVolume = "enclaude-claude-home"
+	ClaudeHomePath   = "/var/lib/enclaude/claude"
+)
+
+// ArtifactsPath is where the artifacts directory is mounted, so reports and
+// generated files reach the host even when workspace changes do not
+const ArtifactsPath = "/artifacts"
+
+// ProjectVolumeName returns a stable Docker volume name for a per-project
+// volume, derived from the host workspace path
+func ProjectVolumeName(purpose, workDir string) string {
+	sum := sha256.Sum256([]byte(workDir))
+	return VolumePrefix + purpose + "-" + hex.EncodeToString(sum[:])[:12]
+}
+
+// ProjectVolumePurpose returns the purpose of a volume named by
+// ProjectVolumeName, with ok false for other names
+func ProjectVolumePurpose(name string) (purpose string, ok bool) {
+	rest, found := strings.CutPrefix(name, VolumePrefix)
+	i := strings.LastIndexByte(rest, '-')
+	if !found || i <= 0 || len(rest)-i-1 != 12 {
+		return "", false
+	}
+	if _, err := hex.DecodeString(rest[i+1:]); err != nil {
+		return "", false
+	}
+	return rest[:i], true
+}
+
+// Volume is a Docker volume sessions use
+type Volume struct {
+	Name    string
+	Created time.Time
+	Size    int64 // Bytes, or -1 if not measured
+	InUse   int64 // Containers using the volume, or -1 if not measured
+}
+
+// Volumes returns the volumes named with VolumePrefix or listed in names,
+// sorted by name. With measure, their size and use are filled in, which
+// can take the engine a while for large volumes.
+func (r *Runner) Volumes(ctx context.Context, names []string, measure bool) ([]Volume, error) {
+	var found []*volume.Volume
+	if measure {
+		usage, err := r.client.DiskUsage(ctx, types.DiskUsageOptions{Types: []types.DiskUsageObject{types.VolumeObject}})
+		if err != nil {
+			return nil, fmt.Errorf("failed to measure volumes: %w", err)
+		}
+		found = usage.Volumes
+	} else {
+		list, err := r.client.VolumeList(ctx, volume.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list volumes: %w", err)
+		}
+		found = list.Volumes
+	}
+
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+	var volumes []Volume
+	for _, v := range found {
+		if v == nil || !(strings.HasPrefix(v.Name, VolumePrefix) || wanted[v.Name]) {
+			continue
+		}
+		vol := Volume{Name: v.Name, Size: -1, InUse: -1}
+		vol.Created, _ = time.Parse(time.RFC3339, v.CreatedAt)
+		if v.UsageData != nil {
+			vol.Size, vol.InUse = v.UsageData.Size, v.UsageData.RefCount
+		}
+		volumes = append(volumes, vol)
+	}
+	sort.Slice(volumes, func(i, j int) bool { return volumes[i].Name < volumes[j].Name })
+	return volumes, nil
+}
+
+// RemoveVolume removes a volume. It fails while a container uses the volume.
+func (r *Runner) RemoveVolume(ctx context.Context, name string) error {
+	if err := r.client.VolumeRemove(ctx, name, false); err != nil {
+		return fmt.Errorf("failed to remove volume %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package container
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestProjectVolumeName(t *testing.T) {
+	a := ProjectVolumeName("history", "/home/user/project-a")
+	b := ProjectVolumeName("history", "/home/user/project-b")
+
+	if !strings.HasPrefix(a, "enclaude-history-") {
+		t.Errorf("ProjectVolumeName() = %s, want enclaude-history- prefix", a)
+	}
+	if a == b {
+		t.Errorf("ProjectVolumeName() should differ between workspaces, both got %s", a)
+	}
+	if a != ProjectVolumeName("history", "/home/user/project-a") {
+		t.Error("ProjectVolumeName() should be stable for the same workspace")
+	}
+}
+
+func TestProjectVolumePurpose(t *testing.T) {
+	tests := []struct {
+		name    string
+		purpose string
+		ok      bool
+	}{
+		{ProjectVolumeName("history", "/home/user/project"), "history", true},
+		{ProjectVolumeName("fast-fs", "/home/user/project"), "fast-fs", true},
+		{"enclaude-npm", "", false},
+		{"enclaude-go-mod", "", false},
+		{"enclaude-history-nothexnothex", "", false},
+		{"other-history-0123456789ab", "", false},
+	}
+	for _, tt := range tests {
+		purpose, ok := ProjectVolumePurpose(tt.name)
+		if purpose != tt.purpose || ok != tt.ok {
+			t.Errorf("ProjectVolumePurpose(%q) = %q, %v; want %q, %v", tt.name, purpose, ok, tt.purpose, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package credentials
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/jakenelson/enclaude/internal/container"
+	"github.com/jakenelson/enclaude/internal/security"
+)
+
+// credentialPaths are host locations that hold credentials, in addition to
+// the security package's denied and credential-controlled paths
+var credentialPaths = []string{
+	"~/.claude",
+	"~/.azure",
+}
+
+// secretEnvMarkers identify environment variable names that carry secrets
+var secretEnvMarkers = []string{
+	"TOKEN",
+	"SECRET",
+	"PASSWORD",
+	"PASSWD",
+	"API_KEY",
+	"ACCESS_KEY",
+	"PRIVATE_KEY",
+	"CREDENTIAL",
+	"AUTH",
+	"_PAT",
+}
+
+// IsSecretEnv reports whether an environment variable name looks like it
+// carries a secret (API keys, tokens, passwords, credential paths, agent sockets)
+func IsSecretEnv(key string) bool {
+	upper := strings.ToUpper(key)
+	for _, marker := range secretEnvMarkers {
+		if strings.Contains(upper, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// AuditNoCredentials verifies that opts carries no credential mounts, or
+// mounts of directories containing them, no host sockets, and no secret
+// environment variables. It backs --no-creds and spec export, so any
+// violation is an error rather than a warning.
+func AuditNoCredentials(opts container.RunOptions) error {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("failed to resolve home directory for credential audit: %w", err)
+	}
+
+	var paths []string
+	paths = append(paths, security.HardcodedDeniedPaths...)
+	paths = append(paths, security.CredentialControlledPaths...)
+	paths = append(paths, credentialPaths...)
+
+	var violations []string
+	for _, m := range opts.Mounts {
+		if m.Volume {
+			continue
+		}
+		// Host sockets, such as agents forwarded under sockets, act with
+		// the user's credentials however they are mounted
+		if info, err := os.Stat(m.Source); err == nil && info.Mode()&os.ModeSocket != 0 {
+			violations = append(violations, fmt.Sprintf("socket %s", m.Source))
+			continue
+		}
+		violation := ""
+		for _, p := range paths {
+			expanded := home + strings.TrimPrefix(p, "~")
+			if security.IsPathInDirectory(m.Source, expanded) {
+				violation = fmt.Sprintf("mount %s (%s)", m.Source, p)
+				break
+			}
+		}
+		// A parent directory, such as ~, exposes what it contains
+		if sensitive, ok := security.ContainedSensitivePath(m.Source); ok && violation == "" {
+			violation = fmt.Sprintf("mount %s (contains %s)", m.Source, sensitive)
+		}
+		if violation != "" {
+			violations = append(violations, violation)
+		}
+	}
+
+	for key := range opts.Environment {
+		if IsSecretEnv(key) {
+			violations = append(violations, "environment variable "+key)
+		}
+	}
+
+	if len(violations) > 0 {
+		sort.Strings(violations)
+		return fmt.Errorf("credential audit failed: found %s", strings.Join(violations, ", "))
+	}
+	return nil
+}
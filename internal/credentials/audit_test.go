@@ -0,0 +1,86 @@
+package credentials
+
+import (
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jakenelson/enclaude/internal/container"
+)
+
+func TestIsSecretEnv(t *testing.T) {
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"ANTHROPIC_API_KEY", true},
+		{"GH_TOKEN", true},
+		{"SSH_AUTH_SOCK", true},
+		{"GOOGLE_APPLICATION_CREDENTIALS", true},
+		{"AZURE_DEVOPS_EXT_PAT", true},
+		{"db_password", true},
+		{"TERM", false},
+		{"EDITOR", false},
+		{"HISTFILE", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsSecretEnv(tt.key); got != tt.want {
+			t.Errorf("IsSecretEnv(%q) = %v, want %v", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestAuditNoCredentials(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	clean := container.RunOptions{
+		Mounts: []container.Mount{
+			{Source: filepath.Join(home, "project"), Target: "/workspace"},
+			{Source: "enclaude-history-abc", Target: "/var/lib/enclaude/history", Volume: true},
+		},
+		Environment: map[string]string{"TERM": "xterm-256color"},
+	}
+	if err := AuditNoCredentials(clean); err != nil {
+		t.Errorf("AuditNoCredentials() on clean options error = %v", err)
+	}
+
+	dirty := container.RunOptions{
+		Mounts: []container.Mount{
+			{Source: filepath.Join(home, ".claude"), Target: container.Home + "/.claude"},
+			{Source: filepath.Join(home, ".ssh", "id_ed25519"), Target: container.Home + "/.ssh/id_ed25519"},
+		},
+		Environment: map[string]string{"GH_TOKEN": "secret"},
+	}
+	err := AuditNoCredentials(dirty)
+	if err == nil {
+		t.Fatal("AuditNoCredentials() on credential mounts should fail")
+	}
+	for _, want := range []string{".claude", ".ssh", "GH_TOKEN"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("AuditNoCredentials() error %q does not mention %s", err, want)
+		}
+	}
+
+	parent := container.RunOptions{Mounts: []container.Mount{{Source: home, Target: "/home-copy", ReadOnly: true}}}
+	if err := AuditNoCredentials(parent); err == nil || !strings.Contains(err.Error(), "contains") {
+		t.Errorf("AuditNoCredentials() on a mount of the home directory error = %v, want it refused", err)
+	}
+}
+
+func TestAuditNoCredentialsSockets(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	sock := filepath.Join(t.TempDir(), "agent.sock")
+	listener, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	defer listener.Close()
+
+	err = AuditNoCredentials(container.RunOptions{Mounts: []container.Mount{{Source: sock, Target: "/run/enclaude/sockets/agent.sock"}}})
+	if err == nil || !strings.Contains(err.Error(), "socket "+sock) {
+		t.Errorf("AuditNoCredentials() with a forwarded socket error = %v", err)
+	}
+}
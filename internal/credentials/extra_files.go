@@ -0,0 +1,100 @@
+package credentials
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/jakenelson/enclaude/internal/config"
+	"github.com/jakenelson/enclaude/internal/container"
+	"github.com/jakenelson/enclaude/internal/security"
+)
+
+// containerHome is HOME inside the container; "~/" in extra file targets
+// refers to it
+const containerHome = container.Home
+
+// reservedTargets may not be shadowed by extra credential files, besides
+// the workspace
+var reservedTargets = []string{"/run/enclaude"}
+
+// collectExtraFiles validates credentials.extra_files and returns read-only
+// mounts for the entries whose source exists on this host. Entries that would
+// expose denied or credential-controlled paths, or land in the workspace at
+// workspaceTarget, are rejected outright rather than skipped, so a
+// misconfiguration is noticed.
+func collectExtraFiles(entries []config.ExtraFile, home, workspaceTarget string) ([]container.Mount, error) {
+	reserved := append([]string{workspaceTarget}, reservedTargets...)
+	var mounts []container.Mount
+	for i, entry := range entries {
+		mount, err := extraFileMount(entry, home, reserved)
+		if err != nil {
+			return nil, fmt.Errorf("credentials.extra_files[%d]: %w", i, err)
+		}
+		if mount != nil {
+			mounts = append(mounts, *mount)
+		}
+	}
+	return mounts, nil
+}
+
+// extraFileMount validates a single entry, returning nil if its source does
+// not exist
+func extraFileMount(entry config.ExtraFile, home string, reserved []string) (*container.Mount, error) {
+	if entry.Source == "" {
+		return nil, fmt.Errorf("source is required")
+	}
+	source, err := security.ExpandPath(entry.Source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid source %q: %w", entry.Source, err)
+	}
+	if err := security.ValidateMountPathStrict(source); err != nil {
+		return nil, fmt.Errorf("source %q denied: %w", entry.Source, err)
+	}
+	if sensitive, ok := security.ContainedSensitivePath(source); ok {
+		return nil, fmt.Errorf("source %q denied: it contains %s", entry.Source, sensitive)
+	}
+
+	target, err := extraFileTarget(entry.Target, source, home, reserved)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Stat(source); os.IsNotExist(err) {
+		return nil, nil
+	}
+	return &container.Mount{Source: source, Target: target, ReadOnly: true}, nil
+}
+
+// extraFileTarget resolves the container path for an entry. Without an
+// explicit target, files under the host home directory keep their place
+// relative to the container home, so ~/.terraformrc stays ~/.terraformrc.
+// Targets in a reserved directory are refused either way.
+func extraFileTarget(target, source, home string, reserved []string) (string, error) {
+	switch {
+	case target == "":
+		rel, err := filepath.Rel(home, source)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			target = filepath.ToSlash(source)
+		} else {
+			target = path.Join(containerHome, filepath.ToSlash(rel))
+		}
+	case target == "~" || strings.HasPrefix(target, "~/"):
+		target = path.Join(containerHome, strings.TrimPrefix(target, "~"))
+	}
+	if !path.IsAbs(target) {
+		return "", fmt.Errorf("target %q must be absolute or start with ~/", target)
+	}
+	target = path.Clean(target)
+	if target == "/" || target == containerHome {
+		return "", fmt.Errorf("target %q would replace a system directory", target)
+	}
+	for _, dir := range reserved {
+		if target == dir || strings.HasPrefix(target, dir+"/") {
+			return "", fmt.Errorf("target %q is reserved (%s)", target, dir)
+		}
+	}
+	return target, nil
+}
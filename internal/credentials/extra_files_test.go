@@ -0,0 +1,101 @@
+package credentials
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jakenelson/enclaude/internal/config"
+)
+
+func TestCollectExtraFiles(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	home, _ = filepath.EvalSymlinks(home)
+
+	for _, f := range []string{".terraformrc", ".cargo/credentials.toml", ".config/op/config", ".aws/credentials", ".aws/config"} {
+		path := filepath.Join(home, f)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte("secret"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	outside := filepath.Join(t.TempDir(), "tool.cfg")
+	os.WriteFile(outside, []byte("x"), 0600)
+
+	tests := []struct {
+		name       string
+		entry      config.ExtraFile
+		wantTarget string // Empty means no mount
+		wantErr    string
+	}{
+		{name: "file keeps place under container home", entry: config.ExtraFile{Source: "~/.terraformrc"}, wantTarget: "/home/enclaude/.terraformrc"},
+		{name: "nested file", entry: config.ExtraFile{Source: "~/.cargo/credentials.toml"}, wantTarget: "/home/enclaude/.cargo/credentials.toml"},
+		{name: "directory", entry: config.ExtraFile{Source: "~/.config/op"}, wantTarget: "/home/enclaude/.config/op"},
+		{name: "explicit home target", entry: config.ExtraFile{Source: "~/.terraformrc", Target: "~/.config/terraform/rc"}, wantTarget: "/home/enclaude/.config/terraform/rc"},
+		{name: "explicit absolute target", entry: config.ExtraFile{Source: "~/.terraformrc", Target: "/etc/terraformrc"}, wantTarget: "/etc/terraformrc"},
+		{name: "outside home keeps path", entry: config.ExtraFile{Source: outside}, wantTarget: outside},
+		{name: "missing source skipped", entry: config.ExtraFile{Source: "~/.npmrc"}},
+		{name: "empty source", entry: config.ExtraFile{}, wantErr: "source is required"},
+		{name: "hardcoded denied", entry: config.ExtraFile{Source: "~/.aws/credentials"}, wantErr: "hardcoded denied"},
+		{name: "credential controlled", entry: config.ExtraFile{Source: "~/.aws/config"}, wantErr: "explicit credential configuration"},
+		{name: "parent of denied path", entry: config.ExtraFile{Source: "~"}, wantErr: "it contains"},
+		{name: "relative target", entry: config.ExtraFile{Source: "~/.terraformrc", Target: "terraformrc"}, wantErr: "must be absolute"},
+		{name: "workspace target", entry: config.ExtraFile{Source: "~/.terraformrc", Target: "/workspace/.terraformrc"}, wantErr: "reserved"},
+		{name: "helper target", entry: config.ExtraFile{Source: "~/.terraformrc", Target: "/run/enclaude/x"}, wantErr: "reserved"},
+		{name: "container home target", entry: config.ExtraFile{Source: "~/.config/op", Target: "~"}, wantErr: "system directory"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mounts, err := collectExtraFiles([]config.ExtraFile{tt.entry}, home, config.WorkspaceTargetDefault)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("collectExtraFiles() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("collectExtraFiles() error = %v", err)
+			}
+			if tt.wantTarget == "" {
+				if len(mounts) != 0 {
+					t.Errorf("collectExtraFiles() = %+v, want no mounts", mounts)
+				}
+				return
+			}
+			if len(mounts) != 1 || mounts[0].Target != tt.wantTarget || !mounts[0].ReadOnly {
+				t.Errorf("collectExtraFiles() = %+v, want read-only mount at %s", mounts, tt.wantTarget)
+			}
+		})
+	}
+}
+
+func TestCollectExtraFilesWorkspaceTarget(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	home, _ = filepath.EvalSymlinks(home)
+	if err := os.WriteFile(filepath.Join(home, ".terraformrc"), []byte("secret"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	project := filepath.Join(t.TempDir(), "project")
+	os.MkdirAll(project, 0755)
+	outside := filepath.Join(project, "tool.cfg")
+	os.WriteFile(outside, []byte("x"), 0600)
+
+	// The workspace is mirrored at its host path, so /workspace is free and
+	// the project directory is not
+	entries := []config.ExtraFile{{Source: "~/.terraformrc", Target: "/workspace/.terraformrc"}}
+	if _, err := collectExtraFiles(entries, home, project); err != nil {
+		t.Errorf("collectExtraFiles() at /workspace error = %v, want it allowed", err)
+	}
+	for _, entry := range []config.ExtraFile{
+		{Source: "~/.terraformrc", Target: project + "/.terraformrc"},
+		{Source: outside},
+	} {
+		if _, err := collectExtraFiles([]config.ExtraFile{entry}, home, project); err == nil || !strings.Contains(err.Error(), "reserved") {
+			t.Errorf("collectExtraFiles(%+v) error = %v, want the workspace reserved", entry, err)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package credentials
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/jakenelson/enclaude/internal/config"
+	"github.com/jakenelson/enclaude/internal/security"
+)
+
+// defaultGitHubAPIURL is used when credentials.github_app.api_url is unset
+const defaultGitHubAPIURL = "https://api.github.com"
+
+// defaultGitHubAppPermissions are requested when
+// credentials.github_app.permissions is unset: enough to push commits
+var defaultGitHubAppPermissions = map[string]string{"contents": "write"}
+
+// GitHubAppToken exchanges GitHub App credentials for an installation token
+// scoped to only the repository checked out in workDir, so the sandbox never
+// receives a token that can touch other repositories. The workspace's origin
+// remote can be rewritten by anything that ran in it, so the repository must
+// also be listed in credentials.github_app.repositories.
+func GitHubAppToken(appCfg config.GitHubAppConfig, workDir string) (string, error) {
+	if appCfg.AppID == "" || appCfg.PrivateKey == "" {
+		return "", fmt.Errorf("credentials.github_app.app_id and private_key are required")
+	}
+
+	owner, repo, err := workspaceGitHubRepo(workDir)
+	if err != nil {
+		return "", err
+	}
+	if !pinnedRepo(appCfg.Repositories, owner, repo) {
+		return "", fmt.Errorf("origin remote is %s/%s, which is not in credentials.github_app.repositories; add it there to allow a token for it", owner, repo)
+	}
+
+	keyPath, err := security.ExpandPath(appCfg.PrivateKey)
+	if err != nil {
+		return "", fmt.Errorf("invalid private key path: %w", err)
+	}
+	keyPEM, err := os.ReadFile(keyPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read private key: %w", err)
+	}
+	jwt, err := appJWT(appCfg.AppID, keyPEM, time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	apiURL := strings.TrimRight(appCfg.APIURL, "/")
+	if apiURL == "" {
+		apiURL = defaultGitHubAPIURL
+	}
+
+	// Find the installation that covers this repository
+	var installation struct {
+		ID int64 `json:"id"`
+	}
+	if err := githubRequest(http.MethodGet, fmt.Sprintf("%s/repos/%s/%s/installation", apiURL, owner, repo), jwt, nil, &installation); err != nil {
+		return "", fmt.Errorf("app is not installed on %s/%s: %w", owner, repo, err)
+	}
+
+	// Request a token restricted to this repository and the permissions it needs
+	permissions := appCfg.Permissions
+	if len(permissions) == 0 {
+		permissions = defaultGitHubAppPermissions
+	}
+	body := map[string]interface{}{"repositories": []string{repo}, "permissions": permissions}
+	var token struct {
+		Token string `json:"token"`
+	}
+	if err := githubRequest(http.MethodPost, fmt.Sprintf("%s/app/installations/%d/access_tokens", apiURL, installation.ID), jwt, body, &token); err != nil {
+		return "", fmt.Errorf("failed to create installation token: %w", err)
+	}
+	if token.Token == "" {
+		return "", fmt.Errorf("GitHub returned an empty installation token")
+	}
+
+	return token.Token, nil
+}
+
+// appJWT creates the short-lived RS256 JWT used to authenticate as the app
+func appJWT(appID string, keyPEM []byte, now time.Time) (string, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return "", fmt.Errorf("private key is not PEM encoded")
+	}
+
+	var key *rsa.PrivateKey
+	if k, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		key = k
+	} else if k, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		rsaKey, ok := k.(*rsa.PrivateKey)
+		if !ok {
+			return "", fmt.Errorf("private key is not an RSA key")
+		}
+		key = rsaKey
+	} else {
+		return "", fmt.Errorf("failed to parse private key: %w", err)
+	}
+
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	// Backdate issued-at to allow for clock drift, as GitHub recommends
+	claims, _ := json.Marshal(map[string]interface{}{
+		"iat": now.Add(-60 * time.Second).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": appID,
+	})
+	signingInput := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(signingInput))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign JWT: %w", err)
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// githubRequest performs an authenticated GitHub API request and decodes the JSON response
+func githubRequest(method, url, jwt string, body, out interface{}) error {
+	var reqBody bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&reqBody).Encode(body); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest(method, url, &reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+
+	client := &http.Client{Timeout: 15 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("GitHub API returned %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// workspaceGitHubRepo returns the owner and name of the workspace's origin remote
+func workspaceGitHubRepo(workDir string) (string, string, error) {
+	out, err := exec.Command("git", "-C", workDir, "config", "--get", "remote.origin.url").Output()
+	if err != nil {
+		return "", "", fmt.Errorf("cannot scope GitHub App token: no origin remote in %s", workDir)
+	}
+	owner, repo, ok := parseGitHubRemote(strings.TrimSpace(string(out)))
+	if !ok {
+		return "", "", fmt.Errorf("cannot scope GitHub App token: unrecognized origin remote %q", strings.TrimSpace(string(out)))
+	}
+	return owner, repo, nil
+}
+
+// pinnedRepo reports whether owner/repo is one of the configured repositories.
+// GitHub names are case-insensitive.
+func pinnedRepo(pinned []string, owner, repo string) bool {
+	for _, p := range pinned {
+		if strings.EqualFold(strings.TrimSpace(p), owner+"/"+repo) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseGitHubRemote extracts owner and repository from a git remote URL in
+// SCP (git@host:owner/repo.git), SSH, or HTTPS form
+func parseGitHubRemote(remote string) (string, string, bool) {
+	path := remote
+	if i := strings.Index(remote, "://"); i >= 0 {
+		// scheme://[user@]host/owner/repo
+		rest := remote[i+3:]
+		slash := strings.Index(rest, "/")
+		if slash < 0 {
+			return "", "", false
+		}
+		path = rest[slash+1:]
+	} else if i := strings.Index(remote, ":"); i >= 0 {
+		path = remote[i+1:]
+	} else {
+		return "", "", false
+	}
+
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	parts := strings.Split(path, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
@@ -0,0 +1,150 @@
+package credentials
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jakenelson/enclaude/internal/config"
+)
+
+func TestParseGitHubRemote(t *testing.T) {
+	tests := []struct {
+		remote    string
+		wantOwner string
+		wantRepo  string
+		wantOK    bool
+	}{
+		{"git@github.com:octo/widgets.git", "octo", "widgets", true},
+		{"https://github.com/octo/widgets", "octo", "widgets", true},
+		{"https://github.com/octo/widgets.git/", "octo", "widgets", true},
+		{"ssh://git@github.com/octo/widgets.git", "octo", "widgets", true},
+		{"https://github.com/octo", "", "", false},
+		{"/local/path/repo", "", "", false},
+	}
+
+	for _, tt := range tests {
+		owner, repo, ok := parseGitHubRemote(tt.remote)
+		if owner != tt.wantOwner || repo != tt.wantRepo || ok != tt.wantOK {
+			t.Errorf("parseGitHubRemote(%q) = (%q, %q, %v), want (%q, %q, %v)",
+				tt.remote, owner, repo, ok, tt.wantOwner, tt.wantRepo, tt.wantOK)
+		}
+	}
+}
+
+func TestAppJWT(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	jwt, err := appJWT("12345", keyPEM, time.Now())
+	if err != nil {
+		t.Fatalf("appJWT() error = %v", err)
+	}
+
+	parts := strings.Split(jwt, ".")
+	if len(parts) != 3 {
+		t.Fatalf("appJWT() = %q, want three segments", jwt)
+	}
+
+	claims, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	if !strings.Contains(string(claims), `"iss":"12345"`) {
+		t.Errorf("appJWT() claims = %s, want iss 12345", claims)
+	}
+
+	sig, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], sig); err != nil {
+		t.Errorf("appJWT() signature does not verify: %v", err)
+	}
+
+	if _, err := appJWT("12345", []byte("not a key"), time.Now()); err == nil {
+		t.Error("appJWT() with invalid key should return an error")
+	}
+}
+
+func TestGitHubAppToken(t *testing.T) {
+	workDir := t.TempDir()
+	for _, args := range [][]string{{"init", "-q"}, {"remote", "add", "origin", "git@github.com:octo/widgets.git"}} {
+		if out, err := exec.Command("git", append([]string{"-C", workDir}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	keyPath := filepath.Join(t.TempDir(), "app.pem")
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	if err := os.WriteFile(keyPath, keyPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var requested map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/octo/widgets/installation":
+			fmt.Fprint(w, `{"id":42}`)
+		case "/app/installations/42/access_tokens":
+			json.NewDecoder(r.Body).Decode(&requested)
+			fmt.Fprint(w, `{"token":"ghs_scoped"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	appCfg := config.GitHubAppConfig{AppID: "12345", PrivateKey: keyPath, APIURL: server.URL}
+
+	// The origin remote alone is not trusted
+	if _, err := GitHubAppToken(appCfg, workDir); err == nil || !strings.Contains(err.Error(), "repositories") {
+		t.Errorf("GitHubAppToken() without a pinned repository error = %v, want it refused", err)
+	}
+	appCfg.Repositories = []string{"octo/other"}
+	if _, err := GitHubAppToken(appCfg, workDir); err == nil {
+		t.Error("GitHubAppToken() for a repository not pinned should return an error")
+	}
+	if requested != nil {
+		t.Fatal("GitHubAppToken() requested a token for a repository not pinned")
+	}
+
+	appCfg.Repositories = []string{"Octo/Widgets"}
+	token, err := GitHubAppToken(appCfg, workDir)
+	if err != nil {
+		t.Fatalf("GitHubAppToken() error = %v", err)
+	}
+	if token != "ghs_scoped" {
+		t.Errorf("GitHubAppToken() = %q, want ghs_scoped", token)
+	}
+	if got := fmt.Sprint(requested["repositories"]); got != "[widgets]" {
+		t.Errorf("token request repositories = %s, want [widgets]", got)
+	}
+	if got := fmt.Sprint(requested["permissions"]); got != "map[contents:write]" {
+		t.Errorf("token request permissions = %s, want the default map[contents:write]", got)
+	}
+
+	appCfg.Permissions = map[string]string{"contents": "read", "pull_requests": "write"}
+	if _, err := GitHubAppToken(appCfg, workDir); err != nil {
+		t.Fatalf("GitHubAppToken() error = %v", err)
+	}
+	if got := fmt.Sprint(requested["permissions"]); got != "map[contents:read pull_requests:write]" {
+		t.Errorf("token request permissions = %s, want the configured ones", got)
+	}
+}
@@ -0,0 +1,78 @@
+package credentials
+
+import (
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/jakenelson/enclaude/internal/config"
+	"github.com/jakenelson/enclaude/internal/container"
+	"github.com/jakenelson/enclaude/internal/security"
+)
+
+// gpgContainerHome is the GnuPG home directory in the container
+const gpgContainerHome = container.Home + "/.gnupg"
+
+// gpgPublicFiles are the GnuPG home files that hold no secret material: the
+// public keyrings, the trust database, and configuration. Private keys
+// (private-keys-v1.d, secring.gpg) are never mounted.
+var gpgPublicFiles = []string{"pubring.kbx", "pubring.gpg", "trustdb.gpg", "gpg.conf"}
+
+// collectGPGCredentials mounts the public keyring read-only and forwards the
+// host agent's extra socket in place of the container's standard agent
+// socket, so gpg in the container signs through the host agent without
+// seeing private keys
+func collectGPGCredentials(cfg *config.Config, home string) []container.Mount {
+	var mounts []container.Mount
+
+	gnupgHome := os.Getenv("GNUPGHOME")
+	if gnupgHome == "" {
+		gnupgHome = filepath.Join(home, ".gnupg")
+	}
+	for _, name := range gpgPublicFiles {
+		source := filepath.Join(gnupgHome, name)
+		if security.FileExists(source) {
+			mounts = append(mounts, container.Mount{
+				Source:   source,
+				Target:   path.Join(gpgContainerHome, name),
+				ReadOnly: true,
+				Kind:     container.MountFile,
+			})
+		}
+	}
+
+	// The extra socket refuses agent management and key export, unlike
+	// S.gpg-agent, which is never forwarded
+	if cfg.Credentials.GPG.AgentForwarding {
+		if sock := gpgExtraSocket(gnupgHome); sock != "" {
+			mounts = append(mounts, container.Mount{
+				Source: sock,
+				Target: gpgContainerHome + "/S.gpg-agent",
+			})
+		}
+	}
+
+	return mounts
+}
+
+// gpgExtraSocket returns the host agent's extra socket, asking gpgconf first
+// and then trying the standard locations, or "" if there is none
+func gpgExtraSocket(gnupgHome string) string {
+	var candidates []string
+	if out, err := exec.Command("gpgconf", "--list-dirs", "agent-extra-socket").Output(); err == nil {
+		candidates = append(candidates, strings.TrimSpace(string(out)))
+	}
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		candidates = append(candidates, filepath.Join(dir, "gnupg", "S.gpg-agent.extra"))
+	}
+	candidates = append(candidates, filepath.Join(gnupgHome, "S.gpg-agent.extra"))
+
+	for _, c := range candidates {
+		if info, err := os.Stat(c); err == nil && info.Mode()&os.ModeSocket != 0 {
+			return c
+		}
+	}
+	return ""
+}
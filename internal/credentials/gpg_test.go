@@ -0,0 +1,65 @@
+package credentials
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jakenelson/enclaude/internal/config"
+)
+
+func TestCollectGPGCredentials(t *testing.T) {
+	gnupgHome := t.TempDir()
+	t.Setenv("GNUPGHOME", gnupgHome)
+	t.Setenv("XDG_RUNTIME_DIR", "")
+	t.Setenv("PATH", "") // no gpgconf; fall back to GNUPGHOME
+
+	for _, name := range []string{"pubring.kbx", "trustdb.gpg", "secring.gpg"} {
+		os.WriteFile(filepath.Join(gnupgHome, name), []byte("x"), 0600)
+	}
+	os.MkdirAll(filepath.Join(gnupgHome, "private-keys-v1.d"), 0700)
+	os.WriteFile(filepath.Join(gnupgHome, "private-keys-v1.d", "ABCD.key"), []byte("secret"), 0600)
+	for _, name := range []string{"S.gpg-agent", "S.gpg-agent.extra"} {
+		l, err := net.Listen("unix", filepath.Join(gnupgHome, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer l.Close()
+	}
+
+	cfg := &config.Config{}
+	cfg.Credentials.GPG = config.GPGConfig{Enabled: true, AgentForwarding: true}
+	got := make(map[string]string)
+	for _, m := range collectGPGCredentials(cfg, t.TempDir()) {
+		got[m.Target] = m.Source
+		if strings.Contains(m.Source, "private-keys") || strings.HasSuffix(m.Source, "secring.gpg") {
+			t.Errorf("private material mounted: %s", m.Source)
+		}
+		if !m.ReadOnly && !strings.HasSuffix(m.Target, "S.gpg-agent") {
+			t.Errorf("%s mounted read-write", m.Source)
+		}
+	}
+
+	want := map[string]string{
+		"/home/enclaude/.gnupg/pubring.kbx": filepath.Join(gnupgHome, "pubring.kbx"),
+		"/home/enclaude/.gnupg/trustdb.gpg": filepath.Join(gnupgHome, "trustdb.gpg"),
+		"/home/enclaude/.gnupg/S.gpg-agent": filepath.Join(gnupgHome, "S.gpg-agent.extra"),
+	}
+	if len(got) != len(want) {
+		t.Errorf("mounts = %v, want %v", got, want)
+	}
+	for target, source := range want {
+		if got[target] != source {
+			t.Errorf("mount at %s = %q, want %q", target, got[target], source)
+		}
+	}
+
+	cfg.Credentials.GPG.AgentForwarding = false
+	for _, m := range collectGPGCredentials(cfg, t.TempDir()) {
+		if strings.HasSuffix(m.Target, "S.gpg-agent") {
+			t.Errorf("agent socket forwarded with agent_forwarding off")
+		}
+	}
+}
@@ -1,7 +1,9 @@
 package credentials
 
 import (
+	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 
 	"github.com/jakenelson/enclaude/internal/config"
@@ -25,8 +27,8 @@ func CollectClaudeAuth(cfg *config.Config) ([]container.Mount, map[string]string
 		auth = config.AuthAuto
 	}
 
-	// Handle API key
-	if auth == config.AuthAuto || auth == config.AuthAPIKey {
+	// Handle API key; Bedrock and Vertex authenticate with cloud credentials instead
+	if (auth == config.AuthAuto || auth == config.AuthAPIKey) && !UsesCloudProvider(cfg) {
 		if key := os.Getenv("ANTHROPIC_API_KEY"); key != "" {
 			env["ANTHROPIC_API_KEY"] = key
 		}
@@ -38,15 +40,25 @@ func CollectClaudeAuth(cfg *config.Config) ([]container.Mount, map[string]string
 		if sessionDir == "" {
 			sessionDir = config.SessionReadOnly
 		}
-		if sessionDir != config.SessionNone {
+		if sessionDir == config.SessionVolume {
+			// Claude keeps ~/.claude.json in CLAUDE_CONFIG_DIR too, so the
+			// whole login lives on the volume
+			mounts = append(mounts, container.Mount{
+				Source: container.ClaudeHomeVolume,
+				Target: container.ClaudeHomePath,
+				Volume: true,
+			})
+			env["ENCLAUDE_CLAUDE_HOME"] = container.ClaudeHomePath
+			env["CLAUDE_CONFIG_DIR"] = container.Home + "/.claude"
+		} else if sessionDir != config.SessionNone {
 			claudePath := filepath.Join(home, ".claude")
 			if security.DirExists(claudePath) {
-				// Mount to /tmp/.claude because container HOME is set to /tmp
-				// This allows Claude to find the session directory while running as non-root
+				// Mount into the container HOME, where Claude looks for it
 				mounts = append(mounts, container.Mount{
 					Source:   claudePath,
-					Target:   "/tmp/.claude",
+					Target:   container.Home + "/.claude",
 					ReadOnly: sessionDir == config.SessionReadOnly,
+					Kind:     container.MountDir,
 				})
 			}
 		}
@@ -55,9 +67,31 @@ func CollectClaudeAuth(cfg *config.Config) ([]container.Mount, map[string]string
 	return mounts, env
 }
 
-// CollectExternalCredentials gathers external service credentials (GitHub, GCloud, SSH).
+// AuthConflict reports whether auth=auto would give Claude both
+// ANTHROPIC_API_KEY and a session directory. Claude then uses the key, which
+// bills API usage even when the login has a subscription.
+func AuthConflict(cfg *config.Config) bool {
+	if cfg.Claude.Auth != "" && cfg.Claude.Auth != config.AuthAuto {
+		return false
+	}
+	if UsesCloudProvider(cfg) || os.Getenv("ANTHROPIC_API_KEY") == "" || cfg.Claude.SessionDir == config.SessionNone {
+		return false
+	}
+	if cfg.Claude.SessionDir == config.SessionVolume {
+		return true
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return false
+	}
+	return security.DirExists(filepath.Join(home, ".claude"))
+}
+
+// CollectExternalCredentials gathers external service credentials (GitHub, GCloud, Bitbucket, Azure DevOps,
+// extra credential files, SSH, GPG).
+// workDir is the host workspace, used to scope GitHub App tokens to its repository.
 // This does not include Claude authentication - use CollectClaudeAuth for that.
-func CollectExternalCredentials(cfg *config.Config) ([]container.Mount, map[string]string, error) {
+func CollectExternalCredentials(cfg *config.Config, workDir string) ([]container.Mount, map[string]string, error) {
 	var mounts []container.Mount
 	env := make(map[string]string)
 
@@ -67,7 +101,14 @@ func CollectExternalCredentials(cfg *config.Config) ([]container.Mount, map[stri
 	}
 
 	// GitHub credentials
-	if shouldEnable(cfg.Credentials.GitHub, "GH_TOKEN", "GITHUB_TOKEN") {
+	if cfg.Credentials.GitHub == config.CredentialApp {
+		// Never fall back to broader host tokens when app scoping was requested
+		token, err := GitHubAppToken(cfg.Credentials.GitHubApp, workDir)
+		if err != nil {
+			return nil, nil, fmt.Errorf("github app: %w", err)
+		}
+		env["GH_TOKEN"] = token
+	} else if shouldEnable(cfg.Credentials.GitHub, "GH_TOKEN", "GITHUB_TOKEN") {
 		// Try environment variable first
 		if token := os.Getenv("GH_TOKEN"); token != "" {
 			env["GH_TOKEN"] = token
@@ -79,8 +120,9 @@ func CollectExternalCredentials(cfg *config.Config) ([]container.Mount, map[stri
 			if security.FileExists(ghConfigPath) {
 				mounts = append(mounts, container.Mount{
 					Source:   ghConfigPath,
-					Target:   "/root/.config/gh/hosts.yml",
+					Target:   container.Home + "/.config/gh/hosts.yml",
 					ReadOnly: true,
+					Kind:     container.MountFile,
 				})
 			}
 		}
@@ -92,24 +134,68 @@ func CollectExternalCredentials(cfg *config.Config) ([]container.Mount, map[stri
 		if security.FileExists(adcPath) {
 			mounts = append(mounts, container.Mount{
 				Source:   adcPath,
-				Target:   "/root/.config/gcloud/application_default_credentials.json",
+				Target:   container.Home + "/.config/gcloud/application_default_credentials.json",
 				ReadOnly: true,
+				Kind:     container.MountFile,
 			})
 			// Set the env var to point to the mounted location
-			env["GOOGLE_APPLICATION_CREDENTIALS"] = "/root/.config/gcloud/application_default_credentials.json"
+			env["GOOGLE_APPLICATION_CREDENTIALS"] = container.Home + "/.config/gcloud/application_default_credentials.json"
 		}
 
 		// Also check for explicit GOOGLE_APPLICATION_CREDENTIALS path
 		if customPath := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"); customPath != "" && security.FileExists(customPath) {
 			mounts = append(mounts, container.Mount{
 				Source:   customPath,
-				Target:   "/root/.config/gcloud/application_default_credentials.json",
+				Target:   container.Home + "/.config/gcloud/application_default_credentials.json",
+				ReadOnly: true,
+				Kind:     container.MountFile,
+			})
+			env["GOOGLE_APPLICATION_CREDENTIALS"] = container.Home + "/.config/gcloud/application_default_credentials.json"
+		}
+	}
+
+	// Bitbucket credentials (access token or username + app password)
+	if shouldEnable(cfg.Credentials.Bitbucket, "BITBUCKET_TOKEN", "BITBUCKET_APP_PASSWORD") {
+		for _, key := range []string{"BITBUCKET_TOKEN", "BITBUCKET_USERNAME", "BITBUCKET_APP_PASSWORD"} {
+			if val := os.Getenv(key); val != "" {
+				env[key] = val
+			}
+		}
+	}
+
+	// Azure DevOps credentials
+	if shouldEnable(cfg.Credentials.AzDO, "AZURE_DEVOPS_EXT_PAT", "SYSTEM_ACCESSTOKEN") {
+		// Try environment variable first; SYSTEM_ACCESSTOKEN is set in Azure Pipelines
+		if token := os.Getenv("AZURE_DEVOPS_EXT_PAT"); token != "" {
+			env["AZURE_DEVOPS_EXT_PAT"] = token
+		} else if token := os.Getenv("SYSTEM_ACCESSTOKEN"); token != "" {
+			env["AZURE_DEVOPS_EXT_PAT"] = token
+		}
+
+		// Mount az devops CLI defaults (organization, project); the PAT itself
+		// lives in the host keyring and is not exposed
+		azdoConfigPath := filepath.Join(home, ".azure", "azuredevops", "config")
+		if security.FileExists(azdoConfigPath) {
+			mounts = append(mounts, container.Mount{
+				Source:   azdoConfigPath,
+				Target:   container.Home + "/.azure/azuredevops/config",
 				ReadOnly: true,
+				Kind:     container.MountFile,
 			})
-			env["GOOGLE_APPLICATION_CREDENTIALS"] = "/root/.config/gcloud/application_default_credentials.json"
 		}
 	}
 
+	// Whitelisted tool credential files, kept out of the workspace
+	workspaceTarget, err := config.ResolveWorkspaceTarget(cfg.Mounts.WorkspaceTarget, workDir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid mounts.workspace_target: %w", err)
+	}
+	extraMounts, err := collectExtraFiles(cfg.Credentials.ExtraFiles, home, workspaceTarget)
+	if err != nil {
+		return nil, nil, err
+	}
+	mounts = append(mounts, extraMounts...)
+
 	// SSH credentials (explicit opt-in)
 	if cfg.Credentials.SSH.Enabled {
 		sshMounts, sshEnv := collectSSHCredentials(cfg, home)
@@ -119,6 +205,11 @@ func CollectExternalCredentials(cfg *config.Config) ([]container.Mount, map[stri
 		}
 	}
 
+	// GPG signing through the host agent (explicit opt-in)
+	if cfg.Credentials.GPG.Enabled {
+		mounts = append(mounts, collectGPGCredentials(cfg, home)...)
+	}
+
 	return mounts, env, nil
 }
 
@@ -138,8 +229,9 @@ func collectSSHCredentials(cfg *config.Config, home string) ([]container.Mount,
 			keyName := filepath.Base(expanded)
 			mounts = append(mounts, container.Mount{
 				Source:   expanded,
-				Target:   filepath.Join("/root/.ssh", keyName),
+				Target:   path.Join(container.Home, ".ssh", keyName),
 				ReadOnly: true,
+				Kind:     container.MountFile,
 			})
 		}
 	}
@@ -150,8 +242,9 @@ func collectSSHCredentials(cfg *config.Config, home string) ([]container.Mount,
 		if security.FileExists(knownHostsPath) {
 			mounts = append(mounts, container.Mount{
 				Source:   knownHostsPath,
-				Target:   "/root/.ssh/known_hosts",
+				Target:   container.Home + "/.ssh/known_hosts",
 				ReadOnly: true,
+				Kind:     container.MountFile,
 			})
 		}
 	}
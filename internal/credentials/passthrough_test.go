@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/jakenelson/enclaude/internal/config"
+	"github.com/jakenelson/enclaude/internal/container"
 )
 
 func TestCollectClaudeAuth_SessionDirectory(t *testing.T) {
@@ -36,21 +37,21 @@ func TestCollectClaudeAuth_SessionDirectory(t *testing.T) {
 		{
 			name:           "default (empty) should be readonly",
 			sessionDir:     "",
-			wantTarget:     "/tmp/.claude",
+			wantTarget:     container.Home + "/.claude",
 			wantReadOnly:   true,
 			wantMountCount: 1,
 		},
 		{
 			name:           "explicit readonly",
 			sessionDir:     config.SessionReadOnly,
-			wantTarget:     "/tmp/.claude",
+			wantTarget:     container.Home + "/.claude",
 			wantReadOnly:   true,
 			wantMountCount: 1,
 		},
 		{
 			name:           "explicit readwrite",
 			sessionDir:     config.SessionReadWrite,
-			wantTarget:     "/tmp/.claude",
+			wantTarget:     container.Home + "/.claude",
 			wantReadOnly:   false,
 			wantMountCount: 1,
 		},
@@ -105,6 +106,27 @@ func TestCollectClaudeAuth_SessionDirectory(t *testing.T) {
 	}
 }
 
+func TestCollectClaudeAuth_SessionVolume(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cfg := &config.Config{
+		Claude: config.ClaudeConfig{Auth: config.AuthAuto, SessionDir: config.SessionVolume},
+	}
+
+	mounts, env := CollectClaudeAuth(cfg)
+	if len(mounts) != 1 {
+		t.Fatalf("CollectClaudeAuth() mount count = %d, want 1", len(mounts))
+	}
+	if m := mounts[0]; !m.Volume || m.Source != container.ClaudeHomeVolume || m.Target != container.ClaudeHomePath || m.ReadOnly {
+		t.Errorf("CollectClaudeAuth() mount = %+v, want the read-write login volume", m)
+	}
+	if env["ENCLAUDE_CLAUDE_HOME"] != container.ClaudeHomePath {
+		t.Errorf("ENCLAUDE_CLAUDE_HOME = %q, want %q", env["ENCLAUDE_CLAUDE_HOME"], container.ClaudeHomePath)
+	}
+	if env["CLAUDE_CONFIG_DIR"] != container.Home+"/.claude" {
+		t.Errorf("CLAUDE_CONFIG_DIR = %q, want %q", env["CLAUDE_CONFIG_DIR"], container.Home+"/.claude")
+	}
+}
+
 func TestCollectClaudeAuth_APIKey(t *testing.T) {
 	// Save and restore original API key
 	originalAPIKey := os.Getenv("ANTHROPIC_API_KEY")
@@ -172,3 +194,103 @@ func TestCollectClaudeAuth_APIKey(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthConflict(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := os.Mkdir(filepath.Join(home, ".claude"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		auth       string
+		sessionDir string
+		provider   string
+		apiKey     string
+		want       bool
+	}{
+		{"auto with both", config.AuthAuto, config.SessionReadOnly, "", "test-key", true},
+		{"unset auth with both", "", config.SessionReadOnly, "", "test-key", true},
+		{"no API key", config.AuthAuto, config.SessionReadOnly, "", "", false},
+		{"session dir disabled", config.AuthAuto, config.SessionNone, "", "test-key", false},
+		{"session volume", config.AuthAuto, config.SessionVolume, "", "test-key", true},
+		{"explicit session", config.AuthSession, config.SessionReadOnly, "", "test-key", false},
+		{"explicit api-key", config.AuthAPIKey, config.SessionReadOnly, "", "test-key", false},
+		{"cloud provider", config.AuthAuto, config.SessionReadOnly, config.ProviderBedrock, "test-key", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ANTHROPIC_API_KEY", tt.apiKey)
+			cfg := &config.Config{
+				Claude: config.ClaudeConfig{Auth: tt.auth, SessionDir: tt.sessionDir, Provider: tt.provider},
+			}
+			if got := AuthConflict(cfg); got != tt.want {
+				t.Errorf("AuthConflict() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCollectExternalCredentials_BitbucketAndAzDO(t *testing.T) {
+	t.Setenv("BITBUCKET_USERNAME", "octo")
+	t.Setenv("BITBUCKET_APP_PASSWORD", "bb-secret")
+	t.Setenv("BITBUCKET_TOKEN", "")
+	t.Setenv("AZURE_DEVOPS_EXT_PAT", "")
+	t.Setenv("SYSTEM_ACCESSTOKEN", "pipeline-token")
+
+	tests := []struct {
+		name      string
+		bitbucket string
+		azdo      string
+		wantEnv   map[string]string
+		wantUnset []string
+	}{
+		{
+			name:      "auto passes through tokens",
+			bitbucket: config.CredentialAuto,
+			azdo:      config.CredentialAuto,
+			wantEnv: map[string]string{
+				"BITBUCKET_USERNAME":     "octo",
+				"BITBUCKET_APP_PASSWORD": "bb-secret",
+				"AZURE_DEVOPS_EXT_PAT":   "pipeline-token",
+			},
+			wantUnset: []string{"BITBUCKET_TOKEN"},
+		},
+		{
+			name:      "disabled passes nothing",
+			bitbucket: config.CredentialDisabled,
+			azdo:      config.CredentialDisabled,
+			wantUnset: []string{"BITBUCKET_USERNAME", "BITBUCKET_APP_PASSWORD", "AZURE_DEVOPS_EXT_PAT"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				Credentials: config.CredentialsConfig{
+					GitHub:    config.CredentialDisabled,
+					GCloud:    config.CredentialDisabled,
+					Bitbucket: tt.bitbucket,
+					AzDO:      tt.azdo,
+				},
+			}
+
+			_, env, err := CollectExternalCredentials(cfg, t.TempDir())
+			if err != nil {
+				t.Fatalf("CollectExternalCredentials() error = %v", err)
+			}
+			for k, want := range tt.wantEnv {
+				if env[k] != want {
+					t.Errorf("CollectExternalCredentials() env[%s] = %q, want %q", k, env[k], want)
+				}
+			}
+			for _, k := range tt.wantUnset {
+				if _, ok := env[k]; ok {
+					t.Errorf("CollectExternalCredentials() should not set %s", k)
+				}
+			}
+		})
+	}
+}
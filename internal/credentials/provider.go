@@ -0,0 +1,204 @@
+package credentials
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/jakenelson/enclaude/internal/config"
+	"github.com/jakenelson/enclaude/internal/container"
+	"github.com/jakenelson/enclaude/internal/security"
+)
+
+// defaultVertexRegion is used when neither claude.vertex.region nor
+// CLOUD_ML_REGION is set
+const defaultVertexRegion = "us-east5"
+
+// vertexADCTarget is where application default credentials are mounted for
+// Vertex AI; it is under the container HOME, so gcloud finds them here too
+const vertexADCTarget = container.Home + "/.config/gcloud/application_default_credentials.json"
+
+// awsCredentials is the credential_process output of `aws configure export-credentials`
+type awsCredentials struct {
+	AccessKeyID     string `json:"AccessKeyId"`
+	SecretAccessKey string `json:"SecretAccessKey"`
+	SessionToken    string `json:"SessionToken"`
+}
+
+// exportAWSCredentials resolves the host's AWS credentials for profile
+// through the AWS CLI, which handles SSO, assumed roles, and credential files
+var exportAWSCredentials = func(profile string) (awsCredentials, error) {
+	args := []string{"configure", "export-credentials", "--format", "process"}
+	if profile != "" {
+		args = append(args, "--profile", profile)
+	}
+	out, err := exec.Command("aws", args...).Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return awsCredentials{}, fmt.Errorf("aws configure export-credentials failed: %s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return awsCredentials{}, fmt.Errorf("failed to run aws CLI: %w", err)
+	}
+	var creds awsCredentials
+	if err := json.Unmarshal(out, &creds); err != nil {
+		return awsCredentials{}, fmt.Errorf("failed to parse AWS credentials: %w", err)
+	}
+	return creds, nil
+}
+
+// UsesCloudProvider reports whether Claude is configured to run through
+// Bedrock or Vertex AI rather than the Anthropic API
+func UsesCloudProvider(cfg *config.Config) bool {
+	return cfg.Claude.Provider == config.ProviderBedrock || cfg.Claude.Provider == config.ProviderVertex
+}
+
+// CollectProviderAuth returns the mounts and environment variables that
+// point Claude Code at the configured model provider. The Anthropic provider
+// needs nothing beyond CollectClaudeAuth.
+func CollectProviderAuth(cfg *config.Config) ([]container.Mount, map[string]string, error) {
+	switch cfg.Claude.Provider {
+	case "", config.ProviderAnthropic:
+		return nil, map[string]string{}, nil
+	case config.ProviderBedrock:
+		env, err := collectBedrockAuth(cfg.Claude.Bedrock)
+		return nil, env, err
+	case config.ProviderVertex:
+		return collectVertexAuth(cfg.Claude.Vertex)
+	default:
+		return nil, nil, fmt.Errorf("invalid claude.provider %q (allowed: %s, %s, %s)",
+			cfg.Claude.Provider, config.ProviderAnthropic, config.ProviderBedrock, config.ProviderVertex)
+	}
+}
+
+// ProviderEndpoint returns the API endpoint Claude Code will call for the
+// configured provider, for connectivity checks
+func ProviderEndpoint(cfg *config.Config) string {
+	switch cfg.Claude.Provider {
+	case config.ProviderBedrock:
+		if region := bedrockRegion(cfg.Claude.Bedrock); region != "" {
+			return fmt.Sprintf("https://bedrock-runtime.%s.amazonaws.com/", region)
+		}
+	case config.ProviderVertex:
+		region := vertexRegion(cfg.Claude.Vertex)
+		if region == "global" {
+			return "https://aiplatform.googleapis.com/"
+		}
+		return fmt.Sprintf("https://%s-aiplatform.googleapis.com/", region)
+	}
+	return container.PreflightURL
+}
+
+func collectBedrockAuth(bedrock config.BedrockConfig) (map[string]string, error) {
+	region := bedrockRegion(bedrock)
+	if region == "" {
+		return nil, fmt.Errorf("bedrock: set claude.bedrock.region or AWS_REGION")
+	}
+	env := map[string]string{
+		"CLAUDE_CODE_USE_BEDROCK": "1",
+		"AWS_REGION":              region,
+	}
+
+	// Bedrock API keys need no further credentials
+	if token := os.Getenv("AWS_BEARER_TOKEN_BEDROCK"); token != "" {
+		env["AWS_BEARER_TOKEN_BEDROCK"] = token
+		return env, nil
+	}
+
+	// Static keys from the environment take precedence over profiles
+	if id, secret := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"); id != "" && secret != "" {
+		env["AWS_ACCESS_KEY_ID"] = id
+		env["AWS_SECRET_ACCESS_KEY"] = secret
+		if token := os.Getenv("AWS_SESSION_TOKEN"); token != "" {
+			env["AWS_SESSION_TOKEN"] = token
+		}
+		return env, nil
+	}
+
+	// Otherwise resolve the profile on the host so ~/.aws is never mounted
+	profile := bedrock.Profile
+	if profile == "" {
+		profile = os.Getenv("AWS_PROFILE")
+	}
+	creds, err := exportAWSCredentials(profile)
+	if err != nil {
+		return nil, fmt.Errorf("bedrock: %w", err)
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return nil, fmt.Errorf("bedrock: no AWS credentials found for profile %q", profile)
+	}
+	env["AWS_ACCESS_KEY_ID"] = creds.AccessKeyID
+	env["AWS_SECRET_ACCESS_KEY"] = creds.SecretAccessKey
+	if creds.SessionToken != "" {
+		env["AWS_SESSION_TOKEN"] = creds.SessionToken
+	}
+	return env, nil
+}
+
+func collectVertexAuth(vertex config.VertexConfig) ([]container.Mount, map[string]string, error) {
+	projectID := vertex.ProjectID
+	for _, key := range []string{"ANTHROPIC_VERTEX_PROJECT_ID", "GOOGLE_CLOUD_PROJECT"} {
+		if projectID == "" {
+			projectID = os.Getenv(key)
+		}
+	}
+	if projectID == "" {
+		return nil, nil, fmt.Errorf("vertex: set claude.vertex.project_id or ANTHROPIC_VERTEX_PROJECT_ID")
+	}
+
+	adcPath, err := applicationDefaultCredentials()
+	if err != nil {
+		return nil, nil, fmt.Errorf("vertex: %w", err)
+	}
+
+	mounts := []container.Mount{{Source: adcPath, Target: vertexADCTarget, ReadOnly: true, Kind: container.MountFile}}
+	env := map[string]string{
+		"CLAUDE_CODE_USE_VERTEX":         "1",
+		"CLOUD_ML_REGION":                vertexRegion(vertex),
+		"ANTHROPIC_VERTEX_PROJECT_ID":    projectID,
+		"GOOGLE_APPLICATION_CREDENTIALS": vertexADCTarget,
+	}
+	return mounts, env, nil
+}
+
+// applicationDefaultCredentials locates the host's Google application
+// default credentials file
+func applicationDefaultCredentials() (string, error) {
+	if path := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"); path != "" {
+		if !security.FileExists(path) {
+			return "", fmt.Errorf("GOOGLE_APPLICATION_CREDENTIALS file not found: %s", path)
+		}
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(home, ".config", "gcloud", "application_default_credentials.json")
+	if !security.FileExists(path) {
+		return "", fmt.Errorf("no application default credentials found; run 'gcloud auth application-default login'")
+	}
+	return path, nil
+}
+
+func bedrockRegion(bedrock config.BedrockConfig) string {
+	if bedrock.Region != "" {
+		return bedrock.Region
+	}
+	if region := os.Getenv("AWS_REGION"); region != "" {
+		return region
+	}
+	return os.Getenv("AWS_DEFAULT_REGION")
+}
+
+func vertexRegion(vertex config.VertexConfig) string {
+	if vertex.Region != "" {
+		return vertex.Region
+	}
+	if region := os.Getenv("CLOUD_ML_REGION"); region != "" {
+		return region
+	}
+	return defaultVertexRegion
+}
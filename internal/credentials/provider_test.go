@@ -0,0 +1,209 @@
+package credentials
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jakenelson/enclaude/internal/config"
+)
+
+func TestCollectProviderAuth_Bedrock(t *testing.T) {
+	for _, key := range []string{"AWS_REGION", "AWS_DEFAULT_REGION", "AWS_PROFILE", "AWS_BEARER_TOKEN_BEDROCK", "AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN"} {
+		t.Setenv(key, "")
+	}
+
+	origExport := exportAWSCredentials
+	defer func() { exportAWSCredentials = origExport }()
+	var exportedProfile string
+	exportAWSCredentials = func(profile string) (awsCredentials, error) {
+		exportedProfile = profile
+		if profile == "broken" {
+			return awsCredentials{}, errors.New("profile not found")
+		}
+		return awsCredentials{AccessKeyID: "AKIAEXPORTED", SecretAccessKey: "exported", SessionToken: "session"}, nil
+	}
+
+	tests := []struct {
+		name        string
+		bedrock     config.BedrockConfig
+		env         map[string]string
+		wantEnv     map[string]string
+		wantProfile string
+		wantErr     bool
+	}{
+		{
+			name:    "missing region",
+			wantErr: true,
+		},
+		{
+			name:    "bearer token",
+			bedrock: config.BedrockConfig{Region: "us-west-2"},
+			env:     map[string]string{"AWS_BEARER_TOKEN_BEDROCK": "bedrock-key"},
+			wantEnv: map[string]string{"CLAUDE_CODE_USE_BEDROCK": "1", "AWS_REGION": "us-west-2", "AWS_BEARER_TOKEN_BEDROCK": "bedrock-key"},
+		},
+		{
+			name: "static keys from environment",
+			env:  map[string]string{"AWS_REGION": "eu-west-1", "AWS_ACCESS_KEY_ID": "AKIA", "AWS_SECRET_ACCESS_KEY": "secret"},
+			wantEnv: map[string]string{
+				"CLAUDE_CODE_USE_BEDROCK": "1", "AWS_REGION": "eu-west-1",
+				"AWS_ACCESS_KEY_ID": "AKIA", "AWS_SECRET_ACCESS_KEY": "secret",
+			},
+		},
+		{
+			name:    "profile exported from config",
+			bedrock: config.BedrockConfig{Region: "us-east-1", Profile: "dev"},
+			env:     map[string]string{"AWS_PROFILE": "other"},
+			wantEnv: map[string]string{
+				"CLAUDE_CODE_USE_BEDROCK": "1", "AWS_REGION": "us-east-1",
+				"AWS_ACCESS_KEY_ID": "AKIAEXPORTED", "AWS_SECRET_ACCESS_KEY": "exported", "AWS_SESSION_TOKEN": "session",
+			},
+			wantProfile: "dev",
+		},
+		{
+			name:        "profile from environment",
+			env:         map[string]string{"AWS_DEFAULT_REGION": "ap-south-1", "AWS_PROFILE": "sso"},
+			wantEnv:     map[string]string{"CLAUDE_CODE_USE_BEDROCK": "1", "AWS_REGION": "ap-south-1", "AWS_ACCESS_KEY_ID": "AKIAEXPORTED", "AWS_SECRET_ACCESS_KEY": "exported", "AWS_SESSION_TOKEN": "session"},
+			wantProfile: "sso",
+		},
+		{
+			name:    "export failure",
+			bedrock: config.BedrockConfig{Region: "us-east-1", Profile: "broken"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			exportedProfile = ""
+
+			cfg := &config.Config{Claude: config.ClaudeConfig{Provider: config.ProviderBedrock, Bedrock: tt.bedrock}}
+			mounts, env, err := CollectProviderAuth(cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CollectProviderAuth() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(mounts) != 0 {
+				t.Errorf("CollectProviderAuth() mounts = %v, want none", mounts)
+			}
+			if len(env) != len(tt.wantEnv) {
+				t.Errorf("CollectProviderAuth() env = %v, want %v", env, tt.wantEnv)
+			}
+			for k, want := range tt.wantEnv {
+				if env[k] != want {
+					t.Errorf("CollectProviderAuth() env[%s] = %q, want %q", k, env[k], want)
+				}
+			}
+			if exportedProfile != tt.wantProfile {
+				t.Errorf("exported profile = %q, want %q", exportedProfile, tt.wantProfile)
+			}
+		})
+	}
+}
+
+func TestCollectProviderAuth_Vertex(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	for _, key := range []string{"ANTHROPIC_VERTEX_PROJECT_ID", "GOOGLE_CLOUD_PROJECT", "CLOUD_ML_REGION", "GOOGLE_APPLICATION_CREDENTIALS"} {
+		t.Setenv(key, "")
+	}
+
+	cfg := &config.Config{Claude: config.ClaudeConfig{
+		Provider: config.ProviderVertex,
+		Vertex:   config.VertexConfig{ProjectID: "my-project"},
+	}}
+
+	// No application default credentials yet
+	if _, _, err := CollectProviderAuth(cfg); err == nil {
+		t.Fatal("CollectProviderAuth() expected error without ADC")
+	}
+
+	adcPath := filepath.Join(home, ".config", "gcloud", "application_default_credentials.json")
+	if err := os.MkdirAll(filepath.Dir(adcPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(adcPath, []byte("{}"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	mounts, env, err := CollectProviderAuth(cfg)
+	if err != nil {
+		t.Fatalf("CollectProviderAuth() error = %v", err)
+	}
+	if len(mounts) != 1 || mounts[0].Source != adcPath || mounts[0].Target != vertexADCTarget || !mounts[0].ReadOnly {
+		t.Errorf("CollectProviderAuth() mounts = %+v, want read-only ADC mount", mounts)
+	}
+	wantEnv := map[string]string{
+		"CLAUDE_CODE_USE_VERTEX":         "1",
+		"CLOUD_ML_REGION":                defaultVertexRegion,
+		"ANTHROPIC_VERTEX_PROJECT_ID":    "my-project",
+		"GOOGLE_APPLICATION_CREDENTIALS": vertexADCTarget,
+	}
+	for k, want := range wantEnv {
+		if env[k] != want {
+			t.Errorf("CollectProviderAuth() env[%s] = %q, want %q", k, env[k], want)
+		}
+	}
+
+	// Project ID is required
+	t.Setenv("CLOUD_ML_REGION", "europe-west1")
+	cfg.Claude.Vertex.ProjectID = ""
+	if _, _, err := CollectProviderAuth(cfg); err == nil {
+		t.Error("CollectProviderAuth() expected error without project ID")
+	}
+	t.Setenv("GOOGLE_CLOUD_PROJECT", "env-project")
+	_, env, err = CollectProviderAuth(cfg)
+	if err != nil {
+		t.Fatalf("CollectProviderAuth() error = %v", err)
+	}
+	if env["ANTHROPIC_VERTEX_PROJECT_ID"] != "env-project" || env["CLOUD_ML_REGION"] != "europe-west1" {
+		t.Errorf("CollectProviderAuth() env = %v, want project and region from environment", env)
+	}
+}
+
+func TestCollectProviderAuth_Anthropic(t *testing.T) {
+	for _, provider := range []string{"", config.ProviderAnthropic} {
+		cfg := &config.Config{Claude: config.ClaudeConfig{Provider: provider}}
+		mounts, env, err := CollectProviderAuth(cfg)
+		if err != nil || len(mounts) != 0 || len(env) != 0 {
+			t.Errorf("CollectProviderAuth(%q) = %v, %v, %v; want nothing", provider, mounts, env, err)
+		}
+	}
+
+	cfg := &config.Config{Claude: config.ClaudeConfig{Provider: "azure"}}
+	if _, _, err := CollectProviderAuth(cfg); err == nil {
+		t.Error("CollectProviderAuth() expected error for unknown provider")
+	}
+}
+
+func TestProviderEndpoint(t *testing.T) {
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+	t.Setenv("CLOUD_ML_REGION", "")
+
+	tests := []struct {
+		name   string
+		claude config.ClaudeConfig
+		want   string
+	}{
+		{"anthropic", config.ClaudeConfig{}, "https://api.anthropic.com/"},
+		{"bedrock", config.ClaudeConfig{Provider: config.ProviderBedrock, Bedrock: config.BedrockConfig{Region: "us-west-2"}}, "https://bedrock-runtime.us-west-2.amazonaws.com/"},
+		{"bedrock without region", config.ClaudeConfig{Provider: config.ProviderBedrock}, "https://api.anthropic.com/"},
+		{"vertex default region", config.ClaudeConfig{Provider: config.ProviderVertex}, "https://us-east5-aiplatform.googleapis.com/"},
+		{"vertex global", config.ClaudeConfig{Provider: config.ProviderVertex, Vertex: config.VertexConfig{Region: "global"}}, "https://aiplatform.googleapis.com/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ProviderEndpoint(&config.Config{Claude: tt.claude}); got != tt.want {
+				t.Errorf("ProviderEndpoint() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package credentials
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jakenelson/enclaude/internal/container"
+)
+
+// TimeBoxDir is where time-boxed credentials are staged inside the container
+const TimeBoxDir = "/run/enclaude/credentials"
+
+// TimeBoxed holds external credentials that are revoked after a TTL.
+// File credentials are copied into a host staging directory instead of being
+// bind-mounted directly, so the in-container helper can zero them on expiry.
+// Environment credentials are exposed through a BASH_ENV script rather than
+// the container environment, so they can be unset for new tool invocations.
+// Directories and sockets cannot be zeroed, so they are mounted as-is and
+// listed in Unrevoked.
+type TimeBoxed struct {
+	Mounts    []container.Mount
+	Env       map[string]string
+	Unrevoked []string // Sources that stay usable for the whole session
+	stageDir  string
+}
+
+// TimeBox stages external credential mounts and environment variables so they
+// expire after ttl. Call Cleanup when the session ends to remove staged copies.
+func TimeBox(mounts []container.Mount, env map[string]string, ttl time.Duration) (*TimeBoxed, error) {
+	if ttl < time.Second {
+		return nil, fmt.Errorf("credential ttl must be at least 1s, got %s", ttl)
+	}
+
+	stageDir, err := os.MkdirTemp("", "enclaude-creds-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create credential staging directory: %w", err)
+	}
+
+	tb := &TimeBoxed{
+		Env:      make(map[string]string),
+		stageDir: stageDir,
+	}
+
+	// Stage file credentials; directories and sockets are passed through as-is
+	for i, m := range mounts {
+		info, err := os.Stat(m.Source)
+		if err != nil || !info.Mode().IsRegular() {
+			tb.Mounts = append(tb.Mounts, m)
+			tb.Unrevoked = append(tb.Unrevoked, m.Source)
+			continue
+		}
+
+		content, err := os.ReadFile(m.Source)
+		if err != nil {
+			tb.Cleanup()
+			return nil, fmt.Errorf("failed to stage credential %q: %w", m.Source, err)
+		}
+		name := fmt.Sprintf("%d-%s", i, filepath.Base(m.Source))
+		if err := os.WriteFile(filepath.Join(stageDir, name), content, 0600); err != nil {
+			tb.Cleanup()
+			return nil, fmt.Errorf("failed to stage credential %q: %w", m.Source, err)
+		}
+		tb.Mounts = append(tb.Mounts, container.Mount{
+			Source:   filepath.Join(stageDir, name),
+			Target:   m.Target,
+			ReadOnly: true,
+			Kind:     container.MountFile,
+		})
+	}
+
+	// Write environment credentials to a script sourced by non-interactive bash
+	envScript := filepath.Join(stageDir, "env.sh")
+	if err := os.WriteFile(envScript, []byte(envExports(env)), 0600); err != nil {
+		tb.Cleanup()
+		return nil, fmt.Errorf("failed to stage credential environment: %w", err)
+	}
+
+	// The staging directory itself is writable so the helper can revoke in place
+	tb.Mounts = append(tb.Mounts, container.Mount{
+		Source:   stageDir,
+		Target:   TimeBoxDir,
+		ReadOnly: false,
+		Kind:     container.MountDir,
+	})
+	tb.Env["BASH_ENV"] = TimeBoxDir + "/env.sh"
+	tb.Env["ENCLAUDE_CREDENTIAL_TTL"] = strconv.Itoa(int(ttl.Seconds()))
+
+	return tb, nil
+}
+
+// Cleanup removes the host staging directory
+func (tb *TimeBoxed) Cleanup() {
+	if tb.stageDir != "" {
+		os.RemoveAll(tb.stageDir)
+	}
+}
+
+// envExports renders environment variables as sorted shell export statements
+func envExports(env map[string]string) string {
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		// Single-quote values, escaping embedded single quotes
+		fmt.Fprintf(&b, "export %s='%s'\n", k, strings.ReplaceAll(env[k], "'", `'\''`))
+	}
+	return b.String()
+}
@@ -0,0 +1,78 @@
+package credentials
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jakenelson/enclaude/internal/container"
+)
+
+func TestTimeBox(t *testing.T) {
+	tmpDir := t.TempDir()
+	tokenFile := filepath.Join(tmpDir, "hosts.yml")
+	if err := os.WriteFile(tokenFile, []byte("oauth_token: secret"), 0600); err != nil {
+		t.Fatalf("failed to write token file: %v", err)
+	}
+
+	mounts := []container.Mount{
+		{Source: tokenFile, Target: container.Home + "/.config/gh/hosts.yml", ReadOnly: true},
+		{Source: tmpDir, Target: "/some/dir", ReadOnly: true},
+	}
+	env := map[string]string{"GH_TOKEN": "it's-secret"}
+
+	tb, err := TimeBox(mounts, env, 90*time.Second)
+	if err != nil {
+		t.Fatalf("TimeBox() error = %v", err)
+	}
+	defer tb.Cleanup()
+
+	// File mount is replaced by a staged copy, directory passes through, staging dir is added
+	if len(tb.Mounts) != 3 {
+		t.Fatalf("TimeBox() mount count = %d, want 3", len(tb.Mounts))
+	}
+	if tb.Mounts[0].Source == tokenFile || tb.Mounts[0].Target != container.Home+"/.config/gh/hosts.yml" {
+		t.Errorf("TimeBox() file mount = %+v, want staged copy", tb.Mounts[0])
+	}
+	if tb.Mounts[1].Source != tmpDir {
+		t.Errorf("TimeBox() directory mount source = %s, want %s", tb.Mounts[1].Source, tmpDir)
+	}
+	if len(tb.Unrevoked) != 1 || tb.Unrevoked[0] != tmpDir {
+		t.Errorf("TimeBox() Unrevoked = %v, want [%s]", tb.Unrevoked, tmpDir)
+	}
+	if tb.Mounts[2].Target != TimeBoxDir || tb.Mounts[2].ReadOnly {
+		t.Errorf("TimeBox() staging mount = %+v, want writable %s", tb.Mounts[2], TimeBoxDir)
+	}
+
+	// Token is not exported in the container environment
+	if _, ok := tb.Env["GH_TOKEN"]; ok {
+		t.Error("TimeBox() should not pass GH_TOKEN in the container environment")
+	}
+	if tb.Env["ENCLAUDE_CREDENTIAL_TTL"] != "90" {
+		t.Errorf("TimeBox() ENCLAUDE_CREDENTIAL_TTL = %q, want %q", tb.Env["ENCLAUDE_CREDENTIAL_TTL"], "90")
+	}
+
+	script, err := os.ReadFile(filepath.Join(tb.Mounts[2].Source, "env.sh"))
+	if err != nil {
+		t.Fatalf("failed to read env.sh: %v", err)
+	}
+	if want := `export GH_TOKEN='it'\''s-secret'`; !strings.Contains(string(script), want) {
+		t.Errorf("env.sh = %q, want it to contain %q", script, want)
+	}
+
+	stageDir := tb.Mounts[2].Source
+	tb.Cleanup()
+	if _, err := os.Stat(stageDir); !os.IsNotExist(err) {
+		t.Errorf("Cleanup() should remove staging directory %s", stageDir)
+	}
+}
+
+func TestTimeBox_InvalidTTL(t *testing.T) {
+	for _, ttl := range []time.Duration{0, 500 * time.Millisecond} {
+		if _, err := TimeBox(nil, nil, ttl); err == nil {
+			t.Errorf("TimeBox() with ttl %s should return an error", ttl)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+// Package domainblock keeps the session from reaching blocked domains and
+// all of their subdomains, which hosts file entries cannot cover. A proxy in
+// the container, set as the session's HTTPS proxy, refuses tunnels to them
+// and opens the rest directly, or through the proxy the session already
+// used.
+package domainblock
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/jakenelson/enclaude/internal/container"
+)
+
+// ContainerDir is where the proxy directory is mounted in the container
+const ContainerDir = "/run/enclaude/domainblock"
+
+// ProxyPort is the loopback port the proxy listens on in the container
+const ProxyPort = 41918
+
+// proxy is the in-container HTTPS proxy refusing the blocked domains
+//
+//go:embed enclaude-block-proxy.js
+var proxy []byte
+
+// Proxy holds the directory the in-container proxy is mounted from
+type Proxy struct {
+	dir     string
+	domains []string
+}
+
+// Start creates the proxy directory holding the proxy blocking domains.
+// Nothing in it is secret, so it is readable by any session uid.
+func Start(domains []string) (*Proxy, error) {
+	if len(domains) == 0 {
+		return nil, fmt.Errorf("no domains to block")
+	}
+	for _, d := range domains {
+		if d == "" || strings.ContainsAny(d, " /:") {
+			return nil, fmt.Errorf("invalid domain %q", d)
+		}
+	}
+
+	dir, err := os.MkdirTemp("", "enclaude-domainblock-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create domain block directory: %w", err)
+	}
+	if err := os.Chmod(dir, 0755); err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("failed to create domain block directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "enclaude-block-proxy.js"), proxy, 0755); err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("failed to write domain block proxy: %w", err)
+	}
+	return &Proxy{dir: dir, domains: domains}, nil
+}
+
+// Mount returns the mount exposing the proxy in the container
+func (p *Proxy) Mount() container.Mount {
+	return container.Mount{Source: p.dir, Target: ContainerDir, ReadOnly: true, Kind: container.MountDir}
+}
+
+// Env returns the environment telling the image entrypoint to start the
+// proxy and make it the session's HTTPS proxy
+func (p *Proxy) Env() map[string]string {
+	return map[string]string{
+		"ENCLAUDE_BLOCK_PROXY":     ContainerDir + "/enclaude-block-proxy.js",
+		"ENCLAUDE_BLOCK_PORT":      strconv.Itoa(ProxyPort),
+		"ENCLAUDE_BLOCKED_DOMAINS": strings.Join(p.domains, " "),
+	}
+}
+
+// Close removes the proxy directory
+func (p *Proxy) Close() {
+	os.RemoveAll(p.dir)
+}
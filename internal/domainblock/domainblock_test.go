@@ -0,0 +1,131 @@
+package domainblock
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStart(t *testing.T) {
+	if _, err := Start(nil); err == nil {
+		t.Error("expected an error without domains")
+	}
+	if _, err := Start([]string{"sentry.io", "bad domain"}); err == nil {
+		t.Error("expected an error for an invalid domain")
+	}
+
+	p, err := Start([]string{"sentry.io", "statsig.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := p.Mount().Source
+	if info, err := os.Stat(dir); err != nil || info.Mode().Perm() != 0755 {
+		t.Errorf("proxy directory: %v, %v; want mode 0755", info, err)
+	}
+	if got := p.Env()["ENCLAUDE_BLOCKED_DOMAINS"]; got != "sentry.io statsig.com" {
+		t.Errorf("ENCLAUDE_BLOCKED_DOMAINS = %q", got)
+	}
+	p.Close()
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("proxy directory left behind: %v", err)
+	}
+}
+
+// freePort returns a loopback port nothing listens on
+func freePort(t *testing.T) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+func TestProxy(t *testing.T) {
+	node, err := exec.LookPath("node")
+	if err != nil {
+		t.Skip("node not installed")
+	}
+
+	echo, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer echo.Close()
+	go func() {
+		for {
+			conn, err := echo.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				io.Copy(conn, conn)
+				conn.Close()
+			}()
+		}
+	}()
+
+	script := filepath.Join(t.TempDir(), "proxy.js")
+	if err := os.WriteFile(script, proxy, 0755); err != nil {
+		t.Fatal(err)
+	}
+	port := strconv.Itoa(freePort(t))
+	cmd := exec.Command(node, script)
+	cmd.Env = append(os.Environ(), "ENCLAUDE_BLOCK_PORT="+port, "ENCLAUDE_BLOCKED_DOMAINS=sentry.io", "ENCLAUDE_UPSTREAM_PROXY=")
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer cmd.Process.Kill()
+
+	connect := func(target string) (net.Conn, *bufio.Reader, string) {
+		t.Helper()
+		var conn net.Conn
+		for deadline := time.Now().Add(5 * time.Second); ; {
+			if conn, err = net.Dial("tcp", "127.0.0.1:"+port); err == nil || time.Now().After(deadline) {
+				break
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+		conn.Write([]byte("CONNECT " + target + " HTTP/1.1\r\nHost: " + target + "\r\n\r\n"))
+		reader := bufio.NewReader(conn)
+		status, _ := reader.ReadString('\n')
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil || line == "\r\n" {
+				break
+			}
+		}
+		return conn, reader, strings.TrimSpace(status)
+	}
+
+	for _, target := range []string{"sentry.io:443", "o1.ingest.us.sentry.io:443", "SENTRY.IO.:443"} {
+		conn, _, status := connect(target)
+		conn.Close()
+		if !strings.Contains(status, " 403 ") {
+			t.Errorf("CONNECT %s: %q, want 403", target, status)
+		}
+	}
+
+	conn, reader, status := connect(echo.Addr().String())
+	defer conn.Close()
+	if !strings.Contains(status, " 200 ") {
+		t.Fatalf("CONNECT to an allowed host: %q, want 200", status)
+	}
+	conn.Write([]byte("ping\n"))
+	if got, _ := reader.ReadString('\n'); got != "ping\n" {
+		t.Errorf("tunnel echoed %q, want ping", got)
+	}
+}
@@ -0,0 +1,73 @@
+#!/usr/bin/env node
+// enclaude-block-proxy: the session's HTTPS proxy on a loopback port. It
+// refuses tunnels to the blocked domains and their subdomains, and opens the
+// rest directly, or through the proxy the session was configured with.
+const http = require('http');
+const net = require('net');
+
+const port = Number(process.env.ENCLAUDE_BLOCK_PORT);
+const domains = (process.env.ENCLAUDE_BLOCKED_DOMAINS || '').split(' ').filter(Boolean).map((d) => d.toLowerCase());
+const upstream = process.env.ENCLAUDE_UPSTREAM_PROXY ? new URL(process.env.ENCLAUDE_UPSTREAM_PROXY) : null;
+
+function blocked(host) {
+  host = host.toLowerCase().replace(/\.$/, '');
+  return domains.some((d) => host === d || host.endsWith('.' + d));
+}
+
+// Only tunnels are proxied: the session's HTTPS_PROXY is the only variable
+// pointing here
+const server = http.createServer((req, res) => {
+  res.writeHead(405, { Connection: 'close' });
+  res.end('enclaude-block-proxy: only CONNECT is supported\n');
+});
+
+server.on('connect', (req, client, head) => {
+  let target;
+  try {
+    target = new URL(`http://${req.url}`);
+  } catch {
+    client.end('HTTP/1.1 400 Bad Request\r\n\r\n');
+    return;
+  }
+  const host = target.hostname.replace(/^\[|\]$/g, '');
+  if (blocked(host)) {
+    client.end('HTTP/1.1 403 Forbidden\r\n\r\n');
+    return;
+  }
+
+  let remote;
+  if (upstream) {
+    // The upstream proxy's reply to CONNECT goes back to the client as is
+    remote = net.createConnection(Number(upstream.port) || 80, upstream.hostname, () => {
+      let request = `CONNECT ${req.url} HTTP/1.1\r\nHost: ${req.url}\r\n`;
+      if (upstream.username) {
+        const credentials = `${decodeURIComponent(upstream.username)}:${decodeURIComponent(upstream.password)}`;
+        request += `Proxy-Authorization: Basic ${Buffer.from(credentials).toString('base64')}\r\n`;
+      }
+      remote.write(request + '\r\n');
+      if (head.length > 0) remote.write(head);
+      client.pipe(remote);
+      remote.pipe(client);
+    });
+  } else {
+    remote = net.createConnection(Number(target.port) || 443, host, () => {
+      client.write('HTTP/1.1 200 Connection Established\r\n\r\n');
+      if (head.length > 0) remote.write(head);
+      client.pipe(remote);
+      remote.pipe(client);
+    });
+  }
+  remote.on('error', () => {
+    if (remote.connecting) client.end('HTTP/1.1 502 Bad Gateway\r\n\r\n');
+    else client.destroy();
+  });
+  client.on('error', () => remote.destroy());
+  client.on('close', () => remote.destroy());
+  remote.on('close', () => client.destroy());
+});
+
+server.on('error', (err) => {
+  console.error(`enclaude-block-proxy: ${err.message}`);
+  process.exit(1);
+});
+server.listen(port, '127.0.0.1');
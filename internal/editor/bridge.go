@@ -0,0 +1,167 @@
+// Package editor bridges $EDITOR requests from the container to the user's
+// editor on the host.
+package editor
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/jakenelson/enclaude/internal/bridge"
+)
+
+// ContainerDir is where the shim and socket appear in the container
+const ContainerDir = "/run/enclaude/editor"
+
+// shim is the in-container $EDITOR script
+//
+//go:embed enclaude-edit.js
+var shim []byte
+
+// terminalEditors take over the terminal, which the session is already using
+var terminalEditors = []string{"vi", "vim", "nvim", "nano", "emacs", "micro", "hx", "helix", "kak", "joe"}
+
+// request is sent by the shim with the file to edit
+type request struct {
+	Name    string `json:"name"`
+	Content []byte `json:"content"`
+}
+
+// response returns the edited file, or an error
+type response struct {
+	Content []byte `json:"content"` // Sent even when empty, as the shim writes it back
+	Error   string `json:"error,omitempty"`
+}
+
+// Bridge listens on a Unix socket for edit requests from the container,
+// opens each file in the host editor, and returns the edited content
+type Bridge struct {
+	*bridge.Dir
+	command string
+	mu      sync.Mutex // Serializes edits so editors don't stack up
+}
+
+// Start creates the bridge directory holding the shim and socket, and starts
+// serving edit requests with command, a shell command line that is given the
+// file path as its final argument and must block until editing is done
+// (e.g., "code --wait")
+func Start(command string) (*Bridge, error) {
+	if command == "" {
+		return nil, fmt.Errorf("no host editor configured; set editor.command or $VISUAL/$EDITOR")
+	}
+
+	dir, err := bridge.New("enclaude-editor-", "editor bridge", ContainerDir, false)
+	if err != nil {
+		return nil, err
+	}
+	b := &Bridge{Dir: dir, command: command}
+	if err := dir.WriteExecutable("enclaude-edit", shim); err != nil {
+		dir.Close()
+		return nil, err
+	}
+	if err := dir.Serve("editor.sock", b.handle); err != nil {
+		dir.Close()
+		return nil, err
+	}
+	return b, nil
+}
+
+// Env returns the environment that routes $EDITOR through the shim
+func (b *Bridge) Env() map[string]string {
+	shimPath := ContainerDir + "/enclaude-edit"
+	return map[string]string{
+		"EDITOR":                 shimPath,
+		"VISUAL":                 shimPath,
+		"ENCLAUDE_EDITOR_SOCKET": ContainerDir + "/editor.sock",
+	}
+}
+
+func (b *Bridge) handle(conn net.Conn, done <-chan struct{}) {
+	var req request
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		json.NewEncoder(conn).Encode(response{Error: "invalid request"})
+		return
+	}
+
+	content, err := b.edit(req, done)
+	if err != nil {
+		json.NewEncoder(conn).Encode(response{Error: err.Error()})
+		return
+	}
+	json.NewEncoder(conn).Encode(response{Content: content})
+}
+
+// edit writes the content to a host temp file named like the original, so
+// editors pick the right syntax, runs the editor, and reads the result back.
+// An editor still open when the session ends is stopped.
+func (b *Bridge) edit(req request, done <-chan struct{}) ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	tmpDir, err := os.MkdirTemp("", "enclaude-edit-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory on host: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	name := filepath.Base(req.Name)
+	if name == "." || name == "/" || name == "" {
+		name = "file"
+	}
+	path := filepath.Join(tmpDir, name)
+	if err := os.WriteFile(path, req.Content, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write temp file on host: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", b.command+` "$@"`, "enclaude-edit", path)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("host editor %q failed: %v: %s", b.command, err, strings.TrimSpace(string(out)))
+	}
+
+	return os.ReadFile(path)
+}
+
+// HostCommand returns the configured editor command, falling back to the
+// host's $VISUAL and $EDITOR
+func HostCommand(configured string) string {
+	if configured != "" {
+		return configured
+	}
+	if v := os.Getenv("VISUAL"); v != "" {
+		return v
+	}
+	return os.Getenv("EDITOR")
+}
+
+// IsTerminalEditor reports whether command runs a terminal editor, which
+// would compete with the session for the terminal
+func IsTerminalEditor(command string) bool {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return false
+	}
+	base := filepath.Base(fields[0])
+	for _, e := range terminalEditors {
+		if base == e {
+			return true
+		}
+	}
+	return false
+}
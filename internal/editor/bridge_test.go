@@ -0,0 +1,80 @@
+package editor
+
+import (
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"testing"
+)
+
+func TestBridgeEdit(t *testing.T) {
+	b, err := Start(`printf 'edited\n' >>`)
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer b.Close()
+
+	conn, err := net.Dial("unix", filepath.Join(b.Path(), "editor.sock"))
+	if err != nil {
+		t.Fatalf("failed to connect to bridge: %v", err)
+	}
+	defer conn.Close()
+
+	if err := json.NewEncoder(conn).Encode(request{Name: "/workspace/.git/COMMIT_EDITMSG", Content: []byte("draft\n")}); err != nil {
+		t.Fatalf("failed to send request: %v", err)
+	}
+	var resp response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		t.Fatalf("failed to read response: %v", err)
+	}
+	if resp.Error != "" {
+		t.Fatalf("bridge returned error: %s", resp.Error)
+	}
+	if string(resp.Content) != "draft\nedited\n" {
+		t.Errorf("edited content = %q, want %q", resp.Content, "draft\nedited\n")
+	}
+}
+
+func TestBridgeEditEmptied(t *testing.T) {
+	b, err := Start(`: >`)
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer b.Close()
+
+	conn, err := net.Dial("unix", filepath.Join(b.Path(), "editor.sock"))
+	if err != nil {
+		t.Fatalf("failed to connect to bridge: %v", err)
+	}
+	defer conn.Close()
+
+	if err := json.NewEncoder(conn).Encode(request{Name: "notes.txt", Content: []byte("draft\n")}); err != nil {
+		t.Fatalf("failed to send request: %v", err)
+	}
+	var resp map[string]interface{}
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		t.Fatalf("failed to read response: %v", err)
+	}
+	if content, ok := resp["content"].(string); !ok || content != "" {
+		t.Errorf("response = %v, want an empty content string", resp)
+	}
+}
+
+func TestIsTerminalEditor(t *testing.T) {
+	tests := []struct {
+		command string
+		want    bool
+	}{
+		{"vim", true},
+		{"/usr/bin/nvim -u NONE", true},
+		{"code --wait", false},
+		{"subl -w", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsTerminalEditor(tt.command); got != tt.want {
+			t.Errorf("IsTerminalEditor(%q) = %v, want %v", tt.command, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+#!/usr/bin/env node
+// enclaude-edit: $EDITOR shim that opens files in the host editor through the
+// enclaude editor bridge and writes the edited content back. Falls back to vi
+// in the container when the bridge is unavailable.
+const fs = require('fs');
+const net = require('net');
+const path = require('path');
+const { spawnSync } = require('child_process');
+
+const args = process.argv.slice(2);
+const socketPath = process.env.ENCLAUDE_EDITOR_SOCKET || path.join(__dirname, 'editor.sock');
+const file = args.filter((a) => !a.startsWith('+') && !a.startsWith('-')).pop();
+
+function fallback(reason) {
+  console.error(`enclaude-edit: ${reason}; falling back to vi`);
+  const result = spawnSync('vi', args, { stdio: 'inherit' });
+  process.exit(result.status === null ? 1 : result.status);
+}
+
+if (!file) {
+  fallback('no file given');
+}
+
+let content = Buffer.alloc(0);
+try {
+  content = fs.readFileSync(file);
+} catch (err) {
+  if (err.code !== 'ENOENT') {
+    fallback(err.message);
+  }
+}
+
+let connected = false;
+let response = '';
+const conn = net.createConnection(socketPath);
+conn.on('connect', () => {
+  connected = true;
+  conn.end(JSON.stringify({ name: path.basename(file), content: content.toString('base64') }) + '\n');
+});
+conn.on('data', (data) => {
+  response += data;
+});
+conn.on('error', (err) => {
+  if (!connected) {
+    fallback(`editor bridge unavailable (${err.message})`);
+  }
+  console.error(`enclaude-edit: ${err.message}`);
+  process.exit(1);
+});
+conn.on('close', () => {
+  let msg;
+  try {
+    msg = JSON.parse(response);
+  } catch (err) {
+    console.error('enclaude-edit: invalid response from host editor bridge');
+    process.exit(1);
+  }
+  if (msg.error) {
+    console.error(`enclaude-edit: ${msg.error}`);
+    process.exit(1);
+  }
+  // An emptied file may come back without content
+  fs.writeFileSync(file, Buffer.from(msg.content || '', 'base64'));
+  process.exit(0);
+});
@@ -0,0 +1,227 @@
+// Package hostexec lets the container run allowlisted commands on the host
+// through a Unix socket, for integrations such as opening URLs or copying to
+// the host clipboard without mounting more of the host.
+package hostexec
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/jakenelson/enclaude/internal/bridge"
+)
+
+// ContainerDir is where the shim, wrappers, and socket appear in the
+// container
+const ContainerDir = "/run/enclaude/host"
+
+// commandTimeout bounds how long a host command may run
+const commandTimeout = time.Minute
+
+// maxOutput caps the stdout and stderr returned for a single command
+const maxOutput = 4 << 20
+
+// shim is the in-container client that forwards a command to the bridge
+//
+//go:embed enclaude-host.js
+var shim []byte
+
+// request is sent by the shim with the command line and its stdin
+type request struct {
+	Args  []string `json:"args"`
+	Stdin []byte   `json:"stdin,omitempty"`
+}
+
+// response returns the command's output and exit code, or an error when the
+// command was refused or could not be started
+type response struct {
+	Stdout   []byte `json:"stdout,omitempty"`
+	Stderr   []byte `json:"stderr,omitempty"`
+	ExitCode int    `json:"exit_code"`
+	Error    string `json:"error,omitempty"`
+}
+
+// AuditFunc is called after every request with the requested command line,
+// the exit code, and any error, including refusals
+type AuditFunc func(args []string, exitCode int, err error)
+
+// Bridge listens on a Unix socket for command requests from the container
+// and runs those that match the allowlist on the host
+type Bridge struct {
+	*bridge.Dir
+	rules [][]string
+	audit AuditFunc
+}
+
+// anyArg is the allowlist word that matches a single argument other than an
+// option
+const anyArg = "*"
+
+// ParseAllowlist splits allowlist entries into command words. An entry is
+// an exact pattern of the whole command line: each word must match the
+// argument in its place, and there must be no further arguments. A "*"
+// word matches any one argument that does not start with "-", so "open *"
+// allows "open https://example.com" but not "open -a Terminal", and
+// "gh auth token" allows only that exact command.
+func ParseAllowlist(entries []string) ([][]string, error) {
+	var rules [][]string
+	for _, entry := range entries {
+		words := strings.Fields(entry)
+		if len(words) == 0 {
+			return nil, fmt.Errorf("empty host command entry")
+		}
+		if strings.Contains(words[0], "/") || words[0] == "." || words[0] == ".." || words[0] == anyArg {
+			return nil, fmt.Errorf("host command %q must be a command name on the host PATH, not a path", entry)
+		}
+		rules = append(rules, words)
+	}
+	return rules, nil
+}
+
+// Start creates the bridge directory holding the shim, a wrapper for each
+// allowed command, and the socket, and starts serving requests
+func Start(allow []string, audit AuditFunc) (*Bridge, error) {
+	rules, err := ParseAllowlist(allow)
+	if err != nil {
+		return nil, err
+	}
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("no host commands allowed; add entries to host_commands.allow")
+	}
+
+	dir, err := bridge.New("enclaude-host-", "host command bridge", ContainerDir, false)
+	if err != nil {
+		return nil, err
+	}
+	b := &Bridge{Dir: dir, rules: rules, audit: audit}
+	if err := writeShims(dir, rules); err != nil {
+		dir.Close()
+		return nil, err
+	}
+	if err := dir.Serve("host.sock", b.handle); err != nil {
+		dir.Close()
+		return nil, err
+	}
+	return b, nil
+}
+
+// writeShims writes the shim and a bin/<name> wrapper per allowed command,
+// so tools in the container can call the command by its usual name
+func writeShims(dir *bridge.Dir, rules [][]string) error {
+	if err := dir.WriteExecutable("enclaude-host", shim); err != nil {
+		return err
+	}
+	for _, rule := range rules {
+		wrapper := fmt.Sprintf("#!/bin/sh\nexec %s/enclaude-host %s \"$@\"\n", ContainerDir, rule[0])
+		if err := dir.WriteExecutable("bin/"+rule[0], []byte(wrapper)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Env returns the environment that locates the socket and the wrapper
+// directory, which the image entrypoint puts first on the PATH
+func (b *Bridge) Env() map[string]string {
+	return map[string]string{
+		"ENCLAUDE_HOST_SOCKET": ContainerDir + "/host.sock",
+		"ENCLAUDE_HOST_BIN":    ContainerDir + "/bin",
+	}
+}
+
+// Allowed reports whether args matches an allowlist entry exactly
+func (b *Bridge) Allowed(args []string) bool {
+	for _, rule := range b.rules {
+		if len(args) != len(rule) {
+			continue
+		}
+		match := true
+		for i, word := range rule {
+			if word == anyArg && !strings.HasPrefix(args[i], "-") {
+				continue
+			}
+			if args[i] != word {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *Bridge) handle(conn net.Conn, _ <-chan struct{}) {
+	var req request
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		json.NewEncoder(conn).Encode(response{Error: "invalid request"})
+		return
+	}
+
+	resp, err := b.run(req)
+	if b.audit != nil {
+		b.audit(req.Args, resp.ExitCode, err)
+	}
+	if err != nil {
+		resp.Error = err.Error()
+	}
+	json.NewEncoder(conn).Encode(resp)
+}
+
+// run executes an allowed command directly, without a shell, so arguments
+// from the container cannot add commands of their own
+func (b *Bridge) run(req request) (response, error) {
+	if len(req.Args) == 0 {
+		return response{ExitCode: -1}, fmt.Errorf("no command given")
+	}
+	if !b.Allowed(req.Args) {
+		return response{ExitCode: -1}, fmt.Errorf("host command not allowed: %s", strings.Join(req.Args, " "))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	var stdout, stderr limitedBuffer
+	cmd := exec.CommandContext(ctx, req.Args[0], req.Args[1:]...)
+	cmd.Stdin = bytes.NewReader(req.Stdin)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	resp := response{Stdout: stdout.Bytes(), Stderr: stderr.Bytes()}
+	var exitErr *exec.ExitError
+	switch {
+	case ctx.Err() != nil:
+		return response{ExitCode: -1}, fmt.Errorf("host command timed out after %s", commandTimeout)
+	case errors.As(err, &exitErr):
+		resp.ExitCode = exitErr.ExitCode()
+	case err != nil:
+		return response{ExitCode: -1}, fmt.Errorf("failed to run host command: %w", err)
+	}
+	return resp, nil
+}
+
+// limitedBuffer keeps the first maxOutput bytes written to it and discards
+// the rest
+type limitedBuffer struct {
+	bytes.Buffer
+}
+
+func (w *limitedBuffer) Write(p []byte) (int, error) {
+	if room := maxOutput - w.Len(); room > 0 {
+		if len(p) > room {
+			w.Buffer.Write(p[:room])
+		} else {
+			w.Buffer.Write(p)
+		}
+	}
+	return len(p), nil
+}
@@ -0,0 +1,125 @@
+package hostexec
+
+import (
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jakenelson/enclaude/internal/bridge"
+)
+
+func TestAllowed(t *testing.T) {
+	b := &Bridge{}
+	var err error
+	b.rules, err = ParseAllowlist([]string{"open *", "pbcopy", "gh auth token"})
+	if err != nil {
+		t.Fatalf("ParseAllowlist() error = %v", err)
+	}
+
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{[]string{"open", "https://example.com"}, true},
+		{[]string{"open"}, false},
+		{[]string{"open", "-a", "Terminal"}, false},
+		{[]string{"open", "--help"}, false},
+		{[]string{"open", "a", "b"}, false},
+		{[]string{"pbcopy"}, true},
+		{[]string{"pbcopy", "-pboard", "find"}, false},
+		{[]string{"gh", "auth", "token"}, true},
+		{[]string{"gh", "auth", "token", "--hostname", "github.com"}, false},
+		{[]string{"gh", "auth", "login"}, false},
+		{[]string{"gh"}, false},
+		{[]string{"opener"}, false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := b.Allowed(tt.args); got != tt.want {
+			t.Errorf("Allowed(%q) = %v, want %v", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestParseAllowlistRejectsPaths(t *testing.T) {
+	for _, entry := range []string{"/usr/bin/open", "../open", "..", "  ", "* foo"} {
+		if _, err := ParseAllowlist([]string{entry}); err == nil {
+			t.Errorf("ParseAllowlist(%q) expected error", entry)
+		}
+	}
+}
+
+func TestBridgeRun(t *testing.T) {
+	type call struct {
+		args     []string
+		exitCode int
+		err      error
+	}
+	var calls []call
+	b, err := Start([]string{"cat", "sh -c *"}, func(args []string, exitCode int, err error) {
+		calls = append(calls, call{args, exitCode, err})
+	})
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer b.Close()
+
+	send := func(req request) response {
+		t.Helper()
+		conn, err := net.Dial("unix", filepath.Join(b.Path(), "host.sock"))
+		if err != nil {
+			t.Fatalf("failed to connect to bridge: %v", err)
+		}
+		defer conn.Close()
+		if err := json.NewEncoder(conn).Encode(req); err != nil {
+			t.Fatalf("failed to send request: %v", err)
+		}
+		var resp response
+		if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+			t.Fatalf("failed to read response: %v", err)
+		}
+		return resp
+	}
+
+	resp := send(request{Args: []string{"cat"}, Stdin: []byte("clipboard")})
+	if resp.Error != "" || string(resp.Stdout) != "clipboard" {
+		t.Errorf("cat response = %+v, want stdout %q", resp, "clipboard")
+	}
+
+	resp = send(request{Args: []string{"sh", "-c", "echo oops >&2; exit 3"}})
+	if resp.ExitCode != 3 || strings.TrimSpace(string(resp.Stderr)) != "oops" {
+		t.Errorf("sh response = %+v, want exit 3 and stderr oops", resp)
+	}
+
+	resp = send(request{Args: []string{"rm", "-rf", "/"}})
+	if !strings.Contains(resp.Error, "not allowed") {
+		t.Errorf("rm response error = %q, want refusal", resp.Error)
+	}
+
+	if len(calls) != 3 {
+		t.Fatalf("audited %d calls, want 3", len(calls))
+	}
+	if calls[2].err == nil || calls[2].args[0] != "rm" {
+		t.Errorf("refused call audit = %+v", calls[2])
+	}
+}
+
+func TestWriteShims(t *testing.T) {
+	dir, err := bridge.New("enclaude-host-test-", "host command bridge", ContainerDir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dir.Close()
+	rules, _ := ParseAllowlist([]string{"pbcopy", "gh auth token"})
+	if err := writeShims(dir, rules); err != nil {
+		t.Fatalf("writeShims() error = %v", err)
+	}
+	for _, name := range []string{"enclaude-host", "bin/pbcopy", "bin/gh"} {
+		if _, err := os.Stat(filepath.Join(dir.Path(), name)); err != nil {
+			t.Errorf("missing %s: %v", name, err)
+		}
+	}
+}
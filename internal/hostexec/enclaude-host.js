@@ -0,0 +1,56 @@
+#!/usr/bin/env node
+// enclaude-host: runs an allowlisted command on the host through the enclaude
+// host command bridge, relaying stdin, stdout, stderr, and the exit code.
+const fs = require('fs');
+const net = require('net');
+const path = require('path');
+
+const args = process.argv.slice(2);
+const socketPath = process.env.ENCLAUDE_HOST_SOCKET || path.join(__dirname, 'host.sock');
+
+if (args.length === 0) {
+  console.error('usage: enclaude-host <command> [args...]');
+  process.exit(2);
+}
+
+let input = Buffer.alloc(0);
+if (!process.stdin.isTTY) {
+  try {
+    input = fs.readFileSync(0);
+  } catch (err) {
+    // No readable stdin; run the command without input
+  }
+}
+
+let response = '';
+const conn = net.createConnection(socketPath);
+conn.on('connect', () => {
+  conn.end(JSON.stringify({ args, stdin: input.toString('base64') }) + '\n');
+});
+conn.on('data', (data) => {
+  response += data;
+});
+conn.on('error', (err) => {
+  console.error(`enclaude-host: host command bridge unavailable (${err.message})`);
+  process.exit(126);
+});
+conn.on('close', () => {
+  let msg;
+  try {
+    msg = JSON.parse(response);
+  } catch (err) {
+    console.error('enclaude-host: invalid response from host command bridge');
+    process.exit(126);
+  }
+  if (msg.stdout) {
+    fs.writeSync(1, Buffer.from(msg.stdout, 'base64'));
+  }
+  if (msg.stderr) {
+    fs.writeSync(2, Buffer.from(msg.stderr, 'base64'));
+  }
+  if (msg.error) {
+    console.error(`enclaude-host: ${msg.error}`);
+    process.exit(126);
+  }
+  process.exit(msg.exit_code || 0);
+});
@@ -0,0 +1,30 @@
+#!/usr/bin/env node
+// enclaude-hostport-relay: listens on each forwarded host port at the address
+// host.enclaude.internal resolves to, and relays each connection over a Unix
+// socket to enclaude on the host, which connects it to the port there.
+const net = require('net');
+const path = require('path');
+
+const dir = process.env.ENCLAUDE_HOSTPORT_DIR;
+const address = process.env.ENCLAUDE_HOSTPORT_ADDR;
+const ports = (process.env.ENCLAUDE_HOSTPORTS || '').split(' ').filter(Boolean);
+
+for (const port of ports) {
+  // Half-open connections are kept, so a client that stops sending still
+  // gets the rest of the reply
+  const server = net.createServer({ allowHalfOpen: true }, (client) => {
+    const upstream = net.createConnection({ path: path.join(dir, `${port}.sock`), allowHalfOpen: true });
+    const close = () => {
+      client.destroy();
+      upstream.destroy();
+    };
+    client.on('error', close);
+    upstream.on('error', close);
+    client.pipe(upstream);
+    upstream.pipe(client);
+  });
+  server.on('error', (err) => {
+    console.error(`enclaude-hostport-relay: port ${port}: ${err.message}`);
+  });
+  server.listen(Number(port), address);
+}
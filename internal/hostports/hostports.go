@@ -0,0 +1,133 @@
+// Package hostports makes TCP ports on the host reachable from the container
+// at host.enclaude.internal, whatever network the session is on. A relay in
+// the container listens on each port at a loopback address the name resolves
+// to, and passes each connection over a Unix socket to the host, where it is
+// connected to the port on the host's loopback interface. Unlike
+// host.docker.internal, this needs neither Docker Desktop nor a route from
+// the container to the host.
+package hostports
+
+import (
+	_ "embed"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jakenelson/enclaude/internal/container"
+	"github.com/jakenelson/enclaude/internal/relay"
+)
+
+// ContainerDir is where the forwarding directory is mounted in the container
+const ContainerDir = "/run/enclaude/hostports"
+
+// Hostname is the name the session reaches forwarded host ports at
+const Hostname = "host.enclaude.internal"
+
+// Address is the loopback address Hostname resolves to in the container. It
+// is not 127.0.0.1, so a server the session runs on a forwarded port's
+// number does not collide with the relay.
+const Address = "127.0.0.2"
+
+// dialTimeout bounds how long connecting to a host port may take
+const dialTimeout = 10 * time.Second
+
+// relayScript is the in-container process listening on the forwarded ports
+//
+//go:embed enclaude-hostport-relay.js
+var relayScript []byte
+
+// ConnAuditFunc is called when a relayed connection closes, with the bytes
+// sent to and received from the host port and any error
+type ConnAuditFunc func(port int, sent, received int64, duration time.Duration, err error)
+
+// Forwarder relays connections from sockets mounted into the container to
+// ports on the host
+type Forwarder struct {
+	ports  []int
+	dir    string
+	server relay.Server
+}
+
+// Start creates the forwarding directory holding the relay and a socket for
+// each port, and starts relaying connections to the ports on the host
+func Start(ports []int, audit ConnAuditFunc) (*Forwarder, error) {
+	for _, port := range ports {
+		if port < 1 || port > 65535 {
+			return nil, fmt.Errorf("invalid host port %d: must be between 1 and 65535", port)
+		}
+	}
+	ports = slices.Compact(slices.Sorted(slices.Values(ports)))
+	if len(ports) == 0 {
+		return nil, fmt.Errorf("no host ports to forward")
+	}
+
+	dir, err := os.MkdirTemp("", "enclaude-hostports-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create host port directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "enclaude-hostport-relay.js"), relayScript, 0755); err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("failed to write host port relay: %w", err)
+	}
+
+	f := &Forwarder{ports: ports, dir: dir}
+	for _, port := range ports {
+		l, err := net.Listen("unix", filepath.Join(dir, strconv.Itoa(port)+".sock"))
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to listen for host port %d: %w", port, err)
+		}
+		addr := net.JoinHostPort("localhost", strconv.Itoa(port))
+		var done relay.DoneFunc
+		if audit != nil {
+			done = func(sent, received int64, duration time.Duration, err error) {
+				audit(port, sent, received, duration, err)
+			}
+		}
+		f.server.Serve(l, func() (net.Conn, error) { return net.DialTimeout("tcp", addr, dialTimeout) }, done)
+	}
+	return f, nil
+}
+
+// Ports returns the forwarded ports, sorted
+func (f *Forwarder) Ports() []int {
+	return f.ports
+}
+
+// Mount returns the mount exposing the relay and sockets in the container
+func (f *Forwarder) Mount() container.Mount {
+	return container.Mount{Source: f.dir, Target: ContainerDir, Kind: container.MountDir}
+}
+
+// Env returns the environment telling the image entrypoint to start the
+// relay on the forwarded ports
+func (f *Forwarder) Env() map[string]string {
+	ports := make([]string, len(f.ports))
+	for i, port := range f.ports {
+		ports[i] = strconv.Itoa(port)
+	}
+	return map[string]string{
+		"ENCLAUDE_HOSTPORT_RELAY": ContainerDir + "/enclaude-hostport-relay.js",
+		"ENCLAUDE_HOSTPORT_DIR":   ContainerDir,
+		"ENCLAUDE_HOSTPORT_ADDR":  Address,
+		"ENCLAUDE_HOSTPORTS":      strings.Join(ports, " "),
+	}
+}
+
+// HostEntry returns the container hosts file entry resolving Hostname to
+// the relay
+func (f *Forwarder) HostEntry() string {
+	return Hostname + ":" + Address
+}
+
+// Close stops accepting connections, ends those in progress, and removes
+// the forwarding directory
+func (f *Forwarder) Close() {
+	f.server.Close()
+	os.RemoveAll(f.dir)
+}
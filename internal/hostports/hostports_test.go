@@ -0,0 +1,128 @@
+package hostports
+
+import (
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// echoServer listens on a loopback port and echoes each connection
+func echoServer(t *testing.T) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				io.Copy(conn, conn)
+				conn.Close()
+			}()
+		}
+	}()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+func TestForwarder(t *testing.T) {
+	port := echoServer(t)
+
+	type result struct {
+		port           int
+		sent, received int64
+		err            error
+	}
+	audited := make(chan result, 1)
+	f, err := Start([]int{port, port}, func(p int, sent, received int64, _ time.Duration, err error) {
+		audited <- result{p, sent, received, err}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := f.Env()["ENCLAUDE_HOSTPORTS"]; got != strconv.Itoa(port) {
+		t.Errorf("ENCLAUDE_HOSTPORTS = %q, want the port once", got)
+	}
+	if f.HostEntry() != "host.enclaude.internal:127.0.0.2" {
+		t.Errorf("HostEntry() = %q", f.HostEntry())
+	}
+	if _, err := os.Stat(filepath.Join(f.dir, "enclaude-hostport-relay.js")); err != nil {
+		t.Errorf("relay not written: %v", err)
+	}
+
+	conn, err := net.Dial("unix", filepath.Join(f.dir, strconv.Itoa(port)+".sock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Write([]byte("ping"))
+	conn.(*net.UnixConn).CloseWrite()
+	reply, _ := io.ReadAll(conn)
+	conn.Close()
+	if string(reply) != "ping" {
+		t.Errorf("reply = %q, want ping", reply)
+	}
+
+	select {
+	case r := <-audited:
+		if r.port != port || r.sent != 4 || r.received != 4 || r.err != nil {
+			t.Errorf("audited %+v, want 4 bytes each way on port %d", r, port)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("connection was not audited")
+	}
+
+	f.Close()
+	if _, err := os.Stat(f.dir); !os.IsNotExist(err) {
+		t.Error("forwarding directory was not removed")
+	}
+}
+
+func TestForwarderClosedPort(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+
+	audited := make(chan error, 1)
+	f, err := Start([]int{port}, func(_ int, _, _ int64, _ time.Duration, err error) {
+		audited <- err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	conn, err := net.Dial("unix", filepath.Join(f.dir, strconv.Itoa(port)+".sock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	select {
+	case err := <-audited:
+		if err == nil {
+			t.Error("connection to a closed host port audited without an error")
+		}
+	case <-time.After(15 * time.Second):
+		t.Fatal("connection was not audited")
+	}
+}
+
+func TestStartInvalid(t *testing.T) {
+	for _, ports := range [][]int{nil, {0}, {70000}} {
+		if f, err := Start(ports, nil); err == nil {
+			f.Close()
+			t.Errorf("Start(%v) succeeded", ports)
+		}
+	}
+}
@@ -0,0 +1,364 @@
+// Package kube runs Claude inside existing Kubernetes pods through kubectl,
+// for teams whose development environments already live in a cluster. No
+// resources are created, apart from an ephemeral debug container on request.
+package kube
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jakenelson/enclaude/internal/terminal"
+	"github.com/moby/term"
+)
+
+// debugDone is created in the debug container to let its idle loop exit
+const debugDone = "/tmp/.enclaude-done"
+
+// debugStartTimeout bounds how long an ephemeral container may take to start
+const debugStartTimeout = 2 * time.Minute
+
+// envName is a variable name the session script can export
+var envName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Target identifies the pod and container to run in
+type Target struct {
+	Pod       string
+	Namespace string // Default: the kubeconfig context's namespace
+	Container string // Default: the pod's default container
+	Context   string // kubeconfig context (default: current)
+}
+
+// File is a host file copied into the pod for the session and removed after.
+// A file that variables in Env point at is staged in the session directory
+// instead of at Target, and the variables set to its path there. Files are
+// never written over the pod's own.
+type File struct {
+	Source string
+	Target string   // Absolute, or "~/" for the pod user's HOME
+	Env    []string // Variables to point at the staged file
+}
+
+// Session is a claude session in an existing pod
+type Session struct {
+	Debug        bool              // Run in an ephemeral debug container sharing the target's processes
+	Image        string            // Debug container image
+	ClaudeBinary string            // Host path of a Linux claude binary, copied in when the container has none
+	WorkDir      string            // Directory in the container to start in (default: the container's)
+	Env          map[string]string // Values starting with "~/" are relative to the pod user's HOME
+	Files        []File
+	ClaudeArgs   []string
+}
+
+// Kubectl runs kubectl against a target pod
+type Kubectl struct {
+	bin    string
+	target Target
+}
+
+// NewKubectl finds kubectl on the PATH and checks that the target pod is
+// running
+func NewKubectl(ctx context.Context, t Target) (*Kubectl, error) {
+	if t.Pod == "" {
+		return nil, fmt.Errorf("a pod is required")
+	}
+	bin, err := exec.LookPath("kubectl")
+	if err != nil {
+		return nil, fmt.Errorf("kubectl not found on PATH; install it to run in Kubernetes pods")
+	}
+	k := &Kubectl{bin: bin, target: t}
+	out, err := k.output(ctx, nil, "get", "pod", t.Pod, "-o", "jsonpath={.status.phase}")
+	if err != nil {
+		return nil, err
+	}
+	if phase := strings.TrimSpace(out); phase != "Running" {
+		return nil, fmt.Errorf("pod %s is %s, not Running", t.Pod, phase)
+	}
+	return k, nil
+}
+
+// globalArgs returns the kubectl flags selecting the target's context and
+// namespace
+func globalArgs(t Target) []string {
+	var args []string
+	if t.Context != "" {
+		args = append(args, "--context", t.Context)
+	}
+	if t.Namespace != "" {
+		args = append(args, "--namespace", t.Namespace)
+	}
+	return args
+}
+
+// execArgs returns `kubectl exec` arguments running argv in container
+func execArgs(t Target, container string, stdin, tty bool, argv ...string) []string {
+	args := append(globalArgs(t), "exec")
+	if stdin {
+		args = append(args, "-i")
+	}
+	if tty {
+		args = append(args, "-t")
+	}
+	args = append(args, t.Pod)
+	if container != "" {
+		args = append(args, "-c", container)
+	}
+	return append(append(args, "--"), argv...)
+}
+
+// output runs kubectl with stdin and returns its stdout, or an error with
+// its stderr
+func (k *Kubectl) output(ctx context.Context, stdin io.Reader, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, k.bin, append(globalArgs(k.target), args...)...)
+	cmd.Stdin = stdin
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("kubectl %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("kubectl %s: %w", args[0], err)
+	}
+	return string(out), nil
+}
+
+// exec runs argv in container, feeding it stdin, and returns its output
+func (k *Kubectl) exec(ctx context.Context, container string, stdin io.Reader, argv ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, k.bin, execArgs(k.target, container, stdin != nil, false, argv...)...)
+	cmd.Stdin = stdin
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s", msg)
+		}
+		return "", err
+	}
+	return string(out), nil
+}
+
+// Run runs claude in the target container, or in an ephemeral debug
+// container, with the session's environment and files, and returns when it
+// exits. Secrets travel over kubectl exec's stdin into a private file that
+// the session script sources and deletes, so they never appear in command
+// lines or the pod spec.
+func (k *Kubectl) Run(ctx context.Context, s Session) error {
+	container := k.target.Container
+	workDir := s.WorkDir
+	if s.Debug {
+		name, stop, err := k.startDebugContainer(ctx, s.Image)
+		if err != nil {
+			return err
+		}
+		defer stop()
+		container = name
+		// The target's filesystem, seen through its first process
+		workDir = "/proc/1/root" + workDir
+	}
+
+	id, err := randomID()
+	if err != nil {
+		return err
+	}
+	dir := "/tmp/.enclaude-" + id
+	files, sessionEnv := stageFiles(dir, s.Files, s.Env)
+	env, err := envScript(sessionEnv)
+	if err != nil {
+		return err
+	}
+	if _, err := k.exec(ctx, container, strings.NewReader(env), "sh", "-c", `umask 077 && mkdir -p "$1/bin" && cat > "$1/env"`, "sh", dir); err != nil {
+		return fmt.Errorf("failed to prepare the session in pod %s: %w", k.target.Pod, err)
+	}
+	var copied []string
+	defer func() {
+		// The session may have been cancelled, so clean up regardless. Only
+		// files this session created are removed.
+		if len(copied) > 0 {
+			k.exec(context.Background(), container, nil, append([]string{"rm", "-f"}, copied...)...)
+		}
+		k.exec(context.Background(), container, nil, "rm", "-rf", dir)
+	}()
+
+	for _, f := range files {
+		target, err := k.copyFile(ctx, container, f.Source, f.Target, 0600)
+		if err != nil {
+			return err
+		}
+		copied = append(copied, target)
+	}
+
+	if _, err := k.exec(ctx, container, nil, "sh", "-c", "command -v claude"); err != nil {
+		if s.ClaudeBinary == "" {
+			return fmt.Errorf("claude is not installed in pod %s; install it in the pod's image, pass --claude-binary with a Linux build to copy in, or use --debug", k.target.Pod)
+		}
+		if _, err := k.copyFile(ctx, container, s.ClaudeBinary, dir+"/bin/claude", 0755); err != nil {
+			return err
+		}
+	}
+
+	tty := term.IsTerminal(os.Stdin.Fd())
+	argv := append([]string{"sh", "-c", sessionScript, "sh", dir, workDir}, s.ClaudeArgs...)
+	cmd := exec.CommandContext(ctx, k.bin, execArgs(k.target, container, true, tty, argv...)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if !tty {
+		return cmd.Run()
+	}
+
+	// kubectl puts the terminal into raw mode itself and cannot undo it if
+	// it is killed
+	terminal.Save(os.Stdin.Fd())
+	err = cmd.Run()
+	if err != nil {
+		terminal.Reset(os.Stdout)
+	} else {
+		terminal.Restore()
+	}
+	return err
+}
+
+// sessionScript runs claude in the pod: $1 is the session directory holding
+// the environment file and any copied claude binary, $2 the directory to
+// start in, and the rest are claude's arguments
+const sessionScript = `dir=$1; cd_to=$2; shift 2
+. "$dir/env" && rm -f "$dir/env" || exit 1
+PATH="$dir/bin:$PATH"; export PATH
+if [ -n "$cd_to" ]; then cd "$cd_to" || exit 1; fi
+claude "$@"`
+
+// stageFiles returns files with those that variables point at moved into
+// the session directory dir, and env with the variables set to match
+func stageFiles(dir string, files []File, env map[string]string) ([]File, map[string]string) {
+	staged := make([]File, len(files))
+	sessionEnv := make(map[string]string, len(env))
+	for k, v := range env {
+		sessionEnv[k] = v
+	}
+	for i, f := range files {
+		if len(f.Env) > 0 {
+			f.Target = fmt.Sprintf("%s/files/%d-%s", dir, i, path.Base(f.Target))
+			for _, name := range f.Env {
+				sessionEnv[name] = f.Target
+			}
+		}
+		staged[i] = f
+	}
+	return staged, sessionEnv
+}
+
+// copyScript writes stdin to $1, a path that may start with "~/", with mode
+// $2, and prints the path written. It refuses to replace an existing file,
+// so the pod's own files are neither truncated nor removed afterwards.
+const copyScript = `case "$1" in "~/"*) set -- "$HOME/${1#"~/"}" "$2";; esac
+if [ -e "$1" ] || [ -L "$1" ]; then echo "$1 already exists; not replacing the pod's own file" >&2; exit 1; fi
+mkdir -p "$(dirname "$1")" && (set -C && umask 077 && cat > "$1") && chmod "$2" "$1" && printf '%s' "$1"`
+
+// copyFile streams a host file into the pod through exec's stdin, which,
+// unlike kubectl cp, needs no tar in the container. It returns the path the
+// file was written to.
+func (k *Kubectl) copyFile(ctx context.Context, container, source, target string, mode os.FileMode) (string, error) {
+	f, err := os.Open(source)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	out, err := k.exec(ctx, container, f, "sh", "-c", copyScript, "sh", target, fmt.Sprintf("%o", mode))
+	if err != nil {
+		return "", fmt.Errorf("failed to copy %s into pod %s: %w", source, k.target.Pod, err)
+	}
+	return out, nil
+}
+
+// startDebugContainer adds an ephemeral container from image to the pod,
+// sharing the target container's process namespace, and waits for it to
+// run. The returned function lets it exit; ephemeral containers cannot be
+// removed, so it stays in the pod spec as terminated.
+func (k *Kubectl) startDebugContainer(ctx context.Context, image string) (string, func(), error) {
+	if image == "" {
+		return "", nil, fmt.Errorf("a debug image is required")
+	}
+	id, err := randomID()
+	if err != nil {
+		return "", nil, err
+	}
+	name := "enclaude-" + id
+	args := []string{"debug", k.target.Pod, "--image", image, "--container", name, "--quiet"}
+	if k.target.Container != "" {
+		args = append(args, "--target", k.target.Container)
+	}
+	idle := fmt.Sprintf("while [ ! -e %s ]; do sleep 1; done", debugDone)
+	if _, err := k.output(ctx, nil, append(args, "--", "sh", "-c", idle)...); err != nil {
+		return "", nil, fmt.Errorf("failed to start debug container: %w", err)
+	}
+
+	deadline := time.Now().Add(debugStartTimeout)
+	query := fmt.Sprintf(`jsonpath={.status.ephemeralContainerStatuses[?(@.name=="%s")].state.running.startedAt}`, name)
+	for {
+		out, err := k.output(ctx, nil, "get", "pod", k.target.Pod, "-o", query)
+		if err == nil && strings.TrimSpace(out) != "" {
+			break
+		}
+		if time.Now().After(deadline) {
+			return "", nil, fmt.Errorf("debug container %s did not start within %s", name, debugStartTimeout)
+		}
+		select {
+		case <-ctx.Done():
+			return "", nil, ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+
+	stop := func() {
+		k.exec(context.Background(), name, nil, "touch", debugDone)
+	}
+	return name, stop, nil
+}
+
+// envScript renders env as shell exports, single-quoting every value. A
+// value starting with "~/" is a path under the pod user's HOME.
+func envScript(env map[string]string) (string, error) {
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		if !envName.MatchString(k) {
+			return "", fmt.Errorf("invalid environment variable name %q", k)
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		v := env[k]
+		if rest, ok := strings.CutPrefix(v, "~/"); ok {
+			fmt.Fprintf(&b, "export %s=\"$HOME\"/%s\n", k, quote(rest))
+		} else {
+			fmt.Fprintf(&b, "export %s=%s\n", k, quote(v))
+		}
+	}
+	return b.String(), nil
+}
+
+// quote single-quotes s for the shell
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func randomID() (string, error) {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate session ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,103 @@
+package kube
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExecArgs(t *testing.T) {
+	target := Target{Pod: "dev-0", Namespace: "team", Container: "app", Context: "staging"}
+	got := strings.Join(execArgs(target, "app", true, true, "sh", "-c", "claude"), " ")
+	want := "--context staging --namespace team exec -i -t dev-0 -c app -- sh -c claude"
+	if got != want {
+		t.Errorf("execArgs() = %q, want %q", got, want)
+	}
+
+	got = strings.Join(execArgs(Target{Pod: "dev-0"}, "", false, false, "true"), " ")
+	if got != "exec dev-0 -- true" {
+		t.Errorf("execArgs() without options = %q", got)
+	}
+}
+
+func TestEnvScript(t *testing.T) {
+	got, err := envScript(map[string]string{"GH_TOKEN": "ghp_x", "QUOTE": "it's $HOME", "ADC": "~/.config/gcloud/adc.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "export ADC=\"$HOME\"/'.config/gcloud/adc.json'\nexport GH_TOKEN='ghp_x'\nexport QUOTE='it'\\''s $HOME'\n"
+	if got != want {
+		t.Errorf("envScript() = %q, want %q", got, want)
+	}
+
+	if _, err := envScript(map[string]string{"BAD-NAME": "x"}); err == nil {
+		t.Error("envScript() accepted an invalid name")
+	}
+}
+
+func TestSessionScript(t *testing.T) {
+	dir := t.TempDir()
+	workDir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "bin"), 0755)
+	// A stand-in claude that reports what it was started with
+	claude := "#!/bin/sh\nprintf '%s|%s|%s' \"$QUOTE\" \"$PWD\" \"$*\"\n"
+	os.WriteFile(filepath.Join(dir, "bin", "claude"), []byte(claude), 0755)
+	env, _ := envScript(map[string]string{"QUOTE": "it's"})
+	os.WriteFile(filepath.Join(dir, "env"), []byte(env), 0600)
+
+	out, err := exec.Command("sh", "-c", sessionScript, "sh", dir, workDir, "--resume", "x y").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "it's|" + workDir + "|--resume x y"; string(out) != want {
+		t.Errorf("session output = %q, want %q", out, want)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "env")); !os.IsNotExist(err) {
+		t.Error("environment file was not removed")
+	}
+}
+
+func TestStageFiles(t *testing.T) {
+	files, env := stageFiles("/tmp/.enclaude-x", []File{
+		{Source: "/h/adc.json", Target: "~/.config/gcloud/adc.json", Env: []string{"GOOGLE_APPLICATION_CREDENTIALS"}},
+		{Source: "/h/id_ed25519", Target: "~/.ssh/id_ed25519"},
+	}, map[string]string{"TERM": "xterm"})
+	if files[0].Target != "/tmp/.enclaude-x/files/0-adc.json" || files[1].Target != "~/.ssh/id_ed25519" {
+		t.Errorf("stageFiles() targets = %q, %q", files[0].Target, files[1].Target)
+	}
+	if env["GOOGLE_APPLICATION_CREDENTIALS"] != files[0].Target || env["TERM"] != "xterm" {
+		t.Errorf("stageFiles() env = %v", env)
+	}
+}
+
+func TestCopyScript(t *testing.T) {
+	home := t.TempDir()
+	run := func(target, content string) (string, error) {
+		cmd := exec.Command("sh", "-c", copyScript, "sh", target, "600")
+		cmd.Env = append(os.Environ(), "HOME="+home)
+		cmd.Stdin = strings.NewReader(content)
+		out, err := cmd.Output()
+		return string(out), err
+	}
+
+	out, err := run("~/.aws/credentials", "copied")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := filepath.Join(home, ".aws", "credentials")
+	if out != want {
+		t.Errorf("copy printed %q, want %q", out, want)
+	}
+	if data, _ := os.ReadFile(want); string(data) != "copied" {
+		t.Errorf("copied file = %q", data)
+	}
+
+	if _, err := run("~/.aws/credentials", "replaced"); err == nil {
+		t.Error("copy over an existing file succeeded")
+	}
+	if data, _ := os.ReadFile(want); string(data) != "copied" {
+		t.Errorf("existing file = %q after a refused copy, want it untouched", data)
+	}
+}
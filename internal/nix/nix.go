@@ -0,0 +1,84 @@
+// Package nix detects Nix flake and devenv workspaces and generates the
+// Dockerfile of an image that realizes their declared development
+// environment, so Claude works with exactly the project's toolchain.
+package nix
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Kinds of declared environment
+const (
+	Flake  = "flake"
+	Devenv = "devenv"
+)
+
+// Label records the kind of environment realized in an image
+const Label = "io.enclaude.nix"
+
+// EnvScript is the shell script in the image that exports the realized
+// environment; the entrypoint sources it
+const EnvScript = "/etc/enclaude/nix-env.sh"
+
+// SourceDir is the build context directory holding the workspace snapshot
+const SourceDir = "src"
+
+// nixImage provides the Nix store and tools copied into the build stage
+const nixImage = "nixos/nix:latest"
+
+// Detect returns the kind of environment declared in dir. devenv projects
+// may also have a flake.nix, so devenv files take precedence.
+func Detect(dir string) (string, bool) {
+	for _, name := range []string{"devenv.nix", "devenv.yaml"} {
+		if fileExists(filepath.Join(dir, name)) {
+			return Devenv, true
+		}
+	}
+	if fileExists(filepath.Join(dir, "flake.nix")) {
+		return Flake, true
+	}
+	return "", false
+}
+
+// Dockerfile returns a Dockerfile that extends base with the environment of
+// kind. The workspace snapshot in SourceDir is only used in an intermediate
+// stage, so project sources never end up in the image; the final image gets
+// the realized Nix store and EnvScript.
+func Dockerfile(base, kind string) (string, error) {
+	var realize string
+	switch kind {
+	case Flake:
+		realize = "nix print-dev-env /tmp/project > /tmp/nix-env.sh"
+	case Devenv:
+		realize = "cd /tmp/project && nix run nixpkgs#devenv -- print-dev-env > /tmp/nix-env.sh"
+	default:
+		return "", fmt.Errorf("unknown nix environment %q", kind)
+	}
+
+	return fmt.Sprintf(`# Generated by 'enclaude build --nix'
+FROM %[1]s AS nix
+
+FROM %[2]s AS realize
+USER root
+COPY --from=nix /nix /nix
+ENV PATH="/nix/var/nix/profiles/default/bin:$PATH"
+RUN mkdir -p /etc/nix && printf '%%s\n' 'experimental-features = nix-command flakes' 'build-users-group =' 'sandbox = false' > /etc/nix/nix.conf
+COPY %[3]s /tmp/project
+RUN %[4]s
+
+FROM %[2]s
+USER root
+COPY --from=realize /nix /nix
+COPY --from=realize /tmp/nix-env.sh %[5]s
+RUN chmod 644 %[5]s
+LABEL %[6]s="%[7]s"
+USER enclaude
+`, nixImage, base, SourceDir, realize, EnvScript, Label, kind), nil
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
@@ -0,0 +1,87 @@
+package nix
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name   string
+		files  []string
+		want   string
+		wantOK bool
+	}{
+		{"none", []string{"go.mod"}, "", false},
+		{"flake", []string{"flake.nix", "flake.lock"}, Flake, true},
+		{"devenv nix", []string{"devenv.nix"}, Devenv, true},
+		{"devenv yaml", []string{"devenv.yaml"}, Devenv, true},
+		{"devenv with flake", []string{"flake.nix", "devenv.nix"}, Devenv, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, f := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, f), nil, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, ok := Detect(dir)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("Detect() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestDetect_DirectoryNamedLikeFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "flake.nix"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := Detect(dir); ok {
+		t.Error("Detect() matched a directory named flake.nix")
+	}
+}
+
+func TestDockerfile(t *testing.T) {
+	tests := []struct {
+		kind    string
+		want    string
+		wantErr bool
+	}{
+		{Flake, "nix print-dev-env /tmp/project", false},
+		{Devenv, "nix run nixpkgs#devenv -- print-dev-env", false},
+		{"shell", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			got, err := Dockerfile("enclaude:latest", tt.kind)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Dockerfile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			for _, want := range []string{tt.want, "FROM enclaude:latest AS realize", Label + `="` + tt.kind + `"`, EnvScript} {
+				if !strings.Contains(got, want) {
+					t.Errorf("Dockerfile() missing %q:\n%s", want, got)
+				}
+			}
+			// Every line is an instruction: a multi-line value would need
+			// continuation
+			for _, line := range strings.Split(strings.TrimSpace(got), "\n") {
+				if word, _, _ := strings.Cut(line, " "); line != "" && word != "#" && word != strings.ToUpper(word) {
+					t.Errorf("Dockerfile() line %q is not an instruction", line)
+				}
+			}
+			// Project sources stay in the intermediate stage
+			final := got[strings.LastIndex(got, "FROM "):]
+			if strings.Contains(final, "COPY "+SourceDir) {
+				t.Errorf("final stage copies the workspace:\n%s", final)
+			}
+		})
+	}
+}
@@ -0,0 +1,453 @@
+// Package oci pushes and pulls single-file artifacts to and from OCI
+// registries over the distribution API, without a container engine.
+package oci
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Media types of the artifact manifest and its empty config
+const (
+	ManifestMediaType    = "application/vnd.oci.image.manifest.v1+json"
+	EmptyConfigMediaType = "application/vnd.oci.empty.v1+json"
+)
+
+// emptyConfig is the config blob of an artifact that has none
+var emptyConfig = []byte("{}")
+
+// maxArtifactSize bounds manifests and blobs read from a registry
+const maxArtifactSize = 4 << 20
+
+// dockerHub is the registry of references without one, and
+// dockerHubAPI the host that serves its API
+const (
+	dockerHub    = "docker.io"
+	dockerHubAPI = "registry-1.docker.io"
+)
+
+// Reference names an artifact in a registry
+type Reference struct {
+	Registry   string // e.g. ghcr.io or docker.io
+	Repository string // e.g. acme/sandboxes
+	Tag        string
+	Digest     string // Set instead of, or in addition to, Tag
+}
+
+// ParseReference parses a reference such as ghcr.io/acme/sandbox:v1,
+// acme/sandbox@sha256:..., or localhost:5000/sandbox. As in Docker, the first
+// path component names a registry only if it contains a dot or a colon or is
+// localhost, and references without a tag or digest use "latest".
+func ParseReference(s string) (Reference, error) {
+	var ref Reference
+	rest, digest, _ := strings.Cut(s, "@")
+	if digest != "" {
+		if !strings.HasPrefix(digest, "sha256:") {
+			return Reference{}, fmt.Errorf("invalid reference %q: unsupported digest", s)
+		}
+		ref.Digest = digest
+	}
+	if i := strings.LastIndex(rest, ":"); i > strings.LastIndex(rest, "/") {
+		rest, ref.Tag = rest[:i], rest[i+1:]
+	}
+	first, path, found := strings.Cut(rest, "/")
+	if found && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		ref.Registry, ref.Repository = first, path
+	} else {
+		ref.Registry, ref.Repository = dockerHub, rest
+		if !found {
+			ref.Repository = "library/" + rest
+		}
+	}
+	if ref.Repository == "" || ref.Repository != strings.ToLower(ref.Repository) {
+		return Reference{}, fmt.Errorf("invalid reference %q: repository must be lowercase and not empty", s)
+	}
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = "latest"
+	}
+	return ref, nil
+}
+
+// String formats the reference with its registry
+func (r Reference) String() string {
+	s := r.Registry + "/" + r.Repository
+	if r.Tag != "" {
+		s += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		s += "@" + r.Digest
+	}
+	return s
+}
+
+// manifestRef is what a manifest is fetched by: the digest when pinned
+func (r Reference) manifestRef() string {
+	if r.Digest != "" {
+		return r.Digest
+	}
+	return r.Tag
+}
+
+// Descriptor points at a blob
+type Descriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+	Size      int64  `json:"size"`
+}
+
+// Manifest is an OCI image manifest carrying an artifact
+type Manifest struct {
+	SchemaVersion int          `json:"schemaVersion"`
+	MediaType     string       `json:"mediaType"`
+	ArtifactType  string       `json:"artifactType,omitempty"`
+	Config        Descriptor   `json:"config"`
+	Layers        []Descriptor `json:"layers"`
+}
+
+// Client talks to registries. Credentials, when set, returns the login for
+// a registry host; registries are otherwise used anonymously.
+type Client struct {
+	HTTP        *http.Client
+	Credentials func(host string) (user, secret string, ok bool)
+
+	tokens map[string]string // Bearer tokens by registry and scope
+}
+
+// Push uploads data as a single-layer artifact of artifactType to ref and
+// returns the digest of its manifest
+func (c *Client) Push(ctx context.Context, ref Reference, artifactType string, data []byte) (string, error) {
+	if ref.Tag == "" {
+		return "", fmt.Errorf("cannot push %s: a tag is required", ref)
+	}
+	manifest := Manifest{
+		SchemaVersion: 2,
+		MediaType:     ManifestMediaType,
+		ArtifactType:  artifactType,
+		Config:        Descriptor{MediaType: EmptyConfigMediaType, Digest: Digest(emptyConfig), Size: int64(len(emptyConfig))},
+		Layers:        []Descriptor{{MediaType: artifactType, Digest: Digest(data), Size: int64(len(data))}},
+	}
+	for _, blob := range [][]byte{emptyConfig, data} {
+		if err := c.pushBlob(ctx, ref, blob); err != nil {
+			return "", fmt.Errorf("failed to push %s: %w", ref, err)
+		}
+	}
+
+	body, err := json.Marshal(manifest)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, c.url(ref, "manifests/"+ref.Tag), bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", ManifestMediaType)
+	resp, err := c.do(req, ref)
+	if err != nil {
+		return "", fmt.Errorf("failed to push %s: %w", ref, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return "", fmt.Errorf("failed to push %s: registry returned %s for the manifest", ref, resp.Status)
+	}
+	return Digest(body), nil
+}
+
+// pushBlob uploads blob to ref's repository unless the registry already has
+// it, using a monolithic upload
+func (c *Client) pushBlob(ctx context.Context, ref Reference, blob []byte) error {
+	digest := Digest(blob)
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, c.url(ref, "blobs/"+digest), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.do(req, ref)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	req, err = http.NewRequestWithContext(ctx, http.MethodPost, c.url(ref, "blobs/uploads/"), nil)
+	if err != nil {
+		return err
+	}
+	resp, err = c.do(req, ref)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("registry returned %s starting an upload", resp.Status)
+	}
+	location, err := req.URL.Parse(resp.Header.Get("Location"))
+	if err != nil || resp.Header.Get("Location") == "" {
+		return fmt.Errorf("registry returned no upload location")
+	}
+	query := location.Query()
+	query.Set("digest", digest)
+	location.RawQuery = query.Encode()
+
+	req, err = http.NewRequestWithContext(ctx, http.MethodPut, location.String(), bytes.NewReader(blob))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	resp, err = c.do(req, ref)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("registry returned %s uploading %s", resp.Status, digest)
+	}
+	return nil
+}
+
+// Pull downloads the artifact at ref, which must be of artifactType, and
+// returns its content and the digest of its manifest
+func (c *Client) Pull(ctx context.Context, ref Reference, artifactType string) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url(ref, "manifests/"+ref.manifestRef()), nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("Accept", ManifestMediaType)
+	body, err := c.fetch(req, ref)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to pull %s: %w", ref, err)
+	}
+	digest := Digest(body)
+	if ref.Digest != "" && ref.Digest != digest {
+		return nil, "", fmt.Errorf("failed to pull %s: manifest digest is %s", ref, digest)
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(body, &manifest); err != nil {
+		return nil, "", fmt.Errorf("failed to pull %s: invalid manifest: %w", ref, err)
+	}
+	if len(manifest.Layers) != 1 || manifest.Layers[0].MediaType != artifactType {
+		return nil, "", fmt.Errorf("%s is not a %s artifact", ref, artifactType)
+	}
+	layer := manifest.Layers[0]
+
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, c.url(ref, "blobs/"+layer.Digest), nil)
+	if err != nil {
+		return nil, "", err
+	}
+	data, err := c.fetch(req, ref)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to pull %s: %w", ref, err)
+	}
+	if Digest(data) != layer.Digest {
+		return nil, "", fmt.Errorf("failed to pull %s: content does not match digest %s", ref, layer.Digest)
+	}
+	return data, digest, nil
+}
+
+// fetch performs a GET and returns the body of a 200 response
+func (c *Client) fetch(req *http.Request, ref Reference) ([]byte, error) {
+	resp, err := c.do(req, ref)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("registry returned %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxArtifactSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxArtifactSize {
+		return nil, fmt.Errorf("artifact is larger than %d bytes", maxArtifactSize)
+	}
+	return data, nil
+}
+
+// do sends req, answering an authentication challenge once. Credentials
+// are only sent to ref's registry: not to an upload location or redirect on
+// another host, such as the storage a registry hands blobs off to.
+func (c *Client) do(req *http.Request, ref Reference) (*http.Response, error) {
+	client := c.httpClient()
+	registryHost := req.URL.Host == apiHost(ref)
+	// Reuse a token from an earlier challenge for the same scope
+	if token := c.tokens[ref.Registry+" "+scope(ref, req.Method)]; token != "" && registryHost {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := client.Do(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || !registryHost {
+		return resp, err
+	}
+	resp.Body.Close()
+
+	auth, err := c.authorize(req.Context(), ref, req.Method, resp.Header.Get("WWW-Authenticate"))
+	if err != nil {
+		return nil, err
+	}
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	retry.Header.Set("Authorization", auth)
+	return client.Do(retry)
+}
+
+// authorize answers a Basic or Bearer challenge with the registry login
+func (c *Client) authorize(ctx context.Context, ref Reference, method, challenge string) (string, error) {
+	user, secret, ok := "", "", false
+	if c.Credentials != nil {
+		user, secret, ok = c.Credentials(ref.Registry)
+	}
+	kind, params := parseChallenge(challenge)
+	switch kind {
+	case "basic":
+		if !ok {
+			return "", fmt.Errorf("%s requires a login; run 'docker login %s'", ref.Registry, ref.Registry)
+		}
+		req := &http.Request{Header: http.Header{}}
+		req.SetBasicAuth(user, secret)
+		return req.Header.Get("Authorization"), nil
+	case "bearer":
+	default:
+		return "", fmt.Errorf("%s returned an unsupported authentication challenge", ref.Registry)
+	}
+
+	realm, err := url.Parse(params["realm"])
+	if err != nil || realm.Scheme == "" {
+		return "", fmt.Errorf("%s returned an invalid token realm", ref.Registry)
+	}
+	query := realm.Query()
+	if params["service"] != "" {
+		query.Set("service", params["service"])
+	}
+	tokenScope := params["scope"]
+	if tokenScope == "" {
+		tokenScope = scope(ref, method)
+	}
+	query.Set("scope", tokenScope)
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if ok {
+		req.SetBasicAuth(user, secret)
+	}
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to get a registry token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		if !ok {
+			return "", fmt.Errorf("%s denied anonymous access (%s); run 'docker login %s'", ref.Registry, resp.Status, ref.Registry)
+		}
+		return "", fmt.Errorf("%s denied a token: %s", ref.Registry, resp.Status)
+	}
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to parse registry token: %w", err)
+	}
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+	if token.Token == "" {
+		return "", fmt.Errorf("%s returned an empty token", ref.Registry)
+	}
+	if c.tokens == nil {
+		c.tokens = make(map[string]string)
+	}
+	c.tokens[ref.Registry+" "+scope(ref, method)] = token.Token
+	return "Bearer " + token.Token, nil
+}
+
+// scope is the token scope a request method needs on ref's repository
+func scope(ref Reference, method string) string {
+	if method == http.MethodGet || method == http.MethodHead {
+		return "repository:" + ref.Repository + ":pull"
+	}
+	return "repository:" + ref.Repository + ":pull,push"
+}
+
+// parseChallenge splits a WWW-Authenticate header such as
+// `Bearer realm="https://auth.example.com/token",service="registry"` into
+// its lowercased scheme and parameters
+func parseChallenge(header string) (string, map[string]string) {
+	kind, rest, _ := strings.Cut(strings.TrimSpace(header), " ")
+	params := make(map[string]string)
+	for rest != "" {
+		var key, value string
+		key, rest, _ = strings.Cut(strings.TrimLeft(rest, " ,"), "=")
+		if strings.HasPrefix(rest, `"`) {
+			value, rest, _ = strings.Cut(rest[1:], `"`)
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+		}
+		if key = strings.TrimSpace(key); key != "" {
+			params[strings.ToLower(key)] = value
+		}
+	}
+	return strings.ToLower(kind), params
+}
+
+// url builds the distribution API URL of path in ref's repository.
+// Registries on localhost are spoken to over plain HTTP, as Docker allows.
+func (c *Client) url(ref Reference, path string) string {
+	host := apiHost(ref)
+	scheme := "https"
+	if name, _, _ := strings.Cut(host, ":"); name == "localhost" || name == "127.0.0.1" {
+		scheme = "http"
+	}
+	return scheme + "://" + host + "/v2/" + ref.Repository + "/" + path
+}
+
+// apiHost is the host that serves the API of ref's registry
+func apiHost(ref Reference) string {
+	if ref.Registry == dockerHub {
+		return dockerHubAPI
+	}
+	return ref.Registry
+}
+
+// httpClient returns c.HTTP, or the default client, set to drop the
+// Authorization header when a redirect leaves the host it was sent to
+func (c *Client) httpClient() *http.Client {
+	var client http.Client
+	if c.HTTP != nil {
+		client = *c.HTTP
+	}
+	checkRedirect := client.CheckRedirect
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if req.URL.Host != via[0].URL.Host {
+			req.Header.Del("Authorization")
+		}
+		if checkRedirect != nil {
+			return checkRedirect(req, via)
+		}
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+	return &client
+}
+
+// Digest returns the sha256 digest of data in OCI form
+func Digest(data []byte) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(data))
+}
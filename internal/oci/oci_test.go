@@ -0,0 +1,223 @@
+package oci
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Reference
+	}{
+		{"acme/approved:v1", Reference{Registry: "docker.io", Repository: "acme/approved", Tag: "v1"}},
+		{"sandbox", Reference{Registry: "docker.io", Repository: "library/sandbox", Tag: "latest"}},
+		{"ghcr.io/acme/sandboxes/go:v2", Reference{Registry: "ghcr.io", Repository: "acme/sandboxes/go", Tag: "v2"}},
+		{"localhost:5000/sandbox", Reference{Registry: "localhost:5000", Repository: "sandbox", Tag: "latest"}},
+		{"ghcr.io/acme/sandbox@sha256:abc", Reference{Registry: "ghcr.io", Repository: "acme/sandbox", Digest: "sha256:abc"}},
+	}
+	for _, tt := range tests {
+		got, err := ParseReference(tt.in)
+		if err != nil {
+			t.Errorf("ParseReference(%q) error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseReference(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"Acme/Sandbox:v1", "ghcr.io/", "acme/sandbox@md5:abc"} {
+		if _, err := ParseReference(bad); err == nil {
+			t.Errorf("ParseReference(%q) should fail", bad)
+		}
+	}
+}
+
+func TestParseChallenge(t *testing.T) {
+	kind, params := parseChallenge(`Bearer realm="https://auth.example.com/token",service="registry.example.com",scope="repository:acme/a:pull,push"`)
+	want := map[string]string{
+		"realm":   "https://auth.example.com/token",
+		"service": "registry.example.com",
+		"scope":   "repository:acme/a:pull,push",
+	}
+	if kind != "bearer" || !reflect.DeepEqual(params, want) {
+		t.Errorf("parseChallenge() = %q, %v", kind, params)
+	}
+}
+
+// registry is an in-memory distribution API that requires a bearer token
+type registry struct {
+	mu        sync.Mutex
+	blobs     map[string][]byte
+	manifests map[string][]byte
+}
+
+func newRegistry(t *testing.T) *httptest.Server {
+	r := &registry{blobs: map[string][]byte{}, manifests: map[string][]byte{}}
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/token" {
+			if user, pass, ok := req.BasicAuth(); !ok || user != "dev" || pass != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			io.WriteString(w, `{"token":"t0ken"}`)
+			return
+		}
+		if req.Header.Get("Authorization") != "Bearer t0ken" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+srv.URL+`/token",service="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		path := strings.TrimPrefix(req.URL.Path, "/v2/acme/sandbox/")
+		body, _ := io.ReadAll(req.Body)
+		switch {
+		case req.Method == http.MethodPost && path == "blobs/uploads/":
+			w.Header().Set("Location", "/v2/acme/sandbox/blobs/uploads/1")
+			w.WriteHeader(http.StatusAccepted)
+		case req.Method == http.MethodPut && path == "blobs/uploads/1":
+			digest := req.URL.Query().Get("digest")
+			if Digest(body) != digest {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			r.blobs[digest] = body
+			w.WriteHeader(http.StatusCreated)
+		case strings.HasPrefix(path, "blobs/"):
+			blob, ok := r.blobs[strings.TrimPrefix(path, "blobs/")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write(blob)
+		case req.Method == http.MethodPut && strings.HasPrefix(path, "manifests/"):
+			r.manifests[strings.TrimPrefix(path, "manifests/")] = body
+			r.manifests[Digest(body)] = body
+			w.WriteHeader(http.StatusCreated)
+		case strings.HasPrefix(path, "manifests/"):
+			manifest, ok := r.manifests[strings.TrimPrefix(path, "manifests/")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write(manifest)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestPushPull(t *testing.T) {
+	srv := newRegistry(t)
+	host := strings.TrimPrefix(srv.URL, "http://")
+	ref, err := ParseReference(host + "/acme/sandbox:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const artifactType = "application/vnd.example.test.v1+json"
+	data := []byte(`{"hello":"world"}`)
+	ctx := context.Background()
+
+	anonymous := &Client{}
+	if _, err := anonymous.Push(ctx, ref, artifactType, data); err == nil || !strings.Contains(err.Error(), "docker login") {
+		t.Errorf("anonymous Push() error = %v, want a login hint", err)
+	}
+
+	client := &Client{Credentials: func(h string) (string, string, bool) { return "dev", "secret", h == host }}
+	digest, err := client.Push(ctx, ref, artifactType, data)
+	if err != nil {
+		t.Fatalf("Push() error: %v", err)
+	}
+
+	got, gotDigest, err := client.Pull(ctx, ref, artifactType)
+	if err != nil {
+		t.Fatalf("Pull() error: %v", err)
+	}
+	if string(got) != string(data) || gotDigest != digest {
+		t.Errorf("Pull() = %q, %s; want %q, %s", got, gotDigest, data, digest)
+	}
+
+	pinned := ref
+	pinned.Tag, pinned.Digest = "", digest
+	if _, _, err := client.Pull(ctx, pinned, artifactType); err != nil {
+		t.Errorf("Pull() by digest error: %v", err)
+	}
+	if _, _, err := client.Pull(ctx, ref, "application/vnd.example.other+json"); err == nil {
+		t.Error("Pull() of a different artifact type should fail")
+	}
+}
+
+func TestCredentialsStayOnRegistry(t *testing.T) {
+	var mu sync.Mutex
+	var leaked []string
+	storage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		if auth := req.Header.Get("Authorization"); auth != "" {
+			leaked = append(leaked, req.Method+" "+auth)
+		}
+		mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+	}))
+	t.Cleanup(storage.Close)
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/token" {
+			io.WriteString(w, `{"token":"t0ken"}`)
+			return
+		}
+		if req.Header.Get("Authorization") != "Bearer t0ken" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+srv.URL+`/token"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case req.Method == http.MethodHead:
+			w.WriteHeader(http.StatusNotFound)
+		case req.Method == http.MethodPost:
+			w.Header().Set("Location", storage.URL+"/upload")
+			w.WriteHeader(http.StatusAccepted)
+		case req.Method == http.MethodGet:
+			http.Redirect(w, req, storage.URL+"/blob", http.StatusTemporaryRedirect)
+		default:
+			w.WriteHeader(http.StatusCreated)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	ref, err := ParseReference(strings.TrimPrefix(srv.URL, "http://") + "/acme/sandbox:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &Client{}
+	if _, err := client.Push(context.Background(), ref, "application/vnd.example.test.v1+json", []byte("{}")); err != nil {
+		t.Fatalf("Push() error: %v", err)
+	}
+	req, err := http.NewRequest(http.MethodGet, client.url(ref, "blobs/sha256:abc"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.do(req, ref)
+	if err != nil {
+		t.Fatalf("do() error: %v", err)
+	}
+	resp.Body.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(leaked) > 0 {
+		t.Errorf("credentials sent to another host: %v", leaked)
+	}
+}
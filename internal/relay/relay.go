@@ -0,0 +1,136 @@
+// Package relay passes connections accepted on a host listener to a
+// connection dialed afresh for each, for the proxies that expose host
+// sockets and ports to the container.
+package relay
+
+import (
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// DialFunc connects to the host side of a relayed connection
+type DialFunc func() (net.Conn, error)
+
+// DoneFunc is called when a relayed connection closes, with the bytes sent
+// to and received from the host side and any error dialing it
+type DoneFunc func(sent, received int64, duration time.Duration, err error)
+
+// Server relays the connections accepted on its listeners. The zero value
+// is ready to use.
+type Server struct {
+	wg sync.WaitGroup
+
+	mu        sync.Mutex
+	listeners []net.Listener
+	conns     map[net.Conn]bool
+	closed    bool
+}
+
+// Serve accepts connections on l until Close, relaying each to a connection
+// from dial. done may be nil.
+func (s *Server) Serve(l net.Listener, dial DialFunc, done DoneFunc) {
+	s.mu.Lock()
+	s.listeners = append(s.listeners, l)
+	if s.closed {
+		l.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			s.wg.Add(1)
+			go func() {
+				defer s.wg.Done()
+				s.relay(conn, dial, done)
+			}()
+		}
+	}()
+}
+
+// Close stops accepting connections, ends those in progress, and waits for
+// them. It does not remove the listeners' sockets.
+func (s *Server) Close() {
+	s.mu.Lock()
+	s.closed = true
+	for _, l := range s.listeners {
+		l.Close()
+	}
+	for c := range s.conns {
+		c.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+// track records c as open or closed, so Close can end it. A connection
+// opened once Close has started is closed at once.
+func (s *Server) track(c net.Conn, open bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conns == nil {
+		s.conns = make(map[net.Conn]bool)
+	}
+	switch {
+	case open && s.closed:
+		c.Close()
+	case open:
+		s.conns[c] = true
+	default:
+		delete(s.conns, c)
+	}
+}
+
+func (s *Server) relay(client net.Conn, dial DialFunc, done DoneFunc) {
+	started := time.Now()
+	s.track(client, true)
+	defer s.track(client, false)
+	defer client.Close()
+
+	host, err := dial()
+	if err != nil {
+		if done != nil {
+			done(0, 0, time.Since(started), err)
+		}
+		return
+	}
+	s.track(host, true)
+	defer s.track(host, false)
+	defer host.Close()
+
+	sent, received := Pipe(client, host)
+	if done != nil {
+		done(sent, received, time.Since(started), nil)
+	}
+}
+
+// Pipe copies between client and host until both directions end, returning
+// the bytes sent to and received from host. When one side stops sending,
+// the other's write half is closed, for any connection type that supports
+// it, so request-response protocols see the end of the request.
+func Pipe(client, host net.Conn) (sent, received int64) {
+	finished := make(chan struct{})
+	go func() {
+		sent, _ = io.Copy(host, client)
+		closeWrite(host)
+		close(finished)
+	}()
+	received, _ = io.Copy(client, host)
+	closeWrite(client)
+	<-finished
+	return sent, received
+}
+
+// closeWrite half-closes c if it supports it, as TCP and Unix connections do
+func closeWrite(c net.Conn) {
+	if hc, ok := c.(interface{ CloseWrite() error }); ok {
+		hc.CloseWrite()
+	}
+}
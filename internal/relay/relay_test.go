@@ -0,0 +1,128 @@
+package relay
+
+import (
+	"io"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// echoListener echoes each connection on a listener of the given network
+func echoListener(t *testing.T, network, address string) net.Listener {
+	t.Helper()
+	l, err := net.Listen(network, address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				io.Copy(conn, conn)
+				conn.Close()
+			}()
+		}
+	}()
+	return l
+}
+
+func TestServer(t *testing.T) {
+	// The host side is reached over TCP and over a Unix socket; both must
+	// see the end of the request for the echo to finish
+	hosts := map[string]net.Listener{
+		"tcp":  echoListener(t, "tcp", "127.0.0.1:0"),
+		"unix": echoListener(t, "unix", filepath.Join(t.TempDir(), "host.sock")),
+	}
+	for network, host := range hosts {
+		t.Run(network, func(t *testing.T) {
+			type result struct {
+				sent, received int64
+				err            error
+			}
+			done := make(chan result, 1)
+
+			var s Server
+			l, err := net.Listen("unix", filepath.Join(t.TempDir(), "relay.sock"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			s.Serve(l, func() (net.Conn, error) {
+				return net.Dial(network, host.Addr().String())
+			}, func(sent, received int64, _ time.Duration, err error) {
+				done <- result{sent, received, err}
+			})
+			defer s.Close()
+
+			conn, err := net.Dial("unix", l.Addr().String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			conn.Write([]byte("ping"))
+			conn.(*net.UnixConn).CloseWrite()
+			reply, _ := io.ReadAll(conn)
+			conn.Close()
+			if string(reply) != "ping" {
+				t.Errorf("reply = %q, want ping", reply)
+			}
+
+			select {
+			case r := <-done:
+				if r.sent != 4 || r.received != 4 || r.err != nil {
+					t.Errorf("done with %+v, want 4 bytes each way", r)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("connection did not finish")
+			}
+		})
+	}
+}
+
+func TestServerClose(t *testing.T) {
+	// A host that never answers must not keep Close waiting
+	host, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer host.Close()
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		if conn, err := host.Accept(); err == nil {
+			accepted <- conn
+		}
+	}()
+
+	var s Server
+	l, err := net.Listen("unix", filepath.Join(t.TempDir(), "relay.sock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Serve(l, func() (net.Conn, error) { return net.Dial("tcp", host.Addr().String()) }, nil)
+
+	conn, err := net.Dial("unix", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	select {
+	case c := <-accepted:
+		defer c.Close()
+	case <-time.After(5 * time.Second):
+		t.Fatal("host was not dialed")
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		s.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close() did not end the connection in progress")
+	}
+}
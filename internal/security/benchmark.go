@@ -0,0 +1,446 @@
+package security
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jakenelson/enclaude/internal/config"
+)
+
+// Benchmark categories, in report order
+const (
+	CategoryNetwork     = "Network exposure"
+	CategoryCredentials = "Credential breadth"
+	CategoryMounts      = "Writable mounts"
+	CategoryImage       = "Image provenance"
+	CategoryIsolation   = "Container isolation"
+)
+
+var categories = []string{CategoryNetwork, CategoryCredentials, CategoryMounts, CategoryImage, CategoryIsolation}
+
+// Check severities, which weight a check in the score
+const (
+	SeverityHigh   = "high"
+	SeverityMedium = "medium"
+	SeverityLow    = "low"
+)
+
+var severityWeight = map[string]int{SeverityHigh: 3, SeverityMedium: 2, SeverityLow: 1}
+
+// secretName matches environment variable names that usually hold secrets
+var secretName = regexp.MustCompile(`(?i)(TOKEN|SECRET|PASSWORD|PASSWD|API_?KEY|ACCESS_?KEY|PRIVATE_?KEY|CREDENTIALS?)`)
+
+// Finding is the outcome of one benchmark check
+type Finding struct {
+	ID             string `json:"id"`
+	Category       string `json:"category"`
+	Severity       string `json:"severity"`
+	Passed         bool   `json:"passed"`
+	Title          string `json:"title"`
+	Detail         string `json:"detail,omitempty"`
+	Recommendation string `json:"recommendation,omitempty"`
+}
+
+// CategoryScore is the score of the checks in one category
+type CategoryScore struct {
+	Name   string `json:"name"`
+	Score  int    `json:"score"` // 0-100
+	Passed int    `json:"passed"`
+	Total  int    `json:"total"`
+}
+
+// Report is the result of evaluating a configuration against the benchmark
+type Report struct {
+	Score      int             `json:"score"` // 0-100, weighted by severity
+	Categories []CategoryScore `json:"categories"`
+	Findings   []Finding       `json:"findings"`
+}
+
+// Failed returns the failed findings, most severe first
+func (r Report) Failed() []Finding {
+	var failed []Finding
+	for _, f := range r.Findings {
+		if !f.Passed {
+			failed = append(failed, f)
+		}
+	}
+	sort.SliceStable(failed, func(i, j int) bool {
+		return severityWeight[failed[i].Severity] > severityWeight[failed[j].Severity]
+	})
+	return failed
+}
+
+// Evaluate scores cfg against the built-in benchmark. It looks only at the
+// configuration, so it describes what any session started with it would get.
+func Evaluate(cfg *config.Config) Report {
+	var findings []Finding
+	findings = append(findings, networkChecks(cfg)...)
+	findings = append(findings, credentialChecks(cfg)...)
+	findings = append(findings, mountChecks(cfg)...)
+	findings = append(findings, imageChecks(cfg)...)
+	findings = append(findings, isolationChecks(cfg)...)
+	return score(findings)
+}
+
+func score(findings []Finding) Report {
+	report := Report{Findings: findings}
+	var passed, total int
+	for _, name := range categories {
+		c := CategoryScore{Name: name}
+		var cPassed, cTotal int
+		for _, f := range findings {
+			if f.Category != name {
+				continue
+			}
+			w := severityWeight[f.Severity]
+			c.Total++
+			cTotal += w
+			if f.Passed {
+				c.Passed++
+				cPassed += w
+			}
+		}
+		if cTotal == 0 {
+			continue
+		}
+		c.Score = cPassed * 100 / cTotal
+		report.Categories = append(report.Categories, c)
+		passed += cPassed
+		total += cTotal
+	}
+	if total > 0 {
+		report.Score = passed * 100 / total
+	}
+	return report
+}
+
+func networkChecks(cfg *config.Config) []Finding {
+	c := cfg.Container
+	findings := []Finding{{
+		ID:             "network-host",
+		Category:       CategoryNetwork,
+		Severity:       SeverityHigh,
+		Passed:         c.Network != config.NetworkHost,
+		Title:          "Session does not share the host network",
+		Detail:         "container.network is host, so the session can reach every service listening on the host, including ones bound to localhost.",
+		Recommendation: "Set container.network to bridge, or to a user-defined network for the services the session needs.",
+	}}
+
+	var exposed []string
+	for _, p := range c.Ports {
+		if !localPort(p) {
+			exposed = append(exposed, p)
+		}
+	}
+	findings = append(findings, Finding{
+		ID:             "network-ports",
+		Category:       CategoryNetwork,
+		Severity:       SeverityMedium,
+		Passed:         len(exposed) == 0,
+		Title:          "No ports are published on all interfaces",
+		Detail:         "Published on every host interface: " + strings.Join(exposed, ", "),
+		Recommendation: "Bind published ports to localhost in container.ports, e.g. \"127.0.0.1:8080:8080\".",
+	})
+
+	findings = append(findings, Finding{
+		ID:             "network-telemetry",
+		Category:       CategoryNetwork,
+		Severity:       SeverityLow,
+		Passed:         cfg.Claude.DisableTelemetry,
+		Title:          "Telemetry and error reporting are blocked",
+		Detail:         "Claude Code may send telemetry and error reports from the session.",
+		Recommendation: "Set claude.disable_telemetry: true.",
+	})
+
+	hostCommands := cfg.HostCommands.Enabled && len(cfg.HostCommands.Allow) > 0
+	findings = append(findings, Finding{
+		ID:             "network-host-commands",
+		Category:       CategoryNetwork,
+		Severity:       SeverityMedium,
+		Passed:         !hostCommands,
+		Title:          "The session cannot run commands on the host",
+		Detail:         "host_commands lets the session run these commands on the host: " + strings.Join(cfg.HostCommands.Allow, ", "),
+		Recommendation: "Disable host_commands, or allow only specific subcommands such as \"gh auth token\".",
+	})
+	return findings
+}
+
+// localPort reports whether a port mapping publishes only on a loopback address
+func localPort(mapping string) bool {
+	host := ""
+	if strings.HasPrefix(mapping, "[") {
+		end := strings.Index(mapping, "]")
+		if end < 0 {
+			return false
+		}
+		host = mapping[1:end]
+	} else if parts := strings.Split(mapping, ":"); len(parts) == 3 {
+		host = parts[0]
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func credentialChecks(cfg *config.Config) []Finding {
+	cr := cfg.Credentials
+	passedThrough := func(setting string) bool {
+		return setting != config.CredentialDisabled && setting != config.CredentialApp
+	}
+
+	findings := []Finding{{
+		ID:             "credentials-github",
+		Category:       CategoryCredentials,
+		Severity:       SeverityMedium,
+		Passed:         !passedThrough(cr.GitHub),
+		Title:          "GitHub access is scoped or withheld",
+		Detail:         fmt.Sprintf("credentials.github is %s, so your GitHub token, with access to every repository you can reach, is passed to the session.", cr.GitHub),
+		Recommendation: "Set credentials.github: app for repository-scoped tokens, or disabled.",
+	}}
+
+	var cloud []string
+	for _, c := range []struct{ name, setting string }{{"gcloud", cr.GCloud}, {"bitbucket", cr.Bitbucket}, {"azdo", cr.AzDO}} {
+		if passedThrough(c.setting) {
+			cloud = append(cloud, c.name)
+		}
+	}
+	findings = append(findings, Finding{
+		ID:             "credentials-services",
+		Category:       CategoryCredentials,
+		Severity:       SeverityMedium,
+		Passed:         len(cloud) == 0,
+		Title:          "Only needed service credentials are passed through",
+		Detail:         "Passed through when present on the host: " + strings.Join(cloud, ", "),
+		Recommendation: "Set the credentials the project does not need to disabled.",
+	})
+
+	ssh := cr.SSH
+	findings = append(findings, Finding{
+		ID:             "credentials-ssh-agent",
+		Category:       CategoryCredentials,
+		Severity:       SeverityHigh,
+		Passed:         !ssh.Enabled || !ssh.AgentForwarding,
+		Title:          "SSH agent is not forwarded",
+		Detail:         "SSH agent forwarding lets the session sign with every key loaded in your agent.",
+		Recommendation: "Set credentials.ssh.agent_forwarding: false and list a deploy key in credentials.ssh.keys.",
+	})
+
+	gpg := cr.GPG
+	findings = append(findings, Finding{
+		ID:             "credentials-gpg-agent",
+		Category:       CategoryCredentials,
+		Severity:       SeverityMedium,
+		Passed:         !gpg.Enabled || !gpg.AgentForwarding,
+		Title:          "GPG agent is not forwarded",
+		Detail:         "GPG agent forwarding lets the session sign and decrypt with your unlocked keys, though never export them.",
+		Recommendation: "Set credentials.gpg.enabled: false unless the project requires signed commits.",
+	})
+
+	findings = append(findings, Finding{
+		ID:             "credentials-ttl",
+		Category:       CategoryCredentials,
+		Severity:       SeverityLow,
+		Passed:         cr.TTL != "",
+		Title:          "Credentials expire",
+		Detail:         "Credentials stay valid in the session for as long as it runs.",
+		Recommendation: "Set credentials.ttl, e.g. \"30m\".",
+	})
+
+	findings = append(findings, Finding{
+		ID:             "credentials-api-key",
+		Category:       CategoryCredentials,
+		Severity:       SeverityMedium,
+		Passed:         cfg.Claude.Secretless || cfg.Claude.Auth == config.AuthSession || cfg.Claude.Provider == config.ProviderBedrock || cfg.Claude.Provider == config.ProviderVertex,
+		Title:          "Anthropic API key stays on the host",
+		Detail:         "ANTHROPIC_API_KEY, when set, is passed into the session, where any code Claude runs can read it.",
+		Recommendation: "Set claude.secretless: true to give the session a placeholder key and add the real one on the host.",
+	})
+
+	findings = append(findings, Finding{
+		ID:             "credentials-session-dir",
+		Category:       CategoryCredentials,
+		Severity:       SeverityMedium,
+		Passed:         cfg.Claude.SessionDir != config.SessionReadWrite,
+		Title:          "Claude session directory is not writable",
+		Detail:         "claude.session_dir is readwrite, so the session can change your Claude login, settings, and hooks on the host.",
+		Recommendation: "Set claude.session_dir: readonly.",
+	})
+
+	var secrets []string
+	for _, name := range cfg.Environment.Passthrough {
+		if secretName.MatchString(name) {
+			secrets = append(secrets, name)
+		}
+	}
+	for name := range cfg.Environment.Custom {
+		if secretName.MatchString(name) {
+			secrets = append(secrets, name)
+		}
+	}
+	sort.Strings(secrets)
+	findings = append(findings, Finding{
+		ID:             "credentials-environment",
+		Category:       CategoryCredentials,
+		Severity:       SeverityMedium,
+		Passed:         len(secrets) == 0,
+		Title:          "No secrets are passed as environment variables",
+		Detail:         "These look like secrets: " + strings.Join(secrets, ", "),
+		Recommendation: "Remove them from environment.passthrough and environment.custom, or pass a narrower token.",
+	})
+
+	var extra []string
+	for _, f := range cr.ExtraFiles {
+		extra = append(extra, f.Source)
+	}
+	findings = append(findings, Finding{
+		ID:             "credentials-extra-files",
+		Category:       CategoryCredentials,
+		Severity:       SeverityLow,
+		Passed:         len(extra) == 0,
+		Title:          "No extra credential files are mounted",
+		Detail:         "Mounted read-only: " + strings.Join(extra, ", "),
+		Recommendation: "Remove entries from credentials.extra_files the project does not need.",
+	})
+	return findings
+}
+
+func mountChecks(cfg *config.Config) []Finding {
+	home := "~"
+	if h, err := ExpandPath("~"); err == nil {
+		home = h
+	}
+
+	var writable, broad []string
+	for _, m := range cfg.Mounts.Defaults {
+		if m.ReadOnly {
+			continue
+		}
+		writable = append(writable, m.Path)
+		if p, err := ExpandPath(m.Path); err == nil && (p == "/" || p == home) {
+			broad = append(broad, m.Path)
+		}
+	}
+
+	findings := []Finding{{
+		ID:             "mounts-broad",
+		Category:       CategoryMounts,
+		Severity:       SeverityHigh,
+		Passed:         len(broad) == 0,
+		Title:          "Home and root directories are not mounted writable",
+		Detail:         "Mounted read-write in every session: " + strings.Join(broad, ", "),
+		Recommendation: "Mount only the directories the project needs, read-only where possible.",
+	}, {
+		ID:             "mounts-defaults",
+		Category:       CategoryMounts,
+		Severity:       SeverityMedium,
+		Passed:         len(writable) == 0,
+		Title:          "Default mounts are read-only",
+		Detail:         "Mounted read-write in every session: " + strings.Join(writable, ", "),
+		Recommendation: "Set readonly: true on mounts.defaults entries the session only reads.",
+	}, {
+		ID:             "mounts-workspace",
+		Category:       CategoryMounts,
+		Severity:       SeverityLow,
+		Passed:         cfg.Workspace.Mode == config.WorkspaceCopy,
+		Title:          "Workspace is a disposable copy",
+		Detail:         "workspace.mode is bind, so changes in the session are made directly to your files.",
+		Recommendation: "Use workspace.mode: copy (or --workspace-mode copy) for untrusted tasks.",
+	}, {
+		ID:             "mounts-gitdir",
+		Category:       CategoryMounts,
+		Severity:       SeverityLow,
+		Passed:         !cfg.Git.MountGitDir,
+		Title:          "Git metadata outside the workspace is not mounted",
+		Detail:         "git.mount_gitdir mounts the repository's shared .git directory read-write, including its hooks.",
+		Recommendation: "Set git.mount_gitdir: false unless Claude needs to commit from a worktree.",
+	}}
+	return findings
+}
+
+func imageChecks(cfg *config.Config) []Finding {
+	image := cfg.Image.Name
+	// Images built locally by enclaude build have no repository path
+	registry := strings.Contains(image, "/")
+	pinned := strings.Contains(image, "@sha256:")
+
+	findings := []Finding{{
+		ID:             "image-pinned",
+		Category:       CategoryImage,
+		Severity:       SeverityMedium,
+		Passed:         !registry || pinned,
+		Title:          "Registry image is pinned by digest",
+		Detail:         image + " is pulled by tag, which can be moved to a different image.",
+		Recommendation: "Set image.name to the digest printed by 'enclaude build --push', e.g. ghcr.io/acme/enclaude@sha256:...",
+	}}
+
+	tag := ""
+	if name, _, _ := strings.Cut(image, "@"); name != "" {
+		if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+			tag = name[i+1:]
+		}
+	}
+	findings = append(findings, Finding{
+		ID:             "image-tag",
+		Category:       CategoryImage,
+		Severity:       SeverityLow,
+		Passed:         !registry || pinned || (tag != "" && tag != "latest"),
+		Title:          "Registry image uses a versioned tag",
+		Detail:         image + " follows latest, so sessions change image without notice.",
+		Recommendation: "Use a versioned tag, or better, a digest.",
+	})
+	return findings
+}
+
+func isolationChecks(cfg *config.Config) []Finding {
+	sec := cfg.Security
+	limits, err := cfg.Container.Resources()
+	limited := err == nil && limits.Memory != "" && limits.PidsLimit > 0
+	user := cfg.Container.User
+
+	return []Finding{{
+		ID:             "isolation-capabilities",
+		Category:       CategoryIsolation,
+		Severity:       SeverityHigh,
+		Passed:         sec.DropCapabilities,
+		Title:          "Linux capabilities are dropped",
+		Detail:         "security.drop_capabilities is false, so the session keeps Docker's default capabilities.",
+		Recommendation: "Set security.drop_capabilities: true.",
+	}, {
+		ID:             "isolation-no-new-privileges",
+		Category:       CategoryIsolation,
+		Severity:       SeverityHigh,
+		Passed:         sec.NoNewPrivileges,
+		Title:          "Processes cannot gain privileges",
+		Detail:         "security.no_new_privileges is false, so setuid binaries in the image can raise privileges.",
+		Recommendation: "Set security.no_new_privileges: true.",
+	}, {
+		ID:             "isolation-read-only-root",
+		Category:       CategoryIsolation,
+		Severity:       SeverityMedium,
+		Passed:         sec.ReadOnlyRoot,
+		Title:          "Root filesystem is read-only",
+		Detail:         "security.read_only_root is false, so the session can modify the image's tools.",
+		Recommendation: "Set security.read_only_root: true.",
+	}, {
+		ID:             "isolation-user",
+		Category:       CategoryIsolation,
+		Severity:       SeverityMedium,
+		Passed:         user != "root" && user != "0" && !strings.HasPrefix(user, "0:"),
+		Title:          "Session does not run as root",
+		Detail:         "container.user is " + user + ".",
+		Recommendation: "Set container.user: auto to run as your own uid.",
+	}, {
+		ID:             "isolation-resources",
+		Category:       CategoryIsolation,
+		Severity:       SeverityLow,
+		Passed:         limited,
+		Title:          "Memory and process counts are limited",
+		Detail:         "Without memory and process limits, a runaway build or fork bomb can exhaust the host.",
+		Recommendation: "Set container.preset to small, medium, or large.",
+	}}
+}
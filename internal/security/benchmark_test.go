@@ -0,0 +1,82 @@
+package security
+
+import (
+	"testing"
+
+	"github.com/jakenelson/enclaude/internal/config"
+)
+
+// hardenedConfig passes every benchmark check
+func hardenedConfig() *config.Config {
+	return &config.Config{
+		Image:  config.ImageConfig{Name: "enclaude:latest"},
+		Claude: config.ClaudeConfig{SessionDir: config.SessionReadOnly, DisableTelemetry: true, Secretless: true},
+		Credentials: config.CredentialsConfig{
+			GitHub:    config.CredentialApp,
+			GCloud:    config.CredentialDisabled,
+			Bitbucket: config.CredentialDisabled,
+			AzDO:      config.CredentialDisabled,
+			TTL:       "30m",
+		},
+		Environment: config.EnvironmentConfig{Passthrough: []string{"TERM", "EDITOR"}},
+		Container:   config.ContainerConfig{User: config.UserAuto, Preset: config.PresetMedium, Network: config.NetworkBridge, Ports: []string{"127.0.0.1:8080:8080", "[::1]:9090:90"}},
+		Security:    config.SecurityConfig{DropCapabilities: true, NoNewPrivileges: true, ReadOnlyRoot: true},
+		Workspace:   config.WorkspaceConfig{Mode: config.WorkspaceCopy},
+	}
+}
+
+func TestEvaluateHardened(t *testing.T) {
+	report := Evaluate(hardenedConfig())
+	if report.Score != 100 {
+		t.Errorf("Score = %d, want 100; failed: %+v", report.Score, report.Failed())
+	}
+	if len(report.Categories) != len(categories) {
+		t.Errorf("got %d categories, want %d", len(report.Categories), len(categories))
+	}
+}
+
+func TestEvaluateFindings(t *testing.T) {
+	cfg := hardenedConfig()
+	cfg.Container.Network = config.NetworkHost
+	cfg.Container.Ports = []string{"8080:8080", "0.0.0.0:9090:90", "127.0.0.1:3000:3000"}
+	cfg.Credentials.GitHub = config.CredentialAuto
+	cfg.Environment.Custom = map[string]string{"NPM_TOKEN": "x"}
+	cfg.Image.Name = "ghcr.io/acme/enclaude:latest"
+
+	report := Evaluate(cfg)
+	failed := make(map[string]Finding)
+	for _, f := range report.Failed() {
+		failed[f.ID] = f
+	}
+	for _, id := range []string{"network-host", "network-ports", "credentials-github", "credentials-environment", "image-pinned", "image-tag"} {
+		if _, ok := failed[id]; !ok {
+			t.Errorf("check %s passed, want failed", id)
+		}
+	}
+	if len(failed) != 6 {
+		t.Errorf("got %d failed checks, want 6: %+v", len(failed), failed)
+	}
+	if got := failed["network-ports"].Detail; got != "Published on every host interface: 8080:8080, 0.0.0.0:9090:90" {
+		t.Errorf("network-ports detail = %q", got)
+	}
+	if first := report.Failed()[0]; first.Severity != SeverityHigh {
+		t.Errorf("first recommendation is %s, want most severe first", first.Severity)
+	}
+
+	// Network: host (3) and ports (2) failed of 3+2+1+2
+	for _, c := range report.Categories {
+		if c.Name == CategoryNetwork && c.Score != 37 {
+			t.Errorf("%s score = %d, want 37", c.Name, c.Score)
+		}
+	}
+	if report.Score >= 100 || report.Score <= 0 {
+		t.Errorf("Score = %d", report.Score)
+	}
+
+	cfg.Image.Name = "ghcr.io/acme/enclaude@sha256:4f1c"
+	for _, f := range Evaluate(cfg).Findings {
+		if (f.ID == "image-pinned" || f.ID == "image-tag") && !f.Passed {
+			t.Errorf("%s failed for an image pinned by digest", f.ID)
+		}
+	}
+}
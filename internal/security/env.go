@@ -0,0 +1,71 @@
+package security
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// hostEnv are variables that describe the host session and would break or
+// leak into the container, so passthrough patterns never match them
+var hostEnv = map[string]bool{
+	"PATH": true, "HOME": true, "USER": true, "LOGNAME": true, "SHELL": true,
+	"PWD": true, "OLDPWD": true, "SHLVL": true, "_": true, "TMPDIR": true, "HOSTNAME": true,
+	"SSH_AUTH_SOCK": true, "SSH_AGENT_PID": true, "GPG_AGENT_INFO": true,
+	"DISPLAY": true, "WAYLAND_DISPLAY": true, "XDG_RUNTIME_DIR": true, "DBUS_SESSION_BUS_ADDRESS": true,
+}
+
+// hostEnvPrefixes mark dynamic linker variables, which patterns never match
+var hostEnvPrefixes = []string{"LD_", "DYLD_"}
+
+// patternDenied matches names a passthrough pattern never passes, beyond
+// secretName: auth settings such as npm_config__auth, and askpass helpers,
+// which name host programs
+var patternDenied = regexp.MustCompile(`(?i)((^|_)AUTH($|_)|_PAT$|COOKIE|SESSION|ASKPASS)`)
+
+// PassthroughEnv resolves environment.passthrough entries against environ,
+// given as KEY=value pairs as from os.Environ. Plain names pass the variable
+// as is, since naming it is an explicit choice. Glob patterns such as LC_*
+// (path.Match syntax) pass every matching variable except host session
+// variables and those whose names look like secrets.
+func PassthroughEnv(patterns, environ []string) (map[string]string, error) {
+	vars := make(map[string]string, len(environ))
+	for _, kv := range environ {
+		if k, v, ok := strings.Cut(kv, "="); ok && k != "" {
+			vars[k] = v
+		}
+	}
+
+	env := make(map[string]string)
+	for _, pattern := range patterns {
+		if !strings.ContainsAny(pattern, "*?[") {
+			if v, ok := vars[pattern]; ok {
+				env[pattern] = v
+			}
+			continue
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid environment.passthrough pattern %q: %w", pattern, err)
+		}
+		for k, v := range vars {
+			if matched, _ := path.Match(pattern, k); matched && !patternExcluded(k) {
+				env[k] = v
+			}
+		}
+	}
+	return env, nil
+}
+
+// patternExcluded reports whether a passthrough pattern must skip name
+func patternExcluded(name string) bool {
+	if hostEnv[name] || secretName.MatchString(name) || patternDenied.MatchString(name) {
+		return true
+	}
+	for _, prefix := range hostEnvPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+package security
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPassthroughEnv(t *testing.T) {
+	environ := []string{
+		"LANG=en_US.UTF-8",
+		"LC_ALL=en_US.UTF-8",
+		"LC_TIME=C",
+		"GIT_AUTHOR_NAME=Me",
+		"GIT_EDITOR=vim",
+		"GIT_ASKPASS=/usr/bin/helper",
+		"npm_config_registry=https://npm.example.com",
+		"npm_config__auth=c2VjcmV0",
+		"npm_config_//npm.example.com/:_authToken=secret",
+		"GITHUB_TOKEN=ghp_secret",
+		"PATH=/usr/bin",
+		"LD_PRELOAD=/tmp/x.so",
+		"EDITOR=vim",
+	}
+	got, err := PassthroughEnv([]string{"LC_*", "GIT_*", "npm_config_*", "EDITOR", "GITHUB_TOKEN", "MISSING", "*"}, environ)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"LANG":                "en_US.UTF-8",
+		"LC_ALL":              "en_US.UTF-8",
+		"LC_TIME":             "C",
+		"GIT_AUTHOR_NAME":     "Me",
+		"GIT_EDITOR":          "vim",
+		"npm_config_registry": "https://npm.example.com",
+		"EDITOR":              "vim",
+		"GITHUB_TOKEN":        "ghp_secret", // Named explicitly
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("PassthroughEnv() = %v, want %v", got, want)
+	}
+
+	if _, err := PassthroughEnv([]string{"LC_["}, environ); err == nil {
+		t.Error("PassthroughEnv() accepted a malformed pattern")
+	}
+}
@@ -163,3 +163,82 @@ func FileExists(path string) bool {
 func DirExists(path string) bool {
 	return PathExists(path, true)
 }
+
+// ContainedSensitivePath returns the first denied or credential-controlled
+// path that lies inside path, so mounting a parent directory such as ~/.aws
+// or ~ cannot expose what mounting the path itself would not
+func ContainedSensitivePath(path string) (string, bool) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", false
+	}
+	for _, list := range [][]string{HardcodedDeniedPaths, CredentialControlledPaths} {
+		for _, sensitive := range list {
+			if pathMatches(expandTilde(sensitive, home), path) {
+				return sensitive, true
+			}
+		}
+	}
+	return "", false
+}
+
+// EngineSocketNames are the API sockets of container engines. Whoever can
+// reach one controls the engine, which amounts to root on the host.
+var EngineSocketNames = []string{"docker.sock", "docker.sock.raw", "podman.sock", "containerd.sock", "buildkitd.sock"}
+
+// engineSocketPaths are where engine sockets usually live, so that mounting a
+// parent directory such as /var/run is caught as well
+var engineSocketPaths = []string{
+	"/var/run/docker.sock",
+	"/run/docker.sock",
+	"~/.docker/run/docker.sock", // Docker Desktop
+	"/run/podman/podman.sock",
+	"/run/containerd/containerd.sock",
+	"/run/buildkit/buildkitd.sock",
+}
+
+// enginePipes are the named pipes Docker listens on under Windows
+var enginePipes = []string{"pipe/docker_engine", "pipe/dockerdesktoplinuxengine"}
+
+// ContainedEngineSocket returns the container engine socket or named pipe
+// that path is, or that lies inside it. Directories are only reported for
+// sockets that exist on this host, including the one DOCKER_HOST points at.
+func ContainedEngineSocket(path string) (string, bool) {
+	normalized := strings.ToLower(strings.ReplaceAll(path, `\`, "/"))
+	for _, pipe := range enginePipes {
+		if strings.Contains(normalized, pipe) {
+			return path, true
+		}
+	}
+	base := filepath.Base(path)
+	for _, name := range EngineSocketNames {
+		if base == name {
+			return path, true
+		}
+	}
+
+	home, _ := os.UserHomeDir()
+	candidates := append([]string(nil), engineSocketPaths...)
+	if host, ok := strings.CutPrefix(os.Getenv("DOCKER_HOST"), "unix://"); ok {
+		candidates = append(candidates, host)
+	}
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		candidates = append(candidates, filepath.Join(dir, "docker.sock"), filepath.Join(dir, "podman", "podman.sock"))
+	}
+	for _, candidate := range candidates {
+		socket := expandTilde(candidate, home)
+		info, err := os.Stat(socket)
+		if err != nil || info.Mode()&os.ModeSocket == 0 {
+			continue
+		}
+		// Match both the path as written and with symlinks such as
+		// /var/run -> /run resolved, as mount sources are
+		if pathMatches(socket, path) {
+			return socket, true
+		}
+		if resolved, err := filepath.EvalSymlinks(socket); err == nil && pathMatches(resolved, path) {
+			return socket, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,42 @@
+package security
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestContainedEngineSocket(t *testing.T) {
+	dir := t.TempDir()
+	sock := filepath.Join(dir, "run", "engine.sock")
+	if err := os.MkdirAll(filepath.Dir(sock), 0755); err != nil {
+		t.Fatal(err)
+	}
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Skipf("cannot create unix socket: %v", err)
+	}
+	defer l.Close()
+	t.Setenv("DOCKER_HOST", "unix://"+sock)
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/var/run/docker.sock", true},
+		{"/home/alice/.docker/run/docker.sock", true},
+		{`\\.\pipe\docker_engine`, true},
+		{"//./pipe/dockerDesktopLinuxEngine", true},
+		{sock, true},
+		{filepath.Join(dir, "run"), true},
+		{dir, true},
+		{filepath.Join(dir, "other"), false},
+		{"/home/alice/src/app", false},
+	}
+	for _, tt := range tests {
+		if _, got := ContainedEngineSocket(tt.path); got != tt.want {
+			t.Errorf("ContainedEngineSocket(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package security
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ProxyProbeHost is probed to detect TLS-intercepting proxies
+const ProxyProbeHost = "api.anthropic.com"
+
+// publicRootNames identify roots operated by public certificate authorities.
+// A chain for ProxyProbeHost that ends anywhere else was almost certainly
+// re-signed by a corporate proxy whose CA is installed in the host trust store.
+var publicRootNames = []string{
+	"Amazon",
+	"Baltimore CyberTrust",
+	"Certum",
+	"COMODO",
+	"DigiCert",
+	"Entrust",
+	"GlobalSign",
+	"Go Daddy",
+	"Google Trust Services",
+	"IdenTrust",
+	"Internet Security Research Group",
+	"Microsoft",
+	"QuoVadis",
+	"Sectigo",
+	"SSL Corporation",
+	"Starfield",
+	"USERTrust",
+}
+
+// DetectProxyCA connects to host through the environment's HTTPS proxy, if
+// any, and returns the root CA that the host trust store (Keychain on macOS,
+// the certificate store on Windows) uses to validate the presented chain,
+// when that root is not a public CA. A nil certificate means no interception
+// was detected.
+func DetectProxyCA(ctx context.Context, host string) (*x509.Certificate, error) {
+	conn, err := dialThroughProxy(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	return interceptingRoot(conn, host, nil)
+}
+
+// interceptingRoot performs a TLS handshake over conn and verifies the peer
+// chain against roots (nil for the platform verifier)
+func interceptingRoot(conn net.Conn, host string, roots *x509.CertPool) (*x509.Certificate, error) {
+	// Verification is done manually below so the chain can be inspected
+	tlsConn := tls.Client(conn, &tls.Config{ServerName: host, InsecureSkipVerify: true})
+	if err := tlsConn.Handshake(); err != nil {
+		return nil, fmt.Errorf("TLS handshake with %s failed: %w", host, err)
+	}
+
+	peers := tlsConn.ConnectionState().PeerCertificates
+	if len(peers) == 0 {
+		return nil, fmt.Errorf("%s presented no certificates", host)
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range peers[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	chains, err := peers[0].Verify(x509.VerifyOptions{
+		DNSName:       host,
+		Intermediates: intermediates,
+		Roots:         roots,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("certificate for %s is not trusted by this host either; its CA cannot be extracted: %w", host, err)
+	}
+
+	chain := chains[0]
+	root := chain[len(chain)-1]
+	if isPublicRoot(root) {
+		return nil, nil
+	}
+	return root, nil
+}
+
+// isPublicRoot reports whether cert belongs to a well-known public CA
+func isPublicRoot(cert *x509.Certificate) bool {
+	names := append([]string{cert.Subject.CommonName}, cert.Subject.Organization...)
+	for _, name := range names {
+		for _, public := range publicRootNames {
+			if strings.Contains(strings.ToLower(name), strings.ToLower(public)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// dialThroughProxy opens a TCP connection to host:443, tunnelling through the
+// HTTPS proxy from the environment with CONNECT when one is configured
+func dialThroughProxy(ctx context.Context, host string) (net.Conn, error) {
+	target := net.JoinHostPort(host, "443")
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+
+	proxyURL, err := http.ProxyFromEnvironment(&http.Request{URL: &url.URL{Scheme: "https", Host: host}})
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy configuration: %w", err)
+	}
+	if proxyURL == nil {
+		return dialer.DialContext(ctx, "tcp", target)
+	}
+
+	proxyAddr := proxyURL.Host
+	if proxyURL.Port() == "" {
+		proxyAddr = net.JoinHostPort(proxyURL.Hostname(), "80")
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", proxyAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to proxy %s: %w", proxyAddr, err)
+	}
+
+	req := fmt.Sprintf("CONNECT %s HTTP/1.1\r\nHost: %s\r\n", target, target)
+	if proxyURL.User != nil {
+		password, _ := proxyURL.User.Password()
+		auth := base64.StdEncoding.EncodeToString([]byte(proxyURL.User.Username() + ":" + password))
+		req += "Proxy-Authorization: Basic " + auth + "\r\n"
+	}
+	req += "\r\n"
+
+	_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+	if _, err := conn.Write([]byte(req)); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to send CONNECT to proxy: %w", err)
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(conn), &http.Request{Method: http.MethodConnect})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to read proxy response: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("proxy refused CONNECT to %s: %s", target, resp.Status)
+	}
+	_ = conn.SetDeadline(time.Time{})
+
+	return conn, nil
+}
+
+// WriteCertPEM writes cert to path in PEM format, creating parent directories
+func WriteCertPEM(path string, cert *x509.Certificate) error {
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
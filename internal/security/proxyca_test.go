@@ -0,0 +1,68 @@
+package security
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestInterceptingRoot(t *testing.T) {
+	// httptest's certificate stands in for a proxy's re-signed chain
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to dial test server: %v", err)
+	}
+	defer conn.Close()
+
+	root, err := interceptingRoot(conn, "example.com", roots)
+	if err != nil {
+		t.Fatalf("interceptingRoot() error = %v", err)
+	}
+	if root == nil || !root.Equal(server.Certificate()) {
+		t.Errorf("interceptingRoot() = %v, want the test server's CA", root)
+	}
+}
+
+func TestInterceptingRoot_Untrusted(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to dial test server: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := interceptingRoot(conn, "example.com", x509.NewCertPool()); err == nil {
+		t.Error("interceptingRoot() with untrusted chain should return an error")
+	}
+}
+
+func TestIsPublicRoot(t *testing.T) {
+	tests := []struct {
+		name    string
+		subject pkix.Name
+		want    bool
+	}{
+		{"isrg", pkix.Name{CommonName: "ISRG Root X1", Organization: []string{"Internet Security Research Group"}}, true},
+		{"digicert", pkix.Name{CommonName: "DigiCert Global Root G2"}, true},
+		{"corporate", pkix.Name{CommonName: "Acme Corp Proxy CA", Organization: []string{"Acme Corp"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isPublicRoot(&x509.Certificate{Subject: tt.subject}); got != tt.want {
+				t.Errorf("isPublicRoot() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package security
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Redacted replaces secrets removed by Redact
+const Redacted = "[REDACTED]"
+
+// secretPatterns match tokens with well-known formats regardless of entropy
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?(-----END [A-Z ]*PRIVATE KEY-----|$)`),
+	regexp.MustCompile(`\bsk-ant-[A-Za-z0-9_-]{16,}`),                                     // Anthropic
+	regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{32,}`),                                         // OpenAI-style
+	regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{20,}|github_pat_[A-Za-z0-9_]{20,})`),     // GitHub
+	regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20,}`),                                      // GitLab
+	regexp.MustCompile(`\b(AKIA|ASIA)[A-Z0-9]{16}\b`),                                     // AWS access key ID
+	regexp.MustCompile(`\bAIza[A-Za-z0-9_-]{35}`),                                         // Google API key
+	regexp.MustCompile(`\bya29\.[A-Za-z0-9_-]{20,}`),                                      // Google OAuth access token
+	regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`),                                 // Slack
+	regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`), // JWT
+	regexp.MustCompile(`(?i)\b(bearer|basic)\s+[A-Za-z0-9._~+/=-]{16,}`),
+}
+
+// Private key markers, which the writer follows across lines, and the lines
+// a key's body is made of: base64 and the headers of an encrypted PEM key
+var (
+	privateKeyBegin = regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`)
+	privateKeyEnd   = regexp.MustCompile(`-----END [A-Z ]*PRIVATE KEY-----`)
+	privateKeyLine  = regexp.MustCompile(`^([A-Za-z0-9+/=]*|(Proc-Type|DEK-Info): .*)$`)
+)
+
+// maxKeyLines bounds how many body lines the writer drops for a private key
+// whose END line never comes, well above the ~50 of a 4096-bit RSA key
+const maxKeyLines = 200
+
+// tokenCandidate matches runs of characters that random tokens are drawn from
+var tokenCandidate = regexp.MustCompile(`[A-Za-z0-9+_=-]{24,}`)
+
+// Tokens shorter than minSecretLength or with less than minSecretEntropy bits
+// of Shannon entropy per character are left alone: identifiers, paths, and
+// words rarely get that random.
+const (
+	minSecretLength  = 24
+	minSecretEntropy = 4.0
+)
+
+// Redact replaces likely secrets in s with Redacted: tokens with known
+// prefixes, private keys, and long high-entropy strings. Hex strings such as
+// commit hashes and container IDs are kept, since they are rarely secret and
+// are what makes logs useful.
+func Redact(s string) string {
+	for _, re := range secretPatterns {
+		s = re.ReplaceAllString(s, Redacted)
+	}
+	return tokenCandidate.ReplaceAllStringFunc(s, func(token string) string {
+		if highEntropy(token) {
+			return Redacted
+		}
+		return token
+	})
+}
+
+// highEntropy reports whether token looks randomly generated: long, mixing
+// upper case, lower case, and digits, with high per-character entropy
+func highEntropy(token string) bool {
+	if len(token) < minSecretLength {
+		return false
+	}
+	var upper, lower, digit bool
+	counts := map[rune]int{}
+	for _, r := range token {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= '0' && r <= '9':
+			digit = true
+		}
+		counts[r]++
+	}
+	if !upper || !lower || !digit {
+		return false
+	}
+	return shannonEntropy(counts, len(token)) >= minSecretEntropy
+}
+
+// shannonEntropy returns bits of entropy per character
+func shannonEntropy(counts map[rune]int, n int) float64 {
+	var h float64
+	for _, c := range counts {
+		p := float64(c) / float64(n)
+		h -= p * math.Log2(p)
+	}
+	return h
+}
+
+// RedactingWriter redacts secrets from text written to an underlying writer.
+// Output is redacted a line at a time so a token split across writes is still
+// caught, and a private key is dropped up to its END line however many
+// writes it spans. A key without an END line is dropped only up to the first
+// line that cannot be part of it, or maxKeyLines lines, so a truncated key
+// does not swallow the rest of the output. Call Close to flush a final
+// partial line.
+type RedactingWriter struct {
+	mu       sync.Mutex
+	w        io.Writer
+	buf      []byte
+	inKey    bool // Inside a private key whose END line has not been written
+	keyLines int  // Body lines dropped for the open key
+	keyWidth int  // Length of the open key's first base64 line
+	keyLast  bool // The open key's last, shorter, base64 line was dropped
+}
+
+// NewRedactingWriter returns a RedactingWriter writing to w
+func NewRedactingWriter(w io.Writer) *RedactingWriter {
+	return &RedactingWriter{w: w}
+}
+
+// maxLineBuffer bounds how much of an unterminated line is held back
+const maxLineBuffer = 64 * 1024
+
+func (r *RedactingWriter) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.buf = append(r.buf, p...)
+	end := bytes.LastIndexByte(r.buf, '\n') + 1
+	if end == 0 && len(r.buf) >= maxLineBuffer {
+		end = len(r.buf)
+	}
+	if end > 0 {
+		if _, err := io.WriteString(r.w, r.redact(string(r.buf[:end]))); err != nil {
+			return 0, err
+		}
+		r.buf = append(r.buf[:0], r.buf[end:]...)
+	}
+	return len(p), nil
+}
+
+// Close writes any buffered partial line. It does not close the underlying
+// writer.
+func (r *RedactingWriter) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.buf) == 0 {
+		return nil
+	}
+	_, err := io.WriteString(r.w, r.redact(string(r.buf)))
+	r.buf = nil
+	return err
+}
+
+// redact redacts text, replacing each private key with Redacted and
+// dropping the rest of one still open from an earlier write
+func (r *RedactingWriter) redact(text string) string {
+	var out strings.Builder
+	for text != "" {
+		if r.inKey {
+			line, rest, _ := strings.Cut(text, "\n")
+			if loc := privateKeyEnd.FindStringIndex(line); loc != nil {
+				text = text[loc[1]:]
+				r.inKey = false
+				continue
+			}
+			if r.keyBody(strings.TrimSpace(line)) {
+				text = rest
+				continue
+			}
+			// Not part of the key, which was cut off: end the line the key
+			// started on and carry on with this one
+			out.WriteString("\n")
+			r.inKey = false
+			continue
+		}
+		loc := privateKeyBegin.FindStringIndex(text)
+		if loc == nil {
+			out.WriteString(Redact(text))
+			break
+		}
+		out.WriteString(Redact(text[:loc[0]]))
+		out.WriteString(Redacted)
+		text = strings.TrimPrefix(strings.TrimLeft(text[loc[1]:], " \r"), "\n")
+		r.inKey, r.keyLines, r.keyWidth, r.keyLast = true, 0, 0, false
+	}
+	return out.String()
+}
+
+// keyBody reports whether line can be the next body line of the open key,
+// recording it if so. Base64 lines share one width except the last, which
+// may be shorter; headers and a blank line may only come before them.
+func (r *RedactingWriter) keyBody(line string) bool {
+	if r.keyLines >= maxKeyLines || r.keyLast || !privateKeyLine.MatchString(line) {
+		return false
+	}
+	header := line == "" || strings.Contains(line, ":")
+	switch {
+	case header && r.keyWidth > 0:
+		return false
+	case header:
+	case r.keyWidth == 0:
+		r.keyWidth = len(line)
+	case len(line) > r.keyWidth:
+		return false
+	case len(line) < r.keyWidth:
+		r.keyLast = true
+	}
+	r.keyLines++
+	return true
+}
@@ -0,0 +1,17 @@
+#!/bin/bash
+# enclaude shell wrapper: checks the command given with -c against the
+# host's shell policy, which audits it, then runs it with the real bash.
+# Shells started without -c run unchecked.
+dir="$(dirname "$0")"
+check=""
+for arg in "$@"; do
+    if [ -n "$check" ] && [ "${arg#-}" = "$arg" ]; then
+        "$dir/enclaude-shell" "$arg" || exit 126
+        break
+    fi
+    case "$arg" in
+        --*) ;;
+        -*c*) check=1 ;;
+    esac
+done
+exec /bin/bash "$@"
@@ -0,0 +1,97 @@
+package shellpolicy
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net"
+
+	"github.com/jakenelson/enclaude/internal/bridge"
+)
+
+// ContainerDir is where the wrapper, shim, and socket appear in the container
+const ContainerDir = "/run/enclaude/shell"
+
+// Shell is the wrapper Claude is pointed at. It is named bash because
+// Claude Code only uses a $SHELL it recognizes as bash or zsh.
+const Shell = ContainerDir + "/bash"
+
+// shim is the in-container client that asks the bridge about a command
+//
+//go:embed enclaude-shell.js
+var shim []byte
+
+// wrapper checks the command given with -c through the shim, then runs the
+// real bash with the original arguments
+//
+//go:embed bash.sh
+var wrapper []byte
+
+// request is sent by the shim with the command line and the directory it
+// runs in
+type request struct {
+	Command string `json:"command"`
+	Cwd     string `json:"cwd"`
+}
+
+// response says whether the command may run, and if not, why
+type response struct {
+	Allowed bool   `json:"allowed"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// AuditFunc is called for every command checked, with the verdict
+type AuditFunc func(command, cwd string, allowed bool, reason string)
+
+// Bridge listens on a Unix socket for commands from the wrapper and checks
+// each against the policy
+type Bridge struct {
+	*bridge.Dir
+	policy *Policy
+	audit  AuditFunc
+}
+
+// Start creates the bridge directory holding the wrapper, the shim, and the
+// socket, and starts serving requests. The directory is mounted read-only,
+// so the session cannot swap the wrapper.
+func Start(policy *Policy, audit AuditFunc) (*Bridge, error) {
+	dir, err := bridge.New("enclaude-shell-", "shell policy bridge", ContainerDir, true)
+	if err != nil {
+		return nil, err
+	}
+	b := &Bridge{Dir: dir, policy: policy, audit: audit}
+	for name, data := range map[string][]byte{"enclaude-shell": shim, "bash": wrapper} {
+		if err := dir.WriteExecutable(name, data); err != nil {
+			dir.Close()
+			return nil, err
+		}
+	}
+	if err := dir.Serve("shell.sock", b.handle); err != nil {
+		dir.Close()
+		return nil, err
+	}
+	return b, nil
+}
+
+// Env returns the environment that points Claude at the wrapper and the
+// wrapper at the socket
+func (b *Bridge) Env() map[string]string {
+	return map[string]string{
+		"SHELL":                 Shell,
+		"CLAUDE_CODE_SHELL":     Shell,
+		"ENCLAUDE_SHELL_SOCKET": ContainerDir + "/shell.sock",
+	}
+}
+
+func (b *Bridge) handle(conn net.Conn, _ <-chan struct{}) {
+	var req request
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		json.NewEncoder(conn).Encode(response{Reason: "invalid request"})
+		return
+	}
+
+	allowed, reason := b.policy.Check(req.Command)
+	if b.audit != nil {
+		b.audit(req.Command, req.Cwd, allowed, reason)
+	}
+	json.NewEncoder(conn).Encode(response{Allowed: allowed, Reason: reason})
+}
@@ -0,0 +1,41 @@
+#!/usr/bin/env node
+// enclaude-shell: asks the host whether the shell policy allows a command
+// line, which the host audits. Exits 0 if it may run; otherwise prints why
+// and exits 126. Without the bridge nothing runs.
+const net = require('net');
+const path = require('path');
+
+const command = process.argv[2];
+const socketPath = process.env.ENCLAUDE_SHELL_SOCKET || path.join(__dirname, 'shell.sock');
+
+if (command === undefined) {
+  console.error('usage: enclaude-shell <command line>');
+  process.exit(2);
+}
+
+let response = '';
+const conn = net.createConnection(socketPath);
+conn.on('connect', () => {
+  conn.end(JSON.stringify({ command, cwd: process.cwd() }) + '\n');
+});
+conn.on('data', (data) => {
+  response += data;
+});
+conn.on('error', (err) => {
+  console.error(`enclaude: shell policy bridge unavailable (${err.message}); command not run`);
+  process.exit(126);
+});
+conn.on('close', () => {
+  let msg;
+  try {
+    msg = JSON.parse(response);
+  } catch (err) {
+    console.error('enclaude: invalid response from shell policy bridge; command not run');
+    process.exit(126);
+  }
+  if (!msg.allowed) {
+    console.error(`enclaude: blocked by shell policy: ${msg.reason || 'not allowed'}`);
+    process.exit(126);
+  }
+  process.exit(0);
+});
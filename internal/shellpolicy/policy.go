@@ -0,0 +1,122 @@
+// Package shellpolicy replaces the shell Claude runs commands with by a
+// wrapper that asks the host to check each command line against an
+// allow/deny policy and audits it before running it. It is a guardrail
+// against mistakes, not a sandbox: only the command line Claude hands the
+// shell is checked, not what the commands it names run in turn.
+package shellpolicy
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// dangerous are command lines blocked when the policy blocks dangerous
+// patterns, whatever the allow list says
+var dangerous = []struct {
+	pattern *regexp.Regexp
+	reason  string
+}{
+	{regexp.MustCompile(`\b(curl|wget)\b[^|;&]*\|\s*(sudo\s+)?(ba|z|da|k)?sh\b`), "pipes a download into a shell"},
+	{regexp.MustCompile(`\brm\s+(-\S+\s+)*-[a-zA-Z]*[rR]\S*\s+(-\S+\s+)*(/\*?|~/?|\$HOME/?|/workspace/?)(\s|[;&|)]|$)`), "recursively removes the root, home, or workspace directory"},
+	{regexp.MustCompile(`\bmkfs(\.\w+)?\b`), "creates a filesystem"},
+	{regexp.MustCompile(`\bdd\b.*\bof=/dev/(sd|hd|vd|xvd|nvme|mmcblk|disk)`), "writes to a block device"},
+	{regexp.MustCompile(`:\(\)\s*\{\s*:\s*\|\s*:\s*&\s*\}\s*;\s*:`), "is a fork bomb"},
+}
+
+// keywords start shell syntax rather than name a command
+var keywords = map[string]bool{
+	"if": true, "then": true, "else": true, "elif": true, "fi": true,
+	"do": true, "done": true, "while": true, "until": true, "esac": true,
+	"{": true, "}": true, "!": true, "time": true,
+}
+
+// loopHeaders start a segment that lists words rather than running them
+var loopHeaders = map[string]bool{"for": true, "case": true, "select": true}
+
+// separators split a command line into simple commands. Quoting is not
+// considered, so a separator inside quotes splits too, which can only make
+// the allow list stricter.
+var separators = regexp.MustCompile("\\|\\|?|&&?|;;?|\\n|\\$\\(|[()`]")
+
+// fdRedirect matches redirections such as 2>&1 and &>, whose ampersand is
+// not a separator
+var fdRedirect = regexp.MustCompile(`[0-9]*[<>]&[0-9-]*|&>>?`)
+
+// assignment matches a leading variable assignment such as FOO=bar
+var assignment = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*=`)
+
+// Policy decides which command lines the shell may run
+type Policy struct {
+	allow          map[string]bool
+	deny           []*regexp.Regexp
+	blockDangerous bool
+}
+
+// NewPolicy builds a policy. allow lists command names; when it is not
+// empty, every command in a line must be one of them. deny lists regular
+// expressions; a line matching any is blocked.
+func NewPolicy(allow, deny []string, blockDangerous bool) (*Policy, error) {
+	p := &Policy{blockDangerous: blockDangerous}
+	for _, name := range allow {
+		name = strings.TrimSpace(name)
+		if name == "" || strings.ContainsAny(name, " \t/") {
+			return nil, fmt.Errorf("shell_policy.allow entry %q must be a command name", name)
+		}
+		if p.allow == nil {
+			p.allow = make(map[string]bool)
+		}
+		p.allow[name] = true
+	}
+	for _, pattern := range deny {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid shell_policy.deny pattern %q: %w", pattern, err)
+		}
+		p.deny = append(p.deny, re)
+	}
+	return p, nil
+}
+
+// Check reports whether command may run, and if not, why
+func (p *Policy) Check(command string) (bool, string) {
+	if p.blockDangerous {
+		for _, d := range dangerous {
+			if d.pattern.MatchString(command) {
+				return false, "command " + d.reason
+			}
+		}
+	}
+	for _, re := range p.deny {
+		if re.MatchString(command) {
+			return false, fmt.Sprintf("command matches deny pattern %q", re.String())
+		}
+	}
+	if p.allow != nil {
+		for _, name := range commandNames(command) {
+			if !p.allow[name] {
+				return false, fmt.Sprintf("%s is not in shell_policy.allow", name)
+			}
+		}
+	}
+	return true, ""
+}
+
+// commandNames returns the name of each simple command in a command line,
+// skipping variable assignments and shell keywords
+func commandNames(command string) []string {
+	var names []string
+	command = fdRedirect.ReplaceAllString(command, " ")
+	for _, segment := range separators.Split(command, -1) {
+		words := strings.Fields(segment)
+		for len(words) > 0 && (keywords[words[0]] || assignment.MatchString(words[0])) {
+			words = words[1:]
+		}
+		if len(words) == 0 || loopHeaders[words[0]] {
+			continue
+		}
+		names = append(names, path.Base(words[0]))
+	}
+	return names
+}
@@ -0,0 +1,194 @@
+// Package sockets forwards host Unix sockets into the container, either by
+// bind-mounting them or by relaying each connection through enclaude so it
+// can be audited and survives the host daemon recreating its socket.
+package sockets
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jakenelson/enclaude/internal/config"
+	"github.com/jakenelson/enclaude/internal/container"
+	"github.com/jakenelson/enclaude/internal/relay"
+	"github.com/jakenelson/enclaude/internal/security"
+)
+
+// ContainerDir holds forwarded sockets that have no explicit target
+const ContainerDir = "/run/enclaude/sockets"
+
+// socketName is a valid socket name
+var socketName = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// Forward is a validated socket to forward
+type Forward struct {
+	Name      string
+	Source    string // Resolved host path
+	Target    string // Container path
+	Mode      string // bind, proxy
+	Env       string
+	Dangerous bool // A container engine socket, allowed by --dangerous
+}
+
+// Resolve validates socket entries from config. Sources must be existing
+// Unix sockets; container engine sockets are refused unless allowDangerous.
+func Resolve(entries []config.SocketEntry, allowDangerous bool) ([]Forward, error) {
+	var forwards []Forward
+	names := make(map[string]bool)
+	for _, e := range entries {
+		f, err := resolve(e)
+		if err != nil {
+			return nil, err
+		}
+		if names[f.Name] {
+			return nil, fmt.Errorf("socket %q is forwarded twice; give one a different name", f.Name)
+		}
+		names[f.Name] = true
+		if f.Dangerous && !allowDangerous {
+			return nil, fmt.Errorf("socket %s (%s) controls the host's container engine, which amounts to root on the host; pass --dangerous to forward it anyway", f.Name, f.Source)
+		}
+		forwards = append(forwards, f)
+	}
+	return forwards, nil
+}
+
+func resolve(e config.SocketEntry) (Forward, error) {
+	if e.Source == "" {
+		return Forward{}, fmt.Errorf("socket entry %q has no source", e.Name)
+	}
+	source, err := security.ExpandPath(os.ExpandEnv(e.Source))
+	if err != nil {
+		return Forward{}, fmt.Errorf("invalid socket source %q: %w", e.Source, err)
+	}
+	info, err := os.Stat(source)
+	if err != nil {
+		return Forward{}, fmt.Errorf("socket %s not available: %w", e.Source, err)
+	}
+	// Only sockets are accepted, so an entry cannot expose a file from a
+	// denied directory such as ~/.gnupg
+	if info.Mode()&os.ModeSocket == 0 {
+		return Forward{}, fmt.Errorf("%s is not a Unix socket", e.Source)
+	}
+
+	f := Forward{Name: e.Name, Source: source, Mode: e.Mode, Env: e.Env, Dangerous: isEngineSocket(source)}
+	if f.Name == "" {
+		f.Name = strings.TrimSuffix(filepath.Base(source), ".sock")
+	}
+	if !socketName.MatchString(f.Name) || f.Name == "." || f.Name == ".." {
+		return Forward{}, fmt.Errorf("invalid socket name %q", f.Name)
+	}
+	switch f.Mode {
+	case "":
+		f.Mode = config.SocketBind
+	case config.SocketBind, config.SocketProxy:
+	default:
+		return Forward{}, fmt.Errorf("invalid mode %q for socket %s (allowed: %s, %s)", f.Mode, f.Name, config.SocketBind, config.SocketProxy)
+	}
+
+	f.Target = e.Target
+	switch {
+	case f.Target == "":
+		f.Target = ContainerDir + "/" + f.Name + ".sock"
+	case strings.HasPrefix(f.Target, "~/"):
+		f.Target = container.Home + f.Target[1:]
+	}
+	if !path.IsAbs(f.Target) || path.Clean(f.Target) == "/" {
+		return Forward{}, fmt.Errorf("socket %s target %q must be an absolute container path", f.Name, e.Target)
+	}
+	f.Target = path.Clean(f.Target)
+	return f, nil
+}
+
+// isEngineSocket reports whether path is a container engine's API socket,
+// including the one DOCKER_HOST points at
+func isEngineSocket(p string) bool {
+	base := filepath.Base(p)
+	for _, s := range security.EngineSocketNames {
+		if base == s {
+			return true
+		}
+	}
+	if host, ok := strings.CutPrefix(os.Getenv("DOCKER_HOST"), "unix://"); ok {
+		if resolved, err := filepath.EvalSymlinks(host); err == nil && resolved == p {
+			return true
+		}
+	}
+	return false
+}
+
+// Warning describes what forwarding f lets the session do
+func Warning(f Forward) string {
+	base := filepath.Base(f.Source)
+	switch {
+	case f.Dangerous:
+		return fmt.Sprintf("socket %s gives the session full control of the host's container engine, equivalent to root on the host", f.Name)
+	case strings.HasPrefix(base, "S.gpg-agent") && base != "S.gpg-agent.extra":
+		return fmt.Sprintf("socket %s lets the session sign and decrypt with your unlocked GPG keys and manage the agent; prefer the restricted S.gpg-agent.extra socket", f.Name)
+	case strings.HasPrefix(base, "S.gpg-agent"):
+		return fmt.Sprintf("socket %s lets the session sign and decrypt with your unlocked GPG keys", f.Name)
+	case f.Source == os.Getenv("SSH_AUTH_SOCK"):
+		return fmt.Sprintf("socket %s lets the session authenticate as you with every key in your SSH agent", f.Name)
+	default:
+		return fmt.Sprintf("socket %s lets the session use %s with your privileges", f.Name, f.Source)
+	}
+}
+
+// Mount returns the bind mount exposing the host socket at the target
+func (f Forward) Mount() container.Mount {
+	return container.Mount{Source: f.Source, Target: f.Target, Kind: container.MountAny}
+}
+
+// ConnAuditFunc is called when a relayed connection closes, with the bytes
+// sent to and received from the host socket and any error
+type ConnAuditFunc func(f Forward, sent, received int64, duration time.Duration, err error)
+
+// Proxy relays connections from a socket mounted into the container to the
+// host socket
+type Proxy struct {
+	forward  Forward
+	dir      string
+	listener net.Listener
+	server   relay.Server
+}
+
+// StartProxy listens on a socket in a private directory and relays each
+// connection to f's host socket, dialing it afresh so a restarted daemon is
+// picked up
+func StartProxy(f Forward, audit ConnAuditFunc) (*Proxy, error) {
+	dir, err := os.MkdirTemp("", "enclaude-socket-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create socket proxy directory: %w", err)
+	}
+	listener, err := net.Listen("unix", filepath.Join(dir, f.Name+".sock"))
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("failed to listen for socket %s: %w", f.Name, err)
+	}
+
+	p := &Proxy{forward: f, dir: dir, listener: listener}
+	var done relay.DoneFunc
+	if audit != nil {
+		done = func(sent, received int64, duration time.Duration, err error) {
+			audit(f, sent, received, duration, err)
+		}
+	}
+	p.server.Serve(listener, func() (net.Conn, error) { return net.Dial("unix", f.Source) }, done)
+	return p, nil
+}
+
+// Mount returns the mount exposing the proxy socket at the target
+func (p *Proxy) Mount() container.Mount {
+	return container.Mount{Source: p.listener.Addr().String(), Target: p.forward.Target, Kind: container.MountAny}
+}
+
+// Close stops accepting connections, ends those in progress, and removes
+// the proxy socket
+func (p *Proxy) Close() {
+	p.server.Close()
+	os.RemoveAll(p.dir)
+}
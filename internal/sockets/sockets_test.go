@@ -0,0 +1,133 @@
+package sockets
+
+import (
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jakenelson/enclaude/internal/config"
+)
+
+// listen creates a Unix socket named name in a temp dir and echoes
+// whatever is written to it
+func listen(t *testing.T, name string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer c.Close()
+				io.Copy(c, c)
+			}()
+		}
+	}()
+	return path
+}
+
+func TestResolve(t *testing.T) {
+	agent := listen(t, "S.gpg-agent.extra")
+	engine := listen(t, "docker.sock")
+	file := filepath.Join(t.TempDir(), "plain")
+	os.WriteFile(file, nil, 0600)
+
+	got, err := Resolve([]config.SocketEntry{{Source: agent, Target: "~/.gnupg/S.gpg-agent", Env: "GPG_AGENT_SOCK"}}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Forward{Name: "S.gpg-agent.extra", Target: "/home/enclaude/.gnupg/S.gpg-agent", Mode: config.SocketBind, Env: "GPG_AGENT_SOCK"}
+	if f := got[0]; f.Name != want.Name || f.Target != want.Target || f.Mode != want.Mode || f.Env != want.Env || f.Dangerous {
+		t.Errorf("Resolve() = %+v, want %+v", f, want)
+	}
+
+	got, err = Resolve([]config.SocketEntry{{Name: "daemon", Source: agent, Mode: config.SocketProxy}}, false)
+	if err != nil || got[0].Target != ContainerDir+"/daemon.sock" {
+		t.Errorf("default target = %+v, %v", got, err)
+	}
+
+	errs := []struct {
+		name    string
+		entries []config.SocketEntry
+		want    string
+	}{
+		{"engine socket", []config.SocketEntry{{Source: engine}}, "--dangerous"},
+		{"not a socket", []config.SocketEntry{{Source: file}}, "not a Unix socket"},
+		{"missing", []config.SocketEntry{{Source: file + ".missing"}}, "not available"},
+		{"bad mode", []config.SocketEntry{{Source: agent, Mode: "tcp"}}, "invalid mode"},
+		{"relative target", []config.SocketEntry{{Source: agent, Target: "agent.sock"}}, "absolute"},
+		{"bad name", []config.SocketEntry{{Name: "../x", Source: agent}}, "invalid socket name"},
+		{"duplicate", []config.SocketEntry{{Source: agent}, {Source: agent}}, "twice"},
+	}
+	for _, tt := range errs {
+		if _, err := Resolve(tt.entries, false); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: Resolve() error = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+
+	got, err = Resolve([]config.SocketEntry{{Source: engine}}, true)
+	if err != nil || !got[0].Dangerous || !strings.Contains(Warning(got[0]), "root") {
+		t.Errorf("Resolve(docker.sock, dangerous) = %+v, %v", got, err)
+	}
+}
+
+func TestProxy(t *testing.T) {
+	source := listen(t, "daemon.sock")
+	f := Forward{Name: "daemon", Source: source, Target: ContainerDir + "/daemon.sock", Mode: config.SocketProxy}
+
+	var mu sync.Mutex
+	var sent, received int64
+	audited := make(chan struct{}, 1)
+	p, err := StartProxy(f, func(_ Forward, s, r int64, _ time.Duration, err error) {
+		mu.Lock()
+		sent, received = s, r
+		mu.Unlock()
+		if err != nil {
+			t.Errorf("relay error: %v", err)
+		}
+		audited <- struct{}{}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	m := p.Mount()
+	if m.Target != f.Target || m.Source == source {
+		t.Fatalf("Mount() = %+v", m)
+	}
+	conn, err := net.Dial("unix", m.Source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Write([]byte("ping"))
+	conn.(*net.UnixConn).CloseWrite()
+	reply, _ := io.ReadAll(conn)
+	conn.Close()
+	if string(reply) != "ping" {
+		t.Errorf("reply = %q, want ping", reply)
+	}
+
+	select {
+	case <-audited:
+	case <-time.After(5 * time.Second):
+		t.Fatal("connection was not audited")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if sent != 4 || received != 4 {
+		t.Errorf("audited sent %d, received %d; want 4, 4", sent, received)
+	}
+}
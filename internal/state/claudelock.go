@@ -0,0 +1,77 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ClaudeDirLock records the enclaude process whose session mounts the host
+// ~/.claude read-write, so parallel sessions do not write the same session
+// data. The holder refreshes the file every HeartbeatInterval.
+type ClaudeDirLock struct {
+	PID       int       `json:"pid,omitempty"` // 0 once released
+	Host      string    `json:"host,omitempty"`
+	Workspace string    `json:"workspace,omitempty"`
+	Acquired  time.Time `json:"acquired,omitempty"`
+	Released  time.Time `json:"released,omitempty"` // When the last holder released the lock
+}
+
+// Stale reports whether a lock last refreshed at refreshed was left behind:
+// released, held by a process on this host that is no longer running, or
+// held elsewhere by a process that stopped refreshing it
+func (l ClaudeDirLock) Stale(host string, refreshed, now time.Time, alive func(pid int) bool) bool {
+	if l.PID == 0 {
+		return true
+	}
+	if l.Host == host {
+		return !alive(l.PID)
+	}
+	return now.Sub(refreshed) > HeartbeatTimeout
+}
+
+// LockClaudeDir takes the ~/.claude lock for lock unless a live process
+// holds it. It returns the lock as found, and whether it was taken.
+func LockClaudeDir(dir string, lock ClaudeDirLock, alive func(pid int) bool) (ClaudeDirLock, bool, error) {
+	stateLock, err := Acquire(dir)
+	if err != nil {
+		return ClaudeDirLock{}, false, err
+	}
+	defer stateLock.Release()
+
+	path := filepath.Join(dir, ClaudeDirLockFile)
+	var current ClaudeDirLock
+	if info, err := os.Stat(path); err == nil && ReadJSON(path, &current) == nil {
+		if !current.Stale(lock.Host, info.ModTime(), time.Now(), alive) {
+			return current, false, nil
+		}
+	}
+	lock.Released = current.Released
+	if err := WriteJSON(path, lock); err != nil {
+		return current, false, err
+	}
+	return current, true, nil
+}
+
+// RefreshClaudeDirLock records that the holder of the ~/.claude lock is alive
+func RefreshClaudeDirLock(dir string) error {
+	now := time.Now()
+	return os.Chtimes(filepath.Join(dir, ClaudeDirLockFile), now, now)
+}
+
+// UnlockClaudeDir releases the ~/.claude lock if the process pid on host
+// still holds it, recording when it was released
+func UnlockClaudeDir(dir, host string, pid int) error {
+	stateLock, err := Acquire(dir)
+	if err != nil {
+		return err
+	}
+	defer stateLock.Release()
+
+	path := filepath.Join(dir, ClaudeDirLockFile)
+	var current ClaudeDirLock
+	if err := ReadJSON(path, &current); err != nil || current.PID != pid || current.Host != host {
+		return nil
+	}
+	return WriteJSON(path, ClaudeDirLock{Released: time.Now()})
+}
@@ -0,0 +1,61 @@
+package state
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Heartbeat timing. A running session touches its heartbeat file every
+// HeartbeatInterval; one untouched for HeartbeatTimeout belongs to a process
+// that has died or hung.
+const (
+	HeartbeatInterval = 30 * time.Second
+	HeartbeatTimeout  = 5 * time.Minute
+)
+
+// Heartbeat records that the process owning runID is alive
+func Heartbeat(dir, runID string) error {
+	path, err := heartbeatPath(dir, runID)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if err := os.Chtimes(path, now, now); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, nil, 0600)
+}
+
+// LastHeartbeat returns when the process owning runID last recorded a
+// heartbeat, and false if it never did
+func LastHeartbeat(dir, runID string) (time.Time, bool) {
+	path, err := heartbeatPath(dir, runID)
+	if err != nil {
+		return time.Time{}, false
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return info.ModTime(), true
+}
+
+// RemoveHeartbeat deletes the heartbeat for runID once its session has ended
+func RemoveHeartbeat(dir, runID string) {
+	if path, err := heartbeatPath(dir, runID); err == nil {
+		os.Remove(path)
+	}
+}
+
+func heartbeatPath(dir, runID string) (string, error) {
+	if runID == "" || strings.ContainsAny(runID, `/\`) {
+		return "", fmt.Errorf("invalid run ID %q", runID)
+	}
+	return filepath.Join(dir, HeartbeatsDir, runID), nil
+}
@@ -0,0 +1,201 @@
+package state
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jakenelson/enclaude/internal/security"
+)
+
+// Garbage collection limits
+const (
+	DefaultRunRetention = 30 * 24 * time.Hour
+	MaxRuns             = 500
+	MaxAuditLogSize     = 4 << 20 // Rotated to audit.log.1 beyond this size
+)
+
+// Run records a single enclaude session
+type Run struct {
+	ID        string    `json:"id"`
+	Workspace string    `json:"workspace"`
+	Image     string    `json:"image"`
+	Args      []string  `json:"args,omitempty"`
+	Started   time.Time `json:"started"`
+	Ended     time.Time `json:"ended,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	Detached  bool      `json:"detached,omitempty"` // Left running when enclaude exited
+	HostUID   int       `json:"host_uid,omitempty"` // Host uid of the session's files with container.user random
+	User      string    `json:"user,omitempty"`     // Container user with container.user random
+}
+
+// AuditEvent is a single line of the audit log
+type AuditEvent struct {
+	Time    time.Time              `json:"time"`
+	Event   string                 `json:"event"`
+	RunID   string                 `json:"run,omitempty"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// NewRunID returns a random identifier for a run
+func NewRunID() string {
+	b := make([]byte, 6)
+	_, _ = rand.Read(b)
+	return time.Now().UTC().Format("20060102-150405") + "-" + hex.EncodeToString(b)
+}
+
+// SaveRun writes the run record to the state directory dir, redacting
+// secrets from its arguments and error
+func SaveRun(dir string, run Run) error {
+	if run.ID == "" || strings.ContainsAny(run.ID, `/\`) {
+		return fmt.Errorf("invalid run ID %q", run.ID)
+	}
+	// Prompts and error output can carry secrets pasted by the user
+	args := make([]string, len(run.Args))
+	for i, arg := range run.Args {
+		args[i] = security.Redact(arg)
+	}
+	run.Args = args
+	run.Error = security.Redact(run.Error)
+	return WriteJSON(filepath.Join(dir, RunsDir, run.ID+".json"), run)
+}
+
+// LoadRun reads the run record with the given ID
+func LoadRun(dir, id string) (Run, error) {
+	var run Run
+	if id == "" || strings.ContainsAny(id, `/\`) {
+		return run, fmt.Errorf("invalid run ID %q", id)
+	}
+	err := ReadJSON(filepath.Join(dir, RunsDir, id+".json"), &run)
+	return run, err
+}
+
+// Runs returns the recorded runs in dir, most recent first
+func Runs(dir string) ([]Run, error) {
+	entries, err := os.ReadDir(filepath.Join(dir, RunsDir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var runs []Run
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		var run Run
+		if err := ReadJSON(filepath.Join(dir, RunsDir, entry.Name()), &run); err != nil {
+			continue
+		}
+		runs = append(runs, run)
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].Started.After(runs[j].Started) })
+	return runs, nil
+}
+
+// AppendAudit appends event to the audit log in dir, redacting secrets from
+// its details
+func AppendAudit(dir string, event AuditEvent) error {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	if event.Details != nil {
+		event.Details = redactValue(event.Details).(map[string]interface{})
+	}
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	lock, err := Acquire(dir)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	f, err := os.OpenFile(filepath.Join(dir, AuditLogFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// redactValue returns a copy of an audit detail value with secrets in its
+// strings redacted
+func redactValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case string:
+		return security.Redact(v)
+	case []string:
+		out := make([]string, len(v))
+		for i, s := range v {
+			out[i] = security.Redact(s)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, e := range v {
+			out[i] = redactValue(e)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			out[k] = redactValue(e)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// GC removes run records and abandoned heartbeats older than retention, keeps
+// at most MaxRuns of the remaining runs, and rotates the audit log once it
+// exceeds MaxAuditLogSize
+func GC(dir string, retention time.Duration) error {
+	lock, err := Acquire(dir)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	runs, err := Runs(dir)
+	if err != nil {
+		return err
+	}
+	cutoff := time.Now().Add(-retention)
+	for i, run := range runs {
+		if i >= MaxRuns || run.Started.Before(cutoff) {
+			if err := os.Remove(filepath.Join(dir, RunsDir, run.ID+".json")); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+
+	// Heartbeats of sessions whose process died without cleaning up
+	if entries, err := os.ReadDir(filepath.Join(dir, HeartbeatsDir)); err == nil {
+		for _, entry := range entries {
+			if info, err := entry.Info(); err == nil && info.ModTime().Before(cutoff) {
+				os.Remove(filepath.Join(dir, HeartbeatsDir, entry.Name()))
+			}
+		}
+	}
+
+	auditPath := filepath.Join(dir, AuditLogFile)
+	if info, err := os.Stat(auditPath); err == nil && info.Size() > MaxAuditLogSize {
+		if err := os.Rename(auditPath, auditPath+".1"); err != nil {
+			return fmt.Errorf("failed to rotate audit log: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+// Package state manages enclaude's on-disk state and cache following the XDG
+// base directory layout:
+//
+//	$XDG_STATE_HOME/enclaude/   (default ~/.local/state/enclaude)
+//	  runs/<id>.json            run history and session metadata
+//	  heartbeats/<id>           touched while the session's enclaude process is alive
+//	  audit.log                 JSON-lines audit events
+//	  state.lock                serializes writers across enclaude processes
+//	  claude-dir.lock           session using the host ~/.claude read-write
+//	  telemetry.json            telemetry consent and install ID
+//	  telemetry-events.jsonl    usage events not yet sent
+//	$XDG_CACHE_HOME/enclaude/   (default ~/.cache/enclaude)
+//	  update-check.json         last release check
+//
+// State is kept until garbage collected; caches may be deleted at any time.
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// Layout of the state and cache directories
+const (
+	RunsDir            = "runs"
+	HeartbeatsDir      = "heartbeats"
+	AuditLogFile       = "audit.log"
+	LockFile           = "state.lock"
+	ClaudeDirLockFile  = "claude-dir.lock"
+	UpdateCheckFile    = "update-check.json"
+	TelemetryFile      = "telemetry.json"
+	TelemetrySpoolFile = "telemetry-events.jsonl"
+)
+
+const appName = "enclaude"
+
+// Dir returns enclaude's state directory, creating it if needed
+func Dir() (string, error) {
+	return xdgDir("XDG_STATE_HOME", filepath.Join(".local", "state"))
+}
+
+// CacheDir returns enclaude's cache directory, creating it if needed
+func CacheDir() (string, error) {
+	return xdgDir("XDG_CACHE_HOME", ".cache")
+}
+
+// xdgDir resolves the enclaude subdirectory of an XDG base directory. Relative
+// values are ignored, as the specification requires.
+func xdgDir(envVar, homeDefault string) (string, error) {
+	base := os.Getenv(envVar)
+	if !filepath.IsAbs(base) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve home directory: %w", err)
+		}
+		base = filepath.Join(home, homeDefault)
+	}
+	dir := filepath.Join(base, appName)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	return dir, nil
+}
+
+// Lock is an exclusive advisory lock on the state directory
+type Lock struct {
+	f *os.File
+}
+
+// Acquire blocks until it holds the lock for the state directory dir
+func Acquire(dir string) (*Lock, error) {
+	f, err := os.OpenFile(filepath.Join(dir, LockFile), os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open state lock: %w", err)
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to lock state directory: %w", err)
+	}
+	return &Lock{f: f}, nil
+}
+
+// Release unlocks the state directory
+func (l *Lock) Release() {
+	_ = syscall.Flock(int(l.f.Fd()), syscall.LOCK_UN)
+	l.f.Close()
+}
+
+// WriteJSON atomically writes v as JSON to path, so concurrent readers never
+// observe a partial file
+func WriteJSON(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// ReadJSON reads the JSON file at path into v
+func ReadJSON(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,251 @@
+// Package telemetry records anonymous, opt-in usage events for enclaude
+// itself: which command ran, how long it took, and the class of any error.
+// Paths, arguments, prompts, and error messages are never recorded.
+//
+// Events are spooled in the state directory and sent in the background on a
+// later run, so no command waits on the network.
+package telemetry
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/jakenelson/enclaude/internal/security"
+	"github.com/jakenelson/enclaude/internal/state"
+)
+
+// Endpoint receives batches of events; ENCLAUDE_TELEMETRY_URL overrides it
+var Endpoint = "https://telemetry.enclaude.dev/v1/events"
+
+// MaxPending caps the spool, so events that cannot be sent are dropped
+// rather than accumulating
+const MaxPending = 200
+
+// Consent is the user's telemetry decision, kept in the state directory
+// rather than the config so a shared project config cannot opt anyone in
+type Consent struct {
+	Enabled   bool      `json:"enabled"`
+	InstallID string    `json:"install_id,omitempty"` // Random, regenerated on each opt-in
+	DecidedAt time.Time `json:"decided_at"`
+}
+
+// Event is a single usage record
+type Event struct {
+	InstallID  string    `json:"install_id"`
+	Time       time.Time `json:"time"`
+	Version    string    `json:"version"`
+	OS         string    `json:"os"`
+	Arch       string    `json:"arch"`
+	Command    string    `json:"command"` // Command path only, e.g. "enclaude config set"
+	DurationMS int64     `json:"duration_ms"`
+	ExitCode   int       `json:"exit_code"`
+	ErrorClass string    `json:"error_class,omitempty"`
+}
+
+// DisabledByEnv reports whether the environment forbids telemetry regardless
+// of consent, through DO_NOT_TRACK or ENCLAUDE_TELEMETRY=off
+func DisabledByEnv() bool {
+	if v := os.Getenv("DO_NOT_TRACK"); v != "" && v != "0" {
+		return true
+	}
+	switch strings.ToLower(os.Getenv("ENCLAUDE_TELEMETRY")) {
+	case "off", "0", "false", "no":
+		return true
+	}
+	return false
+}
+
+// LoadConsent reads the consent recorded in the state directory dir. No
+// recorded decision means telemetry is off.
+func LoadConsent(dir string) (Consent, error) {
+	var c Consent
+	if err := state.ReadJSON(filepath.Join(dir, state.TelemetryFile), &c); err != nil && !os.IsNotExist(err) {
+		return Consent{}, fmt.Errorf("failed to read telemetry consent: %w", err)
+	}
+	return c, nil
+}
+
+// SetConsent records the user's decision. Opting in generates a new install
+// ID; opting out discards it and any events not yet sent.
+func SetConsent(dir string, enabled bool) (Consent, error) {
+	c := Consent{Enabled: enabled, DecidedAt: time.Now()}
+	if enabled {
+		b := make([]byte, 16)
+		if _, err := rand.Read(b); err != nil {
+			return Consent{}, err
+		}
+		c.InstallID = hex.EncodeToString(b)
+	} else if err := os.Remove(filepath.Join(dir, state.TelemetrySpoolFile)); err != nil && !os.IsNotExist(err) {
+		return Consent{}, err
+	}
+	if err := state.WriteJSON(filepath.Join(dir, state.TelemetryFile), c); err != nil {
+		return Consent{}, fmt.Errorf("failed to record telemetry consent: %w", err)
+	}
+	return c, nil
+}
+
+// NewEvent describes a finished command
+func NewEvent(c Consent, version, command string, d time.Duration, exitCode int, err error) Event {
+	return Event{
+		InstallID:  c.InstallID,
+		Time:       time.Now().UTC().Truncate(time.Hour), // Hourly, so events cannot be correlated with other logs
+		Version:    version,
+		OS:         runtime.GOOS,
+		Arch:       runtime.GOARCH,
+		Command:    command,
+		DurationMS: d.Milliseconds(),
+		ExitCode:   exitCode,
+		ErrorClass: ErrorClass(err),
+	}
+}
+
+// ErrorClass names the kind of err without its message, which can contain
+// paths or secrets: cancellation, timeout, or the Go type of the innermost
+// error
+func ErrorClass(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, os.ErrNotExist):
+		return "not-exist"
+	case errors.Is(err, os.ErrPermission):
+		return "permission"
+	}
+	for {
+		next := errors.Unwrap(err)
+		if next == nil {
+			break
+		}
+		err = next
+	}
+	return reflect.TypeOf(err).String()
+}
+
+// Record spools e in the state directory dir for a later Flush. Its strings
+// pass through the same redaction as run history and the audit log.
+func Record(dir string, e Event) error {
+	e.Command = security.Redact(e.Command)
+	e.ErrorClass = security.Redact(e.ErrorClass)
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	lock, err := state.Acquire(dir)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	path := filepath.Join(dir, state.TelemetrySpoolFile)
+	if n, _ := countLines(path); n >= MaxPending {
+		return nil
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// Pending returns the number of events not yet sent
+func Pending(dir string) int {
+	n, _ := countLines(filepath.Join(dir, state.TelemetrySpoolFile))
+	return n
+}
+
+// Flush sends spooled events and removes them once the endpoint accepts
+// them. Events recorded while the request is in flight are kept.
+func Flush(ctx context.Context, dir string) error {
+	path := filepath.Join(dir, state.TelemetrySpoolFile)
+	lock, err := state.Acquire(dir)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	lock.Release()
+	if err != nil || len(data) == 0 {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	var events []json.RawMessage
+	for _, line := range bytes.Split(bytes.TrimSpace(data), []byte("\n")) {
+		if json.Valid(line) {
+			events = append(events, line)
+		}
+	}
+	body, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+
+	endpoint := Endpoint
+	if v := os.Getenv("ENCLAUDE_TELEMETRY_URL"); v != "" {
+		endpoint = v
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send telemetry: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("failed to send telemetry: %s", resp.Status)
+	}
+
+	// Drop only what was sent
+	lock, err = state.Acquire(dir)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+	current, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	rest := bytes.TrimPrefix(current, data)
+	if len(rest) == 0 {
+		return os.Remove(path)
+	}
+	return os.WriteFile(path, rest, 0600)
+}
+
+func countLines(path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	n := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		n++
+	}
+	return n, scanner.Err()
+}
@@ -0,0 +1,20 @@
+package terminal
+
+import "strings"
+
+// Printable drops the C0 and C1 control characters from text the host did
+// not write, such as a session's transcript, so printing it cannot move the
+// cursor, retitle the window, or start an escape sequence. Tabs become
+// spaces, and bytes that are not UTF-8 become U+FFFD, so none reads as an
+// 8-bit control.
+func Printable(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '\t':
+			return ' '
+		case r < 0x20, r == 0x7f, r >= 0x80 && r <= 0x9f:
+			return -1
+		}
+		return r
+	}, s)
+}
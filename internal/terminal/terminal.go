@@ -0,0 +1,86 @@
+// Package terminal keeps the host terminal usable however a session ends.
+// The state from before raw mode is remembered so that any exit path,
+// including a panic, can restore it, and terminal modes that a full-screen
+// program turns on are reset when it does not exit cleanly.
+package terminal
+
+import (
+	"io"
+	"os"
+	"sync"
+
+	"github.com/moby/term"
+)
+
+// ResetModes turns off what an interactive program may leave on when it is
+// killed: text attributes, a hidden cursor, mouse reporting, focus events,
+// bracketed paste, and the kitty keyboard protocol
+const ResetModes = "\x1b[0m\x1b[?25h\x1b[?1000l\x1b[?1002l\x1b[?1003l\x1b[?1006l\x1b[?1004l\x1b[?2004l\x1b[<u"
+
+var (
+	mu    sync.Mutex
+	fd    uintptr
+	saved *term.State
+)
+
+// MakeRaw puts fd into raw mode, remembering its previous state for Restore
+func MakeRaw(f uintptr) error {
+	mu.Lock()
+	defer mu.Unlock()
+	state, err := term.SetRawTerminal(f)
+	if err != nil {
+		return err
+	}
+	if saved == nil {
+		fd, saved = f, state
+	}
+	return nil
+}
+
+// Save remembers fd's current state for Restore, for when a child process
+// such as kubectl puts the terminal into raw mode itself and may be killed
+// before it can undo it
+func Save(f uintptr) error {
+	mu.Lock()
+	defer mu.Unlock()
+	if saved != nil {
+		return nil
+	}
+	state, err := term.SaveState(f)
+	if err != nil {
+		return err
+	}
+	fd, saved = f, state
+	return nil
+}
+
+// Restore returns the terminal to the state remembered by MakeRaw or Save.
+// It may be called more than once and from any goroutine.
+func Restore() {
+	mu.Lock()
+	defer mu.Unlock()
+	if saved != nil {
+		term.RestoreTerminal(fd, saved)
+		saved = nil
+	}
+}
+
+// Reset writes ResetModes to w when it is a terminal and restores the
+// terminal, after a session that did not exit cleanly
+func Reset(w io.Writer) {
+	if f, ok := w.(*os.File); ok && term.IsTerminal(f.Fd()) {
+		io.WriteString(f, ResetModes)
+	}
+	Restore()
+}
+
+// Recover resets the terminal if the calling goroutine is panicking, then
+// lets the panic continue. Defer it first in goroutines that run while the
+// terminal is raw, since a panic there skips every other goroutine's
+// deferred restore.
+func Recover() {
+	if r := recover(); r != nil {
+		Reset(os.Stdout)
+		panic(r)
+	}
+}
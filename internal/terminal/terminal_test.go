@@ -0,0 +1,43 @@
+package terminal
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRecover(t *testing.T) {
+	defer func() {
+		if r := recover(); r != "boom" {
+			t.Errorf("recovered %v, want the original panic", r)
+		}
+	}()
+	defer Recover()
+	panic("boom")
+}
+
+func TestResetNonTerminal(t *testing.T) {
+	// Only terminals get the reset sequence
+	var buf bytes.Buffer
+	Reset(&buf)
+	if buf.Len() != 0 {
+		t.Errorf("Reset() wrote %q to a non-terminal", buf.String())
+	}
+	Restore()
+}
+
+func TestPrintable(t *testing.T) {
+	tests := map[string]string{
+		"go test ./...":                        "go test ./...",
+		"a\tb":                                 "a b",
+		"\x1b]0;pwned\x07rm -rf /":             "]0;pwnedrm -rf /",
+		"\x1b[2J\x1b[Hclear":                   "[2J[Hclear",
+		"csi \u009b31m red":                    "csi 31m red",
+		"raw \x9b byte":                        "raw � byte",
+		"bell\a, backspace\b, del\x7f, ümlaut": "bell, backspace, del, ümlaut",
+	}
+	for in, want := range tests {
+		if got := Printable(in); got != want {
+			t.Errorf("Printable(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
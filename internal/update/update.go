@@ -0,0 +1,173 @@
+// Package update checks GitHub for newer enclaude releases and retrieves
+// their release notes.
+package update
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ReleasesURL lists the project's GitHub releases, newest first
+var ReleasesURL = "https://api.github.com/repos/jakenelson/enclaude/releases"
+
+// CheckInterval is the minimum time between release checks
+const CheckInterval = 24 * time.Hour
+
+// Release is a published enclaude release
+type Release struct {
+	Version    string    `json:"tag_name"`
+	Name       string    `json:"name"`
+	Notes      string    `json:"body"`
+	URL        string    `json:"html_url"`
+	Published  time.Time `json:"published_at"`
+	Draft      bool      `json:"draft"`
+	Prerelease bool      `json:"prerelease"`
+}
+
+// state is the cached result of the last release check
+type state struct {
+	CheckedAt time.Time `json:"checked_at"`
+	Latest    string    `json:"latest"`
+}
+
+// Releases returns published, non-prerelease releases, newest first
+func Releases(ctx context.Context) ([]Release, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ReleasesURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch releases: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch releases: GitHub API returned %s", resp.Status)
+	}
+
+	var all []Release
+	if err := json.NewDecoder(resp.Body).Decode(&all); err != nil {
+		return nil, fmt.Errorf("failed to parse releases: %w", err)
+	}
+
+	var releases []Release
+	for _, r := range all {
+		if !r.Draft && !r.Prerelease {
+			releases = append(releases, r)
+		}
+	}
+	return releases, nil
+}
+
+// Between returns the releases newer than current, newest first. For
+// development builds, only the latest release is returned.
+func Between(releases []Release, current string) []Release {
+	if !IsRelease(current) {
+		if len(releases) > 0 {
+			return releases[:1]
+		}
+		return nil
+	}
+
+	var newer []Release
+	for _, r := range releases {
+		if Compare(r.Version, current) > 0 {
+			newer = append(newer, r)
+		}
+	}
+	return newer
+}
+
+// CachedLatest returns the latest version recorded by the last check, and
+// whether that check is older than CheckInterval
+func CachedLatest(statePath string) (latest string, stale bool) {
+	data, err := os.ReadFile(statePath)
+	if err != nil {
+		return "", true
+	}
+	var s state
+	if err := json.Unmarshal(data, &s); err != nil {
+		return "", true
+	}
+	return s.Latest, time.Since(s.CheckedAt) > CheckInterval
+}
+
+// Refresh looks up the latest release and records it at statePath. The check
+// time is recorded even on failure, so an unreachable API is not retried on
+// every run.
+func Refresh(ctx context.Context, statePath string) error {
+	latest, _ := CachedLatest(statePath)
+	releases, err := Releases(ctx)
+	if err == nil && len(releases) > 0 {
+		latest = releases[0].Version
+	}
+
+	data, _ := json.Marshal(state{CheckedAt: time.Now(), Latest: latest})
+	if mkErr := os.MkdirAll(filepath.Dir(statePath), 0755); mkErr != nil {
+		return mkErr
+	}
+	if writeErr := os.WriteFile(statePath, data, 0644); writeErr != nil {
+		return writeErr
+	}
+	return err
+}
+
+// IsRelease reports whether version is a release version rather than a
+// development build
+func IsRelease(version string) bool {
+	_, ok := parse(version)
+	return ok
+}
+
+// Compare compares two versions of the form v1.2.3, returning -1, 0, or 1.
+// Pre-release and build suffixes are ignored. Unparseable versions sort first.
+func Compare(a, b string) int {
+	pa, okA := parse(a)
+	pb, okB := parse(b)
+	switch {
+	case !okA && !okB:
+		return 0
+	case !okA:
+		return -1
+	case !okB:
+		return 1
+	}
+	for i := range pa {
+		if pa[i] != pb[i] {
+			if pa[i] < pb[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func parse(version string) ([3]int, bool) {
+	var parts [3]int
+	version = strings.TrimPrefix(version, "v")
+	if i := strings.IndexAny(version, "-+"); i >= 0 {
+		version = version[:i]
+	}
+	fields := strings.Split(version, ".")
+	if len(fields) == 0 || len(fields) > 3 {
+		return parts, false
+	}
+	for i, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil || n < 0 {
+			return parts, false
+		}
+		parts[i] = n
+	}
+	return parts, true
+}
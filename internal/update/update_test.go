@@ -0,0 +1,73 @@
+package update
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"v1.2.3", "v1.2.3", 0},
+		{"v1.2.3", "1.2.3", 0},
+		{"v1.10.0", "v1.9.9", 1},
+		{"v1.2", "v1.2.1", -1},
+		{"v2.0.0-rc1", "v2.0.0", 0},
+		{"dev", "v0.0.1", -1},
+	}
+
+	for _, tt := range tests {
+		if got := Compare(tt.a, tt.b); got != tt.want {
+			t.Errorf("Compare(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestBetween(t *testing.T) {
+	releases := []Release{{Version: "v1.3.0"}, {Version: "v1.2.0"}, {Version: "v1.1.0"}}
+
+	got := Between(releases, "v1.1.0")
+	if len(got) != 2 || got[0].Version != "v1.3.0" || got[1].Version != "v1.2.0" {
+		t.Errorf("Between(v1.1.0) = %+v, want v1.3.0 and v1.2.0", got)
+	}
+	if got := Between(releases, "v1.3.0"); len(got) != 0 {
+		t.Errorf("Between(latest) = %+v, want none", got)
+	}
+	if got := Between(releases, "dev"); len(got) != 1 || got[0].Version != "v1.3.0" {
+		t.Errorf("Between(dev) = %+v, want latest only", got)
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]Release{
+			{Version: "v2.0.0-rc1", Prerelease: true},
+			{Version: "v1.4.0"},
+			{Version: "v1.3.0"},
+		})
+	}))
+	defer server.Close()
+
+	orig := ReleasesURL
+	ReleasesURL = server.URL
+	defer func() { ReleasesURL = orig }()
+
+	statePath := filepath.Join(t.TempDir(), "update-check.json")
+	if latest, stale := CachedLatest(statePath); latest != "" || !stale {
+		t.Errorf("CachedLatest() before check = %q, %v; want empty and stale", latest, stale)
+	}
+
+	if err := Refresh(context.Background(), statePath); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	latest, stale := CachedLatest(statePath)
+	if latest != "v1.4.0" || stale {
+		t.Errorf("CachedLatest() after check = %q, %v; want v1.4.0 and fresh", latest, stale)
+	}
+}
@@ -0,0 +1,163 @@
+// Package web bridges a session container's TTY to WebSocket clients.
+// This is experimental and intended for companion web UIs and LAN attach.
+package web
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gorilla/websocket"
+)
+
+// Terminal is a TTY that WebSocket clients can attach to
+type Terminal interface {
+	Attach(ctx context.Context) (io.ReadWriteCloser, error)
+	Resize(ctx context.Context, rows, cols uint) error
+}
+
+// controlMessage is sent by clients as a text frame to control the terminal.
+// Binary frames carry raw terminal input.
+type controlMessage struct {
+	Type string `json:"type"` // resize
+	Rows uint   `json:"rows"`
+	Cols uint   `json:"cols"`
+}
+
+// Subprotocol is the WebSocket subprotocol clients offer. Browsers, which
+// cannot set headers on WebSocket requests, pass the token as a second
+// subprotocol, TokenSubprotocolPrefix followed by the token.
+const (
+	Subprotocol            = "enclaude"
+	TokenSubprotocolPrefix = "enclaude.token."
+)
+
+// Server serves the WebSocket attach endpoint
+type Server struct {
+	Token string
+	// AllowedOrigins are the browser origins, such as
+	// "https://ui.example.com", allowed to attach besides the server's own
+	AllowedOrigins []string
+	terminal       Terminal
+	upgrader       websocket.Upgrader
+}
+
+// NewServer creates a server for the given terminal with a random access token
+func NewServer(terminal Terminal) (*Server, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, fmt.Errorf("failed to generate access token: %w", err)
+	}
+
+	s := &Server{
+		Token:    hex.EncodeToString(buf),
+		terminal: terminal,
+	}
+	s.upgrader = websocket.Upgrader{
+		Subprotocols: []string{Subprotocol},
+		CheckOrigin:  s.checkOrigin,
+	}
+	return s, nil
+}
+
+// checkOrigin allows clients that are not browsers, which send no Origin,
+// pages served from the server's own host, and AllowedOrigins
+func (s *Server) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	for _, allowed := range s.AllowedOrigins {
+		if strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// ServeHTTP authenticates the request and bridges the WebSocket to the terminal
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	stream, err := s.terminal.Attach(r.Context())
+	if err != nil {
+		conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseInternalServerErr, err.Error()))
+		return
+	}
+	defer stream.Close()
+
+	// Terminal output to client
+	go func() {
+		buf := make([]byte, 32*1024)
+		for {
+			n, err := stream.Read(buf)
+			if n > 0 {
+				if werr := conn.WriteMessage(websocket.BinaryMessage, buf[:n]); werr != nil {
+					return
+				}
+			}
+			if err != nil {
+				conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "session ended"))
+				conn.Close()
+				return
+			}
+		}
+	}()
+
+	// Client input and control messages to terminal
+	for {
+		msgType, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		switch msgType {
+		case websocket.BinaryMessage:
+			if _, err := stream.Write(data); err != nil {
+				return
+			}
+		case websocket.TextMessage:
+			var msg controlMessage
+			if err := json.Unmarshal(data, &msg); err != nil {
+				continue
+			}
+			if msg.Type == "resize" && msg.Rows > 0 && msg.Cols > 0 {
+				s.terminal.Resize(r.Context(), msg.Rows, msg.Cols)
+			}
+		}
+	}
+}
+
+// authorized checks the bearer token from the Authorization header or the
+// token subprotocol. Tokens in the URL are not accepted, since URLs end up
+// in logs and browser history.
+func (s *Server) authorized(r *http.Request) bool {
+	var token string
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		token = strings.TrimPrefix(auth, "Bearer ")
+	}
+	for _, protocol := range websocket.Subprotocols(r) {
+		if t, ok := strings.CutPrefix(protocol, TokenSubprotocolPrefix); ok && token == "" {
+			token = t
+		}
+	}
+	return token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.Token)) == 1
+}
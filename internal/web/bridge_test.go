@@ -0,0 +1,138 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// fakeTerminal echoes input back as output and records resizes
+type fakeTerminal struct {
+	mu      sync.Mutex
+	resized [2]uint
+	pr      *io.PipeReader
+	pw      *io.PipeWriter
+}
+
+func newFakeTerminal() *fakeTerminal {
+	pr, pw := io.Pipe()
+	return &fakeTerminal{pr: pr, pw: pw}
+}
+
+func (f *fakeTerminal) Attach(ctx context.Context) (io.ReadWriteCloser, error) {
+	return f, nil
+}
+
+func (f *fakeTerminal) Resize(ctx context.Context, rows, cols uint) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.resized = [2]uint{rows, cols}
+	return nil
+}
+
+func (f *fakeTerminal) Read(p []byte) (int, error)  { return f.pr.Read(p) }
+func (f *fakeTerminal) Write(p []byte) (int, error) { return f.pw.Write(p) }
+func (f *fakeTerminal) Close() error                { return f.pw.Close() }
+
+func TestServer_RejectsMissingToken(t *testing.T) {
+	server, err := NewServer(newFakeTerminal())
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	// A token in the URL is not accepted
+	for _, url := range []string{ts.URL, ts.URL + "?token=wrong", ts.URL + "?token=" + server.Token} {
+		resp, err := http.Get(url)
+		if err != nil {
+			t.Fatalf("GET %s error = %v", url, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("GET %s status = %d, want %d", url, resp.StatusCode, http.StatusUnauthorized)
+		}
+	}
+}
+
+func TestServer_BridgesTerminal(t *testing.T) {
+	term := newFakeTerminal()
+	server, err := NewServer(term)
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(ts.URL, "http")
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, http.Header{"Authorization": {"Bearer " + server.Token}})
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.Close()
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"resize","rows":40,"cols":120}`)); err != nil {
+		t.Fatalf("WriteMessage(resize) error = %v", err)
+	}
+	if err := conn.WriteMessage(websocket.BinaryMessage, []byte("hello")); err != nil {
+		t.Fatalf("WriteMessage(input) error = %v", err)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("ReadMessage() error = %v", err)
+	}
+	if !bytes.Equal(data, []byte("hello")) {
+		t.Errorf("ReadMessage() = %q, want %q", data, "hello")
+	}
+
+	term.mu.Lock()
+	defer term.mu.Unlock()
+	if term.resized != [2]uint{40, 120} {
+		t.Errorf("Resize() got %v, want [40 120]", term.resized)
+	}
+}
+
+func TestServer_BrowserClients(t *testing.T) {
+	server, err := NewServer(newFakeTerminal())
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	server.AllowedOrigins = []string{"https://ui.example.com/"}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	wsURL := "ws" + strings.TrimPrefix(ts.URL, "http")
+
+	tests := []struct {
+		origin  string
+		token   string
+		wantErr bool
+	}{
+		{ts.URL, server.Token, false},
+		{"https://ui.example.com", server.Token, false},
+		{"https://evil.example.com", server.Token, true},
+		{ts.URL, "wrong", true},
+	}
+	for _, tt := range tests {
+		dialer := websocket.Dialer{Subprotocols: []string{Subprotocol, TokenSubprotocolPrefix + tt.token}}
+		conn, _, err := dialer.Dial(wsURL, http.Header{"Origin": {tt.origin}})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Dial() from %s with token %q error = %v, want error: %v", tt.origin, tt.token, err, tt.wantErr)
+		}
+		if err == nil {
+			if conn.Subprotocol() != Subprotocol {
+				t.Errorf("Subprotocol() = %q, want %q", conn.Subprotocol(), Subprotocol)
+			}
+			conn.Close()
+		}
+	}
+}
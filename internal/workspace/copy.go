@@ -0,0 +1,84 @@
+package workspace
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// CopyOptions configures a workspace snapshot
+type CopyOptions struct {
+	IncludeIgnored bool // Copy files excluded by .dockerignore/.gitignore
+}
+
+// Copy snapshots the workspace at src into dst, skipping paths excluded by
+// the workspace's ignore files unless IncludeIgnored is set. Ignored
+// directories are pruned entirely, so node_modules and build output are
+// never walked.
+func Copy(src, dst string, opts CopyOptions) error {
+	matcher := NewMatcher(nil)
+	if !opts.IncludeIgnored {
+		var err error
+		matcher, err = LoadMatcher(src)
+		if err != nil {
+			return fmt.Errorf("failed to read ignore files: %w", err)
+		}
+	}
+
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if relPath == "." {
+			return nil
+		}
+
+		if matcher.Match(relPath, info.IsDir()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		target := filepath.Join(dst, relPath)
+		switch {
+		case info.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm())
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case info.Mode().IsRegular():
+			return copyFile(path, target, info.Mode().Perm())
+		default:
+			// Skip sockets, devices, and named pipes
+			return nil
+		}
+	})
+}
+
+func copyFile(src, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,278 @@
+package workspace
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/jakenelson/enclaude/internal/security"
+)
+
+// GitInfo describes what a workspace's git repository needs from the sandbox
+// beyond the workspace mount itself
+type GitInfo struct {
+	IsRepo     bool
+	UsesLFS    bool      // .gitattributes routes files through the LFS filter
+	GitDir     *GitMount // Repository metadata outside the workspace (worktrees, submodule checkouts)
+	Submodules []Submodule
+}
+
+// GitMount maps a host directory to the path git expects in the container
+type GitMount struct {
+	Source string
+	Target string
+
+	// Worktree is set when the repository's core.worktree resolves somewhere
+	// other than the workspace in the container, as for a submodule checkout
+	// opened on its own; git commands fail there even with the mount
+	Worktree string
+}
+
+// Submodule is an entry from .gitmodules
+type Submodule struct {
+	Name        string
+	Path        string // Relative to the workspace, including parent submodules
+	URL         string
+	Initialized bool      // The submodule is checked out in the workspace
+	Local       *GitMount // Set when URL resolves to a repository on this host
+}
+
+// InspectGit reports how the repository at workDir is laid out, given that
+// the workspace is mounted at containerDir
+func InspectGit(workDir, containerDir string) (GitInfo, error) {
+	var info GitInfo
+
+	dotGit := filepath.Join(workDir, ".git")
+	fi, err := os.Stat(dotGit)
+	if os.IsNotExist(err) {
+		return info, nil
+	}
+	if err != nil {
+		return info, err
+	}
+	info.IsRepo = true
+
+	if !fi.IsDir() {
+		gitDir, err := externalGitDir(workDir, containerDir)
+		if err != nil {
+			return info, err
+		}
+		info.GitDir = gitDir
+	}
+
+	info.UsesLFS = usesLFS(filepath.Join(workDir, ".gitattributes"))
+
+	submodules, err := readSubmodules(workDir, containerDir)
+	if err != nil {
+		return info, fmt.Errorf("failed to read .gitmodules: %w", err)
+	}
+
+	// Checked-out submodules may use LFS or have submodules of their own
+	for _, sm := range submodules {
+		info.Submodules = append(info.Submodules, sm)
+		if !sm.Initialized {
+			continue
+		}
+		nested, err := InspectGit(filepath.Join(workDir, sm.Path), path.Join(containerDir, filepath.ToSlash(sm.Path)))
+		if err != nil {
+			return info, err
+		}
+		info.UsesLFS = info.UsesLFS || nested.UsesLFS
+		for _, n := range nested.Submodules {
+			n.Path = path.Join(filepath.ToSlash(sm.Path), n.Path)
+			info.Submodules = append(info.Submodules, n)
+		}
+	}
+	return info, nil
+}
+
+// externalGitDir resolves a .git file ("gitdir: <path>") to the metadata
+// directory that must be mounted, or nil if it is inside the workspace. For
+// linked worktrees the shared common directory is mounted, since it contains
+// the worktree's own gitdir.
+func externalGitDir(workDir, containerDir string) (*GitMount, error) {
+	ref, err := readGitFile(filepath.Join(workDir, ".git"), "gitdir:")
+	if err != nil {
+		return nil, err
+	}
+	host, target := resolveGitPath(workDir, containerDir, ref)
+
+	if common, err := readGitFile(filepath.Join(host, "commondir"), ""); err == nil && common != "" {
+		commonHost, commonTarget := resolveGitPath(host, target, common)
+		if security.IsPathInDirectory(host, commonHost) {
+			host, target = commonHost, commonTarget
+		}
+	}
+
+	if security.IsPathInDirectory(host, workDir) {
+		return nil, nil
+	}
+	mount := &GitMount{Source: host, Target: target}
+	if worktree := configWorktree(filepath.Join(host, "config")); worktree != "" {
+		if _, containerWorktree := resolveGitPath(host, target, worktree); containerWorktree != containerDir {
+			mount.Worktree = containerWorktree
+		}
+	}
+	return mount, nil
+}
+
+// configWorktree returns core.worktree from a repository config file
+func configWorktree(configFile string) string {
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		return ""
+	}
+	inCore := false
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") {
+			inCore = strings.EqualFold(strings.Trim(line, "[]"), "core")
+			continue
+		}
+		if key, value, ok := strings.Cut(line, "="); ok && inCore && strings.EqualFold(strings.TrimSpace(key), "worktree") {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// resolveGitPath resolves ref against a base directory known both on the host
+// and in the container. Relative references resolve the same way on both
+// sides; absolute ones keep their host path.
+func resolveGitPath(hostBase, containerBase, ref string) (string, string) {
+	if filepath.IsAbs(ref) {
+		return filepath.Clean(ref), filepath.ToSlash(filepath.Clean(ref))
+	}
+	return filepath.Join(hostBase, ref), path.Join(containerBase, filepath.ToSlash(ref))
+}
+
+// readGitFile returns the first line of a git metadata file, minus prefix
+func readGitFile(file, prefix string) (string, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	line := strings.TrimSpace(strings.SplitN(string(data), "\n", 2)[0])
+	if !strings.HasPrefix(line, prefix) {
+		return "", fmt.Errorf("unrecognized %s", file)
+	}
+	return strings.TrimSpace(strings.TrimPrefix(line, prefix)), nil
+}
+
+// usesLFS reports whether a .gitattributes file assigns the LFS filter
+func usesLFS(attributesFile string) bool {
+	data, err := os.ReadFile(attributesFile)
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "#") && strings.Contains(line, "filter=lfs") {
+			return true
+		}
+	}
+	return false
+}
+
+// readSubmodules parses .gitmodules in workDir
+func readSubmodules(workDir, containerDir string) ([]Submodule, error) {
+	f, err := os.Open(filepath.Join(workDir, ".gitmodules"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var submodules []Submodule
+	var current *Submodule
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			current = nil
+			section := strings.Trim(line, "[]")
+			if name, ok := strings.CutPrefix(section, "submodule "); ok {
+				submodules = append(submodules, Submodule{Name: strings.Trim(name, `"`)})
+				current = &submodules[len(submodules)-1]
+			}
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case "path":
+			current.Path = strings.TrimSpace(value)
+		case "url":
+			current.URL = strings.TrimSpace(value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	origin := originURL(workDir)
+	for i := range submodules {
+		sm := &submodules[i]
+		if sm.Path != "" {
+			_, err := os.Stat(filepath.Join(workDir, sm.Path, ".git"))
+			sm.Initialized = err == nil
+		}
+		sm.Local = localSubmoduleSource(sm.URL, origin, workDir, containerDir)
+	}
+	return submodules, nil
+}
+
+// localSubmoduleSource returns the host repository a submodule URL refers
+// to, if it is a local path. Relative URLs resolve against the origin remote
+// or, without one, against the superproject itself, as git does.
+func localSubmoduleSource(url, origin, workDir, containerDir string) *GitMount {
+	var host, target string
+	switch {
+	case strings.HasPrefix(url, "file://"):
+		host = filepath.Clean(strings.TrimPrefix(url, "file://"))
+		target = filepath.ToSlash(host)
+	case filepath.IsAbs(url):
+		host = filepath.Clean(url)
+		target = filepath.ToSlash(host)
+	case strings.HasPrefix(url, "./") || strings.HasPrefix(url, "../"):
+		switch {
+		case origin == "":
+			host, target = resolveGitPath(workDir, containerDir, url)
+		case strings.HasPrefix(origin, "file://") || filepath.IsAbs(origin):
+			base := filepath.Clean(strings.TrimPrefix(origin, "file://"))
+			host, target = resolveGitPath(base, filepath.ToSlash(base), url)
+		default:
+			return nil // Relative to a network remote
+		}
+	default:
+		return nil
+	}
+
+	if !security.DirExists(host) || security.IsPathInDirectory(host, workDir) {
+		return nil
+	}
+	return &GitMount{Source: host, Target: target}
+}
+
+// originURL returns the URL of the repository's origin remote, if any
+func originURL(workDir string) string {
+	out, err := exec.Command("git", "-C", workDir, "config", "--get", "remote.origin.url").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
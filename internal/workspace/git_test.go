@@ -0,0 +1,121 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInspectGit_NotRepo(t *testing.T) {
+	info, err := InspectGit(t.TempDir(), "/workspace")
+	if err != nil || info.IsRepo {
+		t.Errorf("InspectGit() = %+v, %v; want non-repo", info, err)
+	}
+}
+
+func TestInspectGit_LFSAndSubmodules(t *testing.T) {
+	root := t.TempDir()
+	work := filepath.Join(root, "app")
+	sibling := filepath.Join(root, "shared")
+
+	os.MkdirAll(filepath.Join(work, ".git"), 0755)
+	os.MkdirAll(sibling, 0755)
+	writeFile(t, filepath.Join(work, ".gitattributes"), "# assets\n*.psd filter=lfs diff=lfs merge=lfs -text\n")
+	writeFile(t, filepath.Join(work, ".gitmodules"), `[submodule "shared"]
+	path = libs/shared
+	url = ../shared
+[submodule "remote"]
+	path = libs/remote
+	url = https://github.com/acme/remote.git
+[submodule "vendored"]
+	path = vendor/tool
+	url = ../app/vendor-src
+`)
+	// libs/remote is checked out and has a nested submodule of its own
+	writeFile(t, filepath.Join(work, "libs", "remote", ".git"), "gitdir: ../../.git/modules/remote\n")
+	writeFile(t, filepath.Join(work, "libs", "remote", ".gitmodules"), "[submodule \"deep\"]\n\tpath = deep\n\turl = "+sibling+"\n")
+	os.MkdirAll(filepath.Join(work, "vendor-src"), 0755)
+
+	info, err := InspectGit(work, "/workspace")
+	if err != nil {
+		t.Fatalf("InspectGit() error = %v", err)
+	}
+	if !info.IsRepo || !info.UsesLFS || info.GitDir != nil {
+		t.Errorf("InspectGit() = %+v, want repo using LFS with internal gitdir", info)
+	}
+
+	want := map[string]struct {
+		initialized bool
+		local       *GitMount
+	}{
+		"libs/shared":      {false, &GitMount{Source: sibling, Target: "/shared"}},
+		"libs/remote":      {true, nil},
+		"vendor/tool":      {false, nil}, // Source is inside the workspace
+		"libs/remote/deep": {false, &GitMount{Source: sibling, Target: sibling}},
+	}
+	if len(info.Submodules) != len(want) {
+		t.Fatalf("Submodules = %+v, want %d entries", info.Submodules, len(want))
+	}
+	for _, sm := range info.Submodules {
+		w, ok := want[sm.Path]
+		if !ok {
+			t.Errorf("unexpected submodule %q", sm.Path)
+			continue
+		}
+		if sm.Initialized != w.initialized {
+			t.Errorf("%s: Initialized = %v, want %v", sm.Path, sm.Initialized, w.initialized)
+		}
+		switch {
+		case w.local == nil && sm.Local != nil:
+			t.Errorf("%s: Local = %+v, want nil", sm.Path, sm.Local)
+		case w.local != nil && (sm.Local == nil || *sm.Local != *w.local):
+			t.Errorf("%s: Local = %+v, want %+v", sm.Path, sm.Local, w.local)
+		}
+	}
+}
+
+func TestInspectGit_ExternalGitDir(t *testing.T) {
+	root := t.TempDir()
+
+	// Linked worktree: the shared common directory is mounted
+	mainGit := filepath.Join(root, "main", ".git")
+	worktreeGitDir := filepath.Join(mainGit, "worktrees", "feature")
+	writeFile(t, filepath.Join(worktreeGitDir, "commondir"), "../..\n")
+	worktree := filepath.Join(root, "feature")
+	writeFile(t, filepath.Join(worktree, ".git"), "gitdir: "+worktreeGitDir+"\n")
+
+	info, err := InspectGit(worktree, "/workspace")
+	if err != nil {
+		t.Fatalf("InspectGit() error = %v", err)
+	}
+	if info.GitDir == nil || info.GitDir.Source != mainGit || info.GitDir.Target != mainGit || info.GitDir.Worktree != "" {
+		t.Errorf("worktree GitDir = %+v, want %s mounted at the same path", info.GitDir, mainGit)
+	}
+
+	// Submodule checkout opened on its own: core.worktree points elsewhere
+	super := filepath.Join(root, "super")
+	moduleDir := filepath.Join(super, ".git", "modules", "lib")
+	writeFile(t, filepath.Join(moduleDir, "config"), "[core]\n\tbare = false\n\tworktree = ../../../lib\n")
+	writeFile(t, filepath.Join(super, "lib", ".git"), "gitdir: ../.git/modules/lib\n")
+
+	info, err = InspectGit(filepath.Join(super, "lib"), "/workspace")
+	if err != nil {
+		t.Fatalf("InspectGit() error = %v", err)
+	}
+	if info.GitDir == nil || info.GitDir.Source != moduleDir || info.GitDir.Target != "/.git/modules/lib" {
+		t.Fatalf("submodule GitDir = %+v, want %s at /.git/modules/lib", info.GitDir, moduleDir)
+	}
+	if info.GitDir.Worktree != "/lib" {
+		t.Errorf("submodule Worktree = %q, want /lib", info.GitDir.Worktree)
+	}
+}
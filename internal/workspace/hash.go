@@ -0,0 +1,88 @@
+package workspace
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// maxHashBytes bounds how much HashTree reads, so a large directory cannot
+// stall a session's start
+const maxHashBytes = 64 << 20
+
+// ErrTreeTooLarge is returned by HashTree for trees over its size limit
+var ErrTreeTooLarge = errors.New("too large to hash")
+
+// HashTree returns a SHA-256 digest of the file or directory at root,
+// covering every regular file's path relative to root, mode, and contents,
+// and every symlink's target, in lexical order. Sockets and other special
+// files are left out. Trees holding more than 64 MiB are not hashed.
+func HashTree(root string) (string, error) {
+	h := sha256.New()
+	var total int64
+	hashFile := func(path, rel string, mode fs.FileMode) error {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		fmt.Fprintf(h, "file %q %o\n", filepath.ToSlash(rel), mode.Perm())
+		n, err := io.Copy(h, io.LimitReader(f, maxHashBytes-total+1))
+		total += n
+		if err != nil {
+			return err
+		}
+		if total > maxHashBytes {
+			return fmt.Errorf("%s holds more than %d MiB: %w", root, maxHashBytes>>20, ErrTreeTooLarge)
+		}
+		return nil
+	}
+
+	info, err := os.Stat(root)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		if !info.Mode().IsRegular() {
+			return "", fmt.Errorf("%s is not a regular file or directory", root)
+		}
+		if err := hashFile(root, ".", info.Mode()); err != nil {
+			return "", err
+		}
+		return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+	}
+
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.Type()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(h, "link %q %q\n", filepath.ToSlash(rel), target)
+		case d.Type().IsRegular():
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			return hashFile(path, rel, info.Mode())
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,52 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHashTree(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "gh"), 0755)
+	os.WriteFile(filepath.Join(dir, "gh", "hosts.yml"), []byte("github.com:\n  user: me\n"), 0600)
+	os.Symlink("hosts.yml", filepath.Join(dir, "gh", "link"))
+
+	first, err := HashTree(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(first, "sha256:") {
+		t.Errorf("HashTree() = %q, want a sha256 digest", first)
+	}
+	if again, _ := HashTree(dir); again != first {
+		t.Errorf("HashTree() is not stable: %q then %q", first, again)
+	}
+
+	// Contents, modes, and new files all change the digest
+	for _, change := range []func(){
+		func() {
+			os.WriteFile(filepath.Join(dir, "gh", "hosts.yml"), []byte("github.com:\n  user: you\n"), 0600)
+		},
+		func() { os.Chmod(filepath.Join(dir, "gh", "hosts.yml"), 0644) },
+		func() { os.WriteFile(filepath.Join(dir, "gh", "config.yml"), nil, 0600) },
+	} {
+		change()
+		next, err := HashTree(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if next == first {
+			t.Error("HashTree() did not change after the tree changed")
+		}
+		first = next
+	}
+
+	if _, err := HashTree(filepath.Join(dir, "gh", "hosts.yml")); err != nil {
+		t.Errorf("HashTree() of a file: %v", err)
+	}
+	if _, err := HashTree(filepath.Join(dir, "missing")); err == nil {
+		t.Error("HashTree() of a missing path succeeded")
+	}
+}
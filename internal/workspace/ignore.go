@@ -0,0 +1,134 @@
+// Package workspace prepares the host working directory for mounting,
+// including copy-based modes that snapshot it into a staging directory.
+package workspace
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// IgnoreFiles are read from the workspace root, in order, to build the matcher
+var IgnoreFiles = []string{".dockerignore", ".gitignore"}
+
+// ignoreRule is a single compiled ignore pattern
+type ignoreRule struct {
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// Matcher decides whether workspace paths are excluded by ignore patterns.
+// Patterns follow .gitignore conventions: a pattern without a slash matches
+// at any depth, a leading slash anchors it to the root, a trailing slash
+// matches directories only, "**" spans directories, and "!" re-includes.
+type Matcher struct {
+	rules []ignoreRule
+}
+
+// LoadMatcher reads ignore patterns from the IgnoreFiles present in root
+func LoadMatcher(root string) (*Matcher, error) {
+	var patterns []string
+	for _, name := range IgnoreFiles {
+		f, err := os.Open(filepath.Join(root, name))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			patterns = append(patterns, scanner.Text())
+		}
+		f.Close()
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return NewMatcher(patterns), nil
+}
+
+// NewMatcher compiles ignore patterns; blank lines and comments are skipped
+func NewMatcher(patterns []string) *Matcher {
+	m := &Matcher{}
+	for _, p := range patterns {
+		p = strings.TrimSpace(p)
+		if p == "" || strings.HasPrefix(p, "#") {
+			continue
+		}
+
+		rule := ignoreRule{}
+		if strings.HasPrefix(p, "!") {
+			rule.negate = true
+			p = p[1:]
+		}
+		if strings.HasSuffix(p, "/") {
+			rule.dirOnly = true
+			p = strings.TrimRight(p, "/")
+		}
+
+		anchored := strings.Contains(p, "/")
+		p = strings.TrimPrefix(p, "/")
+		if p == "" {
+			continue
+		}
+
+		expr := globToRegexp(p)
+		if !anchored {
+			expr = "(.*/)?" + expr
+		}
+		re, err := regexp.Compile("^" + expr + "$")
+		if err != nil {
+			continue
+		}
+		rule.re = re
+		m.rules = append(m.rules, rule)
+	}
+	return m
+}
+
+// Match reports whether relPath (slash-separated, relative to the workspace
+// root) is ignored. The last matching rule wins.
+func (m *Matcher) Match(relPath string, isDir bool) bool {
+	relPath = filepath.ToSlash(relPath)
+	ignored := false
+	for _, rule := range m.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if rule.re.MatchString(relPath) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// globToRegexp converts a glob pattern to a regular expression fragment
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				i++
+				if i+1 < len(glob) && glob[i+1] == '/' {
+					i++
+					b.WriteString("(.*/)?")
+				} else {
+					b.WriteString(".*")
+				}
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,81 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatcher(t *testing.T) {
+	m := NewMatcher([]string{
+		"# comment",
+		"node_modules/",
+		"/build",
+		"*.log",
+		"!keep.log",
+		"docs/**/*.tmp",
+	})
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"node_modules", true, true},
+		{"web/node_modules", true, true},
+		{"node_modules", false, false}, // dir-only pattern
+		{"build", true, true},
+		{"src/build", true, false}, // anchored to root
+		{"debug.log", false, true},
+		{"logs/app/debug.log", false, true},
+		{"keep.log", false, false}, // negated
+		{"docs/a/b/c.tmp", false, true},
+		{"docs/c.tmp", false, true},
+		{"src/main.go", false, false},
+	}
+
+	for _, tt := range tests {
+		if got := m.Match(tt.path, tt.isDir); got != tt.want {
+			t.Errorf("Match(%q, %v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+		}
+	}
+}
+
+func TestCopy(t *testing.T) {
+	src := t.TempDir()
+	dst := t.TempDir()
+
+	files := map[string]string{
+		".gitignore":                "node_modules/\n*.log\n",
+		"main.go":                   "package main",
+		"debug.log":                 "noise",
+		"node_modules/pkg/index.js": "module.exports = {}",
+	}
+	for name, content := range files {
+		path := filepath.Join(src, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	if err := Copy(src, dst, CopyOptions{}); err != nil {
+		t.Fatalf("Copy() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "main.go")); err != nil {
+		t.Error("Copy() should copy main.go")
+	}
+	for _, ignored := range []string{"debug.log", "node_modules"} {
+		if _, err := os.Stat(filepath.Join(dst, ignored)); !os.IsNotExist(err) {
+			t.Errorf("Copy() should skip ignored path %s", ignored)
+		}
+	}
+
+	all := t.TempDir()
+	if err := Copy(src, all, CopyOptions{IncludeIgnored: true}); err != nil {
+		t.Fatalf("Copy(IncludeIgnored) error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(all, "node_modules", "pkg", "index.js")); err != nil {
+		t.Error("Copy(IncludeIgnored) should copy ignored files")
+	}
+}
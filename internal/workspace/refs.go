@@ -0,0 +1,206 @@
+package workspace
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Ref is a directory outside the workspace that the workspace's build
+// configuration points at, a candidate for mounting read-only with --ref
+type Ref struct {
+	Path   string // Absolute host path
+	Source string // Configuration file that refers to it, relative to the workspace
+}
+
+// SuggestRefs returns the directories outside root that go.mod replace
+// directives and tsconfig.json path mappings and project references point
+// at. Directories that do not exist are left out, as are ones inside
+// another suggestion.
+func SuggestRefs(root string) []Ref {
+	var refs []Ref
+	add := func(source string, paths []string) {
+		for _, p := range paths {
+			p = existingDir(p)
+			if p == "" || inDir(p, root) {
+				continue
+			}
+			refs = append(refs, Ref{Path: p, Source: source})
+		}
+	}
+	add("go.mod", goModReplaces(filepath.Join(root, "go.mod")))
+	add("tsconfig.json", tsconfigPaths(filepath.Join(root, "tsconfig.json")))
+
+	sort.Slice(refs, func(i, j int) bool { return refs[i].Path < refs[j].Path })
+	var out []Ref
+	for _, r := range refs {
+		if len(out) > 0 && inDir(r.Path, out[len(out)-1].Path) {
+			continue
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// goModReplaces returns the local directories that replace directives in
+// the go.mod at path point at
+func goModReplaces(path string) []string {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var dirs []string
+	inBlock := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "//")
+		line = strings.TrimSpace(line)
+		switch {
+		case inBlock && line == ")":
+			inBlock = false
+			continue
+		case line == "replace (":
+			inBlock = true
+			continue
+		case strings.HasPrefix(line, "replace "):
+			line = strings.TrimPrefix(line, "replace ")
+		case !inBlock:
+			continue
+		}
+		_, target, ok := strings.Cut(line, "=>")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(target)
+		if len(fields) == 0 {
+			continue
+		}
+		// Module paths are replaced by directories only when the target
+		// is written as a filesystem path
+		dir := strings.Trim(fields[0], "\"`")
+		if filepath.IsAbs(dir) || strings.HasPrefix(dir, "./") || strings.HasPrefix(dir, "../") {
+			dirs = append(dirs, resolve(filepath.Dir(path), dir))
+		}
+	}
+	return dirs
+}
+
+// tsconfigPaths returns the directories that compilerOptions.paths and
+// project references in the tsconfig.json at path point at
+func tsconfigPaths(path string) []string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var tsconfig struct {
+		CompilerOptions struct {
+			BaseURL string              `json:"baseUrl"`
+			Paths   map[string][]string `json:"paths"`
+		} `json:"compilerOptions"`
+		References []struct {
+			Path string `json:"path"`
+		} `json:"references"`
+	}
+	if err := json.Unmarshal(stripJSONC(data), &tsconfig); err != nil {
+		return nil
+	}
+
+	dir := filepath.Dir(path)
+	// Mappings are relative to baseUrl, or to the tsconfig without one
+	base := dir
+	if tsconfig.CompilerOptions.BaseURL != "" {
+		base = resolve(dir, tsconfig.CompilerOptions.BaseURL)
+	}
+	var dirs []string
+	for _, targets := range tsconfig.CompilerOptions.Paths {
+		for _, t := range targets {
+			// "../lib/src/*" maps into the directory holding the wildcard
+			if i := strings.Index(t, "*"); i >= 0 {
+				t = t[:i]
+			}
+			dirs = append(dirs, resolve(base, t))
+		}
+	}
+	for _, r := range tsconfig.References {
+		dirs = append(dirs, resolve(dir, r.Path))
+	}
+	return dirs
+}
+
+// stripJSONC removes the comments and trailing commas tsconfig files allow
+func stripJSONC(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	inString := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case inString:
+			out = append(out, c)
+			if c == '\\' && i+1 < len(data) {
+				i++
+				out = append(out, data[i])
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+			out = append(out, c)
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			i--
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			end := strings.Index(string(data[i+2:]), "*/")
+			if end < 0 {
+				return out
+			}
+			i += end + 3
+		case c == '}' || c == ']':
+			// Drop a comma left before the closing bracket
+			j := len(out) - 1
+			for j >= 0 && strings.ContainsRune(" \t\r\n", rune(out[j])) {
+				j--
+			}
+			if j >= 0 && out[j] == ',' {
+				out = append(out[:j], out[j+1:]...)
+			}
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// resolve joins p onto dir unless it is absolute
+func resolve(dir, p string) string {
+	if filepath.IsAbs(p) {
+		return filepath.Clean(p)
+	}
+	return filepath.Join(dir, p)
+}
+
+// existingDir returns p if it is a directory, the directory holding it if p
+// is a file, or "" if neither exists
+func existingDir(p string) string {
+	info, err := os.Stat(p)
+	if err != nil {
+		return ""
+	}
+	if !info.IsDir() {
+		return filepath.Dir(p)
+	}
+	return p
+}
+
+// inDir reports whether p is dir or inside it
+func inDir(p, dir string) bool {
+	rel, err := filepath.Rel(dir, p)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
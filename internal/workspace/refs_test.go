@@ -0,0 +1,59 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSuggestRefs(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "app")
+	for _, d := range []string{"app/internal", "shared/pkg", "proto", "ui/src", "tools"} {
+		os.MkdirAll(filepath.Join(dir, d), 0755)
+	}
+	os.WriteFile(filepath.Join(dir, "ui", "src", "index.ts"), nil, 0644)
+
+	gomod := `module example.com/app
+
+replace example.com/shared => ../shared // local checkout
+replace example.com/internal => ./internal
+
+replace (
+	example.com/proto v1.2.0 => ../proto
+	example.com/pinned => example.com/fork v1.0.0
+	example.com/shared/pkg => ../shared/pkg
+	example.com/gone => ../gone
+)
+`
+	os.WriteFile(filepath.Join(root, "go.mod"), []byte(gomod), 0644)
+
+	tsconfig := `{
+  // JSONC, as tsc accepts it
+  "compilerOptions": {
+    "baseUrl": ".",
+    "paths": {
+      "@ui/*": ["../ui/src/*"], /* wildcard */
+      "@ui": ["../ui/src/index.ts"],
+      "@local/*": ["./internal/*"],
+    },
+  },
+  "references": [{ "path": "../tools" }, { "path": "//not-a-comment" },],
+}`
+	os.WriteFile(filepath.Join(root, "tsconfig.json"), []byte(tsconfig), 0644)
+
+	want := []Ref{
+		{Path: filepath.Join(dir, "proto"), Source: "go.mod"},
+		{Path: filepath.Join(dir, "shared"), Source: "go.mod"},
+		{Path: filepath.Join(dir, "tools"), Source: "tsconfig.json"},
+		{Path: filepath.Join(dir, "ui", "src"), Source: "tsconfig.json"},
+	}
+	if got := SuggestRefs(root); !reflect.DeepEqual(got, want) {
+		t.Errorf("SuggestRefs() = %+v, want %+v", got, want)
+	}
+
+	if got := SuggestRefs(filepath.Join(dir, "tools")); got != nil {
+		t.Errorf("SuggestRefs() without config = %+v, want none", got)
+	}
+}
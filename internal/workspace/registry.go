@@ -0,0 +1,167 @@
+package workspace
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/jakenelson/enclaude/internal/security"
+)
+
+// Pin holds the settings a workspace is pinned to
+type Pin struct {
+	Image   string `json:"image,omitempty"`
+	Profile string `json:"profile,omitempty"`
+	Auth    string `json:"auth,omitempty"` // Claude auth chosen when both an API key and a session exist
+}
+
+// Registry maps workspace directories to their pinned settings, and records
+// the workspaces the user has trusted. It is stored centrally rather than in
+// the project, so neither ends up in version control.
+type Registry struct {
+	path       string
+	Workspaces map[string]Pin       `json:"workspaces"`
+	Trusted    map[string]time.Time `json:"trusted,omitempty"` // When each directory was trusted
+
+	// Digest of each project config file whose restricted settings the user
+	// approved
+	Approved map[string]string `json:"approved,omitempty"`
+}
+
+// LoadRegistry reads the registry at path; a missing file is an empty registry
+func LoadRegistry(path string) (*Registry, error) {
+	r := &Registry{path: path, Workspaces: make(map[string]Pin), Trusted: make(map[string]time.Time), Approved: make(map[string]string)}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read workspace registry: %w", err)
+	}
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, fmt.Errorf("failed to parse workspace registry %s: %w", path, err)
+	}
+	if r.Workspaces == nil {
+		r.Workspaces = make(map[string]Pin)
+	}
+	if r.Trusted == nil {
+		r.Trusted = make(map[string]time.Time)
+	}
+	if r.Approved == nil {
+		r.Approved = make(map[string]string)
+	}
+	return r, nil
+}
+
+// Save writes the registry back to its file
+func (r *Registry) Save() error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return fmt.Errorf("failed to create registry directory: %w", err)
+	}
+	if err := os.WriteFile(r.path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write workspace registry: %w", err)
+	}
+	return nil
+}
+
+// Pin records pin for the workspace at dir, replacing any existing pin
+func (r *Registry) Pin(dir string, pin Pin) {
+	r.Workspaces[filepath.Clean(dir)] = pin
+}
+
+// Unpin removes the pin for exactly dir, reporting whether one existed
+func (r *Registry) Unpin(dir string) bool {
+	dir = filepath.Clean(dir)
+	_, ok := r.Workspaces[dir]
+	delete(r.Workspaces, dir)
+	return ok
+}
+
+// Lookup returns the pin that applies to dir: the pin of dir itself or of
+// its nearest pinned ancestor, so subdirectories of a project share its pin
+func (r *Registry) Lookup(dir string) (Pin, string, bool) {
+	dir = filepath.Clean(dir)
+	best := ""
+	for pinned := range r.Workspaces {
+		if security.IsPathInDirectory(dir, pinned) && len(pinned) > len(best) {
+			best = pinned
+		}
+	}
+	if best == "" {
+		return Pin{}, "", false
+	}
+	return r.Workspaces[best], best, true
+}
+
+// Dirs returns the pinned workspace directories in sorted order
+func (r *Registry) Dirs() []string {
+	dirs := make([]string, 0, len(r.Workspaces))
+	for dir := range r.Workspaces {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	return dirs
+}
+
+// Trust records that the workspace at dir, and everything below it, is
+// trusted as of at
+func (r *Registry) Trust(dir string, at time.Time) {
+	r.Trusted[filepath.Clean(dir)] = at
+}
+
+// Untrust removes the trust of exactly dir, reporting whether it was
+// trusted, along with the approval of every project config below it
+func (r *Registry) Untrust(dir string) bool {
+	dir = filepath.Clean(dir)
+	_, ok := r.Trusted[dir]
+	delete(r.Trusted, dir)
+	for file := range r.Approved {
+		if security.IsPathInDirectory(file, dir) {
+			delete(r.Approved, file)
+		}
+	}
+	return ok
+}
+
+// Approve records that the user approved the project config file with the
+// given content digest
+func (r *Registry) Approve(file, digest string) {
+	r.Approved[filepath.Clean(file)] = digest
+}
+
+// IsApproved reports whether the project config file was approved with
+// exactly the given content digest, so any edit needs approving again
+func (r *Registry) IsApproved(file, digest string) bool {
+	approved, ok := r.Approved[filepath.Clean(file)]
+	return ok && approved == digest
+}
+
+// TrustedBy returns the trusted directory dir is in: dir itself or its
+// nearest trusted ancestor
+func (r *Registry) TrustedBy(dir string) (string, bool) {
+	dir = filepath.Clean(dir)
+	best := ""
+	for trusted := range r.Trusted {
+		if security.IsPathInDirectory(dir, trusted) && len(trusted) > len(best) {
+			best = trusted
+		}
+	}
+	return best, best != ""
+}
+
+// TrustedDirs returns the trusted workspace directories in sorted order
+func (r *Registry) TrustedDirs() []string {
+	dirs := make([]string, 0, len(r.Trusted))
+	for dir := range r.Trusted {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	return dirs
+}
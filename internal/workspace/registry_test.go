@@ -0,0 +1,147 @@
+package workspace
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRegistry_Lookup(t *testing.T) {
+	r := &Registry{Workspaces: map[string]Pin{
+		"/home/user/src/app":          {Image: "enclaude:go1.22"},
+		"/home/user/src/app/frontend": {Image: "enclaude:node", Profile: "web"},
+		"/home/user/work":             {Profile: "work"},
+	}}
+
+	tests := []struct {
+		name    string
+		dir     string
+		wantDir string
+		wantPin Pin
+		wantOK  bool
+	}{
+		{"exact", "/home/user/src/app", "/home/user/src/app", Pin{Image: "enclaude:go1.22"}, true},
+		{"subdirectory", "/home/user/src/app/cmd/server", "/home/user/src/app", Pin{Image: "enclaude:go1.22"}, true},
+		{"nearest ancestor wins", "/home/user/src/app/frontend/src", "/home/user/src/app/frontend", Pin{Image: "enclaude:node", Profile: "web"}, true},
+		{"trailing slash", "/home/user/work/", "/home/user/work", Pin{Profile: "work"}, true},
+		{"sibling with shared prefix", "/home/user/src/application", "", Pin{}, false},
+		{"unpinned", "/tmp", "", Pin{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pin, dir, ok := r.Lookup(tt.dir)
+			if ok != tt.wantOK || dir != tt.wantDir || pin != tt.wantPin {
+				t.Errorf("Lookup(%q) = %+v, %q, %v; want %+v, %q, %v", tt.dir, pin, dir, ok, tt.wantPin, tt.wantDir, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestRegistry_SaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "enclaude", "workspaces.json")
+
+	r, err := LoadRegistry(path)
+	if err != nil {
+		t.Fatalf("LoadRegistry() on missing file error = %v", err)
+	}
+	if len(r.Workspaces) != 0 {
+		t.Fatalf("LoadRegistry() on missing file = %v, want empty", r.Workspaces)
+	}
+
+	r.Pin("/src/a/", Pin{Image: "enclaude:a"})
+	r.Pin("/src/b", Pin{Profile: "work"})
+	if err := r.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := LoadRegistry(path)
+	if err != nil {
+		t.Fatalf("LoadRegistry() error = %v", err)
+	}
+	if got := loaded.Dirs(); len(got) != 2 || got[0] != "/src/a" || got[1] != "/src/b" {
+		t.Errorf("Dirs() = %v, want [/src/a /src/b]", got)
+	}
+	if loaded.Workspaces["/src/b"].Profile != "work" {
+		t.Errorf("loaded pin = %+v, want profile work", loaded.Workspaces["/src/b"])
+	}
+
+	if !loaded.Unpin("/src/a") {
+		t.Error("Unpin() = false for a pinned workspace")
+	}
+	if loaded.Unpin("/src/a") {
+		t.Error("Unpin() = true for an unpinned workspace")
+	}
+}
+
+func TestRegistry_Trust(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "workspaces.json")
+	r, err := LoadRegistry(path)
+	if err != nil {
+		t.Fatalf("LoadRegistry() error = %v", err)
+	}
+
+	if _, ok := r.TrustedBy("/home/user/src/app"); ok {
+		t.Error("TrustedBy() = true in an empty registry")
+	}
+
+	r.Trust("/home/user/src/app/", time.Now())
+	if err := r.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	loaded, err := LoadRegistry(path)
+	if err != nil {
+		t.Fatalf("LoadRegistry() error = %v", err)
+	}
+
+	tests := []struct {
+		dir    string
+		wantOK bool
+	}{
+		{"/home/user/src/app", true},
+		{"/home/user/src/app/cmd", true},
+		{"/home/user/src/application", false},
+		{"/home/user/src", false},
+	}
+	for _, tt := range tests {
+		if dir, ok := loaded.TrustedBy(tt.dir); ok != tt.wantOK || (ok && dir != "/home/user/src/app") {
+			t.Errorf("TrustedBy(%q) = %q, %v; want %v", tt.dir, dir, ok, tt.wantOK)
+		}
+	}
+
+	if !loaded.Untrust("/home/user/src/app") {
+		t.Error("Untrust() = false for a trusted workspace")
+	}
+	if _, ok := loaded.TrustedBy("/home/user/src/app"); ok {
+		t.Error("TrustedBy() = true after Untrust()")
+	}
+}
+
+func TestRegistry_Approve(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "workspaces.json")
+	r, err := LoadRegistry(path)
+	if err != nil {
+		t.Fatalf("LoadRegistry() error = %v", err)
+	}
+	file := "/home/user/src/app/.enclaude.yaml"
+	r.Trust("/home/user/src/app", time.Now())
+	r.Approve(file, "abc")
+	if err := r.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	loaded, err := LoadRegistry(path)
+	if err != nil {
+		t.Fatalf("LoadRegistry() error = %v", err)
+	}
+
+	if !loaded.IsApproved(file, "abc") {
+		t.Error("IsApproved() = false for the approved digest")
+	}
+	if loaded.IsApproved(file, "def") {
+		t.Error("IsApproved() = true after the file changed")
+	}
+	loaded.Untrust("/home/user/src/app")
+	if loaded.IsApproved(file, "abc") {
+		t.Error("IsApproved() = true after the workspace was untrusted")
+	}
+}
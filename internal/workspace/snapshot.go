@@ -0,0 +1,96 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// fileState is what a Snapshot records about each file
+type fileState struct {
+	size    int64
+	modTime time.Time
+	mode    os.FileMode
+}
+
+// Snapshot records the size, modification time, and mode of every file in a
+// workspace, so changes made during a session can be counted afterwards
+// without keeping a copy of the contents
+type Snapshot map[string]fileState
+
+// Changes lists workspace paths, relative to the root, that differ between
+// two snapshots
+type Changes struct {
+	Added    []string
+	Modified []string
+	Removed  []string
+}
+
+// Count returns the total number of changed paths
+func (c Changes) Count() int {
+	return len(c.Added) + len(c.Modified) + len(c.Removed)
+}
+
+// TakeSnapshot records the files under root. Paths excluded by the
+// workspace's ignore files are skipped, as is the .git directory, so build
+// output and git's own bookkeeping are not reported as changes.
+func TakeSnapshot(root string) (Snapshot, error) {
+	matcher, err := LoadMatcher(root)
+	if err != nil {
+		return nil, err
+	}
+
+	snap := Snapshot{}
+	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			// Files may vanish while a session is still writing
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		relPath, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if relPath == "." {
+			return nil
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" || matcher.Match(relPath, true) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if matcher.Match(relPath, false) {
+			return nil
+		}
+		snap[relPath] = fileState{size: info.Size(), modTime: info.ModTime(), mode: info.Mode()}
+		return nil
+	})
+	return snap, err
+}
+
+// Diff returns the paths added, modified, or removed between s and after
+func (s Snapshot) Diff(after Snapshot) Changes {
+	var changes Changes
+	for path, now := range after {
+		before, ok := s[path]
+		switch {
+		case !ok:
+			changes.Added = append(changes.Added, path)
+		case before != now:
+			changes.Modified = append(changes.Modified, path)
+		}
+	}
+	for path := range s {
+		if _, ok := after[path]; !ok {
+			changes.Removed = append(changes.Removed, path)
+		}
+	}
+	sort.Strings(changes.Added)
+	sort.Strings(changes.Modified)
+	sort.Strings(changes.Removed)
+	return changes
+}
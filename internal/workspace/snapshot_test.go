@@ -0,0 +1,60 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSnapshotDiff(t *testing.T) {
+	root := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(".gitignore", "build/\n")
+	write("keep.go", "package keep")
+	write("edit.go", "package edit")
+	write("remove.go", "package remove")
+	write(".git/HEAD", "ref: refs/heads/main")
+
+	before, err := TakeSnapshot(root)
+	if err != nil {
+		t.Fatalf("TakeSnapshot() error = %v", err)
+	}
+
+	write("edit.go", "package edited")
+	past := time.Now().Add(-time.Hour)
+	os.Chtimes(filepath.Join(root, "edit.go"), past, past)
+	os.Remove(filepath.Join(root, "remove.go"))
+	write("pkg/new.go", "package pkg")
+	write("build/out.bin", "ignored")
+	write(".git/HEAD", "ref: refs/heads/feature")
+
+	after, err := TakeSnapshot(root)
+	if err != nil {
+		t.Fatalf("TakeSnapshot() error = %v", err)
+	}
+
+	want := Changes{
+		Added:    []string{filepath.Join("pkg", "new.go")},
+		Modified: []string{"edit.go"},
+		Removed:  []string{"remove.go"},
+	}
+	got := before.Diff(after)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff() = %+v, want %+v", got, want)
+	}
+	if got.Count() != 3 {
+		t.Errorf("Count() = %d, want 3", got.Count())
+	}
+}
@@ -0,0 +1,140 @@
+package workspace
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Usage records the size of every file in a workspace and the directories
+// that hold them. Unlike Snapshot it includes ignored paths, since those are
+// where dependencies and build output usually pile up.
+type Usage struct {
+	files map[string]int64
+	dirs  map[string]bool
+}
+
+// PathGrowth is the space a session added under one path
+type PathGrowth struct {
+	Path  string // Relative to the workspace root
+	Dir   bool
+	Bytes int64
+	Files int
+}
+
+// Growth is the change in a workspace's size between two measurements
+type Growth struct {
+	Bytes   int64 // May be negative if the session freed space
+	Files   int
+	Largest []PathGrowth // Largest new or grown paths, largest first
+}
+
+// MeasureUsage records the files under root. Symlinks are not followed.
+func MeasureUsage(root string) (Usage, error) {
+	u := Usage{files: map[string]int64{}, dirs: map[string]bool{}}
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Files may vanish while a session is still writing
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		relPath, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if relPath == "." {
+			return nil
+		}
+		if d.IsDir() {
+			u.dirs[relPath] = true
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		u.files[relPath] = info.Size()
+		return nil
+	})
+	return u, err
+}
+
+// Bytes returns the total size of the files
+func (u Usage) Bytes() int64 {
+	var total int64
+	for _, size := range u.files {
+		total += size
+	}
+	return total
+}
+
+// Files returns the number of files
+func (u Usage) Files() int {
+	return len(u.files)
+}
+
+// Growth compares u with after. Space added under a directory that did not
+// exist before, such as a fresh node_modules, is reported against the
+// outermost new directory; space added to existing directories is reported
+// against each file. At most limit paths are listed.
+func (u Usage) Growth(after Usage, limit int) Growth {
+	g := Growth{Bytes: after.Bytes() - u.Bytes(), Files: after.Files() - u.Files()}
+
+	byPath := map[string]*PathGrowth{}
+	for path, size := range after.files {
+		added := size - u.files[path]
+		if added <= 0 {
+			continue
+		}
+		key, dir := path, false
+		if root := u.newRoot(path); root != "" {
+			key, dir = root, true
+		}
+		p, ok := byPath[key]
+		if !ok {
+			p = &PathGrowth{Path: key, Dir: dir}
+			byPath[key] = p
+		}
+		p.Bytes += added
+		if _, existed := u.files[path]; !existed {
+			p.Files++
+		}
+	}
+
+	for _, p := range byPath {
+		g.Largest = append(g.Largest, *p)
+	}
+	sort.Slice(g.Largest, func(i, j int) bool {
+		if g.Largest[i].Bytes != g.Largest[j].Bytes {
+			return g.Largest[i].Bytes > g.Largest[j].Bytes
+		}
+		return g.Largest[i].Path < g.Largest[j].Path
+	})
+	if len(g.Largest) > limit {
+		g.Largest = g.Largest[:limit]
+	}
+	return g
+}
+
+// newRoot returns the outermost directory containing path that u did not
+// have, or "" if path's directory already existed
+func (u Usage) newRoot(path string) string {
+	parts := strings.Split(filepath.Dir(path), string(filepath.Separator))
+	if parts[0] == "." {
+		return ""
+	}
+	for i := range parts {
+		dir := filepath.Join(parts[:i+1]...)
+		if !u.dirs[dir] {
+			return dir
+		}
+	}
+	return ""
+}
@@ -0,0 +1,58 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUsageGrowth(t *testing.T) {
+	root := t.TempDir()
+	write := func(name string, size int) {
+		t.Helper()
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(strings.Repeat("x", size)), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(".gitignore", 10)
+	write("app.log", 100)
+	write("src/main.go", 50)
+
+	before, err := MeasureUsage(root)
+	if err != nil {
+		t.Fatalf("MeasureUsage() error = %v", err)
+	}
+	if before.Bytes() != 160 || before.Files() != 3 {
+		t.Errorf("MeasureUsage() = %d bytes in %d files, want 160 in 3", before.Bytes(), before.Files())
+	}
+
+	write("node_modules/a/index.js", 1000)
+	write("node_modules/b/lib/index.js", 2000)
+	write("app.log", 600)
+	write("src/gen.go", 300)
+	os.Remove(filepath.Join(root, "src/main.go"))
+
+	after, err := MeasureUsage(root)
+	if err != nil {
+		t.Fatalf("MeasureUsage() error = %v", err)
+	}
+	got := before.Growth(after, 2)
+	want := Growth{
+		Bytes: 3750,
+		Files: 2,
+		Largest: []PathGrowth{
+			{Path: "node_modules", Dir: true, Bytes: 3000, Files: 2},
+			{Path: "app.log", Bytes: 500},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Growth() = %+v, want %+v", got, want)
+	}
+}
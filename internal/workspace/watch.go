@@ -0,0 +1,207 @@
+package workspace
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// ChangeKind describes what happened to a file
+type ChangeKind string
+
+const (
+	Created  ChangeKind = "created"
+	Modified ChangeKind = "modified"
+	Removed  ChangeKind = "removed"
+)
+
+// Change is a file created, modified, or removed in a watched workspace
+type Change struct {
+	Path string // Relative to the workspace root
+	Kind ChangeKind
+}
+
+// watchDebounce coalesces the burst of events a single save produces
+const watchDebounce = 250 * time.Millisecond
+
+// Watcher reports file changes under a workspace as they happen. Like
+// TakeSnapshot, it skips .git and paths excluded by the ignore files.
+type Watcher struct {
+	root    string
+	matcher *Matcher
+	fsw     *fsnotify.Watcher
+	pending map[string]ChangeKind
+}
+
+// NewWatcher starts watching every directory under root
+func NewWatcher(root string) (*Watcher, error) {
+	matcher, err := LoadMatcher(root)
+	if err != nil {
+		return nil, err
+	}
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	w := &Watcher{root: root, matcher: matcher, fsw: fsw, pending: map[string]ChangeKind{}}
+	if err := w.addTree(root, false); err != nil {
+		fsw.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// Run delivers changes to fn, in path order per batch, until ctx is
+// cancelled. The watcher is closed when Run returns.
+func (w *Watcher) Run(ctx context.Context, fn func(Change)) error {
+	defer w.fsw.Close()
+
+	timer := time.NewTimer(watchDebounce)
+	timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			w.flush(fn)
+			return nil
+		case err, ok := <-w.fsw.Errors:
+			if !ok {
+				return nil
+			}
+			return err
+		case event, ok := <-w.fsw.Events:
+			if !ok {
+				return nil
+			}
+			if w.handle(event) {
+				timer.Reset(watchDebounce)
+			}
+		case <-timer.C:
+			w.flush(fn)
+		}
+	}
+}
+
+// handle records an fsnotify event as a pending change, reporting whether
+// it was relevant
+func (w *Watcher) handle(event fsnotify.Event) bool {
+	relPath, err := filepath.Rel(w.root, event.Name)
+	if err != nil || relPath == "." {
+		return false
+	}
+
+	switch {
+	case event.Has(fsnotify.Create):
+		info, err := os.Lstat(event.Name)
+		if err != nil {
+			return false
+		}
+		if info.IsDir() {
+			if w.ignored(relPath, true) {
+				return false
+			}
+			// Files created before the watch was added are reported here
+			w.addTree(event.Name, true)
+			return true
+		}
+		if w.ignored(relPath, false) {
+			return false
+		}
+		w.record(relPath, Created)
+	case event.Has(fsnotify.Write), event.Has(fsnotify.Chmod):
+		if w.ignored(relPath, false) {
+			return false
+		}
+		if info, err := os.Lstat(event.Name); err != nil || info.IsDir() {
+			return false
+		}
+		w.record(relPath, Modified)
+	case event.Has(fsnotify.Remove), event.Has(fsnotify.Rename):
+		// A rename is reported as a removal of the old name and a creation
+		// of the new one
+		if w.ignored(relPath, false) {
+			return false
+		}
+		w.record(relPath, Removed)
+	default:
+		return false
+	}
+	return true
+}
+
+// record merges a change into the pending batch
+func (w *Watcher) record(relPath string, kind ChangeKind) {
+	w.pending[relPath] = mergeChange(w.pending[relPath], kind)
+	if w.pending[relPath] == "" {
+		delete(w.pending, relPath)
+	}
+}
+
+// mergeChange combines a pending change with a newer one for the same path.
+// A file created and removed within one batch is not reported at all.
+func mergeChange(prev, next ChangeKind) ChangeKind {
+	switch {
+	case prev == Created && next == Removed:
+		return ""
+	case prev == Created:
+		return Created
+	case prev == Removed && next == Created:
+		return Modified
+	default:
+		return next
+	}
+}
+
+// flush delivers the pending batch
+func (w *Watcher) flush(fn func(Change)) {
+	paths := make([]string, 0, len(w.pending))
+	for path := range w.pending {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		fn(Change{Path: path, Kind: w.pending[path]})
+	}
+	w.pending = map[string]ChangeKind{}
+}
+
+// ignored reports whether relPath is excluded from watching
+func (w *Watcher) ignored(relPath string, isDir bool) bool {
+	for _, part := range strings.Split(filepath.ToSlash(relPath), "/") {
+		if part == ".git" {
+			return true
+		}
+	}
+	return w.matcher.Match(relPath, isDir)
+}
+
+// addTree watches dir and every directory below it that is not ignored.
+// With reportFiles set, files already present are recorded as created.
+func (w *Watcher) addTree(dir string, reportFiles bool) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		relPath, err := filepath.Rel(w.root, path)
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			if reportFiles && !w.ignored(relPath, false) {
+				w.record(relPath, Created)
+			}
+			return nil
+		}
+		if relPath != "." && w.ignored(relPath, true) {
+			return filepath.SkipDir
+		}
+		return w.fsw.Add(path)
+	})
+}
@@ -0,0 +1,85 @@
+package workspace
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMergeChange(t *testing.T) {
+	tests := []struct {
+		prev, next, want ChangeKind
+	}{
+		{"", Created, Created},
+		{"", Modified, Modified},
+		{Created, Modified, Created},
+		{Created, Removed, ""},
+		{Modified, Removed, Removed},
+		{Removed, Created, Modified},
+	}
+	for _, tt := range tests {
+		if got := mergeChange(tt.prev, tt.next); got != tt.want {
+			t.Errorf("mergeChange(%q, %q) = %q, want %q", tt.prev, tt.next, got, tt.want)
+		}
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	root := t.TempDir()
+	os.WriteFile(filepath.Join(root, ".gitignore"), []byte("build/\n"), 0644)
+	os.WriteFile(filepath.Join(root, "existing.go"), []byte("package a"), 0644)
+	os.WriteFile(filepath.Join(root, "doomed.go"), []byte("package a"), 0644)
+	os.MkdirAll(filepath.Join(root, ".git"), 0755)
+
+	w, err := NewWatcher(root)
+	if err != nil {
+		t.Fatalf("NewWatcher() error = %v", err)
+	}
+
+	var mu sync.Mutex
+	got := map[string]ChangeKind{}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		w.Run(ctx, func(c Change) {
+			mu.Lock()
+			got[c.Path] = c.Kind
+			mu.Unlock()
+		})
+	}()
+
+	os.WriteFile(filepath.Join(root, "existing.go"), []byte("package b"), 0644)
+	os.Remove(filepath.Join(root, "doomed.go"))
+	os.MkdirAll(filepath.Join(root, "pkg"), 0755)
+	os.WriteFile(filepath.Join(root, "pkg", "new.go"), []byte("package pkg"), 0644)
+	os.MkdirAll(filepath.Join(root, "build"), 0755)
+	os.WriteFile(filepath.Join(root, "build", "out.bin"), []byte("ignored"), 0644)
+	os.WriteFile(filepath.Join(root, ".git", "index"), []byte("ignored"), 0644)
+
+	want := map[string]ChangeKind{
+		"existing.go":                  Modified,
+		"doomed.go":                    Removed,
+		filepath.Join("pkg", "new.go"): Created,
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		matched := reflect.DeepEqual(got, want)
+		mu.Unlock()
+		if matched || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("changes = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,64 @@
+package wsl
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+)
+
+// AgentPipe is the named pipe the Windows OpenSSH agent listens on
+const AgentPipe = "//./pipe/openssh-ssh-agent"
+
+// relayStartTimeout bounds how long to wait for socat to create the socket
+const relayStartTimeout = 2 * time.Second
+
+// StartAgentRelay bridges the Windows OpenSSH agent into the distribution as
+// a Unix socket, using socat on the Linux side and npiperelay.exe to reach
+// the named pipe. It returns the socket path and a function that stops the
+// relay and removes the socket.
+func StartAgentRelay() (string, func(), error) {
+	socat, err := exec.LookPath("socat")
+	if err != nil {
+		return "", nil, fmt.Errorf("socat not found; install it in the WSL distribution")
+	}
+	relay, err := exec.LookPath("npiperelay.exe")
+	if err != nil {
+		return "", nil, fmt.Errorf("npiperelay.exe not found on PATH; install it on Windows and add it to PATH")
+	}
+
+	dir, err := os.MkdirTemp("", "enclaude-ssh-agent-")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create agent relay directory: %w", err)
+	}
+	sock := filepath.Join(dir, "agent.sock")
+
+	// socat splits the EXEC command on whitespace, so npiperelay.exe must be
+	// on a path without spaces
+	cmd := exec.Command(socat,
+		"UNIX-LISTEN:"+sock+",fork,mode=600",
+		fmt.Sprintf("EXEC:%s -ei -s %s,nofork", relay, AgentPipe),
+	)
+	if err := cmd.Start(); err != nil {
+		os.RemoveAll(dir)
+		return "", nil, fmt.Errorf("failed to start agent relay: %w", err)
+	}
+	stop := func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+		os.RemoveAll(dir)
+	}
+
+	deadline := time.Now().Add(relayStartTimeout)
+	for {
+		if _, err := os.Stat(sock); err == nil {
+			return sock, stop, nil
+		}
+		if time.Now().After(deadline) {
+			stop()
+			return "", nil, fmt.Errorf("agent relay did not create %s", sock)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
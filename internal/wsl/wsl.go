@@ -0,0 +1,122 @@
+// Package wsl detects Windows Subsystem for Linux and maps paths between the
+// Linux distribution and the Windows host
+package wsl
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"strings"
+)
+
+// DefaultMountRoot is where WSL mounts Windows drives unless /etc/wsl.conf
+// sets [automount] root
+const DefaultMountRoot = "/mnt/"
+
+// Info describes the WSL environment enclaude is running in
+type Info struct {
+	// Version is 1 or 2 under WSL, and 0 otherwise
+	Version int
+	// Distro is the distribution name from WSL_DISTRO_NAME
+	Distro string
+	// MountRoot is the directory Windows drives are mounted under
+	MountRoot string
+}
+
+// Detect inspects the running kernel and environment for WSL
+func Detect() Info {
+	release, err := os.ReadFile("/proc/sys/kernel/osrelease")
+	if err != nil {
+		return Info{}
+	}
+	info := detect(string(release), os.Getenv("WSL_DISTRO_NAME"))
+	if info.Version == 0 {
+		return info
+	}
+	info.MountRoot = DefaultMountRoot
+	if f, err := os.Open("/etc/wsl.conf"); err == nil {
+		defer f.Close()
+		info.MountRoot = parseMountRoot(bufio.NewScanner(f))
+	}
+	return info
+}
+
+// detect classifies a kernel release string. WSL 2 kernels are built as
+// "microsoft-standard-WSL2"; WSL 1 reports a "Microsoft" suffix.
+func detect(release, distro string) Info {
+	release = strings.ToLower(release)
+	switch {
+	case strings.Contains(release, "wsl2"), strings.Contains(release, "microsoft-standard"):
+		return Info{Version: 2, Distro: distro}
+	case strings.Contains(release, "microsoft"):
+		return Info{Version: 1, Distro: distro}
+	}
+	return Info{}
+}
+
+// parseMountRoot reads the [automount] root setting from a wsl.conf file
+func parseMountRoot(s *bufio.Scanner) string {
+	section := ""
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.ToLower(strings.TrimSpace(line[1 : len(line)-1]))
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || section != "automount" || strings.TrimSpace(strings.ToLower(key)) != "root" {
+			continue
+		}
+		value = strings.Trim(strings.TrimSpace(value), `"`)
+		if value == "" {
+			break
+		}
+		return strings.TrimRight(value, "/") + "/"
+	}
+	return DefaultMountRoot
+}
+
+// Enabled reports whether enclaude is running under WSL
+func (i Info) Enabled() bool {
+	return i.Version > 0
+}
+
+// Drive returns the upper-case Windows drive letter when path is on a mounted
+// Windows drive such as /mnt/c
+func (i Info) Drive(p string) (string, bool) {
+	if !i.Enabled() {
+		return "", false
+	}
+	rest, ok := strings.CutPrefix(path.Clean(p)+"/", i.mountRoot())
+	if !ok || len(rest) < 2 || rest[1] != '/' {
+		return "", false
+	}
+	letter := rest[0]
+	if !('a' <= letter && letter <= 'z' || 'A' <= letter && letter <= 'Z') {
+		return "", false
+	}
+	return strings.ToUpper(string(letter)), true
+}
+
+// WindowsPath returns the path Windows programs use to reach p: a drive path
+// like C:\Users\me for mounted drives, and a \\wsl$ UNC path for files in the
+// distribution's own filesystem
+func (i Info) WindowsPath(p string) string {
+	p = path.Clean(p)
+	if drive, ok := i.Drive(p); ok {
+		rest := strings.TrimPrefix(p, i.mountRoot())[1:]
+		return drive + `:\` + strings.ReplaceAll(strings.TrimPrefix(rest, "/"), "/", `\`)
+	}
+	return `\\wsl$\` + i.Distro + strings.ReplaceAll(p, "/", `\`)
+}
+
+// mountRoot returns the drive mount root, falling back to the WSL default
+func (i Info) mountRoot() string {
+	if i.MountRoot == "" {
+		return DefaultMountRoot
+	}
+	return i.MountRoot
+}
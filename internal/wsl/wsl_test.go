@@ -0,0 +1,79 @@
+package wsl
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		release string
+		version int
+	}{
+		{"5.15.153.1-microsoft-standard-WSL2", 2},
+		{"6.6.36.3-microsoft-standard-WSL2+", 2},
+		{"4.4.0-19041-Microsoft", 1},
+		{"6.8.0-45-generic", 0},
+		{"", 0},
+	}
+	for _, tt := range tests {
+		got := detect(tt.release, "Ubuntu")
+		if got.Version != tt.version {
+			t.Errorf("detect(%q).Version = %d, want %d", tt.release, got.Version, tt.version)
+		}
+	}
+}
+
+func TestParseMountRoot(t *testing.T) {
+	tests := []struct {
+		name string
+		conf string
+		want string
+	}{
+		{"empty", "", "/mnt/"},
+		{"custom", "[automount]\nroot = /win\n", "/win/"},
+		{"quoted", "[automount]\nenabled = true\nroot = \"/\"\n", "/"},
+		{"other section", "[network]\nroot = /win\n", "/mnt/"},
+		{"commented", "[automount]\n# root = /win\n", "/mnt/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseMountRoot(bufio.NewScanner(strings.NewReader(tt.conf)))
+			if got != tt.want {
+				t.Errorf("parseMountRoot() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWindowsPath(t *testing.T) {
+	info := Info{Version: 2, Distro: "Ubuntu", MountRoot: "/mnt/"}
+	tests := []struct {
+		path    string
+		drive   string
+		windows string
+	}{
+		{"/mnt/c/Users/me/project", "C", `C:\Users\me\project`},
+		{"/mnt/d", "D", `D:\`},
+		{"/mnt/wsl/docker", "", `\\wsl$\Ubuntu\mnt\wsl\docker`},
+		{"/home/me/project/", "", `\\wsl$\Ubuntu\home\me\project`},
+	}
+	for _, tt := range tests {
+		drive, ok := info.Drive(tt.path)
+		if drive != tt.drive || ok != (tt.drive != "") {
+			t.Errorf("Drive(%q) = %q, %v, want %q", tt.path, drive, ok, tt.drive)
+		}
+		if got := info.WindowsPath(tt.path); got != tt.windows {
+			t.Errorf("WindowsPath(%q) = %q, want %q", tt.path, got, tt.windows)
+		}
+	}
+
+	if _, ok := (Info{}).Drive("/mnt/c/Users"); ok {
+		t.Error("Drive() outside WSL should not match")
+	}
+	root := Info{Version: 2, MountRoot: "/"}
+	if drive, ok := root.Drive("/c/src"); !ok || drive != "C" {
+		t.Errorf("Drive(/c/src) with root / = %q, %v", drive, ok)
+	}
+}